@@ -1,13 +1,27 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2debug"
 	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+	"github.com/arturoeanton/go-r2lang/pkg/r2lsp"
+	"github.com/arturoeanton/go-r2lang/pkg/r2mod"
 )
 
 const version = "0.1.1"
@@ -33,22 +47,65 @@ func main() {
 		}
 	}()
 
+	// "r2 lsp" runs the language server on stdin/stdout for editors.
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		if err := r2lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "r2 typecheck" checks the type annotations of files without running them.
+	if len(os.Args) > 1 && os.Args[1] == "typecheck" {
+		if !typeCheck(os.Args[2:]) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "r2 debug" runs a program under the debugger, from the terminal or, with
+	// -dap, for an editor speaking the Debug Adapter Protocol on stdin/stdout.
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		if err := debugCode(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "r2 get" fetches the packages of the project in the current directory.
+	if len(os.Args) > 1 && os.Args[1] == "get" {
+		if err := getPackages(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
 		helpFlag    = flag.Bool("help", false, "Show help information")
 		versionFlag = flag.Bool("version", false, "Show version information")
 		verbose     = flag.Bool("verbose", false, "Enable verbose output")
 		debug       = flag.Bool("debug", false, "Enable debug mode")
 		optimize    = flag.Bool("optimize", false, "Enable code optimization")
-		profile     = flag.String("profile", "", "Enable profiling (cpu, memory, trace)")
-		output      = flag.String("output", "", "Output file for compilation")
+		profile     = flag.String("profile", "", "Enable profiling (cpu, memory, trace, r2)")
+		output      = flag.String("output", "", "Output file for compilation or profiling")
 		workDir     = flag.String("workdir", "", "Working directory for execution")
 		args        = flag.String("args", "", "Arguments to pass to R2Lang program")
 		env         = flag.String("env", "", "Environment variables (key=value,key2=value2)")
 		timeout     = flag.String("timeout", "", "Execution timeout (e.g., 30s, 5m)")
 		maxMemory   = flag.String("max-memory", "", "Maximum memory usage (e.g., 100MB, 1GB)")
+		maxDepth    = flag.Int("max-depth", 0, "Maximum depth of nested function calls (default 1000)")
+		sandbox     = flag.Bool("sandbox", false, "Run untrusted code: pure libraries only, io confined to -sandbox-root")
+		strict      = flag.Bool("strict", false, "Check the type annotations of every call and declaration at run time")
+		sandboxRoot = flag.String("sandbox-root", "", "Root directory of the io module with -sandbox (default: current directory)")
+		watch       = flag.Bool("watch", false, "Re-run the script when it or one of its imports changes")
 		interactive = flag.Bool("interactive", false, "Enable interactive mode")
 		check       = flag.Bool("check", false, "Check syntax only, don't execute")
 		format      = flag.Bool("format", false, "Format R2Lang code")
+		write       = flag.Bool("w", false, "With -format, write the result back to the source files")
+		showDiff    = flag.Bool("d", false, "With -format, print a diff instead of the formatted code")
 		compile     = flag.Bool("compile", false, "Compile to bytecode")
 		bytecode    = flag.Bool("bytecode", false, "Execute bytecode file")
 	)
@@ -85,6 +142,17 @@ func main() {
 		filename = "main.r2"
 	}
 
+	// -format accepts several files and directories, so it runs before the
+	// single-file checks below.
+	if *format {
+		paths := argsv
+		if len(paths) == 0 {
+			paths = []string{filename}
+		}
+		formatCode(paths, *write, *showDiff, *verbose)
+		return
+	}
+
 	// Validate file exists
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		fmt.Printf("Error: File '%s' does not exist.\n", filename)
@@ -92,7 +160,12 @@ func main() {
 	}
 
 	// Validate file extension
-	if !strings.HasSuffix(filename, ".r2") {
+	if *bytecode {
+		if !strings.HasSuffix(filename, ".r2c") {
+			fmt.Printf("Error: File '%s' is not a .r2c file.\n", filename)
+			os.Exit(1)
+		}
+	} else if !strings.HasSuffix(filename, ".r2") {
 		fmt.Printf("Error: File '%s' is not a .r2 file.\n", filename)
 		os.Exit(1)
 	}
@@ -110,31 +183,57 @@ func main() {
 		if *verbose {
 			fmt.Printf("Checking syntax of '%s'...\n", filename)
 		}
-		checkSyntax(filename)
+		checkSyntax(filename, *verbose)
 		return
 	}
 
-	if *format {
+	if *compile {
 		if *verbose {
-			fmt.Printf("Formatting '%s'...\n", filename)
+			fmt.Printf("Compiling '%s'...\n", filename)
 		}
-		formatCode(filename)
+		compileCode(filename, *output, *verbose)
 		return
 	}
 
-	if *compile {
-		if *verbose {
-			fmt.Printf("Compiling '%s'...\n", filename)
+	limits, err := parseLimits(*timeout, *maxMemory, *maxDepth)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Script arguments: the words of -args followed by everything after the
+	// file name ("r2 script.r2 a b" or "r2 script.r2 -- -x").
+	scriptArgs, err := splitArgs(*args)
+	if err != nil {
+		fmt.Printf("Error: invalid -args: %v\n", err)
+		os.Exit(1)
+	}
+	if len(argsv) > 1 {
+		rest := argsv[1:]
+		if rest[0] == "--" {
+			rest = rest[1:]
 		}
-		compileCode(filename, *output)
-		return
+		scriptArgs = append(scriptArgs, rest...)
+	}
+	opts := r2lang.Options{Args: scriptArgs, Limits: limits, Sandbox: *sandbox, Root: *sandboxRoot, Strict: *strict}
+
+	if *watch && (*bytecode || *profile != "") {
+		fmt.Println("Error: -watch cannot be combined with -bytecode or -profile.")
+		os.Exit(1)
 	}
 
+	stopProfile, err := startProfile(*profile, *output, &opts, *verbose)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer stopProfile()
+
 	if *bytecode {
 		if *verbose {
 			fmt.Printf("Executing bytecode '%s'...\n", filename)
 		}
-		executeBytecode(filename)
+		executeBytecode(filename, opts)
 		return
 	}
 
@@ -168,6 +267,12 @@ func main() {
 		if *workDir != "" {
 			fmt.Printf("  Working Dir: %s\n", *workDir)
 		}
+		if *watch {
+			fmt.Printf("  Watch: %t\n", *watch)
+		}
+		if *sandbox {
+			fmt.Printf("  Sandbox Root: %s\n", filepath.Clean(*sandboxRoot))
+		}
 		if *args != "" {
 			fmt.Printf("  Arguments: %s\n", *args)
 		}
@@ -179,42 +284,383 @@ func main() {
 		fmt.Printf("Executing '%s'...\n", filename)
 	}
 
-	r2lang.RunCode(filename)
+	if *watch {
+		watchCode(filename, opts)
+		return
+	}
+
+	r2lang.RunCodeWithOptions(filename, opts)
 }
 
-func checkSyntax(filename string) {
-	// This would integrate with the parser to check syntax
-	fmt.Printf("Syntax check for '%s' - Feature not yet implemented\n", filename)
-	fmt.Println("This feature will be available in a future version.")
+// getPackages adds the packages in specs to the project of the current
+// directory, creating its r2.mod if there is none, and fetches every package
+// it needs.
+func getPackages(specs []string) error {
+	project, err := r2mod.FindProject(".")
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		if project, err = r2mod.NewProject("."); err != nil {
+			return err
+		}
+	}
+	return project.Get(specs, os.Stdout)
 }
 
-func formatCode(filename string) {
-	// This would integrate with a code formatter
-	fmt.Printf("Code formatting for '%s' - Feature not yet implemented\n", filename)
-	fmt.Println("This feature will be available in a future version.")
+// debugCode runs "r2 debug [-dap] [-strict] [FILE] [SCRIPT ARGS...]": the
+// debugger console on FILE (main.r2 by default), or the DAP server, which
+// gets the program from the launch request.
+func debugCode(args []string) error {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	dap := flags.Bool("dap", false, "Serve the Debug Adapter Protocol on stdin/stdout")
+	strict := flags.Bool("strict", false, "Check the type annotations of every call and declaration at run time")
+	flags.Parse(args)
+	opts := r2lang.Options{Strict: *strict}
+	if *dap {
+		return r2debug.NewAdapter(os.Stdin, os.Stdout).Serve(opts)
+	}
+	filename := "main.r2"
+	if flags.NArg() > 0 {
+		filename = flags.Arg(0)
+		opts.Args = flags.Args()[1:]
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return err
+	}
+	return r2debug.NewConsole(os.Stdin, os.Stderr).Run(filename, opts)
+}
+
+// watchCode runs filename with r2lang.Watch until Ctrl+C.
+func watchCode(filename string, opts r2lang.Options) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "[watch] running %s, restarting on changes (Ctrl+C to stop)\n", filename)
+	r2lang.Watch(ctx, filename, opts, r2lang.WatchOptions{})
+}
+
+func checkSyntax(filename string, verbose bool) {
+	result, err := r2lang.CheckFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading the file %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	for _, diag := range result.Diagnostics {
+		fmt.Fprintln(os.Stderr, diag.Error())
+	}
+	if len(result.Diagnostics) > 0 {
+		os.Exit(1)
+	}
+
+	if verbose {
+		fmt.Printf("OK: %d file(s) checked, no errors found\n", len(result.Files))
+	}
 }
 
-func compileCode(filename, output string) {
-	// This would compile R2Lang to bytecode
+// typeCheck runs r2lang.TypeCheckFile on each file, main.r2 by default, and
+// prints the problems found. It reports whether there were none.
+func typeCheck(files []string) bool {
+	if len(files) == 0 {
+		files = []string{"main.r2"}
+	}
+	ok := true
+	for _, file := range files {
+		result, err := r2lang.TypeCheckFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the file %s: %v\n", file, err)
+			ok = false
+			continue
+		}
+		for _, diag := range result.Diagnostics {
+			fmt.Fprintln(os.Stderr, diag.Error())
+		}
+		if len(result.Diagnostics) > 0 {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// formatCode formats every .r2 file in paths, descending into directories.
+// By default the result is printed to stdout; -w rewrites the files that
+// changed and -d prints a unified diff. Errors are reported per file and make
+// the command exit with status 1 once all files have been processed.
+func formatCode(paths []string, write, showDiff, verbose bool) {
+	failed := false
+	for _, path := range paths {
+		files, err := r2Files(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		for _, file := range files {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Formatting '%s'...\n", file)
+			}
+			if err := formatFile(file, write, showDiff); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func formatFile(filename string, write, showDiff bool) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	src := string(data)
+	formatted, err := r2core.Format(src, filename)
+	if err != nil {
+		return err
+	}
+
+	if showDiff {
+		fmt.Print(r2lang.UnifiedDiff(filename+".orig", filename, src, formatted))
+	}
+	if write {
+		if formatted == src {
+			return nil
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filename, []byte(formatted), info.Mode().Perm())
+	}
+	if !showDiff {
+		fmt.Print(formatted)
+	}
+	return nil
+}
+
+// r2Files expands path into the .r2 files it names: the file itself, or every
+// .r2 file below it when it is a directory (hidden directories are skipped).
+func r2Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s' does not exist", path)
+	}
+	if !info.IsDir() {
+		if !strings.HasSuffix(path, ".r2") {
+			return nil, fmt.Errorf("file '%s' is not a .r2 file", path)
+		}
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, ".r2") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func compileCode(filename, output string, verbose bool) {
 	outputFile := output
 	if outputFile == "" {
 		outputFile = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".r2c"
 	}
 
-	fmt.Printf("Compiling '%s' to '%s' - Feature not yet implemented\n", filename, outputFile)
-	fmt.Println("This feature will be available in a future version.")
+	if err := r2lang.CompileFile(filename, outputFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if verbose {
+		fmt.Printf("Compiled '%s' to '%s'\n", filename, outputFile)
+	}
+}
+
+func executeBytecode(filename string, opts r2lang.Options) {
+	r2lang.RunBytecode(filename, opts)
 }
 
-func executeBytecode(filename string) {
-	// This would execute compiled bytecode
-	fmt.Printf("Executing bytecode '%s' - Feature not yet implemented\n", filename)
-	fmt.Println("This feature will be available in a future version.")
+// splitArgs splits the -args string into words. Words are separated by
+// spaces and may be quoted with ' or " to include spaces.
+func splitArgs(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// profileFiles are the default output files of each -profile mode.
+var profileFiles = map[string]string{
+	"cpu":    "cpu.pprof",
+	"memory": "mem.pprof",
+	"trace":  "trace.out",
+	"r2":     "r2.pprof",
+}
+
+// startProfile starts the profile selected with -profile and returns the
+// function that stops it and writes the file (-output, or the mode's default
+// name). cpu, memory and trace profile the interpreter with runtime/pprof and
+// runtime/trace; r2 is collected by the interpreter itself through
+// opts.Profile and attributes time to R2 functions.
+func startProfile(mode, output string, opts *r2lang.Options, verbose bool) (stop func(), err error) {
+	if mode == "" {
+		return func() {}, nil
+	}
+	name, ok := profileFiles[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown -profile %q (expected cpu, memory, trace or r2)", mode)
+	}
+	if output != "" {
+		name = output
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var finish func() error
+	switch mode {
+	case "cpu":
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		finish = func() error {
+			pprof.StopCPUProfile()
+			return nil
+		}
+	case "memory":
+		finish = func() error {
+			runtime.GC() // Estadísticas del heap al día
+			return pprof.WriteHeapProfile(f)
+		}
+	case "trace":
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		finish = func() error {
+			trace.Stop()
+			return nil
+		}
+	case "r2":
+		opts.Profile = f
+		finish = func() error { return nil }
+	}
+
+	return func() {
+		err := finish()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the %s profile: %v\n", mode, err)
+		} else if verbose {
+			fmt.Printf("Profile written to '%s'\n", name)
+		}
+	}, nil
+}
+
+// parseLimits converts the -timeout, -max-memory and -max-depth flags into
+// run limits.
+func parseLimits(timeout, maxMemory string, maxDepth int) (r2lang.Limits, error) {
+	var limits r2lang.Limits
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return limits, fmt.Errorf("invalid -timeout %q (expected a duration such as 30s or 5m)", timeout)
+		}
+		limits.Timeout = d
+	}
+	if maxMemory != "" {
+		n, err := parseSize(maxMemory)
+		if err != nil {
+			return limits, fmt.Errorf("invalid -max-memory %q (expected a size such as 100MB or 1GB)", maxMemory)
+		}
+		limits.MaxMemory = n
+	}
+	if maxDepth < 0 {
+		return limits, fmt.Errorf("invalid -max-depth %d (expected a positive number of calls)", maxDepth)
+	}
+	limits.MaxDepth = maxDepth
+	return limits, nil
+}
+
+// parseSize parses a byte count with an optional B, KB, MB or GB suffix
+// (powers of 1024, case-insensitive).
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * multiplier, nil
 }
 
 func showHelp() {
 	fmt.Printf("R2Lang v%s - Dynamic Programming Language\n\n", version)
 	fmt.Println("USAGE:")
-	fmt.Println("  r2 [OPTIONS] [FILE]")
+	fmt.Println("  r2 [OPTIONS] [FILE] [--] [SCRIPT ARGS...]")
+	fmt.Println("  r2 lsp                  Run the language server on stdin/stdout")
+	fmt.Println("  r2 typecheck [FILE...]  Check the type annotations of FILE and its imports")
+	fmt.Println("  r2 debug [FILE] [--] [SCRIPT ARGS...]")
+	fmt.Println("                          Debug FILE step by step from the terminal")
+	fmt.Println("  r2 debug -dap           Run the debug adapter (DAP) on stdin/stdout")
+	fmt.Println("  r2 get [PACKAGE@VERSION...]")
+	fmt.Println("                          Add packages (git URL, directory or registry name)")
+	fmt.Println("                          to r2.mod and fetch them into r2_modules")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  R2Lang is a dynamic programming language with JavaScript-like syntax.")
@@ -222,6 +668,7 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("ARGUMENTS:")
 	fmt.Println("  FILE                    R2Lang file to execute (default: main.r2)")
+	fmt.Println("  SCRIPT ARGS             Arguments for the script, available as argv")
 	fmt.Println()
 	fmt.Println("OPTIONS:")
 	fmt.Println()
@@ -234,21 +681,30 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("Execution Options:")
 	fmt.Println("  -workdir DIR            Set working directory")
-	fmt.Println("  -args STRING            Arguments to pass to R2Lang program")
+	fmt.Println("  -args STRING            Arguments to pass to R2Lang program (argv)")
 	fmt.Println("  -env KEY=VALUE,...      Environment variables")
 	fmt.Println("  -timeout DURATION       Execution timeout (e.g., 30s, 5m)")
 	fmt.Println("  -max-memory SIZE        Maximum memory usage (e.g., 100MB, 1GB)")
+	fmt.Println("  -max-depth N            Maximum depth of nested calls (default 1000;")
+	fmt.Println("                          tail calls don't count)")
+	fmt.Println("  -sandbox                Run untrusted code: no os, network or db libraries,")
+	fmt.Println("                          io confined to -sandbox-root")
+	fmt.Println("  -sandbox-root DIR       Directory seen as / by io with -sandbox (default: .)")
+	fmt.Println("  -watch                  Re-run the script when it or its imports change")
+	fmt.Println("  -strict                 Check annotated arguments, results and declarations at run time")
 	fmt.Println()
 	fmt.Println("Code Processing:")
 	fmt.Println("  -check                  Check syntax only, don't execute")
-	fmt.Println("  -format                 Format R2Lang code")
+	fmt.Println("  -format                 Format R2Lang code (files or directories)")
+	fmt.Println("  -w                      With -format, write the result back to the files")
+	fmt.Println("  -d                      With -format, print a diff instead of the code")
 	fmt.Println("  -optimize               Enable code optimization")
 	fmt.Println("  -compile                Compile to bytecode")
-	fmt.Println("  -output FILE            Output file for compilation")
+	fmt.Println("  -output FILE            Output file for compilation or profiling")
 	fmt.Println("  -bytecode               Execute bytecode file")
 	fmt.Println()
 	fmt.Println("Performance:")
-	fmt.Println("  -profile TYPE           Enable profiling (cpu, memory, trace)")
+	fmt.Println("  -profile TYPE           Enable profiling (cpu, memory, trace, r2)")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
 	fmt.Println("  r2                              # Execute main.r2")
@@ -256,13 +712,25 @@ func showHelp() {
 	fmt.Println("  r2 -verbose script.r2           # Execute with verbose output")
 	fmt.Println("  r2 -debug script.r2             # Execute with debug information")
 	fmt.Println("  r2 -check script.r2             # Check syntax only")
-	fmt.Println("  r2 -format script.r2            # Format code")
+	fmt.Println("  r2 typecheck script.r2          # Check type annotations")
+	fmt.Println("  r2 debug script.r2              # Debug with breakpoints and steps")
+	fmt.Println("  r2 -strict script.r2            # Fail on a call with arguments of the wrong type")
+	fmt.Println("  r2 -format script.r2            # Print formatted code")
+	fmt.Println("  r2 -format -w ./src             # Format every .r2 file under ./src in place")
+	fmt.Println("  r2 -format -d ./src             # Show what -w would change")
 	fmt.Println("  r2 -compile script.r2           # Compile to bytecode")
 	fmt.Println("  r2 -compile -output app.r2c script.r2  # Compile with custom output")
 	fmt.Println("  r2 -bytecode app.r2c            # Execute bytecode")
+	fmt.Println("  r2 script.r2 -n 3 input.txt     # Pass arguments to the script (argv)")
+	fmt.Println("  r2 -args \"-n 3\" script.r2      # Same, through -args")
+	fmt.Println("  r2 -watch server.r2             # Restart server.r2 whenever a source file changes")
 	fmt.Println("  r2 -timeout 30s script.r2       # Execute with timeout")
+	fmt.Println("  r2 -max-memory 256MB script.r2  # Abort if the heap grows past 256MB")
+	fmt.Println("  r2 -max-depth 100000 script.r2  # Allow deeper (non-tail) recursion")
+	fmt.Println("  r2 -sandbox -sandbox-root data script.r2  # Untrusted script, files under data/")
 	fmt.Println("  r2 -optimize script.r2          # Execute with optimizations")
-	fmt.Println("  r2 -profile cpu script.r2       # Execute with CPU profiling")
+	fmt.Println("  r2 -profile cpu script.r2       # Execute with CPU profiling (cpu.pprof)")
+	fmt.Println("  r2 -profile r2 script.r2        # Time per R2 function (go tool pprof r2.pprof)")
 	fmt.Println()
 	fmt.Println("LANGUAGE FEATURES:")
 	fmt.Println("  Variables:              let x = 10;")
@@ -290,7 +758,7 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("FILE EXTENSIONS:")
 	fmt.Println("  .r2                    R2Lang source files")
-	fmt.Println("  .r2c                   R2Lang compiled bytecode (future)")
+	fmt.Println("  .r2c                   R2Lang compiled bytecode")
 	fmt.Println("  *_test.r2              R2Lang test files")
 	fmt.Println()
 	fmt.Println("RELATED COMMANDS:")
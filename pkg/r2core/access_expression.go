@@ -14,7 +14,13 @@ type AccessExpression struct {
 }
 
 func (ae *AccessExpression) Eval(env *Environment) interface{} {
-	objVal := ae.Object.Eval(env)
+	return ae.access(env, ae.Object.Eval(env))
+}
+
+// access resuelve ae.Member sobre un objeto ya evaluado (compartido con la VM).
+func (ae *AccessExpression) access(env *Environment, objVal interface{}) interface{} {
+	// Los helpers de cada tipo no conocen la posición: el error es de este acceso
+	defer locateError(env, ae.Position)
 
 	// Unwrap ReturnValue if necessary (recursively)
 	for {
@@ -32,8 +38,12 @@ func (ae *AccessExpression) Eval(env *Environment) interface{} {
 
 	switch obj := objVal.(type) {
 	case *ObjectInstance:
+		checkPrivateAccess(env, obj.Class, ae.Member)
 		return evalMemberAccess(obj, ae.Member)
 	case map[string]interface{}:
+		if _, isClass := obj["ClassName"]; isClass {
+			checkPrivateAccess(env, obj, ae.Member)
+		}
 		return evalMapAccess(obj, ae.Member)
 	case map[string]*Variable:
 		return evalVariableMapAccess(obj, ae.Member)
@@ -45,6 +55,24 @@ func (ae *AccessExpression) Eval(env *Environment) interface{} {
 		return evalDSLAccess(obj, ae.Member, env)
 	case *DSLResult:
 		return evalDSLResultAccess(obj, ae.Member, env)
+	case *Promise:
+		return evalPromiseAccess(obj, ae.Member, env)
+	case *GeneratorObject:
+		return evalGeneratorAccess(obj, ae.Member)
+	case *Set:
+		return evalSetAccess(obj, ae.Member)
+	case *OrderedMap:
+		return evalOrderedMapAccess(obj, ae.Member)
+	case *ImmutableList:
+		return evalImmutableListAccess(obj, ae.Member)
+	case *ImmutableMap:
+		return evalImmutableMapAccess(obj, ae.Member)
+	case *DecimalValue:
+		return evalDecimalAccess(obj, ae.Member)
+	case *EnumType:
+		return evalEnumAccess(obj, ae.Member)
+	case *EnumValue:
+		return evalEnumValueAccess(obj, ae.Member)
 	case string:
 		return evalStringAccess(obj, ae.Member)
 	case attrGetter:
@@ -53,14 +81,16 @@ func (ae *AccessExpression) Eval(env *Environment) interface{} {
 		// of being unpacked into a map or struct field here.
 		attr, found := obj.Getattr(ae.Member)
 		if !found {
-			panic("The object does not have the property: " + ae.Member)
+			panic(newRuntimeError("ReferenceError", "The object does not have the property: "+ae.Member))
 		}
 		return attr.Eval(env)
+	case Iterator:
+		return evalIteratorAccess(obj, ae.Member)
 	default:
 		if ae.Position != nil && env.CurrentFile != "" {
 			ae.Position.Filename = env.CurrentFile
 		}
-		PanicWithStack(ae.Position, fmt.Sprintf("access to property in unsupported type: %T", objVal), env.callStack)
+		PanicWithClass("TypeError", ae.Position, fmt.Sprintf("access to property in unsupported type: %T", objVal), env.callStack)
 		return nil
 	}
 }
@@ -75,7 +105,10 @@ type attrGetter interface {
 func evalMemberAccess(instance *ObjectInstance, member string) interface{} {
 	val, exists := instance.Env.Get(member)
 	if !exists {
-		panic("The object does not have the property: " + member)
+		panic(newRuntimeError("ReferenceError", "The object does not have the property: "+member))
+	}
+	if accessor, ok := val.(*Accessor); ok {
+		return accessor.get(member)
 	}
 	return val
 }
@@ -83,7 +116,7 @@ func evalMemberAccess(instance *ObjectInstance, member string) interface{} {
 func evalMapAccess(m map[string]interface{}, member string) interface{} {
 	val, exists := m[member]
 	if !exists {
-		panic("The map does not have the key:" + member)
+		panic(newRuntimeError("ReferenceError", "The map does not have the key:"+member))
 	}
 	return val
 }
@@ -91,7 +124,7 @@ func evalMapAccess(m map[string]interface{}, member string) interface{} {
 func evalVariableMapAccess(m map[string]*Variable, member string) interface{} {
 	variable, exists := m[member]
 	if !exists {
-		panic("The module does not have the member: " + member)
+		panic(newRuntimeError("ReferenceError", "The module does not have the member: "+member))
 	}
 	return variable.Value
 }
@@ -121,7 +154,7 @@ func evalArrayAccess(arr InterfaceSlice, member string, env *Environment) interf
 	case "join":
 		return evalArrayJoin(arr)
 	default:
-		panic("Array does not have property: " + member)
+		panic(newRuntimeError("ReferenceError", "Array does not have property: "+member))
 	}
 }
 
@@ -476,7 +509,7 @@ func evalDSLAccess(dsl *DSLDefinition, member string, env *Environment) interfac
 	case "warnings":
 		return toInterfaceSlice(dsl.Warnings)
 	default:
-		panic("DSL does not have property: " + member)
+		panic(newRuntimeError("ReferenceError", "DSL does not have property: "+member))
 	}
 }
 
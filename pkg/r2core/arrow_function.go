@@ -5,6 +5,8 @@ type ArrowFunction struct {
 	Params       []Parameter // Function parameters
 	Body         Node        // Either an expression or a block statement
 	IsExpression bool        // true if body is expression, false if block
+	Async        bool        // async (a, b) => ...
+	scope        *Scope      // Layout de la llamada (ver Resolve)
 }
 
 func (af *ArrowFunction) Eval(env *Environment) interface{} {
@@ -21,6 +23,7 @@ func (af *ArrowFunction) Eval(env *Environment) interface{} {
 		Body:     af.createBody(),
 		Env:      env, // Lexical scoping - capture current environment
 		IsMethod: false,
+		IsAsync:  af.Async,
 		code:     "arrow_function",
 	}
 	return fn
@@ -30,14 +33,14 @@ func (af *ArrowFunction) Eval(env *Environment) interface{} {
 func (af *ArrowFunction) createBody() *BlockStatement {
 	if af.IsExpression {
 		// For expression bodies, wrap in a return statement
-		returnStmt := &ReturnStatement{Value: af.Body}
-		return &BlockStatement{Statements: []Node{returnStmt}}
+		returnStmt := &ReturnStatement{Value: af.Body, tail: inTailPosition(af.Body)}
+		return &BlockStatement{Statements: []Node{returnStmt}, scope: af.scope}
 	} else {
 		// For block bodies, assume it's already a BlockStatement
 		if blockStmt, ok := af.Body.(*BlockStatement); ok {
 			return blockStmt
 		}
 		// Fallback: wrap in block
-		return &BlockStatement{Statements: []Node{af.Body}}
+		return &BlockStatement{Statements: []Node{af.Body}, scope: af.scope}
 	}
 }
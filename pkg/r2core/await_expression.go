@@ -0,0 +1,16 @@
+package r2core
+
+// AwaitExpression espera a una promesa: await expr
+// Si el valor no es una promesa se devuelve tal cual; si la promesa se
+// rechaza, el motivo se lanza como una excepción.
+type AwaitExpression struct {
+	Value Node
+}
+
+func (ae *AwaitExpression) Eval(env *Environment) interface{} {
+	val := ae.Value.Eval(env)
+	if p, ok := val.(*Promise); ok {
+		return p.Await(env)
+	}
+	return val
+}
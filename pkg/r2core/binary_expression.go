@@ -48,11 +48,38 @@ func (be *BinaryExpression) Eval(env *Environment) interface{} {
 }
 
 func (be *BinaryExpression) evaluateArithmeticOp(lv, rv interface{}, env *Environment) interface{} {
+	if _, ok := lv.(float64); ok {
+		if _, ok := rv.(float64); ok {
+			// Con dos float64 sólo falla la división por cero, que ya lleva
+			// la posición
+			return be.applyOp(lv, rv, env)
+		}
+	}
+	// Los helpers numéricos no conocen la posición: el error es de esta operación
+	defer locateError(env, be.GetPosition())
+	return be.applyOp(lv, rv, env)
+}
+
+// applyOp es evaluateArithmeticOp sin ubicar los errores.
+func (be *BinaryExpression) applyOp(lv, rv interface{}, env *Environment) interface{} {
 	// Manejar operaciones con fechas primero
 	if dateResult := be.evalDateOperations(lv, rv); dateResult != nil {
 		return dateResult
 	}
 
+	// Los números exactos (int64, BigInt, Decimal) se comparan y operan bit
+	// a bit sin pasar por float64
+	switch be.Op {
+	case "<", ">", "<=", ">=":
+		if r, ok := exactComparison(be.Op, lv, rv); ok {
+			return r
+		}
+	case "&", "|", "^", "<<", ">>":
+		if r, ok := exactBitwise(be.Op, lv, rv); ok {
+			return r
+		}
+	}
+
 	switch be.Op {
 	case "+":
 		return addValues(lv, rv)
@@ -76,6 +103,8 @@ func (be *BinaryExpression) evaluateArithmeticOp(lv, rv interface{}, env *Enviro
 		return equals(lv, rv)
 	case "!=":
 		return !equals(lv, rv)
+	case INSTANCEOF:
+		return instanceOf(lv, rv)
 	case "&":
 		// Bitwise AND
 		return float64(int64(toFloat(lv)) & int64(toFloat(rv)))
@@ -92,7 +121,8 @@ func (be *BinaryExpression) evaluateArithmeticOp(lv, rv interface{}, env *Enviro
 		// Right shift
 		return float64(int64(toFloat(lv)) >> uint(int64(toFloat(rv))))
 	default:
-		panic("Unsupported binary operator: " + be.Op)
+		PanicWithStack(be.GetPosition(), "Unsupported binary operator: "+be.Op, env.callStack)
+		return nil
 	}
 }
 
@@ -116,12 +146,12 @@ func (be *BinaryExpression) tryFastArithmetic(env *Environment) interface{} {
 		return leftNum.Value * rightNum.Value
 	case "/":
 		if rightNum.Value == 0 {
-			PanicWithStack(be.GetPosition(), "Division by zero", env.callStack)
+			PanicWithClass("ArithmeticError", be.GetPosition(), "Division by zero", env.callStack)
 		}
 		return leftNum.Value / rightNum.Value
 	case "%":
 		if rightNum.Value == 0 {
-			PanicWithStack(be.GetPosition(), "Modulo by zero", env.callStack)
+			PanicWithClass("ArithmeticError", be.GetPosition(), "Modulo by zero", env.callStack)
 		}
 		return float64(int(leftNum.Value) % int(rightNum.Value))
 	case "<":
@@ -249,9 +279,12 @@ func (be *BinaryExpression) evaluatePipeline(leftValue, rightFunction interface{
 	case *FunctionLiteral:
 		// Anonymous function - create user function and call
 		userFunc := &UserFunction{
-			Params: rightFunc.Params,
-			Body:   rightFunc.Body,
-			Env:    env,
+			Params:      rightFunc.Params,
+			Body:        rightFunc.Body,
+			Env:         env,
+			IsAsync:     rightFunc.Async,
+			IsGenerator: rightFunc.Generator,
+			ReturnType:  rightFunc.Return,
 		}
 		return userFunc.Call(leftValue)
 	default:
@@ -280,15 +313,23 @@ func (be *BinaryExpression) divValuesWithPosition(a, b interface{}, env *Environ
 	if af, ok := a.(float64); ok {
 		if bf, ok := b.(float64); ok {
 			if bf == 0 {
-				PanicWithStack(be.GetPosition(), "Division by zero", env.callStack)
+				PanicWithClass("ArithmeticError", be.GetPosition(), "Division by zero", env.callStack)
 			}
 			return af / bf
 		}
 	}
+	if isExactNumber(a) || isExactNumber(b) {
+		if exactZero(b) || b == 0.0 {
+			PanicWithClass("ArithmeticError", be.GetPosition(), "Division by zero", env.callStack)
+		}
+		if r, ok := exactArith("/", a, b); ok {
+			return r
+		}
+	}
 
 	den := toFloat(b)
 	if den == 0 {
-		PanicWithStack(be.GetPosition(), "Division by zero", env.callStack)
+		PanicWithClass("ArithmeticError", be.GetPosition(), "Division by zero", env.callStack)
 	}
 	return toFloat(a) / den
 }
@@ -299,15 +340,23 @@ func (be *BinaryExpression) modValuesWithPosition(a, b interface{}, env *Environ
 	if af, ok := a.(float64); ok {
 		if bf, ok := b.(float64); ok {
 			if bf == 0 {
-				PanicWithStack(be.GetPosition(), "Modulo by zero", env.callStack)
+				PanicWithClass("ArithmeticError", be.GetPosition(), "Modulo by zero", env.callStack)
 			}
 			return float64(int64(af) % int64(bf))
 		}
 	}
+	if isExactNumber(a) || isExactNumber(b) {
+		if exactZero(b) || b == 0.0 {
+			PanicWithClass("ArithmeticError", be.GetPosition(), "Modulo by zero", env.callStack)
+		}
+		if r, ok := exactArith("%", a, b); ok {
+			return r
+		}
+	}
 
 	den := toFloat(b)
 	if den == 0 {
-		PanicWithStack(be.GetPosition(), "Modulo by zero", env.callStack)
+		PanicWithClass("ArithmeticError", be.GetPosition(), "Modulo by zero", env.callStack)
 	}
 	return float64(int(toFloat(a)) % int(den))
 }
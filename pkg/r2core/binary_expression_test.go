@@ -440,7 +440,7 @@ func TestBinaryExpression_DivisionByZero(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
 			t.Error("Expected panic for division by zero")
-		} else if r != "Division by zero" {
+		} else if err, ok := r.(*RuntimeError); !ok || err.Class != "ArithmeticError" || err.Message != "Division by zero" {
 			t.Errorf("Expected 'Division by zero' panic, got %v", r)
 		}
 	}()
@@ -460,7 +460,7 @@ func TestBinaryExpression_ModuloByZero(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
 			t.Error("Expected panic for modulo by zero")
-		} else if r != "Modulo by zero" {
+		} else if err, ok := r.(*RuntimeError); !ok || err.Class != "ArithmeticError" || err.Message != "Modulo by zero" {
 			t.Errorf("Expected 'Modulo by zero' panic, got %v", r)
 		}
 	}()
@@ -482,7 +482,7 @@ func TestBinaryExpression_UnsupportedOperator(t *testing.T) {
 			t.Error("Expected panic for unsupported operator")
 		} else {
 			expectedMsg := "Unsupported binary operator: **"
-			if r != expectedMsg {
+			if err, ok := r.(*RuntimeError); !ok || err.Message != expectedMsg {
 				t.Errorf("Expected %q panic, got %v", expectedMsg, r)
 			}
 		}
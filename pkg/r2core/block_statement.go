@@ -2,12 +2,19 @@ package r2core
 
 type BlockStatement struct {
 	Statements []Node
+	Positions  []*PositionInfo // Dónde empieza cada sentencia, para el depurador; puede faltar
+	scope      *Scope          // Layout de la llamada si es el cuerpo de una función (ver Resolve)
 }
 
 func (bs *BlockStatement) Eval(env *Environment) interface{} {
 	var result interface{}
-	for _, stmt := range bs.Statements {
-		val := stmt.Eval(env)
+	for i, stmt := range bs.Statements {
+		var val interface{}
+		if env.debugger != nil && i < len(bs.Positions) {
+			val = env.debugger.run(env, stmt, bs.Positions[i])
+		} else {
+			val = stmt.Eval(env)
+		}
 		if rv, ok := val.(ReturnValue); ok {
 			return rv
 		}
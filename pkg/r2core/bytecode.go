@@ -0,0 +1,617 @@
+package r2core
+
+import (
+	"reflect"
+	"time"
+)
+
+// ============================================================
+// BYTECODE VM
+// ============================================================
+//
+// La VM ejecuta el código que produce Compile sobre el mismo Environment y los
+// mismos valores que el intérprete de árbol: las funciones siguen siendo
+// *UserFunction (su Body es un bloque que contiene el CompiledCode), así que
+// las librerías nativas, los métodos y las clausuras funcionan igual en ambos
+// modos. Las operaciones delegan en los mismos helpers que usan los Eval
+// (evaluateArithmeticOp, access, ...), y los nodos que el compilador no
+// traduce se ejecutan con su propio Eval (OpEval).
+//
+// Las funciones cuyo cuerpo no necesita un entorno propio (ver Frame) corren
+// como frames en la pila de la VM: OpCall deja los argumentos en la pila, esos
+// valores pasan a ser los parámetros y detrás van las demás variables
+// locales, que se leen y escriben por slot (OpGetLocal, OpSetLocal, ...). Así
+// una llamada no crea un Environment ni pasa por CallExpression.call.
+
+// Opcode identifica una instrucción de la VM.
+type Opcode uint8
+
+const (
+	OpConst      Opcode = iota // push Consts[A]
+	OpPop                      // descarta el tope
+	OpDup                      // duplica el tope
+	OpGet                      // push del valor de Nodes[A].(*Identifier)
+	OpLet                      // let Names[A] = pop
+	OpConstDecl                // const Names[A] = pop
+	OpUpdate                   // Nodes[A].(*Identifier) = tope (el valor queda en la pila)
+	OpSetMember                // obj, val := pop, pop; push assignMember(Nodes[A], obj, val)
+	OpSetIndex                 // push assignIndexExpression(Nodes[A], pop, env)
+	OpBinary                   // r, l := pop, pop; push l Nodes[A].Op r; B = floatOps de Op (0 si no tiene)
+	OpPipe                     // r, l := pop, pop; push l |> r
+	OpToBool                   // push toBool(pop)
+	OpUnary                    // push Nodes[A].Operator pop
+	OpAccess                   // push pop.Nodes[A].Member
+	OpIndex                    // i, c := pop, pop; push c[i]
+	OpCall                     // llama a la función bajo los B argumentos; A = *CallExpression, C = 1 si puede ser directa
+	OpArray                    // arma un array con los B valores del tope
+	OpMap                      // arma Nodes[A].(*MapLiteral) con los B valores del tope
+	OpEval                     // push Nodes[A].Eval(env)
+	OpJump                     // pc = A
+	OpJumpFalse                // if !toBool(pop) { pc = A }
+	OpJumpTrue                 // if toBool(pop) { pc = A }
+	OpJumpNotNil               // if tope != nil { pc = A } else { pop }
+	OpResult                   // valor de la sentencia = pop; B/C = destino de break/continue (-1 fuera de un bucle), A = fin de la sentencia de nivel superior
+	OpReturn                   // return pop
+	OpBreak                    // break fuera de un bucle compilado; A = fin de la sentencia de nivel superior
+	OpContinue                 // continue fuera de un bucle compilado; A = fin de la sentencia de nivel superior
+	OpEnterScope               // env = newScopedEnv(env, Scopes[A]); en un frame, vacía sus slots desde B
+	OpExitScope                // restaura el env anterior
+	OpLoop                     // abre un bucle del tipo loopKinds[A]
+	OpLoopCheck                // verifica los límites del ExecutionLimiter
+	OpLoopCount                // cuenta una iteración
+	OpLoopSave                 // guarda el valor de la iteración completa
+	OpLoopEnd                  // cierra el bucle; su valor pasa a ser el de la sentencia
+	OpIterInit                 // prepara el for-in de Nodes[A].(*ForStatement)
+	OpIterNext                 // siguiente elemento del for-in (con OpLoopCheck incluido) o pc = B si terminó
+	OpTailCall                 // OpCall en posición de cola: push el *tailCall (ver CallExpression.tailCall)
+	OpGetLocal                 // push de la variable local del slot B (Nodes[A].(*Identifier) sin frame)
+	OpSetLocal                 // variable local del slot B = tope, como OpUpdate
+	OpLetLocal                 // let Names[A] = pop en el slot B
+	OpConstLocal               // const Names[A] = pop en el slot B
+	OpCheckType                // en modo estricto, el tope debe cumplir el tipo de Nodes[A].(*LetStatement)
+)
+
+// Instr es una instrucción de la VM; el significado de A, B y C depende de Op.
+type Instr struct {
+	Op      Opcode
+	A, B, C int32
+}
+
+// CompiledCode es un bloque de sentencias compilado a bytecode. Implementa
+// Node: su Eval ejecuta la VM y devuelve lo mismo que BlockStatement.Eval (o
+// que Program.Eval si Program es true) sobre las sentencias originales.
+type CompiledCode struct {
+	Code    []Instr
+	Consts  []interface{} // nil, bool, float64 o string
+	Names   []string
+	Nodes   []Node
+	Scopes  []*Scope // Layout del entorno de cada for clásico (nil si no se resolvió)
+	Program bool     // Nivel superior: return desempaqueta el valor y break/continue no cortan
+
+	// Layout de las variables del cuerpo de una función que puede correr como
+	// frame en la pila de la VM, sin entorno propio: no declara nada fuera de
+	// su layout ni del de sus for clásicos, no crea clausuras ni entornos (nada
+	// de OpEval ni for-in) y ningún helper que recibe el entorno evalúa nodos
+	// en él. nil si
+	// el cuerpo necesita su entorno. Locals es la cantidad de slots del frame:
+	// los de Frame y detrás los de los for clásicos del cuerpo.
+	Frame  *Scope
+	Locals int
+
+	// Archivo fuente del programa, el que se reporta en los errores al
+	// ejecutarlo; sólo en el CompiledCode de nivel superior
+	Source string
+}
+
+// Operadores que OpBinary resuelve directamente cuando los dos operandos son
+// float64, con el mismo resultado que evaluateArithmeticOp. La división y el
+// módulo no están: fallan con la posición del nodo si el divisor es cero.
+const (
+	floatNone int32 = iota
+	floatAdd
+	floatSub
+	floatMul
+	floatLess
+	floatGreater
+	floatLessEq
+	floatGreaterEq
+	floatEq
+	floatNotEq
+	floatOpCount
+)
+
+var floatOps = map[string]int32{
+	"+": floatAdd, "-": floatSub, "*": floatMul,
+	"<": floatLess, ">": floatGreater, "<=": floatLessEq, ">=": floatGreaterEq,
+	"==": floatEq, "!=": floatNotEq,
+}
+
+// floatOp aplica el operador op de floatOps a l y r.
+func floatOp(op int32, l, r float64) interface{} {
+	switch op {
+	case floatAdd:
+		return l + r
+	case floatSub:
+		return l - r
+	case floatMul:
+		return l * r
+	case floatLess:
+		return l < r
+	case floatGreater:
+		return l > r
+	case floatLessEq:
+		return l <= r
+	case floatGreaterEq:
+		return l >= r
+	case floatEq:
+		return l == r
+	default:
+		return l != r
+	}
+}
+
+// loopKind describe cada tipo de bucle tal como lo reporta el ExecutionLimiter.
+type loopKind struct {
+	name, location, timeout, canceled string
+}
+
+var loopKinds = []loopKind{
+	{"while", "while statement", "while_timeout", "while_context_canceled"},
+	{"for", "for statement", "for_timeout", "for_context_canceled"},
+	{"for-in", "for-in statement", "for_in_timeout", "for_in_context_canceled"},
+}
+
+const (
+	loopWhile = iota
+	loopFor
+	loopForIn
+)
+
+type vmLoop struct {
+	kind    *loopKind
+	ctx     *LoopContext
+	limiter *ExecutionLimiter
+	result  interface{}
+
+	// Estado del for-in
+	arr     []interface{}
+	keys    *reflect.MapIter
+	entries EntryIterator
+	iter    Iterator
+	i       int
+}
+
+// check aplica los límites del ExecutionLimiter antes de cada iteración.
+func (lp *vmLoop) check(env *Environment) {
+	if !lp.limiter.Enabled {
+		return
+	}
+	if lp.limiter.CheckTimeLimit() {
+		panic(NewTimeoutError(lp.kind.timeout, env.GetContext()))
+	}
+	if lp.limiter.CheckContext() {
+		panic(NewTimeoutError(lp.kind.canceled, env.GetContext()))
+	}
+	if lp.limiter.CheckMemoryLimit() {
+		panic(lp.limiter.memoryLimitError())
+	}
+	if lp.ctx.Iterations >= lp.ctx.MaxIterations {
+		panic(NewInfiniteLoopError(lp.kind.name, lp.ctx))
+	}
+}
+
+// vmStack es la pila de valores de la VM. Las funciones que se llaman como
+// frames (ver Frame) tienen sus variables locales en ella, a partir de su base.
+type vmStack struct {
+	values []interface{}
+}
+
+// unsetLocal es el valor del slot de una variable local de un frame que
+// todavía no se declaró: igual que con un slot vacío de un entorno, la
+// variable se busca por nombre.
+type unsetLocal struct{}
+
+func (cc *CompiledCode) Eval(env *Environment) interface{} {
+	return cc.run(&vmStack{values: make([]interface{}, 0, 16)}, env, -1)
+}
+
+// run ejecuta el código con la pila vs. Corre como frame si base no es -1:
+// sus variables locales están en vs desde base y env es el entorno en el que
+// se definió la función.
+func (cc *CompiledCode) run(vs *vmStack, env *Environment, base int) interface{} {
+	var (
+		stack  = vs.values
+		result interface{}
+		scopes []*Environment
+		loops  []*vmLoop
+	)
+	// Un return o una excepción dentro de un for-in sobre un iterador salen
+	// sin pasar por OpLoopEnd: el iterador se cierra aquí. La pila puede
+	// haber crecido: frameCall la limpia desde la base del frame.
+	defer func() {
+		vs.values = stack
+		for _, lp := range loops {
+			if lp.iter != nil {
+				CloseIterator(lp.iter)
+			}
+		}
+	}()
+	pop := func() interface{} {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+
+	code := cc.Code
+	for pc := 0; pc < len(code); pc++ {
+		in := &code[pc]
+		switch in.Op {
+		case OpConst:
+			stack = append(stack, cc.Consts[in.A])
+		case OpPop:
+			stack = stack[:len(stack)-1]
+		case OpDup:
+			stack = append(stack, stack[len(stack)-1])
+		case OpGet:
+			stack = append(stack, getVariable(env, cc.Nodes[in.A].(*Identifier), base >= 0))
+		case OpGetLocal:
+			if base >= 0 {
+				if v := stack[base+int(in.B)]; v != (unsetLocal{}) {
+					stack = append(stack, v)
+					continue
+				}
+			}
+			stack = append(stack, getVariable(env, cc.Nodes[in.A].(*Identifier), base >= 0))
+		case OpLet:
+			env.Set(cc.Names[in.A], pop())
+		case OpLetLocal:
+			if base >= 0 {
+				stack[base+int(in.B)] = pop()
+				continue
+			}
+			env.Set(cc.Names[in.A], pop())
+		case OpConstDecl:
+			env.SetConst(cc.Names[in.A], pop())
+		case OpConstLocal:
+			if base >= 0 {
+				// Como SetConst sobre un slot: el compilador asegura que nada
+				// más escribe la variable
+				if stack[base+int(in.B)] != (unsetLocal{}) {
+					panic("variable '" + cc.Names[in.A] + "' already declared")
+				}
+				stack[base+int(in.B)] = pop()
+				continue
+			}
+			env.SetConst(cc.Names[in.A], pop())
+		case OpCheckType:
+			ls := cc.Nodes[in.A].(*LetStatement)
+			checkDeclared(env, ls.Name, ls.Type, stack[len(stack)-1])
+		case OpUpdate:
+			updateVariable(env, cc.Nodes[in.A].(*Identifier), stack[len(stack)-1], base >= 0)
+		case OpSetLocal:
+			if base >= 0 {
+				if slot := &stack[base+int(in.B)]; *slot != (unsetLocal{}) {
+					*slot = stack[len(stack)-1]
+					continue
+				}
+			}
+			updateVariable(env, cc.Nodes[in.A].(*Identifier), stack[len(stack)-1], base >= 0)
+		case OpSetMember:
+			obj := pop()
+			val := pop()
+			stack = append(stack, assignMember(cc.Nodes[in.A].(*AccessExpression), obj, val, env))
+		case OpSetIndex:
+			stack = append(stack, assignIndexExpression(cc.Nodes[in.A].(*IndexExpression), pop(), env))
+		case OpBinary:
+			r := pop()
+			l := pop()
+			if in.B != floatNone {
+				if lf, ok := l.(float64); ok {
+					if rf, ok := r.(float64); ok {
+						stack = append(stack, floatOp(in.B, lf, rf))
+						continue
+					}
+				}
+			}
+			stack = append(stack, cc.Nodes[in.A].(*BinaryExpression).evaluateArithmeticOp(l, r, env))
+		case OpPipe:
+			r := pop()
+			l := pop()
+			stack = append(stack, cc.Nodes[in.A].(*BinaryExpression).evaluatePipeline(l, r, env))
+		case OpToBool:
+			stack[len(stack)-1] = toBool(stack[len(stack)-1])
+		case OpUnary:
+			stack[len(stack)-1] = cc.Nodes[in.A].(*UnaryExpression).apply(stack[len(stack)-1])
+		case OpAccess:
+			obj := stack[len(stack)-1]
+			stack[len(stack)-1] = cc.Nodes[in.A].(*AccessExpression).access(cc.callerEnv(env, stack, base, obj), obj)
+		case OpIndex:
+			idx := pop()
+			stack[len(stack)-1] = cc.Nodes[in.A].(*IndexExpression).index(stack[len(stack)-1], idx)
+		case OpCall, OpTailCall:
+			if in.C == 1 {
+				// Llamada directa a una función que corre como frame: los
+				// argumentos ya están en la pila
+				fnAt := len(stack) - int(in.B) - 1
+				if uf, ok := stack[fnAt].(*UserFunction); ok && !uf.IsAsync && !uf.IsGenerator && !hasPlaceholders(stack[fnAt+1:]) {
+					if code := uf.frameCode(); code != nil {
+						vs.values = stack
+						val := uf.frameCall(vs, code, fnAt+1, int(in.B))
+						stack = append(vs.values[:fnAt], val)
+						continue
+					}
+				}
+			}
+			var args []interface{}
+			if in.B > 0 {
+				args = make([]interface{}, in.B)
+				copy(args, stack[len(stack)-int(in.B):])
+				stack = stack[:len(stack)-int(in.B)]
+			}
+			ce := cc.Nodes[in.A].(*CallExpression)
+			callEnv := cc.callerEnv(env, stack, base, stack[len(stack)-1])
+			if in.Op == OpTailCall {
+				stack[len(stack)-1] = ce.tailCall(callEnv, stack[len(stack)-1], args)
+			} else {
+				stack[len(stack)-1] = ce.call(callEnv, stack[len(stack)-1], args)
+			}
+		case OpArray:
+			var elements []interface{}
+			if in.B > 0 {
+				elements = make([]interface{}, in.B)
+				copy(elements, stack[len(stack)-int(in.B):])
+				stack = stack[:len(stack)-int(in.B)]
+			}
+			stack = append(stack, ExpandSpreadInArray(elements))
+		case OpMap:
+			ml := cc.Nodes[in.A].(*MapLiteral)
+			vals := stack[len(stack)-int(in.B):]
+			m := make(map[string]interface{})
+			for i, pair := range ml.Pairs {
+				setMapPair(env, m, pair, vals[i])
+			}
+			stack = append(stack[:len(stack)-int(in.B)], m)
+		case OpEval:
+			stack = append(stack, cc.Nodes[in.A].Eval(env))
+		case OpJump:
+			pc = int(in.A) - 1
+		case OpJumpFalse:
+			if !toBool(pop()) {
+				pc = int(in.A) - 1
+			}
+		case OpJumpTrue:
+			if toBool(pop()) {
+				pc = int(in.A) - 1
+			}
+		case OpJumpNotNil:
+			if stack[len(stack)-1] != nil {
+				pc = int(in.A) - 1
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case OpResult:
+			// Igual que BlockStatement.Eval: una sentencia cuyo valor es de
+			// control (return/break/continue) corta el bloque.
+			val := pop()
+			switch rv := val.(type) {
+			case ReturnValue:
+				if cc.Program {
+					return rv.Value
+				}
+				return rv
+			case BreakValue:
+				if in.B >= 0 {
+					pc = int(in.B) - 1
+					continue
+				}
+				if !cc.Program {
+					return val
+				}
+				result = val
+				pc = int(in.A) - 1
+				continue
+			case ContinueValue:
+				if in.C >= 0 {
+					pc = int(in.C) - 1
+					continue
+				}
+				if !cc.Program {
+					return val
+				}
+				result = val
+				pc = int(in.A) - 1
+				continue
+			}
+			result = val
+		case OpReturn:
+			if cc.Program {
+				return pop()
+			}
+			return ReturnValue{Value: pop()}
+		case OpBreak:
+			if !cc.Program {
+				return BreakValue{}
+			}
+			result = BreakValue{}
+			pc = int(in.A) - 1
+		case OpContinue:
+			if !cc.Program {
+				return ContinueValue{}
+			}
+			result = ContinueValue{}
+			pc = int(in.A) - 1
+		case OpEnterScope:
+			if base >= 0 {
+				locals := stack[base+int(in.B):][:len(cc.Scopes[in.A].names)]
+				for i := range locals {
+					locals[i] = unsetLocal{}
+				}
+				continue
+			}
+			scopes = append(scopes, env)
+			env = newScopedEnv(env, cc.Scopes[in.A])
+		case OpExitScope:
+			if base >= 0 {
+				continue
+			}
+			env = scopes[len(scopes)-1]
+			scopes = scopes[:len(scopes)-1]
+		case OpLoop:
+			limiter := env.GetLimiter()
+			kind := &loopKinds[in.A]
+			loops = append(loops, &vmLoop{
+				kind:    kind,
+				limiter: limiter,
+				ctx: &LoopContext{
+					Type:          kind.name,
+					MaxIterations: limiter.MaxIterations,
+					StartTime:     time.Now(),
+					Location:      kind.location,
+				},
+			})
+		case OpLoopCheck:
+			loops[len(loops)-1].check(env)
+		case OpLoopCount:
+			loops[len(loops)-1].ctx.Iterations++
+		case OpLoopSave:
+			loops[len(loops)-1].result = result
+		case OpLoopEnd:
+			lp := loops[len(loops)-1]
+			if lp.iter != nil {
+				CloseIterator(lp.iter)
+			}
+			result = lp.result
+			loops = loops[:len(loops)-1]
+		case OpIterInit:
+			fs := cc.Nodes[in.A].(*ForStatement)
+			lp := loops[len(loops)-1]
+			raw := fs.collection(env)
+			env.Set("$c", raw)
+			switch coll := raw.(type) {
+			case InterfaceSlice:
+				lp.arr = coll
+			case []interface{}:
+				lp.arr = coll
+			case map[string]interface{}:
+				// MapRange recorre el mapa con la misma semántica que range
+				lp.keys = reflect.ValueOf(coll).MapRange()
+			case *ImmutableList:
+				lp.arr = coll.Values()
+			case EntryIterable:
+				lp.entries = coll.Entries()
+			default:
+				it, ok := GetIterator(raw)
+				if !ok {
+					panic("Not an array or map for 'for'")
+				}
+				lp.iter = it
+			}
+		case OpIterNext:
+			fs := cc.Nodes[in.A].(*ForStatement)
+			lp := loops[len(loops)-1]
+			var k, v, bound interface{}
+			switch {
+			case lp.iter != nil:
+				var done bool
+				if v, done = lp.iter.Next(); done {
+					pc = int(in.B) - 1
+					continue
+				}
+				// Como en evalForInIterator, la variable toma el valor
+				k, bound = float64(lp.i), v
+				lp.i++
+			case lp.entries != nil:
+				var done bool
+				if k, v, done = lp.entries.NextEntry(); done {
+					pc = int(in.B) - 1
+					continue
+				}
+				bound = k
+			case lp.keys != nil:
+				if !lp.keys.Next() {
+					pc = int(in.B) - 1
+					continue
+				}
+				k, v = lp.keys.Key().String(), lp.keys.Value().Interface()
+				bound = k
+			default:
+				if lp.i >= len(lp.arr) {
+					pc = int(in.B) - 1
+					continue
+				}
+				k, v = float64(lp.i), lp.arr[lp.i]
+				bound = k
+				lp.i++
+			}
+			// Como en evalForIn, los límites se verifican sólo si hay otro elemento
+			lp.check(env)
+			env.Set(fs.inIndexName, bound)
+			env.Set("$k", k)
+			env.Set("$v", v)
+		default:
+			panic("bytecode: unknown opcode")
+		}
+	}
+	return result
+}
+
+// getVariable es OpGet: el valor de la variable id, por su slot si el
+// resolver la ligó o si no por nombre. inFrame indica que env es el entorno
+// de afuera de la función que corre como frame, no el de la llamada.
+func getVariable(env *Environment, id *Identifier, inFrame bool) interface{} {
+	if target := refTarget(env, id.ref, inFrame); target != nil {
+		if variable := target.slots[id.ref.slot].Load(); variable != nil {
+			return variable.Value
+		}
+	}
+	if v, ok := env.Get(id.Name); ok && id.Name != "_" {
+		return v
+	}
+	// Placeholder o variable no declarada: lo resuelve Identifier.Eval
+	return id.Eval(env)
+}
+
+// updateVariable es OpUpdate: asigna val a la variable id.
+func updateVariable(env *Environment, id *Identifier, val interface{}, inFrame bool) {
+	if target := refTarget(env, id.ref, inFrame); target != nil && target.updateSlot(id.ref.slot, id.Name, val) {
+		return
+	}
+	env.Update(id.Name, val)
+}
+
+// refTarget es resolveRef desde env, que en un frame es el entorno de afuera
+// de la función.
+func refTarget(env *Environment, ref *varRef, inFrame bool) *Environment {
+	switch {
+	case ref == nil:
+		return nil
+	case !inFrame:
+		return env.resolveRef(ref)
+	case ref.depth > 0:
+		return env.resolveRefAt(ref.scope.parent, ref.depth-1)
+	}
+	return nil
+}
+
+// callerEnv devuelve el entorno con el que se llama a v o se accede a sus
+// miembros. Instanciar un blueprint y el use de un DSL crean un entorno
+// interno del entorno del llamador (ver instantiateObject y evalDSLAccess);
+// en un frame, ése es un entorno de la función con las variables locales que
+// ya tienen valor (las de la función, no las de sus for).
+func (cc *CompiledCode) callerEnv(env *Environment, stack []interface{}, base int, v interface{}) *Environment {
+	if base < 0 {
+		return env
+	}
+	switch v.(type) {
+	case map[string]interface{}, *DSLDefinition:
+	default:
+		return env
+	}
+	frameEnv := newScopedEnv(env, cc.Frame)
+	for i, name := range cc.Frame.names {
+		if local := stack[base+i]; local != (unsetLocal{}) {
+			frameEnv.setSlot(i, name, local)
+		}
+	}
+	return frameEnv
+}
@@ -0,0 +1,1362 @@
+package r2core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ============================================================
+// FORMATO .r2c
+// ============================================================
+//
+// Un archivo .r2c es la cabecera "R2C" seguida de la versión del formato, del
+// archivo fuente (CompiledCode.Source) y del CompiledCode de nivel superior. Los nodos que el bytecode referencia (los que
+// se ejecutan con OpEval, o que aportan posición y operador a una instrucción)
+// se serializan completos, así que cargar un .r2c no vuelve a parsear nada.
+// Los enteros van como varint, los float64 por sus bits y los strings con su
+// longitud delante. Los layouts de slots que dejó Resolve (ver Scope) van con
+// los nodos que los usan: la primera vez completos y después por número, así
+// que los bloques y las variables que comparten un layout lo siguen
+// compartiendo al cargar.
+
+const bytecodeMagic = "R2C"
+
+// BytecodeVersion es la versión del formato .r2c; DecodeBytecode rechaza
+// archivos de otra versión.
+const BytecodeVersion = 16
+
+// Etiquetas de los nodos serializados.
+const (
+	tagNil byte = iota
+	tagCompiledCode
+	tagProgram
+	tagBlock
+	tagExprStatement
+	tagLet
+	tagMultipleLet
+	tagConst
+	tagMultipleConst
+	tagAssign
+	tagIf
+	tagWhile
+	tagFor
+	tagReturn
+	tagBreak
+	tagContinue
+	tagFunctionDeclaration
+	tagFunctionLiteral
+	tagArrowFunction
+	tagTry
+	tagThrow
+	tagObjectDeclaration
+	tagImport
+	tagDSL
+	tagArrayDestructuring
+	tagObjectDestructuring
+	tagIdentifier
+	tagNumber
+	tagString
+	tagBoolean
+	tagNilLiteral
+	tagDate
+	tagArray
+	tagMap
+	tagBinary
+	tagUnary
+	tagCall
+	tagAccess
+	tagOptionalAccess
+	tagIndex
+	tagOptionalIndex
+	tagTernary
+	tagTemplate
+	tagSpread
+	tagMatch
+	tagArrayComprehension
+	tagObjectComprehension
+	tagAwait
+	tagYield
+	tagExactNumber
+	tagSwitch
+	tagEnum
+	tagStatic
+	tagAccessor
+	tagInterface
+	tagExport
+)
+
+// Etiquetas de los patrones de match.
+const (
+	patNil byte = iota
+	patLiteral
+	patVariable
+	patWildcard
+	patArray
+	patObject
+	patOr
+	patGuarded
+	patValue
+)
+
+// Etiquetas de las constantes del bytecode.
+const (
+	constNil byte = iota
+	constFalse
+	constTrue
+	constNumber
+	constString
+)
+
+// EncodeBytecode serializa code en el formato .r2c.
+func EncodeBytecode(code *CompiledCode) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("cannot encode bytecode: %v", r)
+		}
+	}()
+	w := &bcWriter{}
+	w.buf.WriteString(bytecodeMagic)
+	w.uint(BytecodeVersion)
+	w.str(code.Source)
+	w.node(code)
+	return w.buf.Bytes(), nil
+}
+
+// DecodeBytecode carga un CompiledCode serializado con EncodeBytecode.
+func DecodeBytecode(data []byte) (code *CompiledCode, err error) {
+	if !bytes.HasPrefix(data, []byte(bytecodeMagic)) {
+		return nil, errors.New("not an R2Lang bytecode file")
+	}
+	r := &bcReader{data: data, pos: len(bytecodeMagic)}
+	defer func() {
+		if rec := recover(); rec != nil {
+			code, err = nil, fmt.Errorf("corrupt bytecode file at offset %d: %v", r.pos, rec)
+		}
+	}()
+	if v := r.uint(); v != BytecodeVersion {
+		return nil, fmt.Errorf("unsupported bytecode version %d (expected %d); recompile the source", v, BytecodeVersion)
+	}
+	source := r.str()
+	code, ok := r.node().(*CompiledCode)
+	if !ok {
+		return nil, errors.New("bytecode file does not contain a program")
+	}
+	code.Source = source
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("corrupt bytecode file: %d trailing bytes", len(data)-r.pos)
+	}
+	return code, nil
+}
+
+// ------------------------------------------------------------
+// Escritura
+// ------------------------------------------------------------
+
+type bcWriter struct {
+	buf    bytes.Buffer
+	scopes map[*Scope]int // Número de los layouts ya escritos
+}
+
+func (w *bcWriter) uint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *bcWriter) int(v int) {
+	w.buf.Write(binary.AppendVarint(nil, int64(v)))
+}
+
+func (w *bcWriter) bool(b bool) {
+	if b {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *bcWriter) float(f float64) {
+	w.uint(math.Float64bits(f))
+}
+
+func (w *bcWriter) str(s string) {
+	w.uint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *bcWriter) strs(list []string) {
+	w.uint(uint64(len(list)))
+	for _, s := range list {
+		w.str(s)
+	}
+}
+
+func (w *bcWriter) pos(p *PositionInfo) {
+	w.bool(p != nil)
+	if p != nil {
+		w.int(p.Line)
+		w.int(p.Col)
+		w.int(p.Pos)
+		w.str(p.Filename)
+	}
+}
+
+// scope escribe un layout: 0 si es nil, su número si ya se escribió o el
+// número siguiente seguido del layout exterior y los nombres.
+func (w *bcWriter) scope(s *Scope) {
+	if s == nil {
+		w.uint(0)
+		return
+	}
+	if id, ok := w.scopes[s]; ok {
+		w.uint(uint64(id))
+		return
+	}
+	if w.scopes == nil {
+		w.scopes = map[*Scope]int{}
+	}
+	id := len(w.scopes) + 1
+	w.scopes[s] = id
+	w.uint(uint64(id))
+	w.scope(s.parent)
+	w.strs(s.names)
+}
+
+func (w *bcWriter) nodes(list []Node) {
+	w.uint(uint64(len(list)))
+	for _, n := range list {
+		w.node(n)
+	}
+}
+
+// block escribe un *BlockStatement que puede ser nil (un *BlockStatement nil
+// dentro de un Node no es un Node nil).
+func (w *bcWriter) block(b *BlockStatement) {
+	if b == nil {
+		w.buf.WriteByte(tagNil)
+		return
+	}
+	w.node(b)
+}
+
+// typ escribe una anotación de tipo que puede ser nil.
+func (w *bcWriter) typ(t *TypeAnnotation) {
+	w.bool(t != nil)
+	if t != nil {
+		w.pos(t.Position)
+		w.strs(t.Types)
+	}
+}
+
+func (w *bcWriter) params(params []Parameter) {
+	w.uint(uint64(len(params)))
+	for _, p := range params {
+		w.str(p.Name)
+		w.node(p.DefaultValue)
+		w.typ(p.Type)
+		w.bool(p.Optional)
+	}
+}
+
+func (w *bcWriter) generators(gens []Generator) {
+	w.uint(uint64(len(gens)))
+	for _, g := range gens {
+		w.str(g.Variable)
+		w.node(g.Iterator)
+	}
+}
+
+func (w *bcWriter) constant(v interface{}) {
+	switch c := v.(type) {
+	case nil:
+		w.buf.WriteByte(constNil)
+	case bool:
+		if c {
+			w.buf.WriteByte(constTrue)
+		} else {
+			w.buf.WriteByte(constFalse)
+		}
+	case float64:
+		w.buf.WriteByte(constNumber)
+		w.float(c)
+	case string:
+		w.buf.WriteByte(constString)
+		w.str(c)
+	default:
+		panic(fmt.Sprintf("unsupported constant of type %T", v))
+	}
+}
+
+func (w *bcWriter) node(n Node) {
+	switch s := n.(type) {
+	case nil:
+		w.buf.WriteByte(tagNil)
+	case *CompiledCode:
+		w.buf.WriteByte(tagCompiledCode)
+		w.bool(s.Program)
+		w.uint(uint64(len(s.Code)))
+		for _, in := range s.Code {
+			w.buf.WriteByte(byte(in.Op))
+			w.int(int(in.A))
+			w.int(int(in.B))
+			w.int(int(in.C))
+		}
+		w.uint(uint64(len(s.Consts)))
+		for _, c := range s.Consts {
+			w.constant(c)
+		}
+		w.strs(s.Names)
+		w.nodes(s.Nodes)
+		w.uint(uint64(len(s.Scopes)))
+		for _, scope := range s.Scopes {
+			w.scope(scope)
+		}
+		w.scope(s.Frame)
+		w.int(s.Locals)
+	case *Program:
+		w.buf.WriteByte(tagProgram)
+		w.nodes(s.Statements)
+	case *BlockStatement:
+		w.buf.WriteByte(tagBlock)
+		w.nodes(s.Statements)
+		w.scope(s.scope)
+	case *ExprStatement:
+		w.buf.WriteByte(tagExprStatement)
+		w.node(s.Expr)
+	case *LetStatement:
+		w.buf.WriteByte(tagLet)
+		w.str(s.Name)
+		w.typ(s.Type)
+		w.node(s.Value)
+	case *MultipleLetStatement:
+		w.buf.WriteByte(tagMultipleLet)
+		w.uint(uint64(len(s.Declarations)))
+		for _, d := range s.Declarations {
+			w.str(d.Name)
+			w.typ(d.Type)
+			w.node(d.Value)
+		}
+	case *ConstStatement:
+		w.buf.WriteByte(tagConst)
+		w.str(s.Name)
+		w.typ(s.Type)
+		w.node(s.Value)
+	case *MultipleConstStatement:
+		w.buf.WriteByte(tagMultipleConst)
+		w.uint(uint64(len(s.Declarations)))
+		for _, d := range s.Declarations {
+			w.str(d.Name)
+			w.typ(d.Type)
+			w.node(d.Value)
+		}
+	case *GenericAssignStatement:
+		w.buf.WriteByte(tagAssign)
+		w.node(s.Left)
+		w.node(s.Right)
+	case *IfStatement:
+		w.buf.WriteByte(tagIf)
+		w.node(s.Condition)
+		w.block(s.Consequence)
+		w.block(s.Alternative)
+	case *WhileStatement:
+		w.buf.WriteByte(tagWhile)
+		w.node(s.Condition)
+		w.block(s.Body)
+	case *ForStatement:
+		w.buf.WriteByte(tagFor)
+		w.node(s.Init)
+		w.node(s.Condition)
+		w.node(s.Post)
+		w.block(s.Body)
+		w.bool(s.inFlag)
+		w.str(s.inArray)
+		w.node(s.inExpr)
+		w.str(s.inIndexName)
+		w.str(s.LoopID)
+		w.scope(s.scope)
+	case *ReturnStatement:
+		w.buf.WriteByte(tagReturn)
+		w.node(s.Value)
+	case *BreakStatement:
+		w.buf.WriteByte(tagBreak)
+	case *ContinueStatement:
+		w.buf.WriteByte(tagContinue)
+	case *FunctionDeclaration:
+		w.buf.WriteByte(tagFunctionDeclaration)
+		w.pos(s.Position)
+		w.str(s.Name)
+		w.strs(s.Args)
+		w.params(s.Params)
+		w.block(s.Body)
+		w.bool(s.Async)
+		w.bool(s.Generator)
+		w.typ(s.Return)
+	case *FunctionLiteral:
+		w.buf.WriteByte(tagFunctionLiteral)
+		w.strs(s.Args)
+		w.params(s.Params)
+		w.block(s.Body)
+		w.bool(s.Async)
+		w.bool(s.Generator)
+		w.typ(s.Return)
+	case *ArrowFunction:
+		w.buf.WriteByte(tagArrowFunction)
+		w.params(s.Params)
+		w.node(s.Body)
+		w.bool(s.IsExpression)
+		w.bool(s.Async)
+		w.scope(s.scope)
+	case *TryStatement:
+		w.buf.WriteByte(tagTry)
+		w.block(s.Body)
+		w.uint(uint64(len(s.Catches)))
+		for _, c := range s.Catches {
+			w.str(c.Var)
+			w.typ(c.Type)
+			w.block(c.Body)
+		}
+		w.block(s.FinallyBlock)
+	case *ThrowStatement:
+		w.buf.WriteByte(tagThrow)
+		w.pos(s.Position)
+		w.node(s.Value)
+	case *ObjectDeclaration:
+		w.buf.WriteByte(tagObjectDeclaration)
+		w.pos(s.Position)
+		w.str(s.Name)
+		w.str(s.ParentName)
+		w.strs(s.Interfaces)
+		w.nodes(s.Members)
+	case *ImportStatement:
+		w.buf.WriteByte(tagImport)
+		w.pos(s.Position)
+		w.str(s.Path)
+		w.str(s.Alias)
+		w.bool(s.Names != nil)
+		w.uint(uint64(len(s.Names)))
+		for _, n := range s.Names {
+			w.str(n.Name)
+			w.str(n.Alias)
+		}
+	case *ExportStatement:
+		w.buf.WriteByte(tagExport)
+		w.node(s.Declaration)
+	case *DSLDefinition:
+		w.buf.WriteByte(tagDSL)
+		w.str(s.Token.Type)
+		w.str(s.Token.Value)
+		w.int(s.Token.Line)
+		w.int(s.Token.Pos)
+		w.int(s.Token.Col)
+		w.int(s.Token.Start)
+		if s.Name == nil {
+			w.buf.WriteByte(tagNil)
+		} else {
+			w.node(s.Name)
+		}
+		w.block(s.Body)
+	case *ArrayDestructuring:
+		w.buf.WriteByte(tagArrayDestructuring)
+		w.strs(s.Names)
+		w.node(s.Value)
+	case *ObjectDestructuring:
+		w.buf.WriteByte(tagObjectDestructuring)
+		w.strs(s.Names)
+		w.node(s.Value)
+	case *Identifier:
+		w.buf.WriteByte(tagIdentifier)
+		w.pos(s.Position)
+		w.str(s.Name)
+		w.bool(s.ref != nil)
+		if s.ref != nil {
+			w.scope(s.ref.scope)
+			w.int(s.ref.depth)
+			w.int(s.ref.slot)
+		}
+	case *NumberLiteral:
+		w.buf.WriteByte(tagNumber)
+		w.float(s.Value)
+	case *ExactNumberLiteral:
+		w.buf.WriteByte(tagExactNumber)
+		w.str(s.Raw)
+	case *StringLiteral:
+		w.buf.WriteByte(tagString)
+		w.str(s.Value)
+	case *BooleanLiteral:
+		w.buf.WriteByte(tagBoolean)
+		w.bool(s.Value)
+	case *NilLiteral:
+		w.buf.WriteByte(tagNilLiteral)
+	case *DateLiteral:
+		w.buf.WriteByte(tagDate)
+		data, err := s.Value.Time.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+		w.str(string(data))
+	case *ArrayLiteral:
+		w.buf.WriteByte(tagArray)
+		w.nodes(s.Elements)
+	case *MapLiteral:
+		w.buf.WriteByte(tagMap)
+		w.uint(uint64(len(s.Pairs)))
+		for _, p := range s.Pairs {
+			w.node(p.Key)
+			w.node(p.Value)
+		}
+	case *BinaryExpression:
+		w.buf.WriteByte(tagBinary)
+		w.pos(s.Position)
+		w.node(s.Left)
+		w.str(s.Op)
+		w.node(s.Right)
+	case *UnaryExpression:
+		w.buf.WriteByte(tagUnary)
+		w.str(s.Operator)
+		w.node(s.Right)
+	case *AwaitExpression:
+		w.buf.WriteByte(tagAwait)
+		w.node(s.Value)
+	case *YieldExpression:
+		w.buf.WriteByte(tagYield)
+		w.node(s.Value)
+		w.bool(s.Delegate)
+	case *CallExpression:
+		w.buf.WriteByte(tagCall)
+		w.pos(s.Position)
+		w.node(s.Callee)
+		w.nodes(s.Args)
+	case *AccessExpression:
+		w.buf.WriteByte(tagAccess)
+		w.pos(s.Position)
+		w.node(s.Object)
+		w.str(s.Member)
+	case *OptionalAccessExpression:
+		w.buf.WriteByte(tagOptionalAccess)
+		w.node(s.Object)
+		w.str(s.Member)
+	case *IndexExpression:
+		w.buf.WriteByte(tagIndex)
+		w.node(s.Left)
+		w.node(s.Index)
+	case *OptionalIndexExpression:
+		w.buf.WriteByte(tagOptionalIndex)
+		w.node(s.Object)
+		w.node(s.Index)
+	case *TernaryExpression:
+		w.buf.WriteByte(tagTernary)
+		w.node(s.Condition)
+		w.node(s.TrueExpr)
+		w.node(s.FalseExpr)
+	case *TemplateString:
+		w.buf.WriteByte(tagTemplate)
+		w.uint(uint64(len(s.Parts)))
+		for _, p := range s.Parts {
+			w.bool(p.IsExpression)
+			w.str(p.Content)
+			w.node(p.Expression)
+			w.str(p.Format)
+		}
+	case *SpreadExpression:
+		w.buf.WriteByte(tagSpread)
+		w.node(s.Value)
+	case *MatchExpression:
+		w.buf.WriteByte(tagMatch)
+		w.node(s.Value)
+		w.uint(uint64(len(s.Cases)))
+		for _, c := range s.Cases {
+			w.pattern(c.Pattern)
+			w.node(c.Guard)
+			w.node(c.Body)
+			w.bool(c.IsDefault)
+		}
+	case *SwitchStatement:
+		w.buf.WriteByte(tagSwitch)
+		w.node(s.Value)
+		w.uint(uint64(len(s.Cases)))
+		for _, c := range s.Cases {
+			w.uint(uint64(len(c.Patterns)))
+			for _, p := range c.Patterns {
+				w.pattern(p)
+			}
+			w.block(c.Body)
+			w.bool(c.IsDefault)
+		}
+	case *EnumDeclaration:
+		w.buf.WriteByte(tagEnum)
+		w.str(s.Name)
+		w.uint(uint64(len(s.Members)))
+		for _, m := range s.Members {
+			w.str(m.Name)
+			w.node(m.Value)
+		}
+	case *StaticMember:
+		w.buf.WriteByte(tagStatic)
+		w.node(s.Member)
+	case *AccessorDeclaration:
+		w.buf.WriteByte(tagAccessor)
+		w.str(s.Kind)
+		w.node(s.Func)
+	case *InterfaceDeclaration:
+		w.buf.WriteByte(tagInterface)
+		w.str(s.Keyword)
+		w.str(s.Name)
+		w.uint(uint64(len(s.Methods)))
+		for _, m := range s.Methods {
+			w.node(m)
+		}
+	case *ArrayComprehension:
+		w.buf.WriteByte(tagArrayComprehension)
+		w.node(s.Expression)
+		w.generators(s.Generators)
+		w.nodes(s.Conditions)
+	case *ObjectComprehension:
+		w.buf.WriteByte(tagObjectComprehension)
+		w.node(s.KeyExpr)
+		w.node(s.ValueExpr)
+		w.generators(s.Generators)
+		w.nodes(s.Conditions)
+	default:
+		panic(fmt.Sprintf("unsupported node of type %T", n))
+	}
+}
+
+func (w *bcWriter) pattern(p Pattern) {
+	switch s := p.(type) {
+	case nil:
+		w.buf.WriteByte(patNil)
+	case *LiteralPattern:
+		w.buf.WriteByte(patLiteral)
+		w.node(s.Value)
+	case *VariablePattern:
+		w.buf.WriteByte(patVariable)
+		w.str(s.Name)
+	case *WildcardPattern:
+		w.buf.WriteByte(patWildcard)
+	case *ArrayPattern:
+		w.buf.WriteByte(patArray)
+		w.uint(uint64(len(s.Elements)))
+		for _, e := range s.Elements {
+			w.pattern(e)
+		}
+	case *ObjectPattern:
+		w.buf.WriteByte(patObject)
+		keys := make([]string, 0, len(s.Fields))
+		for k := range s.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // Salida determinista
+		w.uint(uint64(len(keys)))
+		for _, k := range keys {
+			w.str(k)
+			w.pattern(s.Fields[k])
+		}
+	case *OrPattern:
+		w.buf.WriteByte(patOr)
+		w.uint(uint64(len(s.Patterns)))
+		for _, e := range s.Patterns {
+			w.pattern(e)
+		}
+	case *GuardedPattern:
+		w.buf.WriteByte(patGuarded)
+		w.pattern(s.Pattern)
+		w.node(s.Guard)
+	case *ValuePattern:
+		w.buf.WriteByte(patValue)
+		w.node(s.Value)
+	default:
+		panic(fmt.Sprintf("unsupported pattern of type %T", p))
+	}
+}
+
+// ------------------------------------------------------------
+// Lectura
+// ------------------------------------------------------------
+
+type bcReader struct {
+	data   []byte
+	pos    int
+	scopes []*Scope // Los layouts leídos, por número
+}
+
+func (r *bcReader) byte() byte {
+	if r.pos >= len(r.data) {
+		panic("unexpected end of file")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *bcReader) uint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		panic("invalid integer")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *bcReader) int() int {
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		panic("invalid integer")
+	}
+	r.pos += n
+	return int(v)
+}
+
+// count lee la longitud de una lista, acotada por lo que queda del archivo.
+func (r *bcReader) count() int {
+	n := r.uint()
+	if n > uint64(len(r.data)-r.pos) {
+		panic("invalid length")
+	}
+	return int(n)
+}
+
+func (r *bcReader) bool() bool {
+	return r.byte() != 0
+}
+
+func (r *bcReader) float() float64 {
+	return math.Float64frombits(r.uint())
+}
+
+func (r *bcReader) str() string {
+	n := r.count()
+	s := string(r.data[r.pos : r.pos+n])
+	r.pos += n
+	return s
+}
+
+func (r *bcReader) strs() []string {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	list := make([]string, n)
+	for i := range list {
+		list[i] = r.str()
+	}
+	return list
+}
+
+func (r *bcReader) position() *PositionInfo {
+	if !r.bool() {
+		return nil
+	}
+	return &PositionInfo{Line: r.int(), Col: r.int(), Pos: r.int(), Filename: r.str()}
+}
+
+func (r *bcReader) scope() *Scope {
+	id := r.uint()
+	switch {
+	case id == 0:
+		return nil
+	case id <= uint64(len(r.scopes)):
+		return r.scopes[id-1]
+	case id != uint64(len(r.scopes))+1:
+		panic(fmt.Sprintf("invalid scope %d", id))
+	}
+	s := &Scope{}
+	r.scopes = append(r.scopes, s)
+	s.parent = r.scope()
+	s.names = r.strs()
+	s.index = make(map[string]int, len(s.names))
+	for i, name := range s.names {
+		s.index[name] = i
+	}
+	return s
+}
+
+// ref lee la variable resuelta de un Identifier y verifica que su slot
+// exista en el layout al que apunta.
+func (r *bcReader) ref() *varRef {
+	if !r.bool() {
+		return nil
+	}
+	ref := &varRef{scope: r.scope(), depth: r.int(), slot: r.int()}
+	target := ref.scope
+	for i := 0; i < ref.depth && target != nil; i++ {
+		target = target.parent
+	}
+	if target == nil || ref.depth < 0 || ref.slot < 0 || ref.slot >= len(target.names) {
+		panic("invalid variable slot")
+	}
+	return ref
+}
+
+func (r *bcReader) nodes() []Node {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	list := make([]Node, n)
+	for i := range list {
+		list[i] = r.node()
+	}
+	return list
+}
+
+func (r *bcReader) block() *BlockStatement {
+	n := r.node()
+	if n == nil {
+		return nil
+	}
+	b, ok := n.(*BlockStatement)
+	if !ok {
+		panic(fmt.Sprintf("expected a block, found %T", n))
+	}
+	return b
+}
+
+func (r *bcReader) typ() *TypeAnnotation {
+	if !r.bool() {
+		return nil
+	}
+	return &TypeAnnotation{Position: r.position(), Types: r.strs()}
+}
+
+func (r *bcReader) params() []Parameter {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	params := make([]Parameter, n)
+	for i := range params {
+		params[i] = Parameter{Name: r.str(), DefaultValue: r.node(), Type: r.typ(), Optional: r.bool()}
+	}
+	return params
+}
+
+func (r *bcReader) catches() []*CatchClause {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	catches := make([]*CatchClause, n)
+	for i := range catches {
+		catches[i] = &CatchClause{Var: r.str(), Type: r.typ(), Body: r.block()}
+	}
+	return catches
+}
+
+func (r *bcReader) generators() []Generator {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	gens := make([]Generator, n)
+	for i := range gens {
+		gens[i] = Generator{Variable: r.str(), Iterator: r.node()}
+	}
+	return gens
+}
+
+func (r *bcReader) constant() interface{} {
+	switch tag := r.byte(); tag {
+	case constNil:
+		return nil
+	case constFalse:
+		return false
+	case constTrue:
+		return true
+	case constNumber:
+		return r.float()
+	case constString:
+		return r.str()
+	default:
+		panic(fmt.Sprintf("unknown constant tag %d", tag))
+	}
+}
+
+func (r *bcReader) node() Node {
+	switch tag := r.byte(); tag {
+	case tagNil:
+		return nil
+	case tagCompiledCode:
+		cc := &CompiledCode{Program: r.bool()}
+		cc.Code = make([]Instr, r.count())
+		for i := range cc.Code {
+			cc.Code[i] = Instr{Op: Opcode(r.byte()), A: int32(r.int()), B: int32(r.int()), C: int32(r.int())}
+		}
+		cc.Consts = make([]interface{}, r.count())
+		for i := range cc.Consts {
+			cc.Consts[i] = r.constant()
+		}
+		cc.Names = r.strs()
+		cc.Nodes = r.nodes()
+		if n := r.count(); n > 0 {
+			cc.Scopes = make([]*Scope, n)
+			for i := range cc.Scopes {
+				cc.Scopes[i] = r.scope()
+			}
+		}
+		cc.Frame = r.scope()
+		cc.Locals = r.int()
+		verifyCode(cc)
+		return cc
+	case tagProgram:
+		return &Program{Statements: r.nodes()}
+	case tagBlock:
+		return &BlockStatement{Statements: r.nodes(), scope: r.scope()}
+	case tagExprStatement:
+		return &ExprStatement{Expr: r.node()}
+	case tagLet:
+		return &LetStatement{Name: r.str(), Type: r.typ(), Value: r.node()}
+	case tagMultipleLet:
+		s := &MultipleLetStatement{Declarations: make([]LetDeclaration, r.count())}
+		for i := range s.Declarations {
+			s.Declarations[i] = LetDeclaration{Name: r.str(), Type: r.typ(), Value: r.node()}
+		}
+		return s
+	case tagConst:
+		return &ConstStatement{Name: r.str(), Type: r.typ(), Value: r.node()}
+	case tagMultipleConst:
+		s := &MultipleConstStatement{Declarations: make([]ConstDeclaration, r.count())}
+		for i := range s.Declarations {
+			s.Declarations[i] = ConstDeclaration{Name: r.str(), Type: r.typ(), Value: r.node()}
+		}
+		return s
+	case tagAssign:
+		return &GenericAssignStatement{Left: r.node(), Right: r.node()}
+	case tagIf:
+		return &IfStatement{Condition: r.node(), Consequence: r.block(), Alternative: r.block()}
+	case tagWhile:
+		return &WhileStatement{Condition: r.node(), Body: r.block()}
+	case tagFor:
+		return &ForStatement{Init: r.node(), Condition: r.node(), Post: r.node(), Body: r.block(),
+			inFlag: r.bool(), inArray: r.str(), inExpr: r.node(), inIndexName: r.str(), LoopID: r.str(), scope: r.scope()}
+	case tagReturn:
+		return &ReturnStatement{Value: r.node()}
+	case tagBreak:
+		return &BreakStatement{}
+	case tagContinue:
+		return &ContinueStatement{}
+	case tagFunctionDeclaration:
+		return &FunctionDeclaration{BaseNode: BaseNode{Position: r.position()}, Name: r.str(),
+			Args: r.strs(), Params: r.params(), Body: r.block(), Async: r.bool(), Generator: r.bool(), Return: r.typ()}
+	case tagFunctionLiteral:
+		return &FunctionLiteral{Args: r.strs(), Params: r.params(), Body: r.block(), Async: r.bool(), Generator: r.bool(), Return: r.typ()}
+	case tagArrowFunction:
+		return &ArrowFunction{Params: r.params(), Body: r.node(), IsExpression: r.bool(), Async: r.bool(), scope: r.scope()}
+	case tagTry:
+		return &TryStatement{Body: r.block(), Catches: r.catches(), FinallyBlock: r.block()}
+	case tagThrow:
+		return &ThrowStatement{BaseNode: BaseNode{Position: r.position()}, Value: r.node()}
+	case tagObjectDeclaration:
+		return &ObjectDeclaration{BaseNode: BaseNode{Position: r.position()}, Name: r.str(), ParentName: r.str(),
+			Interfaces: r.strs(), Members: r.nodes()}
+	case tagImport:
+		is := &ImportStatement{BaseNode: BaseNode{Position: r.position()}, Path: r.str(), Alias: r.str()}
+		selective := r.bool()
+		names := make([]ImportName, r.count())
+		for i := range names {
+			names[i] = ImportName{Name: r.str(), Alias: r.str()}
+		}
+		if selective {
+			is.Names = names
+		}
+		return is
+	case tagExport:
+		return &ExportStatement{Declaration: r.node()}
+	case tagDSL:
+		tok := Token{Type: r.str(), Value: r.str(), Line: r.int(), Pos: r.int(), Col: r.int(), Start: r.int()}
+		dsl := &DSLDefinition{Token: tok}
+		if name := r.node(); name != nil {
+			id, ok := name.(*Identifier)
+			if !ok {
+				panic(fmt.Sprintf("expected an identifier, found %T", name))
+			}
+			dsl.Name = id
+		}
+		dsl.Body = r.block()
+		return dsl
+	case tagArrayDestructuring:
+		return &ArrayDestructuring{Names: r.strs(), Value: r.node()}
+	case tagObjectDestructuring:
+		return &ObjectDestructuring{Names: r.strs(), Value: r.node()}
+	case tagIdentifier:
+		return &Identifier{BaseNode: BaseNode{Position: r.position()}, Name: r.str(), ref: r.ref()}
+	case tagNumber:
+		return &NumberLiteral{Value: r.float()}
+	case tagExactNumber:
+		raw := r.str()
+		value, err := ParseNumber(raw)
+		if err != nil {
+			panic(err.Error())
+		}
+		return &ExactNumberLiteral{Raw: raw, Value: value}
+	case tagString:
+		return &StringLiteral{Value: r.str()}
+	case tagBoolean:
+		return &BooleanLiteral{Value: r.bool()}
+	case tagNilLiteral:
+		return &NilLiteral{}
+	case tagDate:
+		var t time.Time
+		if err := t.UnmarshalBinary([]byte(r.str())); err != nil {
+			panic(err)
+		}
+		return &DateLiteral{Value: NewDateValue(t)}
+	case tagArray:
+		return &ArrayLiteral{Elements: r.nodes()}
+	case tagMap:
+		ml := &MapLiteral{Pairs: make([]MapPair, r.count())}
+		for i := range ml.Pairs {
+			ml.Pairs[i] = MapPair{Key: r.node(), Value: r.node()}
+		}
+		return ml
+	case tagBinary:
+		return &BinaryExpression{BaseNode: BaseNode{Position: r.position()}, Left: r.node(), Op: r.str(), Right: r.node()}
+	case tagUnary:
+		return &UnaryExpression{Operator: r.str(), Right: r.node()}
+	case tagAwait:
+		return &AwaitExpression{Value: r.node()}
+	case tagYield:
+		return &YieldExpression{Value: r.node(), Delegate: r.bool()}
+	case tagCall:
+		return &CallExpression{BaseNode: BaseNode{Position: r.position()}, Callee: r.node(), Args: r.nodes()}
+	case tagAccess:
+		return &AccessExpression{BaseNode: BaseNode{Position: r.position()}, Object: r.node(), Member: r.str()}
+	case tagOptionalAccess:
+		return &OptionalAccessExpression{Object: r.node(), Member: r.str()}
+	case tagIndex:
+		return &IndexExpression{Left: r.node(), Index: r.node()}
+	case tagOptionalIndex:
+		return &OptionalIndexExpression{Object: r.node(), Index: r.node()}
+	case tagTernary:
+		return &TernaryExpression{Condition: r.node(), TrueExpr: r.node(), FalseExpr: r.node()}
+	case tagTemplate:
+		ts := &TemplateString{Parts: make([]TemplatePart, r.count())}
+		for i := range ts.Parts {
+			ts.Parts[i] = TemplatePart{IsExpression: r.bool(), Content: r.str(), Expression: r.node(), Format: r.str()}
+		}
+		return ts
+	case tagSpread:
+		return &SpreadExpression{Value: r.node()}
+	case tagMatch:
+		me := &MatchExpression{Value: r.node()}
+		me.Cases = make([]MatchCase, r.count())
+		for i := range me.Cases {
+			me.Cases[i] = MatchCase{Pattern: r.pattern(), Guard: r.node(), Body: r.node(), IsDefault: r.bool()}
+		}
+		return me
+	case tagSwitch:
+		ss := &SwitchStatement{Value: r.node()}
+		ss.Cases = make([]SwitchCase, r.count())
+		for i := range ss.Cases {
+			c := &ss.Cases[i]
+			c.Patterns = make([]Pattern, r.count())
+			for j := range c.Patterns {
+				c.Patterns[j] = r.pattern()
+			}
+			c.Body = r.block()
+			c.IsDefault = r.bool()
+		}
+		return ss
+	case tagEnum:
+		ed := &EnumDeclaration{Name: r.str()}
+		ed.Members = make([]EnumMemberDecl, r.count())
+		for i := range ed.Members {
+			ed.Members[i] = EnumMemberDecl{Name: r.str(), Value: r.node()}
+		}
+		return ed
+	case tagStatic:
+		return &StaticMember{Member: r.node()}
+	case tagAccessor:
+		ad := &AccessorDeclaration{Kind: r.str()}
+		ad.Func, _ = r.node().(*FunctionDeclaration)
+		return ad
+	case tagInterface:
+		id := &InterfaceDeclaration{Keyword: r.str(), Name: r.str()}
+		id.Methods = make([]*FunctionDeclaration, r.count())
+		for i := range id.Methods {
+			id.Methods[i], _ = r.node().(*FunctionDeclaration)
+		}
+		return id
+	case tagArrayComprehension:
+		return &ArrayComprehension{Expression: r.node(), Generators: r.generators(), Conditions: r.nodes()}
+	case tagObjectComprehension:
+		return &ObjectComprehension{KeyExpr: r.node(), ValueExpr: r.node(), Generators: r.generators(), Conditions: r.nodes()}
+	default:
+		panic(fmt.Sprintf("unknown node tag %d", tag))
+	}
+}
+
+// vmState es lo que verifyCode sabe de la VM antes de una instrucción: el
+// tamaño de la pila y cuántos bucles y entornos de for hay abiertos.
+type vmState struct {
+	stack, loops, scopes int
+}
+
+// verifyCode comprueba que cc, recién leído, se pueda ejecutar sin salirse de
+// sus tablas ni de la pila: que los operandos de cada instrucción apunten a
+// una constante, un nombre, un nodo del tipo que espera la VM o un layout
+// existentes, que los saltos caigan dentro del código y que cada instrucción
+// se alcance siempre con la misma pila y los mismos bucles abiertos, como en
+// el código que genera Compile. Un salto hacia atrás sólo puede volver al
+// comienzo de un bucle (OpLoopCheck u OpIterNext), así que tampoco puede
+// armar un ciclo que no cuente iteraciones.
+func verifyCode(cc *CompiledCode) {
+	code := cc.Code
+	states := make([]*vmState, len(code)+1)
+	states[0] = &vmState{}
+	work := []int{0}
+	for len(work) > 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		if pc == len(code) {
+			continue
+		}
+		in := code[pc]
+		st := *states[pc]
+		fail := func(format string, args ...interface{}) {
+			panic(fmt.Sprintf("instruction %d (opcode %d): ", pc, in.Op) + fmt.Sprintf(format, args...))
+		}
+		node := func(want func(Node) bool) Node {
+			if in.A < 0 || int(in.A) >= len(cc.Nodes) || !want(cc.Nodes[in.A]) {
+				fail("invalid node %d", in.A)
+			}
+			return cc.Nodes[in.A]
+		}
+		slot := func() {
+			if in.B < 0 || (cc.Frame != nil && int(in.B) >= cc.Locals) {
+				fail("invalid slot %d", in.B)
+			}
+		}
+		count := func(n int32) int {
+			if n < 0 {
+				fail("invalid count %d", n)
+			}
+			return int(n)
+		}
+		pops := func(n int) {
+			if st.stack < n {
+				fail("stack underflow")
+			}
+			st.stack -= n
+		}
+		var targets []int32
+		next := true
+		if cc.Frame != nil {
+			switch in.Op {
+			case OpEval, OpIterInit, OpSetIndex, OpPipe:
+				fail("not allowed in a frame")
+			}
+		}
+		switch in.Op {
+		case OpConst:
+			if in.A < 0 || int(in.A) >= len(cc.Consts) {
+				fail("invalid constant %d", in.A)
+			}
+			st.stack++
+		case OpPop, OpLet, OpConstDecl:
+			if in.Op != OpPop && (in.A < 0 || int(in.A) >= len(cc.Names)) {
+				fail("invalid name %d", in.A)
+			}
+			pops(1)
+		case OpDup:
+			pops(1)
+			st.stack += 2
+		case OpGet, OpUpdate, OpGetLocal, OpSetLocal:
+			node(func(n Node) bool { _, ok := n.(*Identifier); return ok })
+			if in.Op == OpGetLocal || in.Op == OpSetLocal {
+				slot()
+			}
+			if in.Op == OpUpdate || in.Op == OpSetLocal {
+				pops(1)
+			}
+			st.stack++
+		case OpLetLocal, OpConstLocal:
+			if in.A < 0 || int(in.A) >= len(cc.Names) {
+				fail("invalid name %d", in.A)
+			}
+			slot()
+			pops(1)
+		case OpCheckType:
+			node(func(n Node) bool { ls, ok := n.(*LetStatement); return ok && ls.Type != nil })
+			pops(1)
+			st.stack++
+		case OpSetMember, OpAccess:
+			node(func(n Node) bool { _, ok := n.(*AccessExpression); return ok })
+			if in.Op == OpSetMember {
+				pops(1)
+			}
+			pops(1)
+			st.stack++
+		case OpSetIndex, OpIndex:
+			node(func(n Node) bool { _, ok := n.(*IndexExpression); return ok })
+			if in.Op == OpIndex {
+				pops(1)
+			}
+			pops(1)
+			st.stack++
+		case OpBinary, OpPipe:
+			node(func(n Node) bool { _, ok := n.(*BinaryExpression); return ok })
+			if in.B < floatNone || in.B >= floatOpCount {
+				fail("invalid operator %d", in.B)
+			}
+			pops(2)
+			st.stack++
+		case OpToBool:
+			pops(1)
+			st.stack++
+		case OpUnary:
+			node(func(n Node) bool { _, ok := n.(*UnaryExpression); return ok })
+			pops(1)
+			st.stack++
+		case OpCall, OpTailCall:
+			node(func(n Node) bool { _, ok := n.(*CallExpression); return ok })
+			if in.C != 0 && in.C != 1 {
+				fail("invalid call kind %d", in.C)
+			}
+			pops(count(in.B) + 1)
+			st.stack++
+		case OpArray:
+			pops(count(in.B))
+			st.stack++
+		case OpMap:
+			ml := node(func(n Node) bool { _, ok := n.(*MapLiteral); return ok }).(*MapLiteral)
+			if count(in.B) != len(ml.Pairs) {
+				fail("expected %d values, found %d", len(ml.Pairs), in.B)
+			}
+			pops(int(in.B))
+			st.stack++
+		case OpEval:
+			node(func(n Node) bool { return n != nil })
+			st.stack++
+		case OpJump:
+			targets, next = []int32{in.A}, false
+		case OpJumpFalse, OpJumpTrue:
+			pops(1)
+			targets = []int32{in.A}
+		case OpJumpNotNil:
+			// Si salta, el valor queda en la pila
+			pops(1)
+			taken := st
+			taken.stack++
+			jump(code, states, &work, pc, in.A, taken, fail)
+		case OpResult:
+			pops(1)
+			if in.B >= 0 {
+				targets = append(targets, in.B)
+			}
+			if in.C >= 0 {
+				targets = append(targets, in.C)
+			}
+			if cc.Program && (in.B < 0 || in.C < 0) {
+				targets = append(targets, in.A)
+			}
+		case OpReturn:
+			pops(1)
+			next = false
+		case OpBreak, OpContinue:
+			if cc.Program {
+				targets = []int32{in.A}
+			}
+			next = false
+		case OpEnterScope:
+			if in.A < 0 || int(in.A) >= len(cc.Scopes) {
+				fail("invalid scope %d", in.A)
+			}
+			if cc.Frame != nil && (cc.Scopes[in.A] == nil || in.B < 0 || int(in.B)+len(cc.Scopes[in.A].names) > cc.Locals) {
+				fail("invalid slots %d for scope %d", in.B, in.A)
+			}
+			st.scopes++
+		case OpExitScope:
+			if st.scopes == 0 {
+				fail("no scope to exit")
+			}
+			st.scopes--
+		case OpLoop:
+			if in.A < 0 || int(in.A) >= len(loopKinds) {
+				fail("invalid loop kind %d", in.A)
+			}
+			st.loops++
+		case OpLoopCheck, OpLoopCount, OpLoopSave, OpLoopEnd, OpIterInit, OpIterNext:
+			if st.loops == 0 {
+				fail("no open loop")
+			}
+			switch in.Op {
+			case OpLoopEnd:
+				st.loops--
+			case OpIterInit, OpIterNext:
+				node(func(n Node) bool { fs, ok := n.(*ForStatement); return ok && fs.inFlag })
+				if in.Op == OpIterNext {
+					targets = []int32{in.B}
+				}
+			}
+		default:
+			fail("unknown opcode")
+		}
+		for _, target := range targets {
+			jump(code, states, &work, pc, target, st, fail)
+		}
+		if next {
+			jump(code, states, &work, pc, int32(pc+1), st, fail)
+		}
+	}
+}
+
+// jump registra que desde pc se llega a target con el estado st.
+func jump(code []Instr, states []*vmState, work *[]int, pc int, target int32, st vmState, fail func(string, ...interface{})) {
+	if target < 0 || int(target) > len(code) {
+		fail("jump to %d out of range", target)
+	}
+	if int(target) <= pc {
+		if op := code[target].Op; op != OpLoopCheck && op != OpIterNext {
+			fail("backward jump to %d", target)
+		}
+	}
+	switch prev := states[target]; {
+	case prev == nil:
+		states[target] = &st
+		*work = append(*work, int(target))
+	case *prev != st:
+		fail("inconsistent stack at %d", target)
+	}
+}
+
+func (r *bcReader) pattern() Pattern {
+	switch tag := r.byte(); tag {
+	case patNil:
+		return nil
+	case patLiteral:
+		return &LiteralPattern{Value: r.node()}
+	case patVariable:
+		return &VariablePattern{Name: r.str()}
+	case patWildcard:
+		return &WildcardPattern{}
+	case patArray:
+		ap := &ArrayPattern{Elements: make([]Pattern, r.count())}
+		for i := range ap.Elements {
+			ap.Elements[i] = r.pattern()
+		}
+		return ap
+	case patObject:
+		n := r.count()
+		op := &ObjectPattern{Fields: make(map[string]Pattern, n)}
+		for i := 0; i < n; i++ {
+			k := r.str()
+			op.Fields[k] = r.pattern()
+		}
+		return op
+	case patOr:
+		op := &OrPattern{Patterns: make([]Pattern, r.count())}
+		for i := range op.Patterns {
+			op.Patterns[i] = r.pattern()
+		}
+		return op
+	case patGuarded:
+		return &GuardedPattern{Pattern: r.pattern(), Guard: r.node()}
+	case patValue:
+		return &ValuePattern{Value: r.node()}
+	default:
+		panic(fmt.Sprintf("unknown pattern tag %d", tag))
+	}
+}
@@ -0,0 +1,703 @@
+package r2core
+
+import "math"
+
+// Compile traduce prog a bytecode. El resultado, evaluado sobre un
+// Environment, hace exactamente lo mismo que prog.Eval.
+//
+// Se compilan las sentencias y expresiones del núcleo del lenguaje (variables,
+// operadores, llamadas, acceso a miembros e índices, if, while, for, return,
+// break, continue) y los cuerpos de todas las funciones; el resto de los nodos
+// (match, switch, try, clases, DSL, comprehensions, ...) queda en el código como OpEval
+// y se ejecuta con su propio Eval. Los nodos de prog pueden quedar referenciados
+// por el resultado, así que prog no debe modificarse después.
+func Compile(prog *Program) *CompiledCode {
+	return compileStatements(prog.Statements, true)
+}
+
+type jumpPatch struct {
+	at    int
+	field byte // 'A', 'B' o 'C'
+}
+
+type loopLabels struct {
+	breaks, continues []jumpPatch
+}
+
+type compiler struct {
+	out     *CompiledCode
+	consts  map[interface{}]int
+	names   map[string]int
+	nodes   map[Node]int
+	loops   []*loopLabels
+	discard bool // El valor de las sentencias se descarta (init/post del for)
+
+	// En el nivel superior, un break/continue fuera de un bucle sólo corta la
+	// sentencia actual (Program.Eval sigue con la siguiente): sus saltos se
+	// resuelven al final de ella.
+	stmtEnd []jumpPatch
+
+	method bool // Se compilan los miembros de una clase
+
+	// Layout del cuerpo de función que se compila si puede correr como frame
+	// (ver CompiledCode.Frame): sus variables van por slot con OpGetLocal y
+	// compañía. frameOK pasa a false con lo primero que necesita el entorno.
+	frame        *Scope
+	frameOK      bool
+	frameScope   *Scope          // Layout de las declaraciones que se compilan: el de la función o el de un for
+	frameSlots   map[*Scope]int  // Primer slot del frame de cada layout: 0 el de la función, después los de los for
+	frameConsts  map[string]bool // Variables locales declaradas con const
+	frameWritten map[string]bool // Parámetros y variables locales que se declaran con let o se asignan
+}
+
+func newCompiler(program bool) *compiler {
+	return &compiler{
+		out:    &CompiledCode{Program: program},
+		consts: map[interface{}]int{},
+		names:  map[string]int{},
+		nodes:  map[Node]int{},
+	}
+}
+
+func compileStatements(stmts []Node, program bool) *CompiledCode {
+	c := newCompiler(program)
+	c.statements(stmts)
+	return c.out
+}
+
+func (c *compiler) statements(stmts []Node) {
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+		for _, p := range c.stmtEnd {
+			c.patch(p, c.here())
+		}
+		c.stmtEnd = nil
+	}
+}
+
+// body compila el cuerpo de una función: el bloque resultante contiene una
+// única sentencia, el CompiledCode, así que UserFunction lo ejecuta igual (y
+// con el mismo layout de slots).
+func (c *compiler) body(b *BlockStatement) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	return &BlockStatement{Statements: []Node{compileStatements(b.Statements, false)}, scope: b.scope}
+}
+
+// function compila el cuerpo de una función, como body. Si la función no es
+// un método, sus parámetros son los primeros slots de su layout y no tienen
+// valor por defecto, el cuerpo se compila para poder correr también como
+// frame; queda con Frame si no usa nada que necesite su entorno.
+func (c *compiler) function(params []Parameter, args []string, b *BlockStatement) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	fc := newCompiler(false)
+	names := args
+	if len(params) > 0 {
+		names = make([]string, len(params))
+		for i, param := range params {
+			if param.DefaultValue != nil {
+				names = nil
+				break
+			}
+			names[i] = param.Name
+		}
+	}
+	if !c.method && b.scope != nil && (names != nil || len(params) == 0) && len(names) <= len(b.scope.names) {
+		fc.frame, fc.frameOK, fc.frameScope = b.scope, true, b.scope
+		fc.frameSlots = map[*Scope]int{b.scope: 0}
+		fc.out.Locals = len(b.scope.names)
+		fc.frameConsts, fc.frameWritten = map[string]bool{}, map[string]bool{}
+		for i, name := range names {
+			if b.scope.names[i] != name {
+				fc.frameOK = false
+			}
+			fc.frameWritten[name] = true
+		}
+	}
+	fc.statements(b.Statements)
+	if fc.frameOK {
+		for name := range fc.frameConsts {
+			if fc.frameWritten[name] {
+				// Reasignarla falla en el entorno; el frame no lo detectaría
+				fc.frameOK = false
+			}
+		}
+	}
+	if fc.frameOK {
+		fc.out.Frame = b.scope
+	}
+	return &BlockStatement{Statements: []Node{fc.out}, scope: b.scope}
+}
+
+// local devuelve el slot en el frame de la variable id si es una variable
+// local del cuerpo que se compila como frame: de la función o de uno de sus
+// for.
+func (c *compiler) local(id *Identifier) (int, bool) {
+	if c.frame == nil {
+		return 0, false
+	}
+	if id.ref != nil {
+		scope := id.ref.scope
+		for depth := id.ref.depth; depth > 0 && scope != nil; depth-- {
+			scope = scope.parent
+		}
+		if first, ok := c.frameSlots[scope]; ok {
+			return first + id.ref.slot, true
+		}
+	}
+	for scope := range c.frameSlots {
+		if _, declared := scope.index[id.Name]; declared {
+			// El resolver no la ligó: sólo se puede buscar en el entorno
+			c.frameOK = false
+		}
+	}
+	return 0, false
+}
+
+// declare emite la declaración de name con op (OpLet u OpConstDecl), en su
+// slot si es una variable local del cuerpo que se compila como frame.
+func (c *compiler) declare(op Opcode, name string) {
+	slot, local := 0, false
+	if c.frame != nil {
+		slot, local = c.frameScope.index[name]
+		slot += c.frameSlots[c.frameScope]
+	}
+	switch {
+	case !local:
+		c.emit(op, c.name(name), 0, 0)
+	case op == OpLet:
+		c.frameWritten[name] = true
+		c.emit(OpLetLocal, c.name(name), slot, 0)
+	default:
+		c.frameConsts[name] = true
+		c.emit(OpConstLocal, c.name(name), slot, 0)
+	}
+}
+
+// checkType emite, para una variable anotada con valor inicial, el
+// OpCheckType que lo comprueba en modo estricto.
+func (c *compiler) checkType(name string, typ *TypeAnnotation, value Node) {
+	if typ != nil && value != nil {
+		c.emit(OpCheckType, c.node(&LetStatement{Name: name, Type: typ}), 0, 0)
+	}
+}
+
+func (c *compiler) emit(op Opcode, a, b, cc int) int {
+	switch op {
+	case OpEval, OpIterInit, OpSetIndex, OpPipe:
+		// Evalúan nodos, crean entornos o buscan variables en el entorno
+		c.frameOK = false
+	}
+	c.out.Code = append(c.out.Code, Instr{Op: op, A: int32(a), B: int32(b), C: int32(cc)})
+	return len(c.out.Code) - 1
+}
+
+func (c *compiler) here() int {
+	return len(c.out.Code)
+}
+
+func (c *compiler) patch(p jumpPatch, target int) {
+	in := &c.out.Code[p.at]
+	switch p.field {
+	case 'A':
+		in.A = int32(target)
+	case 'B':
+		in.B = int32(target)
+	case 'C':
+		in.C = int32(target)
+	}
+}
+
+// floatKey distingue en c.consts los float64 por sus bits (0 y -0, NaN).
+type floatKey uint64
+
+func (c *compiler) constant(v interface{}) int {
+	key := v
+	if f, ok := v.(float64); ok {
+		key = floatKey(math.Float64bits(f))
+	}
+	if i, ok := c.consts[key]; ok {
+		return i
+	}
+	c.out.Consts = append(c.out.Consts, v)
+	c.consts[key] = len(c.out.Consts) - 1
+	return len(c.out.Consts) - 1
+}
+
+func (c *compiler) name(s string) int {
+	if i, ok := c.names[s]; ok {
+		return i
+	}
+	c.out.Names = append(c.out.Names, s)
+	c.names[s] = len(c.out.Names) - 1
+	return len(c.out.Names) - 1
+}
+
+func (c *compiler) node(n Node) int {
+	if i, ok := c.nodes[n]; ok {
+		return i
+	}
+	c.out.Nodes = append(c.out.Nodes, n)
+	c.nodes[n] = len(c.out.Nodes) - 1
+	return len(c.out.Nodes) - 1
+}
+
+// result cierra una sentencia: su valor (en el tope) pasa a ser el del bloque.
+// Dentro de un bucle, un valor de control salta a su break/continue.
+func (c *compiler) result() {
+	if c.discard {
+		c.emit(OpPop, 0, 0, 0)
+		return
+	}
+	if len(c.loops) == 0 {
+		c.outsideLoop(c.emit(OpResult, 0, -1, -1))
+		return
+	}
+	loop := c.loops[len(c.loops)-1]
+	at := c.emit(OpResult, 0, -1, -1)
+	loop.breaks = append(loop.breaks, jumpPatch{at, 'B'})
+	loop.continues = append(loop.continues, jumpPatch{at, 'C'})
+}
+
+// outsideLoop registra una instrucción que, en el nivel superior, salta al
+// final de la sentencia actual con un break/continue fuera de un bucle.
+func (c *compiler) outsideLoop(at int) {
+	if c.out.Program {
+		c.stmtEnd = append(c.stmtEnd, jumpPatch{at, 'A'})
+	}
+}
+
+func (c *compiler) block(stmts []Node) {
+	if len(stmts) == 0 {
+		c.emit(OpConst, c.constant(nil), 0, 0)
+		c.result()
+		return
+	}
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+	}
+}
+
+func (c *compiler) stmt(n Node) {
+	switch s := n.(type) {
+	case *ExprStatement:
+		c.expr(s.Expr)
+		c.result()
+	case *LetStatement:
+		c.exprOrNil(s.Value)
+		c.checkType(s.Name, s.Type, s.Value)
+		c.declare(OpLet, s.Name)
+		c.emit(OpConst, c.constant(nil), 0, 0)
+		c.result()
+	case *MultipleLetStatement:
+		if len(s.Declarations) == 0 {
+			c.emit(OpConst, c.constant(nil), 0, 0)
+		}
+		for i, decl := range s.Declarations {
+			c.exprOrNil(decl.Value)
+			c.checkType(decl.Name, decl.Type, decl.Value)
+			if i == len(s.Declarations)-1 {
+				c.emit(OpDup, 0, 0, 0) // El valor de la sentencia es el del último
+			}
+			c.declare(OpLet, decl.Name)
+		}
+		c.result()
+	case *ConstStatement:
+		c.exprOrNil(s.Value)
+		c.checkType(s.Name, s.Type, s.Value)
+		c.declare(OpConstDecl, s.Name)
+		c.emit(OpConst, c.constant(nil), 0, 0)
+		c.result()
+	case *MultipleConstStatement:
+		for _, decl := range s.Declarations {
+			c.exprOrNil(decl.Value)
+			c.checkType(decl.Name, decl.Type, decl.Value)
+			c.declare(OpConstDecl, decl.Name)
+		}
+		c.emit(OpConst, c.constant(nil), 0, 0)
+		c.result()
+	case *GenericAssignStatement:
+		c.assign(s)
+	case *BlockStatement:
+		c.block(s.Statements)
+	case *IfStatement:
+		c.expr(s.Condition)
+		jumpElse := c.emit(OpJumpFalse, 0, 0, 0)
+		c.block(s.Consequence.Statements)
+		jumpEnd := c.emit(OpJump, 0, 0, 0)
+		c.patch(jumpPatch{jumpElse, 'A'}, c.here())
+		if s.Alternative != nil {
+			c.block(s.Alternative.Statements)
+		} else {
+			c.emit(OpConst, c.constant(nil), 0, 0)
+			c.result()
+		}
+		c.patch(jumpPatch{jumpEnd, 'A'}, c.here())
+	case *WhileStatement:
+		c.emit(OpLoop, loopWhile, 0, 0)
+		top := c.here()
+		c.emit(OpLoopCheck, 0, 0, 0)
+		c.expr(s.Condition)
+		exit := c.emit(OpJumpFalse, 0, 0, 0)
+		c.emit(OpLoopCount, 0, 0, 0)
+		c.loopBody(s.Body, exit, top)
+	case *ForStatement:
+		c.forStmt(s)
+	case *ReturnStatement:
+		if s.tail {
+			c.tailExpr(s.Value)
+		} else {
+			c.exprOrNil(s.Value)
+		}
+		c.emit(OpReturn, 0, 0, 0)
+	case *BreakStatement:
+		if len(c.loops) == 0 {
+			c.outsideLoop(c.emit(OpBreak, 0, 0, 0))
+			return
+		}
+		loop := c.loops[len(c.loops)-1]
+		loop.breaks = append(loop.breaks, jumpPatch{c.emit(OpJump, 0, 0, 0), 'A'})
+	case *ContinueStatement:
+		if len(c.loops) == 0 {
+			c.outsideLoop(c.emit(OpContinue, 0, 0, 0))
+			return
+		}
+		loop := c.loops[len(c.loops)-1]
+		loop.continues = append(loop.continues, jumpPatch{c.emit(OpJump, 0, 0, 0), 'A'})
+	default:
+		c.emit(OpEval, c.node(c.rewrite(n)), 0, 0)
+		c.result()
+	}
+}
+
+// loopBody compila el cuerpo de un bucle y su cierre. exit es el salto (ya
+// emitido) que sale del bucle y top el inicio de la siguiente iteración, que
+// es también el destino de continue salvo que haya post (el del for), que se
+// ejecuta antes de volver a top.
+func (c *compiler) loopBody(body *BlockStatement, exit int, top int, post ...Node) {
+	cont := top
+	loop := &loopLabels{}
+	c.loops = append(c.loops, loop)
+	c.block(body.Statements)
+	c.loops = c.loops[:len(c.loops)-1]
+
+	c.emit(OpLoopSave, 0, 0, 0)
+	if len(post) > 0 {
+		cont = c.here()
+		c.discarding(post[0])
+	}
+	c.emit(OpJump, top, 0, 0)
+	end := c.here()
+	c.emit(OpLoopEnd, 0, 0, 0)
+
+	if c.out.Code[exit].Op == OpIterNext {
+		c.patch(jumpPatch{exit, 'B'}, end)
+	} else {
+		c.patch(jumpPatch{exit, 'A'}, end)
+	}
+	for _, p := range loop.breaks {
+		c.patch(p, end)
+	}
+	for _, p := range loop.continues {
+		c.patch(p, cont)
+	}
+}
+
+func (c *compiler) forStmt(s *ForStatement) {
+	if s.inFlag {
+		c.emit(OpLoop, loopForIn, 0, 0)
+		c.emit(OpIterInit, c.node(s), 0, 0)
+		top := c.here()
+		next := c.emit(OpIterNext, c.node(s), 0, 0)
+		c.emit(OpLoopCount, 0, 0, 0)
+		c.loopBody(s.Body, next, top)
+		return
+	}
+
+	c.out.Scopes = append(c.out.Scopes, s.scope)
+	first := 0
+	if c.frame != nil {
+		if s.scope == nil {
+			c.frameOK = false
+		} else {
+			// En un frame, las variables del for son más slots locales
+			first = c.out.Locals
+			c.out.Locals += len(s.scope.names)
+			c.frameSlots[s.scope] = first
+			outer := c.frameScope
+			c.frameScope = s.scope
+			defer func() { c.frameScope = outer }()
+		}
+	}
+	c.emit(OpEnterScope, len(c.out.Scopes)-1, first, 0)
+	if s.Init != nil {
+		c.discarding(s.Init)
+	}
+	c.emit(OpLoop, loopFor, 0, 0)
+	top := c.here()
+	c.emit(OpLoopCheck, 0, 0, 0)
+	c.expr(s.Condition)
+	exit := c.emit(OpJumpFalse, 0, 0, 0)
+	c.emit(OpLoopCount, 0, 0, 0)
+	if s.Post != nil {
+		c.loopBody(s.Body, exit, top, s.Post)
+	} else {
+		c.loopBody(s.Body, exit, top)
+	}
+	c.emit(OpExitScope, 0, 0, 0)
+}
+
+// discarding compila una sentencia cuyo valor ignora el intérprete (init y
+// post del for).
+func (c *compiler) discarding(n Node) {
+	loops, discard := c.loops, c.discard
+	c.loops, c.discard = nil, true
+	c.stmt(n)
+	c.loops, c.discard = loops, discard
+}
+
+func (c *compiler) assign(s *GenericAssignStatement) {
+	switch left := s.Left.(type) {
+	case *Identifier:
+		c.expr(s.Right)
+		if slot, ok := c.local(left); ok {
+			c.frameWritten[left.Name] = true
+			c.emit(OpSetLocal, c.node(left), slot, 0)
+		} else {
+			c.emit(OpUpdate, c.node(left), 0, 0)
+		}
+	case *AccessExpression:
+		c.expr(s.Right)
+		c.expr(left.Object)
+		c.emit(OpSetMember, c.node(left), 0, 0)
+	case *IndexExpression:
+		c.expr(s.Right)
+		c.emit(OpSetIndex, c.node(left), 0, 0)
+	default:
+		c.emit(OpEval, c.node(s), 0, 0)
+	}
+	c.result()
+}
+
+func (c *compiler) exprOrNil(n Node) {
+	if n == nil {
+		c.emit(OpConst, c.constant(nil), 0, 0)
+		return
+	}
+	c.expr(n)
+}
+
+// tailExpr compila el valor de un return en posición de cola: la llamada en
+// la que termina es un OpTailCall (ver evalTail).
+func (c *compiler) tailExpr(n Node) {
+	switch e := n.(type) {
+	case *CallExpression:
+		c.expr(e.Callee)
+		for _, arg := range e.Args {
+			c.expr(arg)
+		}
+		c.emit(OpTailCall, c.node(e), len(e.Args), 0)
+	case *TernaryExpression:
+		c.expr(e.Condition)
+		jumpElse := c.emit(OpJumpFalse, 0, 0, 0)
+		c.tailExpr(e.TrueExpr)
+		jumpEnd := c.emit(OpJump, 0, 0, 0)
+		c.patch(jumpPatch{jumpElse, 'A'}, c.here())
+		c.tailExpr(e.FalseExpr)
+		c.patch(jumpPatch{jumpEnd, 'A'}, c.here())
+	default:
+		c.expr(n)
+	}
+}
+
+func (c *compiler) expr(n Node) {
+	switch e := n.(type) {
+	case *NumberLiteral:
+		c.emit(OpConst, c.constant(e.Value), 0, 0)
+	case *StringLiteral:
+		c.emit(OpConst, c.constant(e.Value), 0, 0)
+	case *BooleanLiteral:
+		c.emit(OpConst, c.constant(e.Value), 0, 0)
+	case *NilLiteral:
+		c.emit(OpConst, c.constant(nil), 0, 0)
+	case *Identifier:
+		if slot, ok := c.local(e); ok {
+			c.emit(OpGetLocal, c.node(e), slot, 0)
+		} else {
+			c.emit(OpGet, c.node(e), 0, 0)
+		}
+	case *BinaryExpression:
+		c.binary(e)
+	case *UnaryExpression:
+		c.expr(e.Right)
+		c.emit(OpUnary, c.node(e), 0, 0)
+	case *CallExpression:
+		c.expr(e.Callee)
+		for _, arg := range e.Args {
+			c.expr(arg)
+		}
+		c.emit(OpCall, c.node(e), len(e.Args), directCall(e))
+	case *AccessExpression:
+		c.expr(e.Object)
+		c.emit(OpAccess, c.node(e), 0, 0)
+	case *IndexExpression:
+		c.expr(e.Left)
+		c.expr(e.Index)
+		c.emit(OpIndex, c.node(e), 0, 0)
+	case *TernaryExpression:
+		c.expr(e.Condition)
+		jumpElse := c.emit(OpJumpFalse, 0, 0, 0)
+		c.expr(e.TrueExpr)
+		jumpEnd := c.emit(OpJump, 0, 0, 0)
+		c.patch(jumpPatch{jumpElse, 'A'}, c.here())
+		c.expr(e.FalseExpr)
+		c.patch(jumpPatch{jumpEnd, 'A'}, c.here())
+	case *ArrayLiteral:
+		for _, el := range e.Elements {
+			c.expr(el)
+		}
+		c.emit(OpArray, 0, len(e.Elements), 0)
+	case *MapLiteral:
+		// MapLiteral.Eval evalúa cada clave después de su valor; compilar
+		// primero todos los valores sólo es equivalente si las claves son
+		// literales.
+		for _, pair := range e.Pairs {
+			switch pair.Key.(type) {
+			case *StringLiteral, *NumberLiteral, *BooleanLiteral:
+			default:
+				c.emit(OpEval, c.node(c.rewrite(e)), 0, 0)
+				return
+			}
+		}
+		for _, pair := range e.Pairs {
+			c.expr(pair.Value)
+		}
+		c.emit(OpMap, c.node(e), len(e.Pairs), 0)
+	default:
+		c.emit(OpEval, c.node(c.rewrite(n)), 0, 0)
+	}
+}
+
+// directCall devuelve 1 si la llamada ce puede ser una llamada directa a una
+// función que corre como frame (ver OpCall): no es a super ni tiene spreads.
+func directCall(ce *CallExpression) int {
+	if ce.superCall() {
+		return 0
+	}
+	for _, arg := range ce.Args {
+		if _, spread := arg.(*SpreadExpression); spread {
+			return 0
+		}
+	}
+	return 1
+}
+
+func (c *compiler) binary(e *BinaryExpression) {
+	// Igual que tryFastArithmetic: dos literales numéricos se resuelven en el
+	// momento (salvo la división por cero, que debe fallar al ejecutar).
+	if _, ok := e.Left.(*NumberLiteral); ok {
+		if r, ok := e.Right.(*NumberLiteral); ok && !(e.Op == "/" && r.Value == 0) && !(e.Op == "%" && int(r.Value) == 0) {
+			if v := e.tryFastArithmetic(nil); v != nil {
+				c.emit(OpConst, c.constant(v), 0, 0)
+				return
+			}
+		}
+	}
+
+	c.expr(e.Left)
+	switch e.Op {
+	case "&&", "||":
+		op := OpJumpFalse
+		if e.Op == "||" {
+			op = OpJumpTrue
+		}
+		short := c.emit(op, 0, 0, 0)
+		c.expr(e.Right)
+		c.emit(OpToBool, 0, 0, 0)
+		end := c.emit(OpJump, 0, 0, 0)
+		c.patch(jumpPatch{short, 'A'}, c.here())
+		c.emit(OpConst, c.constant(e.Op == "||"), 0, 0)
+		c.patch(jumpPatch{end, 'A'}, c.here())
+	case "??":
+		end := c.emit(OpJumpNotNil, 0, 0, 0)
+		c.expr(e.Right)
+		c.patch(jumpPatch{end, 'A'}, c.here())
+	case "|>":
+		c.expr(e.Right)
+		c.emit(OpPipe, c.node(e), 0, 0)
+	default:
+		c.expr(e.Right)
+		c.emit(OpBinary, c.node(e), int(floatOps[e.Op]), 0)
+	}
+}
+
+// rewrite devuelve n listo para OpEval: las funciones, métodos y bloques que
+// contiene directamente se reemplazan por copias con el cuerpo compilado. Los
+// nodos originales no se modifican.
+func (c *compiler) rewrite(n Node) Node {
+	switch s := n.(type) {
+	case *FunctionDeclaration:
+		fd := *s
+		fd.Body = c.function(s.Params, s.Args, s.Body)
+		return &fd
+	case *FunctionLiteral:
+		fl := *s
+		fl.Body = c.function(s.Params, s.Args, s.Body)
+		return &fl
+	case *ArrowFunction:
+		body := s.createBody()
+		return &ArrowFunction{Params: s.Params, Body: c.function(s.Params, nil, body), IsExpression: false, Async: s.Async, scope: s.scope}
+	case *TryStatement:
+		ts := *s
+		ts.Body = c.body(s.Body)
+		ts.Catches = make([]*CatchClause, len(s.Catches))
+		for i, cc := range s.Catches {
+			clause := *cc
+			clause.Body = c.body(cc.Body)
+			ts.Catches[i] = &clause
+		}
+		ts.FinallyBlock = c.body(s.FinallyBlock)
+		return &ts
+	case *SwitchStatement:
+		ss := *s
+		ss.Cases = make([]SwitchCase, len(s.Cases))
+		for i, sc := range s.Cases {
+			sc.Body = c.body(sc.Body)
+			ss.Cases[i] = sc
+		}
+		return &ss
+	case *ObjectDeclaration:
+		od := *s
+		od.Members = make([]Node, len(s.Members))
+		method := c.method
+		c.method = true
+		for i, m := range s.Members {
+			od.Members[i] = c.rewrite(m)
+		}
+		c.method = method
+		return &od
+	case *StaticMember:
+		return &StaticMember{Member: c.rewrite(s.Member)}
+	case *ExportStatement:
+		return &ExportStatement{Declaration: c.rewrite(s.Declaration)}
+	case *AccessorDeclaration:
+		return &AccessorDeclaration{Kind: s.Kind, Func: c.rewrite(s.Func).(*FunctionDeclaration)}
+	case *InterfaceDeclaration:
+		id := *s
+		id.Methods = make([]*FunctionDeclaration, len(s.Methods))
+		method := c.method
+		c.method = true
+		defer func() { c.method = method }()
+		for i, m := range s.Methods {
+			if m.Body != nil {
+				m = c.rewrite(m).(*FunctionDeclaration)
+			}
+			id.Methods[i] = m
+		}
+		return &id
+	}
+	return n
+}
@@ -0,0 +1,323 @@
+package r2core
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runBoth ejecuta input con el intérprete de árbol y con la VM (pasando por el
+// formato .r2c), y devuelve lo que cada uno registró con log(...) más el valor
+// final (o el panic).
+func runBoth(t *testing.T, input string) (tree, vm string) {
+	t.Helper()
+	run := func(compiled bool) (out string) {
+		var sb strings.Builder
+		env := newTestEnv()
+		env.Set("log", BuiltinFunction(func(args ...interface{}) interface{} {
+			sb.WriteString(fmt.Sprint(args...) + "\n")
+			return nil
+		}))
+		defer func() {
+			if r := recover(); r != nil {
+				sb.WriteString(fmt.Sprintf("panic: %v", r))
+			}
+			out = sb.String()
+		}()
+		prog := NewParser(input).ParseProgram()
+		var result interface{}
+		if compiled {
+			data, err := EncodeBytecode(Compile(prog))
+			if err != nil {
+				t.Fatalf("EncodeBytecode: %v", err)
+			}
+			code, err := DecodeBytecode(data)
+			if err != nil {
+				t.Fatalf("DecodeBytecode: %v", err)
+			}
+			result = code.Eval(env)
+		} else {
+			result = prog.Eval(env)
+		}
+		sb.WriteString(fmt.Sprintf("=> %v", result))
+		return
+	}
+	return run(false), run(true)
+}
+
+// compileCases cubre cada forma que el compilador traduce de manera distinta.
+var compileCases = map[string]string{
+	"arithmetic":     `let x = 2 * 3 + 4; let y = x % 4; log(x, y, 10 / 4, 1 - -2, ~5, 1 << 3)`,
+	"folding":        `log(1 + 2, 7 % 2, 3 < 4)`,
+	"strings":        `let s = "a" + 1 + "b"; log(s, s.length)`,
+	"logical":        `log(true && 0, nil || "x", 0 ?? 5, nil ?? 6, !1)`,
+	"short circuit":  `func f() { log("called"); return true } log(false && f(), true || f())`,
+	"ternary":        `let a = 3; log(a > 2 ? "big" : "small")`,
+	"if else":        `let r = 0; if (r) { log("yes") } else if (r == 0) { log("zero") } else { log("no") }`,
+	"while":          `let i = 0; while (i < 5) { i++; if (i == 2) { continue } if (i == 4) { break } log(i) }`,
+	"for":            `let acc = []; for (let i = 0; i < 5; i++) { if (i == 1) { continue } acc.push(i) } log(acc)`,
+	"for no post":    `for (let i = 0; i < 3;) { log(i); i = i + 1 }`,
+	"for scope":      `let i = 10; for (let i = 0; i < 2; i++) { } log(i)`,
+	"for in":         `let xs = [3, 4, 5]; for (i in xs) { if (i == 1) { continue } log(i, $v) }`,
+	"for in map":     `let m = {a: 1}; for (k in m) { log(k, $v) }`,
+	"nested loops":   `for (let i = 0; i < 3; i++) { for (let j = 0; j < 3; j++) { if (j == 1) { break } log(i, j) } }`,
+	"functions":      `func add(a, b = 10) { return a + b } log(add(1), add(1, 2))`,
+	"implicit value": `func f(x) { if (x) { "yes" } else { "no" } } log(f(1), f(0))`,
+	"closures":       `func counter() { let n = 0; return () => { n = n + 1; return n } } let c = counter(); c(); log(c())`,
+	"arrows":         `let sq = x => x * x; log([1, 2, 3].map(sq), sq(4))`,
+	"recursion":      `func fib(n) { if (n < 2) { return n } return fib(n - 1) + fib(n - 2) } log(fib(15))`,
+	"maps":           `let m = {a: 1, "b": 2}; m.c = 3; m["d"] = 4; log(m.a + m.b + m.c + m["d"])`,
+	"computed keys":  `let k = "z"; let m = {[k]: 1}; log(m.z)`,
+	"spread":         `let a = [1, 2]; let b = [...a, 3]; let m = {...{x: 1}, y: 2}; log(b, m.x, m.y)`,
+	"arrays":         `let a = [1, 2, 3]; a[0] = 9; log(a[0], a[-1], [])`,
+	"classes":        `class P { let n; constructor(n) { this.n = n } hi() { return "hi " + this.n } } let p = P("a"); log(p.hi())`,
+	"inheritance":    `class A { f() { return "a" } } class B extends A { g() { return this.f() + "b" } } log(B().g())`,
+	"try catch":      `func f() { try { throw "boom" } catch (e) { return "caught " + e } } log(f())`,
+	"try return":     `func f() { for (let i = 0; i < 5; i++) { try { if (i == 2) { return i } } catch (e) {} } return -1 } log(f())`,
+	"match":          `let v = 2; log(match v { case 1 => "one" case 2 => "two" case _ => "other" })`,
+	"template":       "let name = \"x\"; log(`hi ${name}`)",
+	"comprehension":  `log([x * 2 for x in [1, 2, 3] if x > 1])`,
+	"const":          `const c = 1; log(c); c = 2`,
+	"multiple let":   `let a = 1, b = 2; log(a + b)`,
+	"undeclared":     `log(1); log(nope)`,
+	"division zero":  `let z = 0; log(1 / z)`,
+	"pipeline":       `func double(x) { return x * 2 } log(3 |> double)`,
+	"placeholder":    `func add(a, b) { return a + b } let inc = add(1, _); log(inc(2))`,
+	"return top":     `log(1); return 5; log(2)`,
+	"break top":      `if (true) { break; log("skipped") } log("after")`,
+	"break in func":  `func f() { break; log("no") } log(f())`,
+	"loop value":     `func f() { let i = 0; while (i < 3) { i = i + 1 } } log(f())`,
+	"break value":    `func g() { break } let i = 0; while (true) { i++; g() } log(i)`,
+	"destructuring":  `let [a, b] = [1, 2]; let {x} = {x: 3}; log(a, b, x)`,
+	"optional":       `let m = nil; log(m?.a)`,
+	"dates":          `let d = @2024-01-02; log(d.year())`,
+	"generators":     `func* count(n) { let i = 0; while (i < n) { yield i; i = i + 1 } } for (x in count(3)) { log(x); if (x == 1) { break } } func f() { for (x in count(5)) { if (x == 2) { return x } } } log(f(), [...count(2)])`,
+	"exact numbers":  `let price = 12.50d; let n = 10n; log(price * 3, price / 4, n * n * n, 9007199254740993 + 1, -n, price > 12, 7n % 3, 1n << 70)`,
+	"switch":         `func kind(v) { switch (v) { case 1, 2: return "small" case [a, b] if a > b: return "desc" case {name}: return name default: return "other" } } let i = 0; while (i < 5) { i = i + 1; switch (i) { case 2: continue case 4: break default: log(i) } } log(kind(2), kind([3, 1]), kind({name: "n"}), kind(7))`,
+	"enums":          `enum Status { Active, Suspended = "S" } func label(s) { return match s { case Status.Active => "on" case Status.Suspended => "off" } } for (s in Status) { log(s, s.ordinal, s.value, label(s)) } log(Status.valueOf("Active") == Status.Active, Status["Suspended"].name)`,
+	"class members":  `class Shape { let #id = 0; static let count = 0; static next() { this.count = this.count + 1; return this.count } constructor() { this.#id = Shape.next() } get id() { return this.#id } set id(v) { this.#id = v } } class Sq extends Shape {} let s = Sq(); s.id = s.id * 10; log(s.id, Shape.count, s instanceof Shape, Shape() instanceof Sq); try { log(s.#id) } catch (e) { log("private") }`,
+	"interfaces":     `trait Named { name(); greet() { return "hi " + this.name() } } interface Sized { size() } class Box implements Named, Sized { name() { return "box" } size() { return 2 } } let b = Box(); log(b.greet(), b.size(), b instanceof Named); try { class Bad implements Sized {} } catch (e) { log("missing") }`,
+	"exports":        `export func twice(x) { return x * 2 } export const base = 3, step = 1; export class Box { let v = 1 } log(twice(base), step, Box().v)`,
+	"types":          `func add(a: number, b?: number = 1): number { return a + b } let xs: number[] = [add(1), add(2, 3)]; const s: string | nil = nil; let f = (x: number) => x * 2; log(xs, s, f(2))`,
+	"exceptions":     `class Oops extends Error { let name = "Oops" } func f(x) { if (x > 1) { throw Oops("big", x) } throw x } for (v in [1, 2]) { try { f($v) } catch (e: Oops) { log(e.name, e.message, e.cause, e.position.line, "" + e) } catch (e: number) { log("number", e) } } try { missing } catch (e: ReferenceError) { log(e.message) }`,
+	"frames":         `func sum(n) { let t = 0; for (let i = 0; i < n; i++) { let sq = i * i; t = t + sq } return t } func pt(x) { let y = x * 2; return {x: x, y: y} } func make(n) { let k = n; return Box(k) } class Box { let v; constructor(v) { this.v = v } } func rec(n, acc) { if (n == 0) { return acc } return rec(n - 1, acc + n) } func few(a, b) { return [a, b] } log(sum(4), pt(3).y, make(5).v, rec(100, 0), few(1))`,
+	"frame errors":   `func dup() { for (let i = 0; i < 2; i++) { const c = i } const d = 1; let d = 2 } try { dup() } catch (e) { log("dup") } func f(x) { x = x + 1; return y } let y = 7; log(f(1)); func g() { return zz } log(g())`,
+	"async":          `async func f(x) { if (x < 0) { throw "neg" } return x * 2 } let g = async x => x + 1; log(await f(2), await g(1)); try { await f(-1) } catch (e) { log("caught " + e) }`,
+}
+
+func TestCompile_SameResultAsTreeWalker(t *testing.T) {
+	for name, input := range compileCases {
+		t.Run(name, func(t *testing.T) {
+			tree, vm := runBoth(t, input)
+			if tree != vm {
+				t.Errorf("tree-walker and VM differ\ntree:\n%s\nvm:\n%s", tree, vm)
+			}
+		})
+	}
+}
+
+func TestCompile_CompilesFunctionBodies(t *testing.T) {
+	prog := NewParser(`func f(a) { return a + 1 } let g = x => x`).ParseProgram()
+	code := Compile(prog)
+
+	env := NewEnvironment()
+	code.Eval(env)
+	for _, name := range []string{"f", "g"} {
+		val, _ := env.Get(name)
+		fn, ok := val.(*UserFunction)
+		if !ok {
+			t.Fatalf("%s: expected *UserFunction, got %T", name, val)
+		}
+		if _, ok := fn.Body.Statements[0].(*CompiledCode); !ok || len(fn.Body.Statements) != 1 {
+			t.Errorf("%s: expected a compiled body, got %#v", name, fn.Body.Statements)
+		}
+	}
+	// Los nodos originales no se modifican
+	if _, ok := prog.Statements[0].(*FunctionDeclaration).Body.Statements[0].(*CompiledCode); ok {
+		t.Error("Compile modified the original function declaration")
+	}
+}
+
+// Las funciones sin métodos, defaults ni entornos propios se compilan como
+// frames: sus variables locales, también las de sus for, son slots de la pila
+// de la VM.
+func TestCompile_FunctionFrames(t *testing.T) {
+	prog := NewParser(`func f(n) { let t = 0; for (let i = 0; i < n; i++) { t = t + i } return t } func g(a = 1) { return a } func h() { return [x * 2 for x in [1]] }`).ParseProgram()
+	code := Compile(prog)
+
+	env := NewEnvironment()
+	code.Eval(env)
+	frames := map[string]bool{"f": true, "g": false, "h": false}
+	for name, want := range frames {
+		val, _ := env.Get(name)
+		fn := val.(*UserFunction)
+		if got := fn.frameCode() != nil; got != want {
+			t.Errorf("%s: frame = %v, want %v", name, got, want)
+		}
+	}
+	f, _ := env.Get("f")
+	if cc := f.(*UserFunction).frameCode(); cc.Locals != 3 {
+		t.Errorf("f: expected 3 locals (n, t, i), got %d", cc.Locals)
+	}
+	if got := f.(*UserFunction).Call(float64(5)); got != float64(10) {
+		t.Errorf("f(5) = %v, want 10", got)
+	}
+}
+
+// Los cuerpos compilados y los for conservan los slots de Resolve también
+// después de pasar por el formato .r2c.
+func TestBytecode_KeepsSlots(t *testing.T) {
+	data, err := EncodeBytecode(Compile(NewParser(`func f(a) { let s = 0; for (let i = 0; i < a; i++) { s = s + i } return s }`).ParseProgram()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := DecodeBytecode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnvironment()
+	code.Eval(env)
+	val, _ := env.Get("f")
+	body := val.(*UserFunction).Body
+	if body.scope == nil || len(body.scope.names) != 2 {
+		t.Fatalf("expected the layout [a s], got %#v", body.scope)
+	}
+	cc := body.Statements[0].(*CompiledCode)
+	if len(cc.Scopes) != 1 || cc.Scopes[0] == nil || cc.Scopes[0].parent != body.scope {
+		t.Fatalf("expected the layout of the for inside the one of f, got %#v", cc.Scopes)
+	}
+	for _, n := range cc.Nodes {
+		if id, ok := n.(*Identifier); ok && id.ref == nil {
+			t.Errorf("%s was not resolved", id.Name)
+		}
+	}
+	if got := val.(*UserFunction).Call(float64(5)); got != float64(10) {
+		t.Errorf("expected 10, got %v", got)
+	}
+}
+
+func TestCompile_LoopLimits(t *testing.T) {
+	prog := NewParser(`let i = 0; while (true) { i = i + 1 }`).ParseProgram()
+	code := Compile(prog)
+
+	env := NewEnvironment()
+	env.GetLimiter().MaxIterations = 100
+	defer func() {
+		r := recover()
+		err, ok := r.(*InfiniteLoopError)
+		if !ok {
+			t.Fatalf("expected *InfiniteLoopError, got %T: %v", r, r)
+		}
+		if err.Type != "while" {
+			t.Errorf("expected a while loop error, got %q", err.Type)
+		}
+		if i, _ := env.Get("i"); i != float64(100) {
+			t.Errorf("expected 100 iterations, got %v", i)
+		}
+	}()
+	code.Eval(env)
+}
+
+func TestBytecode_Deterministic(t *testing.T) {
+	src := `let m = match {a: 1} { case {a, b} => a case _ => 0 }; let d = @2024-01-02T10:00:00Z`
+	first, err := EncodeBytecode(Compile(NewParser(src).ParseProgram()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		again, _ := EncodeBytecode(Compile(NewParser(src).ParseProgram()))
+		if string(again) != string(first) {
+			t.Fatal("encoding the same program twice produced different bytes")
+		}
+	}
+}
+
+func TestBytecode_DecodeErrors(t *testing.T) {
+	valid, err := EncodeBytecode(Compile(NewParser(`let x = 1; log(x)`).ParseProgram()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		data []byte
+		want string
+	}{
+		"not bytecode": {[]byte("let x = 1"), "not an R2Lang bytecode file"},
+		"version":      {append([]byte("R2C"), 99), "unsupported bytecode version 99"},
+		"truncated":    {valid[:len(valid)-3], "corrupt bytecode file"},
+		"trailing":     {append(append([]byte{}, valid...), 0), "trailing bytes"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeBytecode(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// DecodeBytecode rechaza el código que la VM no puede ejecutar.
+func TestBytecode_VerifiesOperands(t *testing.T) {
+	get := &Identifier{Name: "x"}
+	tests := map[string]struct {
+		code *CompiledCode
+		want string
+	}{
+		"constant":       {&CompiledCode{Code: []Instr{{Op: OpConst, A: 1}}, Consts: []interface{}{nil}}, "invalid constant 1"},
+		"name":           {&CompiledCode{Code: []Instr{{Op: OpConst}, {Op: OpLet, A: 3}}, Consts: []interface{}{nil}}, "invalid name 3"},
+		"node type":      {&CompiledCode{Code: []Instr{{Op: OpConst}, {Op: OpConst}, {Op: OpBinary}}, Consts: []interface{}{nil}, Nodes: []Node{get}}, "invalid node 0"},
+		"jump":           {&CompiledCode{Code: []Instr{{Op: OpJump, A: 5}}}, "jump to 5 out of range"},
+		"backward jump":  {&CompiledCode{Code: []Instr{{Op: OpGet}, {Op: OpPop}, {Op: OpJump, A: 0}}, Nodes: []Node{get}}, "backward jump to 0"},
+		"underflow":      {&CompiledCode{Code: []Instr{{Op: OpGet}, {Op: OpArray, B: 2}}, Nodes: []Node{get}}, "stack underflow"},
+		"inconsistent":   {&CompiledCode{Code: []Instr{{Op: OpGet}, {Op: OpJumpFalse, A: 3}, {Op: OpGet}, {Op: OpPop}}, Nodes: []Node{get}}, "inconsistent stack at 3"},
+		"loop":           {&CompiledCode{Code: []Instr{{Op: OpLoopEnd}}}, "no open loop"},
+		"scope":          {&CompiledCode{Code: []Instr{{Op: OpEnterScope, A: 0}}}, "invalid scope 0"},
+		"check type":     {&CompiledCode{Code: []Instr{{Op: OpGet}, {Op: OpCheckType}}, Nodes: []Node{get}}, "invalid node 0"},
+		"unknown opcode": {&CompiledCode{Code: []Instr{{Op: OpCheckType + 1}}}, "unknown opcode"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := EncodeBytecode(tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = DecodeBytecode(data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// Un .r2c con cualquier byte cambiado se rechaza al cargarlo o se ejecuta
+// sin que la VM falle con un error de Go ni se cuelgue.
+func TestBytecode_MutatedFiles(t *testing.T) {
+	src := `func f(n) { let s = 0; for (let i = 0; i < n; i++) { if (i == 2) { continue } s = s + i } return s } let m = {a: 1}; for (x in [1, 2]) { m.a = m.a + x } log(f(10), m.a && true, nil ?? 3)`
+	valid, err := EncodeBytecode(Compile(NewParser(src).ParseProgram()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := len(bytecodeMagic); i < len(valid); i++ {
+		for _, b := range []byte{0, 1, 2, 0x7f, 0x80, 0xff, valid[i] + 1, valid[i] - 1} {
+			data := append([]byte{}, valid...)
+			data[i] = b
+			code, err := DecodeBytecode(data)
+			if err != nil {
+				continue
+			}
+			func() {
+				defer func() {
+					if r, ok := recover().(runtime.Error); ok {
+						t.Errorf("byte %d set to %d: %v", i, b, r)
+					}
+				}()
+				env := newTestEnv()
+				env.Set("log", BuiltinFunction(func(args ...interface{}) interface{} { return nil }))
+				env.GetLimiter().SetLimits(1000, 100, time.Second)
+				code.Eval(env)
+			}()
+		}
+	}
+}
@@ -9,23 +9,33 @@ type CallExpression struct {
 }
 
 func (ce *CallExpression) Eval(env *Environment) interface{} {
-	flagSuper := false
-	if o := ce.Callee; o != nil {
-		if ae, ok := o.(*AccessExpression); ok {
-			if id, ok := ae.Object.(*Identifier); ok {
-				if id.Name == "super" {
-					flagSuper = true
-				}
-			}
-		}
-	}
-
 	calleeVal := ce.Callee.Eval(env)
+	return ce.call(env, calleeVal, ce.args(env))
+}
 
+// args evalúa los argumentos de la llamada.
+func (ce *CallExpression) args(env *Environment) []interface{} {
 	var argVals []interface{}
 	for _, a := range ce.Args {
 		argVals = append(argVals, a.Eval(env))
 	}
+	return argVals
+}
+
+// superCall indica si la llamada es super.método(...).
+func (ce *CallExpression) superCall() bool {
+	if ae, ok := ce.Callee.(*AccessExpression); ok {
+		if id, ok := ae.Object.(*Identifier); ok {
+			return id.Name == "super"
+		}
+	}
+	return false
+}
+
+// call invoca calleeVal con argumentos ya evaluados (compartido con la VM).
+func (ce *CallExpression) call(env *Environment, calleeVal interface{}, argVals []interface{}) interface{} {
+	flagSuper := ce.superCall()
+
 	// Expandir spreads en argumentos si los hay
 	argVals = ExpandSpreadInFunctionCall(argVals)
 
@@ -39,13 +49,14 @@ func (ce *CallExpression) Eval(env *Environment) interface{} {
 			if ce.Position != nil && env.CurrentFile != "" {
 				ce.Position.Filename = env.CurrentFile
 			}
-			PanicWithStack(ce.Position, "Cannot create partial application with non-function value ["+fmt.Sprintf("%T", ce.Callee)+"]", env.callStack)
+			PanicWithClass("TypeError", ce.Position, "Cannot create partial application with non-function value ["+fmt.Sprintf("%T", ce.Callee)+"]", env.callStack)
 			return nil
 		}
 	}
 
 	switch cv := calleeVal.(type) {
 	case BuiltinFunction:
+		defer locateError(env, ce.Position)
 		return cv(argVals...)
 	case *UserFunction:
 		if flagSuper {
@@ -70,11 +81,14 @@ func (ce *CallExpression) Eval(env *Environment) interface{} {
 	case map[string]interface{}:
 		// Instanciar un blueprint
 		return instantiateObject(env, cv, argVals)
+	case *CollectionType:
+		return cv.New(argVals...)
 	case func() interface{}:
 		// Handle Go native functions with no args
 		return cv()
 	case func(...interface{}) interface{}:
 		// Handle Go native functions with variable args
+		defer locateError(env, ce.Position)
 		return cv(argVals...)
 	case func(string) interface{}:
 		// Handle DSL use function with string argument
@@ -88,7 +102,7 @@ func (ce *CallExpression) Eval(env *Environment) interface{} {
 		if ce.Position != nil && env.CurrentFile != "" {
 			ce.Position.Filename = env.CurrentFile
 		}
-		PanicWithStack(ce.Position, "Attempt to call something that is neither a function nor a blueprint ["+fmt.Sprintf("%T", ce.Callee)+"]", env.callStack)
+		PanicWithClass("TypeError", ce.Position, "Attempt to call something that is neither a function nor a blueprint ["+fmt.Sprintf("%T", ce.Callee)+"]", env.callStack)
 		return nil
 	}
 }
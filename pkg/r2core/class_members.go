@@ -0,0 +1,162 @@
+package r2core
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// staticsKey es la clave del blueprint con los nombres de sus miembros
+// static. Esos miembros viven sólo en el blueprint: instantiateObject no los
+// copia a las instancias.
+const staticsKey = "$static"
+
+// StaticMember es un miembro de clase declarado con static:
+//
+//	class Counter {
+//	    static let count = 0
+//	    static create() { ... }
+//	}
+//
+// Member es un *LetStatement o un *FunctionDeclaration.
+type StaticMember struct {
+	Member Node
+}
+
+func (sm *StaticMember) Eval(env *Environment) interface{} {
+	panic("'static' is only allowed inside a class")
+}
+
+// AccessorDeclaration es un "get nombre() {...}" o un "set nombre(v) {...}"
+// dentro de una clase. Kind es GET o SET.
+type AccessorDeclaration struct {
+	Kind string
+	Func *FunctionDeclaration
+}
+
+func (ad *AccessorDeclaration) Eval(env *Environment) interface{} {
+	panic("'" + ad.Kind + "' accessors are only allowed inside a class")
+}
+
+// Accessor es una propiedad calculada: leerla llama a Get y asignarla llama
+// a Set. Cualquiera de los dos puede faltar.
+type Accessor struct {
+	Get *UserFunction
+	Set *UserFunction
+}
+
+// bindTo devuelve una copia del accessor con sus funciones ligadas a env.
+func (a *Accessor) bindTo(env *Environment) *Accessor {
+	return &Accessor{Get: a.Get.bindTo(env), Set: a.Set.bindTo(env)}
+}
+
+func (a *Accessor) get(member string) interface{} {
+	if a.Get == nil {
+		panic("Property " + member + " has a setter but no getter")
+	}
+	return a.Get.Call()
+}
+
+func (a *Accessor) set(member string, val interface{}) interface{} {
+	if a.Set == nil {
+		panic("Cannot assign to " + member + ": the property has a getter but no setter")
+	}
+	a.Set.Call(val)
+	return val
+}
+
+// statics devuelve el conjunto de miembros static del blueprint, creándolo
+// si hace falta.
+func statics(blueprint map[string]interface{}) map[string]bool {
+	s, ok := blueprint[staticsKey].(map[string]bool)
+	if !ok {
+		s = make(map[string]bool)
+		blueprint[staticsKey] = s
+	}
+	return s
+}
+
+// staticSet devuelve el conjunto de miembros static del blueprint sin
+// crearlo; puede ser nil.
+func staticSet(blueprint map[string]interface{}) map[string]bool {
+	s, _ := blueprint[staticsKey].(map[string]bool)
+	return s
+}
+
+// staticMethod liga fn al blueprint: dentro de un método static, this es la
+// clase.
+func staticMethod(fn *UserFunction, blueprint map[string]interface{}, env *Environment) *UserFunction {
+	classEnv := NewInnerEnv(env)
+	classEnv.Set("self", blueprint)
+	classEnv.Set("this", blueprint)
+	return fn.bindTo(classEnv)
+}
+
+// isPrivateName indica si member es un campo o método #privado.
+func isPrivateName(member string) bool {
+	return strings.HasPrefix(member, "#")
+}
+
+// checkPrivateAccess impide leer o escribir un miembro #privado de class
+// fuera de los métodos de esa clase (o de sus subclases y superclases).
+func checkPrivateAccess(env *Environment, class map[string]interface{}, member string) {
+	if !isPrivateName(member) || class == nil {
+		return
+	}
+	if self, ok := env.Get("this"); ok {
+		var selfClass map[string]interface{}
+		switch s := self.(type) {
+		case *ObjectInstance:
+			selfClass = s.Class
+		case map[string]interface{}:
+			selfClass = s
+		}
+		if selfClass != nil && (isSubclassOf(selfClass, class) || isSubclassOf(class, selfClass)) {
+			return
+		}
+	}
+	panic(fmt.Sprintf("Private member %s is not accessible outside class %v", member, class["ClassName"]))
+}
+
+// isSubclassOf indica si class es base o desciende de ella siguiendo la
+// cadena de super.
+func isSubclassOf(class, base map[string]interface{}) bool {
+	for class != nil {
+		if sameBlueprint(class, base) {
+			return true
+		}
+		class, _ = class["super"].(map[string]interface{})
+	}
+	return false
+}
+
+func sameBlueprint(a, b map[string]interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// instanceOf implementa "value instanceof Type": una instancia lo es de su
+// clase, de todas sus superclases y de toda interface cuyos métodos tiene
+// (aunque su clase no la declare), un miembro de enum lo es de su enum y una
+// colección nativa de su constructor.
+func instanceOf(value, class interface{}) bool {
+	switch c := class.(type) {
+	case map[string]interface{}:
+		if obj, ok := value.(*ObjectInstance); ok {
+			return isSubclassOf(obj.Class, c)
+		}
+		return false
+	case *InterfaceType:
+		if obj, ok := value.(*ObjectInstance); ok {
+			return c.implementedBy(obj.Class)
+		}
+		return false
+	case *EnumType:
+		if v, ok := value.(*EnumValue); ok {
+			return v.Enum == c
+		}
+		return false
+	case *CollectionType:
+		return c.is(value)
+	}
+	panic(fmt.Sprintf("Right-hand side of 'instanceof' is not a class, interface or enum: %T", class))
+}
@@ -0,0 +1,170 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClass_Accessors(t *testing.T) {
+	code := `
+		class Temp {
+			let celsius = 0
+			get fahrenheit() { return this.celsius * 9 / 5 + 32 }
+			set fahrenheit(f) { this.celsius = (f - 32) * 5 / 9 }
+			get label() { return this.celsius + "C" }
+		}
+		class Room extends Temp {
+			get label() { return "room " + this.celsius }
+		}
+		let t = Temp()
+		t.fahrenheit = 212
+		let r = Room()
+		r.fahrenheit = 50
+		[t.celsius, t.fahrenheit, t.label, r.label, r?.fahrenheit]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[100 212 100C room 10 50]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestClass_ReadOnlyAccessor(t *testing.T) {
+	code := `
+		class Box { get size() { return 1 } }
+		let b = Box()
+		b.size = 2
+	`
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(fmt.Sprint(r), "no setter") {
+			t.Errorf("expected a read-only error, got %v", r)
+		}
+	}()
+	evalCode(t, code)
+}
+
+func TestClass_StaticMembers(t *testing.T) {
+	code := `
+		class Counter {
+			static let created = 0
+			let id = 0
+			static create() {
+				this.created = this.created + 1
+				let c = Counter()
+				c.id = this.created
+				return c
+			}
+		}
+		class Sub extends Counter {}
+		let a = Counter.create()
+		let b = Counter.create()
+		let s = Sub.create()
+		[a.id, b.id, Counter.created, Sub.created, s.id]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[1 2 2 1 1]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestClass_StaticMembersAreNotCopiedToInstances(t *testing.T) {
+	code := `
+		class Config { static let defaults = 1 }
+		Config().defaults
+	`
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected the instance to not have the static field")
+		}
+	}()
+	evalCode(t, code)
+}
+
+func TestClass_PrivateMembers(t *testing.T) {
+	code := `
+		class Account {
+			let #balance = 0
+			deposit(n) { this.#balance = this.#check(n) + this.#balance }
+			#check(n) { if (n <= 0) { throw "invalid amount" } return n }
+			get balance() { return this.#balance }
+			equals(other) { return this.#balance == other.#balance }
+		}
+		class Savings extends Account {
+			bonus() { this.deposit(this.#balance / 10) }
+		}
+		let a = Account()
+		a.deposit(50)
+		let s = Savings()
+		s.deposit(100)
+		s.bonus()
+		[a.balance, s.balance, a.equals(Account())]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[50 110 false]" {
+		t.Errorf("unexpected result %s", got)
+	}
+
+	for _, outside := range []string{"a.#balance", "a.#balance = 1", "a.#check(1)", "a?.#balance"} {
+		t.Run(outside, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), "Private member") {
+					t.Errorf("expected a private access error, got %v", r)
+				}
+			}()
+			evalCode(t, "class Account { let #balance = 0\n #check(n) { return n } }\nlet a = Account()\n"+outside)
+		})
+	}
+}
+
+func TestClass_InstanceOf(t *testing.T) {
+	code := `
+		class Animal {}
+		class Dog extends Animal {}
+		class Cat extends Animal {}
+		enum Color { Red }
+		let d = Dog()
+		[d instanceof Dog, d instanceof Animal, d instanceof Cat, Animal() instanceof Dog,
+		 1 instanceof Animal, nil instanceof Dog, Color.Red instanceof Color, !(d instanceof Cat) && true]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[true true false false false false true true]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestClass_MemberParseErrors(t *testing.T) {
+	for _, code := range []string{
+		"class A { get x(v) { return v } }",
+		"class A { set x() {} }",
+		"class A { static get x() { return 1 } }",
+	} {
+		t.Run(code, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected a parse error")
+				}
+			}()
+			NewParser(code).ParseProgram()
+		})
+	}
+}
+
+func TestClass_MembersNamedLikeModifiers(t *testing.T) {
+	code := `
+		class Store {
+			let data = 1
+			get() { return this.data }
+			set(v) { this.data = v }
+			static() { return "s" }
+		}
+		let s = Store()
+		s.set(5)
+		[s.get(), s.static()]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[5 s]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
@@ -0,0 +1,669 @@
+package r2core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CollectionType es el constructor de una colección nativa: Set, Map,
+// ImmutableList o ImmutableMap. Se llama como una clase, con un iterable
+// opcional, y sirve a la derecha de instanceof y en las anotaciones de tipo.
+type CollectionType struct {
+	Name  string
+	build func(arg interface{}) interface{}
+	is    func(value interface{}) bool
+}
+
+// New crea una colección vacía, o con los elementos del iterable args[0].
+func (ct *CollectionType) New(args ...interface{}) interface{} {
+	if len(args) > 1 {
+		panic(fmt.Sprintf("%s: at most one argument (an iterable) is accepted", ct.Name))
+	}
+	var arg interface{}
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	return ct.build(arg)
+}
+
+func (ct *CollectionType) String() string {
+	return ct.Name
+}
+
+// collectionTypes son los constructores globales. Como las clases de Error,
+// no se guardan en el entorno (ver Environment.Get): un programa puede
+// declarar su propio Map o Set. Se arma en init porque construir una
+// colección usa Environment.Get, que los busca aquí.
+var collectionTypes map[string]*CollectionType
+
+func init() {
+	collectionTypes = map[string]*CollectionType{
+		"Set": {
+			Name: "Set",
+			build: func(arg interface{}) interface{} {
+				return NewSet(collectionItems("Set", arg)...)
+			},
+			is: func(value interface{}) bool {
+				_, ok := value.(*Set)
+				return ok
+			},
+		},
+		"Map": {
+			Name: "Map",
+			build: func(arg interface{}) interface{} {
+				m := NewOrderedMap()
+				for _, pair := range collectionPairs("Map", arg) {
+					m.Set(pair[0], pair[1])
+				}
+				return m
+			},
+			is: func(value interface{}) bool {
+				_, ok := value.(*OrderedMap)
+				return ok
+			},
+		},
+		"ImmutableList": {
+			Name: "ImmutableList",
+			build: func(arg interface{}) interface{} {
+				return NewImmutableList(collectionItems("ImmutableList", arg)...)
+			},
+			is: func(value interface{}) bool {
+				_, ok := value.(*ImmutableList)
+				return ok
+			},
+		},
+		"ImmutableMap": {
+			Name: "ImmutableMap",
+			build: func(arg interface{}) interface{} {
+				m := emptyImmutableMap
+				for _, pair := range collectionPairs("ImmutableMap", arg) {
+					m = m.Set(pair[0], pair[1])
+				}
+				return m
+			},
+			is: func(value interface{}) bool {
+				_, ok := value.(*ImmutableMap)
+				return ok
+			},
+		},
+	}
+}
+
+// CollectionNames devuelve los nombres de los constructores de colecciones.
+func CollectionNames() []string {
+	names := make([]string, 0, len(collectionTypes))
+	for name := range collectionTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectionItems devuelve los elementos con los que se construye una
+// colección: los de un array o un iterable; nil es ninguno.
+func collectionItems(name string, arg interface{}) []interface{} {
+	if arg == nil {
+		return nil
+	}
+	if items, ok := arrayItems(arg); ok {
+		return items
+	}
+	if it, ok := GetIterator(arg); ok {
+		return CollectIterator(it)
+	}
+	panic(fmt.Sprintf("%s: the argument must be an array or an iterable, got %s", name, typeName(arg)))
+}
+
+// collectionPairs devuelve los pares [clave, valor] con los que se construye
+// un mapa: los de otro mapa con claves, las propiedades de un objeto (por
+// orden alfabético, porque no tienen otro) o los elementos de un iterable,
+// que tienen que ser pares.
+func collectionPairs(name string, arg interface{}) [][2]interface{} {
+	switch v := arg.(type) {
+	case KeyedCollection:
+		pairs := make([][2]interface{}, 0, v.Len())
+		v.Range(func(key, value interface{}) bool {
+			pairs = append(pairs, [2]interface{}{key, value})
+			return true
+		})
+		return pairs
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([][2]interface{}, len(keys))
+		for i, k := range keys {
+			pairs[i] = [2]interface{}{k, v[k]}
+		}
+		return pairs
+	}
+	items := collectionItems(name, arg)
+	pairs := make([][2]interface{}, len(items))
+	for i, item := range items {
+		pair, ok := arrayItems(item)
+		if !ok {
+			if list, isList := item.(*ImmutableList); isList {
+				pair, ok = list.Values(), true
+			}
+		}
+		if !ok || len(pair) != 2 {
+			panic(fmt.Sprintf("%s: each entry must be a [key, value] pair, got %v", name, item))
+		}
+		pairs[i] = [2]interface{}{pair[0], pair[1]}
+	}
+	return pairs
+}
+
+// KeyedCollection es una colección con claves de cualquier tipo: Map e
+// ImmutableMap. for-in la recorre como a un mapa (ver EntryIterable) y la
+// desestructuración de objetos busca en ella las claves string.
+type KeyedCollection interface {
+	EntryIterable
+	Len() int
+	Get(key interface{}) (interface{}, bool)
+	// Range llama a fn con cada par, en orden, hasta que devuelva false.
+	Range(fn func(key, value interface{}) bool)
+}
+
+// Las claves de las colecciones se comparan como SameValueZero: los números
+// por valor sin importar su tipo (1, 1.0, 1n y 1.00d son la misma clave,
+// como en ==), las fechas por instante, los strings, bools y nil por valor y
+// todo lo demás (objetos, mapas, funciones, otras colecciones) por
+// identidad. collectionKey convierte cada valor en la clave de Go que lo
+// representa.
+type (
+	numberKey string // El número como racional ("3/2"), o "NaN", "+Inf", "-Inf"
+	dateKey   int64  // Nanosegundos desde el epoch
+	refKey    struct {
+		kind reflect.Kind
+		ptr  uintptr
+	}
+)
+
+func collectionKey(v interface{}) interface{} {
+	switch k := v.(type) {
+	case nil, string, bool:
+		return v
+	case float64:
+		switch {
+		case math.IsNaN(k):
+			return numberKey("NaN")
+		case math.IsInf(k, 1):
+			return numberKey("+Inf")
+		case math.IsInf(k, -1):
+			return numberKey("-Inf")
+		}
+		// Un float64 vale lo que su representación decimal más corta, como
+		// al escribirlo: 0.1 y 0.1d son la misma clave
+		r, _ := new(big.Rat).SetString(strconv.FormatFloat(k, 'g', -1, 64))
+		return numberKey(r.RatString())
+	case int:
+		return numberKey(strconv.Itoa(k))
+	case int64:
+		return numberKey(strconv.FormatInt(k, 10))
+	case *big.Int:
+		return numberKey(k.String())
+	case *DecimalValue:
+		den := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(k.scale)), nil)
+		return numberKey(new(big.Rat).SetFrac(k.unscaled, den).RatString())
+	case *DateValue:
+		return dateKey(k.Time.UnixNano())
+	case []interface{}, InterfaceSlice:
+		// append puede moverlos: no tienen una identidad estable
+		panic("An array cannot be a key of a Set or Map; use an ImmutableList or a string")
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Func:
+		return refKey{kind: rv.Kind(), ptr: rv.Pointer()}
+	}
+	if !rv.Type().Comparable() {
+		panic(fmt.Sprintf("A value of type %s cannot be a key of a Set or Map", typeName(v)))
+	}
+	return v
+}
+
+// linkedHash es la tabla de Set y OrderedMap: un índice por clave más una
+// lista doblemente enlazada en orden de inserción. Las entradas borradas
+// conservan su next, así que un iterador parado en una sigue adelante.
+type linkedHash struct {
+	mu         sync.RWMutex
+	index      map[interface{}]*hashEntry
+	head, tail *hashEntry
+}
+
+type hashEntry struct {
+	key, value interface{}
+	prev, next *hashEntry
+	removed    bool
+}
+
+func (h *linkedHash) init() {
+	h.index = make(map[interface{}]*hashEntry)
+}
+
+func (h *linkedHash) len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.index)
+}
+
+func (h *linkedHash) get(key interface{}) (interface{}, bool) {
+	hk := collectionKey(key)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if e, ok := h.index[hk]; ok {
+		return e.value, true
+	}
+	return nil, false
+}
+
+// put guarda key con value; una clave que ya estaba conserva su lugar.
+func (h *linkedHash) put(key, value interface{}) {
+	hk := collectionKey(key)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if e, ok := h.index[hk]; ok {
+		e.value = value
+		return
+	}
+	e := &hashEntry{key: key, value: value, prev: h.tail}
+	if h.tail != nil {
+		h.tail.next = e
+	} else {
+		h.head = e
+	}
+	h.tail = e
+	h.index[hk] = e
+}
+
+func (h *linkedHash) remove(key interface{}) bool {
+	hk := collectionKey(key)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.index[hk]
+	if !ok {
+		return false
+	}
+	delete(h.index, hk)
+	e.removed = true
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		h.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		h.tail = e.prev
+	}
+	return true
+}
+
+func (h *linkedHash) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for e := h.head; e != nil; e = e.next {
+		e.removed = true
+	}
+	h.index = make(map[interface{}]*hashEntry)
+	h.head, h.tail = nil, nil
+}
+
+// rangeEntries llama a fn con cada entrada, en orden, hasta que devuelva
+// false. fn corre sin el lock, así que puede modificar la tabla.
+func (h *linkedHash) rangeEntries(fn func(key, value interface{}) bool) {
+	it := &hashIterator{h: h}
+	for {
+		key, value, done := it.NextEntry()
+		if done || !fn(key, value) {
+			return
+		}
+	}
+}
+
+// hashIterator recorre un linkedHash; cada paso toma el lock, así que la
+// tabla se puede modificar mientras tanto.
+type hashIterator struct {
+	h       *linkedHash
+	cur     *hashEntry
+	started bool
+}
+
+func (it *hashIterator) NextEntry() (key, value interface{}, done bool) {
+	it.h.mu.RLock()
+	defer it.h.mu.RUnlock()
+	e := it.h.head
+	if it.started {
+		e = nil
+		if it.cur != nil {
+			e = it.cur.next
+		}
+	}
+	it.started = true
+	for e != nil && e.removed {
+		e = e.next
+	}
+	it.cur = e
+	if e == nil {
+		return nil, nil, true
+	}
+	return e.key, e.value, false
+}
+
+// Next devuelve las claves: los elementos, en un Set.
+func (it *hashIterator) Next() (interface{}, bool) {
+	key, _, done := it.NextEntry()
+	return key, done
+}
+
+// Set es un conjunto en orden de inserción (ver collectionKey).
+type Set struct {
+	table linkedHash
+}
+
+func NewSet(items ...interface{}) *Set {
+	s := &Set{}
+	s.table.init()
+	for _, item := range items {
+		s.Add(item)
+	}
+	return s
+}
+
+func (s *Set) Add(value interface{}) {
+	s.table.put(value, value)
+}
+
+func (s *Set) Has(value interface{}) bool {
+	_, ok := s.table.get(value)
+	return ok
+}
+
+func (s *Set) Delete(value interface{}) bool {
+	return s.table.remove(value)
+}
+
+func (s *Set) Len() int {
+	return s.table.len()
+}
+
+// Values devuelve los elementos de s en orden.
+func (s *Set) Values() []interface{} {
+	values := make([]interface{}, 0, s.Len())
+	s.table.rangeEntries(func(key, _ interface{}) bool {
+		values = append(values, key)
+		return true
+	})
+	return values
+}
+
+func (s *Set) Iterator() Iterator {
+	return &hashIterator{h: &s.table}
+}
+
+func (s *Set) String() string {
+	return "Set{" + joinValues(s.Values()) + "}"
+}
+
+// OrderedMap es el Map de R2: un mapa en orden de inserción cuyas claves
+// pueden ser de cualquier tipo (ver collectionKey).
+type OrderedMap struct {
+	table linkedHash
+}
+
+func NewOrderedMap() *OrderedMap {
+	m := &OrderedMap{}
+	m.table.init()
+	return m
+}
+
+func (m *OrderedMap) Get(key interface{}) (interface{}, bool) {
+	return m.table.get(key)
+}
+
+func (m *OrderedMap) Set(key, value interface{}) {
+	m.table.put(key, value)
+}
+
+func (m *OrderedMap) Delete(key interface{}) bool {
+	return m.table.remove(key)
+}
+
+func (m *OrderedMap) Len() int {
+	return m.table.len()
+}
+
+func (m *OrderedMap) Range(fn func(key, value interface{}) bool) {
+	m.table.rangeEntries(fn)
+}
+
+// Iterator recorre los pares [clave, valor], que es lo que ven el spread y
+// la desestructuración.
+func (m *OrderedMap) Iterator() Iterator {
+	return entryPairs(m.Entries())
+}
+
+func (m *OrderedMap) Entries() EntryIterator {
+	return &hashIterator{h: &m.table}
+}
+
+func (m *OrderedMap) String() string {
+	return "Map{" + joinEntries(m) + "}"
+}
+
+// entryPairs adapta un EntryIterator a un Iterator de pares [clave, valor].
+func entryPairs(entries EntryIterator) Iterator {
+	return NewIterator(func() (interface{}, bool) {
+		key, value, done := entries.NextEntry()
+		if done {
+			return nil, true
+		}
+		return []interface{}{key, value}, false
+	}, nil)
+}
+
+// entryKeys adapta un EntryIterator a un Iterator de sus claves.
+func entryKeys(entries EntryIterator) Iterator {
+	return NewIterator(func() (interface{}, bool) {
+		key, _, done := entries.NextEntry()
+		return key, done
+	}, nil)
+}
+
+func joinValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = collectionElementString(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinEntries(m KeyedCollection) string {
+	parts := make([]string, 0, m.Len())
+	m.Range(func(key, value interface{}) bool {
+		parts = append(parts, collectionElementString(key)+": "+collectionElementString(value))
+		return true
+	})
+	return strings.Join(parts, ", ")
+}
+
+// collectionElementString muestra los strings entre comillas para que
+// Set{"1"} no se confunda con Set{1}.
+func collectionElementString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return toString(v)
+}
+
+// collectionLen es size() (o len(), length()) de las colecciones.
+func collectionLen(n func() int) BuiltinFunction {
+	return func(args ...interface{}) interface{} {
+		return float64(n())
+	}
+}
+
+// otherSet convierte el argumento de union, intersection, difference o
+// isSubsetOf en un Set.
+func otherSet(method string, args []interface{}) *Set {
+	if len(args) != 1 {
+		panic(method + " needs 1 argument (an iterable)")
+	}
+	if s, ok := args[0].(*Set); ok {
+		return s
+	}
+	return NewSet(collectionItems(method, args[0])...)
+}
+
+func evalSetAccess(s *Set, member string) interface{} {
+	switch member {
+	case "size", "len", "length":
+		return collectionLen(s.Len)
+	case "add":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			for _, arg := range args {
+				s.Add(arg)
+			}
+			return s
+		})
+	case "has":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("has needs 1 argument")
+			}
+			return s.Has(args[0])
+		})
+	case "delete", "remove":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic(member + " needs 1 argument")
+			}
+			return s.Delete(args[0])
+		})
+	case "clear":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			s.table.clear()
+			return nil
+		})
+	case "toArray", "values":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return s.Values()
+		})
+	case "union":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			result := NewSet(s.Values()...)
+			for _, v := range otherSet(member, args).Values() {
+				result.Add(v)
+			}
+			return result
+		})
+	case "intersection", "difference":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			other := otherSet(member, args)
+			keep := member == "intersection"
+			result := NewSet()
+			for _, v := range s.Values() {
+				if other.Has(v) == keep {
+					result.Add(v)
+				}
+			}
+			return result
+		})
+	case "isSubsetOf":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			other := otherSet(member, args)
+			for _, v := range s.Values() {
+				if !other.Has(v) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "Set does not have the method: "+member))
+}
+
+// keyedAccess resuelve los métodos de lectura que comparten Map e
+// ImmutableMap.
+func keyedAccess(m KeyedCollection, member string) (interface{}, bool) {
+	switch member {
+	case "size", "len", "length":
+		return collectionLen(m.Len), true
+	case "get":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 || len(args) > 2 {
+				panic("get needs 1 or 2 arguments (key, default)")
+			}
+			if value, ok := m.Get(args[0]); ok {
+				return value
+			}
+			if len(args) == 2 {
+				return args[1]
+			}
+			return nil
+		}), true
+	case "has":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("has needs 1 argument")
+			}
+			_, ok := m.Get(args[0])
+			return ok
+		}), true
+	case "keys", "values", "entries":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			result := make([]interface{}, 0, m.Len())
+			m.Range(func(key, value interface{}) bool {
+				switch member {
+				case "keys":
+					result = append(result, key)
+				case "values":
+					result = append(result, value)
+				default:
+					result = append(result, []interface{}{key, value})
+				}
+				return true
+			})
+			return result
+		}), true
+	}
+	return nil, false
+}
+
+func evalOrderedMapAccess(m *OrderedMap, member string) interface{} {
+	if fn, ok := keyedAccess(m, member); ok {
+		return fn
+	}
+	switch member {
+	case "set":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 2 {
+				panic("set needs 2 arguments (key, value)")
+			}
+			m.Set(args[0], args[1])
+			return m
+		})
+	case "delete", "remove":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic(member + " needs 1 argument")
+			}
+			return m.Delete(args[0])
+		})
+	case "clear":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			m.table.clear()
+			return nil
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "Map does not have the method: "+member))
+}
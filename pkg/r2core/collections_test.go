@@ -0,0 +1,199 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCollections_Language(t *testing.T) {
+	cases := map[string]struct{ input, want string }{
+		"set": {
+			`let s = Set([3, 1, 3, "1", 1.0]); s.add(2).add(3); log(s, s.size(), s.has(1), s.has("3")); s.delete(1); log(s.toArray())`,
+			"Set{3, 1, \"1\", 2} 4 true false\n[3 1 2]\n",
+		},
+		"set algebra": {
+			`let a = Set([1, 2, 3]); let b = Set([2, 3, 4]); log(a.union(b), a.intersection(b), a.difference(b), Set([2]).isSubsetOf(a))`,
+			"Set{1, 2, 3, 4} Set{2, 3} Set{1} true\n",
+		},
+		"map keys": {
+			`let o = {}; let m = Map([[1, "num"], ["1", "str"]]); m[o] = "obj"; m.set(true, "bool"); log(m.size(), m[1], m["1"], m.get(o), m[{}], m.get(2, "none"), m.keys()[3])`,
+			"4numstrobj<nil>nonetrue\n",
+		},
+		"float and decimal keys": {
+			`let m = Map([[0.1, "a"]]); m[0.1d] = "b"; let s = Set([0.3d, 0.1 + 0.2, 1.5, 3/2, 2n]); log(m.size(), m[0.1], s.size(), s.has(0.3), s.has(2))`,
+			"1b4 true true\n",
+		},
+		"map order": {
+			`let m = Map(); m.set("b", 1).set("a", 2).set("b", 3); m.delete("a"); m["c"] = 4; log(m, m.entries())`,
+			"Map{\"b\": 3, \"c\": 4} [[b 3] [c 4]]\n",
+		},
+		"for in": {
+			`for (x in Set(["a", "b"])) { log(x) } for (k in Map([[1, "x"], [2, "y"]])) { log(k, $v) } for (i in ImmutableList([5, 6])) { log(i, $v) } for (k in ImmutableMap({a: 1})) { log(k, $v) }`,
+			"a\nb\n1x\n2y\n0 5\n1 6\na1\n",
+		},
+		"spread and destructuring": {
+			`let s = Set([1, 2, 2]); let m = Map([["a", 1], ["b", 2]]); let [x, y] = s; let {a, b} = m; let o = {...m, c: 3}; log([...s, ...ImmutableList([3])], x, y, a, b, o.b)`,
+			"[1 2 3] 1 2 1 2 2\n",
+		},
+		"comprehension": {
+			`log([k * 2 for k in Set([1, 2])], [k for k in Map([["x", 1]])])`,
+			"[2 4] [x]\n",
+		},
+		"immutable list": {
+			`let a = ImmutableList([1, 2]); let b = a.push(3).set(0, 9); log(a, b, b[-1], b.pop(), a.concat([4]).size())`,
+			"ImmutableList[1, 2] ImmutableList[9, 2, 3] 3 ImmutableList[9, 2] 3\n",
+		},
+		"immutable map": {
+			`let a = ImmutableMap({b: 1}); let b = a.set("a", 2).set("b", 3); log(a, b, b.delete("b"), a["b"], b.has("a"))`,
+			"ImmutableMap{\"b\": 1} ImmutableMap{\"b\": 3, \"a\": 2} ImmutableMap{\"a\": 2} 1 true\n",
+		},
+		"instanceof": {
+			`log(Set() instanceof Set, Map() instanceof Set, ImmutableList() instanceof ImmutableList, ImmutableMap() instanceof Map)`,
+			"true false true false\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tree, vm := runBoth(t, tc.input)
+			if want := tc.want + "=> <nil>"; tree != want || vm != want {
+				t.Errorf("expected %q\ntree: %q\nvm:   %q", want, tree, vm)
+			}
+		})
+	}
+}
+
+func TestCollections_Errors(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`Set([[1]])`, "An array cannot be a key of a Set or Map"},
+		{`Map([1, 2])`, "Map"},
+		{`let l = ImmutableList([1]); l[0] = 2`, "ImmutableList is immutable"},
+		{`let m = ImmutableMap(); m["a"] = 2`, "ImmutableMap is immutable"},
+		{`ImmutableList().pop()`, "empty"},
+		{`Set(1, 2)`, "Set"},
+	}
+	for _, tt := range tests {
+		msg := recoverMessage(func() { evalTyped(t, tt.code, false) })
+		if !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.code, tt.want, msg)
+		}
+	}
+}
+
+// Las versiones viejas no cambian cuando se derivan otras nuevas, también
+// cuando el árbol tiene varios niveles.
+func TestImmutableList_Persistent(t *testing.T) {
+	const n = 5000
+	list := NewImmutableList()
+	var versions []*ImmutableList
+	for i := 0; i < n; i++ {
+		list = list.Push(float64(i))
+		if i%1000 == 0 {
+			versions = append(versions, list)
+		}
+	}
+	if list.Len() != n {
+		t.Fatalf("expected %d elements, got %d", n, list.Len())
+	}
+	changed := list.Set(1234, "x")
+	for i := 0; i < n; i++ {
+		if got := list.Get(i); got != float64(i) {
+			t.Fatalf("Get(%d) = %v", i, got)
+		}
+		want := interface{}(float64(i))
+		if i == 1234 {
+			want = "x"
+		}
+		if got := changed.Get(i); got != want {
+			t.Fatalf("changed.Get(%d) = %v, want %v", i, got, want)
+		}
+	}
+	for k, v := range versions {
+		if v.Len() != k*1000+1 || v.Get(k*1000) != float64(k*1000) {
+			t.Errorf("version %d changed: len %d", k, v.Len())
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		list = list.Pop()
+		if list.Len() != i || (i > 0 && list.Get(i-1) != float64(i-1)) {
+			t.Fatalf("Pop to %d elements broke the list", i)
+		}
+	}
+	if changed.Len() != n {
+		t.Errorf("Pop changed a previous version")
+	}
+}
+
+func TestImmutableMap_Persistent(t *testing.T) {
+	const n = 3000
+	m := NewImmutableMap()
+	for i := 0; i < n; i++ {
+		m = m.Set(float64(i), fmt.Sprint(i))
+	}
+	full := m
+	for i := 0; i < n; i += 2 {
+		m = m.Delete(float64(i))
+	}
+	if full.Len() != n || m.Len() != n/2 {
+		t.Fatalf("expected %d and %d entries, got %d and %d", n, n/2, full.Len(), m.Len())
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := full.Get(float64(i)); !ok || v != fmt.Sprint(i) {
+			t.Fatalf("full.Get(%d) = %v, %v", i, v, ok)
+		}
+		_, ok := m.Get(float64(i))
+		if ok != (i%2 == 1) {
+			t.Fatalf("m.Get(%d) found = %v", i, ok)
+		}
+	}
+	// El orden de inserción sobrevive a la compactación
+	prev := -1.0
+	m.Range(func(k, v interface{}) bool {
+		if k.(float64) <= prev {
+			t.Fatalf("key %v after %v", k, prev)
+		}
+		prev = k.(float64)
+		return true
+	})
+	if again := m.Set(float64(1), "one"); again.Len() != m.Len() {
+		t.Errorf("replacing a key changed the size")
+	}
+}
+
+// Las claves con el mismo hash terminan en una lista de colisiones.
+func TestHamt_Collisions(t *testing.T) {
+	root := &hamtNode{}
+	keys := []string{"a", "b", "c"}
+	for i, k := range keys {
+		root, _ = root.with(k, 42, 0, i)
+	}
+	for i, k := range keys {
+		if v, ok := root.get(k, 42, 0); !ok || v != i {
+			t.Errorf("get(%q) = %v, %v", k, v, ok)
+		}
+	}
+	root, removed := root.without("b", 42, 0)
+	if _, ok := root.get("b", 42, 0); !removed || ok {
+		t.Errorf("b was not removed")
+	}
+	if v, ok := root.get("c", 42, 0); !ok || v != 2 {
+		t.Errorf("get(c) = %v, %v after removing b", v, ok)
+	}
+}
+
+func TestCollections_Types(t *testing.T) {
+	code := `func keys(m: Map): Set { return Set(m.keys()) } `
+	if got := evalTyped(t, code+`keys(Map([[1, 2]])).size()`, true); got != float64(1) {
+		t.Errorf("expected 1, got %v", got)
+	}
+	msg := recoverMessage(func() { evalTyped(t, code+`keys({a: 1})`, true) })
+	if !strings.Contains(msg, "argument m must be Map, got map") {
+		t.Errorf("unexpected error %q", msg)
+	}
+	if errs := typeCheck(t, code+"\nlet s: Set = keys(Map())\nlet l: ImmutableList = Set()"); len(errs) != 1 || !strings.Contains(errs[0], "Cannot assign Set to l: ImmutableList") {
+		t.Errorf("unexpected typecheck errors %v", errs)
+	}
+}
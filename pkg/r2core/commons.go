@@ -2,6 +2,7 @@ package r2core
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -52,6 +53,12 @@ func toFloat(val interface{}) float64 {
 			return cached
 		}
 		return float64(v)
+	case int64:
+		return float64(v)
+	case *big.Int:
+		return bigToFloat(v)
+	case *DecimalValue:
+		return v.Float64()
 	case bool:
 		if v {
 			return 1
@@ -79,7 +86,7 @@ func toFloat(val interface{}) float64 {
 		// Smart conversion: try to parse as number
 		f, err := smartParseFloat(v)
 		if err != nil {
-			panic("Cannot convert string to number:" + v)
+			panic(newRuntimeError("TypeError", "Cannot convert string to number:"+v))
 		}
 
 		// Limitar tamaño del cache
@@ -90,7 +97,7 @@ func toFloat(val interface{}) float64 {
 		commonsStringCacheMu.Unlock()
 		return f
 	}
-	panic("Cannot convert value to number")
+	panic(newRuntimeError("TypeError", "Cannot convert value to number"))
 }
 func toBool(val interface{}) bool {
 	if val == nil {
@@ -103,6 +110,8 @@ func toBool(val interface{}) bool {
 		return v != 0
 	case int:
 		return v != 0
+	case int64, *big.Int, *DecimalValue:
+		return !exactZero(v)
 	case string:
 		return v != ""
 	}
@@ -111,17 +120,17 @@ func toBool(val interface{}) bool {
 
 // Para unificar la lógica numérica en "=="
 func isNumeric(v interface{}) bool {
-	switch v.(type) {
-	case float64, int:
-		return true
-	}
-	return false
+	return numericKind(v) != numNone
 }
 
 // Corrige la comparación "=="
 func equals(a, b interface{}) bool {
 	// Si ambos son numéricos, compare con toFloat
 	if isNumeric(a) && isNumeric(b) {
+		if isExactNumber(a) || isExactNumber(b) {
+			cmp, _ := CompareNumbers(a, b)
+			return cmp == 0
+		}
 		return toFloat(a) == toFloat(b)
 	}
 	// sino comparamos string/bool/nil
@@ -136,6 +145,8 @@ func equals(a, b interface{}) bool {
 		}
 	case nil:
 		return b == nil
+	case *EnumValue:
+		return b == aa
 	}
 	return false
 }
@@ -147,6 +158,9 @@ func addValues(a, b interface{}) interface{} {
 			return af + bf // Sin allocaciones extra
 		}
 	}
+	if r, ok := exactArith("+", a, b); ok {
+		return r
+	}
 
 	// P5 Feature: Smart auto-conversion
 	// Handle mixed types and smart numeric strings
@@ -181,6 +195,9 @@ func subValues(a, b interface{}) interface{} {
 			return af - bf // Sin allocaciones extra
 		}
 	}
+	if r, ok := exactArith("-", a, b); ok {
+		return r
+	}
 	// Object pool desactivado para operaciones simples
 	return toFloat(a) - toFloat(b)
 }
@@ -191,6 +208,9 @@ func mulValues(a, b interface{}) interface{} {
 			return af * bf // Sin allocaciones extra
 		}
 	}
+	if r, ok := exactArith("*", a, b); ok {
+		return r
+	}
 	// Object pool desactivado para operaciones simples
 	return toFloat(a) * toFloat(b)
 }
@@ -199,15 +219,18 @@ func divValues(a, b interface{}) interface{} {
 	if af, ok := a.(float64); ok {
 		if bf, ok := b.(float64); ok {
 			if bf == 0 {
-				panic("Division by zero")
+				panic(newRuntimeError("ArithmeticError", "Division by zero"))
 			}
 			return af / bf // Sin allocaciones extra
 		}
 	}
+	if r, ok := exactArith("/", a, b); ok {
+		return r
+	}
 
 	den := toFloat(b)
 	if den == 0 {
-		panic("Division by zero")
+		panic(newRuntimeError("ArithmeticError", "Division by zero"))
 	}
 	// Object pool desactivado para operaciones simples
 	return toFloat(a) / den
@@ -218,15 +241,18 @@ func modValues(a, b interface{}) interface{} {
 	if af, ok := a.(float64); ok {
 		if bf, ok := b.(float64); ok {
 			if bf == 0 {
-				panic("Modulo by zero")
+				panic(newRuntimeError("ArithmeticError", "Modulo by zero"))
 			}
 			return float64(int(af) % int(bf))
 		}
 	}
+	if r, ok := exactArith("%", a, b); ok {
+		return r
+	}
 
 	den := toFloat(b)
 	if den == 0 {
-		panic("Modulo by zero")
+		panic(newRuntimeError("ArithmeticError", "Modulo by zero"))
 	}
 	return float64(int(toFloat(a)) % int(den))
 }
@@ -245,11 +271,10 @@ func assignIndexExpression(idxExpr *IndexExpression, newVal interface{}, env *En
 		container[key] = newVal
 		return newVal
 	case []interface{}:
-		idxF, ok := indexVal.(float64)
+		idx, ok := arrayIndex(indexVal)
 		if !ok {
 			panic("assignIndexExpression: array index must be a number")
 		}
-		idx := int(idxF)
 		if idx < 0 {
 			idx = len(container) + idx
 		}
@@ -268,11 +293,10 @@ func assignIndexExpression(idxExpr *IndexExpression, newVal interface{}, env *En
 		container[idx] = newVal
 		return newVal
 	case InterfaceSlice:
-		idxF, ok := indexVal.(float64)
+		idx, ok := arrayIndex(indexVal)
 		if !ok {
 			panic("assignIndexExpression: array index must be a number")
 		}
-		idx := int(idxF)
 		if idx < 0 {
 			idx = len(container) + idx
 		}
@@ -290,6 +314,11 @@ func assignIndexExpression(idxExpr *IndexExpression, newVal interface{}, env *En
 		}
 		container[idx] = newVal
 		return newVal
+	case *OrderedMap:
+		container.Set(indexVal, newVal)
+		return newVal
+	case *ImmutableList, *ImmutableMap:
+		panic(fmt.Sprintf("assignIndexExpression: %s is immutable; use set(), which returns a new one", typeName(container)))
 	default:
 		panic("Not a map or array to assign index")
 	}
@@ -312,15 +341,13 @@ func updateArrayInEnv(node Node, newArray []interface{}, env *Environment) {
 		indexVal := n.Index.Eval(env)
 		switch container := leftVal.(type) {
 		case []interface{}:
-			if idxF, ok := indexVal.(float64); ok {
-				idx := int(idxF)
+			if idx, ok := arrayIndex(indexVal); ok {
 				if idx >= 0 && idx < len(container) {
 					container[idx] = newArray
 				}
 			}
 		case InterfaceSlice:
-			if idxF, ok := indexVal.(float64); ok {
-				idx := int(idxF)
+			if idx, ok := arrayIndex(indexVal); ok {
 				if idx >= 0 && idx < len(container) {
 					container[idx] = newArray
 				}
@@ -329,6 +356,26 @@ func updateArrayInEnv(node Node, newArray []interface{}, env *Environment) {
 	}
 }
 
+// arrayIndex convierte el índice de un array (de cualquiera de los tipos
+// numéricos) a int; como con un float64, un decimal se trunca.
+func arrayIndex(v interface{}) (int, bool) {
+	switch i := v.(type) {
+	case float64:
+		return int(i), true
+	case int:
+		return i, true
+	case int64:
+		return int(i), true
+	case *big.Int:
+		if i.IsInt64() {
+			return int(i.Int64()), true
+		}
+	case *DecimalValue:
+		return arrayIndex(i.Truncate())
+	}
+	return 0, false
+}
+
 func isBinaryOp(op string) bool {
 	ops := []string{"+", "-", "*", "/", "%", "<", ">", "<=", ">=", "==", "!=", "&&", "||", "&", "|", "^", "<<", ">>", "??", "|>"}
 	for _, o := range ops {
@@ -361,6 +408,11 @@ func toString(val interface{}) string {
 		return "<function>"
 	case *UserFunction:
 		return "<function>"
+	case *ObjectInstance:
+		if isError(v) {
+			return errorString(v)
+		}
+		return fmt.Sprintf("%v", v)
 	default:
 		if isFunctionValue(v) {
 			return "<function>"
@@ -396,6 +448,11 @@ func toStringOptimized(val interface{}) string {
 		return "<function>"
 	case *UserFunction:
 		return "<function>"
+	case *ObjectInstance:
+		if isError(v) {
+			return errorString(v)
+		}
+		return fmt.Sprintf("%v", v)
 	default:
 		if isFunctionValue(v) {
 			return "<function>"
@@ -544,6 +601,11 @@ func smartToString(val interface{}) string {
 		return "false"
 	case nil:
 		return ""
+	case *ObjectInstance:
+		if isError(v) {
+			return errorString(v)
+		}
+		return fmt.Sprintf("%v", v)
 	default:
 		return fmt.Sprintf("%v", v)
 	}
@@ -63,6 +63,10 @@ func (ac *ArrayComprehension) generateElements(env *Environment, genIndex int, b
 	if is, ok := iterable.(InterfaceSlice); ok {
 		iterable = []interface{}(is)
 	}
+	if keyed, ok := iterable.(EntryIterable); ok {
+		// Como en for-in, la variable toma las claves de un Map
+		iterable = entryKeys(keyed.Entries())
+	}
 
 	var results []interface{}
 
@@ -99,7 +103,20 @@ func (ac *ArrayComprehension) generateElements(env *Environment, genIndex int, b
 			results = append(results, subResults...)
 		}
 	default:
-		panic("Cannot iterate over non-iterable value in comprehension")
+		it, ok := GetIterator(iter)
+		if !ok {
+			panic("Cannot iterate over non-iterable value in comprehension")
+		}
+		eachFromIterator(it, func(item interface{}) {
+			newBindings := make(map[string]interface{})
+			for k, v := range bindings {
+				newBindings[k] = v
+			}
+			newBindings[generator.Variable] = item
+
+			subResults := ac.generateElements(env, genIndex+1, newBindings)
+			results = append(results, subResults...)
+		})
 	}
 
 	return results
@@ -159,6 +176,10 @@ func (oc *ObjectComprehension) generatePairs(env *Environment, genIndex int, bin
 	if is, ok := iterable.(InterfaceSlice); ok {
 		iterable = []interface{}(is)
 	}
+	if keyed, ok := iterable.(EntryIterable); ok {
+		// Como en for-in, la variable toma las claves de un Map
+		iterable = entryKeys(keyed.Entries())
+	}
 
 	var results []interface{}
 
@@ -189,8 +210,34 @@ func (oc *ObjectComprehension) generatePairs(env *Environment, genIndex int, bin
 			results = append(results, subResults...)
 		}
 	default:
-		panic("Cannot iterate over non-iterable value in comprehension")
+		it, ok := GetIterator(iter)
+		if !ok {
+			panic("Cannot iterate over non-iterable value in comprehension")
+		}
+		eachFromIterator(it, func(item interface{}) {
+			newBindings := make(map[string]interface{})
+			for k, v := range bindings {
+				newBindings[k] = v
+			}
+			newBindings[generator.Variable] = item
+
+			subResults := oc.generatePairs(env, genIndex+1, newBindings)
+			results = append(results, subResults...)
+		})
 	}
 
 	return results
 }
+
+// eachFromIterator llama a fn con cada elemento de it, y lo cierra aunque fn
+// lance una excepción.
+func eachFromIterator(it Iterator, fn func(item interface{})) {
+	defer CloseIterator(it)
+	for {
+		item, done := it.Next()
+		if done {
+			return
+		}
+		fn(item)
+	}
+}
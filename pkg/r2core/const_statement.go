@@ -3,6 +3,7 @@ package r2core
 // ConstStatement => const x = expr;
 type ConstStatement struct {
 	Name  string
+	Type  *TypeAnnotation // let nombre: tipo; nil si no está anotado
 	Value Node
 }
 
@@ -10,6 +11,7 @@ func (cs *ConstStatement) Eval(env *Environment) interface{} {
 	var val interface{}
 	if cs.Value != nil {
 		val = cs.Value.Eval(env)
+		checkDeclared(env, cs.Name, cs.Type, val)
 	}
 	env.SetConst(cs.Name, val)
 	return nil
@@ -22,6 +24,7 @@ type MultipleConstStatement struct {
 
 type ConstDeclaration struct {
 	Name  string
+	Type  *TypeAnnotation // let nombre: tipo; nil si no está anotado
 	Value Node
 }
 
@@ -30,6 +33,7 @@ func (mcs *MultipleConstStatement) Eval(env *Environment) interface{} {
 		var val interface{}
 		if decl.Value != nil {
 			val = decl.Value.Eval(env)
+			checkDeclared(env, decl.Name, decl.Type, val)
 		}
 		env.SetConst(decl.Name, val)
 	}
@@ -0,0 +1,481 @@
+package r2core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDebugTerminated es el panic con el que termina un programa cuando el
+// depurador lo corta (ver Debugger.Terminate). Ningún catch lo captura.
+var ErrDebugTerminated = errors.New("debug session terminated")
+
+// stepMode es cómo sigue la ejecución después de una parada.
+type stepMode int
+
+const (
+	stepContinue  stepMode = iota // hasta el próximo breakpoint
+	stepIn                        // hasta la próxima sentencia
+	stepOver                      // hasta la próxima sentencia de esta función o de las que llaman
+	stepOut                       // hasta volver a la función que llamó a esta
+	stepTerminate                 // cortar el programa
+)
+
+// Debugger detiene un programa antes de sus sentencias: en los
+// breakpoints, al lanzarse una excepción si se pidió, o paso a paso. Se
+// instala con Environment.SetDebugger antes de evaluar el programa; cada
+// parada llega por Stops y la ejecución sigue con Continue, StepIn,
+// StepOver o StepOut.
+//
+// El depurador sigue una sola pila de llamadas: las goroutines que lance el
+// programa se detienen igual, de a una por vez, pero sus frames se mezclan
+// con los del hilo principal.
+type Debugger struct {
+	mu          sync.Mutex
+	breakpoints map[string]map[int]bool // Ruta absoluta -> líneas
+	paths       map[string]string       // Filename de las posiciones -> ruta absoluta
+	exceptions  atomic.Bool             // Detenerse al lanzarse una excepción
+	pause       bool                    // Detenerse en la próxima sentencia
+	mode        stepMode
+	depth       int             // Profundidad de la pila al pedir el paso
+	trail       []debugSite     // Sentencia en curso de cada profundidad de la pila
+	last        *debugSite      // Última sentencia alcanzada
+	preset      map[string]bool // Nombres del entorno global antes de la primera sentencia
+	raised      interface{}     // Excepción ya informada, mientras se propaga
+	paused      bool
+	terminated  bool
+
+	evaluating atomic.Int32 // Evaluaciones del depurador en curso: no se detienen
+	stopMu     sync.Mutex   // Sólo una goroutine detenida a la vez
+	stops      chan *DebugStop
+	resume     chan stepMode
+}
+
+// debugSite es una sentencia alcanzada: dónde está y en qué entorno corre.
+// base es el entorno en el que empezó la llamada de su función.
+type debugSite struct {
+	pos       *PositionInfo
+	env, base *Environment
+	depth     int
+}
+
+// DebugStop es una parada del programa.
+type DebugStop struct {
+	Reason    string      // "breakpoint", "step", "pause" o "exception"
+	Exception interface{} // El valor lanzado, si Reason es "exception"
+	Frames    []*DebugFrame
+}
+
+// Position devuelve la posición de la sentencia en la que se detuvo.
+func (s *DebugStop) Position() *PositionInfo {
+	return s.Frames[0].Position
+}
+
+// DebugFrame es una llamada en curso en una parada: la más reciente es la
+// primera de DebugStop.Frames y la última es el programa principal.
+type DebugFrame struct {
+	Name      string
+	Position  *PositionInfo // La sentencia en curso de la llamada
+	env, base *Environment
+	d         *Debugger
+}
+
+// DebugScope es un ámbito de variables de un frame.
+type DebugScope struct {
+	Name string
+	Vars []DebugVar
+}
+
+// DebugVar es una variable visible en un frame.
+type DebugVar struct {
+	Name  string
+	Value interface{}
+}
+
+func NewDebugger() *Debugger {
+	return &Debugger{
+		breakpoints: make(map[string]map[int]bool),
+		paths:       make(map[string]string),
+		stops:       make(chan *DebugStop),
+		resume:      make(chan stepMode, 1),
+	}
+}
+
+// SetDebugger instala d en el programa de e: a partir de ahí cada sentencia
+// pasa por él. Debe llamarse antes de empezar a evaluar.
+func (e *Environment) SetDebugger(d *Debugger) {
+	e.debugger = d
+}
+
+// Stops devuelve el canal por el que llega cada parada. El programa queda
+// detenido hasta que se llame a Continue, StepIn, StepOver o StepOut.
+func (d *Debugger) Stops() <-chan *DebugStop {
+	return d.stops
+}
+
+// SetBreakpoints reemplaza los breakpoints de file por lines.
+func (d *Debugger) SetBreakpoints(file string, lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := d.absPath(file)
+	if len(lines) == 0 {
+		delete(d.breakpoints, path)
+		return
+	}
+	set := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		set[line] = true
+	}
+	d.breakpoints[path] = set
+}
+
+// Breakpoints devuelve las líneas con breakpoint de file, en orden.
+func (d *Debugger) Breakpoints(file string) []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var lines []int
+	for line := range d.breakpoints[d.absPath(file)] {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// SetBreakOnExceptions indica si detenerse donde se lanza cada excepción,
+// la capture o no un catch.
+func (d *Debugger) SetBreakOnExceptions(on bool) {
+	d.exceptions.Store(on)
+}
+
+// Pause detiene el programa en su próxima sentencia.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	d.pause = true
+	d.mu.Unlock()
+}
+
+// Continue sigue hasta el próximo breakpoint. Devuelve false si el programa
+// no estaba detenido.
+func (d *Debugger) Continue() bool { return d.send(stepContinue) }
+
+// StepIn sigue hasta la próxima sentencia, entrando en las llamadas.
+func (d *Debugger) StepIn() bool { return d.send(stepIn) }
+
+// StepOver sigue hasta la próxima sentencia sin entrar en las llamadas.
+func (d *Debugger) StepOver() bool { return d.send(stepOver) }
+
+// StepOut sigue hasta volver a la función que llamó a la actual.
+func (d *Debugger) StepOut() bool { return d.send(stepOut) }
+
+// Terminate corta el programa con ErrDebugTerminated en su próxima
+// sentencia, o ya si está detenido.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	d.terminated = true
+	d.mu.Unlock()
+	d.send(stepTerminate)
+}
+
+func (d *Debugger) send(mode stepMode) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.paused {
+		return false
+	}
+	d.paused = false
+	d.resume <- mode
+	return true
+}
+
+// absPath devuelve la ruta absoluta con la que se comparan los breakpoints.
+// Debe llamarse con d.mu tomado.
+func (d *Debugger) absPath(file string) string {
+	if path, ok := d.paths[file]; ok {
+		return path
+	}
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = filepath.Clean(file)
+	}
+	d.paths[file] = path
+	return path
+}
+
+// run evalúa la sentencia stmt, que empieza en pos, deteniéndose antes si
+// corresponde. Es el punto de entrada de Program y BlockStatement.
+func (d *Debugger) run(env *Environment, stmt Node, pos *PositionInfo) interface{} {
+	if d.evaluating.Load() > 0 || pos.Filename == errorPreludeFile {
+		return stmt.Eval(env)
+	}
+	d.statement(env, pos)
+	if d.exceptions.Load() {
+		defer d.recoverException(env)
+	}
+	return stmt.Eval(env)
+}
+
+// statement registra que se alcanzó la sentencia de pos y se detiene si
+// hay un breakpoint, un paso pendiente o una pausa pedida.
+func (d *Debugger) statement(env *Environment, pos *PositionInfo) {
+	depth := env.callStack.Len()
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		panic(ErrDebugTerminated)
+	}
+	if d.preset == nil {
+		d.preset = make(map[string]bool)
+		for name := range env.root().GetStore() {
+			d.preset[name] = true
+		}
+	}
+
+	for len(d.trail) <= depth {
+		d.trail = append(d.trail, debugSite{})
+	}
+	d.trail = d.trail[:depth+1]
+	site := debugSite{pos: pos, env: env, base: env, depth: depth}
+	if prev := d.trail[depth]; prev.base != nil && encloses(prev.base, env) {
+		site.base = prev.base
+	}
+	d.trail[depth] = site
+
+	// Un breakpoint se cumple al llegar a su línea, no en cada sentencia
+	// de una línea con varias; sí cada vez que se repite la misma.
+	last := d.last
+	d.last = &site
+	reason := ""
+	switch {
+	case d.pause:
+		reason = "pause"
+	case d.mode == stepIn,
+		d.mode == stepOver && depth <= d.depth,
+		d.mode == stepOut && depth < d.depth:
+		reason = "step"
+	case d.breakpoints[d.absPath(pos.Filename)][pos.Line]:
+		if last == nil || last.pos == pos || last.depth != depth ||
+			last.pos.Line != pos.Line || last.pos.Filename != pos.Filename {
+			reason = "breakpoint"
+		}
+	}
+	d.mu.Unlock()
+
+	if reason != "" {
+		d.stop(env, reason, nil)
+	}
+}
+
+// recoverException detiene el programa donde se lanzó una excepción, con
+// las variables de la sentencia todavía a la vista, y la deja seguir.
+func (d *Debugger) recoverException(env *Environment) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, closing := r.(generatorExit); !closing && r != ErrDebugTerminated && d.evaluating.Load() == 0 {
+		d.mu.Lock()
+		reported := sameValue(r, d.raised)
+		d.raised = r
+		d.mu.Unlock()
+		if !reported {
+			d.evaluating.Add(1)
+			value := exceptionValue(r, env)
+			d.evaluating.Add(-1)
+			d.stop(env, "exception", value)
+		}
+	}
+	panic(r)
+}
+
+// handled indica que un catch capturó la excepción en curso: si vuelve a
+// lanzarse la misma, es otra parada.
+func (d *Debugger) handled() {
+	d.mu.Lock()
+	d.raised = nil
+	d.mu.Unlock()
+}
+
+// stop detiene la goroutine actual hasta que el frontend la deja seguir.
+func (d *Debugger) stop(env *Environment, reason string, exception interface{}) {
+	d.stopMu.Lock()
+	defer d.stopMu.Unlock()
+
+	frames := env.callStack.Clone().Frames
+	d.mu.Lock()
+	stop := &DebugStop{Reason: reason, Exception: exception}
+	for depth := len(d.trail) - 1; depth >= 0; depth-- {
+		site := d.trail[depth]
+		if site.env == nil {
+			continue
+		}
+		name := "<main>"
+		if depth > 0 && depth <= len(frames) {
+			name = frames[depth-1].FunctionName
+		}
+		stop.Frames = append(stop.Frames, &DebugFrame{
+			Name: name, Position: site.pos, env: site.env, base: site.base, d: d,
+		})
+	}
+	depth := len(d.trail) - 1
+	d.pause = false
+	d.paused = true
+	terminated := d.terminated
+	d.mu.Unlock()
+	if terminated {
+		panic(ErrDebugTerminated)
+	}
+
+	d.stops <- stop
+	mode := <-d.resume
+
+	d.mu.Lock()
+	d.mode = mode
+	d.depth = depth
+	d.mu.Unlock()
+	if mode == stepTerminate {
+		panic(ErrDebugTerminated)
+	}
+}
+
+// Scopes devuelve las variables visibles en el frame: las locales de la
+// llamada, de la más interna a la más externa, y las globales que declaró
+// el programa (no las librerías).
+func (f *DebugFrame) Scopes() []DebugScope {
+	root := f.env.root()
+	seen := make(map[string]bool)
+	locals := DebugScope{Name: "Locals"}
+	for e := f.env; e != nil && e != root; e = e.outer {
+		locals.Vars = scopeVars(locals.Vars, e, seen, nil)
+		if e == f.base {
+			break
+		}
+	}
+	f.d.mu.Lock()
+	preset := f.d.preset
+	f.d.mu.Unlock()
+	globals := DebugScope{Name: "Globals", Vars: scopeVars(nil, root, seen, preset)}
+	return []DebugScope{locals, globals}
+}
+
+// Evaluate evalúa expr en el entorno del frame, sin detenerse en sus
+// sentencias. Una excepción vuelve como error.
+func (f *DebugFrame) Evaluate(expr string) (result interface{}, err error) {
+	f.d.evaluating.Add(1)
+	defer f.d.evaluating.Add(-1)
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+	return NewParserWithFile(expr, "<eval>").ParseProgram().Eval(f.env), nil
+}
+
+// DescribeValue devuelve cómo muestra el depurador v: su texto, con los
+// strings entre comillas, y su tipo.
+func DescribeValue(v interface{}) (text, typ string) {
+	switch v := v.(type) {
+	case string:
+		text = strconv.Quote(v)
+	case *ObjectInstance:
+		if isError(v) {
+			text = errorString(v)
+			break
+		}
+		var fields []string
+		for _, field := range DebugChildren(v) {
+			value, _ := DescribeValue(field.Value)
+			fields = append(fields, field.Name+": "+value)
+		}
+		text = typeName(v) + "{" + strings.Join(fields, ", ") + "}"
+	default:
+		text = toString(v)
+	}
+	if r := []rune(text); len(r) > describeLimit {
+		text = string(r[:describeLimit]) + "…"
+	}
+	return text, typeName(v)
+}
+
+// describeLimit es el largo máximo del texto de DescribeValue.
+const describeLimit = 200
+
+// DebugChildren devuelve lo que contiene v para desplegarlo en el
+// depurador: los elementos de un array, las claves de un map o los campos
+// de un objeto. Devuelve nil para los demás valores.
+func DebugChildren(v interface{}) []DebugVar {
+	switch v := v.(type) {
+	case []interface{}:
+		vars := make([]DebugVar, len(v))
+		for i, item := range v {
+			vars[i] = DebugVar{Name: strconv.Itoa(i), Value: item}
+		}
+		return vars
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		vars := make([]DebugVar, len(keys))
+		for i, k := range keys {
+			vars[i] = DebugVar{Name: k, Value: v[k]}
+		}
+		return vars
+	case *ObjectInstance:
+		var vars []DebugVar
+		for _, field := range scopeVars(nil, v.Env, map[string]bool{}, objectNames) {
+			if _, method := field.Value.(*UserFunction); !method {
+				vars = append(vars, field)
+			}
+		}
+		return vars
+	}
+	return nil
+}
+
+// objectNames son los nombres del entorno de un objeto que no son campos.
+var objectNames = map[string]bool{"self": true, "this": true, "super": true, "ClassName": true}
+
+// scopeVars agrega a vars las variables de e que no están en seen ni en
+// skip, ordenadas por nombre.
+func scopeVars(vars []DebugVar, e *Environment, seen, skip map[string]bool) []DebugVar {
+	store := e.GetStore()
+	names := make([]string, 0, len(store))
+	for name := range store {
+		if !seen[name] && !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		seen[name] = true
+		vars = append(vars, DebugVar{Name: name, Value: store[name]})
+	}
+	return vars
+}
+
+// encloses indica si env es outer o está dentro de outer.
+func encloses(outer, env *Environment) bool {
+	for e := env; e != nil; e = e.outer {
+		if e == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue compara dos valores de panic sin fallar con los que no son
+// comparables.
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta.Comparable() && a == b
+}
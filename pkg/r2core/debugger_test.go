@@ -0,0 +1,238 @@
+package r2core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const debugCode = `let total = 0
+func add(n) {
+	let doubled = n * 2
+	total = total + doubled
+	return doubled
+}
+for (let i = 0; i < 2; i++) {
+	add(i)
+}
+total`
+
+// debugRun corre code en main.r2 con d y llama a next en cada parada; next
+// decide cómo sigue el programa. Devuelve el resultado o el panic final.
+func debugRun(t *testing.T, d *Debugger, code string, next func(stop *DebugStop)) (result interface{}) {
+	t.Helper()
+	env := newTestEnv()
+	env.SetDebugger(d)
+	done := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- r
+			}
+		}()
+		done <- NewParserWithFile(code, "main.r2").ParseProgram().Eval(env)
+	}()
+	for {
+		select {
+		case stop := <-d.Stops():
+			next(stop)
+		case result = <-done:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatal("the program did not finish")
+		}
+	}
+}
+
+// describeStop resume una parada como "razón línea frame[,frame...]".
+func describeStop(stop *DebugStop) string {
+	var names []string
+	for _, f := range stop.Frames {
+		names = append(names, fmt.Sprintf("%s:%d", f.Name, f.Position.Line))
+	}
+	return fmt.Sprintf("%s %s", stop.Reason, strings.Join(names, ","))
+}
+
+func TestDebugger_Breakpoints(t *testing.T) {
+	d := NewDebugger()
+	d.SetBreakpoints("main.r2", []int{4})
+	var stops []string
+	var locals []string
+	result := debugRun(t, d, debugCode, func(stop *DebugStop) {
+		stops = append(stops, describeStop(stop))
+		scopes := stop.Frames[0].Scopes()
+		var vars []string
+		for _, v := range scopes[0].Vars {
+			vars = append(vars, fmt.Sprintf("%s=%v", v.Name, v.Value))
+		}
+		locals = append(locals, strings.Join(vars, " "))
+		d.Continue()
+	})
+	if result != 2.0 {
+		t.Errorf("expected 2, got %v", result)
+	}
+	want := []string{"breakpoint add:4,<main>:8", "breakpoint add:4,<main>:8"}
+	if strings.Join(stops, "|") != strings.Join(want, "|") {
+		t.Errorf("expected stops %v, got %v", want, stops)
+	}
+	// Las locales son las de la llamada; el índice del for está en el frame
+	// del programa principal
+	if locals[1] != "doubled=2 n=1" {
+		t.Errorf("unexpected locals %q", locals[1])
+	}
+}
+
+func TestDebugger_Globals(t *testing.T) {
+	d := NewDebugger()
+	d.SetBreakpoints("main.r2", []int{10})
+	var globals []string
+	debugRun(t, d, debugCode, func(stop *DebugStop) {
+		for _, v := range stop.Frames[0].Scopes()[1].Vars {
+			globals = append(globals, v.Name)
+		}
+		d.Continue()
+	})
+	// Sin true, false ni nil: sólo lo que declaró el programa
+	if strings.Join(globals, ",") != "add,total" {
+		t.Errorf("unexpected globals %v", globals)
+	}
+}
+
+func TestDebugger_Stepping(t *testing.T) {
+	d := NewDebugger()
+	d.SetBreakpoints("main.r2", []int{8})
+	steps := []func() bool{d.StepIn, d.StepOver, d.StepOut, d.StepOver, d.Continue}
+	var stops []string
+	debugRun(t, d, debugCode, func(stop *DebugStop) {
+		stops = append(stops, describeStop(stop))
+		if len(steps) > 0 {
+			steps[0]()
+			steps = steps[1:]
+		} else {
+			d.Continue()
+		}
+	})
+	want := []string{
+		"breakpoint <main>:8",
+		"step add:3,<main>:8",
+		"step add:4,<main>:8",
+		"step <main>:8", // Vuelve al for: la siguiente vuelta
+		"step <main>:10",
+	}
+	if strings.Join(stops, "|") != strings.Join(want, "|") {
+		t.Errorf("expected stops:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(stops, "\n"))
+	}
+}
+
+func TestDebugger_Pause(t *testing.T) {
+	d := NewDebugger()
+	d.Pause()
+	var stops []string
+	debugRun(t, d, debugCode, func(stop *DebugStop) {
+		stops = append(stops, describeStop(stop))
+		d.Continue()
+	})
+	if len(stops) != 1 || stops[0] != "pause <main>:1" {
+		t.Errorf("expected a single pause on the first line, got %v", stops)
+	}
+}
+
+func TestDebugger_Exceptions(t *testing.T) {
+	code := `func check(n) {
+	let limit = 1
+	if (n > limit) {
+		throw ArgumentError("too big")
+	}
+}
+try { check(5) } catch (e) { }
+check(7)`
+	d := NewDebugger()
+	d.SetBreakOnExceptions(true)
+	var stops []string
+	result := debugRun(t, d, code, func(stop *DebugStop) {
+		e := stop.Exception.(*ObjectInstance)
+		message, _ := e.Env.Get("message")
+		n, _ := stop.Frames[0].Evaluate("n + limit")
+		stops = append(stops, fmt.Sprintf("%s %v %v", describeStop(stop), message, n))
+		d.Continue()
+	})
+	// Una parada por throw, aunque lo capture un catch; la excepción sin
+	// capturar sigue su camino después de la parada
+	want := "exception check:4,<main>:7 too big 6|exception check:4,<main>:8 too big 8"
+	if s := strings.Join(stops, "|"); s != want {
+		t.Errorf("expected %s, got %s", want, s)
+	}
+	if _, ok := result.(*Exception); !ok {
+		t.Errorf("expected the uncaught exception, got %v", result)
+	}
+}
+
+func TestDebugger_Evaluate(t *testing.T) {
+	d := NewDebugger()
+	d.SetBreakpoints("main.r2", []int{5})
+	var results []string
+	debugRun(t, d, debugCode, func(stop *DebugStop) {
+		frame := stop.Frames[0]
+		v, _ := frame.Evaluate("doubled + 100")
+		_, err := frame.Evaluate("missing")
+		results = append(results, fmt.Sprintf("%v %v", v, err != nil))
+		// Lo que se evalúa puede cambiar el estado del programa
+		frame.Evaluate("total = total + 10")
+		d.Continue()
+	})
+	if strings.Join(results, ",") != "100 true,102 true" {
+		t.Errorf("unexpected results %v", results)
+	}
+}
+
+func TestDebugger_Terminate(t *testing.T) {
+	d := NewDebugger()
+	d.SetBreakpoints("main.r2", []int{8})
+	stops := 0
+	result := debugRun(t, d, `let cleaned = false
+try {
+	while (true) {
+		let x = 1
+		x = 2
+		x = 3
+		x = 4
+		x = 5
+	}
+} catch (e) {
+	cleaned = "caught"
+}`, func(stop *DebugStop) {
+		stops++
+		d.Terminate()
+	})
+	err, ok := result.(error)
+	if stops != 1 || !ok || !errors.Is(err, ErrDebugTerminated) {
+		t.Errorf("expected the program to end with ErrDebugTerminated after one stop, got %d stops and %v", stops, result)
+	}
+}
+
+func TestDebugger_Values(t *testing.T) {
+	objs := evalErrors(t, `
+		class Point {
+			let x = 1
+			let y
+			constructor(y) { this.y = y }
+			norm() { return this.x + this.y }
+		}
+		[Point("a"), Error("boom")]
+	`).([]interface{})
+	var got []string
+	for _, v := range []interface{}{"a\nb", 2.5, []interface{}{1.0, "x"}, objs[0], objs[1]} {
+		text, typ := DescribeValue(v)
+		got = append(got, typ+" "+text)
+	}
+	want := []string{`string "a\nb"`, "number 2.5", "array [1 x]", `Point Point{x: 1, y: "a"}`, "Error Error: boom"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	children := DebugChildren(map[string]interface{}{"b": 2.0, "a": 1.0})
+	if len(children) != 2 || children[0].Name != "a" || DebugChildren(1.0) != nil {
+		t.Errorf("unexpected children %v", children)
+	}
+}
@@ -19,7 +19,12 @@ func (ad *ArrayDestructuring) Eval(env *Environment) interface{} {
 	case InterfaceSlice:
 		arr = []interface{}(v)
 	default:
-		panic("ArrayDestructuring: right side must be an array")
+		it, ok := GetIterator(value)
+		if !ok {
+			panic("ArrayDestructuring: right side must be an array")
+		}
+		// De un iterador sólo se piden los elementos que se asignan
+		arr = takeFromIterator(it, len(ad.Names))
 	}
 
 	// Asignar cada elemento a su variable correspondiente
@@ -42,6 +47,20 @@ func (ad *ArrayDestructuring) Eval(env *Environment) interface{} {
 	return nil
 }
 
+// takeFromIterator pide a it hasta n elementos y lo cierra.
+func takeFromIterator(it Iterator, n int) []interface{} {
+	defer CloseIterator(it)
+	arr := make([]interface{}, 0, n)
+	for len(arr) < n {
+		v, done := it.Next()
+		if done {
+			break
+		}
+		arr = append(arr, v)
+	}
+	return arr
+}
+
 // ObjectDestructuring representa la desestructuración de objetos
 // let {name, age} = user
 type ObjectDestructuring struct {
@@ -66,6 +85,14 @@ func (od *ObjectDestructuring) Eval(env *Environment) interface{} {
 		for k, variable := range v {
 			obj[k] = variable.Value
 		}
+	case KeyedCollection:
+		// De un Map se toman las claves string con esos nombres
+		obj = make(map[string]interface{}, len(od.Names))
+		for _, name := range od.Names {
+			if val, ok := v.Get(name); ok {
+				obj[name] = val
+			}
+		}
 	default:
 		panic("ObjectDestructuring: right side must be an object")
 	}
@@ -3,7 +3,6 @@ package r2core
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"runtime"
 	"strconv"
 	"strings"
@@ -124,9 +123,9 @@ func (dsl *DSLDefinition) Eval(env *Environment) interface{} {
 	}
 	dsl.Warnings = warnings
 	if len(warnings) > 0 {
-		fmt.Fprintf(os.Stderr, "DSL '%s': %d grammar warning(s):\n", dslName, len(warnings))
+		fmt.Fprintf(env.Stderr(), "DSL '%s': %d grammar warning(s):\n", dslName, len(warnings))
 		for _, w := range warnings {
-			fmt.Fprintf(os.Stderr, "  - %s\n", w)
+			fmt.Fprintf(env.Stderr(), "  - %s\n", w)
 		}
 	}
 
@@ -220,11 +219,11 @@ func (dsl *DSLDefinition) Eval(env *Environment) interface{} {
 			if len(args) < 2 {
 				return fmt.Errorf("DSL completions: 2 arguments (code, offset) are required")
 			}
-			offset, ok := args[1].(float64)
+			offset, ok := arrayIndex(args[1])
 			if !ok {
 				return fmt.Errorf("DSL completions: second argument (offset) must be a number")
 			}
-			return dsl.Grammar.Completions(code, int(offset))
+			return dsl.Grammar.Completions(code, offset)
 		},
 		"grammar":   dsl.Grammar,
 		"functions": dsl.Functions,
@@ -548,6 +547,9 @@ func (dsl *DSLDefinition) callDSLFunction(fn *FunctionDeclaration, args []interf
 		if limiter.CheckContext() {
 			panic(NewTimeoutError("function_context_canceled", fnEnv.GetContext()))
 		}
+		if limiter.CheckMemoryLimit() {
+			panic(limiter.memoryLimitError())
+		}
 		limiter.EnterFunction(fn.Name)
 		defer limiter.ExitFunction()
 	}
@@ -0,0 +1,182 @@
+package r2core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EnumDeclaration declara un tipo enumerado:
+//
+//	enum Status { Active, Suspended = "S" }
+//
+// Cada miembro tiene un ordinal (su posición), un nombre y un valor: el de su
+// inicializador o, si no tiene, el ordinal.
+type EnumDeclaration struct {
+	Name    string
+	Members []EnumMemberDecl
+}
+
+// EnumMemberDecl es un miembro de un enum tal como se escribió.
+type EnumMemberDecl struct {
+	Name  string
+	Value Node // nil sin inicializador
+}
+
+// EnumType es el valor de un enum declarado. Es inmutable: sus miembros se
+// fijan al evaluar la declaración.
+type EnumType struct {
+	Name    string
+	Members []*EnumValue
+	byName  map[string]*EnumValue
+}
+
+// EnumValue es un miembro de un enum. Hay una sola instancia por miembro, así
+// que la igualdad es la identidad.
+type EnumValue struct {
+	Enum    *EnumType
+	Name    string
+	Ordinal int
+	Value   interface{}
+}
+
+func (ed *EnumDeclaration) Eval(env *Environment) interface{} {
+	enum := &EnumType{Name: ed.Name, byName: make(map[string]*EnumValue, len(ed.Members))}
+	for i, m := range ed.Members {
+		var value interface{} = float64(i)
+		if m.Value != nil {
+			value = m.Value.Eval(env)
+		}
+		member := &EnumValue{Enum: enum, Name: m.Name, Ordinal: i, Value: value}
+		enum.Members = append(enum.Members, member)
+		enum.byName[m.Name] = member
+	}
+	env.Set(ed.Name, enum)
+	return nil
+}
+
+func (e *EnumType) String() string {
+	return "enum " + e.Name + " {" + strings.Join(enumNames(e.Members), ", ") + "}"
+}
+
+// Lookup devuelve el miembro llamado name, o nil si no existe.
+func (e *EnumType) Lookup(name string) *EnumValue {
+	return e.byName[name]
+}
+
+// Values devuelve los miembros en orden de declaración.
+func (e *EnumType) Values() []interface{} {
+	values := make([]interface{}, len(e.Members))
+	for i, m := range e.Members {
+		values[i] = m
+	}
+	return values
+}
+
+// Iterator implementa Iterable: for (s in Status) recorre los miembros.
+func (e *EnumType) Iterator() Iterator {
+	return &sliceIterator{items: e.Values()}
+}
+
+func (v *EnumValue) String() string {
+	return v.Enum.Name + "." + v.Name
+}
+
+// MarshalJSON escribe el miembro por su nombre, también cuando lo serializa
+// una librería con encoding/json (http.JSON, por ejemplo).
+func (v *EnumValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Name)
+}
+
+// evalEnumAccess resuelve Status.Active y los métodos del tipo: values(),
+// valueOf(name) y fromValue(value). Los miembros tienen prioridad sobre los
+// métodos.
+func evalEnumAccess(e *EnumType, member string) interface{} {
+	if m := e.byName[member]; m != nil {
+		return m
+	}
+	switch member {
+	case "name":
+		return e.Name
+	case "values":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return e.Values()
+		})
+	case "valueOf":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic(e.Name + ".valueOf needs 1 argument (the member name)")
+			}
+			name, _ := args[0].(string)
+			m := e.byName[name]
+			if m == nil {
+				panic(fmt.Sprintf("%s has no member %v (members: %s)", e.Name, args[0], strings.Join(enumNames(e.Members), ", ")))
+			}
+			return m
+		})
+	case "fromValue":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic(e.Name + ".fromValue needs 1 argument")
+			}
+			for _, m := range e.Members {
+				if equals(m.Value, args[0]) || isEqual(m.Value, args[0]) {
+					return m
+				}
+			}
+			return nil
+		})
+	}
+	panic(fmt.Sprintf("%s has no member %s", e.Name, member))
+}
+
+// evalEnumValueAccess resuelve las propiedades de un miembro.
+func evalEnumValueAccess(v *EnumValue, member string) interface{} {
+	switch member {
+	case "name":
+		return v.Name
+	case "ordinal":
+		return float64(v.Ordinal)
+	case "value":
+		return v.Value
+	case "toString":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return v.String()
+		})
+	}
+	panic(fmt.Sprintf("%s has no property %s", v, member))
+}
+
+func enumNames(members []*EnumValue) []string {
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// unhandledEnumMembers devuelve los miembros del enum de value que ningún
+// caso sin guard de me cubre. Sirve para explicar por qué un match sobre un
+// enum no encontró caso.
+func (me *MatchExpression) unhandledEnumMembers(value *EnumValue, env *Environment) []*EnumValue {
+	var missing []*EnumValue
+	for _, m := range value.Enum.Members {
+		handled := false
+		for _, c := range me.Cases {
+			if c.Guard != nil {
+				continue
+			}
+			if _, isGuarded := c.Pattern.(*GuardedPattern); isGuarded {
+				continue
+			}
+			if ok, _ := c.Pattern.MatchValue(m, env); ok {
+				handled = true
+				break
+			}
+		}
+		if !handled {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
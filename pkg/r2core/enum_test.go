@@ -0,0 +1,135 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEnum_Members(t *testing.T) {
+	code := `
+		enum Status { Active, Suspended = "S" }
+		enum Level {
+			Low
+			High = 10,
+		}
+		let s = Status.Suspended
+		[s.name, s.ordinal, s.value, Status.Active.value, Level.High.value, Status.values(), s.toString(), Status.name]
+	`
+	got := evalCode(t, code).([]interface{})
+	want := []string{"Suspended", "1", "S", "0", "10", "[Status.Active Status.Suspended]", "Status.Suspended", "Status"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
+func TestEnum_LookupAndEquality(t *testing.T) {
+	code := `
+		enum Status { Active, Suspended = "S" }
+		[
+			Status.valueOf("Active") == Status.Active,
+			Status["Suspended"] == Status.Suspended,
+			Status["Nope"],
+			Status.fromValue("S") == Status.Suspended,
+			Status.fromValue("x"),
+			Status.Active == Status.Suspended,
+			Status.Active != Status.Suspended
+		]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[true true <nil> true <nil> false true]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestEnum_ForInSwitchAndMatch(t *testing.T) {
+	code := `
+		enum Color { Red, Green, Blue }
+		func warm(c) {
+			switch (c) {
+				case Color.Red:
+					return true
+				default:
+					return false
+			}
+		}
+		let out = []
+		for (c in Color) {
+			out = out.push(c.name + ":" + warm(c) + ":" + match c {
+				case Color.Red => "r"
+				case Color.Green => "g"
+				case _ => "other"
+			})
+		}
+		out
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[Red:true:r Green:false:g Blue:false:other]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestEnum_Immutable(t *testing.T) {
+	for _, code := range []string{
+		`enum Status { Active } Status.Active = 1`,
+		`enum Status { Active } Status.Active.value = 1`,
+	} {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), "immutable") {
+					t.Errorf("%s: expected an immutability error, got %v", code, r)
+				}
+			}()
+			evalCode(t, code)
+		}()
+	}
+}
+
+func TestEnum_UnknownMember(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(fmt.Sprint(r), "members: Active, Suspended") {
+			t.Errorf("expected the valid members in the error, got %v", r)
+		}
+	}()
+	evalCode(t, `enum Status { Active, Suspended } Status.valueOf("Closed")`)
+}
+
+func TestEnum_MatchReportsUnhandledMembers(t *testing.T) {
+	code := `
+		enum Status { Active, Suspended, Closed }
+		let s = Status.Closed
+		match s {
+			case Status.Active => 1
+			case Status.Suspended if false => 2
+		}
+	`
+	defer func() {
+		r := recover()
+		msg := fmt.Sprint(r)
+		if !strings.Contains(msg, "Status.Closed") || !strings.Contains(msg, "unhandled members of Status: Suspended, Closed") {
+			t.Errorf("expected the unhandled members in the error, got %v", r)
+		}
+	}()
+	evalCode(t, code)
+}
+
+func TestEnum_ParseErrors(t *testing.T) {
+	for _, src := range []string{
+		`enum Status { Active, Active }`,
+		`enum Status { Active Suspended }`,
+		`enum Status { "Active" }`,
+	} {
+		if _, errs := ParseWithErrors(src, ""); len(errs) == 0 {
+			t.Errorf("expected a parse error for %q", src)
+		}
+	}
+}
+
+func TestEnum_EnumIsStillAnIdentifier(t *testing.T) {
+	if got := evalCode(t, `let enum = 2; enum = enum + 1; enum`); got != 3.0 {
+		t.Errorf("expected 3, got %v", got)
+	}
+}
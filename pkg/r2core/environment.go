@@ -3,7 +3,9 @@ package r2core
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,6 +43,47 @@ type Environment struct {
 	// Execution limiter para prevenir loops infinitos
 	limiter *ExecutionLimiter
 	context context.Context
+
+	// Salida del programa; nil hereda la del outer (os.Stdout/os.Stderr en la raíz)
+	stdout io.Writer
+	stderr io.Writer
+
+	// Política de capacidades del programa (ver SetPolicy); nil hereda la del outer
+	policy interface{}
+
+	// Librerías estándar (ver SetLibraries); nil hereda las del outer
+	libraries *Libraries
+
+	// Resolución de los imports de paquetes (ver SetModuleResolver); nil
+	// hereda la del outer
+	resolver ModuleResolver
+
+	// Comprobación de las anotaciones de tipo al llamar (ver SetStrictTypes);
+	// nil hereda la del outer
+	strictTypes *bool
+
+	// Lo que exporta cada módulo importado, por ruta. Compartido con los
+	// entornos internos y protegido por importedMu, como imported
+	modules map[string]map[string]*Variable
+
+	// Nombres declarados con export en este entorno; nil si no hay ninguno
+	exports map[string]bool
+
+	// Clases de Error predefinidas (ver errorClass); sólo en el entorno
+	// global, nil hasta que el programa nombra alguna
+	errorClasses map[string]map[string]interface{}
+
+	// Depurador del programa (ver SetDebugger); compartido con los entornos
+	// internos, nil si no se está depurando
+	debugger *Debugger
+
+	// Layout de las variables del entorno cuando lo creó newScopedEnv: las
+	// declaradas en la función o el for viven en slots y no en store.
+	// dynamic indica que store recibió algún nombre fuera del layout, que
+	// puede tapar a una variable resuelta más afuera (ver resolveRef)
+	scope   *Scope
+	slots   []atomic.Pointer[Variable]
+	dynamic atomic.Bool
 }
 
 func NewEnvironment() *Environment {
@@ -48,6 +91,7 @@ func NewEnvironment() *Environment {
 		store:       make(map[string]*Variable),
 		outer:       nil,
 		imported:    make(map[string]bool),
+		modules:     make(map[string]map[string]*Variable),
 		importStack: make([]string, 0),
 		importedMu:  &sync.Mutex{},
 		callStack:   &CallStack{Frames: make([]StackFrame, 0)},
@@ -62,6 +106,7 @@ func NewInnerEnv(outer *Environment) *Environment {
 		store:       make(map[string]*Variable),
 		outer:       outer,
 		imported:    outer.imported,    // Share imported map to prevent duplicates
+		modules:     outer.modules,     // Share the exports of the imported modules
 		importStack: outer.importStack, // Share import stack for cyclic detection
 		importedMu:  outer.importedMu,  // Share the mutex guarding imported/importStack
 		callStack:   outer.callStack,   // Share call stack for debugging
@@ -70,6 +115,31 @@ func NewInnerEnv(outer *Environment) *Environment {
 		lookupCache: make(map[string]interface{}),
 		limiter:     outer.limiter, // Compartir limiter con el outer environment
 		context:     outer.context,
+		debugger:    outer.debugger,
+	}
+}
+
+// newScopedEnv crea el entorno de una llamada o de un for con el layout de
+// scope. store y lookupCache se crean recién cuando el entorno recibe un
+// nombre fuera del layout. Sin scope es NewInnerEnv.
+func newScopedEnv(outer *Environment, scope *Scope) *Environment {
+	if scope == nil {
+		return NewInnerEnv(outer)
+	}
+	return &Environment{
+		outer:       outer,
+		imported:    outer.imported,
+		modules:     outer.modules,
+		importStack: outer.importStack,
+		importedMu:  outer.importedMu,
+		callStack:   outer.callStack,
+		Dir:         outer.Dir,
+		CurrentFile: outer.CurrentFile,
+		limiter:     outer.limiter,
+		context:     outer.context,
+		debugger:    outer.debugger,
+		scope:       scope,
+		slots:       make([]atomic.Pointer[Variable], len(scope.names)),
 	}
 }
 
@@ -84,16 +154,103 @@ func (e *Environment) GetStore() map[string]interface{} {
 	for name, variable := range e.store {
 		result[name] = variable.Value
 	}
+	for i := range e.slots {
+		if variable := e.slots[i].Load(); variable != nil {
+			result[e.scope.names[i]] = variable.Value
+		}
+	}
 	return result
 }
 
+// slotIndex retorna el slot de name si es una de las variables del layout
+// del entorno.
+func (e *Environment) slotIndex(name string) (int, bool) {
+	if e.scope == nil {
+		return 0, false
+	}
+	i, ok := e.scope.index[name]
+	return i, ok
+}
+
+// setSlot es Set para una variable del layout. Los slots se reemplazan con
+// CompareAndSwap para que las lecturas no necesiten el lock.
+func (e *Environment) setSlot(i int, name string, value interface{}) {
+	variable := &Variable{Value: value}
+	for {
+		existing := e.slots[i].Load()
+		if existing != nil && existing.IsConst {
+			panic("cannot assign to const variable '" + name + "'")
+		}
+		if e.slots[i].CompareAndSwap(existing, variable) {
+			return
+		}
+	}
+}
+
+// updateSlot es Update para una variable del layout: false si todavía no se
+// declaró en este entorno.
+func (e *Environment) updateSlot(i int, name string, value interface{}) bool {
+	variable := &Variable{Value: value}
+	for {
+		existing := e.slots[i].Load()
+		if existing == nil {
+			return false
+		}
+		if existing.IsConst {
+			panic("cannot assign to const variable '" + name + "'")
+		}
+		if e.slots[i].CompareAndSwap(existing, variable) {
+			return true
+		}
+	}
+}
+
+// resolveRef retorna el entorno donde está la variable ref, ref.depth
+// entornos hacia afuera de e, o nil si la cadena de entornos no es la que vio
+// el resolver: e no es un entorno con el layout de ref, algún entorno del
+// camino tiene otro layout (una construcción que no se resolvió) o recibió
+// nombres fuera del suyo. Entonces la variable se busca por nombre.
+func (e *Environment) resolveRef(ref *varRef) *Environment {
+	return e.resolveRefAt(ref.scope, ref.depth)
+}
+
+// resolveRefAt es resolveRef para una variable que está depth entornos hacia
+// afuera de e, que tiene el layout scope. Una función que corre como frame
+// (ver CompiledCode.Frame) no tiene entorno: sus variables de afuera se
+// buscan desde el de la función con un entorno menos.
+func (e *Environment) resolveRefAt(scope *Scope, depth int) *Environment {
+	if scope == nil || e.scope != scope {
+		return nil
+	}
+	for ; depth > 0; depth-- {
+		if e.dynamic.Load() {
+			return nil
+		}
+		e, scope = e.outer, scope.parent
+		if e == nil || scope == nil || e.scope != scope {
+			return nil
+		}
+	}
+	return e
+}
+
 func (e *Environment) Set(name string, value interface{}) {
+	if i, ok := e.slotIndex(name); ok {
+		e.setSlot(i, name, value)
+		return
+	}
+	if e.scope != nil {
+		e.dynamic.Store(true)
+	}
 	e.storeMu.Lock()
 	// Check if variable already exists and is const
 	if existing, exists := e.store[name]; exists && existing.IsConst {
 		e.storeMu.Unlock()
 		panic("cannot assign to const variable '" + name + "'")
 	}
+	if e.store == nil {
+		e.store = make(map[string]*Variable)
+	}
 	e.store[name] = &Variable{Value: value, IsConst: false}
 	e.storeMu.Unlock()
 	// Limpiar cache cuando se modifica una variable
@@ -104,12 +261,24 @@ func (e *Environment) Set(name string, value interface{}) {
 
 // SetConst creates an immutable variable
 func (e *Environment) SetConst(name string, value interface{}) {
+	if i, ok := e.slotIndex(name); ok {
+		if !e.slots[i].CompareAndSwap(nil, &Variable{Value: value, IsConst: true}) {
+			panic("variable '" + name + "' already declared")
+		}
+		return
+	}
+	if e.scope != nil {
+		e.dynamic.Store(true)
+	}
 	e.storeMu.Lock()
 	// Check if variable already exists
 	if _, exists := e.store[name]; exists {
 		e.storeMu.Unlock()
 		panic("variable '" + name + "' already declared")
 	}
+	if e.store == nil {
+		e.store = make(map[string]*Variable)
+	}
 	e.store[name] = &Variable{Value: value, IsConst: true}
 	e.storeMu.Unlock()
 	// Limpiar cache cuando se modifica una variable
@@ -121,6 +290,26 @@ func (e *Environment) SetConst(name string, value interface{}) {
 // Update modifica una variable existente en el scope correcto
 func (e *Environment) Update(name string, value interface{}) {
 	// Buscar la variable en el scope actual
+	if i, ok := e.slotIndex(name); ok {
+		if e.updateSlot(i, name, value) {
+			return
+		}
+	} else if e.updateStore(name, value) {
+		return
+	}
+
+	// Si no está en el scope actual, buscar en el outer scope
+	if e.outer != nil {
+		e.outer.Update(name, value)
+		return
+	}
+
+	// Si no existe en ningún scope, crear en el scope actual
+	e.Set(name, value)
+}
+
+// updateStore es Update para una variable de store: false si no está.
+func (e *Environment) updateStore(name string, value interface{}) bool {
 	e.storeMu.Lock()
 	if existing, ok := e.store[name]; ok {
 		if existing.IsConst {
@@ -133,21 +322,48 @@ func (e *Environment) Update(name string, value interface{}) {
 		e.lookupCacheMu.Lock()
 		delete(e.lookupCache, name)
 		e.lookupCacheMu.Unlock()
-		return
+		return true
 	}
 	e.storeMu.Unlock()
+	return false
+}
+
+func (e *Environment) Get(name string) (interface{}, bool) {
+	// Una variable del layout está en su slot o todavía no se declaró aquí
+	if i, ok := e.slotIndex(name); ok {
+		if variable := e.slots[i].Load(); variable != nil {
+			return variable.Value, true
+		}
+	} else if val, ok := e.getStore(name); ok {
+		return val, true
+	}
 
-	// Si no está en el scope actual, buscar en el outer scope
 	if e.outer != nil {
-		e.outer.Update(name, value)
-		return
+		return e.outer.Get(name)
 	}
 
-	// Si no existe en ningún scope, crear en el scope actual
-	e.Set(name, value)
+	if e.outer != nil {
+		return e.outer.Get(name)
+	}
+	// En el entorno global, un nombre desconocido puede ser de una librería
+	// estándar que todavía no se cargó
+	if e.libraries != nil && e.libraries.resolve(e, name) {
+		return e.Get(name)
+	}
+	// O una de las clases de Error predefinidas, que no se guardan en el
+	// entorno para que el programa pueda declarar otras con el mismo nombre
+	if errorClassNames[name] {
+		return e.errorClass(name), true
+	}
+	// Y lo mismo los constructores de las colecciones nativas
+	if ct, ok := collectionTypes[name]; ok {
+		return ct, true
+	}
+	return nil, false
 }
 
-func (e *Environment) Get(name string) (interface{}, bool) {
+// getStore busca name en store, pasando por el cache de lookups.
+func (e *Environment) getStore(name string) (interface{}, bool) {
 	// Fast path: buscar en cache optimizado
 	e.lookupCacheMu.RLock()
 	if val, ok := e.lookupCache[name]; ok {
@@ -161,22 +377,19 @@ func (e *Environment) Get(name string) (interface{}, bool) {
 	e.storeMu.RLock()
 	variable, ok := e.store[name]
 	e.storeMu.RUnlock()
-	if ok {
-		val := variable.Value
-		// Cachear solo si el cache no está lleno (evitar memory leak)
-		e.lookupCacheMu.Lock()
-		if len(e.lookupCache) < 100 { // Limitar tamaño del cache
-			e.lookupCache[name] = val
-		}
-		e.lookupCacheMu.Unlock()
-		atomic.AddInt64(&e.cacheMisses, 1)
-		return val, true
+	if !ok {
+		return nil, false
 	}
-
-	if e.outer != nil {
-		return e.outer.Get(name)
+	val := variable.Value
+	// Cachear solo si el cache no está lleno (evitar memory leak); los
+	// entornos con layout no tienen cache
+	e.lookupCacheMu.Lock()
+	if e.lookupCache != nil && len(e.lookupCache) < 100 { // Limitar tamaño del cache
+		e.lookupCache[name] = val
 	}
-	return nil, false
+	e.lookupCacheMu.Unlock()
+	atomic.AddInt64(&e.cacheMisses, 1)
+	return val, true
 }
 
 func (e *Environment) Run(parser *Parser) (result interface{}) {
@@ -201,18 +414,39 @@ func (e *Environment) Run(parser *Parser) (result interface{}) {
 			}
 		}()//*/
 
+	return e.runMain(ast)
+}
+
+// RunCompiled ejecuta código producido por Compile igual que Run ejecuta el
+// programa parseado.
+func (e *Environment) RunCompiled(code *CompiledCode) interface{} {
+	defer wg.Wait()
+	wg = sync.WaitGroup{}
+	return e.runMain(code)
+}
+
+// RunProgram ejecuta un programa ya parseado (por ejemplo con
+// ParseWithErrors) igual que Run.
+func (e *Environment) RunProgram(prog *Program) interface{} {
+	defer wg.Wait()
+	wg = sync.WaitGroup{}
+	return e.runMain(prog)
+}
+
+// runMain evalúa el nivel superior del programa y luego llama a main() si
+// está definida.
+func (e *Environment) runMain(prog Node) (result interface{}) {
 	e.SetCurrenFx(".")
 
 	// Ejecutar
-	result = ast.Eval(e)
+	result = prog.Eval(e)
 
 	// Llamar a main() si está
 	mainVal, ok := e.Get("main")
 	if ok {
 		mainFn, isFn := mainVal.(*UserFunction)
 		if !isFn {
-			fmt.Println("Error: ‘main’ is not a function.")
-			os.Exit(1)
+			panic("‘main’ is not a function")
 		}
 		result = mainFn.Call()
 	}
@@ -233,6 +467,83 @@ func (e *Environment) SetCurrenFx(name string) {
 	e.currenFxMu.Unlock()
 }
 
+// SetOutput redirige la salida estándar y de errores del programa (print,
+// console, etc.). Un writer nil vuelve a heredar el del entorno exterior.
+func (e *Environment) SetOutput(stdout, stderr io.Writer) {
+	e.stdout = stdout
+	e.stderr = stderr
+}
+
+// Stdout retorna el writer donde el programa escribe su salida. Sin
+// SetOutput es os.Stdout, leído en cada llamada.
+func (e *Environment) Stdout() io.Writer {
+	for env := e; env != nil; env = env.outer {
+		if env.stdout != nil {
+			return env.stdout
+		}
+	}
+	return os.Stdout
+}
+
+// Stderr retorna el writer de errores del programa (os.Stderr por defecto).
+func (e *Environment) Stderr() io.Writer {
+	for env := e; env != nil; env = env.outer {
+		if env.stderr != nil {
+			return env.stderr
+		}
+	}
+	return os.Stderr
+}
+
+// FileGuard valida las rutas de los archivos que lee el intérprete (imports).
+// Lo implementa la política de capacidades instalada con SetPolicy.
+type FileGuard interface {
+	CheckRead(path string) error
+}
+
+// SetPolicy instala la política de capacidades del programa. r2core sólo la
+// consulta como FileGuard; las librerías que la definen (r2libs) la leen con
+// Policy para restringir sus builtins.
+func (e *Environment) SetPolicy(policy interface{}) {
+	e.policy = policy
+}
+
+// Policy retorna la política instalada en este entorno o en uno exterior, o
+// nil si no hay ninguna.
+func (e *Environment) Policy() interface{} {
+	for env := e; env != nil; env = env.outer {
+		if env.policy != nil {
+			return env.policy
+		}
+	}
+	return nil
+}
+
+// ModuleResolver resuelve los imports de paquetes, los que no son rutas:
+// import "strutil" o import "strutil/text.r2". r2core no conoce los
+// manifiestos; el intérprete instala uno con SetModuleResolver.
+type ModuleResolver interface {
+	// Resolve devuelve el archivo que importa spec. ok es false si spec no
+	// nombra un paquete conocido; entonces se resuelve como ruta relativa.
+	Resolve(spec string) (path string, ok bool, err error)
+}
+
+// SetModuleResolver instala la resolución de los imports de paquetes.
+func (e *Environment) SetModuleResolver(resolver ModuleResolver) {
+	e.resolver = resolver
+}
+
+// ModuleResolver retorna la resolución instalada en este entorno o en uno
+// exterior, o nil.
+func (e *Environment) ModuleResolver() ModuleResolver {
+	for env := e; env != nil; env = env.outer {
+		if env.resolver != nil {
+			return env.resolver
+		}
+	}
+	return nil
+}
+
 // GetLimiter retorna el ExecutionLimiter
 func (e *Environment) GetLimiter() *ExecutionLimiter {
 	if e.limiter == nil {
@@ -262,6 +573,29 @@ func (e *Environment) SetLimits(maxIter int64, maxDepth int, maxTime time.Durati
 	limiter.SetLimits(maxIter, maxDepth, maxTime)
 }
 
+// SetTimeout limita el tiempo total de ejecución a partir de ahora: fija
+// MaxExecutionTime y reemplaza el contexto del limiter y del entorno por uno
+// que se cancela al vencer el plazo. Un timeout <= 0 quita el límite.
+func (e *Environment) SetTimeout(timeout time.Duration) {
+	limiter := e.GetLimiter()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+		timeout = 0
+	}
+
+	limiter.mu.Lock()
+	limiter.MaxExecutionTime = timeout
+	limiter.StartTime = time.Now()
+	limiter.mu.Unlock()
+	limiter.Context = ctx
+	limiter.Cancel = cancel
+	e.context = ctx
+}
+
 // ExecuteWithTimeout ejecuta código con un timeout específico
 func (e *Environment) ExecuteWithTimeout(node Node, timeout time.Duration) interface{} {
 	// Crear contexto con timeout
@@ -355,3 +689,16 @@ func (e *Environment) MarkImported(filePath string) {
 	e.imported[filePath] = true
 	e.importedMu.Unlock()
 }
+
+// ImportedFiles returns, sorted, the files imported so far by the program,
+// including the ones that failed to load
+func (e *Environment) ImportedFiles() []string {
+	e.importedMu.Lock()
+	defer e.importedMu.Unlock()
+	files := make([]string, 0, len(e.imported))
+	for file := range e.imported {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
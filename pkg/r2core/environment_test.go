@@ -2,6 +2,7 @@ package r2core
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -490,3 +491,206 @@ func TestEnvironment_ConcurrentModuleStoreSnapshot(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+// evalResolved evalúa code resolviendo o no sus variables y devuelve el
+// resultado o el panic con el que terminó, como texto.
+func evalResolved(code string, resolve bool) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = fmt.Sprint("panic: ", r)
+		}
+	}()
+	env := newTestEnv()
+	parser := NewParser(code)
+	parser.SetResolve(resolve)
+	return fmt.Sprint(parser.ParseProgram().Eval(env))
+}
+
+func TestEnvironment_ResolvedSemantics(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"locals and params", `
+			func f(a, b = a * 2) { let c = a + b; c = c * 10; return c }
+			f(1) + f(2, 3)`, "80"},
+		{"shadowing", `
+			let x = "global"
+			func f(x) { func g() { let x = "inner"; return x } return x + g() }
+			f("param") + x`, "paraminnerglobal"},
+		{"closures", `
+			func counter() { let n = 0; return () => { n = n + 1; return n } }
+			let c1 = counter()
+			let c2 = counter()
+			c1(); c1(); c2()
+			[c1(), c2()]`, "[3 2]"},
+		{"declared later", `
+			func f() {
+				let read = () => y
+				let y = 5
+				return read() + y
+			}
+			f()`, "10"},
+		{"outer before local let", `
+			let v = 1
+			func f() { let before = v; let v = 2; return [before, v] }
+			f()`, "[1 2]"},
+		{"assign undeclared", `
+			func f() { created = 7 }
+			f()
+			created`, "7"},
+		{"for scope", `
+			func f() {
+				let last = nil
+				for (let i = 0; i < 3; i++) { let k = i * i; last = () => k + i }
+				return last()
+			}
+			f()`, "7"},
+		{"for variable outside", `
+			func f() { for (let i = 0; i < 3; i++) { } return i }
+			f()`, "Undeclared variable: i"},
+		{"for-in and catch", `
+			func f(items) {
+				let total = 0
+				for (item in items) { total = total + item + $v }
+				try { throw 5 } catch (total) { total = total * 100 }
+				return total
+			}
+			f([1, 2, 3])`, "9"},
+		{"destructuring and switch", `
+			func f() {
+				let a = 1
+				let g = () => a
+				let [a2, b2] = [10, 20]
+				switch (a) { case 1: let a = 99 }
+				return g() + a2 + b2 + a
+			}
+			f()`, "32"},
+		{"const", `
+			func f() { const k = 1; k = 2 }
+			f()`, "panic: cannot assign to const variable 'k'"},
+		{"methods", `
+			class Acc {
+				let total = 0
+				add(n) { let adder = () => { this.total = this.total + n }; adder(); return this }
+			}
+			Acc().add(2).add(3).total`, "5"},
+		{"recursion", `
+			func fib(n) { if (n < 2) { return n } return fib(n - 1) + fib(n - 2) }
+			fib(15)`, "610"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, byName := evalResolved(tt.code, true), evalResolved(tt.code, false)
+			if resolved != byName {
+				t.Errorf("resolved %q, by name %q", resolved, byName)
+			}
+			if !strings.Contains(resolved, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, resolved)
+			}
+		})
+	}
+}
+
+func TestEnvironment_ResolveSlots(t *testing.T) {
+	prog := NewParser(`
+		let g = 1
+		func f(a) {
+			let b = a
+			return () => a + b + g
+		}`).ParseProgram()
+	fn := prog.Statements[1].(*FunctionDeclaration)
+	if names := strings.Join(fn.Body.scope.names, ","); names != "a,b" {
+		t.Errorf("unexpected layout %s", names)
+	}
+	arrow := fn.Body.Statements[1].(*ReturnStatement).Value.(*ArrowFunction)
+	sum := arrow.Body.(*BinaryExpression)
+	a := sum.Left.(*BinaryExpression).Left.(*Identifier)
+	b := sum.Left.(*BinaryExpression).Right.(*Identifier)
+	if a.ref == nil || a.ref.depth != 1 || a.ref.slot != 0 || b.ref == nil || b.ref.slot != 1 {
+		t.Errorf("unexpected refs %+v %+v", a.ref, b.ref)
+	}
+	// Las variables del programa principal se buscan por nombre
+	if g := sum.Right.(*Identifier); g.ref != nil {
+		t.Errorf("expected g to be unresolved, got %+v", g.ref)
+	}
+}
+
+func TestEnvironment_ScopedEnv(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", "outer")
+	scope := &Scope{names: []string{"x", "k"}, index: map[string]int{"x": 0, "k": 1}}
+	env := newScopedEnv(outer, scope)
+
+	// Un slot vacío es una variable que todavía no se declaró aquí
+	if val, _ := env.Get("x"); val != "outer" {
+		t.Errorf("expected the outer x, got %v", val)
+	}
+	env.Update("x", "updated")
+	if val, _ := outer.Get("x"); val != "updated" {
+		t.Errorf("expected Update to reach the outer x, got %v", val)
+	}
+	env.Set("x", "local")
+	env.SetConst("k", 1)
+	if val, _ := env.Get("x"); val != "local" || env.store != nil || env.dynamic.Load() {
+		t.Errorf("expected x in its slot, got %v and store %v", val, env.store)
+	}
+	env.Set("extra", true)
+	store := env.GetStore()
+	if !env.dynamic.Load() || len(store) != 3 || store["k"] != 1 {
+		t.Errorf("unexpected store %v", store)
+	}
+
+	// Un nombre fuera del layout tapa a la variable resuelta más afuera
+	inner := newScopedEnv(env, &Scope{parent: scope, index: map[string]int{}})
+	ref := &varRef{scope: inner.scope, depth: 1, slot: 0}
+	if inner.resolveRef(ref) != env {
+		t.Error("expected the reference to resolve to the outer environment")
+	}
+	inner.Set("x", "shadow")
+	if val, _ := inner.Get("x"); val != "shadow" || inner.resolveRef(ref) != nil {
+		t.Errorf("expected the shadowing x to win, got %v", val)
+	}
+	if NewInnerEnv(env).resolveRef(ref) != nil {
+		t.Error("expected an environment without layout not to resolve")
+	}
+
+	defer func() {
+		if r := recover(); r != "variable 'k' already declared" {
+			t.Errorf("unexpected panic %v", r)
+		}
+	}()
+	env.SetConst("k", 2)
+}
+
+// TestEnvironment_ConcurrentSlots llama a la misma clausura desde varios
+// goroutines: las lecturas y escrituras de slots no usan locks. Run with
+// -race to catch a regression.
+func TestEnvironment_ConcurrentSlots(t *testing.T) {
+	env := NewEnvironment()
+	NewParser(`
+		func counter() {
+			let n = 0
+			return () => { n = n + 1; let seen = n; return seen }
+		}
+		let next = counter()
+	`).ParseProgram().Eval(env)
+	next, _ := env.Get("next")
+	fn := next.(*UserFunction)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				fn.Call()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fn.Call().(float64); n < 1 || n > 1001 {
+		t.Errorf("unexpected count %v", n)
+	}
+}
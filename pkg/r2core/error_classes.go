@@ -0,0 +1,308 @@
+package r2core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errorPrelude declara las clases de Error predefinidas. Cada entorno
+// global las crea la primera vez que el programa nombra alguna (ver
+// Environment.Get), así que un programa que no las usa no paga nada.
+//
+// Un throw completa position y stack de la instancia que lanza; los errores
+// del intérprete (una variable sin declarar, un timeout...) se lanzan como
+// *RuntimeError con su clase y llegan al catch ya convertidos en instancias
+// de estas clases (ver exceptionValue).
+const errorPrelude = `
+class Error {
+	let name = "Error"
+	let message
+	let cause
+	let position
+	let stack = []
+	constructor(message, cause) {
+		this.message = message
+		this.cause = cause
+	}
+	toString() { return this.name + ": " + this.message }
+}
+class TypeError extends Error { let name = "TypeError" }
+class ArgumentError extends Error { let name = "ArgumentError" }
+class ReferenceError extends Error { let name = "ReferenceError" }
+class TimeoutError extends Error { let name = "TimeoutError" }
+class LimitError extends Error { let name = "LimitError" }
+class ArithmeticError extends Error { let name = "ArithmeticError" }
+`
+
+// errorPreludeFile es el nombre de archivo de las posiciones de
+// errorPrelude; el depurador no se detiene en sus sentencias.
+const errorPreludeFile = "<prelude>"
+
+// errorClassNames son los nombres de las clases de errorPrelude.
+var errorClassNames = map[string]bool{
+	"Error": true, "TypeError": true, "ArgumentError": true,
+	"ReferenceError": true, "TimeoutError": true, "LimitError": true,
+	"ArithmeticError": true,
+}
+
+// ErrorClassNames devuelve los nombres de las clases de Error predefinidas.
+func ErrorClassNames() []string {
+	names := make([]string, 0, len(errorClassNames))
+	for name := range errorClassNames {
+		names = append(names, name)
+	}
+	return names
+}
+
+// errorKey marca los blueprints de las clases de Error y de sus subclases,
+// que lo heredan al declararse.
+const errorKey = "$error"
+
+var (
+	errorPreludeProgram = sync.OnceValue(func() *Program {
+		return NewParserWithFile(errorPrelude, errorPreludeFile).ParseProgram()
+	})
+	errorClassesMu sync.Mutex
+)
+
+// errorClass devuelve el blueprint de la clase de Error name del entorno
+// global de e, creándolas todas si todavía no existen.
+func (e *Environment) errorClass(name string) map[string]interface{} {
+	root := e.root()
+	errorClassesMu.Lock()
+	classes := root.errorClasses
+	errorClassesMu.Unlock()
+	if classes == nil {
+		scratch := NewEnvironment()
+		errorPreludeProgram().Eval(scratch)
+		created := make(map[string]map[string]interface{}, len(errorClassNames))
+		for n := range errorClassNames {
+			class, _ := scratch.lookupLocal(n)
+			blueprint := class.(map[string]interface{})
+			blueprint[errorKey] = true
+			created[n] = blueprint
+		}
+		errorClassesMu.Lock()
+		if root.errorClasses == nil {
+			root.errorClasses = created
+		}
+		classes = root.errorClasses
+		errorClassesMu.Unlock()
+	}
+	return classes[name]
+}
+
+// isError indica si obj es una instancia de Error o de una subclase.
+func isError(obj *ObjectInstance) bool {
+	marked, _ := obj.Class[errorKey].(bool)
+	return marked
+}
+
+// Exception es el valor del panic de un throw: Value es lo lanzado, sea
+// cual sea su tipo. Si nadie lo captura llega a Go como un error.
+type Exception struct {
+	Value interface{}
+}
+
+func (ex *Exception) Error() string {
+	obj, ok := ex.Value.(*ObjectInstance)
+	if !ok || !isError(obj) {
+		return toString(ex.Value)
+	}
+	if obj.text != "" {
+		return obj.text
+	}
+	text := CreatePositionError(errorPosition(obj), errorString(obj))
+	stack, _ := obj.Env.lookupLocal("stack")
+	if items, ok := arrayItems(stack); ok && len(items) > 0 {
+		text += "\n" + callStackHeader
+		for _, line := range items {
+			text += "    " + toString(line) + "\n"
+		}
+	}
+	return text
+}
+
+// Unwrap devuelve el error de Go del que viene la excepción, como el
+// ErrTimeout de un TimeoutError, para que errors.Is lo siga encontrando.
+func (ex *Exception) Unwrap() error {
+	if obj, ok := ex.Value.(*ObjectInstance); ok {
+		return obj.err
+	}
+	if err, ok := ex.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// ThrowError lanza, como un throw de R2, una instancia nueva de la clase de
+// Error class con message.
+func ThrowError(env *Environment, class, message string) {
+	obj := newError(env, class, message, nil)
+	setErrorSite(obj, nil, stackLines(env.callStack.Clone().Frames))
+	panic(&Exception{Value: obj})
+}
+
+// newError crea una instancia de la clase de Error class. err es el error
+// de Go que representa, si lo hay.
+func newError(env *Environment, class, message string, err error) *ObjectInstance {
+	obj := instantiateObject(env, env.errorClass(class), []interface{}{message})
+	obj.err = err
+	return obj
+}
+
+// setErrorSite guarda dónde se lanzó el error obj.
+func setErrorSite(obj *ObjectInstance, pos *PositionInfo, stack []interface{}) {
+	if pos != nil {
+		obj.Env.Set("position", map[string]interface{}{
+			"file": pos.Filename,
+			"line": float64(pos.Line),
+			"col":  float64(pos.Col),
+		})
+	}
+	obj.Env.Set("stack", stack)
+}
+
+// errorPosition devuelve la posición guardada en obj, o nil.
+func errorPosition(obj *ObjectInstance) *PositionInfo {
+	value, _ := obj.Env.lookupLocal("position")
+	pos, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	file, _ := pos["file"].(string)
+	line, _ := pos["line"].(float64)
+	col, _ := pos["col"].(float64)
+	return &PositionInfo{Filename: file, Line: int(line), Col: int(col)}
+}
+
+// ErrorText devuelve el texto con el que se muestra v si es una instancia
+// de Error, para las funciones de las librerías que imprimen valores.
+func ErrorText(v interface{}) (string, bool) {
+	obj, ok := v.(*ObjectInstance)
+	if !ok || !isError(obj) {
+		return "", false
+	}
+	return errorString(obj), true
+}
+
+// errorString es el texto de obj al concatenarlo o mostrarlo: lo que
+// devuelve su método toString. Un error del intérprete se muestra con el
+// texto con el que se lanzó, con su posición, como antes de que llegara al
+// catch convertido en una instancia de Error.
+func errorString(obj *ObjectInstance) string {
+	if obj.text != "" {
+		return obj.text
+	}
+	if fn, ok := obj.Env.lookupLocal("toString"); ok {
+		if method, ok := fn.(*UserFunction); ok {
+			return toString(method.Call())
+		}
+	}
+	message, _ := obj.Env.lookupLocal("message")
+	return toString(message)
+}
+
+// stackLines convierte las frames de una pila de llamadas en el stack de un
+// Error: la llamada más reciente primero.
+func stackLines(frames []StackFrame) []interface{} {
+	lines := make([]interface{}, 0, len(frames))
+	for i := len(frames) - 1; i >= 0; i-- {
+		lines = append(lines, frames[i].String())
+	}
+	return lines
+}
+
+// RuntimeError es un error del intérprete o de una librería: Class es la
+// clase de Error con la que lo recibe un catch, y Position y Stack dicen
+// dónde ocurrió. Un error lanzado sin posición desde un helper la recibe de
+// la llamada o la operación que lo ejecutaba (ver locateError).
+type RuntimeError struct {
+	Class    string
+	Message  string
+	Position *PositionInfo
+	Stack    []StackFrame
+}
+
+// Error es el texto con el que se muestra el error: el mensaje con su
+// posición y la pila de llamadas.
+func (e *RuntimeError) Error() string {
+	return CreatePositionError(e.Position, e.Message) + formatFrames(e.Stack)
+}
+
+// PanicWithClass lanza un *RuntimeError de la clase de Error class, en pos
+// y con la pila de llamadas callStack.
+func PanicWithClass(class string, pos *PositionInfo, message string, callStack *CallStack) {
+	panic(&RuntimeError{Class: class, Message: message, Position: pos, Stack: callFrames(callStack)})
+}
+
+// newRuntimeError crea el error que lanza un helper que no conoce la
+// posición; locateError le pone la de la llamada o la operación.
+func newRuntimeError(class, message string) *RuntimeError {
+	return &RuntimeError{Class: class, Message: message}
+}
+
+// callFrames devuelve una copia de las frames de callStack, que puede ser nil.
+func callFrames(callStack *CallStack) []StackFrame {
+	if callStack == nil {
+		return nil
+	}
+	return callStack.Clone().Frames
+}
+
+// locateError es un defer de las llamadas a builtins y de las operaciones:
+// completa con pos y la pila de env un *RuntimeError que llegó sin posición,
+// y convierte en uno de la clase Error el string con el que panican las
+// librerías. Los demás panics siguen igual.
+func locateError(env *Environment, pos *PositionInfo) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	switch v := r.(type) {
+	case *RuntimeError:
+		if v.Position == nil && pos != nil {
+			v.Position = pos
+			v.Stack = callFrames(env.callStack)
+		}
+	case string:
+		r = &RuntimeError{Class: "Error", Message: v, Position: pos, Stack: callFrames(env.callStack)}
+	}
+	panic(r)
+}
+
+// exceptionValue devuelve lo que recibe un catch por el panic r: el valor
+// lanzado con throw tal cual, y los errores del intérprete convertidos en
+// instancias de Error.
+func exceptionValue(r interface{}, env *Environment) interface{} {
+	switch v := r.(type) {
+	case *Exception:
+		return v.Value
+	case *RuntimeError:
+		obj := newError(env, v.Class, v.Message, v)
+		obj.text = v.Error()
+		setErrorSite(obj, v.Position, stackLines(v.Stack))
+		return obj
+	case *InfiniteLoopError:
+		class := "LimitError"
+		if errors.Is(v, ErrTimeout) || errors.Is(v, ErrInfiniteLoop) {
+			class = "TimeoutError"
+		}
+		obj := newError(env, class, v.Error(), v)
+		obj.text = v.Error()
+		setErrorSite(obj, nil, []interface{}{})
+		return obj
+	case error:
+		obj := newError(env, "Error", v.Error(), v)
+		obj.text = v.Error()
+		setErrorSite(obj, nil, []interface{}{})
+		return obj
+	}
+
+	text := fmt.Sprint(r)
+	obj := newError(env, "Error", text, nil)
+	obj.text = text
+	setErrorSite(obj, nil, []interface{}{})
+	return obj
+}
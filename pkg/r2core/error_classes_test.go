@@ -0,0 +1,190 @@
+package r2core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func evalErrors(t *testing.T, code string) interface{} {
+	t.Helper()
+	env := newTestEnv()
+	return NewParserWithFile(code, "main.r2").ParseProgram().Eval(env)
+}
+
+func TestErrors_ThrowAnyValue(t *testing.T) {
+	got := evalErrors(t, `
+		let out = []
+		for (v in [1, "s", {code: 7}, [1, 2]]) {
+			try { throw $v } catch (e) { out = out + [e] }
+		}
+		out
+	`).([]interface{})
+	if got[0] != 1.0 || got[1] != "s" || got[2].(map[string]interface{})["code"] != 7.0 || len(got[3].([]interface{})) != 2 {
+		t.Errorf("expected the thrown values unchanged, got %v", got)
+	}
+}
+
+func TestErrors_ErrorInstances(t *testing.T) {
+	got := evalErrors(t, `
+		class NotFound extends Error { let name = "NotFound" }
+		func find(key) { throw NotFound("no " + key, "db") }
+		func lookup(key) { return find(key) }
+		let e = nil
+		try { lookup("k") } catch (err) { e = err }
+		[e.name, e.message, e.cause, e instanceof Error, e.position.line, e.stack, "" + e, Error("x").stack]
+	`).([]interface{})
+	// lookup no está en el stack: return find(key) es una llamada de cola
+	// (ver TestTailCall_ErrorStack)
+	want := []string{"NotFound", "no k", "db", "true", "3", "[main.r2:3:6 in find()]", "NotFound: no k", "[]"}
+	if s := toStrings(got); strings.Join(s, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, s)
+	}
+}
+
+func TestErrors_TypedCatch(t *testing.T) {
+	got := evalErrors(t, `
+		func kind(f) {
+			try {
+				f()
+			} catch (e: TypeError | ArgumentError) {
+				return "arg " + e.message
+			} catch (e: Error) {
+				return e.name
+			} catch (e: string) {
+				return "string " + e
+			} catch {
+				return "other"
+			}
+		}
+		[kind(() => { throw TypeError("t") }), kind(() => { throw ArgumentError("a") }),
+		 kind(() => { throw Error("e") }), kind(() => { throw "s" }), kind(() => { throw 1 }),
+		 kind(() => missing)]
+	`).([]interface{})
+	want := "arg t,arg a,Error,string s,other,ReferenceError"
+	if s := strings.Join(toStrings(got), ","); s != want {
+		t.Errorf("expected %s, got %s", want, s)
+	}
+
+	// Sin un catch que lo acepte, el valor sigue después del finally
+	got = evalErrors(t, `
+		let ran = false
+		let outer = nil
+		try {
+			try { throw 5 } catch (e: string) { outer = "wrong" } finally { ran = true }
+		} catch (e) { outer = e }
+		[outer, ran]
+	`).([]interface{})
+	if got[0] != 5.0 || got[1] != true {
+		t.Errorf("expected the unmatched exception to propagate after finally, got %v", got)
+	}
+}
+
+func TestErrors_RuntimeErrors(t *testing.T) {
+	got := evalErrors(t, `
+		func div(a, b) { return a / b }
+		let e = nil
+		try { div(1, 0) } catch (err: Error) { e = err }
+		[e.name, e.message, e.position.file, e.position.line, e.stack]
+	`).([]interface{})
+	want := "ArithmeticError,Division by zero,main.r2,2,[main.r2:2:6 in div()]"
+	if s := strings.Join(toStrings(got), ","); s != want {
+		t.Errorf("expected %s, got %s", want, s)
+	}
+
+	// Como texto siguen siendo el mensaje con su posición
+	text := evalErrors(t, `let e = nil
+try { 1 / 0 } catch (err) { e = err }
+"" + e`)
+	if text != "main.r2:2:9: Division by zero" {
+		t.Errorf("expected the original text, got %q", text)
+	}
+	msg := recoverMessage(func() { evalErrors(t, "try { 1 / 0 } catch (e) { throw e }") })
+	if msg != "main.r2:1:9: Division by zero" {
+		t.Errorf("expected a rethrown error to keep its text, got %q", msg)
+	}
+}
+
+// Los errores del intérprete y de las librerías llegan al catch con su clase
+// y la posición de la operación o la llamada que falló.
+func TestErrors_RuntimeErrorClasses(t *testing.T) {
+	env := newTestEnv()
+	env.Set("sqrt", BuiltinFunction(func(args ...interface{}) interface{} { return toFloat(args[0]) }))
+	env.Set("legacy", BuiltinFunction(func(args ...interface{}) interface{} { panic("legacy: bad argument") }))
+	cases := map[string]string{
+		`let x = 1; x()`:           "TypeError 1:13",
+		`return {} - 1`:            "TypeError 1:11",
+		`sqrt("x")`:                "TypeError 1:5",
+		`let z = 0; return 1 / z`:  "ArithmeticError 1:21",
+		`let n = nil; return n.a`:  "TypeError 1:22",
+		`return nope`:              "ReferenceError 1:11",
+		`legacy()`:                 "Error 1:7",
+		`return [1, 2] * {}`:       "TypeError 1:15",
+		`let m = {}; return m.a.b`: "ReferenceError 1:21",
+	}
+	for code, want := range cases {
+		prog := NewParserWithFile(`func f() { `+code+` } try { f() } catch (e) { [e.name, e.position] }`, "main.r2").ParseProgram()
+		caught := prog.Eval(env).([]interface{})
+		got := fmt.Sprint(caught[0], " <nil>")
+		if pos, ok := caught[1].(map[string]interface{}); ok {
+			// Las columnas de want son dentro de code
+			got = fmt.Sprintf("%v %v:%v", caught[0], pos["line"], pos["col"].(float64)-11)
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", code, want, got)
+		}
+	}
+}
+
+func TestErrors_StrictTypes(t *testing.T) {
+	got := evalTyped(t, typedCode+`
+		let out = []
+		for (f in [() => total([Circle(1)], "2"), () => total(), () => total([], 1, 2)]) {
+			try { $v() } catch (e: Error) { out = out + [e.name + ": " + e.message] }
+		}
+		out
+	`, true).([]interface{})
+	want := []string{
+		"TypeError: total: argument scale must be number, got string",
+		"ArgumentError: total: missing argument shapes: Shape[]",
+		"ArgumentError: total takes 2 argument(s), got 3",
+	}
+	if s := toStrings(got); strings.Join(s, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(s, "\n"))
+	}
+}
+
+func TestErrors_Limits(t *testing.T) {
+	env := newTestEnv()
+	env.SetLimits(100, 1000, 10*time.Second)
+	got := NewParser(`
+		let name = nil
+		try { while (true) {} } catch (e: TimeoutError) { name = e.name }
+		name
+	`).ParseProgram().Eval(env)
+	if got != "TimeoutError" {
+		t.Errorf("expected a TimeoutError, got %v", got)
+	}
+
+	// Relanzado, sigue siendo el error de Go del límite
+	var panicVal interface{}
+	func() {
+		defer func() { panicVal = recover() }()
+		NewParser(`try { while (true) {} } catch (e) { throw e }`).ParseProgram().Eval(env)
+	}()
+	err, ok := panicVal.(error)
+	if !ok || !errors.Is(err, ErrInfiniteLoop) {
+		t.Errorf("expected the rethrown error to wrap ErrInfiniteLoop, got %v", panicVal)
+	}
+}
+
+func TestErrors_Uncaught(t *testing.T) {
+	msg := recoverMessage(func() {
+		evalErrors(t, "func f() {\n\tthrow TypeError(\"bad\")\n}\nf()")
+	})
+	if !strings.HasPrefix(msg, "main.r2:2:6: TypeError: bad\n"+callStackHeader) || !strings.Contains(msg, "in f()") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
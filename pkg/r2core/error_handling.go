@@ -2,6 +2,7 @@ package r2core
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"runtime"
 	"strings"
@@ -172,21 +173,24 @@ func GetCallerInfo() (string, string, int) {
 func PanicWithContext(errorType string, ctx ErrorContext) {
 	ef := NewErrorFormatter()
 	var message string
+	class := "Error"
 
 	switch errorType {
 	case "type":
 		message = ef.FormatTypeError(ctx)
+		class = "TypeError"
 	case "operation":
 		message = ef.FormatOperationError(ctx)
 	case "argument":
 		message = ef.FormatArgumentError(ctx)
+		class = "ArgumentError"
 	case "runtime":
 		message = ef.FormatRuntimeError(ctx)
 	default:
 		message = fmt.Sprintf("Error desconocido en %s: %s", ctx.Function, ctx.Operation)
 	}
 
-	panic(message)
+	panic(newRuntimeError(class, message))
 }
 
 // Helper functions para crear contextos rápidamente
@@ -237,6 +241,14 @@ func typeof(value interface{}) string {
 	if value == nil {
 		return "nil"
 	}
+	switch value.(type) {
+	case *big.Int:
+		return "bigint"
+	case *DecimalValue:
+		return "decimal"
+	case *EnumValue:
+		return "enum"
+	}
 
 	t := reflect.TypeOf(value)
 	switch t.Kind() {
@@ -165,7 +165,11 @@ func TestImprovedPanicMessages(t *testing.T) {
 	t.Run("PanicWithContext_Type", func(t *testing.T) {
 		defer func() {
 			if r := recover(); r != nil {
-				msg := r.(string)
+				re := r.(*RuntimeError)
+				if re.Class != "TypeError" {
+					t.Errorf("Expected TypeError class, got: %s", re.Class)
+				}
+				msg := re.Message
 				if !strings.Contains(msg, "Type error") {
 					t.Errorf("Expected type error message, got: %s", msg)
 				}
@@ -181,7 +185,7 @@ func TestImprovedPanicMessages(t *testing.T) {
 	t.Run("PanicWithContext_Operation", func(t *testing.T) {
 		defer func() {
 			if r := recover(); r != nil {
-				msg := r.(string)
+				msg := r.(*RuntimeError).Message
 				if !strings.Contains(msg, "Operation error") {
 					t.Errorf("Expected operation error message, got: %s", msg)
 				}
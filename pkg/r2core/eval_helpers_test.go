@@ -0,0 +1,21 @@
+package r2core
+
+import "testing"
+
+// newTestEnv devuelve un entorno global con true, false y nil, como el que
+// arma el intérprete antes de correr un script. Los tests nuevos del paquete
+// lo usan en lugar de armar su propio entorno.
+func newTestEnv() *Environment {
+	env := NewEnvironment()
+	env.Set("true", true)
+	env.Set("false", false)
+	env.Set("nil", nil)
+	return env
+}
+
+// evalCode evalúa code en un entorno nuevo y devuelve el valor de su última
+// sentencia.
+func evalCode(t *testing.T, code string) interface{} {
+	t.Helper()
+	return NewParser(code).ParseProgram().Eval(newTestEnv())
+}
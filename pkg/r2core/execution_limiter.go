@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/metrics"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +16,7 @@ var (
 	ErrInfiniteLoop   = errors.New("infinite loop detected")
 	ErrRecursionLimit = errors.New("recursion limit exceeded")
 	ErrTimeout        = errors.New("execution timeout")
+	ErrMemoryLimit    = errors.New("memory limit exceeded")
 )
 
 // ExecutionLimiter controla límites de ejecución para prevenir loops infinitos
@@ -32,7 +35,9 @@ type ExecutionLimiter struct {
 	Enabled           bool
 	Context           context.Context
 	Cancel            context.CancelFunc
+	MaxMemory         int64 // Bytes de heap permitidos; 0 = sin límite (ver WatchMemory)
 	mu                sync.Mutex
+	memoryUsed        atomic.Int64 // Heap vivo medido al superar MaxMemory
 }
 
 // LoopContext representa el contexto de un bucle específico
@@ -56,6 +61,21 @@ type InfiniteLoopError struct {
 }
 
 func (ile *InfiniteLoopError) Error() string {
+	switch ile.Sentinel {
+	case ErrTimeout:
+		return fmt.Sprintf(
+			"Tiempo de ejecución excedido (%v) en %s:\n"+
+				"- Sugerencia: %s",
+			ile.Stats["timeout_type"], ile.Location, ile.Suggestion,
+		)
+	case ErrMemoryLimit:
+		return fmt.Sprintf(
+			"Límite de memoria excedido en %s:\n"+
+				"- Heap en uso: %v bytes (límite %v bytes)\n"+
+				"- Sugerencia: %s",
+			ile.Location, ile.Stats["used_bytes"], ile.Stats["limit_bytes"], ile.Suggestion,
+		)
+	}
 	return fmt.Sprintf(
 		"Loop infinito detectado (%s) en %s:\n"+
 			"- Iteraciones: %d\n"+
@@ -154,6 +174,55 @@ func (el *ExecutionLimiter) CheckContext() bool {
 	}
 }
 
+// CheckMemoryLimit verifica si el monitor de WatchMemory detectó que el heap
+// superó MaxMemory
+func (el *ExecutionLimiter) CheckMemoryLimit() bool {
+	return el.Enabled && el.memoryUsed.Load() > 0
+}
+
+// memoryLimitError crea el error para el exceso detectado por CheckMemoryLimit
+func (el *ExecutionLimiter) memoryLimitError() *InfiniteLoopError {
+	return NewMemoryLimitError(el.memoryUsed.Load(), el.MaxMemory)
+}
+
+// WatchMemory revisa cada interval el heap vivo tras el último GC; si supera
+// maxBytes, los bucles y las llamadas a función abortan el script en su
+// próxima verificación con ErrMemoryLimit. Go no mide la memoria por
+// goroutine, así que el heap medido es el de todo el proceso: con varios
+// scripts en paralelo, uno puede abortar por la memoria que usan los demás.
+// No toca el soft limit del runtime. La función retornada detiene el monitor.
+func (el *ExecutionLimiter) WatchMemory(maxBytes int64, interval time.Duration) (stop func()) {
+	el.MaxMemory = maxBytes
+	el.memoryUsed.Store(0)
+
+	done := make(chan struct{})
+	go func() {
+		sample := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			metrics.Read(sample)
+			if sample[0].Value.Kind() != metrics.KindUint64 {
+				continue
+			}
+			if live := int64(sample[0].Value.Uint64()); live > maxBytes {
+				el.memoryUsed.Store(live)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
 // IncrementIterations incrementa el contador de iteraciones
 func (el *ExecutionLimiter) IncrementIterations() {
 	if el.Enabled {
@@ -268,3 +337,17 @@ func NewTimeoutError(timeoutType string, ctx context.Context) *InfiniteLoopError
 		},
 	}
 }
+
+// NewMemoryLimitError crea un error de límite de memoria
+func NewMemoryLimitError(used, limit int64) *InfiniteLoopError {
+	return &InfiniteLoopError{
+		Type:       "memory",
+		Location:   "global execution",
+		Sentinel:   ErrMemoryLimit,
+		Suggestion: "Reduce el tamaño de las estructuras en memoria o aumenta -max-memory",
+		Stats: map[string]interface{}{
+			"used_bytes":  used,
+			"limit_bytes": limit,
+		},
+	}
+}
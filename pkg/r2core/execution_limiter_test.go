@@ -3,6 +3,8 @@ package r2core
 import (
 	"context"
 	"errors"
+	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 )
@@ -237,3 +239,59 @@ func TestExecutionLimiter_SetLimits(t *testing.T) {
 		t.Errorf("Expected max execution time 10s, got %v", limiter.MaxExecutionTime)
 	}
 }
+
+func TestMemoryLimitError(t *testing.T) {
+	err := NewMemoryLimitError(2048, 1024)
+
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Error("Error should be identified as ErrMemoryLimit")
+	}
+	if !strings.Contains(err.Error(), "2048 bytes (límite 1024 bytes)") {
+		t.Errorf("Unexpected message: %s", err.Error())
+	}
+}
+
+func TestExecutionLimiter_WatchMemory(t *testing.T) {
+	env := newTestEnv()
+	before := debug.SetMemoryLimit(-1)
+	stop := env.GetLimiter().WatchMemory(2<<20, time.Millisecond)
+	defer stop()
+	if got := debug.SetMemoryLimit(-1); got != before {
+		t.Errorf("WatchMemory changed the runtime memory limit from %d to %d", before, got)
+	}
+
+	prog := NewParser(`
+		let xs = []
+		let i = 0
+		while (true) { xs = xs.push("some reasonably long string value " + i); i = i + 1 }
+	`).ParseProgram()
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(*InfiniteLoopError); !ok || !errors.Is(err, ErrMemoryLimit) {
+			t.Fatalf("Expected a memory limit error, got %v", r)
+		}
+	}()
+	prog.Eval(env)
+}
+
+func TestEnvironment_SetTimeout(t *testing.T) {
+	env := newTestEnv()
+	env.SetTimeout(20 * time.Millisecond)
+
+	prog := NewParser(`func spin() { while (true) { } } spin()`).ParseProgram()
+	start := time.Now()
+	defer func() {
+		r := recover()
+		if err, ok := r.(*InfiniteLoopError); !ok || !errors.Is(err, ErrTimeout) {
+			t.Fatalf("Expected a timeout error, got %v", r)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Timeout took too long to stop the script: %v", elapsed)
+		}
+		if _, ok := env.GetContext().Deadline(); !ok {
+			t.Error("Expected the environment context to carry the deadline")
+		}
+	}()
+	prog.Eval(env)
+}
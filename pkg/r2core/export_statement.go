@@ -0,0 +1,165 @@
+package r2core
+
+import "strings"
+
+// ExportStatement es una declaración precedida de export:
+//
+//	export func parse(s) { ... }
+//	export let version = "1.0"
+//
+// Si un módulo exporta algún nombre, quien lo importa sólo ve esos nombres;
+// si no exporta ninguno, ve todos los que declara.
+type ExportStatement struct {
+	Declaration Node
+}
+
+func (es *ExportStatement) Eval(env *Environment) interface{} {
+	val := es.Declaration.Eval(env)
+	env.storeMu.Lock()
+	if env.exports == nil {
+		env.exports = make(map[string]bool)
+	}
+	for _, name := range declaredNames(es.Declaration) {
+		env.exports[name] = true
+	}
+	env.storeMu.Unlock()
+	return val
+}
+
+// declaredNames devuelve los nombres que declara n, o nil si n no es una
+// declaración que se pueda exportar.
+func declaredNames(n Node) []string {
+	switch d := n.(type) {
+	case *LetStatement:
+		return []string{d.Name}
+	case *MultipleLetStatement:
+		names := make([]string, len(d.Declarations))
+		for i, decl := range d.Declarations {
+			names[i] = decl.Name
+		}
+		return names
+	case *ConstStatement:
+		return []string{d.Name}
+	case *MultipleConstStatement:
+		names := make([]string, len(d.Declarations))
+		for i, decl := range d.Declarations {
+			names[i] = decl.Name
+		}
+		return names
+	case *FunctionDeclaration:
+		return []string{d.Name}
+	case *ObjectDeclaration:
+		return []string{d.Name}
+	case *EnumDeclaration:
+		return []string{d.Name}
+	case *InterfaceDeclaration:
+		return []string{d.Name}
+	}
+	return nil
+}
+
+// moduleExports devuelve lo que un módulo evaluado en moduleEnv deja ver a
+// quien lo importa: sus exports o, si no tiene, todo lo que declaró menos
+// currentFile.
+func moduleExports(moduleEnv *Environment) map[string]*Variable {
+	// Copia bajo lock: el módulo importado puede haber lanzado goroutines via
+	// "go"/"r2" que todavía mutan moduleEnv.store de forma concurrente
+	moduleEnv.storeMu.RLock()
+	defer moduleEnv.storeMu.RUnlock()
+	symbols := make(map[string]*Variable, len(moduleEnv.store))
+	for k, v := range moduleEnv.store {
+		if moduleEnv.exports != nil && !moduleEnv.exports[k] {
+			continue
+		}
+		if moduleEnv.exports == nil && k == "currentFile" {
+			continue
+		}
+		symbols[k] = v
+	}
+	return symbols
+}
+
+// ExportedNames devuelve, sin evaluarlo, los nombres que deja ver a quien lo
+// importa el módulo parseado en prog: los que exporta o, si no exporta
+// ninguno, los que declara en su nivel superior (también dentro de if, while,
+// try y for-in, que no crean entorno). ok es false si eso no se puede saber,
+// porque el módulo importa otro sin alias y toma sus nombres.
+func ExportedNames(prog *Program) (names map[string]bool, ok bool) {
+	exports := map[string]bool{}
+	declared := map[string]bool{}
+	ok = true
+	var visit func(Node) bool
+	visit = func(n Node) bool {
+		switch s := n.(type) {
+		case *ExportStatement:
+			for _, name := range declaredNames(s.Declaration) {
+				exports[name] = true
+			}
+		case *LetStatement, *MultipleLetStatement, *ConstStatement, *MultipleConstStatement:
+			for _, name := range declaredNames(s) {
+				declared[name] = true
+			}
+		case *FunctionDeclaration, *ObjectDeclaration, *EnumDeclaration, *InterfaceDeclaration:
+			for _, name := range declaredNames(s) {
+				declared[name] = true
+			}
+			return false
+		case *ArrayDestructuring:
+			for _, name := range s.Names {
+				declared[name] = true
+			}
+		case *ObjectDestructuring:
+			for _, name := range s.Names {
+				declared[name] = true
+			}
+		case *GenericAssignStatement:
+			// Asignar un nombre que no existe lo declara
+			if id, isID := s.Left.(*Identifier); isID {
+				declared[id.Name] = true
+			}
+		case *ImportStatement:
+			switch {
+			case s.Names != nil:
+				for _, n := range s.Names {
+					if n.Alias != "" {
+						declared[n.Alias] = true
+					} else {
+						declared[n.Name] = true
+					}
+				}
+			case s.Alias != "":
+				declared[s.Alias] = true
+			case strings.HasPrefix(s.Path, stdPrefix):
+				declared[strings.TrimPrefix(s.Path, stdPrefix)] = true
+			default:
+				ok = false
+			}
+		case *DSLDefinition:
+			if s.Name != nil {
+				declared[s.Name.Name] = true
+			}
+			return false
+		case *ForStatement:
+			if !s.inFlag {
+				return false
+			}
+			for _, name := range []string{s.inIndexName, "$c", "$k", "$v"} {
+				declared[name] = true
+			}
+		case *TryStatement:
+			// Los catch tienen su propio entorno
+			Inspect(s.Body, visit)
+			Inspect(s.FinallyBlock, visit)
+			return false
+		case *FunctionLiteral, *ArrowFunction, *SwitchStatement, *MatchExpression,
+			*ArrayComprehension, *ObjectComprehension:
+			return false
+		}
+		return true
+	}
+	Inspect(prog, visit)
+	if len(exports) > 0 {
+		return exports, true
+	}
+	return declared, ok
+}
@@ -0,0 +1,158 @@
+package r2core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// evalModule evalúa code como si fuera el archivo main.r2 de dir, después de
+// escribir files en dir.
+func evalModule(t *testing.T, files map[string]string, code string) (env *Environment, result interface{}) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env = NewEnvironment()
+	env.Set("nil", nil)
+	env.Dir = dir
+	env.CurrentFile = filepath.Join(dir, "main.r2")
+	return env, NewParser(code).ParseProgram().Eval(env)
+}
+
+func recoverMessage(fn func()) (msg string) {
+	defer func() { msg = fmt.Sprint(recover()) }()
+	fn()
+	return ""
+}
+
+func TestExport_OnlyExportedNamesAreImported(t *testing.T) {
+	files := map[string]string{
+		"util.r2": `let factor = 2
+export func twice(x) { return x * factor }
+export const version = "1.0"
+export class Box { let v = 1 }`,
+	}
+	env, got := evalModule(t, files, `import "./util.r2"
+import "./util.r2" as u
+[twice(3), version, Box().v, u.twice(4)]`)
+	if fmt.Sprint(got) != "[6 1.0 1 8]" {
+		t.Errorf("unexpected result %v", got)
+	}
+	for _, hidden := range []string{"factor", "currentFile"} {
+		if _, ok := env.Get(hidden); ok {
+			t.Errorf("expected %s not to be imported", hidden)
+		}
+	}
+	u, _ := env.Get("u")
+	if _, ok := u.(map[string]*Variable)["factor"]; ok {
+		t.Error("expected factor not to be visible through the alias")
+	}
+}
+
+func TestExport_ModuleWithoutExports(t *testing.T) {
+	files := map[string]string{"util.r2": `let factor = 2
+func twice(x) { return x * factor }`}
+	env, got := evalModule(t, files, `import "./util.r2"
+twice(factor)`)
+	if got != float64(4) {
+		t.Errorf("expected 4, got %v", got)
+	}
+	if _, ok := env.Get("currentFile"); ok {
+		t.Error("expected currentFile not to be imported")
+	}
+}
+
+func TestImport_Selective(t *testing.T) {
+	files := map[string]string{
+		"util.r2": `export func twice(x) { return x * 2 }
+export let name = "util"
+let secret = 1`,
+		"other.r2": `import { twice } from "./util.r2"
+export let four = twice(2)`,
+	}
+	env, got := evalModule(t, files, `import { four } from "./other.r2"
+import { twice as double, name } from "./util.r2"
+[four, double(5), name]`)
+	if fmt.Sprint(got) != "[4 10 util]" {
+		t.Errorf("unexpected result %v", got)
+	}
+	if _, ok := env.Get("twice"); ok {
+		t.Error("expected only the alias of twice to be bound")
+	}
+
+	msg := recoverMessage(func() {
+		evalModule(t, files, `import { secret } from "./util.r2"`)
+	})
+	if !strings.Contains(msg, "./util.r2 does not export secret") {
+		t.Errorf("unexpected error %q", msg)
+	}
+}
+
+func TestImport_StdLibraries(t *testing.T) {
+	registered := 0
+	libs := NewLibraries()
+	libs.Add("json", func(env *Environment) {
+		registered++
+		env.Set("json", map[string]interface{}{
+			"stringify": BuiltinFunction(func(args ...interface{}) interface{} { return fmt.Sprint(args[0]) }),
+		})
+	}, "json")
+
+	env := NewEnvironment()
+	env.SetLibraries(libs)
+	if registered != 0 {
+		t.Fatal("expected json not to be registered before it is used")
+	}
+	got := NewParser(`import "std:json" as j
+import { stringify } from "std:json"
+[j.stringify(1), stringify(2), json.stringify(3)]`).ParseProgram().Eval(env)
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("unexpected result %v", got)
+	}
+	if registered != 1 {
+		t.Errorf("expected json to be registered once, got %d", registered)
+	}
+
+	msg := recoverMessage(func() {
+		NewParser(`import "std:nope"`).ParseProgram().Eval(env)
+	})
+	if !strings.Contains(msg, `Unknown standard library "std:nope" (available: json)`) {
+		t.Errorf("unexpected error %q", msg)
+	}
+}
+
+func TestExportedNames(t *testing.T) {
+	cases := []struct {
+		code string
+		want []string
+		ok   bool
+	}{
+		{"export func a() {}\nlet b = 1\nexport const c = 2", []string{"a", "c"}, true},
+		{"func a() { let inner = 1 }\nif (true) { let b = 1 }\nclass C {}\nimport { d as e } from \"./x.r2\"", []string{"a", "b", "C", "e"}, true},
+		{"import \"./x.r2\"\nlet a = 1", nil, false},
+	}
+	for _, c := range cases {
+		names, ok := ExportedNames(NewParser(c.code).ParseProgram())
+		if ok != c.ok {
+			t.Errorf("%q: ok = %v, expected %v", c.code, ok, c.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(names) != len(c.want) {
+			t.Errorf("%q: got %v, expected %v", c.code, names, c.want)
+		}
+		for _, name := range c.want {
+			if !names[name] {
+				t.Errorf("%q: missing %s in %v", c.code, name, names)
+			}
+		}
+	}
+}
@@ -11,9 +11,11 @@ type ForStatement struct {
 	Body      *BlockStatement
 	inFlag    bool
 	inArray   string
+	inExpr    Node // for (x in expr) cuando la colección no es un nombre
 	//inMap       string
 	inIndexName string
 	LoopID      string // Para identificación JIT
+	scope       *Scope // Layout del entorno del for clásico (ver Resolve)
 }
 
 func (fs *ForStatement) Eval(env *Environment) interface{} {
@@ -36,8 +38,12 @@ func (fs *ForStatement) evalForIn(env *Environment) interface{} {
 	}
 
 	var result interface{}
-	raw, _ := env.Get(fs.inArray)
+	raw := fs.collection(env)
 	env.Set("$c", raw)
+	if list, ok := raw.(*ImmutableList); ok {
+		// Se recorre como un array: la variable toma cada índice
+		raw = list.Values()
+	}
 
 	if arr, ok := raw.(InterfaceSlice); ok {
 		for i, v := range arr {
@@ -53,6 +59,11 @@ func (fs *ForStatement) evalForIn(env *Environment) interface{} {
 					panic(NewTimeoutError("for_in_context_canceled", env.GetContext()))
 				}
 
+				// Verificar límite de memoria
+				if limiter.CheckMemoryLimit() {
+					panic(limiter.memoryLimitError())
+				}
+
 				// Verificar límite de iteraciones del bucle
 				if loopCtx.Iterations >= loopCtx.MaxIterations {
 					panic(NewInfiniteLoopError("for-in", loopCtx))
@@ -92,6 +103,11 @@ func (fs *ForStatement) evalForIn(env *Environment) interface{} {
 					panic(NewTimeoutError("for_in_context_canceled", env.GetContext()))
 				}
 
+				// Verificar límite de memoria
+				if limiter.CheckMemoryLimit() {
+					panic(limiter.memoryLimitError())
+				}
+
 				// Verificar límite de iteraciones del bucle
 				if loopCtx.Iterations >= loopCtx.MaxIterations {
 					panic(NewInfiniteLoopError("for-in", loopCtx))
@@ -131,6 +147,11 @@ func (fs *ForStatement) evalForIn(env *Environment) interface{} {
 					panic(NewTimeoutError("for_in_context_canceled", env.GetContext()))
 				}
 
+				// Verificar límite de memoria
+				if limiter.CheckMemoryLimit() {
+					panic(limiter.memoryLimitError())
+				}
+
 				// Verificar límite de iteraciones del bucle
 				if loopCtx.Iterations >= loopCtx.MaxIterations {
 					panic(NewInfiniteLoopError("for-in", loopCtx))
@@ -156,15 +177,105 @@ func (fs *ForStatement) evalForIn(env *Environment) interface{} {
 			}
 			result = val
 		}
+	} else if keyed, ok := raw.(EntryIterable); ok {
+		return fs.evalForInEntries(env, keyed.Entries(), loopCtx)
+	} else if it, ok := GetIterator(raw); ok {
+		return fs.evalForInIterator(env, it, loopCtx)
 	} else {
 		panic("Not an array or map for 'for'")
 	}
 	return result
 }
 
+// collection evalúa lo que recorre un for-in.
+func (fs *ForStatement) collection(env *Environment) interface{} {
+	if fs.inExpr != nil {
+		return fs.inExpr.Eval(env)
+	}
+	raw, _ := env.Get(fs.inArray)
+	return raw
+}
+
+// evalForInIterator recorre un Iterator (un generador, por ejemplo) pidiendo
+// los elementos de a uno. Como no hay índices, la variable del bucle toma el
+// valor de cada elemento; $k es su posición y $v otra vez el valor. Si el
+// bucle termina antes (break, return o una excepción) el iterador se cierra.
+func (fs *ForStatement) evalForInIterator(env *Environment, it Iterator, loopCtx *LoopContext) interface{} {
+	defer CloseIterator(it)
+	return fs.evalForInSteps(env, loopCtx, func(i int) (bound, k, v interface{}, done bool) {
+		v, done = it.Next()
+		return v, float64(i), v, done
+	})
+}
+
+// evalForInEntries recorre una colección con claves como a un mapa: la
+// variable y $k toman cada clave y $v su valor, en orden.
+func (fs *ForStatement) evalForInEntries(env *Environment, entries EntryIterator, loopCtx *LoopContext) interface{} {
+	return fs.evalForInSteps(env, loopCtx, func(int) (bound, k, v interface{}, done bool) {
+		k, v, done = entries.NextEntry()
+		return k, k, v, done
+	})
+}
+
+// evalForInSteps ejecuta el cuerpo con cada elemento que devuelve next: lo
+// que toma la variable del bucle, $k y $v.
+func (fs *ForStatement) evalForInSteps(env *Environment, loopCtx *LoopContext, next func(i int) (bound, k, v interface{}, done bool)) interface{} {
+	limiter := env.GetLimiter()
+
+	var result interface{}
+	for i := 0; ; i++ {
+		bound, k, v, done := next(i)
+		if done {
+			break
+		}
+		// Verificar límites antes de cada iteración
+		if limiter.Enabled {
+			// Verificar timeout global
+			if limiter.CheckTimeLimit() {
+				panic(NewTimeoutError("for_in_timeout", env.GetContext()))
+			}
+
+			// Verificar context cancelation
+			if limiter.CheckContext() {
+				panic(NewTimeoutError("for_in_context_canceled", env.GetContext()))
+			}
+
+			// Verificar límite de memoria
+			if limiter.CheckMemoryLimit() {
+				panic(limiter.memoryLimitError())
+			}
+
+			// Verificar límite de iteraciones del bucle
+			if loopCtx.Iterations >= loopCtx.MaxIterations {
+				panic(NewInfiniteLoopError("for-in", loopCtx))
+			}
+		}
+
+		env.Set(fs.inIndexName, bound)
+		env.Set("$k", k)
+		env.Set("$v", v)
+
+		// Incrementar contador de iteraciones del bucle
+		loopCtx.Iterations++
+
+		val := fs.Body.Eval(env)
+		if rv, ok := val.(ReturnValue); ok {
+			return rv
+		}
+		if _, ok := val.(BreakValue); ok {
+			break
+		}
+		if _, ok := val.(ContinueValue); ok {
+			continue
+		}
+		result = val
+	}
+	return result
+}
+
 func (fs *ForStatement) evalStandardFor(env *Environment) interface{} {
 	// Crear un nuevo scope para la inicialización del loop
-	newEnv := NewInnerEnv(env)
+	newEnv := newScopedEnv(env, fs.scope)
 
 	// Intentar optimización específica para loops simples
 	if optimized := fs.trySimpleLoopOptimization(newEnv); optimized != nil {
@@ -215,6 +326,11 @@ func (fs *ForStatement) executeStandardLoop(env *Environment) interface{} {
 				panic(NewTimeoutError("for_context_canceled", env.GetContext()))
 			}
 
+			// Verificar límite de memoria
+			if limiter.CheckMemoryLimit() {
+				panic(limiter.memoryLimitError())
+			}
+
 			// Verificar límite de iteraciones del bucle
 			if loopCtx.Iterations >= loopCtx.MaxIterations {
 				panic(NewInfiniteLoopError("for", loopCtx))
@@ -0,0 +1,924 @@
+package r2core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format devuelve src en su forma canónica: sangría de 4 espacios, un espacio
+// alrededor de los operadores binarios, sin ';' al final de las sentencias y
+// como mucho una línea en blanco entre sentencias. Los comentarios se
+// conservan; los que estaban en medio de una expresión pasan a la línea
+// anterior a la sentencia que los contiene. Los sinónimos de palabras clave se
+// normalizan (var → let, function/method → func, obj → class) y los literales
+// (números, strings, templates, fechas) se escriben tal como estaban.
+//
+// El resultado es estable: Format(Format(src)) == Format(src).
+func Format(src string, filename string) (out string, err error) {
+	p := &Parser{
+		lexer:    NewLexer(src),
+		filename: filename,
+		collect:  true,
+		layout:   &formatInfo{raw: map[Node]string{}, lists: map[interface{}]*spanList{}},
+	}
+	p.nextToken()
+	p.nextToken()
+	prog := p.ParseProgram()
+	if len(p.errors) > 0 {
+		return "", p.errors[0]
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = "", fmt.Errorf("%s: cannot format: %v", filename, r)
+		}
+	}()
+
+	f := &formatter{src: src, info: p.layout, comments: scanComments(src)}
+	f.lines = append(f.lines, 0)
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+
+	body := f.list(prog, len(prog.Statements), -1, len(src), 0, func(i, indent int) string {
+		return f.stmt(prog.Statements[i], indent)
+	})
+	if body == "" {
+		return "", nil
+	}
+	return body + "\n", nil
+}
+
+// formatInfo guarda lo que el AST descarta pero el formateador necesita: el
+// texto original de los literales y dónde empieza y termina cada elemento de
+// las listas (sentencias, miembros, pares, casos) para ubicar comentarios y
+// líneas en blanco. Sólo se rellena cuando Parser.layout no es nil.
+type formatInfo struct {
+	raw   map[Node]string
+	lists map[interface{}]*spanList
+}
+
+type srcSpan struct {
+	start, end int
+}
+
+type spanList struct {
+	open, close int // Offsets del delimitador de apertura y de cierre
+	items       []srcSpan
+}
+
+func (p *Parser) recordRaw(node Node, tok Token) {
+	if p.layout == nil {
+		return
+	}
+	p.layout.raw[node] = p.lexer.input[tok.Start:p.tokenEnd(tok)]
+}
+
+// tokenEnd devuelve el offset siguiente al último carácter de tok.
+func (p *Parser) tokenEnd(tok Token) int {
+	lx := NewLexer(p.lexer.input)
+	lx.pos = tok.Start
+	lx.NextToken()
+	return lx.pos
+}
+
+func (p *Parser) openList() *spanList {
+	if p.layout == nil {
+		return nil
+	}
+	return &spanList{open: p.prevTok.Start}
+}
+
+// add registra el elemento que empezó en start y acaba de parsearse.
+func (l *spanList) add(p *Parser, start int) {
+	if l == nil {
+		return
+	}
+	l.items = append(l.items, srcSpan{start: start, end: p.tokenEnd(p.prevTok)})
+}
+
+func (p *Parser) closeList(key interface{}, l *spanList) {
+	if l == nil {
+		return
+	}
+	l.close = p.curTok.Start
+	p.layout.lists[key] = l
+}
+
+// endAtBody hace terminar el último elemento de l, y la lista de body, en la
+// última sentencia de body (o en end si está vacío). Los cuerpos de case no
+// tienen delimitador de cierre: sin esto, los comentarios previos al case
+// siguiente quedarían dentro del cuerpo anterior.
+func (l *spanList) endAtBody(p *Parser, body *BlockStatement, end int) {
+	if l == nil {
+		return
+	}
+	inner := p.layout.lists[body]
+	if n := len(inner.items); n > 0 {
+		end = inner.items[n-1].end
+	}
+	inner.close = end
+	l.items[len(l.items)-1].end = end
+}
+
+const (
+	formatIndent = "    "
+	formatWidth  = 100
+)
+
+type comment struct {
+	start, end int
+	text       string
+	used       bool
+}
+
+// scanComments extrae los comentarios de src. Se apoya en el lexer para no
+// confundir un "//" dentro de un string con un comentario: todo lo que el
+// lexer salta entre dos tokens es espacio o comentario.
+func scanComments(src string) []*comment {
+	var comments []*comment
+	lx := NewLexer(src)
+	for {
+		before := lx.pos
+		tok := lx.NextToken()
+		gap := src[before:tok.Start]
+		for i := 0; i+1 < len(gap); i++ {
+			if gap[i] != '/' {
+				continue
+			}
+			switch gap[i+1] {
+			case '/':
+				j := strings.IndexByte(gap[i:], '\n')
+				if j < 0 {
+					j = len(gap) - i
+				}
+				text := strings.TrimRight(gap[i:i+j], " \t\r")
+				comments = append(comments, &comment{start: before + i, end: before + i + j, text: text})
+				i += j
+			case '*':
+				j := strings.Index(gap[i+2:], "*/")
+				end := len(gap)
+				if j >= 0 {
+					end = i + 2 + j + 2
+				}
+				comments = append(comments, &comment{start: before + i, end: before + end, text: gap[i:end]})
+				i = end - 1
+			}
+		}
+		if tok.Type == TOKEN_EOF {
+			return comments
+		}
+	}
+}
+
+type formatter struct {
+	src      string
+	info     *formatInfo
+	comments []*comment
+	lines    []int // Offset donde empieza cada línea
+}
+
+func (f *formatter) lineOf(off int) int {
+	return sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > off }) - 1
+}
+
+// Los intentos de layout en una sola línea pueden consumir comentarios de
+// bloques anidados; si el intento se descarta hay que devolverlos.
+func (f *formatter) saveComments() []bool {
+	used := make([]bool, len(f.comments))
+	for i, c := range f.comments {
+		used[i] = c.used
+	}
+	return used
+}
+
+func (f *formatter) restoreComments(used []bool) {
+	for i, c := range f.comments {
+		c.used = used[i]
+	}
+}
+
+// take marca como usados y devuelve los comentarios pendientes en [from, to).
+func (f *formatter) take(from, to int) []*comment {
+	var out []*comment
+	for _, c := range f.comments {
+		if !c.used && c.start >= from && c.start < to {
+			c.used = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (f *formatter) hasComments(from, to int) bool {
+	for _, c := range f.comments {
+		if !c.used && c.start > from && c.start < to {
+			return true
+		}
+	}
+	return false
+}
+
+// list imprime n elementos, uno por línea con la sangría indicada, junto con
+// los comentarios que los rodean. key identifica la lista en formatInfo; si no
+// está (nodos sintéticos) se imprimen sólo los elementos.
+func (f *formatter) list(key interface{}, n int, open, close int, indent int, item func(i, indent int) string) string {
+	var b strings.Builder
+	pad := strings.Repeat(formatIndent, indent)
+	spans := f.info.lists[key]
+	if spans != nil && key != nil {
+		if _, isProg := key.(*Program); !isProg {
+			open, close = spans.open, spans.close
+		}
+	}
+	prevLine := f.lineOf(open)
+	if open < 0 {
+		prevLine = -1
+	}
+	emitted := false
+	write := func(text string, line int) {
+		if emitted {
+			b.WriteString("\n")
+			if line-prevLine > 1 {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(pad)
+		b.WriteString(text)
+		emitted = true
+	}
+
+	for i := 0; i < n; i++ {
+		if spans == nil || i >= len(spans.items) {
+			write(item(i, indent), prevLine+1)
+			prevLine++
+			continue
+		}
+		sp := spans.items[i]
+		for _, c := range f.take(-1, sp.start) {
+			write(c.text, f.lineOf(c.start))
+			prevLine = f.lineOf(c.end - 1)
+		}
+		text := item(i, indent)
+		line := f.lineOf(sp.start)
+		for _, c := range f.take(sp.start, sp.end) {
+			write(c.text, line)
+			prevLine = line - 1
+		}
+		write(text, line)
+		prevLine = f.lineOf(sp.end - 1)
+
+		next := close
+		if i+1 < len(spans.items) {
+			next = spans.items[i+1].start
+		}
+		for _, c := range f.comments {
+			if !c.used && c.start >= sp.end && c.start < next && f.lineOf(c.start) == prevLine {
+				c.used = true
+				b.WriteString(" ")
+				b.WriteString(c.text)
+				prevLine = f.lineOf(c.end - 1)
+			}
+		}
+	}
+	for _, c := range f.take(-1, close) {
+		write(c.text, f.lineOf(c.start))
+		prevLine = f.lineOf(c.end - 1)
+	}
+	return b.String()
+}
+
+func (f *formatter) block(b *BlockStatement, indent int) string {
+	if b == nil {
+		return "{}"
+	}
+	body := f.list(b, len(b.Statements), -1, -1, indent+1, func(i, indent int) string {
+		return f.stmt(b.Statements[i], indent)
+	})
+	if body == "" {
+		return "{}"
+	}
+	return "{\n" + body + "\n" + strings.Repeat(formatIndent, indent) + "}"
+}
+
+func (f *formatter) stmt(n Node, indent int) string {
+	switch s := n.(type) {
+	case *LetStatement:
+		if s.Value == nil {
+			return "let " + s.Name + typeSuffix(s.Type)
+		}
+		return "let " + s.Name + typeSuffix(s.Type) + " = " + f.expr(s.Value, indent)
+	case *MultipleLetStatement:
+		parts := make([]string, len(s.Declarations))
+		for i, d := range s.Declarations {
+			parts[i] = d.Name + typeSuffix(d.Type)
+			if d.Value != nil {
+				parts[i] += " = " + f.expr(d.Value, indent)
+			}
+		}
+		return "let " + strings.Join(parts, ", ")
+	case *ConstStatement:
+		return "const " + s.Name + typeSuffix(s.Type) + " = " + f.expr(s.Value, indent)
+	case *MultipleConstStatement:
+		parts := make([]string, len(s.Declarations))
+		for i, d := range s.Declarations {
+			parts[i] = d.Name + typeSuffix(d.Type) + " = " + f.expr(d.Value, indent)
+		}
+		return "const " + strings.Join(parts, ", ")
+	case *ArrayDestructuring:
+		return "let [" + strings.Join(s.Names, ", ") + "] = " + f.expr(s.Value, indent)
+	case *ObjectDestructuring:
+		return "let {" + strings.Join(s.Names, ", ") + "} = " + f.expr(s.Value, indent)
+	case *GenericAssignStatement:
+		return f.assign(s, indent)
+	case *ExprStatement:
+		text := f.expr(s.Expr, indent)
+		// Una sentencia que empieza con + o - se uniría a la línea anterior
+		// como operador binario
+		if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+			text = "(" + text + ")"
+		}
+		return text
+	case *ReturnStatement:
+		if s.Value == nil {
+			// "return" solo al final de la línea no es válido
+			return "return;"
+		}
+		return "return " + f.expr(s.Value, indent)
+	case *BreakStatement:
+		return "break"
+	case *ContinueStatement:
+		return "continue"
+	case *ThrowStatement:
+		return "throw " + f.expr(s.Value, indent)
+	case *ImportStatement:
+		text := "import " + f.rawOr(s, quoteString(s.Path))
+		if s.Names != nil {
+			names := make([]string, len(s.Names))
+			for i, n := range s.Names {
+				names[i] = n.Name
+				if n.Alias != "" {
+					names[i] += " as " + n.Alias
+				}
+			}
+			text = "import {" + strings.Join(names, ", ") + "} from " + f.rawOr(s, quoteString(s.Path))
+		}
+		if s.Alias != "" {
+			text += " as " + s.Alias
+		}
+		return text
+	case *FunctionDeclaration:
+		return asyncPrefix(s.Async) + "func" + generatorMark(s.Generator) + " " + s.Name + f.params(s.Params, indent) + typeSuffix(s.Return) + " " + f.block(s.Body, indent)
+	case *IfStatement:
+		return f.ifStmt(s, indent)
+	case *WhileStatement:
+		return "while (" + f.expr(s.Condition, indent) + ") " + f.block(s.Body, indent)
+	case *SwitchStatement:
+		return f.switchStmt(s, indent)
+	case *ForStatement:
+		if s.inFlag {
+			coll := s.inArray
+			if s.inExpr != nil {
+				coll = f.expr(s.inExpr, indent)
+			}
+			return "for (" + s.inIndexName + " in " + coll + ") " + f.block(s.Body, indent)
+		}
+		var header []string
+		if s.Init != nil {
+			header = append(header, f.stmt(s.Init, indent))
+		}
+		header = append(header, f.expr(s.Condition, indent))
+		post := ""
+		if s.Post != nil {
+			post = f.stmt(s.Post, indent)
+		}
+		// Sin init el parser lee la condición directamente tras "("
+		return "for (" + strings.Join(header, "; ") + "; " + post + ") " + f.block(s.Body, indent)
+	case *TryStatement:
+		text := "try " + f.block(s.Body, indent)
+		for _, c := range s.Catches {
+			if c.Type == nil && (c.Var == "" || c.Var == "$e") {
+				text += " catch " + f.block(c.Body, indent)
+			} else {
+				text += " catch (" + c.Var + typeSuffix(c.Type) + ") " + f.block(c.Body, indent)
+			}
+		}
+		if s.FinallyBlock != nil {
+			text += " finally " + f.block(s.FinallyBlock, indent)
+		}
+		return text
+	case *ObjectDeclaration:
+		return f.objectDecl(s, indent)
+	case *ExportStatement:
+		return "export " + f.stmt(s.Declaration, indent)
+	case *InterfaceDeclaration:
+		head := s.Keyword + " " + s.Name
+		body := f.list(s, len(s.Methods), -1, -1, indent+1, func(i, indent int) string {
+			m := s.Methods[i]
+			if m.Body == nil {
+				return m.Name + f.params(m.Params, indent) + typeSuffix(m.Return)
+			}
+			return f.classMember(m, indent)
+		})
+		if body == "" {
+			return head + " {}"
+		}
+		return head + " {\n" + body + "\n" + strings.Repeat(formatIndent, indent) + "}"
+	case *DSLDefinition:
+		return "dsl " + s.Name.Name + " " + f.block(s.Body, indent)
+	case *EnumDeclaration:
+		if len(s.Members) == 0 {
+			return "enum " + s.Name + " {}"
+		}
+		return "enum " + s.Name + " " + f.items(s, "{", "}", len(s.Members), indent, func(i, indent int) string {
+			m := s.Members[i]
+			if m.Value == nil {
+				return m.Name
+			}
+			return m.Name + " = " + f.expr(m.Value, indent)
+		})
+	case *BlockStatement:
+		return f.block(s, indent)
+	}
+	return f.expr(n, indent)
+}
+
+// assign reconstruye x++, x-- y x op= y, que el parser expande a
+// x = x op y reutilizando el mismo nodo a ambos lados.
+func (f *formatter) assign(s *GenericAssignStatement, indent int) string {
+	left := f.expr(s.Left, indent)
+	if be, ok := s.Right.(*BinaryExpression); ok && be.Left == s.Left {
+		if num, ok := be.Right.(*NumberLiteral); ok && num.Value == 1 && f.info.raw[num] == "" {
+			if be.Op == "+" {
+				return left + "++"
+			}
+			if be.Op == "-" {
+				return left + "--"
+			}
+		}
+		switch be.Op {
+		case "+", "-", "*", "/":
+			return left + " " + be.Op + "= " + f.expr(be.Right, indent)
+		}
+	}
+	return left + " = " + f.expr(s.Right, indent)
+}
+
+func (f *formatter) ifStmt(s *IfStatement, indent int) string {
+	text := "if (" + f.expr(s.Condition, indent) + ") " + f.block(s.Consequence, indent)
+	if s.Alternative == nil {
+		return text
+	}
+	// El parser envuelve "else if" en un bloque sintético (sin posición)
+	if _, real := f.info.lists[s.Alternative]; !real && len(s.Alternative.Statements) == 1 {
+		if elseIf, ok := s.Alternative.Statements[0].(*IfStatement); ok {
+			return text + " else " + f.ifStmt(elseIf, indent)
+		}
+	}
+	return text + " else " + f.block(s.Alternative, indent)
+}
+
+// switchStmt deja case y default al nivel del switch más uno y las
+// sentencias de cada caso un nivel más adentro.
+func (f *formatter) switchStmt(s *SwitchStatement, indent int) string {
+	head := "switch (" + f.expr(s.Value, indent) + ") {"
+	body := f.list(s, len(s.Cases), -1, -1, indent+1, func(i, indent int) string {
+		c := s.Cases[i]
+		text := "default:"
+		if !c.IsDefault {
+			labels := make([]string, len(c.Patterns))
+			for j, pat := range c.Patterns {
+				labels[j] = f.pattern(pat, indent)
+			}
+			text = "case " + strings.Join(labels, ", ") + ":"
+		}
+		stmts := f.list(c.Body, len(c.Body.Statements), -1, -1, indent+1, func(j, indent int) string {
+			return f.stmt(c.Body.Statements[j], indent)
+		})
+		if stmts == "" {
+			return text
+		}
+		return text + "\n" + stmts
+	})
+	if body == "" {
+		return head + "}"
+	}
+	return head + "\n" + body + "\n" + strings.Repeat(formatIndent, indent) + "}"
+}
+
+func (f *formatter) objectDecl(s *ObjectDeclaration, indent int) string {
+	head := "class " + s.Name
+	if s.ParentName != "" {
+		head += " extends " + s.ParentName
+	}
+	if len(s.Interfaces) > 0 {
+		head += " implements " + strings.Join(s.Interfaces, ", ")
+	}
+	body := f.list(s, len(s.Members), -1, -1, indent+1, func(i, indent int) string {
+		return f.classMember(s.Members[i], indent)
+	})
+	if body == "" {
+		return head + " {}"
+	}
+	return head + " {\n" + body + "\n" + strings.Repeat(formatIndent, indent) + "}"
+}
+
+func (f *formatter) classMember(n Node, indent int) string {
+	switch m := n.(type) {
+	case *FunctionDeclaration:
+		return asyncPrefix(m.Async) + generatorMark(m.Generator) + m.Name + f.params(m.Params, indent) + typeSuffix(m.Return) + " " + f.block(m.Body, indent)
+	case *StaticMember:
+		return "static " + f.classMember(m.Member, indent)
+	case *AccessorDeclaration:
+		return m.Kind + " " + f.classMember(m.Func, indent)
+	}
+	return f.stmt(n, indent)
+}
+
+func (f *formatter) params(params []Parameter, indent int) string {
+	return f.seq("(", ")", len(params), indent, false, func(i, indent int) string {
+		text := params[i].Name
+		if params[i].Optional {
+			text += "?"
+		}
+		text += typeSuffix(params[i].Type)
+		if params[i].DefaultValue != nil {
+			text += " = " + f.expr(params[i].DefaultValue, indent)
+		}
+		return text
+	})
+}
+
+// typeSuffix imprime la anotación ": tipo", o nada si no hay.
+func typeSuffix(t *TypeAnnotation) string {
+	if t == nil {
+		return ""
+	}
+	return ": " + t.String()
+}
+
+// seq imprime una lista entre delimitadores en una sola línea si cabe, o un
+// elemento por línea si no. Con hug, el último elemento puede ocupar varias
+// líneas sin romper la lista (p. ej. una función pasada como argumento).
+func (f *formatter) seq(open, close string, n int, indent int, hug bool, item func(i, indent int) string) string {
+	if n == 0 {
+		return open + close
+	}
+	saved := f.saveComments()
+	items := make([]string, n)
+	multi := 0
+	for i := range items {
+		items[i] = item(i, indent)
+		if strings.Contains(items[i], "\n") {
+			multi++
+		}
+	}
+	flat := open + strings.Join(items, ", ") + close
+	first := flat
+	if i := strings.IndexByte(flat, '\n'); i >= 0 {
+		first = flat[:i]
+	}
+	fits := len(formatIndent)*indent+utf8.RuneCountInString(first) <= formatWidth
+	if fits && (multi == 0 || (hug && multi == 1 && strings.Contains(items[n-1], "\n"))) {
+		return flat
+	}
+
+	f.restoreComments(saved)
+	pad := strings.Repeat(formatIndent, indent+1)
+	var b strings.Builder
+	b.WriteString(open)
+	for i := 0; i < n; i++ {
+		b.WriteString("\n" + pad + item(i, indent+1))
+		if i < n-1 {
+			b.WriteString(",")
+		}
+	}
+	b.WriteString("\n" + strings.Repeat(formatIndent, indent) + close)
+	return b.String()
+}
+
+// items imprime un literal de array o map: en una línea si cabe y no tiene
+// comentarios dentro, o un elemento por línea conservando los comentarios.
+func (f *formatter) items(key interface{}, open, close string, n int, indent int, item func(i, indent int) string) string {
+	if spans := f.info.lists[key]; spans == nil || !f.hasComments(spans.open, spans.close) {
+		saved := f.saveComments()
+		parts := make([]string, n)
+		flat := true
+		for i := range parts {
+			parts[i] = item(i, indent)
+			flat = flat && !strings.Contains(parts[i], "\n")
+		}
+		text := open + strings.Join(parts, ", ") + close
+		if flat && len(formatIndent)*indent+utf8.RuneCountInString(text) <= formatWidth {
+			return text
+		}
+		f.restoreComments(saved)
+	}
+	body := f.list(key, n, -1, -1, indent+1, func(i, indent int) string {
+		if i < n-1 {
+			return item(i, indent) + ","
+		}
+		return item(i, indent)
+	})
+	return open + "\n" + body + "\n" + strings.Repeat(formatIndent, indent) + close
+}
+
+// Precedencias para decidir paréntesis: por debajo de los operadores binarios
+// van la flecha y el ternario; por encima, unarios y expresiones postfijas.
+const (
+	precArrow   = 0
+	precTernary = 1
+	precUnary   = 13
+	precPostfix = 14
+)
+
+func (f *formatter) prec(n Node) int {
+	switch e := n.(type) {
+	case *ArrowFunction, *YieldExpression:
+		return precArrow
+	case *TernaryExpression:
+		return precTernary
+	case *BinaryExpression:
+		return getPrecedence(e.Op) + 1
+	case *UnaryExpression, *SpreadExpression, *AwaitExpression:
+		return precUnary
+	case *NumberLiteral:
+		if raw := f.rawOr(e, ""); strings.HasPrefix(raw, "-") || strings.HasPrefix(raw, "+") || (raw == "" && e.Value < 0) {
+			return precUnary
+		}
+	case *ExactNumberLiteral:
+		if strings.HasPrefix(e.Raw, "-") || strings.HasPrefix(e.Raw, "+") {
+			return precUnary
+		}
+	}
+	return precPostfix
+}
+
+// operand imprime n entre paréntesis si su precedencia es menor que min.
+func (f *formatter) operand(n Node, min int, indent int) string {
+	text := f.expr(n, indent)
+	if f.prec(n) < min {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func (f *formatter) expr(n Node, indent int) string {
+	switch e := n.(type) {
+	case nil:
+		return ""
+	case *NumberLiteral:
+		return f.rawOr(e, strconv.FormatFloat(e.Value, 'f', -1, 64))
+	case *ExactNumberLiteral:
+		return e.Raw
+	case *StringLiteral:
+		return f.rawOr(e, quoteString(e.Value))
+	case *TemplateString:
+		return f.rawOr(e, "``")
+	case *DateLiteral:
+		return f.rawOr(e, "@"+quoteString(e.Value.String()))
+	case *BooleanLiteral:
+		return strconv.FormatBool(e.Value)
+	case *NilLiteral:
+		return "nil"
+	case *Identifier:
+		return e.Name
+	case *BinaryExpression:
+		return f.binary(e, indent)
+	case *UnaryExpression:
+		text := f.operand(e.Right, precUnary, indent)
+		// "--" es otro token, y "~-1" se leería como dos unarios
+		if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+			text = "(" + text + ")"
+		}
+		return e.Operator + text
+	case *SpreadExpression:
+		return "..." + f.operand(e.Value, precUnary, indent)
+	case *AwaitExpression:
+		return "await " + f.operand(e.Value, precUnary, indent)
+	case *YieldExpression:
+		text := "yield"
+		if e.Delegate {
+			text += "*"
+		}
+		if e.Value != nil {
+			text += " " + f.expr(e.Value, indent)
+		}
+		return text
+	case *TernaryExpression:
+		return f.operand(e.Condition, precTernary+1, indent) + " ? " +
+			f.operand(e.TrueExpr, precTernary, indent) + " : " + f.operand(e.FalseExpr, precTernary, indent)
+	case *CallExpression:
+		return f.operand(e.Callee, precPostfix, indent) + f.seq("(", ")", len(e.Args), indent, true, func(i, indent int) string {
+			return f.expr(e.Args[i], indent)
+		})
+	case *AccessExpression:
+		return f.operand(e.Object, precPostfix, indent) + "." + e.Member
+	case *OptionalAccessExpression:
+		return f.operand(e.Object, precPostfix, indent) + "?." + e.Member
+	case *IndexExpression:
+		return f.operand(e.Left, precPostfix, indent) + "[" + f.expr(e.Index, indent) + "]"
+	case *OptionalIndexExpression:
+		return f.operand(e.Object, precPostfix, indent) + "?.[" + f.expr(e.Index, indent) + "]"
+	case *ArrayLiteral:
+		return f.items(e, "[", "]", len(e.Elements), indent, func(i, indent int) string {
+			return f.expr(e.Elements[i], indent)
+		})
+	case *MapLiteral:
+		if len(e.Pairs) == 0 {
+			return "{}"
+		}
+		return f.items(e, "{", "}", len(e.Pairs), indent, func(i, indent int) string {
+			return f.mapPair(e.Pairs[i], indent)
+		})
+	case *FunctionLiteral:
+		return asyncPrefix(e.Async) + "func" + generatorMark(e.Generator) + f.params(e.Params, indent) + typeSuffix(e.Return) + " " + f.block(e.Body, indent)
+	case *ArrowFunction:
+		return f.arrow(e, indent)
+	case *MatchExpression:
+		return f.match(e, indent)
+	case *ArrayComprehension:
+		return "[" + f.expr(e.Expression, indent) + f.generators(e.Generators, e.Conditions, indent) + "]"
+	case *ObjectComprehension:
+		return "{" + f.expr(e.KeyExpr, indent) + ": " + f.expr(e.ValueExpr, indent) +
+			f.generators(e.Generators, e.Conditions, indent) + "}"
+	}
+	panic(fmt.Sprintf("unsupported node %T", n))
+}
+
+func (f *formatter) binary(e *BinaryExpression, indent int) string {
+	p := getPrecedence(e.Op) + 1
+	left := f.operand(e.Left, p, indent)
+	right := f.operand(e.Right, p+1, indent)
+	text := left + " " + e.Op + " " + right
+	if e.Op != "|>" || strings.Contains(text, "\n") ||
+		len(formatIndent)*indent+utf8.RuneCountInString(text) <= formatWidth {
+		return text
+	}
+	// Un pipeline largo se parte antes de cada |>
+	var stages []Node
+	var head Node = e
+	for {
+		be, ok := head.(*BinaryExpression)
+		if !ok || be.Op != "|>" {
+			break
+		}
+		stages = append([]Node{be.Right}, stages...)
+		head = be.Left
+	}
+	pad := strings.Repeat(formatIndent, indent+1)
+	text = f.operand(head, p, indent)
+	for _, stage := range stages {
+		text += "\n" + pad + "|> " + f.operand(stage, p+1, indent+1)
+	}
+	return text
+}
+
+func (f *formatter) arrow(e *ArrowFunction, indent int) string {
+	params := f.params(e.Params, indent)
+	if len(e.Params) == 1 && e.Params[0].DefaultValue == nil && e.Params[0].Type == nil {
+		params = e.Params[0].Name
+	}
+	if !e.IsExpression {
+		if b, ok := e.Body.(*BlockStatement); ok {
+			return asyncPrefix(e.Async) + params + " => " + f.block(b, indent)
+		}
+	}
+	body := f.operand(e.Body, precTernary, indent)
+	// Tras "=>" una llave abre un bloque, no un map
+	if strings.HasPrefix(body, "{") {
+		body = "(" + body + ")"
+	}
+	return asyncPrefix(e.Async) + params + " => " + body
+}
+
+func asyncPrefix(async bool) string {
+	if async {
+		return "async "
+	}
+	return ""
+}
+
+func generatorMark(generator bool) string {
+	if generator {
+		return "*"
+	}
+	return ""
+}
+
+func (f *formatter) mapPair(pair MapPair, indent int) string {
+	if spread, ok := pair.Value.(*SpreadExpression); ok {
+		if key, ok := pair.Key.(*StringLiteral); ok && key.Value == "..." && f.info.raw[key] == "" {
+			return f.expr(spread, indent)
+		}
+	}
+	var key string
+	if sl, ok := pair.Key.(*StringLiteral); ok {
+		key = f.rawOr(sl, quoteString(sl.Value))
+	} else {
+		key = "[" + f.expr(pair.Key, indent) + "]"
+	}
+	return key + ": " + f.expr(pair.Value, indent)
+}
+
+func (f *formatter) generators(gens []Generator, conds []Node, indent int) string {
+	var b strings.Builder
+	for _, g := range gens {
+		b.WriteString(" for " + g.Variable + " in " + f.expr(g.Iterator, indent))
+	}
+	for _, c := range conds {
+		b.WriteString(" if " + f.expr(c, indent))
+	}
+	return b.String()
+}
+
+func (f *formatter) match(e *MatchExpression, indent int) string {
+	head := "match " + f.expr(e.Value, indent) + " {"
+	body := f.list(e, len(e.Cases), -1, -1, indent+1, func(i, indent int) string {
+		c := e.Cases[i]
+		text := "case " + f.pattern(c.Pattern, indent)
+		if c.Guard != nil {
+			text += " if " + f.expr(c.Guard, indent)
+		}
+		return text + " => " + f.expr(c.Body, indent)
+	})
+	if body == "" {
+		return head + "}"
+	}
+	return head + "\n" + body + "\n" + strings.Repeat(formatIndent, indent) + "}"
+}
+
+func (f *formatter) pattern(pat Pattern, indent int) string {
+	switch p := pat.(type) {
+	case *WildcardPattern:
+		return "_"
+	case *VariablePattern:
+		return p.Name
+	case *LiteralPattern:
+		return f.expr(p.Value, indent)
+	case *ValuePattern:
+		return f.expr(p.Value, indent)
+	case *GuardedPattern:
+		return f.pattern(p.Pattern, indent) + " if " + f.expr(p.Guard, indent)
+	case *ArrayPattern:
+		parts := make([]string, len(p.Elements))
+		for i, el := range p.Elements {
+			parts[i] = f.pattern(el, indent)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *ObjectPattern:
+		// El parser guarda los campos en un map: el orden original se pierde
+		names := make([]string, 0, len(p.Fields))
+		for name := range p.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			if vp, ok := p.Fields[name].(*VariablePattern); ok && vp.Name == name {
+				parts[i] = name
+			} else {
+				parts[i] = name + ": " + f.pattern(p.Fields[name], indent)
+			}
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	panic(fmt.Sprintf("unsupported pattern %T", pat))
+}
+
+func (f *formatter) rawOr(n Node, fallback string) string {
+	if raw, ok := f.info.raw[n]; ok {
+		return raw
+	}
+	return fallback
+}
+
+// quoteString escribe s como literal de string usando sólo los escapes que
+// entiende el lexer.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
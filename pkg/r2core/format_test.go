@@ -0,0 +1,199 @@
+package r2core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat_Canonical(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "keywords and spacing",
+			src:  "var x=1;\nfunction add(a,b){return a+b;}\n",
+			want: "let x = 1\nfunc add(a, b) {\n    return a + b\n}\n",
+		},
+		{
+			name: "blank lines collapsed",
+			src:  "let x = 1\n\n\n\nlet y = 2\n",
+			want: "let x = 1\n\nlet y = 2\n",
+		},
+		{
+			name: "compound assignment",
+			src:  "x += 2;\ni++\n",
+			want: "x += 2\ni++\n",
+		},
+		{
+			name: "else if",
+			src:  "if (a) { print(1) } else if (b) { print(2) } else { print(3) }\n",
+			want: "if (a) {\n    print(1)\n} else if (b) {\n    print(2)\n} else {\n    print(3)\n}\n",
+		},
+		{
+			name: "class members",
+			src:  "obj Point {\n  let x\n  method show() { print(this.x) }\n}\n",
+			want: "class Point {\n    let x\n    show() {\n        print(this.x)\n    }\n}\n",
+		},
+		{
+			name: "arrow, template and comprehension",
+			src:  "let f = (x) => x * 2\nlet s = `hi ${name}`\nlet l = [x*2 for x in xs if x>1]\n",
+			want: "let f = x => x * 2\nlet s = `hi ${name}`\nlet l = [x * 2 for x in xs if x > 1]\n",
+		},
+		{
+			name: "match",
+			src:  "let r = match v { case 1 => \"one\"\n case _ => \"other\" }\n",
+			want: "let r = match v {\n    case 1 => \"one\"\n    case _ => \"other\"\n}\n",
+		},
+		{
+			name: "dsl",
+			src:  "dsl Calc {\n token(\"NUM\", \"[0-9]+\")\n}\n",
+			want: "dsl Calc {\n    token(\"NUM\", \"[0-9]+\")\n}\n",
+		},
+		{
+			name: "literals kept as written",
+			src:  "let a = 007\nlet b = 'single'\nlet c = 1.50\n",
+			want: "let a = 007\nlet b = 'single'\nlet c = 1.50\n",
+		},
+		{
+			name: "generators",
+			src:  "function *gen(n){let x = yield n\nyield* other(x)\nyield}\nlet g = func*(){yield 1+2}\nclass C { *items() { yield 1 } }\nfor (v in gen(1)) { log(v) }\n",
+			want: "func* gen(n) {\n    let x = yield n\n    yield* other(x)\n    yield\n}\nlet g = func*() {\n    yield 1 + 2\n}\nclass C {\n    *items() {\n        yield 1\n    }\n}\nfor (v in gen(1)) {\n    log(v)\n}\n",
+		},
+		{
+			name: "exact numbers",
+			src:  "let total = 12.50d*-3n\nlet id = 9007199254740993\n",
+			want: "let total = 12.50d * -3n\nlet id = 9007199254740993\n",
+		},
+		{
+			name: "switch",
+			src:  "switch(x){\ncase 1,2: log(\"a\")\n// pares\ncase [a,b] if a>b:\nlog(a)\nbreak\ndefault:\n}\n",
+			want: "switch (x) {\n    case 1, 2:\n        log(\"a\")\n    // pares\n    case [a, b] if a > b:\n        log(a)\n        break\n    default:\n}\n",
+		},
+		{
+			name: "enums",
+			src:  "enum Status{Active,Suspended=\"S\"}\nenum Empty {}\nlet x = match s { case Status.Active => 1 case _ => 2 }\n",
+			want: "enum Status {Active, Suspended = \"S\"}\nenum Empty {}\nlet x = match s {\n    case Status.Active => 1\n    case _ => 2\n}\n",
+		},
+		{
+			name: "class members",
+			src:  "class Temp{static let unit=\"C\"\nlet #c=0\nget c(){return this.#c}\nset c(v){this.#c=v}\nstatic async load(){}\n}\nlet ok = t instanceof   Temp\n",
+			want: "class Temp {\n    static let unit = \"C\"\n    let #c = 0\n    get c() {\n        return this.#c\n    }\n    set c(v) {\n        this.#c = v\n    }\n    static async load() {}\n}\nlet ok = t instanceof Temp\n",
+		},
+		{
+			name: "interfaces",
+			src:  "trait Named{name();func greet(){return \"hi \"+this.name()}\n}\ninterface Empty {}\nclass Box extends Base implements Named,Sized{}\n",
+			want: "trait Named {\n    name()\n    greet() {\n        return \"hi \" + this.name()\n    }\n}\ninterface Empty {}\nclass Box extends Base implements Named, Sized {}\n",
+		},
+		{
+			name: "imports and exports",
+			src:  "import {parse,dump as d} from \"./util.r2\"\nimport \"std:json\"   as j\nexport   func f(){return 1}\nexport const a=1,b=2\n",
+			want: "import {parse, dump as d} from \"./util.r2\"\nimport \"std:json\" as j\nexport func f() {\n    return 1\n}\nexport const a = 1, b = 2\n",
+		},
+		{
+			name: "type annotations",
+			src:  "func find(items:Item[],name ?: string=\"x\"):Item|nil{return nil}\nlet n:number=1\nconst a:string=\"a\",b:bool=true\nlet f=(x:number)=>x\ninterface Shape { area():number }\n",
+			want: "func find(items: Item[], name?: string = \"x\"): Item | nil {\n    return nil\n}\nlet n: number = 1\nconst a: string = \"a\", b: bool = true\nlet f = (x: number) => x\ninterface Shape {\n    area(): number\n}\n",
+		},
+		{
+			name: "exceptions",
+			src:  "try{throw TypeError(\"bad\",x)}catch(e:TypeError|ArgumentError){throw e}catch(e){log(e)}catch{ }finally{done()}\nthrow {code:1};\n",
+			want: "try {\n    throw TypeError(\"bad\", x)\n} catch (e: TypeError | ArgumentError) {\n    throw e\n} catch (e) {\n    log(e)\n} catch {} finally {\n    done()\n}\nthrow {code: 1}\n",
+		},
+		{
+			name: "async and await",
+			src:  "async function get(u){return await(fetch(u))}\nlet f = async (x)=>await x\nclass C { async run() { await f(1) } }\n",
+			want: "async func get(u) {\n    return await fetch(u)\n}\nlet f = async x => await x\nclass C {\n    async run() {\n        await f(1)\n    }\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.src, "test.r2")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			again, err := Format(got, "test.r2")
+			if err != nil || again != got {
+				t.Errorf("format is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormat_KeepsComments(t *testing.T) {
+	src := `// header
+let x = 1 // trailing
+let m = {
+  a: 1, // uno
+  /* dos */ "b": 2
+}
+obj Point {
+  // coords
+  let x
+}
+func f() {
+    return 1
+    // al final
+}
+`
+	got, err := Format(src, "test.r2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range []string{"// header", "let x = 1 // trailing", "a: 1, // uno", "/* dos */", "    // coords", "    // al final"} {
+		if !strings.Contains(got, c) {
+			t.Errorf("comment %q lost:\n%s", c, got)
+		}
+	}
+}
+
+func TestFormat_PreservesSemantics(t *testing.T) {
+	src := "let a = (1 + 2) * 3\nlet b = 1 - (2 - 3)\nlet c = ~(-1)\nlet d = (x) => ({a: x})\n"
+	got, err := Format(src, "test.r2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "let a = (1 + 2) * 3\nlet b = 1 - (2 - 3)\nlet c = ~(-1)\nlet d = x => ({a: x})\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormat_ParseError(t *testing.T) {
+	_, err := Format("let x = 1\nlet y = )\n", "bad.r2")
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if !strings.HasPrefix(err.Error(), "bad.r2:2:") {
+		t.Errorf("expected file:line:col error, got %q", err.Error())
+	}
+}
+
+func TestFormat_Examples(t *testing.T) {
+	files, _ := filepath.Glob("../../examples/*.r2")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := Format(string(data), file)
+		if err != nil {
+			continue // Algunos ejemplos muestran errores a propósito
+		}
+		twice, err := Format(once, file)
+		if err != nil {
+			t.Errorf("%s: formatted output does not parse: %v", file, err)
+			continue
+		}
+		if once != twice {
+			t.Errorf("%s: format is not idempotent", file)
+		}
+	}
+}
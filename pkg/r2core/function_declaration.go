@@ -3,21 +3,27 @@ package r2core
 // Función con nombre
 type FunctionDeclaration struct {
 	BaseNode
-	Name   string
-	Args   []string    // For backward compatibility
-	Params []Parameter // New parameter structure with default values
-	Body   *BlockStatement
+	Name      string
+	Args      []string    // For backward compatibility
+	Params    []Parameter // New parameter structure with default values
+	Body      *BlockStatement
+	Async     bool            // async func nombre(...) { ... }
+	Generator bool            // func* nombre(...) { ... yield ... }
+	Return    *TypeAnnotation // func nombre(...): tipo { ... }
 }
 
 func (fd *FunctionDeclaration) Eval(env *Environment) interface{} {
 	fn := &UserFunction{
-		Args:     fd.Args,
-		Params:   fd.Params,
-		Body:     fd.Body,
-		Env:      env,
-		IsMethod: false,
-		code:     fd.Name,
-		position: fd.Position,
+		Args:        fd.Args,
+		Params:      fd.Params,
+		Body:        fd.Body,
+		Env:         env,
+		IsMethod:    false,
+		IsAsync:     fd.Async,
+		IsGenerator: fd.Generator,
+		ReturnType:  fd.Return,
+		code:        fd.Name,
+		position:    fd.Position,
 	}
 	env.Set(fd.Name, fn)
 	return nil
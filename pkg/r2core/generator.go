@@ -0,0 +1,254 @@
+package r2core
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// generatorKey es el nombre con el que el entorno de un generador guarda su
+// estado; YieldExpression lo busca para saber a quién entregar el valor. El
+// "$" impide que choque con una variable del script.
+const generatorKey = "$generator"
+
+// GeneratorObject es lo que devuelve la llamada a una función generadora
+// (func* nombre() {...}). El cuerpo no corre hasta el primer next(): entonces
+// avanza hasta el siguiente yield, que entrega un valor y deja la función
+// suspendida hasta el next() siguiente. Implementa Iterator.
+//
+// El cuerpo corre en su propio goroutine, pero nunca a la vez que quien lo
+// consume: cada next() le pasa el control y espera a que lo devuelva.
+type GeneratorObject struct {
+	run  *generatorRun
+	name string
+}
+
+// generatorRun es el estado compartido entre el GeneratorObject y el
+// goroutine del cuerpo. Está separado para que el GeneratorObject pueda
+// recolectarse (y su finalizer cierre el goroutine) aunque el cuerpo siga
+// suspendido.
+type generatorRun struct {
+	mu       sync.Mutex
+	body     func() interface{}
+	resume   chan generatorResume
+	steps    chan generatorStep
+	started  bool
+	finished bool
+	closing  bool
+}
+
+// generatorResume lleva al cuerpo el valor enviado con next(v), o la orden de
+// terminar.
+type generatorResume struct {
+	value interface{}
+	close bool
+}
+
+// generatorStep lleva al consumidor lo que hizo el cuerpo: un yield, un
+// return (done) o un error (failed).
+type generatorStep struct {
+	value  interface{}
+	done   bool
+	failed bool
+}
+
+// generatorExit es el panic con el que yield termina un generador cerrado
+// antes del final. Los catch no lo atrapan, pero los finally sí corren.
+type generatorExit struct{}
+
+// newGenerator prepara la llamada a uf sin ejecutarla.
+func newGenerator(uf *UserFunction, currentEnv *Environment, args []interface{}) *GeneratorObject {
+	base := currentEnv
+	if base == nil {
+		base = uf.Env
+	}
+	// Pila de llamadas propia: las del generador y las del consumidor se
+	// alternan, y no deben apilarse en la misma.
+	genEnv := NewInnerEnv(base)
+	genEnv.callStack = base.callStack.Clone()
+
+	run := &generatorRun{
+		resume: make(chan generatorResume),
+		steps:  make(chan generatorStep),
+	}
+	genEnv.Set(generatorKey, run)
+	run.body = func() interface{} {
+		return uf.call(genEnv, args)
+	}
+
+	g := &GeneratorObject{run: run, name: uf.code}
+	// Un generador abandonado a medio camino deja su goroutine suspendido en
+	// un yield; al recolectarse se cierra. Un error en sus finally ya no
+	// tiene a quién llegar y se descarta.
+	runtime.SetFinalizer(g, func(g *GeneratorObject) {
+		go func() {
+			defer func() { recover() }()
+			g.run.close()
+		}()
+	})
+	return g
+}
+
+// Next implementa Iterator.
+func (g *GeneratorObject) Next() (interface{}, bool) {
+	return g.run.next(nil)
+}
+
+// Send reanuda el generador; value es el resultado del yield en el que estaba
+// suspendido.
+func (g *GeneratorObject) Send(value interface{}) (interface{}, bool) {
+	return g.run.next(value)
+}
+
+// Close termina el generador: el yield en el que está suspendido sale como
+// si hubiera un return, corriendo los finally pendientes.
+func (g *GeneratorObject) Close() {
+	g.run.close()
+}
+
+func (g *GeneratorObject) String() string {
+	if g.name == "" {
+		return "Generator{}"
+	}
+	return "Generator{" + g.name + "}"
+}
+
+func (run *generatorRun) next(sent interface{}) (interface{}, bool) {
+	// Un generador que se pide a sí mismo el siguiente valor se bloquearía
+	if !run.mu.TryLock() {
+		panic("generator is already running")
+	}
+	defer run.mu.Unlock()
+	if run.finished {
+		return nil, true
+	}
+	if !run.started {
+		run.started = true
+		go run.start()
+	} else {
+		run.resume <- generatorResume{value: sent}
+	}
+	step := <-run.steps
+	if step.done || step.failed {
+		run.finished = true
+	}
+	if step.failed {
+		panic(step.value)
+	}
+	return step.value, step.done
+}
+
+func (run *generatorRun) close() {
+	if !run.mu.TryLock() {
+		panic("generator is already running")
+	}
+	defer run.mu.Unlock()
+	if run.finished {
+		return
+	}
+	run.finished = true
+	if !run.started {
+		return
+	}
+	run.closing = true
+	run.resume <- generatorResume{close: true}
+	if step := <-run.steps; step.failed {
+		panic(step.value)
+	}
+}
+
+// start corre el cuerpo en el goroutine del generador.
+func (run *generatorRun) start() {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(generatorExit); ok {
+				run.steps <- generatorStep{done: true}
+				return
+			}
+			run.steps <- generatorStep{value: r, failed: true}
+		}
+	}()
+	value := run.body()
+	if rv, ok := value.(ReturnValue); ok {
+		value = rv.Value
+	}
+	run.steps <- generatorStep{value: value, done: true}
+}
+
+// yield entrega value al consumidor y espera a que lo reanude; devuelve el
+// valor que éste envíe con next(v).
+func (run *generatorRun) yield(value interface{}) interface{} {
+	if run.closing {
+		panic(generatorExit{})
+	}
+	run.steps <- generatorStep{value: value}
+	msg := <-run.resume
+	if msg.close {
+		panic(generatorExit{})
+	}
+	return msg.value
+}
+
+// delegate implementa yield*: entrega uno a uno los elementos de iterable y
+// devuelve el valor con el que termina (el return de un generador).
+func (run *generatorRun) delegate(iterable interface{}) interface{} {
+	it, ok := ToIterator(iterable)
+	if !ok {
+		panic(fmt.Sprintf("yield*: value of type %T is not iterable", iterable))
+	}
+	defer CloseIterator(it)
+	gen, isGen := it.(*GeneratorObject)
+	var sent interface{}
+	for {
+		var value interface{}
+		var done bool
+		if isGen {
+			value, done = gen.Send(sent)
+		} else {
+			value, done = it.Next()
+		}
+		if done {
+			return value
+		}
+		sent = run.yield(value)
+	}
+}
+
+// currentGenerator devuelve el generador cuyo cuerpo se está evaluando en env.
+func currentGenerator(env *Environment) *generatorRun {
+	if v, ok := env.Get(generatorKey); ok {
+		if run, ok := v.(*generatorRun); ok {
+			return run
+		}
+	}
+	return nil
+}
+
+// evalGeneratorAccess resuelve los métodos de un generador: next(v),
+// return(v) y toArray().
+func evalGeneratorAccess(g *GeneratorObject, member string) interface{} {
+	switch member {
+	case "next":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			var sent interface{}
+			if len(args) > 0 {
+				sent = args[0]
+			}
+			return iteratorStep(g.Send(sent))
+		})
+	case "return":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			g.Close()
+			var value interface{}
+			if len(args) > 0 {
+				value = args[0]
+			}
+			return iteratorStep(value, true)
+		})
+	case "toArray":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return CollectIterator(g)
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "Generator does not have the method: "+member))
+}
@@ -0,0 +1,270 @@
+package r2core
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGenerator_ForIn(t *testing.T) {
+	code := `
+		func* count(n) {
+			let i = 0
+			while (i < n) {
+				yield i
+				i = i + 1
+			}
+		}
+		let out = []
+		for (x in count(3)) { out = out.push(x) }
+		out
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[0 1 2]" {
+		t.Errorf("expected [0 1 2], got %s", got)
+	}
+}
+
+func TestGenerator_IsLazy(t *testing.T) {
+	code := `
+		let log = []
+		func* g() {
+			log = log.push("start")
+			yield 1
+			log = log.push("after 1")
+			yield 2
+		}
+		let it = g()
+		let before = log.length()
+		let first = it.next()
+		[before, first.value, first.done, log]
+	`
+	got := evalCode(t, code).([]interface{})
+	if got[0] != 0.0 {
+		t.Errorf("the body ran before next(): %v", got[0])
+	}
+	if got[1] != 1.0 || got[2] != false {
+		t.Errorf("expected {value: 1, done: false}, got %v %v", got[1], got[2])
+	}
+	if log := fmt.Sprint(got[3]); log != "[start]" {
+		t.Errorf("the body ran past the first yield: %s", log)
+	}
+}
+
+func TestGenerator_NextProtocol(t *testing.T) {
+	code := `
+		func* g() {
+			let got = yield "first"
+			yield got * 2
+			return "end"
+		}
+		let it = g()
+		[it.next().value, it.next(21).value, it.next(), it.next()]
+	`
+	got := evalCode(t, code).([]interface{})
+	if got[0] != "first" || got[1] != 42.0 {
+		t.Errorf("expected first and 42, got %v %v", got[0], got[1])
+	}
+	last := got[2].(map[string]interface{})
+	if last["value"] != "end" || last["done"] != true {
+		t.Errorf("expected the return value with done, got %v", last)
+	}
+	after := got[3].(map[string]interface{})
+	if after["value"] != nil || after["done"] != true {
+		t.Errorf("expected a finished generator to stay done, got %v", after)
+	}
+}
+
+func TestGenerator_InfiniteWithBreak(t *testing.T) {
+	code := `
+		func* naturals() {
+			let n = 0
+			while (true) {
+				yield n
+				n = n + 1
+			}
+		}
+		let sum = 0
+		for (n in naturals()) {
+			if (n > 100) { break }
+			sum = sum + n
+		}
+		sum
+	`
+	if got := evalCode(t, code); got != 5050.0 {
+		t.Errorf("expected 5050, got %v", got)
+	}
+}
+
+func TestGenerator_BreakRunsFinally(t *testing.T) {
+	code := `
+		let cleaned = false
+		func* g() {
+			try {
+				yield 1
+				yield 2
+			} catch (e) {
+				cleaned = "caught"
+			} finally {
+				cleaned = true
+			}
+		}
+		for (x in g()) { break }
+		cleaned
+	`
+	if got := evalCode(t, code); got != true {
+		t.Errorf("expected finally to run and catch to be skipped, got %v", got)
+	}
+}
+
+func TestGenerator_SpreadDestructuringComprehension(t *testing.T) {
+	code := `
+		func* count(n) {
+			let i = 0
+			while (i < n) {
+				yield i
+				i = i + 1
+			}
+		}
+		func* naturals() {
+			let n = 0
+			while (true) {
+				yield n
+				n = n + 1
+			}
+		}
+		let [a, b] = naturals()
+		[[...count(3)], a, b, [x * 10 for x in count(3) if x > 0], {x: x for x in count(2)}]
+	`
+	got := evalCode(t, code).([]interface{})
+	want := []string{"[0 1 2]", "0", "1", "[10 20]", "map[0:0 1:1]"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
+func TestGenerator_Delegate(t *testing.T) {
+	code := `
+		func* inner() {
+			yield 1
+			yield 2
+			return "inner done"
+		}
+		let result = nil
+		func* outer() {
+			yield 0
+			result = yield* inner()
+			yield* [3, 4]
+		}
+		[outer().toArray(), result]
+	`
+	got := evalCode(t, code).([]interface{})
+	if s := fmt.Sprint(got[0]); s != "[0 1 2 3 4]" {
+		t.Errorf("expected [0 1 2 3 4], got %s", s)
+	}
+	if got[1] != "inner done" {
+		t.Errorf("expected yield* to return the inner return value, got %v", got[1])
+	}
+}
+
+func TestGenerator_ErrorPropagatesToConsumer(t *testing.T) {
+	code := `
+		func* g() {
+			yield 1
+			throw "boom"
+		}
+		let seen = []
+		let caught = nil
+		try {
+			for (x in g()) { seen = seen.push(x) }
+		} catch (e) {
+			caught = e
+		}
+		[seen, caught]
+	`
+	got := evalCode(t, code).([]interface{})
+	if fmt.Sprint(got[0]) != "[1]" || got[1] != "boom" {
+		t.Errorf("expected [1] and boom, got %v", got)
+	}
+}
+
+func TestGenerator_MethodsAndObjectProtocol(t *testing.T) {
+	code := `
+		class Bag {
+			let items
+			constructor(items) { this.items = items }
+			*iterator() {
+				for (i in this.items) { yield this.items[i] }
+			}
+		}
+		class Countdown {
+			let n
+			constructor(n) { this.n = n }
+			next() {
+				if (this.n == 0) { return {done: true} }
+				this.n = this.n - 1
+				return {value: this.n + 1, done: false}
+			}
+		}
+		let lit = func*() { yield "lit" }
+		[[...Bag(["a", "b"])], [...Countdown(3)], [...lit()]]
+	`
+	got := evalCode(t, code).([]interface{})
+	want := []string{"[a b]", "[3 2 1]", "[lit]"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
+func TestGenerator_YieldIsIdentifierOutsideGenerators(t *testing.T) {
+	code := `
+		let yield = 5
+		func f() { return yield + 1 }
+		f()
+	`
+	if got := evalCode(t, code); got != 6.0 {
+		t.Errorf("expected 6, got %v", got)
+	}
+}
+
+func TestGenerator_ParseErrors(t *testing.T) {
+	for _, src := range []string{
+		`async func* g() { yield 1 }`,
+		`let f = async func*() { yield 1 }`,
+	} {
+		if _, errs := ParseWithErrors(src, ""); len(errs) == 0 {
+			t.Errorf("expected a parse error for %q", src)
+		}
+	}
+}
+
+func TestGenerator_AbandonedIsCollected(t *testing.T) {
+	code := `
+		func* naturals() {
+			let n = 0
+			while (true) {
+				yield n
+				n = n + 1
+			}
+		}
+		let i = 0
+		while (i < 50) {
+			naturals().next()
+			i = i + 1
+		}
+	`
+	before := runtime.NumGoroutine()
+	evalCode(t, code)
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before+5 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before+5 {
+		t.Errorf("abandoned generators leaked goroutines: %d before, %d after", before, n)
+	}
+}
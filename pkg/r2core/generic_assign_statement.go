@@ -1,5 +1,7 @@
 package r2core
 
+import "fmt"
+
 type GenericAssignStatement struct {
 	Left  Node
 	Right Node
@@ -10,23 +12,45 @@ func (gas *GenericAssignStatement) Eval(env *Environment) interface{} {
 	val := gas.Right.Eval(env)
 	switch left := gas.Left.(type) {
 	case *Identifier:
+		if left.ref != nil {
+			if target := env.resolveRef(left.ref); target != nil && target.updateSlot(left.ref.slot, left.Name, val) {
+				return val
+			}
+		}
 		env.Update(left.Name, val)
 		return val
 	case *AccessExpression:
-		objVal := left.Object.Eval(env)
-		switch obj := objVal.(type) {
-		case *ObjectInstance:
-			obj.Env.Set(left.Member, val)
-			return val
-		case map[string]interface{}:
-			obj[left.Member] = val
-			return val
-		default:
-			panic("Cannot assign to property of non-object type")
-		}
+		return assignMember(left, left.Object.Eval(env), val, env)
 	case *IndexExpression:
 		return assignIndexExpression(left, val, env)
 	default:
 		panic("Cannot assign to this expression")
 	}
 }
+
+// assignMember asigna val a la propiedad left.Member de objVal.
+func assignMember(left *AccessExpression, objVal interface{}, val interface{}, env *Environment) interface{} {
+	switch obj := objVal.(type) {
+	case *ObjectInstance:
+		checkPrivateAccess(env, obj.Class, left.Member)
+		if current, ok := obj.Env.Get(left.Member); ok {
+			if accessor, isAccessor := current.(*Accessor); isAccessor {
+				return accessor.set(left.Member, val)
+			}
+		}
+		obj.Env.Set(left.Member, val)
+		return val
+	case map[string]interface{}:
+		if _, isClass := obj["ClassName"]; isClass {
+			checkPrivateAccess(env, obj, left.Member)
+		}
+		obj[left.Member] = val
+		return val
+	case *EnumType:
+		panic(fmt.Sprintf("Cannot assign to %s.%s: enums are immutable", obj.Name, left.Member))
+	case *EnumValue:
+		panic(fmt.Sprintf("Cannot assign to %s.%s: enums are immutable", obj, left.Member))
+	default:
+		panic("Cannot assign to property of non-object type")
+	}
+}
@@ -3,6 +3,7 @@ package r2core
 type Identifier struct {
 	BaseNode
 	Name string
+	ref  *varRef // Dónde está la variable según Resolve; nil: se busca por nombre
 }
 
 func (id *Identifier) Eval(env *Environment) interface{} {
@@ -11,12 +12,20 @@ func (id *Identifier) Eval(env *Environment) interface{} {
 		return &Placeholder{BaseNode: id.BaseNode}
 	}
 
+	if id.ref != nil {
+		if target := env.resolveRef(id.ref); target != nil {
+			if variable := target.slots[id.ref.slot].Load(); variable != nil {
+				return variable.Value
+			}
+		}
+	}
+
 	val, ok := env.Get(id.Name)
 	if !ok {
 		if id.Position != nil && env.CurrentFile != "" {
 			id.Position.Filename = env.CurrentFile
 		}
-		PanicWithStack(id.Position, "Undeclared variable: "+id.Name, env.callStack)
+		PanicWithClass("ReferenceError", id.Position, "Undeclared variable: "+id.Name, env.callStack)
 	}
 	return val
 }
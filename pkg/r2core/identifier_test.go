@@ -78,7 +78,7 @@ func TestIdentifier_Eval_UndeclaredVariable(t *testing.T) {
 			t.Error("Expected panic for undeclared variable")
 		} else {
 			expectedMsg := "Undeclared variable: undeclaredVariable"
-			if r != expectedMsg {
+			if err, ok := r.(*RuntimeError); !ok || err.Class != "ReferenceError" || err.Message != expectedMsg {
 				t.Errorf("Expected %q panic, got %v", expectedMsg, r)
 			}
 		}
@@ -189,7 +189,7 @@ func TestIdentifier_Eval_EmptyVariableName(t *testing.T) {
 			t.Error("Expected panic for empty variable name")
 		} else {
 			expectedMsg := "Undeclared variable: "
-			if r != expectedMsg {
+			if err, ok := r.(*RuntimeError); !ok || err.Message != expectedMsg {
 				t.Errorf("Expected %q panic, got %v", expectedMsg, r)
 			}
 		}
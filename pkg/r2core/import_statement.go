@@ -7,23 +7,113 @@ import (
 	"strings"
 )
 
-// ImportStatement representa una declaración de importación con alias.
+// ImportStatement representa una declaración de importación:
+//
+//	import "./util.r2"                  // todo lo que exporta, sin prefijo
+//	import "./util.r2" as u             // bajo un alias: u.parse
+//	import { parse, dump as d } from "./util.r2"
+//	import "std:json"                   // una librería estándar: json.parse
 type ImportStatement struct {
+	BaseNode
 	Path  string
-	Alias string // Alias opcional
+	Alias string       // Alias opcional
+	Names []ImportName // import { ... } from; nil si no es selectivo
+}
+
+// ImportName es un nombre de un import selectivo, con su alias opcional.
+type ImportName struct {
+	Name  string
+	Alias string
 }
 
 func (is *ImportStatement) Eval(env *Environment) interface{} {
-	filePath := is.Path
+	if strings.HasPrefix(is.Path, stdPrefix) {
+		name, module := importStd(env, is.Path)
+		symbols := make(map[string]*Variable, len(module))
+		for k, v := range module {
+			symbols[k] = &Variable{Value: v}
+		}
+		switch {
+		case is.Names != nil:
+			is.bindNames(env, symbols)
+		case is.Alias != "":
+			env.Set(is.Alias, module)
+		default:
+			env.Set(name, module)
+		}
+		return nil
+	}
+
+	symbols := is.load(env)
+	switch {
+	case is.Names != nil:
+		is.bindNames(env, symbols)
+	case is.Alias != "":
+		// Si hay un alias, asignar los símbolos bajo ese alias
+		env.Set(is.Alias, symbols)
+	default:
+		// Si no hay alias, exportar directamente
+		for k, v := range symbols {
+			env.Set(k, v.Value)
+		}
+	}
+	return nil
+}
+
+// bindNames define en env los nombres de un import selectivo.
+func (is *ImportStatement) bindNames(env *Environment, symbols map[string]*Variable) {
+	for _, n := range is.Names {
+		v, ok := symbols[n.Name]
+		if !ok {
+			if is.Position != nil && env.CurrentFile != "" {
+				is.Position.Filename = env.CurrentFile
+			}
+			PanicWithClass("ReferenceError", is.Position, fmt.Sprintf("%s does not export %s", is.Path, n.Name), env.callStack)
+		}
+		name := n.Name
+		if n.Alias != "" {
+			name = n.Alias
+		}
+		env.Set(name, v.Value)
+	}
+}
+
+// resolve devuelve la ruta canónica del archivo de is.Path: el de un paquete
+// si el ModuleResolver lo conoce, o la ruta relativa al módulo que importa.
+func (is *ImportStatement) resolve(env *Environment) string {
+	if IsPackageSpecifier(is.Path) {
+		if resolver := env.ModuleResolver(); resolver != nil {
+			path, ok, err := resolver.Resolve(is.Path)
+			if err != nil {
+				panic(fmt.Sprintf("Error importing %s: %v", is.Path, err))
+			}
+			if ok {
+				return filepath.Clean(path)
+			}
+		}
+	}
 
 	// Resolver rutas relativas
+	filePath := is.Path
 	if !filepath.IsAbs(filePath) {
-		dir := env.Dir
-		filePath = filepath.Join(dir, filePath)
+		filePath = filepath.Join(env.Dir, filePath)
+	}
+	return filepath.Clean(filePath)
+}
+
+// IsPackageSpecifier indica si path puede nombrar un paquete: no es una ruta
+// absoluta, ni empieza con "./" o "../", ni es una librería "std:".
+func IsPackageSpecifier(path string) bool {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, stdPrefix) {
+		return false
 	}
+	return !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") && path != "." && path != ".."
+}
 
-	// Clean the path to get canonical form
-	filePath = filepath.Clean(filePath)
+// load evalúa el módulo de is.Path la primera vez que se importa y devuelve
+// lo que exporta; los imports siguientes reciben lo mismo.
+func (is *ImportStatement) load(env *Environment) map[string]*Variable {
+	filePath := is.resolve(env)
 
 	// Check for cyclic imports BEFORE checking if already imported
 	if env.IsImportCycle(filePath) {
@@ -32,9 +122,17 @@ func (is *ImportStatement) Eval(env *Environment) interface{} {
 		panic(fmt.Sprintf("Cyclic import detected: %s", strings.Join(chain, " -> ")))
 	}
 
+	if guard, ok := env.Policy().(FileGuard); ok {
+		if err := guard.CheckRead(filePath); err != nil {
+			panic(fmt.Sprintf("Error importing %s: %v", is.Path, err))
+		}
+	}
+
 	// Verificar si ya fue importado
 	if env.IsImported(filePath) {
-		return nil // Ya importado, no hacer nada
+		env.importedMu.Lock()
+		defer env.importedMu.Unlock()
+		return env.modules[filePath]
 	}
 
 	// Add to import stack for cycle detection
@@ -72,25 +170,9 @@ func (is *ImportStatement) Eval(env *Environment) interface{} {
 	// Evaluar en el entorno del módulo
 	importedProgram.Eval(moduleEnv)
 
-	// Obtener los símbolos del módulo importado (copia bajo lock: el módulo
-	// importado puede haber lanzado goroutines via "go"/"r2" que todavía
-	// mutan moduleEnv.store de forma concurrente)
-	moduleEnv.storeMu.RLock()
-	symbols := make(map[string]*Variable, len(moduleEnv.store))
-	for k, v := range moduleEnv.store {
-		symbols[k] = v
-	}
-	moduleEnv.storeMu.RUnlock()
-
-	// Si hay un alias, asignar los símbolos bajo ese alias
-	if is.Alias != "" {
-		env.Set(is.Alias, symbols)
-	} else {
-		// Si no hay alias, exportar directamente
-		for k, v := range symbols {
-			env.Set(k, v)
-		}
-	}
-
-	return nil
+	symbols := moduleExports(moduleEnv)
+	env.importedMu.Lock()
+	env.modules[filePath] = symbols
+	env.importedMu.Unlock()
+	return symbols
 }
@@ -8,9 +8,11 @@ type IndexExpression struct {
 }
 
 func (ie *IndexExpression) Eval(env *Environment) interface{} {
-	leftVal := ie.Left.Eval(env)
-	indexVal := ie.Index.Eval(env)
+	return ie.index(ie.Left.Eval(env), ie.Index.Eval(env))
+}
 
+// index indexa un contenedor ya evaluado (compartido con la VM).
+func (ie *IndexExpression) index(leftVal, indexVal interface{}) interface{} {
 	switch container := leftVal.(type) {
 	case map[string]interface{}:
 		strKey, ok := indexVal.(string)
@@ -22,12 +24,21 @@ func (ie *IndexExpression) Eval(env *Environment) interface{} {
 			return nil
 		}
 		return vv
+	case *EnumType:
+		// Status["Active"]: búsqueda por nombre, nil si no existe
+		name, ok := indexVal.(string)
+		if !ok {
+			panic("index must be a string for enum")
+		}
+		if m := container.Lookup(name); m != nil {
+			return m
+		}
+		return nil
 	case []interface{}:
-		fIndex, ok := indexVal.(float64)
+		idx, ok := arrayIndex(indexVal)
 		if !ok {
 			panic("index must be numeric for array")
 		}
-		idx := int(fIndex)
 		if idx < 0 {
 
 			idx = (len(container) + idx)
@@ -37,11 +48,10 @@ func (ie *IndexExpression) Eval(env *Environment) interface{} {
 		}
 		return container[idx]
 	case InterfaceSlice:
-		fIndex, ok := indexVal.(float64)
+		idx, ok := arrayIndex(indexVal)
 		if !ok {
 			panic("index must be numeric for array")
 		}
-		idx := int(fIndex)
 		if idx < 0 {
 
 			idx = (len(container) + idx)
@@ -50,6 +60,12 @@ func (ie *IndexExpression) Eval(env *Environment) interface{} {
 			panic(fmt.Sprintf("index out of range: %d len of array %d", idx, len(container)))
 		}
 		return container[idx]
+	case *ImmutableList:
+		return container.Get(listIndex(indexVal))
+	case KeyedCollection:
+		// Como en un mapa, una clave que no está es nil
+		vv, _ := container.Get(indexVal)
+		return vv
 	default:
 		panic("index on something that is neither map nor array")
 	}
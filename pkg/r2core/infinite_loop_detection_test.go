@@ -222,7 +222,7 @@ func TestRecursionInfinite_Detection(t *testing.T) {
 		},
 		{
 			name:        "deep recursion exceeding limit",
-			code:        `func deep(n) { if (n <= 0) { return 0; } return deep(n - 1); } deep(20);`,
+			code:        `func deep(n) { if (n <= 0) { return 0; } return 1 + deep(n - 1); } deep(20);`,
 			maxDepth:    10,
 			shouldPanic: true,
 			errorType:   "recursion",
@@ -0,0 +1,189 @@
+package r2core
+
+// Inspect recorre el árbol de n en profundidad: llama a f con cada nodo y, si
+// f devuelve true, sigue con sus hijos (las sentencias, expresiones, cuerpos
+// de funciones, patrones de match y switch, ...). Igual que ast.Inspect de Go.
+func Inspect(n Node, f func(Node) bool) {
+	if n == nil || !f(n) {
+		return
+	}
+	inspect := func(nodes ...Node) {
+		for _, child := range nodes {
+			Inspect(child, f)
+		}
+	}
+	block := func(b *BlockStatement) {
+		if b != nil {
+			Inspect(b, f)
+		}
+	}
+	params := func(params []Parameter) {
+		for _, p := range params {
+			inspect(p.DefaultValue)
+		}
+	}
+	generators := func(gens []Generator) {
+		for _, g := range gens {
+			inspect(g.Iterator)
+		}
+	}
+	switch s := n.(type) {
+	case *CompiledCode:
+		inspect(s.Nodes...)
+	case *Program:
+		inspect(s.Statements...)
+	case *BlockStatement:
+		inspect(s.Statements...)
+	case *ExprStatement:
+		inspect(s.Expr)
+	case *LetStatement:
+		inspect(s.Value)
+	case *MultipleLetStatement:
+		for _, d := range s.Declarations {
+			inspect(d.Value)
+		}
+	case *ConstStatement:
+		inspect(s.Value)
+	case *MultipleConstStatement:
+		for _, d := range s.Declarations {
+			inspect(d.Value)
+		}
+	case *GenericAssignStatement:
+		inspect(s.Left, s.Right)
+	case *IfStatement:
+		inspect(s.Condition)
+		block(s.Consequence)
+		block(s.Alternative)
+	case *WhileStatement:
+		inspect(s.Condition)
+		block(s.Body)
+	case *ForStatement:
+		inspect(s.Init, s.Condition, s.Post, s.inExpr)
+		block(s.Body)
+	case *ReturnStatement:
+		inspect(s.Value)
+	case *ThrowStatement:
+		inspect(s.Value)
+	case *FunctionDeclaration:
+		params(s.Params)
+		block(s.Body)
+	case *FunctionLiteral:
+		params(s.Params)
+		block(s.Body)
+	case *ArrowFunction:
+		params(s.Params)
+		inspect(s.Body)
+	case *TryStatement:
+		block(s.Body)
+		for _, c := range s.Catches {
+			block(c.Body)
+		}
+		block(s.FinallyBlock)
+	case *ObjectDeclaration:
+		inspect(s.Members...)
+	case *ExportStatement:
+		inspect(s.Declaration)
+	case *DSLDefinition:
+		block(s.Body)
+	case *ArrayDestructuring:
+		inspect(s.Value)
+	case *ObjectDestructuring:
+		inspect(s.Value)
+	case *ArrayLiteral:
+		inspect(s.Elements...)
+	case *MapLiteral:
+		for _, p := range s.Pairs {
+			inspect(p.Key, p.Value)
+		}
+	case *BinaryExpression:
+		inspect(s.Left, s.Right)
+	case *UnaryExpression:
+		inspect(s.Right)
+	case *AwaitExpression:
+		inspect(s.Value)
+	case *YieldExpression:
+		inspect(s.Value)
+	case *CallExpression:
+		inspect(s.Callee)
+		inspect(s.Args...)
+	case *AccessExpression:
+		inspect(s.Object)
+	case *OptionalAccessExpression:
+		inspect(s.Object)
+	case *IndexExpression:
+		inspect(s.Left, s.Index)
+	case *OptionalIndexExpression:
+		inspect(s.Object, s.Index)
+	case *TernaryExpression:
+		inspect(s.Condition, s.TrueExpr, s.FalseExpr)
+	case *TemplateString:
+		for _, p := range s.Parts {
+			inspect(p.Expression)
+		}
+	case *SpreadExpression:
+		inspect(s.Value)
+	case *MatchExpression:
+		inspect(s.Value)
+		for _, c := range s.Cases {
+			inspectPattern(c.Pattern, f)
+			inspect(c.Guard, c.Body)
+		}
+	case *SwitchStatement:
+		inspect(s.Value)
+		for _, c := range s.Cases {
+			for _, p := range c.Patterns {
+				inspectPattern(p, f)
+			}
+			block(c.Body)
+		}
+	case *EnumDeclaration:
+		for _, m := range s.Members {
+			inspect(m.Value)
+		}
+	case *StaticMember:
+		inspect(s.Member)
+	case *AccessorDeclaration:
+		if s.Func != nil {
+			Inspect(s.Func, f)
+		}
+	case *InterfaceDeclaration:
+		for _, m := range s.Methods {
+			if m != nil {
+				Inspect(m, f)
+			}
+		}
+	case *ArrayComprehension:
+		inspect(s.Expression)
+		generators(s.Generators)
+		inspect(s.Conditions...)
+	case *ObjectComprehension:
+		inspect(s.KeyExpr, s.ValueExpr)
+		generators(s.Generators)
+		inspect(s.Conditions...)
+	}
+}
+
+// inspectPattern recorre las expresiones de un patrón de match o switch.
+func inspectPattern(p Pattern, f func(Node) bool) {
+	switch s := p.(type) {
+	case *LiteralPattern:
+		Inspect(s.Value, f)
+	case *ValuePattern:
+		Inspect(s.Value, f)
+	case *GuardedPattern:
+		inspectPattern(s.Pattern, f)
+		Inspect(s.Guard, f)
+	case *ArrayPattern:
+		for _, e := range s.Elements {
+			inspectPattern(e, f)
+		}
+	case *ObjectPattern:
+		for _, e := range s.Fields {
+			inspectPattern(e, f)
+		}
+	case *OrPattern:
+		for _, e := range s.Patterns {
+			inspectPattern(e, f)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package r2core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInspect_VisitsNestedNodes(t *testing.T) {
+	prog := NewParser(`func f(a = x1) { if (x2) { return [y => x3, {k: x4}] } }
+let m = match v { case [p] if x5 == 1 => x6 case _ => 0 }
+class C { g() { for (i in x7()) { try { x8 } catch (e) { x9 } } } }`).ParseProgram()
+	var names []string
+	Inspect(prog, func(n Node) bool {
+		if id, ok := n.(*Identifier); ok && len(id.Name) == 2 && id.Name[0] == 'x' {
+			names = append(names, id.Name)
+		}
+		return true
+	})
+	want := []string{"x1", "x2", "x3", "x4", "x5", "x6", "x7", "x8", "x9"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+}
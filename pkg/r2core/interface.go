@@ -0,0 +1,113 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InterfaceDeclaration declara una interface o un trait: la lista de métodos
+// que debe tener una clase que lo implementa.
+//
+//	trait Plugin {
+//	    name()
+//	    run(input)
+//	    describe() { return "plugin " + this.name() }
+//	}
+//
+// Un método sin cuerpo es obligatorio; uno con cuerpo es una implementación
+// por defecto que se agrega a las clases que no lo definen. interface y
+// trait son sinónimos; Keyword conserva el que se escribió.
+type InterfaceDeclaration struct {
+	Keyword string
+	Name    string
+	Methods []*FunctionDeclaration // Body nil: método obligatorio
+}
+
+// InterfaceType es el valor de una interface declarada.
+type InterfaceType struct {
+	Keyword string
+	Name    string
+	Methods []InterfaceMethod
+}
+
+// InterfaceMethod es un método de una interface. Default es nil si es
+// obligatorio.
+type InterfaceMethod struct {
+	Name    string
+	Args    []string
+	Default *UserFunction
+}
+
+func (id *InterfaceDeclaration) Eval(env *Environment) interface{} {
+	iface := &InterfaceType{Keyword: id.Keyword, Name: id.Name}
+	for _, fd := range id.Methods {
+		m := InterfaceMethod{Name: fd.Name, Args: fd.Args}
+		if fd.Body != nil {
+			m.Default = newMethod(fd)
+		}
+		iface.Methods = append(iface.Methods, m)
+	}
+	env.Set(id.Name, iface)
+	return nil
+}
+
+func (it *InterfaceType) String() string {
+	return it.Keyword + " " + it.Name
+}
+
+func (m InterfaceMethod) signature() string {
+	return m.Name + "(" + strings.Join(m.Args, ", ") + ")"
+}
+
+// implementedBy indica si la clase tiene todos los métodos de la interface,
+// propios, heredados o agregados por ella.
+func (it *InterfaceType) implementedBy(class map[string]interface{}) bool {
+	for _, m := range it.Methods {
+		if !hasMethod(class, m.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasMethod indica si name es un método de instancia del blueprint.
+func hasMethod(blueprint map[string]interface{}, name string) bool {
+	if _, isFn := blueprint[name].(*UserFunction); !isFn {
+		return false
+	}
+	return !staticSet(blueprint)[name]
+}
+
+// implement comprueba que la clase tenga los métodos de cada interface de
+// od.Interfaces y le agrega las implementaciones por defecto que no define.
+// Falla, con la posición de la clase, listando los métodos que faltan.
+func (od *ObjectDeclaration) implement(blueprint map[string]interface{}, env *Environment) {
+	for _, name := range od.Interfaces {
+		raw, _ := env.Get(name)
+		iface, ok := raw.(*InterfaceType)
+		if !ok {
+			od.fail(env, fmt.Sprintf("class %s cannot implement %s: it is not an interface or trait", od.Name, name))
+		}
+		var missing []string
+		for _, m := range iface.Methods {
+			if hasMethod(blueprint, m.Name) {
+				continue
+			}
+			if m.Default != nil && !staticSet(blueprint)[m.Name] {
+				blueprint[m.Name] = m.Default
+				continue
+			}
+			missing = append(missing, m.signature())
+		}
+		if len(missing) > 0 {
+			od.fail(env, fmt.Sprintf("class %s does not implement %s %s: missing %s", od.Name, iface.Keyword, iface.Name, strings.Join(missing, ", ")))
+		}
+	}
+}
+
+func (od *ObjectDeclaration) fail(env *Environment, message string) {
+	if od.Position != nil && env.CurrentFile != "" {
+		od.Position.Filename = env.CurrentFile
+	}
+	PanicWithClass("TypeError", od.Position, message, env.callStack)
+}
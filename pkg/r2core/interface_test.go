@@ -0,0 +1,89 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInterface_DefaultsAndConformance(t *testing.T) {
+	code := `
+		trait Plugin {
+			name()
+			run(input)
+			describe() { return "plugin " + this.name() }
+		}
+		interface Closeable { func close() }
+		class Base implements Closeable {
+			close() { return "closed" }
+		}
+		class Echo extends Base implements Plugin {
+			name() { return "echo" }
+			run(input) { return input }
+		}
+		class Loud extends Echo {
+			describe() { return "LOUD" }
+		}
+		let e = Echo()
+		[e.describe(), e.run(1), e.close(), Loud().describe(), Loud().name()]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[plugin echo 1 closed LOUD echo]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestInterface_MissingMethods(t *testing.T) {
+	code := `trait Plugin {
+    name()
+    run(input)
+    stop()
+}
+class Half implements Plugin {
+    name() { return "half" }
+    static run(input) { return input }
+}`
+	defer func() {
+		r := fmt.Sprint(recover())
+		want := "test.r2:6:5: class Half does not implement trait Plugin: missing run(input), stop()"
+		if !strings.Contains(r, want) {
+			t.Errorf("expected %q, got %q", want, r)
+		}
+	}()
+	NewParserWithFile(code, "test.r2").ParseProgram().Eval(NewEnvironment())
+}
+
+func TestInterface_NotAnInterface(t *testing.T) {
+	defer func() {
+		if r := fmt.Sprint(recover()); !strings.Contains(r, "class X cannot implement Base: it is not an interface or trait") {
+			t.Errorf("unexpected error %s", r)
+		}
+	}()
+	evalCode(t, "class Base {}\nclass X implements Base {}")
+}
+
+func TestInterface_InstanceOfIsStructural(t *testing.T) {
+	code := `
+		interface Shape { area() }
+		class Square { area() { return 4 } }
+		class Named { let area = 1 }
+		class Empty {}
+		[Square() instanceof Shape, Named() instanceof Shape, Empty() instanceof Shape, 1 instanceof Shape]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[true false false false]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestInterface_KeywordsStayIdentifiers(t *testing.T) {
+	code := `
+		let interface = 1
+		let trait = 2
+		func implements(x) { return x + interface + trait }
+		implements(3)
+	`
+	if got := evalCode(t, code); got != float64(6) {
+		t.Errorf("expected 6, got %v", got)
+	}
+}
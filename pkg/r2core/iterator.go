@@ -0,0 +1,205 @@
+package r2core
+
+import "fmt"
+
+// Iterator es el protocolo de iteración perezosa de R2: los elementos se
+// piden de a uno con Next, sin materializar la secuencia completa. for-in, el
+// spread (...), la desestructuración de arrays, las comprehensions y las
+// funciones del módulo collections aceptan cualquier Iterator, además de los
+// arrays y mapas de siempre.
+//
+// Desde R2 un objeto es iterable si tiene un método next() que devuelve un
+// mapa {value, done} (como los generadores), o un método iterator() que
+// devuelve un objeto así. Desde Go basta con implementar Iterator, o
+// Iterable si cada recorrido necesita su propio estado (ver GetIterator).
+type Iterator interface {
+	// Next devuelve el siguiente elemento, o done=true cuando no hay más.
+	Next() (value interface{}, done bool)
+}
+
+// Iterable es un valor que se puede recorrer varias veces: cada llamada a
+// Iterator empieza un recorrido nuevo (por ejemplo, io.FileStream abre el
+// archivo otra vez).
+type Iterable interface {
+	Iterator() Iterator
+}
+
+// EntryIterable es una colección con claves (Map, ImmutableMap) que for-in y
+// las comprehensions recorren como a un mapa: la variable toma cada clave y
+// $v su valor. Su Iterator, el del spread y la desestructuración, da los
+// pares [clave, valor].
+type EntryIterable interface {
+	Iterable
+	Entries() EntryIterator
+}
+
+// EntryIterator recorre los pares de una EntryIterable, en orden.
+type EntryIterator interface {
+	NextEntry() (key, value interface{}, done bool)
+}
+
+// IteratorCloser es un Iterator que retiene recursos (un archivo, el
+// goroutine de un generador) y debe cerrarse si se abandona antes del final,
+// por ejemplo con un break dentro de for-in.
+type IteratorCloser interface {
+	Iterator
+	Close()
+}
+
+// GetIterator devuelve el Iterator de v, si v sigue el protocolo. Los arrays
+// y los mapas no cuentan: quien los acepta los recorre directamente.
+func GetIterator(v interface{}) (Iterator, bool) {
+	switch it := v.(type) {
+	case Iterator:
+		return it, true
+	case Iterable:
+		return it.Iterator(), true
+	case *ObjectInstance:
+		if fn, ok := it.Env.Get("iterator"); ok && isIteratorMethod(fn) {
+			inner, ok := GetIterator(callFunction(fn))
+			if !ok {
+				panic("iterator() must return an object with a next() method")
+			}
+			return inner, true
+		}
+		if fn, ok := it.Env.Get("next"); ok && isIteratorMethod(fn) {
+			return &objectIterator{next: fn}, true
+		}
+	}
+	return nil, false
+}
+
+// ToIterator es como GetIterator pero también recorre arrays.
+func ToIterator(v interface{}) (Iterator, bool) {
+	switch arr := v.(type) {
+	case []interface{}:
+		return &sliceIterator{items: arr}, true
+	case InterfaceSlice:
+		return &sliceIterator{items: arr}, true
+	}
+	return GetIterator(v)
+}
+
+// CloseIterator cierra it si retiene recursos; si no, no hace nada.
+func CloseIterator(it Iterator) {
+	if c, ok := it.(IteratorCloser); ok {
+		c.Close()
+	}
+}
+
+// CollectIterator consume it y devuelve sus elementos en un array.
+func CollectIterator(it Iterator) []interface{} {
+	defer CloseIterator(it)
+	result := make([]interface{}, 0)
+	for {
+		v, done := it.Next()
+		if done {
+			return result
+		}
+		result = append(result, v)
+	}
+}
+
+// NewIterator arma un Iterator a partir de una función next y, opcionalmente,
+// de la función que libera sus recursos. Lo usan las librerías para devolver
+// secuencias perezosas (collections.map sobre un iterador, por ejemplo).
+func NewIterator(next func() (interface{}, bool), close func()) Iterator {
+	return &funcIterator{next: next, close: close}
+}
+
+type funcIterator struct {
+	next   func() (interface{}, bool)
+	close  func()
+	closed bool
+}
+
+func (fi *funcIterator) Next() (interface{}, bool) {
+	if fi.closed {
+		return nil, true
+	}
+	v, done := fi.next()
+	if done {
+		fi.Close()
+	}
+	return v, done
+}
+
+func (fi *funcIterator) Close() {
+	if fi.closed {
+		return
+	}
+	fi.closed = true
+	if fi.close != nil {
+		fi.close()
+	}
+}
+
+func (fi *funcIterator) String() string {
+	return "Iterator{}"
+}
+
+type sliceIterator struct {
+	items []interface{}
+	i     int
+}
+
+func (si *sliceIterator) Next() (interface{}, bool) {
+	if si.i >= len(si.items) {
+		return nil, true
+	}
+	v := si.items[si.i]
+	si.i++
+	return v, false
+}
+
+// objectIterator adapta un objeto de R2 con método next() al protocolo.
+type objectIterator struct {
+	next interface{}
+}
+
+func (oi *objectIterator) Next() (interface{}, bool) {
+	return iteratorResult(callFunction(oi.next))
+}
+
+// iteratorResult interpreta el {value, done} que devuelve un next() de R2.
+func iteratorResult(res interface{}) (interface{}, bool) {
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		panic(fmt.Sprintf("next() must return a map {value, done}, got %T", res))
+	}
+	return m["value"], toBool(m["done"])
+}
+
+func isIteratorMethod(fn interface{}) bool {
+	switch fn.(type) {
+	case *UserFunction, BuiltinFunction:
+		return true
+	}
+	return false
+}
+
+// iteratorStep es el {value, done} que ven los scripts al llamar a next().
+func iteratorStep(value interface{}, done bool) map[string]interface{} {
+	return map[string]interface{}{"value": value, "done": done}
+}
+
+// evalIteratorAccess resuelve los métodos de un Iterator de Go desde R2:
+// next, toArray y close.
+func evalIteratorAccess(it Iterator, member string) interface{} {
+	switch member {
+	case "next":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return iteratorStep(it.Next())
+		})
+	case "toArray":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return CollectIterator(it)
+		})
+	case "close":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			CloseIterator(it)
+			return nil
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "Iterator does not have the method: "+member))
+}
@@ -3,6 +3,7 @@ package r2core
 // LetStatement => let x = expr;
 type LetStatement struct {
 	Name  string
+	Type  *TypeAnnotation // let nombre: tipo; nil si no está anotado
 	Value Node
 }
 
@@ -10,6 +11,7 @@ func (ls *LetStatement) Eval(env *Environment) interface{} {
 	var val interface{}
 	if ls.Value != nil {
 		val = ls.Value.Eval(env)
+		checkDeclared(env, ls.Name, ls.Type, val)
 	}
 	env.Set(ls.Name, val)
 	return nil
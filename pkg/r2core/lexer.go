@@ -46,6 +46,9 @@ const (
 	CATCH    = "catch"
 	FINALLY  = "finally"
 	THROW    = "throw"
+	ASYNC    = "async"
+	AWAIT    = "await"
+	YIELD    = "yield"
 	BREAK    = "break"
 	CONTINUE = "continue"
 	TRUE     = "true"
@@ -53,6 +56,19 @@ const (
 	NIL      = "nil"
 	MATCH    = "match"
 	CASE     = "case"
+	SWITCH   = "switch"
+	DEFAULT  = "default"
+	ENUM     = "enum"
+	STATIC   = "static"
+	GET      = "get"
+	SET      = "set"
+
+	INSTANCEOF = "instanceof"
+	INTERFACE  = "interface"
+	TRAIT      = "trait"
+	IMPLEMENTS = "implements"
+	EXPORT     = "export"
+	FROM       = "from"
 
 	// DSL tokens
 	DSL = "dsl"
@@ -98,6 +114,7 @@ type Token struct {
 	Line  int
 	Pos   int
 	Col   int
+	Start int // Byte offset of the token's first character in the input
 }
 
 // ============================================================
@@ -162,7 +179,9 @@ func (l *Lexer) parseNumberOrSign() Token {
 		hasDigits = true
 		l.nextch()
 	}
+	hasDot := false
 	if l.pos < l.length && l.input[l.pos] == '.' {
+		hasDot = true
 		l.nextch()
 		for l.pos < l.length && isDigit(l.input[l.pos]) {
 			hasDigits = true
@@ -172,6 +191,11 @@ func (l *Lexer) parseNumberOrSign() Token {
 	if !hasDigits {
 		panic("Invalid number in " + l.input[start:l.pos])
 	}
+	// Sufijos de los tipos exactos: 10n (BigInt) y 12.50d (Decimal)
+	if l.pos < l.length && (l.input[l.pos] == 'd' || (l.input[l.pos] == 'n' && !hasDot)) &&
+		(l.pos+1 >= l.length || !isValidIdentifierChar(rune(l.input[l.pos+1]))) {
+		l.nextch()
+	}
 	val := l.input[start:l.pos]
 	l.currentToken = Token{Type: TOKEN_NUMBER, Value: val, Line: l.line, Pos: l.pos, Col: l.col}
 	return l.currentToken
@@ -210,22 +234,23 @@ skipWhitespace:
 	}
 
 	if l.pos >= l.length {
-		l.currentToken = Token{Type: TOKEN_EOF, Value: "", Line: l.line, Pos: l.pos, Col: l.col}
+		l.currentToken = Token{Type: TOKEN_EOF, Value: "", Line: l.line, Pos: l.pos, Col: l.col, Start: l.pos}
 		return l.currentToken
 	}
 
 	ch := l.input[l.pos]
+	start := l.pos
 
 	if token, ok := l.parseSymbolToken(ch); ok {
-		return token
+		return l.markStart(token, start)
 	}
 
 	if token, ok := l.parseNumberToken(ch); ok {
-		return token
+		return l.markStart(token, start)
 	}
 
 	if token, ok := l.parseIdentifierToken(ch); ok {
-		return token
+		return l.markStart(token, start)
 	}
 
 	// Panic instead of os.Exit(1): os.Exit bypasses deferred recover() calls,
@@ -234,6 +259,15 @@ skipWhitespace:
 	panic(fmt.Sprintf("Line: %d,Col: %d\nUnexpected character in lexer: %c\n", l.line, l.col, ch))
 }
 
+// markStart records where token began in the input. The individual
+// scanners set Pos inconsistently (some before, most after consuming the
+// token), so Start is the one offset tooling can rely on.
+func (l *Lexer) markStart(token Token, start int) Token {
+	token.Start = start
+	l.currentToken.Start = start
+	return token
+}
+
 func (l *Lexer) parseSymbolToken(ch byte) (Token, bool) {
 	// Números con signo y operadores
 	// busca signos + o - seguidos de dígitos y que no estén precedidos por (, [, , o =
@@ -478,10 +512,17 @@ func (l *Lexer) parseIdentifierToken(ch byte) (Token, bool) {
 		l.currentToken = Token{Type: TOKEN_STRING, Value: val, Line: l.line, Pos: l.pos, Col: l.col}
 		return l.currentToken, true
 	}
-	// Identificadores Unicode
+	// Identificadores Unicode; "#nombre" es un campo privado de una clase
+	start := l.pos
+	private := ch == '#' && l.pos+1 < l.length
+	if private {
+		l.pos++
+	}
 	r, size := utf8.DecodeRuneInString(l.input[l.pos:])
 	if r != utf8.RuneError && isValidIdentifierStart(r) {
-		start := l.pos
+		if private {
+			l.col++
+		}
 		l.pos += size
 		l.col += size
 
@@ -534,6 +575,7 @@ func (l *Lexer) parseIdentifierToken(ch byte) (Token, bool) {
 			return l.currentToken, true
 		}
 	}
+	l.pos = start
 	return Token{}, false
 }
 
@@ -0,0 +1,150 @@
+package r2core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// stdPrefix marca los imports de la librería estándar: import "std:json".
+const stdPrefix = "std:"
+
+// Libraries son las librerías estándar que un programa puede importar con
+// "std:nombre". r2core no conoce r2libs: las agrega el intérprete (r2lang)
+// con Add y las instala con SetLibraries.
+//
+// Una librería con globales se carga además la primera vez que el programa
+// usa uno de ellos sin importarla, así que ninguna se registra hasta que
+// hace falta.
+type Libraries struct {
+	mu       sync.Mutex
+	register map[string]func(env *Environment)
+	globals  map[string]string                 // global -> librería que lo define
+	loaded   map[string]map[string]interface{} // librería -> lo que registró
+}
+
+func NewLibraries() *Libraries {
+	return &Libraries{
+		register: make(map[string]func(env *Environment)),
+		globals:  make(map[string]string),
+		loaded:   make(map[string]map[string]interface{}),
+	}
+}
+
+// Add agrega la librería name, que register registra en un entorno. globals
+// son los nombres que register define y que se cargan al usarlos; sin
+// globals la librería sólo se carga con import "std:name".
+func (l *Libraries) Add(name string, register func(env *Environment), globals ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.register[name] = register
+	for _, g := range globals {
+		l.globals[g] = name
+	}
+}
+
+// Names devuelve los nombres de las librerías, ordenados.
+func (l *Libraries) Names() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names := make([]string, 0, len(l.register))
+	for name := range l.register {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// load registra la librería name en un entorno propio, hijo de root para que
+// herede su salida y su política, y devuelve lo que definió. Cada librería se
+// registra una sola vez.
+func (l *Libraries) load(root *Environment, name string) (map[string]interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if values, ok := l.loaded[name]; ok {
+		return values, true
+	}
+	register, ok := l.register[name]
+	if !ok {
+		return nil, false
+	}
+	libEnv := NewInnerEnv(root)
+	register(libEnv)
+	values := libEnv.GetStore()
+	l.loaded[name] = values
+	return values, true
+}
+
+// resolve carga, en root, la librería que define el global name si todavía
+// no se cargó. Devuelve false si ninguna lo define.
+func (l *Libraries) resolve(root *Environment, name string) bool {
+	l.mu.Lock()
+	lib, ok := l.globals[name]
+	l.mu.Unlock()
+	if !ok {
+		return false
+	}
+	values, _ := l.load(root, lib)
+	for k, v := range values {
+		if _, defined := root.lookupLocal(k); !defined {
+			root.Set(k, v)
+		}
+	}
+	_, defined := root.lookupLocal(name)
+	return defined
+}
+
+// SetLibraries instala las librerías estándar del programa.
+func (e *Environment) SetLibraries(libs *Libraries) {
+	e.libraries = libs
+}
+
+// Libraries devuelve las librerías instaladas en este entorno o en uno
+// exterior, o nil.
+func (e *Environment) Libraries() *Libraries {
+	for env := e; env != nil; env = env.outer {
+		if env.libraries != nil {
+			return env.libraries
+		}
+	}
+	return nil
+}
+
+// root devuelve el entorno global.
+func (e *Environment) root() *Environment {
+	env := e
+	for env.outer != nil {
+		env = env.outer
+	}
+	return env
+}
+
+// lookupLocal busca name sólo en este entorno, sin pasar por los exteriores.
+func (e *Environment) lookupLocal(name string) (interface{}, bool) {
+	e.storeMu.RLock()
+	defer e.storeMu.RUnlock()
+	if v, ok := e.store[name]; ok {
+		return v.Value, true
+	}
+	return nil, false
+}
+
+// importStd devuelve el nombre y el módulo de la librería estándar de path
+// ("std:json").
+func importStd(env *Environment, path string) (string, map[string]interface{}) {
+	name := strings.TrimPrefix(path, stdPrefix)
+	libs := env.Libraries()
+	if libs == nil {
+		panic(fmt.Sprintf("Cannot import %s: no standard library is available", path))
+	}
+	values, ok := libs.load(env.root(), name)
+	if !ok {
+		panic(fmt.Sprintf("Unknown standard library %q (available: %s)", path, strings.Join(libs.Names(), ", ")))
+	}
+	module, isModule := values[name].(map[string]interface{})
+	if !isModule {
+		panic(fmt.Sprintf("Cannot import %s: it is not a module", path))
+	}
+	return name, module
+}
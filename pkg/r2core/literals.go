@@ -9,6 +9,17 @@ func (nl *NumberLiteral) Eval(env *Environment) interface{} {
 	return nl.Value
 }
 
+// ExactNumberLiteral es un literal numérico que no es float64: 10n (BigInt),
+// 12.50d (Decimal) o un entero demasiado grande para un float64 (int64).
+type ExactNumberLiteral struct {
+	Raw   string // el literal tal como está en el código
+	Value interface{}
+}
+
+func (el *ExactNumberLiteral) Eval(env *Environment) interface{} {
+	return el.Value
+}
+
 type StringLiteral struct {
 	Value string
 }
@@ -40,18 +51,24 @@ func (al *ArrayLiteral) Eval(env *Environment) interface{} {
 
 // Queremos un "FunctionLiteral" para soportar func(...) { ... } anónimas
 type FunctionLiteral struct {
-	Args   []string    // For backward compatibility
-	Params []Parameter // New parameter structure with default values
-	Body   *BlockStatement
+	Args      []string    // For backward compatibility
+	Params    []Parameter // New parameter structure with default values
+	Body      *BlockStatement
+	Async     bool            // async func(...) { ... }
+	Generator bool            // func*(...) { ... yield ... }
+	Return    *TypeAnnotation // func(...): tipo { ... }
 }
 
 func (fl *FunctionLiteral) Eval(env *Environment) interface{} {
 	fn := &UserFunction{
-		Args:     fl.Args,
-		Params:   fl.Params,
-		Body:     fl.Body,
-		Env:      env, // closure
-		IsMethod: false,
+		Args:        fl.Args,
+		Params:      fl.Params,
+		Body:        fl.Body,
+		Env:         env, // closure
+		IsMethod:    false,
+		IsAsync:     fl.Async,
+		IsGenerator: fl.Generator,
+		ReturnType:  fl.Return,
 	}
 	return fn
 }
@@ -13,28 +13,39 @@ func (ml *MapLiteral) Eval(env *Environment) interface{} {
 	m := make(map[string]interface{})
 
 	for _, pair := range ml.Pairs {
-		// Verificar si el valor es un spread
-		val := pair.Value.Eval(env)
-		if sv, isSpread := IsSpreadValue(val); isSpread {
-			// Expandir objeto spread
-			switch obj := sv.Value.(type) {
-			case map[string]interface{}:
-				// Expandir todas las propiedades del objeto
-				for k, v := range obj {
-					m[k] = v
-				}
-			default:
-				// Si no es un objeto, lo tratamos como una propiedad normal
-				keyVal := pair.Key.Eval(env)
-				keyStr := toString(keyVal)
-				m[keyStr] = obj
+		setMapPair(env, m, pair, pair.Value.Eval(env))
+	}
+	return m
+}
+
+// setMapPair guarda en m el par ya evaluado a val; la clave sólo se evalúa si
+// hace falta (compartido con la VM).
+func setMapPair(env *Environment, m map[string]interface{}, pair MapPair, val interface{}) {
+	// Verificar si el valor es un spread
+	if sv, isSpread := IsSpreadValue(val); isSpread {
+		// Expandir objeto spread
+		switch obj := sv.Value.(type) {
+		case map[string]interface{}:
+			// Expandir todas las propiedades del objeto
+			for k, v := range obj {
+				m[k] = v
 			}
-		} else {
-			// Evaluar clave y valor normalmente
+		case KeyedCollection:
+			// Las claves que no son strings se convierten como las calculadas
+			obj.Range(func(k, v interface{}) bool {
+				m[toString(k)] = v
+				return true
+			})
+		default:
+			// Si no es un objeto, lo tratamos como una propiedad normal
 			keyVal := pair.Key.Eval(env)
 			keyStr := toString(keyVal)
-			m[keyStr] = val
+			m[keyStr] = obj
 		}
+	} else {
+		// Evaluar clave y valor normalmente
+		keyVal := pair.Key.Eval(env)
+		keyStr := toString(keyVal)
+		m[keyStr] = val
 	}
-	return m
 }
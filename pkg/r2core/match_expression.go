@@ -1,7 +1,9 @@
 package r2core
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 )
 
 // MatchExpression represents a match statement (P3)
@@ -193,6 +195,11 @@ func (me *MatchExpression) Eval(env *Environment) interface{} {
 		}
 	}
 
+	if ev, ok := value.(*EnumValue); ok {
+		missing := me.unhandledEnumMembers(ev, env)
+		panic(fmt.Sprintf("No matching case found in match expression for %s; unhandled members of %s: %s",
+			ev, ev.Enum.Name, strings.Join(enumNames(missing), ", ")))
+	}
 	panic("No matching case found in match expression")
 }
 
@@ -8,6 +8,7 @@ type MultipleLetStatement struct {
 // LetDeclaration representa una declaración individual en una declaración múltiple
 type LetDeclaration struct {
 	Name  string
+	Type  *TypeAnnotation // let nombre: tipo; nil si no está anotado
 	Value Node
 }
 
@@ -18,6 +19,7 @@ func (mls *MultipleLetStatement) Eval(env *Environment) interface{} {
 	for _, decl := range mls.Declarations {
 		if decl.Value != nil {
 			value := decl.Value.Eval(env)
+			checkDeclared(env, decl.Name, decl.Type, value)
 			env.Set(decl.Name, value)
 			lastValue = value
 		} else {
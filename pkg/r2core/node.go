@@ -79,19 +79,12 @@ func CreatePositionError(pos *PositionInfo, message string) string {
 
 // PanicWithPosition creates a panic with position information for VSCode linking
 func PanicWithPosition(pos *PositionInfo, message string) {
-	panic(CreatePositionError(pos, message))
+	panic(&RuntimeError{Class: "Error", Message: message, Position: pos})
 }
 
 // PanicWithStack creates a panic with position and call stack information
 func PanicWithStack(pos *PositionInfo, message string, callStack *CallStack) {
-	errorMsg := CreatePositionError(pos, message)
-	if callStack != nil {
-		stackTrace := callStack.FormatStackTrace()
-		if stackTrace != "" {
-			errorMsg += stackTrace
-		}
-	}
-	panic(errorMsg)
+	PanicWithClass("Error", pos, message, callStack)
 }
 
 // GetNodePosition extracts position from a node if it implements PositionedNode
@@ -108,27 +101,37 @@ func (cs *CallStack) FormatStackTrace() string {
 	frames := make([]StackFrame, len(cs.Frames))
 	copy(frames, cs.Frames)
 	cs.mu.Unlock()
+	return formatFrames(frames)
+}
 
+// formatFrames escribe frames como la pila de llamadas de un mensaje de
+// error, la llamada más reciente primero; "" si no hay ninguna.
+func formatFrames(frames []StackFrame) string {
 	if len(frames) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
-	sb.WriteString("\nR2Lang call stack (most recent call first):\n")
+	sb.WriteString("\n" + callStackHeader)
 
 	for i := len(frames) - 1; i >= 0; i-- {
-		frame := frames[i]
-		if frame.Position != nil && frame.Position.Filename != "" {
-			sb.WriteString(fmt.Sprintf("    %s:%d:%d in %s()\n",
-				frame.Position.Filename, frame.Position.Line, frame.Position.Col, frame.FunctionName))
-		} else {
-			sb.WriteString(fmt.Sprintf("    in %s()\n", frame.FunctionName))
-		}
+		sb.WriteString("    " + frames[i].String() + "\n")
 	}
 
 	return sb.String()
 }
 
+// callStackHeader encabeza la pila de llamadas en los mensajes de error.
+const callStackHeader = "R2Lang call stack (most recent call first):\n"
+
+// String describe la frame como una línea de la pila de llamadas.
+func (f StackFrame) String() string {
+	if f.Position != nil && f.Position.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d in %s()", f.Position.Filename, f.Position.Line, f.Position.Col, f.FunctionName)
+	}
+	return fmt.Sprintf("in %s()", f.FunctionName)
+}
+
 // PushFrame adds a new frame to the call stack
 func (cs *CallStack) PushFrame(functionName string, pos *PositionInfo, args []interface{}) {
 	frame := StackFrame{
@@ -150,6 +153,13 @@ func (cs *CallStack) PopFrame() {
 	cs.mu.Unlock()
 }
 
+// Len returns the number of frames in the call stack
+func (cs *CallStack) Len() int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return len(cs.Frames)
+}
+
 // Clone creates a copy of the call stack
 func (cs *CallStack) Clone() *CallStack {
 	cs.mu.Lock()
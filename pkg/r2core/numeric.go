@@ -0,0 +1,664 @@
+package r2core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Tipos numéricos de R2. Además del float64 de siempre hay tres tipos
+// exactos:
+//
+//	int64          entero de 64 bits; si una operación desborda pasa a BigInt
+//	*big.Int       BigInt de precisión arbitraria, literal 10n
+//	*DecimalValue  decimal de punto fijo, literal 12.50d
+//
+// Al operar dos números de tipos distintos se promueven al "mayor" según
+// int64 < BigInt < Decimal. Un float64 entra al tipo exacto del otro operando
+// (los literales sin sufijo siguen siendo float64, y 12.50d * 2 debe seguir
+// siendo exacto); sólo si el float no tiene equivalente exacto (3.5 junto a
+// un entero, NaN, Inf) el resultado es float64.
+
+// maxSafeInteger es 2^53: los enteros menores (en valor absoluto) entran
+// exactos en un float64. Los literales y los números leídos de JSON o de una base
+// de datos que lo superan se guardan como int64 o BigInt.
+const maxSafeInteger = 1 << 53
+
+// decimalDivisionScale es la cantidad mínima de decimales con la que se
+// calcula una división de Decimal que no es exacta.
+const decimalDivisionScale = 16
+
+type numKind int
+
+const (
+	numNone numKind = iota
+	numFloat
+	numInt
+	numBig
+	numDecimal
+)
+
+func numericKind(v interface{}) numKind {
+	switch v.(type) {
+	case float64, int:
+		return numFloat
+	case int64:
+		return numInt
+	case *big.Int:
+		return numBig
+	case *DecimalValue:
+		return numDecimal
+	}
+	return numNone
+}
+
+// isExactNumber indica si v es int64, BigInt o Decimal.
+func isExactNumber(v interface{}) bool {
+	return numericKind(v) >= numInt
+}
+
+// DecimalValue es un número decimal de punto fijo: unscaled * 10^-scale.
+// Es inmutable; cada operación devuelve un valor nuevo. La escala se
+// conserva (12.50d imprime "12.50") y crece sólo lo necesario para que el
+// resultado sea exacto.
+type DecimalValue struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimalValue crea el decimal unscaled * 10^-scale.
+func NewDecimalValue(unscaled *big.Int, scale int) *DecimalValue {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return &DecimalValue{unscaled: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// ParseDecimal interpreta s ("12.50", "-3", "1.5e3") como Decimal.
+func ParseDecimal(s string) (*DecimalValue, error) {
+	text := strings.TrimSpace(s)
+	exp := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal: %q", s)
+		}
+		exp = e
+		text = text[:i]
+	}
+	digits := text
+	scale := 0
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		digits = text[:i] + text[i+1:]
+		scale = len(text) - i - 1
+	}
+	if digits == "" || digits == "-" || digits == "+" || strings.ContainsAny(digits[1:], "+-") {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+	return NewDecimalValue(unscaled, scale-exp), nil
+}
+
+// DecimalFromFloat convierte f al decimal más corto que lo representa
+// (0.1 es 0.1d, no 0.1000000000000000055...).
+func DecimalFromFloat(f float64) *DecimalValue {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(newRuntimeError("TypeError", fmt.Sprintf("Cannot convert %v to decimal", f)))
+	}
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		panic(err.Error())
+	}
+	return d
+}
+
+func (d *DecimalValue) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	cut := len(digits) - d.scale
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+func (d *DecimalValue) Type() string {
+	return "Decimal"
+}
+
+// Scale devuelve la cantidad de decimales.
+func (d *DecimalValue) Scale() int {
+	return d.scale
+}
+
+// Sign devuelve -1, 0 o 1.
+func (d *DecimalValue) Sign() int {
+	return d.unscaled.Sign()
+}
+
+// Float64 devuelve el float64 más cercano.
+func (d *DecimalValue) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Round devuelve d con exactamente places decimales, redondeando la mitad
+// hacia afuera del cero (12.345 -> 12.35, -12.345 -> -12.35).
+func (d *DecimalValue) Round(places int) *DecimalValue {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d.rescale(places)
+	}
+	return &DecimalValue{unscaled: divRound(d.unscaled, pow10(d.scale-places)), scale: places}
+}
+
+// Truncate descarta los decimales.
+func (d *DecimalValue) Truncate() *big.Int {
+	return new(big.Int).Quo(d.unscaled, pow10(d.scale))
+}
+
+// Cmp compara d con other: -1, 0 o 1.
+func (d *DecimalValue) Cmp(other *DecimalValue) int {
+	scale := max(d.scale, other.scale)
+	return d.rescale(scale).unscaled.Cmp(other.rescale(scale).unscaled)
+}
+
+// rescale lleva d a una escala mayor o igual, sin perder nada.
+func (d *DecimalValue) rescale(scale int) *DecimalValue {
+	if scale == d.scale {
+		return d
+	}
+	return &DecimalValue{unscaled: new(big.Int).Mul(d.unscaled, pow10(scale-d.scale)), scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// divRound divide redondeando la mitad hacia afuera del cero.
+func divRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// ParseNumber interpreta el texto de un literal numérico: "10" y "1.5" son
+// float64, "10n" es BigInt y "12.50d" Decimal. Un entero sin sufijo que no
+// entra exacto en un float64 se guarda como int64 (o BigInt si tampoco entra
+// en 64 bits), para que los IDs grandes no pierdan dígitos.
+func ParseNumber(text string) (interface{}, error) {
+	switch {
+	case strings.HasSuffix(text, "n"):
+		n, ok := new(big.Int).SetString(strings.TrimPrefix(text[:len(text)-1], "+"), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid BigInt literal: %s", text)
+		}
+		return n, nil
+	case strings.HasSuffix(text, "d"):
+		return ParseDecimal(text[:len(text)-1])
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, err
+	}
+	if math.Abs(f) < maxSafeInteger || strings.ContainsAny(text, ".eE") {
+		return f, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if n, ok := new(big.Int).SetString(strings.TrimPrefix(text, "+"), 10); ok {
+		return n, nil
+	}
+	return f, nil
+}
+
+// IntegerValue devuelve i como float64 si entra exacto en uno, o como int64
+// si no. Lo usan las librerías para los enteros que leen de afuera (JSON, una
+// base de datos) sin cambiar el tipo de los números de siempre.
+func IntegerValue(i int64) interface{} {
+	if i > -maxSafeInteger && i < maxSafeInteger {
+		return float64(i)
+	}
+	return i
+}
+
+// ToInt64 convierte v a int64, descartando los decimales.
+func ToInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case *big.Int:
+		if !n.IsInt64() {
+			panic("BigInt " + n.String() + " does not fit in int64")
+		}
+		return n.Int64()
+	case *DecimalValue:
+		return ToInt64(n.Truncate())
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64); err == nil {
+			return i
+		}
+	}
+	f := toFloat(v)
+	if math.IsNaN(f) || f >= 1<<63 || f < -(1<<63) {
+		panic(newRuntimeError("TypeError", fmt.Sprintf("Cannot convert %v to int64", v)))
+	}
+	return int64(f)
+}
+
+// ToBigInt convierte v a BigInt, descartando los decimales.
+func ToBigInt(v interface{}) *big.Int {
+	switch n := v.(type) {
+	case *big.Int:
+		return n
+	case int64:
+		return big.NewInt(n)
+	case int:
+		return big.NewInt(int64(n))
+	case *DecimalValue:
+		return n.Truncate()
+	case string:
+		if b, ok := new(big.Int).SetString(strings.TrimSpace(n), 10); ok {
+			return b
+		}
+		panic(newRuntimeError("TypeError", "Cannot convert string to BigInt: "+n))
+	}
+	f := toFloat(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic(newRuntimeError("TypeError", fmt.Sprintf("Cannot convert %v to BigInt", f)))
+	}
+	b, _ := new(big.Float).SetFloat64(math.Trunc(f)).Int(nil)
+	return b
+}
+
+// ToDecimal convierte v a Decimal.
+func ToDecimal(v interface{}) *DecimalValue {
+	switch n := v.(type) {
+	case *DecimalValue:
+		return n
+	case int64:
+		return &DecimalValue{unscaled: big.NewInt(n)}
+	case int:
+		return &DecimalValue{unscaled: big.NewInt(int64(n))}
+	case *big.Int:
+		return &DecimalValue{unscaled: n}
+	case string:
+		d, err := ParseDecimal(n)
+		if err != nil {
+			panic(newRuntimeError("TypeError", "Cannot convert string to decimal: "+n))
+		}
+		return d
+	}
+	return DecimalFromFloat(toFloat(v))
+}
+
+// bigToFloat devuelve el float64 más cercano a n.
+func bigToFloat(n *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f
+}
+
+// exactZero indica si v es un cero de alguno de los tipos exactos.
+func exactZero(v interface{}) bool {
+	switch n := v.(type) {
+	case int64:
+		return n == 0
+	case *big.Int:
+		return n.Sign() == 0
+	case *DecimalValue:
+		return n.Sign() == 0
+	}
+	return false
+}
+
+// promoteNumbers lleva a y b a un mismo tipo numérico según las reglas de
+// arriba. Devuelve numNone si alguno no es un número.
+func promoteNumbers(a, b interface{}) (numKind, interface{}, interface{}) {
+	ka, kb := numericKind(a), numericKind(b)
+	if ka == numNone || kb == numNone {
+		return numNone, a, b
+	}
+	if ka == numFloat && kb != numFloat {
+		a, ka = floatToExact(toFloat(a), kb)
+	}
+	if kb == numFloat && ka != numFloat {
+		b, kb = floatToExact(toFloat(b), ka)
+	}
+	if ka == numFloat || kb == numFloat {
+		return numFloat, toFloat(a), toFloat(b)
+	}
+	kind := max(ka, kb)
+	switch kind {
+	case numBig:
+		return kind, ToBigInt(a), ToBigInt(b)
+	case numDecimal:
+		return kind, ToDecimal(a), ToDecimal(b)
+	}
+	return kind, a, b
+}
+
+// floatToExact convierte f al tipo exacto target si puede hacerlo sin
+// perder nada; si no, lo deja como float64.
+func floatToExact(f float64, target numKind) (interface{}, numKind) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f, numFloat
+	}
+	if target == numDecimal {
+		return DecimalFromFloat(f), numDecimal
+	}
+	if f != math.Trunc(f) {
+		return f, numFloat
+	}
+	if target == numInt && f >= -(1<<63) && f < 1<<63 {
+		return int64(f), numInt
+	}
+	return ToBigInt(f), numBig
+}
+
+// exactArith resuelve op (+ - * / %) cuando alguno de los operandos es un
+// número exacto. ok es false si la operación no le corresponde (ningún
+// número exacto, o un operando que no es número).
+func exactArith(op string, a, b interface{}) (result interface{}, ok bool) {
+	if !isExactNumber(a) && !isExactNumber(b) {
+		return nil, false
+	}
+	kind, x, y := promoteNumbers(a, b)
+	if (op == "/" || op == "%") && kind != numNone && kind != numFloat && exactZero(y) {
+		if op == "/" {
+			panic(newRuntimeError("ArithmeticError", "Division by zero"))
+		}
+		panic(newRuntimeError("ArithmeticError", "Modulo by zero"))
+	}
+	switch kind {
+	case numFloat:
+		return floatArith(op, x.(float64), y.(float64)), true
+	case numInt:
+		return intArith(op, x.(int64), y.(int64)), true
+	case numBig:
+		return bigArith(op, x.(*big.Int), y.(*big.Int)), true
+	case numDecimal:
+		return decimalArith(op, x.(*DecimalValue), y.(*DecimalValue)), true
+	}
+	return nil, false
+}
+
+func floatArith(op string, x, y float64) interface{} {
+	switch op {
+	case "+":
+		return x + y
+	case "-":
+		return x - y
+	case "*":
+		return x * y
+	case "/":
+		if y == 0 {
+			panic(newRuntimeError("ArithmeticError", "Division by zero"))
+		}
+		return x / y
+	default:
+		if y == 0 {
+			panic(newRuntimeError("ArithmeticError", "Modulo by zero"))
+		}
+		return float64(int64(x) % int64(y))
+	}
+}
+
+func intArith(op string, x, y int64) interface{} {
+	switch op {
+	case "+":
+		s := x + y
+		if (x >= 0) == (y >= 0) && (s >= 0) != (x >= 0) {
+			return new(big.Int).Add(big.NewInt(x), big.NewInt(y))
+		}
+		return s
+	case "-":
+		s := x - y
+		if (x >= 0) != (y >= 0) && (s >= 0) != (x >= 0) {
+			return new(big.Int).Sub(big.NewInt(x), big.NewInt(y))
+		}
+		return s
+	case "*":
+		return bigToInt64(new(big.Int).Mul(big.NewInt(x), big.NewInt(y)))
+	case "/":
+		if x%y != 0 {
+			return float64(x) / float64(y)
+		}
+		if x == math.MinInt64 && y == -1 {
+			return new(big.Int).Neg(big.NewInt(x))
+		}
+		return x / y
+	default:
+		return x % y
+	}
+}
+
+func bigArith(op string, x, y *big.Int) interface{} {
+	switch op {
+	case "+":
+		return new(big.Int).Add(x, y)
+	case "-":
+		return new(big.Int).Sub(x, y)
+	case "*":
+		return new(big.Int).Mul(x, y)
+	case "/":
+		q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+		if r.Sign() != 0 {
+			f, _ := new(big.Rat).SetFrac(x, y).Float64()
+			return f
+		}
+		return q
+	default:
+		return new(big.Int).Rem(x, y)
+	}
+}
+
+func decimalArith(op string, x, y *DecimalValue) interface{} {
+	switch op {
+	case "*":
+		return &DecimalValue{unscaled: new(big.Int).Mul(x.unscaled, y.unscaled), scale: x.scale + y.scale}
+	case "/":
+		return decimalDiv(x, y)
+	}
+	scale := max(x.scale, y.scale)
+	xs, ys := x.rescale(scale).unscaled, y.rescale(scale).unscaled
+	switch op {
+	case "+":
+		return &DecimalValue{unscaled: new(big.Int).Add(xs, ys), scale: scale}
+	case "-":
+		return &DecimalValue{unscaled: new(big.Int).Sub(xs, ys), scale: scale}
+	default:
+		return &DecimalValue{unscaled: new(big.Int).Rem(xs, ys), scale: scale}
+	}
+}
+
+// decimalDiv divide con al menos decimalDivisionScale decimales y quita los
+// ceros sobrantes, sin bajar de la escala de los operandos: 10.00d / 4 es
+// 2.50 y 1d / 3 es 0.3333333333333333.
+func decimalDiv(x, y *DecimalValue) *DecimalValue {
+	minScale := max(x.scale, y.scale)
+	scale := max(minScale, decimalDivisionScale)
+	// x/y con scale decimales: x.u * 10^(scale - x.s + y.s) / y.u
+	num := new(big.Int).Mul(x.unscaled, pow10(scale-x.scale+y.scale))
+	q := divRound(num, y.unscaled)
+	ten, rem := big.NewInt(10), new(big.Int)
+	for scale > minScale {
+		quo, r := new(big.Int).QuoRem(q, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		q, scale = quo, scale-1
+	}
+	return &DecimalValue{unscaled: q, scale: scale}
+}
+
+// bigToInt64 devuelve n como int64 si entra, o como BigInt si no.
+func bigToInt64(n *big.Int) interface{} {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	return n
+}
+
+// CompareNumbers compara dos números de cualquier tipo (-1, 0 o 1) con las
+// mismas reglas de promoción que la aritmética; ok es false si alguno no es
+// número. Con un NaN de por medio cmp es 2, que no satisface ninguna
+// comparación.
+func CompareNumbers(a, b interface{}) (cmp int, ok bool) {
+	kind, x, y := promoteNumbers(a, b)
+	switch kind {
+	case numFloat:
+		fx, fy := x.(float64), y.(float64)
+		switch {
+		case fx < fy:
+			return -1, true
+		case fx > fy:
+			return 1, true
+		case fx == fy:
+			return 0, true
+		}
+		return 2, true
+	case numInt:
+		ix, iy := x.(int64), y.(int64)
+		switch {
+		case ix < iy:
+			return -1, true
+		case ix > iy:
+			return 1, true
+		}
+		return 0, true
+	case numBig:
+		return x.(*big.Int).Cmp(y.(*big.Int)), true
+	case numDecimal:
+		return x.(*DecimalValue).Cmp(y.(*DecimalValue)), true
+	}
+	return 0, false
+}
+
+// exactComparison resuelve < > <= >= cuando alguno de los operandos es un
+// número exacto.
+func exactComparison(op string, a, b interface{}) (result bool, ok bool) {
+	if !isExactNumber(a) && !isExactNumber(b) {
+		return false, false
+	}
+	cmp, ok := CompareNumbers(a, b)
+	if !ok {
+		return false, false
+	}
+	switch op {
+	case "<":
+		return cmp == -1, true
+	case ">":
+		return cmp == 1, true
+	case "<=":
+		return cmp == -1 || cmp == 0, true
+	default:
+		return cmp == 1 || cmp == 0, true
+	}
+}
+
+// exactBitwise resuelve & | ^ << >> sobre int64 y BigInt. Un int64 que
+// desborda con << pasa a BigInt.
+func exactBitwise(op string, a, b interface{}) (interface{}, bool) {
+	if !isExactNumber(a) && !isExactNumber(b) {
+		return nil, false
+	}
+	kind, x, y := promoteNumbers(a, b)
+	switch kind {
+	case numInt, numBig:
+	case numDecimal:
+		panic("Bitwise operator " + op + " needs integers, got a decimal")
+	default:
+		return nil, false
+	}
+	bx, by := ToBigInt(x), ToBigInt(y)
+	result := new(big.Int)
+	switch op {
+	case "&":
+		result.And(bx, by)
+	case "|":
+		result.Or(bx, by)
+	case "^":
+		result.Xor(bx, by)
+	default:
+		if by.Sign() < 0 || !by.IsInt64() {
+			panic("Invalid shift count: " + by.String())
+		}
+		if op == "<<" {
+			result.Lsh(bx, uint(by.Int64()))
+		} else {
+			result.Rsh(bx, uint(by.Int64()))
+		}
+	}
+	if kind == numInt {
+		return bigToInt64(result), true
+	}
+	return result, true
+}
+
+// negateNumber es el - unario de los números exactos.
+func negateNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(n))
+		}
+		return -n
+	case *big.Int:
+		return new(big.Int).Neg(n)
+	case *DecimalValue:
+		return &DecimalValue{unscaled: new(big.Int).Neg(n.unscaled), scale: n.scale}
+	}
+	panic(fmt.Sprintf("Invalid operand for unary minus: %v", v))
+}
+
+// evalDecimalAccess resuelve los métodos de un Decimal: round(n), scale()
+// y toFloat().
+func evalDecimalAccess(d *DecimalValue, member string) interface{} {
+	switch member {
+	case "round":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			places := 0
+			if len(args) > 0 {
+				places = int(toFloat(args[0]))
+			}
+			return d.Round(places)
+		})
+	case "scale":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return float64(d.scale)
+		})
+	case "toFloat":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return d.Float64()
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "Decimal does not have the method: "+member))
+}
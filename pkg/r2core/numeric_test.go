@@ -0,0 +1,194 @@
+package r2core
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestNumeric_Literals(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+		typ  string
+	}{
+		{"10n", "10", "*big.Int"},
+		{"-10n", "-10", "*big.Int"},
+		{"12.50d", "12.50", "*r2core.DecimalValue"},
+		{"3d", "3", "*r2core.DecimalValue"},
+		{"9007199254740993", "9007199254740993", "int64"},
+		{"123456789012345678901234567890", "123456789012345678901234567890", "*big.Int"},
+		{"42", "42", "float64"},
+	}
+	for _, c := range cases {
+		got := evalCode(t, c.src)
+		if s := fmt.Sprint(got); s != c.want {
+			t.Errorf("%s: expected %s, got %s", c.src, c.want, s)
+		}
+		if typ := fmt.Sprintf("%T", got); typ != c.typ {
+			t.Errorf("%s: expected type %s, got %s", c.src, c.typ, typ)
+		}
+	}
+}
+
+func TestNumeric_DecimalIsExact(t *testing.T) {
+	cases := map[string]string{
+		"0.1d + 0.2d":     "0.3",
+		"12.50d * 3":      "37.50",
+		"10.00d / 4":      "2.50",
+		"1d / 3":          "0.3333333333333333",
+		"100.00d - 0.01d": "99.99",
+		"7.5d % 2":        "1.5",
+		"-12.50d":         "-12.50",
+		"12.50d + 1n":     "13.50",
+	}
+	for src, want := range cases {
+		if got := fmt.Sprint(evalCode(t, src)); got != want {
+			t.Errorf("%s: expected %s, got %s", src, want, got)
+		}
+	}
+	// La suma de 0.1 diez veces es exactamente 1 con Decimal
+	code := `
+		let total = 0d
+		let i = 0
+		while (i < 10) { total = total + 0.1d; i = i + 1 }
+		total == 1
+	`
+	if got := evalCode(t, code); got != true {
+		t.Errorf("expected ten times 0.1d to equal 1, got %v", got)
+	}
+}
+
+func TestNumeric_IntegerPromotion(t *testing.T) {
+	cases := map[string]string{
+		"9223372036854775807 + 1": "9223372036854775808",
+		"9007199254740993 + 1":    "9007199254740994",
+		"9007199254740993 * 2.5":  "2.251799813685248e+16",
+		"10n / 5":                 "2",
+		"10n / 4":                 "2.5",
+		"7n % 3":                  "1",
+		"1n << 70":                "1180591620717411303424",
+		"6n & 3":                  "2",
+		"~5n":                     "-6",
+	}
+	for src, want := range cases {
+		if got := fmt.Sprint(evalCode(t, src)); got != want {
+			t.Errorf("%s: expected %s, got %s", src, want, got)
+		}
+	}
+	if got := evalCode(t, "9223372036854775807 + 1"); fmt.Sprintf("%T", got) != "*big.Int" {
+		t.Errorf("expected int64 overflow to promote to BigInt, got %T", got)
+	}
+	if got := evalCode(t, "10n * 2"); fmt.Sprintf("%T", got) != "*big.Int" {
+		t.Errorf("expected BigInt * integral float to stay BigInt, got %T", got)
+	}
+}
+
+func TestNumeric_Comparisons(t *testing.T) {
+	cases := map[string]bool{
+		"12.50d == 12.5":                         true,
+		"10n == 10":                              true,
+		"0.1d == 0.1":                            true,
+		"12.50d > 12":                            true,
+		"3n < 2.5":                               false,
+		"9007199254740993 > 9007199254740992":    true,
+		"9007199254740993 == 9007199254740992":   false,
+		"123456789012345678901234567890n >= 0.5": true,
+		"0d ? true : false":                      false,
+	}
+	for src, want := range cases {
+		if got := evalCode(t, src); got != want {
+			t.Errorf("%s: expected %v, got %v", src, want, got)
+		}
+	}
+}
+
+func TestNumeric_DivisionByZero(t *testing.T) {
+	for _, src := range []string{"1n / 0", "1.5d / 0d", "5n % 0n"} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s: expected a panic", src)
+				}
+			}()
+			evalCode(t, src)
+		}()
+	}
+}
+
+func TestNumeric_RoundAndFormat(t *testing.T) {
+	code := `
+		let a = 12.345d
+		let b = -12.345d
+		let p = 12.5d
+		[a.round(2), b.round(2), p.round(2), ` + "`${a:.1f}`" + `, ` + "`${1234567.5d:,}`" + `, "Total: " + p]
+	`
+	got := evalCode(t, code).([]interface{})
+	want := []string{"12.35", "-12.35", "12.50", "12.3", "1,234,567.5", "Total: 12.5"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
+func TestNumeric_ParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"12.50":  "12.50",
+		"-0.05":  "-0.05",
+		"1.5e3":  "1500",
+		"2.5E-2": "0.025",
+		".5":     "0.5",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", in, err)
+			continue
+		}
+		if d.String() != want {
+			t.Errorf("%s: expected %s, got %s", in, want, d)
+		}
+	}
+	for _, bad := range []string{"", "abc", "1.2.3", "--1"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestNumeric_Conversions(t *testing.T) {
+	if got := ToInt64(3.9); got != 3 {
+		t.Errorf("ToInt64(3.9): expected 3, got %d", got)
+	}
+	if got := ToBigInt("123456789012345678901234567890"); got.String() != "123456789012345678901234567890" {
+		t.Errorf("ToBigInt: got %s", got)
+	}
+	if got := ToDecimal(0.1); got.String() != "0.1" {
+		t.Errorf("ToDecimal(0.1): expected 0.1, got %s", got)
+	}
+	if got := IntegerValue(42); got != 42.0 {
+		t.Errorf("IntegerValue(42): expected float64 42, got %T %v", got, got)
+	}
+	if got := IntegerValue(1 << 60); got != int64(1<<60) {
+		t.Errorf("IntegerValue(2^60): expected int64, got %T %v", got, got)
+	}
+	if cmp, ok := CompareNumbers(big.NewInt(5), 5.0); !ok || cmp != 0 {
+		t.Errorf("CompareNumbers(5n, 5): got %d %v", cmp, ok)
+	}
+}
+
+func TestNumeric_ArrayIndices(t *testing.T) {
+	cases := map[string]string{
+		"let a = [1, 2, 3]; a[1n]":          "2",
+		"let a = [1, 2, 3]; a[1.9d]":        "2",
+		"let a = [1, 2, 3]; a[-1n]":         "3",
+		"let a = [1, 2]; a[0.0d] = 5; a[0]": "5",
+		"let a = [1, 2]; a[2n] = 3; a[2]":   "3",
+	}
+	for src, want := range cases {
+		if got := fmt.Sprint(evalCode(t, src)); got != want {
+			t.Errorf("%s: expected %s, got %s", src, want, got)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package r2core
 
 type ObjectDeclaration struct {
+	BaseNode
 	Name       string
 	ParentName string
+	Interfaces []string // class X implements A, B
 	Members    []Node
 }
 
@@ -10,6 +12,7 @@ func (od *ObjectDeclaration) Eval(env *Environment) interface{} {
 	blueprint := make(map[string]interface{})
 	od.setupInheritance(blueprint, env)
 	od.addMembers(blueprint, env)
+	od.implement(blueprint, env)
 	env.Set(od.Name, blueprint)
 	return nil
 }
@@ -24,12 +27,21 @@ func (od *ObjectDeclaration) setupInheritance(blueprint map[string]interface{},
 	blueprint["SuperClassName"] = blueprint["ClassName"]
 	raw, _ := env.Get(od.ParentName)
 	if props, ok := raw.(map[string]interface{}); ok {
+		parentStatics := staticSet(props)
 		for k, v := range props {
-			if k == "ClassName" || k == "SuperClassName" || k == "super" {
+			if k == "ClassName" || k == "SuperClassName" || k == "super" || k == staticsKey {
 				continue
 			}
+			// Los métodos static heredados se ligan a la subclase; los campos
+			// static empiezan con una copia del valor del padre
+			if fn, ok := v.(*UserFunction); ok && parentStatics[k] {
+				v = staticMethod(fn, blueprint, env)
+			}
 			blueprint[k] = v
 		}
+		for k := range parentStatics {
+			statics(blueprint)[k] = true
+		}
 	}
 }
 
@@ -37,10 +49,25 @@ func (od *ObjectDeclaration) addMembers(blueprint map[string]interface{}, env *E
 	blueprint["ClassName"] = od.Name
 	for _, m := range od.Members {
 		switch node := m.(type) {
-		case *LetStatement:
-			if node.Name == "super" || node.Name == "ClassName" || node.Name == "SuperClassName" {
-				panic("Cannot redefine 'super'")
+		case *StaticMember:
+			od.addStatic(blueprint, node.Member, env)
+		case *AccessorDeclaration:
+			checkMemberName(node.Func.Name)
+			delete(staticSet(blueprint), node.Func.Name)
+			accessor := &Accessor{}
+			if inherited, ok := blueprint[node.Func.Name].(*Accessor); ok {
+				// Redefinir sólo el getter (o el setter) conserva el otro
+				*accessor = *inherited
 			}
+			if node.Kind == GET {
+				accessor.Get = newMethod(node.Func)
+			} else {
+				accessor.Set = newMethod(node.Func)
+			}
+			blueprint[node.Func.Name] = accessor
+		case *LetStatement:
+			checkMemberName(node.Name)
+			delete(staticSet(blueprint), node.Name)
 			// A class field's declared default (e.g. "let value = 0;") used
 			// to be discarded entirely — every field always started as nil
 			// regardless of its initializer, silently breaking any class
@@ -61,16 +88,49 @@ func (od *ObjectDeclaration) addMembers(blueprint map[string]interface{}, env *E
 			}
 			blueprint[node.Name] = defaultValue
 		case *FunctionDeclaration:
-			if node.Name == "super" || node.Name == "ClassName" || node.Name == "SuperClassName" {
-				panic("Cannot redefine 'super'")
-			}
-			fn := &UserFunction{
-				Args:     node.Args,
-				Body:     node.Body,
-				Env:      nil,
-				IsMethod: true,
-			}
-			blueprint[node.Name] = fn
+			checkMemberName(node.Name)
+			delete(staticSet(blueprint), node.Name)
+			blueprint[node.Name] = newMethod(node)
 		}
 	}
 }
+
+// addStatic agrega un campo o método static: queda en el blueprint, donde se
+// lee como Clase.nombre, y las instancias no lo copian.
+func (od *ObjectDeclaration) addStatic(blueprint map[string]interface{}, member Node, env *Environment) {
+	switch node := member.(type) {
+	case *LetStatement:
+		checkMemberName(node.Name)
+		var value interface{}
+		if node.Value != nil {
+			value = node.Value.Eval(env)
+		}
+		blueprint[node.Name] = value
+		statics(blueprint)[node.Name] = true
+	case *FunctionDeclaration:
+		checkMemberName(node.Name)
+		blueprint[node.Name] = staticMethod(newMethod(node), blueprint, env)
+		statics(blueprint)[node.Name] = true
+	}
+}
+
+func checkMemberName(name string) {
+	if name == "super" || name == "ClassName" || name == "SuperClassName" || name == staticsKey {
+		panic("Cannot redefine 'super'")
+	}
+}
+
+// newMethod crea el método sin ligar; instantiateObject lo liga a cada
+// instancia.
+func newMethod(fd *FunctionDeclaration) *UserFunction {
+	return &UserFunction{
+		Args:        fd.Args,
+		Params:      fd.Params,
+		Body:        fd.Body,
+		Env:         nil,
+		IsMethod:    true,
+		IsAsync:     fd.Async,
+		IsGenerator: fd.Generator,
+		ReturnType:  fd.Return,
+	}
+}
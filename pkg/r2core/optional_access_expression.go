@@ -35,6 +35,7 @@ func (oae *OptionalAccessExpression) Eval(env *Environment) interface{} {
 
 	switch obj := objVal.(type) {
 	case *ObjectInstance:
+		checkPrivateAccess(env, obj.Class, oae.Member)
 		return evalMemberAccessOptional(obj, oae.Member)
 	case map[string]interface{}:
 		return evalMapAccessOptional(obj, oae.Member)
@@ -55,6 +56,9 @@ func evalMemberAccessOptional(obj *ObjectInstance, member string) interface{} {
 	}
 
 	if val, exists := obj.Env.Get(member); exists {
+		if accessor, ok := val.(*Accessor); ok {
+			return accessor.get(member)
+		}
 		return val
 	}
 
@@ -116,11 +120,10 @@ func (oie *OptionalIndexExpression) Eval(env *Environment) interface{} {
 }
 
 func optionalArrayIndex(container []interface{}, indexVal interface{}) interface{} {
-	fIndex, ok := indexVal.(float64)
+	idx, ok := arrayIndex(indexVal)
 	if !ok {
 		return nil
 	}
-	idx := int(fIndex)
 	if idx < 0 {
 		idx = len(container) + idx
 	}
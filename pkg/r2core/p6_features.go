@@ -34,6 +34,16 @@ func (pf *PartialFunction) String() string {
 
 // Apply applies arguments to a partial function
 func (pf *PartialFunction) Apply(args ...interface{}) interface{} {
+	fn, full, partial := pf.fill(args)
+	if partial != nil {
+		return partial
+	}
+	return callFunction(fn, full...)
+}
+
+// fill combina args con los argumentos de pf: retorna la función y todos sus
+// argumentos si quedó completa, o la nueva aplicación parcial si no.
+func (pf *PartialFunction) fill(args []interface{}) (fn interface{}, full []interface{}, partial *PartialFunction) {
 	// If there are existing arguments (from explicit partial), combine them
 	if len(pf.Arguments) > 0 && !hasPlaceholders(pf.Arguments) {
 		// This is an explicit partial (partial(func, arg1, arg2, ...))
@@ -44,11 +54,11 @@ func (pf *PartialFunction) Apply(args ...interface{}) interface{} {
 
 		// If we have enough arguments, call the function
 		if len(combinedArgs) >= pf.Arity {
-			return callFunction(pf.Function, combinedArgs[:pf.Arity]...)
+			return pf.Function, combinedArgs[:pf.Arity], nil
 		}
 
 		// Otherwise, return a new partial function
-		return &PartialFunction{
+		return nil, nil, &PartialFunction{
 			Function:  pf.Function,
 			Arguments: combinedArgs,
 			Arity:     pf.Arity,
@@ -78,11 +88,11 @@ func (pf *PartialFunction) Apply(args ...interface{}) interface{} {
 
 	// If all arguments are filled, call the function
 	if filledCount == pf.Arity {
-		return callFunction(pf.Function, newArgs...)
+		return pf.Function, newArgs, nil
 	}
 
 	// Otherwise, return a new partial function
-	return &PartialFunction{
+	return nil, nil, &PartialFunction{
 		Function:  pf.Function,
 		Arguments: newArgs,
 		Arity:     pf.Arity,
@@ -109,17 +119,27 @@ func (cf *CurriedFunction) String() string {
 
 // Apply applies an argument to a curried function
 func (cf *CurriedFunction) Apply(arg interface{}) interface{} {
+	fn, full, next := cf.fill(arg)
+	if next != nil {
+		return next
+	}
+	return callFunction(fn, full...)
+}
+
+// fill agrega arg a los argumentos de cf: retorna la función y todos sus
+// argumentos si quedó completa, o la siguiente CurriedFunction si no.
+func (cf *CurriedFunction) fill(arg interface{}) (fn interface{}, full []interface{}, next *CurriedFunction) {
 	newArgs := make([]interface{}, len(cf.Arguments)+1)
 	copy(newArgs, cf.Arguments)
 	newArgs[len(cf.Arguments)] = arg
 
 	// If we have all arguments, call the function
 	if len(newArgs) == cf.Arity {
-		return callFunction(cf.Function, newArgs...)
+		return cf.Function, newArgs, nil
 	}
 
 	// Otherwise, return a new curried function with the additional argument
-	return &CurriedFunction{
+	return nil, nil, &CurriedFunction{
 		Function:      cf.Function,
 		Arity:         cf.Arity,
 		Arguments:     newArgs,
@@ -215,8 +235,8 @@ func CurryFunction(args ...interface{}) interface{} {
 		if len(args) < 2 {
 			panic("curry: variable arity function requires explicit arity as second argument")
 		}
-		if arityVal, ok := args[1].(float64); ok {
-			arity = int(arityVal)
+		if arityVal, ok := arrayIndex(args[1]); ok {
+			arity = arityVal
 		} else {
 			panic("curry: arity must be a number")
 		}
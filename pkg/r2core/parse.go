@@ -2,7 +2,6 @@ package r2core
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 )
 
@@ -14,6 +13,20 @@ type Parser struct {
 	peekTok  Token
 	baseDir  string // Directorio base para importaciones
 	filename string // Archivo actual para tracking de posición
+
+	// Modo de recolección de errores (ver ParseWithErrors)
+	collect   bool
+	lexFailed bool
+	errors    []*ParseError
+
+	// Información de layout para el formateador (ver Format); nil al ejecutar
+	layout *formatInfo
+
+	// Dentro del cuerpo de un func*: sólo ahí yield es una palabra clave
+	generator bool
+
+	// ParseProgram no resuelve las variables (ver SetResolve)
+	noResolve bool
 }
 
 func NewParser(input string) *Parser {
@@ -36,18 +49,32 @@ func (p *Parser) SetBaseDir(dir string) {
 	p.baseDir = dir
 }
 
+// SetResolve indica si ParseProgram resuelve las variables del programa
+// (ver Resolve); lo hace por defecto. Sin resolver, todas las variables se
+// buscan por nombre.
+func (p *Parser) SetResolve(resolve bool) {
+	p.noResolve = !resolve
+}
+
 func (p *Parser) parseImportStatement() Node {
+	importToken := p.curTok
 	p.nextToken() // Consumir 'import'
 
+	var names []ImportName
+	if p.curTok.Value == "{" {
+		names = p.parseImportNames()
+	}
+
 	if p.curTok.Type != TOKEN_STRING {
 		p.except("A string was expected after ‘import’")
 	}
 
 	path := p.curTok.Value
+	pathTok := p.curTok
 	p.nextToken()
 
 	var alias string
-	if p.curTok.Type == TOKEN_AS {
+	if p.curTok.Type == TOKEN_AS && names == nil {
 		p.nextToken() // Consumir 'as'
 		if p.curTok.Type != TOKEN_IDENT {
 			p.except("An identifier was expected after ‘as’")
@@ -60,36 +87,122 @@ func (p *Parser) parseImportStatement() Node {
 		p.nextToken() // Consumir ';'
 	}
 
-	return &ImportStatement{Path: path, Alias: alias}
+	node := &ImportStatement{
+		BaseNode: BaseNode{Position: CreatePositionInfo(importToken, p.filename)},
+		Path:     path,
+		Alias:    alias,
+		Names:    names,
+	}
+	p.recordRaw(node, pathTok)
+	return node
+}
+
+// parseImportNames parsea "{ a, b as c } from" de un import selectivo.
+func (p *Parser) parseImportNames() []ImportName {
+	names := []ImportName{}
+	p.nextToken() // Consumir '{'
+	for {
+		for p.curTok.Value == "\n" {
+			p.nextToken()
+		}
+		if p.curTok.Value == "}" {
+			break
+		}
+		if p.curTok.Type != TOKEN_IDENT {
+			p.except("A name was expected in ‘import { ... }’")
+		}
+		n := ImportName{Name: p.curTok.Value}
+		p.nextToken()
+		if p.curTok.Type == TOKEN_AS {
+			p.nextToken() // Consumir 'as'
+			if p.curTok.Type != TOKEN_IDENT {
+				p.except("An identifier was expected after ‘as’")
+			}
+			n.Alias = p.curTok.Value
+			p.nextToken()
+		}
+		names = append(names, n)
+		for p.curTok.Value == "\n" {
+			p.nextToken()
+		}
+		if p.curTok.Value != "," {
+			break
+		}
+		p.nextToken() // Consumir ','
+	}
+	if p.curTok.Value != "}" {
+		p.except("‘}’ was expected at the end of the imported names")
+	}
+	p.nextToken()
+	if p.curTok.Value != FROM {
+		p.except("‘from’ was expected after the imported names")
+	}
+	p.nextToken()
+	return names
+}
+
+// parseExportStatement parsea "export" seguido de una declaración.
+func (p *Parser) parseExportStatement() Node {
+	p.nextToken() // Consumir 'export'
+	decl := p.parseStatement()
+	if declaredNames(decl) == nil {
+		p.except("Only let, const, func, class, enum and interface declarations can be exported")
+	}
+	return &ExportStatement{Declaration: decl}
 }
 
 func (p *Parser) nextToken() {
 	p.prevTok = p.curTok
 	p.curTok = p.peekTok
+	if p.collect {
+		p.peekTok = p.collectToken()
+		return
+	}
 	p.peekTok = p.lexer.NextToken()
 
 }
 
 func (p *Parser) ParseProgram() *Program {
 	prog := &Program{}
+	spans := p.openList()
 	for p.curTok.Type != TOKEN_EOF {
 		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
 			p.nextToken()
 			continue
 		}
+		start := p.curTok.Start
+		pos := CreatePositionInfo(p.curTok, p.filename)
+		if p.collect {
+			if stmt := p.parseStatementRecovering(true); stmt != nil {
+				prog.Statements = append(prog.Statements, stmt)
+				prog.Positions = append(prog.Positions, pos)
+				spans.add(p, start)
+			}
+			continue
+		}
 		stmt := p.parseStatement()
 		prog.Statements = append(prog.Statements, stmt)
+		prog.Positions = append(prog.Positions, pos)
+		spans.add(p, start)
+	}
+	p.closeList(prog, spans)
+	if !p.noResolve {
+		Resolve(prog)
 	}
 	return prog
 }
 
 func (p *Parser) parseThrowStatement() Node {
-	p.nextToken()
-	if p.curTok.Type != TOKEN_STRING {
-		p.except("A string was expected after ‘throw’")
+	base := BaseNode{Position: CreatePositionInfo(p.curTok, p.filename)}
+	p.nextToken() // consumir "throw"
+	if p.curTok.Value == ";" || p.curTok.Value == "}" || p.curTok.Type == TOKEN_EOF {
+		p.except("An expression was expected after ‘throw’")
+	}
+	node := &ThrowStatement{BaseNode: base, Value: p.parseExpression()}
+	if p.curTok.Value == ";" {
+		p.nextToken()
 	}
-	message := fmt.Sprint(p.curTok.Value)
-	return &ThrowStatement{Message: message}
+	return node
 }
 
 func (p *Parser) parseStatement() Node {
@@ -132,6 +245,16 @@ func (p *Parser) parseStatement() Node {
 		// esto parsea "func nombre(...) { ... }" => FunctionDeclaration con nombre
 		return p.parseFunctionDeclaration()
 	}
+	if p.curTok.Value == ASYNC && p.peekTok.Type == TOKEN_IDENT && (p.peekTok.Value == FUNC || p.peekTok.Value == FUNCTION) {
+		// "async func nombre(...) { ... }"
+		p.nextToken() // consumir "async"
+		fd := p.parseFunctionDeclaration().(*FunctionDeclaration)
+		if fd.Generator {
+			p.except("async generators are not supported")
+		}
+		fd.Async = true
+		return fd
+	}
 	if p.curTok.Value == IF {
 		return p.parseIfStatement()
 	}
@@ -141,6 +264,9 @@ func (p *Parser) parseStatement() Node {
 	if p.curTok.Value == FOR {
 		return p.parseForStatement()
 	}
+	if p.curTok.Value == SWITCH && p.curTok.Type == TOKEN_IDENT && p.peekTok.Value == "(" {
+		return p.parseSwitchStatement()
+	}
 
 	if p.curTok.Value == OBJECT {
 		return p.parseObjectDeclaration()
@@ -150,6 +276,18 @@ func (p *Parser) parseStatement() Node {
 		return p.parseObjectDeclaration()
 	}
 
+	if p.curTok.Value == ENUM && p.curTok.Type == TOKEN_IDENT && p.peekTok.Type == TOKEN_IDENT {
+		return p.parseEnumDeclaration()
+	}
+
+	if (p.curTok.Value == INTERFACE || p.curTok.Value == TRAIT) && p.curTok.Type == TOKEN_IDENT && p.peekTok.Type == TOKEN_IDENT {
+		return p.parseInterfaceDeclaration()
+	}
+
+	if p.curTok.Value == EXPORT && p.curTok.Type == TOKEN_IDENT && p.peekTok.Type == TOKEN_IDENT {
+		return p.parseExportStatement()
+	}
+
 	if p.curTok.Value == DSL {
 		return p.parseDSLDefinition()
 	}
@@ -160,15 +298,11 @@ func (p *Parser) parseStatement() Node {
 func (p *Parser) parseTryStatement() Node {
 	p.nextToken() // consumir "try"
 	body := p.parseBlockStatement()
-	exceptionVar := "$e"
-	var catchBlock *BlockStatement
-	if p.curTok.Value == CATCH {
+	var catches []*CatchClause
+	for p.curTok.Value == CATCH {
 		p.nextToken() // consumir "catch"
-
-		if p.curTok.Value == "{" {
-			catchBlock = p.parseBlockStatement()
-		} else {
-
+		clause := &CatchClause{Var: "$e"}
+		if p.curTok.Value != "{" {
 			if p.curTok.Value != "(" {
 				p.except("‘(’ was expected after ‘catch’")
 			}
@@ -176,14 +310,16 @@ func (p *Parser) parseTryStatement() Node {
 			if p.curTok.Type != TOKEN_IDENT {
 				p.except("Variable name expected after ‘catch’")
 			}
-			exceptionVar = p.curTok.Value
+			clause.Var = p.curTok.Value
 			p.nextToken()
+			clause.Type = p.parseOptionalType()
 			if p.curTok.Value != ")" {
 				p.except("‘)’ was expected after the exception variable")
 			}
 			p.nextToken() // consumir ")"
-			catchBlock = p.parseBlockStatement()
 		}
+		clause.Body = p.parseBlockStatement()
+		catches = append(catches, clause)
 	}
 
 	var finallyBlock *BlockStatement
@@ -192,7 +328,7 @@ func (p *Parser) parseTryStatement() Node {
 		finallyBlock = p.parseBlockStatement()
 	}
 
-	return &TryStatement{Body: body, CatchBlock: catchBlock, ExceptionVar: exceptionVar, FinallyBlock: finallyBlock}
+	return &TryStatement{Body: body, Catches: catches, FinallyBlock: finallyBlock}
 }
 
 // parseAssignmentOrExpressionStatement
@@ -241,16 +377,17 @@ func (p *Parser) parseAssignmentOrExpressionStatement() Node {
 }
 
 func (p *Parser) parseReturnStatement() Node {
+	base := BaseNode{Position: CreatePositionInfo(p.curTok, p.filename)}
 	p.nextToken() // consumir "return"
 	if p.curTok.Value == ";" {
 		p.nextToken()
-		return &ReturnStatement{Value: nil}
+		return &ReturnStatement{BaseNode: base, Value: nil}
 	}
 	expr := p.parseExpression()
 	if p.curTok.Value == ";" {
 		p.nextToken()
 	}
-	return &ReturnStatement{Value: expr}
+	return &ReturnStatement{BaseNode: base, Value: expr}
 }
 
 func (p *Parser) parseBreakStatement() Node {
@@ -288,13 +425,14 @@ func (p *Parser) parseLetStatement() Node {
 		return &LetStatement{Name: name, Value: nil}
 	}
 
+	typ := p.parseOptionalType()
 	var value Node
 	if p.curTok.Value == "=" {
 		p.nextToken()
 		value = p.parseExpression()
 	}
 
-	declarations = append(declarations, LetDeclaration{Name: name, Value: value})
+	declarations = append(declarations, LetDeclaration{Name: name, Type: typ, Value: value})
 
 	// Parsear declaraciones adicionales separadas por comas
 	for p.curTok.Value == "," {
@@ -307,13 +445,14 @@ func (p *Parser) parseLetStatement() Node {
 		name = p.curTok.Value
 		p.nextToken()
 
+		typ := p.parseOptionalType()
 		var value Node
 		if p.curTok.Value == "=" {
 			p.nextToken()
 			value = p.parseExpression()
 		}
 
-		declarations = append(declarations, LetDeclaration{Name: name, Value: value})
+		declarations = append(declarations, LetDeclaration{Name: name, Type: typ, Value: value})
 	}
 
 	// Consumir punto y coma opcional
@@ -323,7 +462,7 @@ func (p *Parser) parseLetStatement() Node {
 
 	// Si solo hay una declaración, usar LetStatement simple para mantener compatibilidad
 	if len(declarations) == 1 {
-		return &LetStatement{Name: declarations[0].Name, Value: declarations[0].Value}
+		return &LetStatement{Name: declarations[0].Name, Type: declarations[0].Type, Value: declarations[0].Value}
 	}
 
 	// Si hay múltiples declaraciones, usar MultipleLetStatement
@@ -342,6 +481,7 @@ func (p *Parser) parseConstStatement() Node {
 
 	name := p.curTok.Value
 	p.nextToken()
+	typ := p.parseOptionalType()
 
 	// const requires initialization
 	if p.curTok.Value != "=" {
@@ -351,7 +491,7 @@ func (p *Parser) parseConstStatement() Node {
 	p.nextToken()
 	value := p.parseExpression()
 
-	declarations = append(declarations, ConstDeclaration{Name: name, Value: value})
+	declarations = append(declarations, ConstDeclaration{Name: name, Type: typ, Value: value})
 
 	// Parsear declaraciones adicionales separadas por comas
 	for p.curTok.Value == "," {
@@ -363,6 +503,7 @@ func (p *Parser) parseConstStatement() Node {
 
 		name = p.curTok.Value
 		p.nextToken()
+		typ := p.parseOptionalType()
 
 		// const requires initialization
 		if p.curTok.Value != "=" {
@@ -372,7 +513,7 @@ func (p *Parser) parseConstStatement() Node {
 		p.nextToken()
 		value = p.parseExpression()
 
-		declarations = append(declarations, ConstDeclaration{Name: name, Value: value})
+		declarations = append(declarations, ConstDeclaration{Name: name, Type: typ, Value: value})
 	}
 
 	// Consumir punto y coma opcional
@@ -382,7 +523,7 @@ func (p *Parser) parseConstStatement() Node {
 
 	// Si solo hay una declaración, usar ConstStatement simple para mantener compatibilidad
 	if len(declarations) == 1 {
-		return &ConstStatement{Name: declarations[0].Name, Value: declarations[0].Value}
+		return &ConstStatement{Name: declarations[0].Name, Type: declarations[0].Type, Value: declarations[0].Value}
 	}
 
 	// Si hay múltiples declaraciones, usar MultipleConstStatement
@@ -397,6 +538,11 @@ func (p *Parser) parseFunctionDeclaration() Node {
 }
 
 func (p *Parser) parseFunctionDeclaratioWithoutFunc(funcToken Token) Node {
+	// "func* nombre(...)" o, en una clase, "*nombre(...)": generador
+	generator := p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "*"
+	if generator {
+		p.nextToken() // consumir "*"
+	}
 	if p.curTok.Type != TOKEN_IDENT {
 		p.except("Function name expected after 'func'/'function'")
 	}
@@ -406,7 +552,8 @@ func (p *Parser) parseFunctionDeclaratioWithoutFunc(funcToken Token) Node {
 		p.except("'(' expected after function name")
 	}
 	params := p.parseFunctionParameters()
-	body := p.parseBlockStatement()
+	returnType := p.parseOptionalType()
+	body := p.parseFunctionBody(generator)
 
 	// Convert parameters to args for backward compatibility
 	var args []string
@@ -418,7 +565,17 @@ func (p *Parser) parseFunctionDeclaratioWithoutFunc(funcToken Token) Node {
 		BaseNode: BaseNode{
 			Position: CreatePositionInfo(funcToken, p.filename),
 		},
-		Name: funcName, Args: args, Params: params, Body: body}
+		Name: funcName, Args: args, Params: params, Body: body, Generator: generator, Return: returnType}
+}
+
+// parseFunctionBody parsea el cuerpo de una función. yield sólo es una
+// palabra clave dentro de un generador; en las funciones anidadas en él
+// vuelve a ser un identificador.
+func (p *Parser) parseFunctionBody(generator bool) *BlockStatement {
+	saved := p.generator
+	p.generator = generator
+	defer func() { p.generator = saved }()
+	return p.parseBlockStatement()
 }
 
 func (p *Parser) parseIfStatement() Node {
@@ -465,6 +622,122 @@ func (p *Parser) parseWhileStatement() Node {
 	return &WhileStatement{Condition: cond, Body: body}
 }
 
+// switch (expr) { case a, b: ... default: ... }
+func (p *Parser) parseSwitchStatement() Node {
+	p.nextToken() // "switch"
+	if p.curTok.Value != "(" {
+		p.except("‘(’ was expected after ‘switch’")
+	}
+	p.nextToken()
+	value := p.parseExpression()
+	if p.curTok.Value != ")" {
+		p.except("‘)’ was expected after the value in ‘switch’")
+	}
+	p.nextToken()
+	if p.curTok.Value != "{" {
+		p.except("‘{’ was expected after ‘switch (...)’")
+	}
+	p.nextToken()
+
+	ss := &SwitchStatement{Value: value}
+	spans := p.openList()
+	hasDefault := false
+	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
+		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
+			p.nextToken()
+			continue
+		}
+		start := p.curTok.Start
+		var sc SwitchCase
+		switch {
+		case p.curTok.Type == TOKEN_CASE:
+			p.nextToken() // "case"
+			sc.Patterns = append(sc.Patterns, p.parseSwitchLabel())
+			for p.curTok.Value == "," {
+				p.nextToken()
+				sc.Patterns = append(sc.Patterns, p.parseSwitchLabel())
+			}
+		case p.isSwitchDefault():
+			if hasDefault {
+				p.except("A ‘switch’ can only have one ‘default’")
+			}
+			hasDefault = true
+			sc.IsDefault = true
+			p.nextToken() // "default"
+		default:
+			p.except("‘case’ or ‘default’ was expected in ‘switch’")
+		}
+		if p.curTok.Value != ":" {
+			p.except("‘:’ was expected after the ‘case’ label")
+		}
+		colon := p.curTok
+		p.nextToken()
+		sc.Body = p.parseSwitchCaseBody()
+		ss.Cases = append(ss.Cases, sc)
+		spans.add(p, start)
+		spans.endAtBody(p, sc.Body, p.tokenEnd(colon))
+	}
+	if p.curTok.Value != "}" {
+		p.except("‘}’ was expected to end ‘switch’")
+	}
+	p.closeList(ss, spans)
+	p.nextToken()
+	return ss
+}
+
+// parseSwitchLabel lee una etiqueta de case: un patrón de match para
+// arrays y objetos, o cualquier expresión, seguida de un guard opcional.
+// Un identificador suelto seguido de if liga el valor como en match
+// ("case v if v > 100:"); sin guard sigue siendo una expresión a comparar.
+func (p *Parser) parseSwitchLabel() Pattern {
+	var pattern Pattern
+	if p.curTok.Value == "[" || p.curTok.Value == "{" ||
+		p.curTok.Type == TOKEN_IDENT && p.peekTok.Type == TOKEN_IDENT && p.peekTok.Value == IF {
+		pattern = p.parsePattern()
+	} else {
+		pattern = &ValuePattern{Value: p.parseExpression()}
+	}
+	if p.curTok.Type == TOKEN_IDENT && p.curTok.Value == IF {
+		p.nextToken() // "if"
+		pattern = &GuardedPattern{Pattern: pattern, Guard: p.parseExpression()}
+	}
+	return pattern
+}
+
+func (p *Parser) isSwitchDefault() bool {
+	return p.curTok.Type == TOKEN_IDENT && p.curTok.Value == DEFAULT && p.peekTok.Value == ":"
+}
+
+// parseSwitchCaseBody lee las sentencias de un caso hasta el siguiente
+// case, default o el cierre del switch.
+func (p *Parser) parseSwitchCaseBody() *BlockStatement {
+	var stmts []Node
+	var positions []*PositionInfo
+	spans := p.openList()
+	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF && p.curTok.Type != TOKEN_CASE && !p.isSwitchDefault() {
+		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
+			p.nextToken()
+			continue
+		}
+		start := p.curTok.Start
+		pos := CreatePositionInfo(p.curTok, p.filename)
+		if p.collect {
+			if stmt := p.parseStatementRecovering(false); stmt != nil {
+				stmts = append(stmts, stmt)
+				positions = append(positions, pos)
+				spans.add(p, start)
+			}
+			continue
+		}
+		stmts = append(stmts, p.parseStatement())
+		positions = append(positions, pos)
+		spans.add(p, start)
+	}
+	block := &BlockStatement{Statements: stmts, Positions: positions}
+	p.closeList(block, spans)
+	return block
+}
+
 func (p *Parser) parseForStatement() Node {
 	p.nextToken() // "for"
 	if p.curTok.Value != "(" {
@@ -486,8 +759,15 @@ func (p *Parser) parseForInStatement() Node {
 	p.nextToken() // consume index name
 	p.nextToken() // consume 'in'
 
-	collName := p.curTok.Value
-	p.nextToken() // consume collection name
+	// "for (x in nombre)" o "for (x in expr)", por ejemplo un generador
+	var collName string
+	var collExpr Node
+	if p.curTok.Type == TOKEN_IDENT && p.peekTok.Value == ")" {
+		collName = p.curTok.Value
+		p.nextToken() // consume collection name
+	} else {
+		collExpr = p.parseExpression()
+	}
 
 	// Skip to ')'
 	if p.curTok.Value != ")" {
@@ -499,7 +779,7 @@ func (p *Parser) parseForInStatement() Node {
 	body := p.parseBlockStatement()
 	// Create a dummy init that sets the index variable
 	init := &LetStatement{Name: indexName, Value: &NumberLiteral{Value: 0}}
-	return &ForStatement{Init: init, Body: body, inFlag: true, inArray: collName, inIndexName: indexName}
+	return &ForStatement{Init: init, Body: body, inFlag: true, inArray: collName, inExpr: collExpr, inIndexName: indexName}
 }
 
 func (p *Parser) parseStandardForStatement() Node {
@@ -537,6 +817,7 @@ func (p *Parser) parseStandardForStatement() Node {
 }
 
 func (p *Parser) parseObjectDeclaration() Node {
+	pos := CreatePositionInfo(p.curTok, p.filename)
 	p.nextToken() // "obj"
 	if p.curTok.Type != TOKEN_IDENT {
 		p.except("Object name was expected after '" + OBJECT + "'")
@@ -545,6 +826,7 @@ func (p *Parser) parseObjectDeclaration() Node {
 	p.nextToken()
 
 	parentName := p.parseOptionalExtends()
+	interfaces := p.parseOptionalImplements()
 
 	if p.curTok.Value != "{" {
 		p.except("Expected ‘{’ after object name")
@@ -552,27 +834,158 @@ func (p *Parser) parseObjectDeclaration() Node {
 	p.nextToken()
 
 	var members []Node
+	spans := p.openList()
 	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
 		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
 			p.nextToken()
 			continue
 		}
+		start := p.curTok.Start
+		// "static let x = 1", "static nombre() {...}"; igual que con async y
+		// get/set, un método llamado static se declara "static() {...}"
+		static := p.curTok.Value == STATIC && (p.peekTok.Type == TOKEN_IDENT || p.peekTok.Value == "*")
+		if static {
+			p.nextToken() // consumir "static"
+		}
+		if (p.curTok.Value == GET || p.curTok.Value == SET) && p.peekTok.Type == TOKEN_IDENT {
+			if static {
+				p.except("static accessors are not supported")
+			}
+			members = append(members, p.parseAccessorDeclaration())
+			spans.add(p, start)
+			continue
+		}
+		// "async nombre() {...}" o "async func nombre() {...}"; un método
+		// llamado async se declara "async() {...}"
+		async := p.curTok.Value == ASYNC && p.peekTok.Type == TOKEN_IDENT
+		if async {
+			p.nextToken() // consumir "async"
+			if p.curTok.Value == LET || p.curTok.Value == VAR {
+				p.except("Only methods can be async")
+			}
+			if p.curTok.Value == "*" {
+				p.except("async generators are not supported")
+			}
+		}
 		if p.curTok.Value == LET || p.curTok.Value == VAR {
 			members = append(members, p.parseLetStatement())
 		} else if p.curTok.Value == FUNC || p.curTok.Value == FUNCTION || p.curTok.Value == METHOD {
 			members = append(members, p.parseFunctionDeclaration())
-		} else if p.curTok.Type == TOKEN_IDENT {
+		} else if p.curTok.Type == TOKEN_IDENT || (p.curTok.Value == "*" && p.peekTok.Type == TOKEN_IDENT) {
 			methodToken := p.curTok
 			members = append(members, p.parseFunctionDeclaratioWithoutFunc(methodToken))
 		} else {
 			p.except("Inside " + OBJECT + " only 'let', 'var', 'func', 'function' or 'method' are allowed")
 		}
+		if async {
+			fd := members[len(members)-1].(*FunctionDeclaration)
+			if fd.Generator {
+				p.except("async generators are not supported")
+			}
+			fd.Async = true
+		}
+		if static {
+			members[len(members)-1] = &StaticMember{Member: members[len(members)-1]}
+		}
+		spans.add(p, start)
 	}
 	if p.curTok.Value != "}" {
 		p.except("Expected ‘}’ at the end of " + OBJECT)
 	}
+	od := &ObjectDeclaration{BaseNode: BaseNode{Position: pos}, Name: objName, Members: members, ParentName: parentName, Interfaces: interfaces}
+	p.closeList(od, spans)
+	p.nextToken()
+	return od
+}
+
+// parseOptionalImplements lee "implements A, B" después del nombre de una
+// clase (y de su extends).
+func (p *Parser) parseOptionalImplements() []string {
+	if p.curTok.Value != IMPLEMENTS {
+		return nil
+	}
+	var names []string
+	for {
+		p.nextToken() // consumir "implements" o ","
+		if p.curTok.Type != TOKEN_IDENT {
+			p.except("Expected interface name after ‘implements’")
+		}
+		names = append(names, p.curTok.Value)
+		p.nextToken()
+		if p.curTok.Value != "," {
+			return names
+		}
+	}
+}
+
+// parseInterfaceDeclaration parsea "interface Nombre { ... }" o "trait
+// Nombre { ... }". Cada miembro es un método, con o sin func delante: sin
+// cuerpo es obligatorio y con cuerpo es una implementación por defecto.
+func (p *Parser) parseInterfaceDeclaration() Node {
+	id := &InterfaceDeclaration{Keyword: p.curTok.Value}
+	p.nextToken() // "interface" o "trait"
+	id.Name = p.curTok.Value
+	p.nextToken()
+	if p.curTok.Value != "{" {
+		p.except("Expected ‘{’ after " + id.Keyword + " name")
+	}
+	p.nextToken()
+
+	seen := map[string]bool{}
+	spans := p.openList()
+	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
+		if p.curTok.Type == TOKEN_SYMBOL && (p.curTok.Value == "\n" || p.curTok.Value == ";") {
+			p.nextToken()
+			continue
+		}
+		start := p.curTok.Start
+		methodToken := p.curTok
+		if p.curTok.Value == FUNC || p.curTok.Value == FUNCTION || p.curTok.Value == METHOD {
+			p.nextToken()
+		}
+		if p.curTok.Type != TOKEN_IDENT || p.peekTok.Value != "(" {
+			p.except("Only methods are allowed inside " + id.Keyword + " ‘" + id.Name + "’")
+		}
+		fd := &FunctionDeclaration{BaseNode: BaseNode{Position: CreatePositionInfo(methodToken, p.filename)}, Name: p.curTok.Value}
+		if seen[fd.Name] {
+			p.except("Duplicate method ‘" + fd.Name + "’ in " + id.Keyword + " ‘" + id.Name + "’")
+		}
+		seen[fd.Name] = true
+		p.nextToken()
+		fd.Params = p.parseFunctionParameters()
+		for _, param := range fd.Params {
+			fd.Args = append(fd.Args, param.Name)
+		}
+		fd.Return = p.parseOptionalType()
+		if p.curTok.Value == "{" {
+			fd.Body = p.parseFunctionBody(false)
+		} else if p.curTok.Value != "\n" && p.curTok.Value != ";" && p.curTok.Value != "}" {
+			p.except("Expected a new line or a body after method ‘" + fd.Name + "’")
+		}
+		id.Methods = append(id.Methods, fd)
+		spans.add(p, start)
+	}
+	if p.curTok.Value != "}" {
+		p.except("Expected ‘}’ at the end of " + id.Keyword + " ‘" + id.Name + "’")
+	}
+	p.closeList(id, spans)
 	p.nextToken()
-	return &ObjectDeclaration{Name: objName, Members: members, ParentName: parentName}
+	return id
+}
+
+// parseAccessorDeclaration parsea "get nombre() {...}" o "set nombre(v) {...}"
+// dentro de una clase.
+func (p *Parser) parseAccessorDeclaration() Node {
+	kind := p.curTok.Value
+	p.nextToken() // consumir "get" o "set"
+	fd := p.parseFunctionDeclaratioWithoutFunc(p.curTok).(*FunctionDeclaration)
+	if kind == GET && len(fd.Params) != 0 {
+		p.except("A getter takes no parameters: " + fd.Name)
+	}
+	if kind == SET && len(fd.Params) != 1 {
+		p.except("A setter takes exactly one parameter: " + fd.Name)
+	}
+	return &AccessorDeclaration{Kind: kind, Func: fd}
 }
 
 func (p *Parser) parseOptionalExtends() string {
@@ -622,6 +1035,16 @@ func (p *Parser) parseFunctionParameters() []Parameter {
 			paramName := p.curTok.Value
 			p.nextToken()
 
+			// name?: tipo
+			optional := p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "?"
+			if optional {
+				p.nextToken()
+				if p.curTok.Value != ":" {
+					p.except("Expected ':' after '?' in parameter " + paramName)
+				}
+			}
+			paramType := p.parseOptionalType()
+
 			var defaultValue Node
 			if p.curTok.Value == "=" {
 				p.nextToken() // consumir "="
@@ -631,6 +1054,8 @@ func (p *Parser) parseFunctionParameters() []Parameter {
 			params = append(params, Parameter{
 				Name:         paramName,
 				DefaultValue: defaultValue,
+				Type:         paramType,
+				Optional:     optional,
 			})
 
 			if p.curTok.Value == "," {
@@ -661,28 +1086,78 @@ func (p *Parser) parseFunctionParameters() []Parameter {
 	return params
 }
 
+// parseOptionalType parsea la anotación ": tipo" si la hay.
+func (p *Parser) parseOptionalType() *TypeAnnotation {
+	if p.curTok.Type != TOKEN_SYMBOL || p.curTok.Value != ":" {
+		return nil
+	}
+	p.nextToken() // consumir ":"
+	return p.parseType()
+}
+
+// parseType => nombre ("[]")* ("|" nombre ("[]")*)*
+func (p *Parser) parseType() *TypeAnnotation {
+	typ := &TypeAnnotation{Position: CreatePositionInfo(p.curTok, p.filename)}
+	for {
+		if p.curTok.Type != TOKEN_IDENT && p.curTok.Type != TOKEN_NIL {
+			p.except("Type name expected, got: " + p.curTok.Value)
+		}
+		name := p.curTok.Value
+		p.nextToken()
+		for p.curTok.Value == "[" && p.peekTok.Value == "]" {
+			p.nextToken()
+			p.nextToken()
+			name += "[]"
+		}
+		typ.Types = append(typ.Types, name)
+		if p.curTok.Type != TOKEN_SYMBOL || p.curTok.Value != "|" {
+			return typ
+		}
+		p.nextToken() // consumir "|"
+	}
+}
+
 func (p *Parser) parseBlockStatement() *BlockStatement {
 	if p.curTok.Value != "{" {
 		p.except("Expected ‘{’ to start block")
 	}
 	p.nextToken()
 	var stmts []Node
+	var positions []*PositionInfo
+	spans := p.openList()
 	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
 		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
 			p.nextToken()
 			continue
 		}
+		start := p.curTok.Start
+		pos := CreatePositionInfo(p.curTok, p.filename)
+		if p.collect {
+			if stmt := p.parseStatementRecovering(false); stmt != nil {
+				stmts = append(stmts, stmt)
+				positions = append(positions, pos)
+				spans.add(p, start)
+			}
+			continue
+		}
 		stmts = append(stmts, p.parseStatement())
+		positions = append(positions, pos)
+		spans.add(p, start)
 	}
 	if p.curTok.Value != "}" {
 		p.except("Expected ‘}’ to end block")
 	}
+	block := &BlockStatement{Statements: stmts, Positions: positions}
+	p.closeList(block, spans)
 	p.nextToken()
-	return &BlockStatement{Statements: stmts}
+	return block
 }
 
 // parseExpression => parsea ternarios y binarios
 func (p *Parser) parseExpression() Node {
+	if p.generator && p.curTok.Type == TOKEN_IDENT && p.curTok.Value == YIELD {
+		return p.parseYieldExpression()
+	}
 	left := p.parseBinaryExpression(1)
 
 	// Operador ternario tiene la precedencia más baja (pero solo si no es ??)
@@ -700,6 +1175,36 @@ func (p *Parser) parseExpression() Node {
 	return left
 }
 
+// parseYieldExpression => "yield", "yield expr" o "yield* expr". Como en JS,
+// yield tiene la precedencia más baja: "yield a + b" entrega a + b.
+func (p *Parser) parseYieldExpression() Node {
+	p.nextToken() // consumir "yield"
+	ye := &YieldExpression{}
+	if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "*" {
+		p.nextToken() // consumir "*"
+		ye.Delegate = true
+	}
+	if ye.Delegate || !endsYield(p.curTok) {
+		ye.Value = p.parseExpression()
+	}
+	return ye
+}
+
+// endsYield indica si tok termina un yield sin valor
+func endsYield(tok Token) bool {
+	if tok.Type == TOKEN_EOF {
+		return true
+	}
+	if tok.Type != TOKEN_SYMBOL {
+		return false
+	}
+	switch tok.Value {
+	case ")", "]", "}", ";", ",", ":", "\n":
+		return true
+	}
+	return false
+}
+
 // parseBinaryExpression => parsea operadores binarios
 func (p *Parser) parseBinaryExpression(precedence int) Node {
 	left := p.parseUnaryExpression()
@@ -715,6 +1220,7 @@ func (p *Parser) parseBinaryExpression(precedence int) Node {
 			savedPos := p.lexer.pos
 			savedCol := p.lexer.col
 			savedLine := p.lexer.line
+			savedPrevTok := p.prevTok
 			savedCurTok := p.curTok
 			savedPeekTok := p.peekTok
 
@@ -722,11 +1228,12 @@ func (p *Parser) parseBinaryExpression(precedence int) Node {
 				p.nextToken()
 			}
 
-			isOperator := (p.curTok.Type == TOKEN_SYMBOL || p.curTok.Type == TOKEN_NULL_COALESCING || p.curTok.Type == TOKEN_PIPE) && isBinaryOp(p.curTok.Value) && getPrecedence(p.curTok.Value) >= precedence
+			isOperator := isBinaryOpToken(p.curTok) && getPrecedence(p.curTok.Value) >= precedence
 			if !isOperator {
 				p.lexer.pos = savedPos
 				p.lexer.col = savedCol
 				p.lexer.line = savedLine
+				p.prevTok = savedPrevTok
 				p.curTok = savedCurTok
 				p.peekTok = savedPeekTok
 				break
@@ -734,7 +1241,7 @@ func (p *Parser) parseBinaryExpression(precedence int) Node {
 		}
 
 		// Check if current token is a binary operator
-		if !((p.curTok.Type == TOKEN_SYMBOL || p.curTok.Type == TOKEN_NULL_COALESCING || p.curTok.Type == TOKEN_PIPE) && isBinaryOp(p.curTok.Value) && getPrecedence(p.curTok.Value) >= precedence) {
+		if !(isBinaryOpToken(p.curTok) && getPrecedence(p.curTok.Value) >= precedence) {
 			break
 		}
 
@@ -758,6 +1265,18 @@ func (p *Parser) parseBinaryExpression(precedence int) Node {
 	return left
 }
 
+// isBinaryOpToken indica si tok es un operador binario; instanceof es el
+// único que se escribe como un identificador
+func isBinaryOpToken(tok Token) bool {
+	switch tok.Type {
+	case TOKEN_SYMBOL, TOKEN_NULL_COALESCING, TOKEN_PIPE:
+		return isBinaryOp(tok.Value)
+	case TOKEN_IDENT:
+		return tok.Value == INSTANCEOF
+	}
+	return false
+}
+
 // parseUnaryExpression => parsea operadores unarios como !, -, +
 func (p *Parser) parseUnaryExpression() Node {
 	// Operador spread ...
@@ -776,9 +1295,27 @@ func (p *Parser) parseUnaryExpression() Node {
 			return &UnaryExpression{Operator: operator, Right: right}
 		}
 	}
+
+	// await expr; "await" seguido de otra cosa sigue siendo un identificador
+	if p.curTok.Type == TOKEN_IDENT && p.curTok.Value == AWAIT && startsOperand(p.peekTok) {
+		p.nextToken() // consumir "await"
+		return &AwaitExpression{Value: p.parseUnaryExpression()}
+	}
 	return p.parseFactor()
 }
 
+// startsOperand indica si tok puede empezar el operando de un operador prefijo
+func startsOperand(tok Token) bool {
+	switch tok.Type {
+	case TOKEN_IDENT, TOKEN_NUMBER, TOKEN_STRING, TOKEN_TEMPLATE_STRING, TOKEN_DATE,
+		TOKEN_TRUE, TOKEN_FALSE, TOKEN_NIL, TOKEN_MATCH:
+		return true
+	case TOKEN_SYMBOL:
+		return tok.Value == "(" || tok.Value == "[" || tok.Value == "!"
+	}
+	return false
+}
+
 func getPrecedence(op string) int {
 	switch op {
 	case "|>": // Pipeline operator (P4) - very low precedence
@@ -795,7 +1332,7 @@ func getPrecedence(op string) int {
 		return 6
 	case "&":
 		return 7
-	case "==", "!=", "<", ">", "<=", ">=":
+	case "==", "!=", "<", ">", "<=", ">=", INSTANCEOF:
 		return 8
 	case "<<", ">>":
 		return 9
@@ -815,6 +1352,22 @@ func (p *Parser) parseFactor() Node {
 		return p.parseAnonymousFunction()
 	}
 
+	// async func(...) {...}, async (a, b) => ..., async x => ...
+	if p.curTok.Type == TOKEN_IDENT && p.curTok.Value == ASYNC && p.isAsyncFunction() {
+		p.nextToken() // consumir "async"
+		fn := p.parseFactor()
+		switch f := fn.(type) {
+		case *FunctionLiteral:
+			if f.Generator {
+				p.except("async generators are not supported")
+			}
+			f.Async = true
+		case *ArrowFunction:
+			f.Async = true
+		}
+		return fn
+	}
+
 	// Match expression (P3)
 	if p.curTok.Type == TOKEN_MATCH {
 		return p.parseMatchExpression()
@@ -823,21 +1376,31 @@ func (p *Parser) parseFactor() Node {
 	switch p.curTok.Type {
 
 	case TOKEN_NUMBER:
-		val, err := strconv.ParseFloat(p.curTok.Value, 64)
+		val, err := ParseNumber(p.curTok.Value)
 		if err != nil {
 			p.except("Could not parse number: " + p.curTok.Value)
 		}
-		node := &NumberLiteral{Value: val}
+		var node Node
+		if f, ok := val.(float64); ok {
+			node = &NumberLiteral{Value: f}
+		} else {
+			node = &ExactNumberLiteral{Raw: p.curTok.Value, Value: val}
+		}
+		p.recordRaw(node, p.curTok)
 		p.nextToken()
 		return node
 
 	case TOKEN_STRING:
 		node := &StringLiteral{Value: p.curTok.Value}
+		p.recordRaw(node, p.curTok)
 		p.nextToken()
 		return node
 
 	case TOKEN_TEMPLATE_STRING:
-		return p.parseTemplateString()
+		tok := p.curTok
+		node := p.parseTemplateString()
+		p.recordRaw(node, tok)
+		return node
 
 	case TOKEN_DATE:
 		dateValue, err := ParseDateLiteral(p.curTok.Value)
@@ -845,6 +1408,7 @@ func (p *Parser) parseFactor() Node {
 			p.except("Invalid date literal: " + p.curTok.Value + " - " + err.Error())
 		}
 		node := &DateLiteral{Value: dateValue}
+		p.recordRaw(node, p.curTok)
 		p.nextToken()
 		return node
 
@@ -911,11 +1475,16 @@ func (p *Parser) parseFactor() Node {
 func (p *Parser) parseAnonymousFunction() Node {
 	// ya vimos p.curTok == "func" (type=ident)
 	p.nextToken() // consumir "func"
+	generator := p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "*"
+	if generator {
+		p.nextToken() // consumir "*": func*(...) {...}
+	}
 	if p.curTok.Value != "(" {
 		p.except("Expected '(' after 'func' in the anonymous function")
 	}
 	params := p.parseFunctionParameters()
-	body := p.parseBlockStatement()
+	returnType := p.parseOptionalType()
+	body := p.parseFunctionBody(generator)
 
 	// Convert parameters to args for backward compatibility
 	var args []string
@@ -923,7 +1492,7 @@ func (p *Parser) parseAnonymousFunction() Node {
 		args = append(args, param.Name)
 	}
 
-	return &FunctionLiteral{Args: args, Params: params, Body: body}
+	return &FunctionLiteral{Args: args, Params: params, Body: body, Generator: generator, Return: returnType}
 }
 
 func (p *Parser) parsePostfix(left Node) Node {
@@ -944,6 +1513,7 @@ func (p *Parser) parsePostfix(left Node) Node {
 }
 
 func (p *Parser) parseCallExpression(left Node) Node {
+	pos := CreatePositionInfo(p.curTok, p.filename)
 	p.nextToken() // consumir "("
 
 	// Skip newlines after opening paren, so calls can wrap their argument
@@ -967,7 +1537,7 @@ func (p *Parser) parseCallExpression(left Node) Node {
 		p.except("Expected ‘)’ at the end of function call")
 	}
 	p.nextToken() // ")"
-	return &CallExpression{Callee: left, Args: args}
+	return &CallExpression{BaseNode: BaseNode{Position: pos}, Callee: left, Args: args}
 }
 
 // memberName returns the token's value as a member name if it can follow
@@ -997,13 +1567,14 @@ func memberName(tok Token) (string, bool) {
 }
 
 func (p *Parser) parseAccessExpression(left Node) Node {
+	pos := CreatePositionInfo(p.curTok, p.filename)
 	p.nextToken() // "."
 	mem, ok := memberName(p.curTok)
 	if !ok {
 		p.except("Expected identifier after '.'")
 	}
 	p.nextToken()
-	node := &AccessExpression{Object: left, Member: mem}
+	node := &AccessExpression{BaseNode: BaseNode{Position: pos}, Object: left, Member: mem}
 	return p.parsePostfix(node)
 }
 
@@ -1062,9 +1633,12 @@ func (p *Parser) parseArrayLiteral() Node {
 		return &ArrayLiteral{Elements: elems}
 	}
 
+	spans := p.openList()
 	for p.curTok.Value != "]" && p.curTok.Type != TOKEN_EOF {
+		start := p.curTok.Start
 		e := p.parseExpression()
 		elems = append(elems, e)
+		spans.add(p, start)
 
 		if p.curTok.Value == "," {
 			p.nextToken()
@@ -1086,8 +1660,10 @@ func (p *Parser) parseArrayLiteral() Node {
 	if p.curTok.Value != "]" {
 		p.except("Expected ']' at the end of array literal")
 	}
+	arr := &ArrayLiteral{Elements: elems}
+	p.closeList(arr, spans)
 	p.nextToken()
-	return &ArrayLiteral{Elements: elems}
+	return arr
 }
 
 func (p *Parser) parseMapLiteral() Node {
@@ -1108,7 +1684,9 @@ func (p *Parser) parseMapLiteral() Node {
 		p.nextToken()
 		return &MapLiteral{Pairs: pairs}
 	}
+	spans := p.openList()
 	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
+		start := p.curTok.Start
 		// Verificar si es spread operator
 		if p.curTok.Type == TOKEN_ELLIPSIS {
 			p.nextToken() // consumir "..."
@@ -1125,14 +1703,17 @@ func (p *Parser) parseMapLiteral() Node {
 			switch p.curTok.Type {
 			case TOKEN_STRING:
 				keyNode = &StringLiteral{Value: p.curTok.Value}
+				p.recordRaw(keyNode, p.curTok)
 				p.nextToken()
 			case TOKEN_IDENT:
 				// En JavaScript: {foo: "bar"} equivale a {"foo": "bar"}
 				keyNode = &StringLiteral{Value: p.curTok.Value}
+				p.recordRaw(keyNode, p.curTok)
 				p.nextToken()
 			case TOKEN_NUMBER:
 				// En JavaScript: {123: "bar"} es válido
 				keyNode = &StringLiteral{Value: p.curTok.Value}
+				p.recordRaw(keyNode, p.curTok)
 				p.nextToken()
 			case TOKEN_SYMBOL:
 				if p.curTok.Value == "(" {
@@ -1168,6 +1749,7 @@ func (p *Parser) parseMapLiteral() Node {
 
 			pairs = append(pairs, MapPair{Key: keyNode, Value: valNode})
 		}
+		spans.add(p, start)
 
 		if p.curTok.Value == "," {
 			p.nextToken()
@@ -1189,8 +1771,10 @@ func (p *Parser) parseMapLiteral() Node {
 	if p.curTok.Value != "}" {
 		p.except("Expected '}' at the end of map-literal")
 	}
+	ml := &MapLiteral{Pairs: pairs}
+	p.closeList(ml, spans)
 	p.nextToken()
-	return &MapLiteral{Pairs: pairs}
+	return ml
 }
 
 // parseTemplateString parses a template string token into a TemplateString AST node
@@ -1226,6 +1810,34 @@ func (p *Parser) parseDSLDefinition() Node {
 	}
 }
 
+// isAsyncFunction indica si el "async" actual precede a una función anónima o
+// a una arrow function, y no es un identificador más (por ejemplo async(x)).
+func (p *Parser) isAsyncFunction() bool {
+	if p.peekTok.Type == TOKEN_IDENT && (p.peekTok.Value == FUNC || p.peekTok.Value == FUNCTION) {
+		return true
+	}
+	if p.peekTok.Type != TOKEN_IDENT && p.peekTok.Value != "(" {
+		return false
+	}
+	savedPos := p.lexer.pos
+	savedCol := p.lexer.col
+	savedLine := p.lexer.line
+	savedPrevTok := p.prevTok
+	savedCurTok := p.curTok
+	savedPeekTok := p.peekTok
+
+	p.nextToken() // consumir "async"
+	isFunc := (p.curTok.Type == TOKEN_IDENT && p.peekTok.Type == TOKEN_ARROW) || p.isArrowFunctionParameters()
+
+	p.lexer.pos = savedPos
+	p.lexer.col = savedCol
+	p.lexer.line = savedLine
+	p.prevTok = savedPrevTok
+	p.curTok = savedCurTok
+	p.peekTok = savedPeekTok
+	return isFunc
+}
+
 // isArrowFunctionParameters checks if current position looks like arrow function parameters
 func (p *Parser) isArrowFunctionParameters() bool {
 	if p.curTok.Value != "(" {
@@ -1240,6 +1852,7 @@ func (p *Parser) isArrowFunctionParameters() bool {
 		savedPos := p.lexer.pos
 		savedCol := p.lexer.col
 		savedLine := p.lexer.line
+		savedPrevTok := p.prevTok
 		savedCurTok := p.curTok
 		savedPeekTok := p.peekTok
 
@@ -1254,6 +1867,7 @@ func (p *Parser) isArrowFunctionParameters() bool {
 		p.lexer.pos = savedPos
 		p.lexer.col = savedCol
 		p.lexer.line = savedLine
+		p.prevTok = savedPrevTok
 		p.curTok = savedCurTok
 		p.peekTok = savedPeekTok
 
@@ -1313,9 +1927,12 @@ func (p *Parser) parseArrowFunction() Node {
 	}
 	p.nextToken() // consume "=>"
 
-	// Parse body
+	// Parse body; las arrow functions nunca son generadores
 	var body Node
 	var isExpression bool
+	saved := p.generator
+	p.generator = false
+	defer func() { p.generator = saved }()
 
 	if p.curTok.Value == "{" {
 		// Block body: => { statements }
@@ -1405,6 +2022,9 @@ func (p *Parser) parseObjectDestructuring() Node {
 }
 
 func (p *Parser) except(msgErr string) {
+	if p.collect {
+		panic(p.newParseError(p.curTok, msgErr))
+	}
 
 	msg := fmt.Sprintln("Parser Exception: Line:", p.curTok.Line, ":", p.curTok.Col, "Error:", msgErr)
 	// Always panic instead of os.Exit(1): os.Exit bypasses deferred recover()
@@ -1418,6 +2038,55 @@ func (p *Parser) except(msgErr string) {
 
 }
 
+// enum Nombre { A, B = expr }: los miembros se separan con comas o saltos de
+// línea.
+func (p *Parser) parseEnumDeclaration() Node {
+	p.nextToken() // "enum"
+	ed := &EnumDeclaration{Name: p.curTok.Value}
+	p.nextToken()
+	if p.curTok.Value != "{" {
+		p.except("Expected ‘{’ after enum name")
+	}
+	p.nextToken()
+
+	seen := map[string]bool{}
+	spans := p.openList()
+	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
+		if p.curTok.Type == TOKEN_SYMBOL && p.curTok.Value == "\n" {
+			p.nextToken()
+			continue
+		}
+		if p.curTok.Type != TOKEN_IDENT {
+			p.except("Enum member name was expected in ‘" + ed.Name + "’")
+		}
+		start := p.curTok.Start
+		member := EnumMemberDecl{Name: p.curTok.Value}
+		if seen[member.Name] {
+			p.except("Duplicate enum member ‘" + member.Name + "’ in ‘" + ed.Name + "’")
+		}
+		seen[member.Name] = true
+		p.nextToken()
+		if p.curTok.Value == "=" {
+			p.nextToken()
+			member.Value = p.parseExpression()
+		}
+		ed.Members = append(ed.Members, member)
+		spans.add(p, start)
+
+		if p.curTok.Value == "," {
+			p.nextToken()
+		} else if p.curTok.Value != "\n" && p.curTok.Value != "}" {
+			p.except("Expected ‘,’ or a new line after enum member ‘" + member.Name + "’")
+		}
+	}
+	if p.curTok.Value != "}" {
+		p.except("Expected ‘}’ at the end of enum ‘" + ed.Name + "’")
+	}
+	p.closeList(ed, spans)
+	p.nextToken()
+	return ed
+}
+
 // parseMatchExpression parses match expressions (P3)
 func (p *Parser) parseMatchExpression() Node {
 	p.nextToken() // consume "match"
@@ -1430,6 +2099,7 @@ func (p *Parser) parseMatchExpression() Node {
 	p.nextToken() // consume "{"
 
 	var cases []MatchCase
+	spans := p.openList()
 
 	for p.curTok.Value != "}" && p.curTok.Type != TOKEN_EOF {
 		// Skip newlines
@@ -1437,6 +2107,7 @@ func (p *Parser) parseMatchExpression() Node {
 			p.nextToken()
 			continue
 		}
+		start := p.curTok.Start
 
 		if p.curTok.Type != TOKEN_CASE {
 			p.except("Expected 'case' in match expression")
@@ -1467,6 +2138,7 @@ func (p *Parser) parseMatchExpression() Node {
 			Guard:   guard,
 			Body:    body,
 		})
+		spans.add(p, start)
 
 		// Skip optional comma and newlines
 		if p.curTok.Value == "," {
@@ -1480,18 +2152,34 @@ func (p *Parser) parseMatchExpression() Node {
 	if p.curTok.Value != "}" {
 		p.except("Expected '}' at end of match expression")
 	}
-	p.nextToken() // consume "}"
-
-	return &MatchExpression{
+	me := &MatchExpression{
 		Value: value,
 		Cases: cases,
 	}
+	p.closeList(me, spans)
+	p.nextToken() // consume "}"
+
+	return me
 }
 
 // parsePattern parses different types of patterns
 func (p *Parser) parsePattern() Pattern {
 	switch p.curTok.Type {
 	case TOKEN_IDENT:
+		if p.peekTok.Value == "." {
+			// Qualified constant such as an enum member: Status.Active
+			var value Node = &Identifier{BaseNode: BaseNode{Position: CreatePositionInfo(p.curTok, p.filename)}, Name: p.curTok.Value}
+			p.nextToken()
+			for p.curTok.Value == "." {
+				p.nextToken() // consume "."
+				if p.curTok.Type != TOKEN_IDENT {
+					p.except("Expected member name after '.' in pattern")
+				}
+				value = &AccessExpression{Object: value, Member: p.curTok.Value}
+				p.nextToken()
+			}
+			return &LiteralPattern{Value: value}
+		}
 		if p.curTok.Value == "_" {
 			// Wildcard pattern
 			p.nextToken()
@@ -1594,6 +2282,7 @@ func (p *Parser) isArrayComprehension() bool {
 	savedPos := p.lexer.pos
 	savedLine := p.lexer.line
 	savedCol := p.lexer.col
+	savedPrevTok := p.prevTok
 	savedCurTok := p.curTok
 	savedPeekTok := p.peekTok
 
@@ -1603,6 +2292,7 @@ func (p *Parser) isArrayComprehension() bool {
 		p.lexer.pos = savedPos
 		p.lexer.line = savedLine
 		p.lexer.col = savedCol
+		p.prevTok = savedPrevTok
 		p.curTok = savedCurTok
 		p.peekTok = savedPeekTok
 	}()
@@ -1691,6 +2381,7 @@ func (p *Parser) isObjectComprehension() bool {
 	savedPos := p.lexer.pos
 	savedLine := p.lexer.line
 	savedCol := p.lexer.col
+	savedPrevTok := p.prevTok
 	savedCurTok := p.curTok
 	savedPeekTok := p.peekTok
 
@@ -1699,6 +2390,7 @@ func (p *Parser) isObjectComprehension() bool {
 		p.lexer.pos = savedPos
 		p.lexer.line = savedLine
 		p.lexer.col = savedCol
+		p.prevTok = savedPrevTok
 		p.curTok = savedCurTok
 		p.peekTok = savedPeekTok
 	}()
@@ -0,0 +1,166 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError es un error de sintaxis con su posición en el fuente.
+type ParseError struct {
+	Position *PositionInfo
+	Message  string
+}
+
+// Error devuelve el error con el formato file:line:col: message.
+func (e *ParseError) Error() string {
+	return CreatePositionError(e.Position, e.Message)
+}
+
+// ParseWithErrors parsea input sin abortar en el primer error: cada sentencia
+// que falla se registra y el parser se resincroniza en el siguiente salto de
+// línea o ';' para seguir reportando. Está pensado para herramientas (r2 -check)
+// que necesitan todos los errores de un archivo sin evaluarlo.
+func ParseWithErrors(input string, filename string) (*Program, []*ParseError) {
+	p := &Parser{
+		lexer:    NewLexer(input),
+		filename: filename,
+		collect:  true,
+	}
+	p.nextToken()
+	p.nextToken()
+	prog := p.ParseProgram()
+	return prog, p.errors
+}
+
+func (p *Parser) newParseError(tok Token, msg string) *ParseError {
+	return &ParseError{Position: CreatePositionInfo(tok, p.filename), Message: msg}
+}
+
+// addError registra err salvo que ya exista otro en la misma posición: el
+// lookahead puede re-lexear el mismo fragmento, y un error al final del
+// archivo suele arrastrar otro en cada bloque que queda sin cerrar.
+func (p *Parser) addError(err *ParseError) {
+	for _, e := range p.errors {
+		if e.Position.Line == err.Position.Line && e.Position.Col == err.Position.Col {
+			return
+		}
+	}
+	p.errors = append(p.errors, err)
+}
+
+// collectToken lee el siguiente token convirtiendo los panics del lexer en
+// errores. Tras un error léxico el resto del archivo se trata como EOF: la
+// posición del lexer ya no es fiable y seguir sólo produciría ruido.
+func (p *Parser) collectToken() (tok Token) {
+	if p.lexFailed {
+		return Token{Type: TOKEN_EOF, Line: p.lexer.line, Col: p.lexer.col, Pos: p.lexer.length, Start: p.lexer.length}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			msg := fmt.Sprint(r)
+			// Los mensajes del lexer pueden traer su propio prefijo "Line: x,Col: y\n"
+			if i := strings.Index(msg, "\n"); strings.HasPrefix(msg, "Line:") && i >= 0 {
+				msg = msg[i+1:]
+			}
+			msg = strings.TrimSpace(msg)
+			p.addError(&ParseError{
+				Position: &PositionInfo{Line: p.lexer.line, Col: p.lexer.col, Pos: p.lexer.pos, Filename: p.filename},
+				Message:  msg,
+			})
+			p.lexFailed = true
+			tok = Token{Type: TOKEN_EOF, Line: p.lexer.line, Col: p.lexer.col, Pos: p.lexer.length, Start: p.lexer.length}
+		}
+	}()
+	return p.lexer.NextToken()
+}
+
+// parseStatementRecovering parsea una sentencia y, si falla, registra el error
+// y descarta tokens hasta el final de la sentencia. Devuelve nil en ese caso.
+func (p *Parser) parseStatementRecovering(topLevel bool) (stmt Node) {
+	start := p.curTok.Start
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if !p.lexFailed {
+			if perr, ok := r.(*ParseError); ok {
+				p.addError(perr)
+			} else {
+				p.addError(p.newParseError(p.curTok, fmt.Sprint(r)))
+			}
+		}
+		stmt = nil
+		p.synchronize(start, topLevel)
+	}()
+	return p.parseStatement()
+}
+
+// synchronize avanza hasta un punto seguro para retomar el parseo: un salto de
+// línea o ';' fuera de cualquier delimitador abierto por la sentencia fallida,
+// o la '}' que cierra el bloque actual (que no se consume salvo en el nivel
+// superior, donde sobra). Un paréntesis sin cerrar nunca "se come" la llave del
+// bloque: es el error más común y no debe arrastrar al resto del archivo.
+func (p *Parser) synchronize(start int, topLevel bool) {
+	braces, parens := p.openDelimiters(start, p.curTok.Start)
+	for p.curTok.Type != TOKEN_EOF {
+		if p.curTok.Type == TOKEN_SYMBOL {
+			switch p.curTok.Value {
+			case "{":
+				braces++
+			case "(", "[":
+				parens++
+			case ")", "]":
+				if parens > 0 {
+					parens--
+				}
+			case "}":
+				if braces <= 0 {
+					if topLevel {
+						p.nextToken()
+					}
+					return
+				}
+				braces--
+				parens = 0
+			case "\n", ";":
+				if braces <= 0 && parens <= 0 {
+					p.nextToken()
+					return
+				}
+			}
+		}
+		p.nextToken()
+	}
+}
+
+// openDelimiters cuenta las llaves y paréntesis/corchetes que quedan abiertos
+// en input[from:to]. Se re-lexea el fragmento porque el parser no guarda los
+// tokens ya consumidos.
+func (p *Parser) openDelimiters(from, to int) (braces, parens int) {
+	if from < 0 || to > len(p.lexer.input) || from >= to {
+		return 0, 0
+	}
+	defer func() {
+		if recover() != nil {
+			braces, parens = 0, 0
+		}
+	}()
+	lx := NewLexer(p.lexer.input[from:to])
+	for tok := lx.NextToken(); tok.Type != TOKEN_EOF; tok = lx.NextToken() {
+		if tok.Type != TOKEN_SYMBOL {
+			continue
+		}
+		switch tok.Value {
+		case "{":
+			braces++
+		case "}":
+			braces--
+		case "(", "[":
+			parens++
+		case ")", "]":
+			parens--
+		}
+	}
+	return braces, parens
+}
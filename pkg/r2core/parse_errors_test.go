@@ -0,0 +1,92 @@
+package r2core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWithErrors_ValidProgram(t *testing.T) {
+	prog, errs := ParseWithErrors("let x = 1\nfunc f(a) { return a + x }\nstd.print(f(2))\n", "ok.r2")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	if len(prog.Statements) != 3 {
+		t.Errorf("expected 3 statements, got %d", len(prog.Statements))
+	}
+}
+
+func TestParseWithErrors_ReportsEveryStatement(t *testing.T) {
+	input := "let x = 1\nlet y = )\nfunc f() {\n  let z = (1 +\n  print(z)\n}\nlet w = ]\nlet ok = 2\n"
+	prog, errs := ParseWithErrors(input, "bad.r2")
+
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	wantLines := []int{2, 5, 7}
+	for i, err := range errs {
+		if err.Position.Line != wantLines[i] {
+			t.Errorf("error %d: expected line %d, got %d (%v)", i, wantLines[i], err.Position.Line, err)
+		}
+		if !strings.HasPrefix(err.Error(), "bad.r2:") {
+			t.Errorf("error %d: expected file:line:col format, got %q", i, err.Error())
+		}
+	}
+	// let x, func f (recuperado dentro del cuerpo) y let ok
+	if len(prog.Statements) != 3 {
+		t.Errorf("expected 3 surviving statements, got %d", len(prog.Statements))
+	}
+}
+
+func TestParseWithErrors_MissingBrace(t *testing.T) {
+	_, errs := ParseWithErrors("func g() {\n  let a = 1\n", "brace.r2")
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "to end block") {
+		t.Fatalf("expected a single unclosed block error, got %v", errs)
+	}
+}
+
+func TestParseWithErrors_LexerError(t *testing.T) {
+	_, errs := ParseWithErrors("let a = 1\nlet s = \"abc\nlet t = 2\n", "lex.r2")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Message, "String sin cerrar") {
+		t.Errorf("unexpected message: %q", errs[0].Message)
+	}
+	if strings.HasPrefix(errs[0].Message, "Line:") {
+		t.Errorf("lexer position prefix should be stripped: %q", errs[0].Message)
+	}
+}
+
+func TestParseWithErrors_StrayClosingBrace(t *testing.T) {
+	prog, errs := ParseWithErrors("}\nlet ok = 1\n", "stray.r2")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if len(prog.Statements) != 1 {
+		t.Errorf("expected parsing to resume after the stray brace, got %d statements", len(prog.Statements))
+	}
+}
+
+func TestParseProgram_StillPanicsOnError(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected ParseProgram to panic")
+		}
+		if _, ok := r.(string); !ok {
+			t.Errorf("expected the legacy string panic, got %T", r)
+		}
+	}()
+	NewParser("let y = )").ParseProgram()
+}
+
+func TestImportStatement_Position(t *testing.T) {
+	prog := NewParserWithFile("\nimport \"lib.r2\" as lib\n", "main.r2").ParseProgram()
+	imp, ok := prog.Statements[0].(*ImportStatement)
+	if !ok {
+		t.Fatalf("expected *ImportStatement, got %T", prog.Statements[0])
+	}
+	if imp.Position == nil || imp.Position.Line != 2 || imp.Position.Filename != "main.r2" {
+		t.Errorf("unexpected import position: %+v", imp.Position)
+	}
+}
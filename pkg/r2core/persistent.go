@@ -0,0 +1,567 @@
+package r2core
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math/bits"
+)
+
+// Las colecciones inmutables no cambian nunca: set, push, delete... devuelven
+// una colección nueva que comparte con la anterior todo lo que no cambió.
+// ImmutableList es un vector persistente (un trie de 32 hijos por nodo más un
+// buffer para el final, como el de Clojure) e ImmutableMap un HAMT más un
+// vector con el orden de inserción. Las operaciones cuestan O(log32 n).
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+type vecNode struct {
+	children [vecWidth]interface{} // *vecNode en los niveles internos, elementos en las hojas
+}
+
+// pvector es el vector persistente. Los últimos elementos (hasta 32) están
+// en tail; los demás, en hojas de 32 bajo root, que tiene shift/5 niveles
+// internos.
+type pvector struct {
+	count int
+	shift uint
+	root  *vecNode
+	tail  []interface{}
+}
+
+var emptyPVector = &pvector{shift: vecBits, root: &vecNode{}}
+
+func (v *pvector) tailOffset() int {
+	if v.count < vecWidth {
+		return 0
+	}
+	return ((v.count - 1) >> vecBits) << vecBits
+}
+
+// leaf devuelve la hoja (o el tail) que tiene el elemento i.
+func (v *pvector) leaf(i int) []interface{} {
+	if i >= v.tailOffset() {
+		return v.tail
+	}
+	node := v.root
+	for level := v.shift; level > 0; level -= vecBits {
+		node = node.children[(i>>level)&vecMask].(*vecNode)
+	}
+	return node.children[:]
+}
+
+func (v *pvector) get(i int) interface{} {
+	return v.leaf(i)[i&vecMask]
+}
+
+func (v *pvector) assoc(i int, value interface{}) *pvector {
+	if i >= v.tailOffset() {
+		tail := make([]interface{}, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vecMask] = value
+		return &pvector{count: v.count, shift: v.shift, root: v.root, tail: tail}
+	}
+	return &pvector{count: v.count, shift: v.shift, root: assocNode(v.shift, v.root, i, value), tail: v.tail}
+}
+
+func assocNode(level uint, node *vecNode, i int, value interface{}) *vecNode {
+	copied := &vecNode{children: node.children}
+	if level == 0 {
+		copied.children[i&vecMask] = value
+	} else {
+		sub := (i >> level) & vecMask
+		copied.children[sub] = assocNode(level-vecBits, node.children[sub].(*vecNode), i, value)
+	}
+	return copied
+}
+
+func (v *pvector) push(value interface{}) *pvector {
+	if v.count-v.tailOffset() < vecWidth {
+		tail := make([]interface{}, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = value
+		return &pvector{count: v.count + 1, shift: v.shift, root: v.root, tail: tail}
+	}
+	// El tail está lleno: pasa al árbol como una hoja
+	leaf := &vecNode{}
+	copy(leaf.children[:], v.tail)
+	root, shift := v.root, v.shift
+	if (v.count >> vecBits) > (1 << v.shift) {
+		// La raíz también: el árbol crece un nivel
+		root = &vecNode{}
+		root.children[0] = v.root
+		root.children[1] = newVecPath(v.shift, leaf)
+		shift += vecBits
+	} else {
+		root = v.pushLeaf(v.shift, v.root, leaf)
+	}
+	return &pvector{count: v.count + 1, shift: shift, root: root, tail: []interface{}{value}}
+}
+
+func (v *pvector) pushLeaf(level uint, parent, leaf *vecNode) *vecNode {
+	sub := ((v.count - 1) >> level) & vecMask
+	copied := &vecNode{children: parent.children}
+	if level == vecBits {
+		copied.children[sub] = leaf
+	} else if child, ok := parent.children[sub].(*vecNode); ok {
+		copied.children[sub] = v.pushLeaf(level-vecBits, child, leaf)
+	} else {
+		copied.children[sub] = newVecPath(level-vecBits, leaf)
+	}
+	return copied
+}
+
+func newVecPath(level uint, leaf *vecNode) *vecNode {
+	if level == 0 {
+		return leaf
+	}
+	node := &vecNode{}
+	node.children[0] = newVecPath(level-vecBits, leaf)
+	return node
+}
+
+func (v *pvector) pop() *pvector {
+	switch {
+	case v.count == 1:
+		return emptyPVector
+	case v.count-v.tailOffset() > 1:
+		// push siempre copia el tail, así que se puede compartir
+		return &pvector{count: v.count - 1, shift: v.shift, root: v.root, tail: v.tail[:len(v.tail)-1]}
+	}
+	// El tail queda vacío: la última hoja del árbol pasa a serlo
+	tail := make([]interface{}, vecWidth)
+	copy(tail, v.leaf(v.count-2))
+	root, shift := v.popLeaf(v.shift, v.root), v.shift
+	if root == nil {
+		root = &vecNode{}
+	}
+	if shift > vecBits && root.children[1] == nil {
+		root = root.children[0].(*vecNode)
+		shift -= vecBits
+	}
+	return &pvector{count: v.count - 1, shift: shift, root: root, tail: tail}
+}
+
+// popLeaf saca la última hoja del subárbol node; nil si queda vacío.
+func (v *pvector) popLeaf(level uint, node *vecNode) *vecNode {
+	sub := ((v.count - 2) >> level) & vecMask
+	if level > vecBits {
+		child := v.popLeaf(level-vecBits, node.children[sub].(*vecNode))
+		if child == nil && sub == 0 {
+			return nil
+		}
+		copied := &vecNode{children: node.children}
+		if child == nil {
+			copied.children[sub] = nil
+		} else {
+			copied.children[sub] = child
+		}
+		return copied
+	}
+	if sub == 0 {
+		return nil
+	}
+	copied := &vecNode{children: node.children}
+	copied.children[sub] = nil
+	return copied
+}
+
+// ImmutableList es una lista persistente: se lee como un array y cada
+// cambio devuelve una lista nueva.
+type ImmutableList struct {
+	vec *pvector
+}
+
+func NewImmutableList(items ...interface{}) *ImmutableList {
+	vec := emptyPVector
+	for _, item := range items {
+		vec = vec.push(item)
+	}
+	return &ImmutableList{vec: vec}
+}
+
+func (l *ImmutableList) Len() int {
+	return l.vec.count
+}
+
+// Get devuelve el elemento i; los índices negativos cuentan desde el final.
+func (l *ImmutableList) Get(i int) interface{} {
+	return l.vec.get(l.index(i, false))
+}
+
+// Set devuelve la lista con value en la posición i; i == Len() agrega.
+func (l *ImmutableList) Set(i int, value interface{}) *ImmutableList {
+	i = l.index(i, true)
+	if i == l.vec.count {
+		return l.Push(value)
+	}
+	return &ImmutableList{vec: l.vec.assoc(i, value)}
+}
+
+func (l *ImmutableList) Push(values ...interface{}) *ImmutableList {
+	vec := l.vec
+	for _, value := range values {
+		vec = vec.push(value)
+	}
+	return &ImmutableList{vec: vec}
+}
+
+// Pop devuelve la lista sin su último elemento.
+func (l *ImmutableList) Pop() *ImmutableList {
+	if l.vec.count == 0 {
+		panic("pop: the list is empty")
+	}
+	return &ImmutableList{vec: l.vec.pop()}
+}
+
+// Values devuelve los elementos en un array nuevo.
+func (l *ImmutableList) Values() []interface{} {
+	values := make([]interface{}, l.vec.count)
+	for i := range values {
+		values[i] = l.vec.get(i)
+	}
+	return values
+}
+
+func (l *ImmutableList) index(i int, end bool) int {
+	n := l.vec.count
+	if i < 0 {
+		i += n
+	}
+	if i < 0 || i > n || (i == n && !end) {
+		panic(fmt.Sprintf("index out of range: %d len of list %d", i, n))
+	}
+	return i
+}
+
+func (l *ImmutableList) Iterator() Iterator {
+	i := 0
+	return NewIterator(func() (interface{}, bool) {
+		if i >= l.vec.count {
+			return nil, true
+		}
+		i++
+		return l.vec.get(i - 1), false
+	}, nil)
+}
+
+func (l *ImmutableList) String() string {
+	return "ImmutableList[" + joinValues(l.Values()) + "]"
+}
+
+func evalImmutableListAccess(l *ImmutableList, member string) interface{} {
+	switch member {
+	case "size", "len", "length":
+		return collectionLen(l.Len)
+	case "get":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("get needs 1 argument (index)")
+			}
+			return l.Get(listIndex(args[0]))
+		})
+	case "set":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 2 {
+				panic("set needs 2 arguments (index, value)")
+			}
+			return l.Set(listIndex(args[0]), args[1])
+		})
+	case "push":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return l.Push(args...)
+		})
+	case "pop":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return l.Pop()
+		})
+	case "concat":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			result := l
+			for _, arg := range args {
+				result = result.Push(collectionItems("concat", arg)...)
+			}
+			return result
+		})
+	case "toArray", "values":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return l.Values()
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "ImmutableList does not have the method: "+member))
+}
+
+func listIndex(v interface{}) int {
+	i, ok := arrayIndex(v)
+	if !ok {
+		panic("index must be numeric for list")
+	}
+	return i
+}
+
+// hamtNode es un nodo del HAMT de ImmutableMap: bitmap marca cuáles de los
+// 32 lugares de este nivel están ocupados y entries tiene sólo los
+// ocupados, en orden. Pasados los 64 bits del hash, un nodo es una lista de
+// claves que colisionan.
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// hamtEntry es un subárbol (child) o un par.
+type hamtEntry struct {
+	hash  uint64
+	key   interface{} // collectionKey de la clave
+	value interface{}
+	child *hamtNode
+}
+
+const hamtHashBits = 64
+
+var hamtSeed = maphash.MakeSeed()
+
+func hamtHash(key interface{}) uint64 {
+	return maphash.Comparable(hamtSeed, key)
+}
+
+func (n *hamtNode) slot(hash uint64, shift uint) (bit uint32, idx int) {
+	bit = 1 << ((hash >> shift) & vecMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) get(key interface{}, hash uint64, shift uint) (interface{}, bool) {
+	for {
+		if shift >= hamtHashBits {
+			for _, e := range n.entries {
+				if e.key == key {
+					return e.value, true
+				}
+			}
+			return nil, false
+		}
+		bit, idx := n.slot(hash, shift)
+		if n.bitmap&bit == 0 {
+			return nil, false
+		}
+		e := n.entries[idx]
+		if e.child == nil {
+			if e.key == key {
+				return e.value, true
+			}
+			return nil, false
+		}
+		n, shift = e.child, shift+vecBits
+	}
+}
+
+// with devuelve el nodo con key -> value; added indica si la clave es nueva.
+func (n *hamtNode) with(key interface{}, hash uint64, shift uint, value interface{}) (node *hamtNode, added bool) {
+	leaf := hamtEntry{hash: hash, key: key, value: value}
+	if shift >= hamtHashBits {
+		for i, e := range n.entries {
+			if e.key == key {
+				return n.replace(i, leaf), false
+			}
+		}
+		return &hamtNode{entries: append(n.entries[:len(n.entries):len(n.entries)], leaf)}, true
+	}
+	bit, idx := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry, len(n.entries)+1)
+		copy(entries, n.entries[:idx])
+		entries[idx] = leaf
+		copy(entries[idx+1:], n.entries[idx:])
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+	e := n.entries[idx]
+	switch {
+	case e.child != nil:
+		child, added := e.child.with(key, hash, shift+vecBits, value)
+		return n.replace(idx, hamtEntry{child: child}), added
+	case e.key == key:
+		return n.replace(idx, leaf), false
+	}
+	// Otra clave en el mismo lugar: las dos bajan a un subárbol
+	child, _ := (&hamtNode{}).with(e.key, e.hash, shift+vecBits, e.value)
+	child, _ = child.with(key, hash, shift+vecBits, value)
+	return n.replace(idx, hamtEntry{child: child}), true
+}
+
+// without devuelve el nodo sin key; removed indica si estaba.
+func (n *hamtNode) without(key interface{}, hash uint64, shift uint) (node *hamtNode, removed bool) {
+	if shift >= hamtHashBits {
+		for i, e := range n.entries {
+			if e.key == key {
+				return n.remove(i, 0), true
+			}
+		}
+		return n, false
+	}
+	bit, idx := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	e := n.entries[idx]
+	if e.child == nil {
+		if e.key != key {
+			return n, false
+		}
+		return n.remove(idx, bit), true
+	}
+	child, removed := e.child.without(key, hash, shift+vecBits)
+	switch {
+	case !removed:
+		return n, false
+	case len(child.entries) == 0:
+		return n.remove(idx, bit), true
+	case len(child.entries) == 1 && child.entries[0].child == nil:
+		// Un par solo en un subárbol sube a este nivel
+		return n.replace(idx, child.entries[0]), true
+	}
+	return n.replace(idx, hamtEntry{child: child}), true
+}
+
+func (n *hamtNode) replace(idx int, e hamtEntry) *hamtNode {
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[idx] = e
+	return &hamtNode{bitmap: n.bitmap, entries: entries}
+}
+
+func (n *hamtNode) remove(idx int, bit uint32) *hamtNode {
+	entries := make([]hamtEntry, 0, len(n.entries)-1)
+	entries = append(entries, n.entries[:idx]...)
+	entries = append(entries, n.entries[idx+1:]...)
+	return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}
+}
+
+// ImmutableMap es un mapa persistente en orden de inserción, con claves de
+// cualquier tipo como Map. index lleva cada clave a su posición en order,
+// que tiene los pares (nil los borrados).
+type ImmutableMap struct {
+	index *hamtNode
+	order *pvector
+	size  int
+}
+
+type mapEntry struct {
+	key, value interface{}
+}
+
+var emptyImmutableMap = &ImmutableMap{index: &hamtNode{}, order: emptyPVector}
+
+func NewImmutableMap() *ImmutableMap {
+	return emptyImmutableMap
+}
+
+func (m *ImmutableMap) Len() int {
+	return m.size
+}
+
+func (m *ImmutableMap) Get(key interface{}) (interface{}, bool) {
+	hk := collectionKey(key)
+	pos, ok := m.index.get(hk, hamtHash(hk), 0)
+	if !ok {
+		return nil, false
+	}
+	return m.order.get(pos.(int)).(*mapEntry).value, true
+}
+
+// Set devuelve el mapa con key -> value; una clave que ya estaba conserva su
+// lugar.
+func (m *ImmutableMap) Set(key, value interface{}) *ImmutableMap {
+	hk := collectionKey(key)
+	hash := hamtHash(hk)
+	if pos, ok := m.index.get(hk, hash, 0); ok {
+		p := pos.(int)
+		old := m.order.get(p).(*mapEntry)
+		return &ImmutableMap{index: m.index, order: m.order.assoc(p, &mapEntry{key: old.key, value: value}), size: m.size}
+	}
+	index, _ := m.index.with(hk, hash, 0, m.order.count)
+	return &ImmutableMap{index: index, order: m.order.push(&mapEntry{key: key, value: value}), size: m.size + 1}
+}
+
+// Delete devuelve el mapa sin key.
+func (m *ImmutableMap) Delete(key interface{}) *ImmutableMap {
+	hk := collectionKey(key)
+	hash := hamtHash(hk)
+	pos, ok := m.index.get(hk, hash, 0)
+	if !ok {
+		return m
+	}
+	index, _ := m.index.without(hk, hash, 0)
+	result := &ImmutableMap{index: index, order: m.order.assoc(pos.(int), nil), size: m.size - 1}
+	if result.order.count >= vecWidth && result.size*2 <= result.order.count {
+		// Más de la mitad de order son huecos: se arma de nuevo
+		compact := emptyImmutableMap
+		result.Range(func(key, value interface{}) bool {
+			compact = compact.Set(key, value)
+			return true
+		})
+		return compact
+	}
+	return result
+}
+
+func (m *ImmutableMap) Range(fn func(key, value interface{}) bool) {
+	for i := 0; i < m.order.count; i++ {
+		if e, ok := m.order.get(i).(*mapEntry); ok && !fn(e.key, e.value) {
+			return
+		}
+	}
+}
+
+func (m *ImmutableMap) Entries() EntryIterator {
+	return &immutableMapIterator{m: m}
+}
+
+// Iterator recorre los pares [clave, valor], como el de OrderedMap.
+func (m *ImmutableMap) Iterator() Iterator {
+	return entryPairs(m.Entries())
+}
+
+func (m *ImmutableMap) String() string {
+	return "ImmutableMap{" + joinEntries(m) + "}"
+}
+
+type immutableMapIterator struct {
+	m *ImmutableMap
+	i int
+}
+
+func (it *immutableMapIterator) NextEntry() (key, value interface{}, done bool) {
+	for it.i < it.m.order.count {
+		e, ok := it.m.order.get(it.i).(*mapEntry)
+		it.i++
+		if ok {
+			return e.key, e.value, false
+		}
+	}
+	return nil, nil, true
+}
+
+func evalImmutableMapAccess(m *ImmutableMap, member string) interface{} {
+	if fn, ok := keyedAccess(m, member); ok {
+		return fn
+	}
+	switch member {
+	case "set":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 2 {
+				panic("set needs 2 arguments (key, value)")
+			}
+			return m.Set(args[0], args[1])
+		})
+	case "delete", "remove":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic(member + " needs 1 argument")
+			}
+			return m.Delete(args[0])
+		})
+	}
+	panic(newRuntimeError("ReferenceError", "ImmutableMap does not have the method: "+member))
+}
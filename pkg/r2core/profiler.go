@@ -0,0 +1,256 @@
+package r2core
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ============================================================
+// PROFILER DE FUNCIONES R2
+// ============================================================
+//
+// Profiler muestrea a intervalos fijos la pila de llamadas R2 (los frames que
+// UserFunction.NativeCall apila en el CallStack) y acumula cuántas muestras
+// cae en cada pila. El resultado se escribe en el formato de pprof, así que
+// `go tool pprof` muestra funciones R2 con su archivo y línea en lugar de las
+// funciones del intérprete. Es un profiler de tiempo de reloj: una función
+// bloqueada en un builtin (sleep, red) también acumula muestras.
+
+// DefaultProfilePeriod es el intervalo de muestreo de StartProfile.
+const DefaultProfilePeriod = 10 * time.Millisecond
+
+// Profiler acumula muestras de la pila de llamadas R2 de un programa.
+type Profiler struct {
+	callStack *CallStack
+	root      profFrame
+	period    time.Duration
+	start     time.Time
+	duration  time.Duration
+
+	locations map[profFrame]uint64
+	frames    []profFrame // frames[id-1] es la ubicación id
+	samples   map[string]*profSample
+	order     []string // Claves de samples en orden de aparición
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// profFrame es una función R2 y la línea donde está definida.
+type profFrame struct {
+	name string
+	file string
+	line int
+}
+
+type profSample struct {
+	locations []uint64 // Hoja primero, como espera pprof
+	count     int64
+	wall      time.Duration
+}
+
+// StartProfile empieza a muestrear la pila de llamadas de env (compartida
+// por todos sus entornos internos) cada period. El código de nivel superior
+// se atribuye a una función raíz "main" ubicada en env.CurrentFile.
+func StartProfile(env *Environment, period time.Duration) *Profiler {
+	if period <= 0 {
+		period = DefaultProfilePeriod
+	}
+	p := &Profiler{
+		callStack: env.callStack,
+		root:      profFrame{name: "main", file: env.CurrentFile},
+		period:    period,
+		start:     time.Now(),
+		locations: make(map[profFrame]uint64),
+		samples:   make(map[string]*profSample),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *Profiler) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+	last := p.start
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			// El ticker descarta ticks si el proceso está ocupado: cada
+			// muestra cuenta el tiempo real transcurrido desde la anterior.
+			p.sample(now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// sample registra la pila actual, a la que se atribuye elapsed.
+func (p *Profiler) sample(elapsed time.Duration) {
+	p.callStack.mu.Lock()
+	frames := make([]profFrame, 0, len(p.callStack.Frames)+1)
+	for i := len(p.callStack.Frames) - 1; i >= 0; i-- {
+		f := p.callStack.Frames[i]
+		frame := profFrame{name: f.FunctionName}
+		if f.Position != nil {
+			frame.file, frame.line = f.Position.Filename, f.Position.Line
+		}
+		frames = append(frames, frame)
+	}
+	p.callStack.mu.Unlock()
+	frames = append(frames, p.root)
+
+	var key strings.Builder
+	locations := make([]uint64, len(frames))
+	for i, f := range frames {
+		id, ok := p.locations[f]
+		if !ok {
+			p.frames = append(p.frames, f)
+			id = uint64(len(p.frames))
+			p.locations[f] = id
+		}
+		locations[i] = id
+		key.WriteString(strconv.FormatUint(id, 10))
+		key.WriteByte(',')
+	}
+
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &profSample{locations: locations}
+		p.samples[key.String()] = s
+		p.order = append(p.order, key.String())
+	}
+	s.count++
+	s.wall += elapsed
+}
+
+// Stop detiene el muestreo. Es seguro llamarlo más de una vez.
+func (p *Profiler) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.duration = time.Since(p.start)
+	})
+}
+
+// Samples retorna el número total de muestras tomadas.
+func (p *Profiler) Samples() int64 {
+	var n int64
+	for _, s := range p.samples {
+		n += s.count
+	}
+	return n
+}
+
+// WriteTo detiene el muestreo y escribe el perfil en formato pprof
+// (protobuf comprimido con gzip).
+func (p *Profiler) WriteTo(w io.Writer) (int64, error) {
+	p.Stop()
+
+	strs := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		if i, ok := strs[s]; ok {
+			return i
+		}
+		strs[s] = int64(len(table))
+		table = append(table, s)
+		return strs[s]
+	}
+	valueType := func(typ, unit string) []byte {
+		var b []byte
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(str(typ)))
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(str(unit)))
+		return b
+	}
+	message := func(b []byte, field protowire.Number, msg []byte) []byte {
+		b = protowire.AppendTag(b, field, protowire.BytesType)
+		return protowire.AppendBytes(b, msg)
+	}
+	varint := func(b []byte, field protowire.Number, v uint64) []byte {
+		b = protowire.AppendTag(b, field, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+
+	// Campos de profile.proto: 1 sample_type, 2 sample, 4 location,
+	// 5 function, 6 string_table, 9 time_nanos, 10 duration_nanos,
+	// 11 period_type, 12 period.
+	var out []byte
+	out = message(out, 1, valueType("samples", "count"))
+	out = message(out, 1, valueType("wall", "nanoseconds"))
+
+	for _, key := range p.order {
+		s := p.samples[key]
+		var locs, values, msg []byte
+		for _, id := range s.locations {
+			locs = protowire.AppendVarint(locs, id)
+		}
+		values = protowire.AppendVarint(values, uint64(s.count))
+		values = protowire.AppendVarint(values, uint64(s.wall.Nanoseconds()))
+		msg = message(msg, 1, locs)
+		msg = message(msg, 2, values)
+		out = message(out, 2, msg)
+	}
+
+	// Una función y una ubicación por frame, con el mismo id
+	for i, f := range p.frames {
+		id := uint64(i + 1)
+		var line, loc []byte
+		line = varint(line, 1, id)
+		line = varint(line, 2, uint64(f.line))
+		loc = varint(loc, 1, id)
+		loc = message(loc, 4, line)
+		out = message(out, 4, loc)
+	}
+	for i, f := range p.frames {
+		var fn []byte
+		fn = varint(fn, 1, uint64(i+1))
+		fn = varint(fn, 2, uint64(str(f.name)))
+		fn = varint(fn, 3, uint64(str(f.name)))
+		fn = varint(fn, 4, uint64(str(f.file)))
+		fn = varint(fn, 5, uint64(f.line))
+		out = message(out, 5, fn)
+	}
+
+	out = message(out, 11, valueType("wall", "nanoseconds"))
+	out = varint(out, 12, uint64(p.period.Nanoseconds()))
+	out = varint(out, 9, uint64(p.start.UnixNano()))
+	out = varint(out, 10, uint64(p.duration.Nanoseconds()))
+
+	// La tabla de strings va al final, cuando ya contiene todos los nombres
+	for _, s := range table {
+		out = protowire.AppendTag(out, 6, protowire.BytesType)
+		out = protowire.AppendString(out, s)
+	}
+
+	cw := &countingWriter{w: w}
+	zw := gzip.NewWriter(cw)
+	if _, err := zw.Write(out); err != nil {
+		return cw.n, err
+	}
+	err := zw.Close()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
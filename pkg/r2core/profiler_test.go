@@ -0,0 +1,79 @@
+package r2core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestProfiler_AttributesTimeToR2Functions(t *testing.T) {
+	env := NewEnvironment()
+	env.CurrentFile = "prog.r2"
+	env.Set("true", true)
+	env.Set("now", BuiltinFunction(func(args ...interface{}) interface{} {
+		return float64(time.Now().UnixNano())
+	}))
+
+	prog := NewParserWithFile(`
+func spin() {
+    let end = now() + 50000000
+    while (now() < end) { }
+}
+spin()
+`, "prog.r2").ParseProgram()
+
+	profiler := StartProfile(env, time.Millisecond)
+	prog.Eval(env)
+
+	var buf bytes.Buffer
+	if _, err := profiler.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if profiler.Samples() == 0 {
+		t.Fatal("expected at least one sample")
+	}
+
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("profile is not gzipped: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recorrer los campos de primer nivel de profile.proto
+	strs := map[string]bool{}
+	samples := 0
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			t.Fatalf("invalid profile: %v", protowire.ParseError(n))
+		}
+		data = data[n:]
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			t.Fatalf("invalid profile: %v", protowire.ParseError(n))
+		}
+		switch num {
+		case 2:
+			samples++
+		case 6:
+			s, _ := protowire.ConsumeString(data)
+			strs[s] = true
+		}
+		data = data[n:]
+	}
+	if samples == 0 {
+		t.Error("expected samples in the profile")
+	}
+	for _, want := range []string{"spin", "main", "prog.r2", "wall", "nanoseconds"} {
+		if !strs[want] {
+			t.Errorf("string table is missing %q", want)
+		}
+	}
+}
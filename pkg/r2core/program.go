@@ -2,13 +2,19 @@ package r2core
 
 type Program struct {
 	Statements []Node
+	Positions  []*PositionInfo // Dónde empieza cada sentencia, para el depurador; puede faltar
 }
 
 func (p *Program) Eval(env *Environment) interface{} {
 
 	var result interface{}
-	for _, stmt := range p.Statements {
-		val := stmt.Eval(env)
+	for i, stmt := range p.Statements {
+		var val interface{}
+		if env.debugger != nil && i < len(p.Positions) {
+			val = env.debugger.run(env, stmt, p.Positions[i])
+		} else {
+			val = stmt.Eval(env)
+		}
 
 		if rv, ok := val.(ReturnValue); ok {
 			return rv.Value
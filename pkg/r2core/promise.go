@@ -0,0 +1,360 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Promise es el resultado de una llamada asíncrona: se cumple con un valor o
+// se rechaza con el valor de un panic (lo que lanza throw, o cualquier error
+// de ejecución). Await re-lanza el rechazo en el goroutine que espera, así que
+// llega al TryStatement que lo rodea como cualquier otra excepción.
+type Promise struct {
+	done     chan struct{}
+	once     sync.Once
+	value    interface{}
+	reason   interface{}
+	rejected bool
+}
+
+// Estados de una promesa, como los devuelve State
+const (
+	PromisePending   = "pending"
+	PromiseFulfilled = "fulfilled"
+	PromiseRejected  = "rejected"
+)
+
+// NewPromise crea una promesa pendiente.
+func NewPromise() *Promise {
+	return &Promise{done: make(chan struct{})}
+}
+
+// ResolvedPromise devuelve una promesa ya cumplida con value.
+func ResolvedPromise(value interface{}) *Promise {
+	p := NewPromise()
+	p.Resolve(value)
+	return p
+}
+
+// RejectedPromise devuelve una promesa ya rechazada con reason.
+func RejectedPromise(reason interface{}) *Promise {
+	p := NewPromise()
+	p.Reject(reason)
+	return p
+}
+
+// Async ejecuta fn en un goroutine y devuelve la promesa de su resultado. El
+// programa espera a que termine antes de salir, igual que con r2().
+func Async(fn func() interface{}) *Promise {
+	p := NewPromise()
+	Add()
+	go func() {
+		defer Done()
+		defer func() {
+			if r := recover(); r != nil {
+				p.Reject(r)
+			}
+		}()
+		p.Resolve(fn())
+	}()
+	return p
+}
+
+// Resolve cumple la promesa con value. Si value es otra promesa, ésta adopta
+// su resultado cuando se resuelva. Sólo cuenta la primera resolución.
+func (p *Promise) Resolve(value interface{}) {
+	if rv, ok := value.(ReturnValue); ok {
+		value = rv.Value
+	}
+	if other, ok := value.(*Promise); ok {
+		if other == p {
+			p.Reject("a promise cannot be resolved with itself")
+			return
+		}
+		go func() {
+			<-other.done
+			if other.rejected {
+				p.Reject(other.reason)
+			} else {
+				p.Resolve(other.value)
+			}
+		}()
+		return
+	}
+	p.once.Do(func() {
+		p.value = value
+		close(p.done)
+	})
+}
+
+// Reject rechaza la promesa con reason. Sólo cuenta la primera resolución.
+// El motivo de un throw es el valor lanzado.
+func (p *Promise) Reject(reason interface{}) {
+	if ex, ok := reason.(*Exception); ok {
+		reason = ex.Value
+	}
+	p.once.Do(func() {
+		p.reason = reason
+		p.rejected = true
+		close(p.done)
+	})
+}
+
+// Done se cierra cuando la promesa se cumple o se rechaza.
+func (p *Promise) Done() <-chan struct{} {
+	return p.done
+}
+
+// State devuelve "pending", "fulfilled" o "rejected".
+func (p *Promise) State() string {
+	select {
+	case <-p.done:
+		if p.rejected {
+			return PromiseRejected
+		}
+		return PromiseFulfilled
+	default:
+		return PromisePending
+	}
+}
+
+// Result devuelve el valor o el motivo del rechazo de una promesa resuelta.
+func (p *Promise) Result() (value interface{}, reason interface{}, rejected bool) {
+	<-p.done
+	return p.value, p.reason, p.rejected
+}
+
+// Await espera a la promesa y devuelve su valor, o lanza el motivo del
+// rechazo. La espera se corta, con un error de timeout, si se cancela el
+// contexto del limitador de env (timeout global, Ctrl+C en -watch, ...).
+func (p *Promise) Await(env *Environment) interface{} {
+	var cancel <-chan struct{}
+	if env != nil {
+		if limiter := env.GetLimiter(); limiter.Enabled && limiter.Context != nil {
+			cancel = limiter.Context.Done()
+		}
+	}
+	select {
+	case <-p.done:
+	case <-cancel:
+		panic(NewTimeoutError("await_canceled", env.GetLimiter().Context))
+	}
+	if p.rejected {
+		// Los errores de Go (un timeout...) siguen siendo errores del
+		// intérprete; cualquier otro motivo se lanza como con throw
+		if err, ok := p.reason.(error); ok {
+			panic(err)
+		}
+		panic(&Exception{Value: p.reason})
+	}
+	return p.value
+}
+
+// Then devuelve una promesa con el resultado de onFulfilled(valor) u
+// onRejected(motivo), según cómo se resuelva p. Un callback nil deja pasar el
+// valor o el rechazo; uno que lanza rechaza la promesa devuelta.
+func (p *Promise) Then(onFulfilled, onRejected func(interface{}) interface{}) *Promise {
+	return Async(func() interface{} {
+		<-p.done
+		if p.rejected {
+			if onRejected == nil {
+				panic(p.reason)
+			}
+			return onRejected(p.reason)
+		}
+		if onFulfilled == nil {
+			return p.value
+		}
+		return onFulfilled(p.value)
+	})
+}
+
+func (p *Promise) String() string {
+	switch p.State() {
+	case PromiseFulfilled:
+		return fmt.Sprintf("Promise{%v}", p.value)
+	case PromiseRejected:
+		return fmt.Sprintf("Promise{<rejected> %v}", p.reason)
+	}
+	return "Promise{<pending>}"
+}
+
+// ToPromise devuelve v si ya es una promesa, o una promesa cumplida con v.
+func ToPromise(v interface{}) *Promise {
+	if p, ok := v.(*Promise); ok {
+		return p
+	}
+	return ResolvedPromise(v)
+}
+
+// PromiseAll se cumple con los valores de todas las promesas, en orden, o se
+// rechaza con el primer rechazo. Los elementos que no son promesas cuentan
+// como ya cumplidos.
+func PromiseAll(items []interface{}) *Promise {
+	result := NewPromise()
+	values := make([]interface{}, len(items))
+	if len(items) == 0 {
+		result.Resolve(values)
+		return result
+	}
+	var mu sync.Mutex
+	pending := len(items)
+	for i, item := range items {
+		p := ToPromise(item)
+		go func(i int) {
+			value, reason, rejected := p.Result()
+			if rejected {
+				result.Reject(reason)
+				return
+			}
+			mu.Lock()
+			values[i] = value
+			pending--
+			last := pending == 0
+			mu.Unlock()
+			if last {
+				result.Resolve(values)
+			}
+		}(i)
+	}
+	return result
+}
+
+// PromiseRace se resuelve como la primera de las promesas que se resuelva.
+// Con una lista vacía queda pendiente para siempre.
+func PromiseRace(items []interface{}) *Promise {
+	result := NewPromise()
+	for _, item := range items {
+		p := ToPromise(item)
+		go func() {
+			value, reason, rejected := p.Result()
+			if rejected {
+				result.Reject(reason)
+			} else {
+				result.Resolve(value)
+			}
+		}()
+	}
+	return result
+}
+
+// PromiseAny se cumple con el primer valor cumplido, o se rechaza cuando
+// todas se rechazan, con un mensaje que reúne los motivos.
+func PromiseAny(items []interface{}) *Promise {
+	result := NewPromise()
+	if len(items) == 0 {
+		result.Reject("all promises were rejected")
+		return result
+	}
+	reasons := make([]interface{}, len(items))
+	var mu sync.Mutex
+	pending := len(items)
+	for i, item := range items {
+		p := ToPromise(item)
+		go func(i int) {
+			value, reason, rejected := p.Result()
+			if !rejected {
+				result.Resolve(value)
+				return
+			}
+			mu.Lock()
+			reasons[i] = reason
+			pending--
+			last := pending == 0
+			mu.Unlock()
+			if last {
+				parts := make([]string, len(reasons))
+				for j, r := range reasons {
+					parts[j] = fmt.Sprint(r)
+				}
+				result.Reject("all promises were rejected: " + strings.Join(parts, "; "))
+			}
+		}(i)
+	}
+	return result
+}
+
+// PromiseTimeout se resuelve como p, salvo que pase d antes: entonces se
+// rechaza con un TimeoutError de env que envuelve ErrTimeout.
+func PromiseTimeout(env *Environment, p *Promise, d time.Duration) *Promise {
+	result := NewPromise()
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-p.done:
+			if p.rejected {
+				result.Reject(p.reason)
+			} else {
+				result.Resolve(p.value)
+			}
+		case <-timer.C:
+			message := fmt.Sprintf("promise timed out after %v", d)
+			obj := newError(env, "TimeoutError", message, fmt.Errorf("%w: %s", ErrTimeout, message))
+			setErrorSite(obj, nil, []interface{}{})
+			result.Reject(obj)
+		}
+	}()
+	return result
+}
+
+// evalPromiseAccess resuelve los métodos de una promesa: then, catch,
+// finally, await y state.
+func evalPromiseAccess(p *Promise, member string, env *Environment) interface{} {
+	switch member {
+	case "then":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			var onFulfilled, onRejected func(interface{}) interface{}
+			if len(args) > 0 && args[0] != nil {
+				onFulfilled = promiseCallback(args[0], "then")
+			}
+			if len(args) > 1 && args[1] != nil {
+				onRejected = promiseCallback(args[1], "then")
+			}
+			return p.Then(onFulfilled, onRejected)
+		})
+	case "catch":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("catch needs a function")
+			}
+			return p.Then(nil, promiseCallback(args[0], "catch"))
+		})
+	case "finally":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("finally needs a function")
+			}
+			fn := promiseCallback(args[0], "finally")
+			return Async(func() interface{} {
+				value, reason, rejected := p.Result()
+				fn(nil)
+				if rejected {
+					panic(reason)
+				}
+				return value
+			})
+		})
+	case "await":
+		return BuiltinFunction(func(args ...interface{}) interface{} {
+			return p.Await(env)
+		})
+	case "state":
+		return p.State()
+	}
+	panic(newRuntimeError("ReferenceError", "Promise does not have the method: "+member))
+}
+
+// promiseCallback adapta una función de R2 a un callback de Then.
+func promiseCallback(fn interface{}, method string) func(interface{}) interface{} {
+	switch fn.(type) {
+	case *UserFunction, BuiltinFunction, func(...interface{}) interface{}:
+	default:
+		panic(fmt.Sprintf("%s: the argument must be a function, got %T", method, fn))
+	}
+	return func(v interface{}) interface{} {
+		return callFunction(fn, v)
+	}
+}
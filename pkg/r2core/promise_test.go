@@ -0,0 +1,194 @@
+package r2core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func evalAsync(t *testing.T, code string) interface{} {
+	t.Helper()
+	env := newTestEnv()
+	env.Set("sleep", BuiltinFunction(func(args ...interface{}) interface{} {
+		time.Sleep(time.Duration(args[0].(float64)) * time.Millisecond)
+		return nil
+	}))
+	return NewParser(code).ParseProgram().Eval(env)
+}
+
+func TestAsync_CallReturnsPromise(t *testing.T) {
+	code := `
+		let order = []
+		async func work(x) {
+			sleep(20)
+			order = order.push("work")
+			return x * 2
+		}
+		let p = work(21)
+		order = order.push("caller")
+		let v = await p
+		[v, order]
+	`
+	got := evalAsync(t, code).([]interface{})
+	if got[0] != 42.0 {
+		t.Errorf("expected 42, got %v", got[0])
+	}
+	if order := fmt.Sprint(got[1]); order != "[caller work]" {
+		t.Errorf("expected the caller to run before the async body, got %v", order)
+	}
+}
+
+func TestAsync_RejectionIsCatchable(t *testing.T) {
+	code := `
+		async func fail() { throw "boom" }
+		let caught = nil
+		let ranFinally = false
+		try {
+			await fail()
+		} catch (e) {
+			caught = e
+		} finally {
+			ranFinally = true
+		}
+		[caught, ranFinally]
+	`
+	got := evalAsync(t, code).([]interface{})
+	if got[0] != "boom" || got[1] != true {
+		t.Errorf("expected the rejection in catch and finally to run, got %v", got)
+	}
+
+	// Sin try, el rechazo sale de await como cualquier excepción
+	var panicVal interface{}
+	func() {
+		defer func() { panicVal = recover() }()
+		evalAsync(t, `async func fail() { throw "uncaught" } await fail()`)
+	}()
+	if ex, ok := panicVal.(*Exception); !ok || ex.Value != "uncaught" {
+		t.Errorf("expected await to panic with the reason, got %v", panicVal)
+	}
+}
+
+func TestAsync_FunctionForms(t *testing.T) {
+	code := `
+		let lit = async func(x) { return x + 1 }
+		let arrow = async x => x + 2
+		let paren = async (a, b) => a + b
+		class Box {
+			let v = 10
+			async get() { return this.v }
+		}
+		[await lit(1), await arrow(1), await paren(1, 2), await Box().get(), await 7]
+	`
+	got := evalAsync(t, code).([]interface{})
+	want := []interface{}{2.0, 3.0, 3.0, 10.0, 7.0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestAsync_KeywordsStayIdentifiers(t *testing.T) {
+	// async y await sólo son palabras clave delante de una función o de un
+	// operando; como nombres de variables o funciones siguen funcionando
+	code := `
+		let await = 1
+		func async(x) { return x * 10 }
+		[await + 1, async(2)]
+	`
+	got := evalAsync(t, code).([]interface{})
+	if got[0] != 2.0 || got[1] != 20.0 {
+		t.Errorf("expected [2, 20], got %v", got)
+	}
+}
+
+func TestAsync_ThenAndCatch(t *testing.T) {
+	code := `
+		async func value(x) { return x }
+		async func fail() { throw "bad" }
+		let a = await value(1).then(v => v + 1)
+		let b = await fail().catch(e => "handled " + e)
+		let c = await fail().then(v => "not called").catch(e => e)
+		[a, b, c]
+	`
+	got := evalAsync(t, code).([]interface{})
+	if got[0] != 2.0 || got[1] != "handled bad" || got[2] != "bad" {
+		t.Errorf("unexpected results %v", got)
+	}
+}
+
+func TestPromise_Combinators(t *testing.T) {
+	delayed := func(d time.Duration, value interface{}, reject bool) *Promise {
+		return Async(func() interface{} {
+			time.Sleep(d)
+			if reject {
+				panic(value)
+			}
+			return value
+		})
+	}
+
+	all := PromiseAll([]interface{}{delayed(20*time.Millisecond, "a", false), "b", delayed(0, "c", false)})
+	if v := all.Await(nil).([]interface{}); v[0] != "a" || v[1] != "b" || v[2] != "c" {
+		t.Errorf("all: expected [a b c] in order, got %v", v)
+	}
+	_, reason, rejected := PromiseAll([]interface{}{delayed(0, "x", true), delayed(time.Second, "slow", false)}).Result()
+	if !rejected || reason != "x" {
+		t.Errorf("all: expected the first rejection, got %v %v", reason, rejected)
+	}
+
+	race := PromiseRace([]interface{}{delayed(200*time.Millisecond, "slow", false), delayed(0, "fast", false)})
+	if v := race.Await(nil); v != "fast" {
+		t.Errorf("race: expected fast, got %v", v)
+	}
+
+	anyP := PromiseAny([]interface{}{delayed(0, "e1", true), delayed(20*time.Millisecond, "ok", false)})
+	if v := anyP.Await(nil); v != "ok" {
+		t.Errorf("any: expected ok, got %v", v)
+	}
+	_, reason, rejected = PromiseAny([]interface{}{delayed(0, "e1", true), delayed(0, "e2", true)}).Result()
+	if !rejected || !strings.Contains(reason.(string), "e1") || !strings.Contains(reason.(string), "e2") {
+		t.Errorf("any: expected every reason, got %v", reason)
+	}
+
+	env := NewEnvironment()
+	_, reason, rejected = PromiseTimeout(env, delayed(time.Second, "late", false), 10*time.Millisecond).Result()
+	if obj, ok := reason.(*ObjectInstance); !rejected || !ok || !strings.Contains(errorString(obj), "TimeoutError: promise timed out") {
+		t.Errorf("timeout: expected a TimeoutError rejection, got %v", reason)
+	}
+	if !errors.Is(&Exception{Value: reason}, ErrTimeout) {
+		t.Errorf("timeout: expected the rejection to wrap ErrTimeout")
+	}
+	if v := PromiseTimeout(env, delayed(0, "soon", false), time.Second).Await(nil); v != "soon" {
+		t.Errorf("timeout: expected soon, got %v", v)
+	}
+}
+
+func TestPromise_ResolveAdoptsPromise(t *testing.T) {
+	inner := NewPromise()
+	outer := NewPromise()
+	outer.Resolve(inner)
+	if outer.State() != PromisePending {
+		t.Fatalf("expected outer to wait for inner, got %s", outer.State())
+	}
+	inner.Reject("inner failed")
+	if _, reason, rejected := outer.Result(); !rejected || reason != "inner failed" {
+		t.Errorf("expected outer to adopt the rejection, got %v", reason)
+	}
+}
+
+func TestPromise_AwaitStopsOnCancel(t *testing.T) {
+	env := NewEnvironment()
+	limiter := env.GetLimiter()
+	limiter.Cancel()
+
+	defer func() {
+		r := recover()
+		if _, ok := r.(*InfiniteLoopError); !ok {
+			t.Errorf("expected a timeout error, got %v", r)
+		}
+	}()
+	NewPromise().Await(env)
+}
@@ -0,0 +1,332 @@
+package r2core
+
+// Scope es el layout de las variables de un entorno resuelto: el de cada
+// llamada a una función y el de cada for clásico. Resolve le asigna un slot
+// a cada nombre que se declara en la función o el for, y las variables que
+// se leen o asignan ahí adentro guardan a cuántos entornos de distancia y en
+// qué slot está su declaración.
+type Scope struct {
+	parent *Scope         // Layout del entorno exterior; nil si no se resolvió
+	names  []string       // Nombre de cada slot
+	index  map[string]int // Slot de cada nombre; no cambia después de Resolve
+}
+
+// varRef es una variable resuelta: está depth entornos hacia afuera del que
+// tiene el layout scope, en el slot slot.
+type varRef struct {
+	scope *Scope
+	depth int
+	slot  int
+}
+
+// Resolve resuelve las variables de las funciones y los for de prog: cada
+// Identifier que se refiere a una variable declarada en una función o un for
+// que lo encierra queda con su (depth, slot), y las llamadas y los for crean
+// entornos con slots en lugar de mapas (ver newScopedEnv). ParseProgram lo
+// llama al terminar.
+//
+// También marca los return f(...) en posición de cola: los de una función
+// que no están dentro de un try ni de un for-in (que tienen que hacer algo
+// después de la llamada). Esa llamada la hace el trampolín de
+// UserFunction.call sin anidar frames (ver tailCall).
+//
+// Las variables del programa principal, las de las construcciones que crean
+// su propio entorno sin layout (catch, casos de switch, clases, match,
+// comprensiones, DSL) y lo que se alcanza a través de ellas se siguen
+// buscando por nombre. La resolución es sólo un atajo: si en tiempo de
+// ejecución la cadena de entornos no es la que se resolvió o el slot está
+// vacío (la variable todavía no se declaró), la lectura o la asignación van
+// por nombre, así que la semántica no cambia.
+func Resolve(prog *Program) {
+	r := &resolver{}
+	r.push(true) // El programa principal
+	r.stmts(prog.Statements)
+	r.pop()
+	for _, use := range r.uses {
+		use.bind()
+	}
+}
+
+type resolver struct {
+	frame *rsFrame
+	uses  []rsUse // Se ligan al final, cuando los layouts están completos
+	tail  bool    // Los return de aquí están en posición de cola
+}
+
+// rsFrame es una función, un for o una construcción opaca mientras se
+// recorre.
+type rsFrame struct {
+	outer  *rsFrame
+	scope  *Scope
+	opaque bool // Su entorno no tiene layout: sus variables van por nombre
+}
+
+// rsUse es un Identifier leído o asignado dentro de frame.
+type rsUse struct {
+	id    *Identifier
+	frame *rsFrame
+}
+
+// bind busca la declaración de la variable desde su frame hacia afuera.
+func (u rsUse) bind() {
+	depth := 0
+	for f := u.frame; f != nil && !f.opaque; f = f.outer {
+		if slot, ok := f.scope.index[u.id.Name]; ok {
+			u.id.ref = &varRef{scope: u.frame.scope, depth: depth, slot: slot}
+			return
+		}
+		depth++
+	}
+}
+
+func (r *resolver) push(opaque bool) *Scope {
+	scope := &Scope{index: map[string]int{}}
+	if !opaque && r.frame != nil && !r.frame.opaque {
+		scope.parent = r.frame.scope
+	}
+	r.frame = &rsFrame{outer: r.frame, scope: scope, opaque: opaque}
+	return scope
+}
+
+func (r *resolver) pop() {
+	r.frame = r.frame.outer
+}
+
+func (r *resolver) declare(names ...string) {
+	scope := r.frame.scope
+	for _, name := range names {
+		if _, ok := scope.index[name]; !ok {
+			scope.index[name] = len(scope.names)
+			scope.names = append(scope.names, name)
+		}
+	}
+}
+
+func (r *resolver) stmts(stmts []Node) {
+	for _, stmt := range stmts {
+		r.stmt(stmt)
+	}
+}
+
+// stmt recorre una sentencia. Los bloques de if, while y try no crean
+// entorno: lo que declaran es del frame actual.
+func (r *resolver) stmt(n Node) {
+	switch s := n.(type) {
+	case *BlockStatement:
+		if s != nil {
+			r.stmts(s.Statements)
+		}
+	case *ExprStatement:
+		r.expr(s.Expr)
+	case *LetStatement:
+		r.expr(s.Value)
+		r.declare(s.Name)
+	case *MultipleLetStatement:
+		for _, decl := range s.Declarations {
+			r.expr(decl.Value)
+			r.declare(decl.Name)
+		}
+	case *ConstStatement:
+		r.expr(s.Value)
+		r.declare(s.Name)
+	case *MultipleConstStatement:
+		for _, decl := range s.Declarations {
+			r.expr(decl.Value)
+			r.declare(decl.Name)
+		}
+	case *ArrayDestructuring:
+		r.expr(s.Value)
+		r.declare(s.Names...)
+	case *ObjectDestructuring:
+		r.expr(s.Value)
+		r.declare(s.Names...)
+	case *GenericAssignStatement:
+		r.expr(s.Right)
+		r.expr(s.Left)
+	case *IfStatement:
+		r.expr(s.Condition)
+		r.stmt(s.Consequence)
+		r.stmt(s.Alternative)
+	case *WhileStatement:
+		r.expr(s.Condition)
+		r.stmt(s.Body)
+	case *ForStatement:
+		r.forStmt(s)
+	case *ReturnStatement:
+		s.tail = r.tail && inTailPosition(s.Value)
+		r.expr(s.Value)
+	case *ThrowStatement:
+		r.expr(s.Value)
+	case *TryStatement:
+		tail := r.tail
+		r.tail = false
+		r.stmt(s.Body)
+		for _, clause := range s.Catches {
+			r.push(true)
+			r.declare(clause.Var)
+			r.stmt(clause.Body)
+			r.pop()
+		}
+		r.stmt(s.FinallyBlock)
+		r.tail = tail
+	case *SwitchStatement:
+		r.expr(s.Value)
+		for _, c := range s.Cases {
+			r.push(true)
+			r.stmt(c.Body)
+			r.pop()
+		}
+	case *FunctionDeclaration:
+		r.declare(s.Name)
+		r.function(s.Params, s.Args, s.Body, false)
+	case *ObjectDeclaration:
+		r.declare(s.Name)
+		r.push(true)
+		for _, member := range s.Members {
+			r.member(member)
+		}
+		r.pop()
+	case *EnumDeclaration:
+		r.declare(s.Name)
+	case *InterfaceDeclaration:
+		r.declare(s.Name)
+	case *ExportStatement:
+		r.stmt(s.Declaration)
+	default:
+		r.expr(n)
+	}
+}
+
+// member recorre un miembro de una clase. Los métodos se llaman con self,
+// this y super en su entorno.
+func (r *resolver) member(n Node) {
+	switch m := n.(type) {
+	case *FunctionDeclaration:
+		r.function(m.Params, m.Args, m.Body, true)
+	case *AccessorDeclaration:
+		if m.Func != nil {
+			r.function(m.Func.Params, m.Func.Args, m.Func.Body, true)
+		}
+	case *StaticMember:
+		r.member(m.Member)
+	default:
+		r.stmt(n)
+	}
+}
+
+// forStmt recorre un for. El for-in declara su variable (y $c, $k y $v) en
+// el entorno actual; el clásico tiene el suyo.
+func (r *resolver) forStmt(s *ForStatement) {
+	if s.inFlag {
+		r.declare(s.inIndexName, "$c", "$k", "$v")
+		r.expr(s.inExpr)
+		tail := r.tail
+		r.tail = false // Un iterador se cierra al salir del bucle
+		r.stmt(s.Body)
+		r.tail = tail
+		return
+	}
+	scope := r.push(false)
+	r.stmt(s.Init)
+	r.expr(s.Condition)
+	r.stmt(s.Post)
+	r.stmt(s.Body)
+	r.pop()
+	s.scope = scope
+}
+
+// function recorre una función y deja su layout en el cuerpo, que es lo que
+// comparten todas las copias de la función (ver UserFunction.call).
+func (r *resolver) function(params []Parameter, args []string, body *BlockStatement, method bool) {
+	if body == nil {
+		return
+	}
+	tail := r.tail
+	r.tail = true
+	body.scope = r.params(params, args, method)
+	r.stmts(body.Statements)
+	r.pop()
+	r.tail = tail
+}
+
+// params abre el frame de una función y declara sus parámetros.
+func (r *resolver) params(params []Parameter, args []string, method bool) *Scope {
+	scope := r.push(false)
+	if method {
+		r.declare("self", "this", "super")
+	}
+	r.declare(args...)
+	for _, param := range params {
+		r.declare(param.Name)
+		r.expr(param.DefaultValue)
+	}
+	return scope
+}
+
+func (r *resolver) expr(n Node) {
+	switch e := n.(type) {
+	case *Identifier:
+		if e != nil && e.Name != "_" {
+			r.uses = append(r.uses, rsUse{id: e, frame: r.frame})
+		}
+	case *BinaryExpression:
+		r.expr(e.Left)
+		r.expr(e.Right)
+	case *UnaryExpression:
+		r.expr(e.Right)
+	case *TernaryExpression:
+		r.expr(e.Condition)
+		r.expr(e.TrueExpr)
+		r.expr(e.FalseExpr)
+	case *CallExpression:
+		r.expr(e.Callee)
+		for _, arg := range e.Args {
+			r.expr(arg)
+		}
+	case *AccessExpression:
+		r.expr(e.Object)
+	case *OptionalAccessExpression:
+		r.expr(e.Object)
+	case *IndexExpression:
+		r.expr(e.Left)
+		r.expr(e.Index)
+	case *OptionalIndexExpression:
+		r.expr(e.Object)
+		r.expr(e.Index)
+	case *ArrayLiteral:
+		for _, elem := range e.Elements {
+			r.expr(elem)
+		}
+	case *MapLiteral:
+		for _, pair := range e.Pairs {
+			r.expr(pair.Key)
+			r.expr(pair.Value)
+		}
+	case *TemplateString:
+		for _, part := range e.Parts {
+			if part.IsExpression {
+				r.expr(part.Expression)
+			}
+		}
+	case *AwaitExpression:
+		r.expr(e.Value)
+	case *SpreadExpression:
+		r.expr(e.Value)
+	case *YieldExpression:
+		r.expr(e.Value)
+	case *FunctionLiteral:
+		r.function(e.Params, e.Args, e.Body, false)
+	case *ArrowFunction:
+		tail := r.tail
+		r.tail = true
+		e.scope = r.params(e.Params, nil, false)
+		if body, ok := e.Body.(*BlockStatement); ok && !e.IsExpression {
+			body.scope = e.scope
+			r.stmts(body.Statements)
+		} else {
+			r.stmt(e.Body)
+		}
+		r.pop()
+		r.tail = tail
+	}
+}
@@ -1,13 +1,18 @@
 package r2core
 
 type ReturnStatement struct {
+	BaseNode
 	Value Node
+	tail  bool // Value termina en una llamada en posición de cola (ver Resolve)
 }
 
 func (rs *ReturnStatement) Eval(env *Environment) interface{} {
 	if rs.Value == nil {
 		return ReturnValue{Value: nil}
 	}
+	if rs.tail {
+		return ReturnValue{Value: evalTail(rs.Value, env)}
+	}
 	val := rs.Value.Eval(env)
 	return ReturnValue{Value: val}
 }
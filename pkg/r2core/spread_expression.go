@@ -45,6 +45,11 @@ func ExpandSpreadInArray(elements []interface{}) []interface{} {
 				// Para objetos en arrays, conservamos el objeto completo
 				result = append(result, val)
 			default:
+				if it, ok := GetIterator(val); ok {
+					// Un iterador (un generador, por ejemplo) se consume entero
+					result = append(result, CollectIterator(it)...)
+					continue
+				}
 				// Para tipos primitivos, agregamos el valor directamente
 				result = append(result, val)
 			}
@@ -82,6 +87,11 @@ func ExpandSpreadInObject(pairs []MapPair) map[string]interface{} {
 				for k, v := range obj {
 					result[k] = v.Value
 				}
+			case KeyedCollection:
+				obj.Range(func(k, v interface{}) bool {
+					result[toString(k)] = v
+					return true
+				})
 			default:
 				// Si no es un objeto, lo tratamos como una propiedad normal
 				keyStr := toString(pair.Key.Eval(env))
@@ -112,6 +122,10 @@ func ExpandSpreadInFunctionCall(args []interface{}) []interface{} {
 				// Expandir array como argumentos individuales
 				result = append(result, []interface{}(val)...)
 			default:
+				if it, ok := GetIterator(val); ok {
+					result = append(result, CollectIterator(it)...)
+					continue
+				}
 				// Para tipos no-array, agregar como argumento individual
 				result = append(result, val)
 			}
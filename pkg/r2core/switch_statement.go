@@ -0,0 +1,82 @@
+package r2core
+
+// SwitchStatement es la sentencia imperativa de varias ramas:
+//
+//	switch (expr) {
+//	case 1, 2:
+//	    ...
+//	case [x, y] if x > y:
+//	    ...
+//	case v if v > 100:
+//	    ...
+//	default:
+//	    ...
+//	}
+//
+// Se ejecuta sólo el primer caso cuya etiqueta coincide (no hay fallthrough)
+// y default, esté donde esté, sólo si ninguno coincide. Un break dentro de un
+// caso termina el switch; continue y return siguen hacia el bucle o la
+// función que lo contiene.
+type SwitchStatement struct {
+	Value Node
+	Cases []SwitchCase
+}
+
+// SwitchCase es un "case a, b:" o el "default:" de un switch. Cada etiqueta
+// es un Pattern: un ValuePattern para expresiones comunes o los patrones de
+// match ([...], {...}, o un identificador que liga el valor si lleva guard)
+// con un guard opcional (GuardedPattern).
+type SwitchCase struct {
+	Patterns  []Pattern
+	Body      *BlockStatement
+	IsDefault bool
+}
+
+// ValuePattern coincide con el valor de una expresión usando la igualdad de
+// ==, así que "case 10:" coincide con 10n o 10.00d.
+type ValuePattern struct {
+	Value Node
+}
+
+func (vp *ValuePattern) MatchValue(value interface{}, env *Environment) (bool, map[string]interface{}) {
+	expected := vp.Value.Eval(env)
+	if equals(value, expected) || isEqual(value, expected) {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (ss *SwitchStatement) Eval(env *Environment) interface{} {
+	value := ss.Value.Eval(env)
+	var def *SwitchCase
+	for i := range ss.Cases {
+		c := &ss.Cases[i]
+		if c.IsDefault {
+			def = c
+			continue
+		}
+		for _, pattern := range c.Patterns {
+			if matches, bindings := pattern.MatchValue(value, env); matches {
+				return c.run(env, bindings)
+			}
+		}
+	}
+	if def != nil {
+		return def.run(env, nil)
+	}
+	return nil
+}
+
+// run ejecuta el cuerpo del caso en su propio ámbito, con las variables que
+// ligó el patrón. El break que lo corta no sale del switch.
+func (c *SwitchCase) run(env *Environment, bindings map[string]interface{}) interface{} {
+	caseEnv := NewInnerEnv(env)
+	for name, val := range bindings {
+		caseEnv.Set(name, val)
+	}
+	val := c.Body.Eval(caseEnv)
+	if _, ok := val.(BreakValue); ok {
+		return nil
+	}
+	return val
+}
@@ -0,0 +1,179 @@
+package r2core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSwitch_Values(t *testing.T) {
+	code := `
+		func name(d) {
+			switch (d) {
+				case 0, 6:
+					return "weekend"
+				case "x":
+					return "string"
+				case 10n:
+					return "exact"
+				default:
+					return "weekday"
+			}
+		}
+		[name(0), name(6), name(3), name("x"), name(10)]
+	`
+	got := fmt.Sprint(evalCode(t, code))
+	if got != "[weekend weekend weekday string exact]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestSwitch_NoFallthrough(t *testing.T) {
+	code := `
+		let log = []
+		switch (1) {
+			case 1:
+				log = log.push("one")
+			case 2:
+				log = log.push("two")
+			default:
+				log = log.push("default")
+		}
+		log
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[one]" {
+		t.Errorf("expected only the first case to run, got %s", got)
+	}
+}
+
+func TestSwitch_DefaultPositionAndNoMatch(t *testing.T) {
+	code := `
+		let a = "none"
+		switch (2) {
+			default:
+				a = "default"
+			case 2:
+				a = "two"
+		}
+		let b = "none"
+		switch (5) {
+			case 1:
+				b = "one"
+		}
+		[a, b]
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[two none]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestSwitch_Patterns(t *testing.T) {
+	code := `
+		func describe(v) {
+			switch (v) {
+				case [x, y] if x > y:
+					return "desc " + x
+				case [x, y]:
+					return "pair " + y
+				case {kind: "circle", r}:
+					return "circle " + r
+				case {name}:
+					return "named " + name
+				default:
+					return "other"
+			}
+		}
+		[describe([2, 1]), describe([1, 2]), describe({kind: "circle", r: 3}), describe({name: "x"}), describe(7)]
+	`
+	got := evalCode(t, code).([]interface{})
+	want := []string{"desc 2", "pair 2", "circle 3", "named x", "other"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
+func TestSwitch_GuardedBinding(t *testing.T) {
+	code := `
+		let limit = 3
+		func size(n) {
+			switch (n) {
+				case v if v > 100:
+					return "big " + v
+				case limit:
+					return "limit"
+				case _ if n < 0:
+					return "negative"
+				default:
+					return "small"
+			}
+		}
+		[size(500), size(3), size(-1), size(7)]
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[big 500 limit negative small]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestSwitch_BindingsAreScoped(t *testing.T) {
+	code := `
+		let x = "outer"
+		switch ([1, 2]) {
+			case [x, y]:
+				let inner = x + y
+		}
+		x
+	`
+	if got := evalCode(t, code); got != "outer" {
+		t.Errorf("expected pattern bindings to stay inside the case, got %v", got)
+	}
+}
+
+func TestSwitch_BreakAndContinue(t *testing.T) {
+	code := `
+		let out = []
+		let i = 0
+		while (i < 6) {
+			i = i + 1
+			switch (i) {
+				case 2:
+					continue
+				case 4:
+					break
+					out = out.push("unreachable")
+				case 6:
+					out = out.push("six")
+					break
+			}
+			out = out.push(i)
+		}
+		out
+	`
+	if got := fmt.Sprint(evalCode(t, code)); got != "[1 3 4 5 six 6]" {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestSwitch_SwitchIsStillAnIdentifier(t *testing.T) {
+	code := `
+		let switch = 3
+		let default = {default: 4}
+		switch + default.default
+	`
+	if got := evalCode(t, code); got != 7.0 {
+		t.Errorf("expected 7, got %v", got)
+	}
+}
+
+func TestSwitch_ParseErrors(t *testing.T) {
+	for _, src := range []string{
+		`switch (x) { default: 1 default: 2 }`,
+		`switch (x) { log(x) }`,
+		`switch (x) { case 1 log(x) }`,
+		`switch x { case 1: log(x) }`,
+	} {
+		if _, errs := ParseWithErrors(src, ""); len(errs) == 0 {
+			t.Errorf("expected a parse error for %q", src)
+		}
+	}
+}
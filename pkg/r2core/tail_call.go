@@ -0,0 +1,95 @@
+package r2core
+
+// tailCall es una llamada en posición de cola que todavía no se hizo: el
+// return que la contiene (ver Resolve) la devuelve en lugar de llamar, y
+// UserFunction.call la ejecuta después de cerrar el frame actual. Así una
+// recursión de cola, directa o mutua, corre en un frame de Go y ocupa un solo
+// lugar en la pila de llamadas de R2 y en el ExecutionLimiter.
+type tailCall struct {
+	fn   *UserFunction
+	args []interface{}
+}
+
+// inTailPosition indica si n, como valor de un return, termina en una
+// llamada: una llamada o un ternario con alguna rama que lo sea.
+func inTailPosition(n Node) bool {
+	switch e := n.(type) {
+	case *CallExpression:
+		return true
+	case *TernaryExpression:
+		return inTailPosition(e.TrueExpr) || inTailPosition(e.FalseExpr)
+	}
+	return false
+}
+
+// evalTail evalúa el valor de un return en posición de cola: la llamada en
+// la que termina devuelve su *tailCall en lugar de hacerse.
+func evalTail(n Node, env *Environment) interface{} {
+	switch e := n.(type) {
+	case *CallExpression:
+		return e.tailCall(env, e.Callee.Eval(env), e.args(env))
+	case *TernaryExpression:
+		if toBool(e.Condition.Eval(env)) {
+			return evalTail(e.TrueExpr, env)
+		}
+		return evalTail(e.FalseExpr, env)
+	}
+	return n.Eval(env)
+}
+
+// tailCall es call para un return en posición de cola: si calleeVal termina
+// en una llamada a una *UserFunction (directamente, o completando una
+// aplicación parcial o currificada) devuelve el *tailCall; si no, llama como
+// siempre.
+func (ce *CallExpression) tailCall(env *Environment, calleeVal interface{}, argVals []interface{}) interface{} {
+	if !ce.superCall() {
+		expanded := ExpandSpreadInFunctionCall(argVals)
+		if fn, args, ok := tailTarget(calleeVal, expanded); ok {
+			return &tailCall{fn: fn, args: args}
+		}
+	}
+	return ce.call(env, calleeVal, argVals)
+}
+
+// tailTarget retorna la función y los argumentos con los que termina llamar
+// a calleeVal con args, si es una *UserFunction que se puede llamar desde el
+// trampolín. Las async y los generadores no: su llamada no corre el cuerpo.
+func tailTarget(calleeVal interface{}, args []interface{}) (*UserFunction, []interface{}, bool) {
+	if hasPlaceholders(args) {
+		return nil, nil, false
+	}
+	var fn interface{}
+	switch cv := calleeVal.(type) {
+	case *UserFunction:
+		fn = cv
+	case *PartialFunction:
+		var partial *PartialFunction
+		if fn, args, partial = cv.fill(args); partial != nil {
+			return nil, nil, false
+		}
+	case *CurriedFunction:
+		// Sólo si el último argumento es el que la completa; si sobran, los
+		// que sobran se aplican al resultado (ver CallExpression.call)
+		for i, arg := range args {
+			var next *CurriedFunction
+			var full []interface{}
+			fn, full, next = cv.fill(arg)
+			if next == nil {
+				if i != len(args)-1 {
+					return nil, nil, false
+				}
+				args = full
+				break
+			}
+			cv = next
+		}
+		if fn == nil {
+			return nil, nil, false
+		}
+	}
+	uf, ok := fn.(*UserFunction)
+	if !ok || uf.IsAsync || uf.IsGenerator {
+		return nil, nil, false
+	}
+	return uf, args, true
+}
@@ -0,0 +1,178 @@
+package r2core
+
+import (
+	"strings"
+	"testing"
+)
+
+// Las recursiones de 5000 niveles pasan el límite de 1000 llamadas anidadas
+// del ExecutionLimiter.
+func TestTailCall_DeepRecursion(t *testing.T) {
+	cases := map[string]struct{ input, want string }{
+		"self": {
+			`func count(n, acc) { if (n == 0) { return acc } return count(n - 1, acc + 1) } log(count(5000, 0))`,
+			"5000\n",
+		},
+		"mutual": {
+			`func isEven(n) { if (n == 0) { return true } return isOdd(n - 1) } func isOdd(n) { if (n == 0) { return false } return isEven(n - 1) } log(isEven(5000), isOdd(5001))`,
+			"true true\n",
+		},
+		"ternary": {
+			`func sum(n, acc) { return n == 0 ? acc : sum(n - 1, acc + 1) } log(sum(5000, 0))`,
+			"5000\n",
+		},
+		"arrow": {
+			`let sumTo = (n, acc) => n == 0 ? acc : sumTo(n - 1, acc + 1); log(sumTo(5000, 0))`,
+			"5000\n",
+		},
+		"closure": {
+			`func outer() { let step = 2; func loop(n) { if (n <= 0) { return n } return loop(n - step) } return loop(5000) } log(outer())`,
+			"0\n",
+		},
+		"partial": {
+			`func add(k, n) { if (n <= 0) { return k } let next = add(k + 1, _); return next(n - 1) } log(add(0, 5000))`,
+			"5000\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tree, vm := runBoth(t, tc.input)
+			if want := tc.want + "=> <nil>"; tree != want || vm != want {
+				t.Errorf("expected %q\ntree: %q\nvm:   %q", want, tree, vm)
+			}
+		})
+	}
+}
+
+func TestTailCall_Curried(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("curry", BuiltinFunction(CurryFunction))
+	result := NewParser(`func loop(n, acc) { if (n == 0) { return acc } return curry(loop)(n - 1)(acc + 1) } loop(5000, 0)`).ParseProgram().Eval(env)
+	if result != float64(5000) {
+		t.Errorf("expected 5000, got %v", result)
+	}
+}
+
+func TestTailCall_NotInTailPosition(t *testing.T) {
+	cases := map[string]string{
+		// La suma se hace después de la llamada
+		"binary": `func deep(n) { if (n == 0) { return 0 } return 1 + deep(n - 1) } log(deep(5000))`,
+		// El try tiene que poder capturar lo que lance la llamada
+		"try": `func deep(n) { if (n == 0) { return 0 } try { return deep(n - 1) } catch (e) { throw e } } log(deep(5000))`,
+		// La llamada no es el valor del return
+		"let": `func deep(n) { if (n == 0) { return 0 } let r = deep(n - 1); return r } log(deep(5000))`,
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			tree, vm := runBoth(t, input)
+			for _, out := range []string{tree, vm} {
+				if !strings.Contains(out, "panic:") {
+					t.Errorf("expected the recursion limit, got %q", out)
+				}
+			}
+		})
+	}
+}
+
+func TestTailCall_Semantics(t *testing.T) {
+	cases := map[string]struct{ input, want string }{
+		"catch in caller": {
+			`func fail(n) { if (n == 0) { throw "boom" } return fail(n - 1) } func f() { try { return fail(3) } catch (e) { return "caught " + e } } log(f())`,
+			"caught boom\n",
+		},
+		"finally": {
+			`func id(x) { return x } func f() { try { return id(1) } finally { log("finally") } } log(f())`,
+			"finally\n1\n",
+		},
+		"for in": {
+			`func* gen() { try { yield 1; yield 2 } finally { log("closed") } } func id(x) { return x } func f() { for (x in gen()) { return id(x) } } log(f())`,
+			"closed\n1\n",
+		},
+		"builtin": {
+			`func f(x) { return log(x) } f("native")`,
+			"native\n",
+		},
+		"async": {
+			`async func g(x) { return x * 2 } func f(x) { return g(x) } log(await f(2))`,
+			"4\n",
+		},
+		"method": {
+			`class C { let n = 0; loop(k) { if (k == 0) { return this.n } this.n = this.n + 1; return this.loop(k - 1) } } log(C().loop(10))`,
+			"10\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tree, vm := runBoth(t, tc.input)
+			if want := tc.want + "=> <nil>"; tree != want || vm != want {
+				t.Errorf("expected %q\ntree: %q\nvm:   %q", want, tree, vm)
+			}
+		})
+	}
+}
+
+func TestTailCall_CallStack(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("nil", nil)
+	var depths []int
+	env.Set("depth", BuiltinFunction(func(args ...interface{}) interface{} {
+		depths = append(depths, env.GetLimiter().CallDepth())
+		return nil
+	}))
+	NewParser(`func f(n) { depth(); if (n == 0) { return nil } return f(n - 1) } f(3)`).ParseProgram().Eval(env)
+
+	if len(depths) != 4 {
+		t.Fatalf("expected 4 calls, got %v", depths)
+	}
+	for _, d := range depths {
+		if d != depths[0] {
+			t.Errorf("expected every tail call to replace its caller, got depths %v", depths)
+		}
+	}
+}
+
+// Una llamada de cola reemplaza a la función que la hace, así que esa
+// función no aparece en el stack de un Error; una que no es de cola sí.
+func TestTailCall_ErrorStack(t *testing.T) {
+	cases := map[string]struct{ lookup, want string }{
+		"tail":     {`return find(key)`, "[main.r2:2:8 in find() main.r2:4:8 in outer()]"},
+		"not tail": {`let found = find(key); return found`, "[main.r2:2:8 in find() main.r2:3:8 in lookup() main.r2:4:8 in outer()]"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := evalErrors(t, `
+				func find(key) { throw Error("no " + key) }
+				func lookup(key) { `+tc.lookup+` }
+				func outer(key) { let v = lookup(key); return v }
+				let e = nil
+				try { outer("k") } catch (err) { e = err }
+				e.stack`)
+			if s := strings.Join(toStrings([]interface{}{got}), ""); s != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, s)
+			}
+		})
+	}
+}
+
+func TestTailCall_StrictReturnType(t *testing.T) {
+	env := NewEnvironment()
+	env.SetStrictTypes(true)
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(error).Error(), "string") {
+			t.Errorf("expected a return type error, got %v", r)
+		}
+	}()
+	NewParser(`func name(): number { return text() } func text() { return "x" } name()`).ParseProgram().Eval(env)
+}
+
+func TestTailCall_WithoutResolve(t *testing.T) {
+	p := NewParser(`func count(n) { if (n == 0) { return 0 } return count(n - 1) } count(5000)`)
+	p.SetResolve(false)
+	prog := p.ParseProgram()
+	defer func() {
+		if recover() == nil {
+			t.Error("expected unresolved returns to nest calls")
+		}
+	}()
+	prog.Eval(NewEnvironment())
+}
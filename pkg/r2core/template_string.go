@@ -136,8 +136,6 @@ func formatValue(value interface{}, format string) string {
 
 // formatCurrency formats a number as currency
 func formatCurrency(value interface{}, format string) string {
-	num := toFloat(value)
-
 	// Extract precision from format like $,.2f
 	precision := 2
 	if strings.Contains(format, ".") {
@@ -150,7 +148,7 @@ func formatCurrency(value interface{}, format string) string {
 		}
 	}
 
-	formatted := fmt.Sprintf("%."+strconv.Itoa(precision)+"f", num)
+	formatted := formatFixed(value, precision)
 
 	// Add commas if requested
 	if strings.Contains(format, ",") {
@@ -162,8 +160,6 @@ func formatCurrency(value interface{}, format string) string {
 
 // formatFloat formats a float with specified precision
 func formatFloat(value interface{}, format string) string {
-	num := toFloat(value)
-
 	// Extract precision (e.g., ".2f" -> 2)
 	precision := 6
 	if strings.Contains(format, ".") {
@@ -176,7 +172,16 @@ func formatFloat(value interface{}, format string) string {
 		}
 	}
 
-	return fmt.Sprintf("%."+strconv.Itoa(precision)+"f", num)
+	return formatFixed(value, precision)
+}
+
+// formatFixed formatea value con precision decimales. Los números exactos
+// (int64, BigInt, Decimal) se redondean sin pasar por float64.
+func formatFixed(value interface{}, precision int) string {
+	if isExactNumber(value) {
+		return ToDecimal(value).Round(precision).String()
+	}
+	return fmt.Sprintf("%."+strconv.Itoa(precision)+"f", toFloat(value))
 }
 
 // formatPercentage formats a number as percentage
@@ -200,6 +205,9 @@ func formatPercentage(value interface{}, format string) string {
 
 // formatNumberWithCommas adds commas to large numbers
 func formatNumberWithCommas(value interface{}, format string) string {
+	if isExactNumber(value) {
+		return addCommas(fmt.Sprint(value))
+	}
 	num := toFloat(value)
 
 	var str string
@@ -1,10 +1,20 @@
 package r2core
 
+// ThrowStatement lanza un valor cualquiera: throw expr
+// Si es una instancia de Error sin posición, guarda en ella dónde se lanzó y
+// la pila de llamadas de R2.
 type ThrowStatement struct {
-	Message string
+	BaseNode
+	Value Node
 }
 
 func (ts *ThrowStatement) Eval(env *Environment) interface{} {
-	panic(ts.Message)
-	//return nil
+	value := ts.Value.Eval(env)
+	if obj, ok := value.(*ObjectInstance); ok && isError(obj) {
+		// Relanzar un error capturado conserva dónde se lanzó primero
+		if pos, _ := obj.Env.lookupLocal("position"); pos == nil {
+			setErrorSite(obj, ts.Position, stackLines(env.callStack.Clone().Frames))
+		}
+	}
+	panic(&Exception{Value: value})
 }
@@ -1,10 +1,23 @@
 package r2core
 
+// TryStatement es un try con sus catch y su finally opcionales:
+//
+//	try { ... } catch (e: TypeError | ArgumentError) { ... } catch (e) { ... } finally { ... }
+//
+// La excepción la recibe el primer catch cuyo tipo la acepta; si ninguno lo
+// hace sigue propagándose después del finally.
 type TryStatement struct {
 	Body         *BlockStatement
-	CatchBlock   *BlockStatement
+	Catches      []*CatchClause
 	FinallyBlock *BlockStatement
-	ExceptionVar string
+}
+
+// CatchClause es un catch: Var recibe la excepción si es de Type, o
+// cualquiera si Type es nil.
+type CatchClause struct {
+	Var  string
+	Type *TypeAnnotation
+	Body *BlockStatement
 }
 
 func (ts *TryStatement) Eval(env *Environment) interface{} {
@@ -15,29 +28,46 @@ func (ts *TryStatement) Eval(env *Environment) interface{} {
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				if ts.CatchBlock != nil {
-					// Run the catch block under its own recover so that an
-					// exception thrown from inside catch doesn't skip the
-					// finally block below (it still propagates, just after
-					// finally has run).
-					func() {
-						defer func() {
-							if r2 := recover(); r2 != nil {
-								unhandled = true
-								caught = r2
-							}
-						}()
-						newEnv := NewInnerEnv(env)
-						newEnv.Set(ts.ExceptionVar, r)
-						result = ts.CatchBlock.Eval(newEnv)
-					}()
-				} else {
-					// No catch block: remember the panic so it can be
-					// re-raised after the finally block runs, instead
-					// of being silently swallowed.
+				if _, closing := r.(generatorExit); closing || r == ErrDebugTerminated {
+					// Un generador cerrado con break/return() o el
+					// depurador cortando el programa: sólo corre el
+					// finally, no es una excepción del script.
 					unhandled = true
 					caught = r
+					return
 				}
+				// No catch block for it: remember the panic so it can be
+				// re-raised after the finally block runs, instead of being
+				// silently swallowed.
+				unhandled = true
+				caught = r
+				if len(ts.Catches) == 0 {
+					return
+				}
+				// Run the catch block under its own recover so that an
+				// exception thrown from inside catch doesn't skip the
+				// finally block below (it still propagates, just after
+				// finally has run).
+				func() {
+					defer func() {
+						if r2 := recover(); r2 != nil {
+							unhandled = true
+							caught = r2
+						}
+					}()
+					value := exceptionValue(r, env)
+					clause := ts.catchFor(value, env)
+					if clause == nil {
+						return
+					}
+					unhandled = false
+					if env.debugger != nil {
+						env.debugger.handled()
+					}
+					newEnv := NewInnerEnv(env)
+					newEnv.Set(clause.Var, value)
+					result = clause.Body.Eval(newEnv)
+				}()
 			}
 		}()
 		result = ts.Body.Eval(env)
@@ -60,3 +90,13 @@ func (ts *TryStatement) Eval(env *Environment) interface{} {
 
 	return result
 }
+
+// catchFor devuelve el primer catch que acepta value, o nil.
+func (ts *TryStatement) catchFor(value interface{}, env *Environment) *CatchClause {
+	for _, clause := range ts.Catches {
+		if clause.Type == nil || clause.Type.hasType(value, env) {
+			return clause
+		}
+	}
+	return nil
+}
@@ -71,7 +71,7 @@ func TestTryStatement_FinallyExceptionReplacesCatchException(t *testing.T) {
 		}
 	`
 	_, panicVal := evalTryCode(t, code)
-	if panicVal != "C" {
+	if ex, ok := panicVal.(*Exception); !ok || ex.Value != "C" {
 		t.Fatalf("expected finally's exception 'C' to win, got %v", panicVal)
 	}
 }
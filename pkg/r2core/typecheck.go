@@ -0,0 +1,1010 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeCheck comprueba las anotaciones de tipo de prog sin ejecutarlo. El tipo
+// de las variables sin anotar se infiere de su valor inicial; lo que no se
+// puede inferir (el resultado de una librería, un parámetro sin anotar, un
+// nombre importado) es any y no se comprueba. Reporta:
+//
+//   - un valor inicial o una asignación que no es del tipo de la variable
+//   - argumentos de más o de un tipo distinto al del parámetro
+//   - un return que no es del tipo de retorno de la función
+//   - aritmética sobre valores que seguro no son números
+//   - nombres de tipo que no son tipos básicos, clases, interfaces ni enums
+func TypeCheck(prog *Program, filename string) []*ParseError {
+	c := &typeChecker{
+		filename:   filename,
+		classes:    map[string]*tcClass{},
+		interfaces: map[string][]string{},
+		enums:      map[string]map[string]bool{},
+		imported:   map[string]bool{},
+	}
+	c.scope = &tcScope{vars: map[string]*tcVar{}}
+	// Las clases de Error predefinidas, que el programa puede redeclarar
+	c.declareTypes(errorPreludeProgram().Statements)
+	c.declareTypes(prog.Statements)
+	c.block(prog.Statements)
+	return c.errs
+}
+
+// tcType son las alternativas de un tipo inferido; nil es any.
+type tcType []string
+
+// tcVar es una variable visible para el checker.
+type tcVar struct {
+	typ      tcType
+	declared *TypeAnnotation // let x: tipo; nil si el tipo es inferido
+	fn       *tcFunc         // función declarada con func, mientras no se reasigne
+	class    *tcClass        // clase declarada, mientras no se reasigne
+	enum     string          // enum declarado
+	scope    *tcScope
+}
+
+// tcScope es un bloque. fn es la función que lo contiene, nil en el
+// programa.
+type tcScope struct {
+	vars  map[string]*tcVar
+	outer *tcScope
+	fn    *tcFunc
+}
+
+// tcFunc es la firma de una función, un método o un constructor.
+type tcFunc struct {
+	name   string
+	params []Parameter
+	ret    *TypeAnnotation
+}
+
+// typed indica si la función tiene alguna anotación de tipo.
+func (f *tcFunc) typed() bool {
+	if f.ret != nil {
+		return true
+	}
+	for _, p := range f.params {
+		if p.Type != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type tcClass struct {
+	name    string
+	parent  string
+	methods map[string]*tcFunc
+	statics map[string]*tcFunc
+	fields  map[string]*TypeAnnotation
+}
+
+type typeChecker struct {
+	filename   string
+	scope      *tcScope
+	classes    map[string]*tcClass
+	interfaces map[string][]string        // Métodos de cada interface
+	enums      map[string]map[string]bool // Miembros de cada enum
+	imported   map[string]bool            // Nombres importados: tipos opacos
+	errs       []*ParseError
+}
+
+// position devuelve la posición de n; las llamadas y los accesos no la
+// guardan y usan la del nombre a la izquierda.
+func position(n Node) *PositionInfo {
+	switch e := n.(type) {
+	case *Identifier:
+		return e.Position
+	case *BinaryExpression:
+		if e.Position != nil {
+			return e.Position
+		}
+		return position(e.Left)
+	case *CallExpression:
+		if e.Position != nil {
+			return e.Position
+		}
+		return position(e.Callee)
+	case *AccessExpression:
+		if e.Position != nil {
+			return e.Position
+		}
+		return position(e.Object)
+	case *IndexExpression:
+		return position(e.Left)
+	}
+	return nil
+}
+
+func (c *typeChecker) errorf(pos *PositionInfo, format string, args ...interface{}) {
+	if pos == nil {
+		pos = &PositionInfo{Filename: c.filename, Line: 1, Col: 1}
+	}
+	c.errs = append(c.errs, &ParseError{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// ------------------------------------------------------------
+// Tipos
+// ------------------------------------------------------------
+
+// declareTypes registra las clases, interfaces, enums e imports del
+// programa antes de comprobarlo: un tipo se puede usar antes de declararlo.
+func (c *typeChecker) declareTypes(stmts []Node) {
+	for _, stmt := range stmts {
+		if es, ok := stmt.(*ExportStatement); ok {
+			stmt = es.Declaration
+		}
+		switch s := stmt.(type) {
+		case *ObjectDeclaration:
+			c.classes[s.Name] = c.newClass(s)
+		case *InterfaceDeclaration:
+			var methods []string
+			for _, m := range s.Methods {
+				methods = append(methods, m.Name)
+			}
+			c.interfaces[s.Name] = methods
+		case *EnumDeclaration:
+			members := map[string]bool{}
+			for _, m := range s.Members {
+				members[m.Name] = true
+			}
+			c.enums[s.Name] = members
+		case *ImportStatement:
+			if s.Alias != "" {
+				c.imported[s.Alias] = true
+			}
+			for _, name := range s.Names {
+				if name.Alias != "" {
+					c.imported[name.Alias] = true
+				} else {
+					c.imported[name.Name] = true
+				}
+			}
+		}
+	}
+}
+
+func (c *typeChecker) newClass(od *ObjectDeclaration) *tcClass {
+	class := &tcClass{name: od.Name, parent: od.ParentName,
+		methods: map[string]*tcFunc{}, statics: map[string]*tcFunc{}, fields: map[string]*TypeAnnotation{}}
+	for _, member := range od.Members {
+		switch m := member.(type) {
+		case *FunctionDeclaration:
+			class.methods[m.Name] = &tcFunc{name: od.Name + "." + m.Name, params: m.Params, ret: m.Return}
+		case *StaticMember:
+			if fd, ok := m.Member.(*FunctionDeclaration); ok {
+				class.statics[fd.Name] = &tcFunc{name: od.Name + "." + fd.Name, params: fd.Params, ret: fd.Return}
+			}
+		case *LetStatement:
+			if m.Type != nil {
+				class.fields[m.Name] = m.Type
+			}
+		case *MultipleLetStatement:
+			for _, d := range m.Declarations {
+				if d.Type != nil {
+					class.fields[d.Name] = d.Type
+				}
+			}
+		}
+	}
+	return class
+}
+
+// known indica si name es un tipo: básico, declarado o importado.
+func (c *typeChecker) known(name string) bool {
+	for {
+		elem, isArray := elementType(name)
+		if !isArray {
+			break
+		}
+		name = elem
+	}
+	if builtinTypes[name] || c.imported[name] || c.classes[name] != nil || collectionTypes[name] != nil {
+		return true
+	}
+	_, isInterface := c.interfaces[name]
+	_, isEnum := c.enums[name]
+	return isInterface || isEnum
+}
+
+// opaque indica si name es un tipo del que el checker no sabe nada: uno
+// importado o uno desconocido, que ya se reportó.
+func (c *typeChecker) opaque(name string) bool {
+	if elem, isArray := elementType(name); isArray {
+		return c.opaque(elem)
+	}
+	if parts := unionParts(name); parts != nil {
+		for _, part := range parts {
+			if c.opaque(part) {
+				return true
+			}
+		}
+		return false
+	}
+	if builtinTypes[name] || c.classes[name] != nil || collectionTypes[name] != nil {
+		return false
+	}
+	_, isInterface := c.interfaces[name]
+	_, isEnum := c.enums[name]
+	return !isInterface && !isEnum
+}
+
+// annotation reporta los nombres de t que no son tipos.
+func (c *typeChecker) annotation(t *TypeAnnotation) {
+	if t == nil {
+		return
+	}
+	for _, name := range t.Types {
+		if !c.known(name) {
+			c.errorf(t.Position, "Unknown type %s", name)
+		}
+	}
+}
+
+// definite indica si t es un tipo conocido: ni any ni opaco.
+func (c *typeChecker) definite(t tcType) bool {
+	if t == nil {
+		return false
+	}
+	for _, name := range t {
+		if name == "any" || c.opaque(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignable indica si un valor de tipo src puede ir donde se espera dst.
+func (c *typeChecker) assignable(src tcType, dst *TypeAnnotation) bool {
+	if dst == nil {
+		return true
+	}
+	for _, s := range src {
+		ok := false
+		for _, d := range dst.Types {
+			if c.subtype(s, d) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *typeChecker) subtype(s, d string) bool {
+	if parts := unionParts(s); parts != nil {
+		for _, part := range parts {
+			if !c.subtype(part, d) {
+				return false
+			}
+		}
+		return true
+	}
+	if s == d || s == "any" || d == "any" || c.opaque(s) || c.opaque(d) {
+		return true
+	}
+	sElem, sArray := elementType(s)
+	dElem, dArray := elementType(d)
+	switch {
+	case sArray && dArray:
+		return c.subtype(sElem, dElem)
+	case sArray:
+		return d == "array"
+	case dArray:
+		// Un array de elementos desconocidos
+		return s == "array"
+	}
+	if c.classes[s] == nil {
+		return false
+	}
+	if methods, isInterface := c.interfaces[d]; isInterface {
+		for _, m := range methods {
+			if fn, ok := c.method(s, m); ok && fn == nil {
+				return false
+			}
+		}
+		return true
+	}
+	for name := s; ; {
+		if name == d {
+			return true
+		}
+		class := c.classes[name]
+		if class == nil {
+			// Hereda de una clase importada
+			return name != s
+		}
+		if class.parent == "" {
+			return false
+		}
+		name = class.parent
+	}
+}
+
+// method busca el método name en la clase y sus padres. ok es false cuando
+// la respuesta no se conoce: un padre importado.
+func (c *typeChecker) method(className, name string) (fn *tcFunc, ok bool) {
+	for class := c.classes[className]; class != nil; class = c.classes[class.parent] {
+		if fn := class.methods[name]; fn != nil {
+			return fn, true
+		}
+		if class.parent == "" {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// field busca la anotación del campo name en la clase y sus padres.
+func (c *typeChecker) field(className, name string) *TypeAnnotation {
+	for class := c.classes[className]; class != nil; class = c.classes[class.parent] {
+		if t := class.fields[name]; t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// union junta las alternativas de a y b; any si alguna es any.
+func union(a, b tcType) tcType {
+	if a == nil || b == nil {
+		return nil
+	}
+	out := append(tcType{}, a...)
+	for _, name := range b {
+		if !contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func contains(t tcType, name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tcType) String() string {
+	if t == nil {
+		return "any"
+	}
+	return strings.Join(t, " | ")
+}
+
+// ------------------------------------------------------------
+// Variables
+// ------------------------------------------------------------
+
+func (c *typeChecker) push(fn *tcFunc) {
+	c.scope = &tcScope{vars: map[string]*tcVar{}, outer: c.scope, fn: fn}
+}
+
+func (c *typeChecker) pop() {
+	c.scope = c.scope.outer
+}
+
+func (c *typeChecker) declare(name string, v *tcVar) {
+	v.scope = c.scope
+	c.scope.vars[name] = v
+}
+
+func (c *typeChecker) lookupVar(name string) *tcVar {
+	for s := c.scope; s != nil; s = s.outer {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// lookup devuelve el tipo de la variable name. Una variable sin anotar de
+// otra función puede haber cambiado antes de la llamada: es any.
+func (c *typeChecker) lookup(name string) tcType {
+	v := c.lookupVar(name)
+	switch {
+	case v == nil:
+		return nil
+	case v.declared != nil:
+		return v.declared.Types
+	case v.fn != nil:
+		return tcType{"function"}
+	case v.scope.fn != c.scope.fn:
+		return nil
+	}
+	return v.typ
+}
+
+// let comprueba una declaración let o const.
+func (c *typeChecker) let(name string, typ *TypeAnnotation, value Node) {
+	var t tcType
+	if value != nil {
+		t = c.expr(value)
+	}
+	c.annotation(typ)
+	if typ != nil && value != nil && !c.assignable(t, typ) {
+		c.errorf(typ.Position, "Cannot assign %s to %s: %s", t, name, typ)
+	}
+	c.declare(name, &tcVar{typ: t, declared: typ})
+}
+
+// assign comprueba una asignación a una variable.
+func (c *typeChecker) assign(id *Identifier, t tcType) {
+	v := c.lookupVar(id.Name)
+	if v == nil {
+		return
+	}
+	v.fn, v.class = nil, nil
+	switch {
+	case v.declared != nil:
+		if !c.assignable(t, v.declared) {
+			c.errorf(id.Position, "Cannot assign %s to %s: %s", t, id.Name, v.declared)
+		}
+	case v.scope == c.scope:
+		v.typ = t
+	default:
+		// Asignada en un bloque anidado: puede valer cualquiera de los dos
+		v.typ = nil
+	}
+}
+
+// widen pasa a any las variables sin anotar que se asignan en stmts, antes
+// de comprobar el cuerpo de un bucle: en la segunda vuelta ya no valen lo
+// que valían al entrar.
+func (c *typeChecker) widen(stmts ...Node) {
+	for _, stmt := range stmts {
+		c.widenNode(stmt)
+	}
+}
+
+func (c *typeChecker) widenNode(n Node) {
+	switch s := n.(type) {
+	case *BlockStatement:
+		if s != nil {
+			c.widen(s.Statements...)
+		}
+	case *GenericAssignStatement:
+		if id, ok := s.Left.(*Identifier); ok {
+			if v := c.lookupVar(id.Name); v != nil && v.declared == nil {
+				v.typ = nil
+			}
+		}
+	case *IfStatement:
+		c.widenNode(s.Consequence)
+		c.widenNode(s.Alternative)
+	case *WhileStatement:
+		c.widenNode(s.Body)
+	case *ForStatement:
+		c.widen(s.Post, s.Body)
+	case *TryStatement:
+		c.widen(s.Body, s.FinallyBlock)
+		for _, cc := range s.Catches {
+			c.widenNode(cc.Body)
+		}
+	case *SwitchStatement:
+		for _, sc := range s.Cases {
+			c.widenNode(sc.Body)
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// Sentencias
+// ------------------------------------------------------------
+
+// block comprueba stmts después de declarar sus funciones y clases, que se
+// pueden usar antes de su declaración.
+func (c *typeChecker) block(stmts []Node) {
+	for _, stmt := range stmts {
+		if es, ok := stmt.(*ExportStatement); ok {
+			stmt = es.Declaration
+		}
+		switch s := stmt.(type) {
+		case *FunctionDeclaration:
+			c.declare(s.Name, &tcVar{fn: &tcFunc{name: s.Name, params: s.Params, ret: s.Return}})
+		case *ObjectDeclaration:
+			class := c.classes[s.Name]
+			if class == nil {
+				// Una clase declarada dentro de una función
+				class = c.newClass(s)
+				c.classes[s.Name] = class
+			}
+			c.declare(s.Name, &tcVar{class: class})
+		case *EnumDeclaration:
+			c.declare(s.Name, &tcVar{enum: s.Name})
+		}
+	}
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+	}
+}
+
+func (c *typeChecker) nested(b *BlockStatement) {
+	if b == nil {
+		return
+	}
+	c.push(c.scope.fn)
+	c.block(b.Statements)
+	c.pop()
+}
+
+func (c *typeChecker) stmt(n Node) {
+	switch s := n.(type) {
+	case *ExportStatement:
+		c.stmt(s.Declaration)
+	case *ExprStatement:
+		c.expr(s.Expr)
+	case *LetStatement:
+		c.let(s.Name, s.Type, s.Value)
+	case *MultipleLetStatement:
+		for _, d := range s.Declarations {
+			c.let(d.Name, d.Type, d.Value)
+		}
+	case *ConstStatement:
+		c.let(s.Name, s.Type, s.Value)
+	case *MultipleConstStatement:
+		for _, d := range s.Declarations {
+			c.let(d.Name, d.Type, d.Value)
+		}
+	case *ArrayDestructuring:
+		c.expr(s.Value)
+		for _, name := range s.Names {
+			c.declare(name, &tcVar{})
+		}
+	case *ObjectDestructuring:
+		c.expr(s.Value)
+		for _, name := range s.Names {
+			c.declare(name, &tcVar{})
+		}
+	case *GenericAssignStatement:
+		t := c.expr(s.Right)
+		c.assignTo(s.Left, t)
+	case *BlockStatement:
+		c.nested(s)
+	case *IfStatement:
+		c.expr(s.Condition)
+		c.nested(s.Consequence)
+		c.nested(s.Alternative)
+	case *WhileStatement:
+		c.widen(s.Body)
+		c.expr(s.Condition)
+		c.nested(s.Body)
+	case *ForStatement:
+		c.forStmt(s)
+	case *SwitchStatement:
+		c.expr(s.Value)
+		for _, sc := range s.Cases {
+			c.nested(sc.Body)
+		}
+	case *TryStatement:
+		c.nested(s.Body)
+		for _, cc := range s.Catches {
+			c.annotation(cc.Type)
+			c.push(c.scope.fn)
+			c.declare(cc.Var, &tcVar{declared: cc.Type})
+			c.block(cc.Body.Statements)
+			c.pop()
+		}
+		c.nested(s.FinallyBlock)
+	case *ReturnStatement:
+		c.returnStmt(s)
+	case *FunctionDeclaration:
+		c.function(s.Name, s.Params, s.Return, s.Body, nil)
+	case *ObjectDeclaration:
+		c.classDecl(s)
+	case *InterfaceDeclaration:
+		for _, m := range s.Methods {
+			c.params(m.Params)
+			c.annotation(m.Return)
+			if m.Body != nil {
+				c.function(s.Name+"."+m.Name, m.Params, m.Return, m.Body, nil)
+			}
+		}
+	case *EnumDeclaration:
+		for _, m := range s.Members {
+			if m.Value != nil {
+				c.expr(m.Value)
+			}
+		}
+	case *ImportStatement:
+		if s.Alias != "" {
+			c.declare(s.Alias, &tcVar{})
+		}
+		for _, name := range s.Names {
+			alias := name.Alias
+			if alias == "" {
+				alias = name.Name
+			}
+			c.declare(alias, &tcVar{})
+		}
+	}
+}
+
+func (c *typeChecker) forStmt(s *ForStatement) {
+	c.push(c.scope.fn)
+	defer c.pop()
+	if s.inFlag {
+		if s.inExpr != nil {
+			c.expr(s.inExpr)
+		}
+		c.declare(s.inIndexName, &tcVar{})
+	} else if s.Init != nil {
+		c.stmt(s.Init)
+	}
+	c.widen(s.Post, s.Body)
+	if s.Condition != nil {
+		c.expr(s.Condition)
+	}
+	c.nested(s.Body)
+	if s.Post != nil {
+		c.stmt(s.Post)
+	}
+}
+
+// assignTo comprueba la asignación de un valor de tipo t a left.
+func (c *typeChecker) assignTo(left Node, t tcType) {
+	switch l := left.(type) {
+	case *Identifier:
+		c.assign(l, t)
+	case *AccessExpression:
+		obj := c.expr(l.Object)
+		if len(obj) != 1 {
+			return
+		}
+		if typ := c.field(obj[0], l.Member); typ != nil && !c.assignable(t, typ) {
+			c.errorf(position(l), "Cannot assign %s to %s.%s: %s", t, obj[0], l.Member, typ)
+		}
+	default:
+		c.expr(left)
+	}
+}
+
+func (c *typeChecker) returnStmt(s *ReturnStatement) {
+	t := tcType{"nil"}
+	if s.Value != nil {
+		t = c.expr(s.Value)
+	}
+	fn := c.scope.fn
+	if fn != nil && fn.ret != nil && !c.assignable(t, fn.ret) {
+		c.errorf(s.Position, "%s must return %s, got %s", fn.name, fn.ret, t)
+	}
+}
+
+// params reporta los tipos desconocidos de params y los valores por defecto
+// que no son del tipo del parámetro.
+func (c *typeChecker) params(params []Parameter) {
+	for _, p := range params {
+		c.annotation(p.Type)
+		if p.DefaultValue == nil {
+			continue
+		}
+		if t := c.expr(p.DefaultValue); p.Type != nil && !c.assignable(t, p.Type) {
+			c.errorf(p.Type.Position, "Cannot assign %s to %s: %s", t, p.Name, p.Type)
+		}
+	}
+}
+
+// function comprueba el cuerpo de una función en su propio scope. En un
+// método, class es la clase de self.
+func (c *typeChecker) function(name string, params []Parameter, ret *TypeAnnotation, body *BlockStatement, class *tcClass) {
+	fn := &tcFunc{name: name, params: params, ret: ret}
+	c.push(fn)
+	defer c.pop()
+	c.params(params)
+	c.annotation(ret)
+	for _, p := range params {
+		c.declare(p.Name, &tcVar{declared: p.Type})
+	}
+	if class != nil {
+		self := &TypeAnnotation{Types: []string{class.name}}
+		c.declare("self", &tcVar{declared: self})
+		c.declare("this", &tcVar{declared: self})
+		c.declare("super", &tcVar{})
+	}
+	if body != nil {
+		c.block(body.Statements)
+	}
+}
+
+func (c *typeChecker) classDecl(od *ObjectDeclaration) {
+	class := c.classes[od.Name]
+	for _, member := range od.Members {
+		switch m := member.(type) {
+		case *FunctionDeclaration:
+			c.function(class.name+"."+m.Name, m.Params, m.Return, m.Body, class)
+		case *StaticMember:
+			if fd, ok := m.Member.(*FunctionDeclaration); ok {
+				c.function(class.name+"."+fd.Name, fd.Params, fd.Return, fd.Body, nil)
+			}
+		case *AccessorDeclaration:
+			c.function(class.name+"."+m.Func.Name, m.Func.Params, m.Func.Return, m.Func.Body, class)
+		case *LetStatement:
+			c.push(nil)
+			c.let(m.Name, m.Type, m.Value)
+			c.pop()
+		case *MultipleLetStatement:
+			c.push(nil)
+			for _, d := range m.Declarations {
+				c.let(d.Name, d.Type, d.Value)
+			}
+			c.pop()
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// Expresiones
+// ------------------------------------------------------------
+
+// expr comprueba n y devuelve su tipo; nil si no se puede inferir.
+func (c *typeChecker) expr(n Node) tcType {
+	switch e := n.(type) {
+	case *NumberLiteral:
+		return tcType{"number"}
+	case *StringLiteral:
+		return tcType{"string"}
+	case *TemplateString:
+		for _, part := range e.Parts {
+			if part.IsExpression {
+				c.expr(part.Expression)
+			}
+		}
+		return tcType{"string"}
+	case *BooleanLiteral:
+		return tcType{"bool"}
+	case *NilLiteral:
+		return tcType{"nil"}
+	case *ArrayLiteral:
+		return c.array(e)
+	case *MapLiteral:
+		for _, pair := range e.Pairs {
+			c.expr(pair.Value)
+		}
+		return tcType{"map"}
+	case *FunctionLiteral:
+		c.function("<anonymous>", e.Params, e.Return, e.Body, nil)
+		return tcType{"function"}
+	case *ArrowFunction:
+		if body, ok := e.Body.(*BlockStatement); ok && !e.IsExpression {
+			c.function("<anonymous>", e.Params, nil, body, nil)
+		} else {
+			c.push(&tcFunc{name: "<anonymous>", params: e.Params})
+			c.params(e.Params)
+			for _, p := range e.Params {
+				c.declare(p.Name, &tcVar{declared: p.Type})
+			}
+			c.expr(e.Body)
+			c.pop()
+		}
+		return tcType{"function"}
+	case *Identifier:
+		return c.lookup(e.Name)
+	case *BinaryExpression:
+		return c.binary(e)
+	case *UnaryExpression:
+		t := c.expr(e.Right)
+		switch e.Operator {
+		case "!":
+			return tcType{"bool"}
+		case "-":
+			if len(t) == 1 && t[0] == "number" {
+				return t
+			}
+		}
+		return nil
+	case *TernaryExpression:
+		c.expr(e.Condition)
+		return union(c.expr(e.TrueExpr), c.expr(e.FalseExpr))
+	case *CallExpression:
+		return c.call(e)
+	case *AccessExpression:
+		return c.access(e)
+	case *IndexExpression:
+		t := c.expr(e.Left)
+		c.expr(e.Index)
+		if len(t) == 1 {
+			if elem, isArray := elementType(t[0]); isArray {
+				if parts := unionParts(elem); parts != nil {
+					return parts
+				}
+				return tcType{elem}
+			}
+			if t[0] == "string" {
+				return t
+			}
+		}
+		return nil
+	case *AwaitExpression:
+		c.expr(e.Value)
+	case *SpreadExpression:
+		c.expr(e.Value)
+	case *OptionalAccessExpression:
+		c.expr(e.Object)
+	case *OptionalIndexExpression:
+		c.expr(e.Object)
+		c.expr(e.Index)
+	case *YieldExpression:
+		if e.Value != nil {
+			c.expr(e.Value)
+		}
+	}
+	return nil
+}
+
+// array infiere "T[]" cuando todos los elementos son de un mismo tipo T, y
+// "(T | U)[]" cuando son de varios tipos conocidos, para que [1, "x"] no
+// pase por un number[].
+func (c *typeChecker) array(e *ArrayLiteral) tcType {
+	var elems tcType
+	known := true
+	for _, el := range e.Elements {
+		t := c.expr(el)
+		if _, spread := el.(*SpreadExpression); spread || len(t) != 1 || !c.definite(t) || unionParts(t[0]) != nil {
+			known = false
+			continue
+		}
+		if !contains(elems, t[0]) {
+			elems = append(elems, t[0])
+		}
+	}
+	switch {
+	case !known || len(elems) == 0:
+		return tcType{"array"}
+	case len(elems) == 1:
+		return tcType{elems[0] + "[]"}
+	}
+	return tcType{"(" + elems.String() + ")[]"}
+}
+
+// unionParts devuelve las alternativas de un tipo de elemento inferido como
+// "(T | U)", o nil si name no es una unión.
+func unionParts(name string) []string {
+	if !strings.HasPrefix(name, "(") || !strings.HasSuffix(name, ")") {
+		return nil
+	}
+	return strings.Split(name[1:len(name)-1], " | ")
+}
+
+func (c *typeChecker) binary(e *BinaryExpression) tcType {
+	l, r := c.expr(e.Left), c.expr(e.Right)
+	number := tcType{"number"}
+	isNumber := func(t tcType) bool { return len(t) == 1 && t[0] == "number" }
+	switch e.Op {
+	case "+":
+		switch {
+		case isNumber(l) && isNumber(r):
+			return number
+		case len(l) == 1 && l[0] == "string", len(r) == 1 && r[0] == "string":
+			return tcType{"string"}
+		}
+	case "-", "*", "/", "%", "**":
+		c.numeric(position(e), e.Op, l)
+		c.numeric(position(e), e.Op, r)
+		if isNumber(l) && isNumber(r) {
+			return number
+		}
+	case "==", "!=", "<", ">", "<=", ">=", "instanceof", "in":
+		return tcType{"bool"}
+	}
+	return nil
+}
+
+// numeric reporta un operando aritmético que seguro no es un número.
+func (c *typeChecker) numeric(pos *PositionInfo, op string, t tcType) {
+	if !c.definite(t) {
+		return
+	}
+	for _, name := range t {
+		switch name {
+		case "number", "bigint", "decimal":
+			return
+		}
+	}
+	c.errorf(pos, "Operator %s expects numbers, got %s", op, t)
+}
+
+func (c *typeChecker) access(e *AccessExpression) tcType {
+	if id, ok := e.Object.(*Identifier); ok {
+		if v := c.lookupVar(id.Name); v != nil && v.enum != "" {
+			if c.enums[v.enum][e.Member] {
+				return tcType{v.enum}
+			}
+			return nil
+		}
+	}
+	obj := c.expr(e.Object)
+	if len(obj) != 1 || c.classes[obj[0]] == nil {
+		return nil
+	}
+	if typ := c.field(obj[0], e.Member); typ != nil {
+		return typ.Types
+	}
+	if fn, _ := c.method(obj[0], e.Member); fn != nil {
+		return tcType{"function"}
+	}
+	return nil
+}
+
+// call comprueba los argumentos de una llamada a una función, un método o
+// un constructor conocidos, y devuelve su tipo de retorno.
+func (c *typeChecker) call(e *CallExpression) tcType {
+	var sig *tcFunc
+	var result tcType
+	switch callee := e.Callee.(type) {
+	case *Identifier:
+		if v := c.lookupVar(callee.Name); v != nil {
+			switch {
+			case v.fn != nil:
+				sig = v.fn
+			case v.class != nil:
+				sig, _ = c.method(v.class.name, "constructor")
+				result = tcType{v.class.name}
+			}
+		} else if collectionTypes[callee.Name] != nil {
+			// Set(), Map(), ImmutableList(), ImmutableMap()
+			result = tcType{callee.Name}
+		}
+	case *AccessExpression:
+		if id, ok := callee.Object.(*Identifier); ok {
+			if v := c.lookupVar(id.Name); v != nil && v.class != nil {
+				sig = v.class.statics[callee.Member]
+				break
+			}
+		}
+		obj := c.expr(callee.Object)
+		if len(obj) == 1 && c.classes[obj[0]] != nil {
+			sig, _ = c.method(obj[0], callee.Member)
+		}
+	default:
+		c.expr(e.Callee)
+	}
+
+	args := make([]tcType, len(e.Args))
+	spread := false
+	for i, arg := range e.Args {
+		args[i] = c.expr(arg)
+		if _, ok := arg.(*SpreadExpression); ok {
+			spread = true
+		}
+	}
+	if sig == nil {
+		return result
+	}
+	if !spread {
+		c.arguments(e, sig, args)
+	}
+	if result == nil && sig.ret != nil {
+		result = sig.ret.Types
+	}
+	return result
+}
+
+func (c *typeChecker) arguments(e *CallExpression, sig *tcFunc, args []tcType) {
+	if sig.typed() && len(args) > len(sig.params) {
+		c.errorf(position(e), "%s takes %d argument(s), got %d", sig.name, len(sig.params), len(args))
+	}
+	for i, p := range sig.params {
+		if p.Type == nil {
+			continue
+		}
+		if i >= len(args) {
+			if !p.Optional && p.DefaultValue == nil && !c.assignable(tcType{"nil"}, p.Type) {
+				c.errorf(position(e), "%s: missing argument %s: %s", sig.name, p.Name, p.Type)
+			}
+			continue
+		}
+		if p.Optional && len(args[i]) == 1 && args[i][0] == "nil" {
+			continue
+		}
+		if !c.assignable(args[i], p.Type) {
+			c.errorf(position(e), "%s: argument %s must be %s, got %s", sig.name, p.Name, p.Type, args[i])
+		}
+	}
+}
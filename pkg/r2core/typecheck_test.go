@@ -0,0 +1,115 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func typeCheck(t *testing.T, code string) []string {
+	t.Helper()
+	prog, errs := ParseWithErrors(code, "main.r2")
+	if len(errs) > 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	var out []string
+	for _, err := range TypeCheck(prog, "main.r2") {
+		out = append(out, fmt.Sprintf("%d: %s", err.Position.Line, err.Message))
+	}
+	return out
+}
+
+func TestTypeCheck_Reports(t *testing.T) {
+	code := typedCode + `
+let a: number = "x"
+let n: number = 0
+n = "z"
+total([Circle(1), 2])
+total([Circle(1)], "2", 3)
+Circle("r")
+paint(1)
+let w: Widget = nil
+func bad(): string { return 1 }
+let k = true - 1
+let c = Circle(2)
+c.r = "big"
+let s = "s"
+paint(Color.Red, s + 1)
+s = 2
+paint(Color.Red, s)
+try { paint(Color.Red) } catch (e: Oops) { }
+`
+	want := []string{
+		"16: Cannot assign string to a: number",
+		"18: Cannot assign string to n: number",
+		"19: total: argument shapes must be Shape[], got (Circle | number)[]",
+		"20: total takes 2 argument(s), got 3",
+		"20: total: argument scale must be number, got string",
+		"21: Circle.constructor: argument r must be number, got string",
+		"22: paint: argument c must be Color, got number",
+		"23: Unknown type Widget",
+		"24: bad must return string, got number",
+		"25: Operator - expects numbers, got bool",
+		"27: Cannot assign string to Circle.r: number",
+		"31: paint: argument label must be string | nil, got number",
+		"32: Unknown type Oops",
+	}
+	got := typeCheck(t, code)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestTypeCheck_Declarations(t *testing.T) {
+	code := `
+let bad: number[] = [1, "x"]
+const names: string[] = ["a", 2], ok: number[] = [1, 2]
+let any: array = [1, "x"]
+let items = [1, "x"]
+let first: number | string = items[0]
+let n: number = items[1]
+`
+	want := []string{
+		"2: Cannot assign (number | string)[] to bad: number[]",
+		"3: Cannot assign (string | number)[] to names: string[]",
+		"7: Cannot assign number | string to n: number",
+	}
+	got := typeCheck(t, code)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestTypeCheck_Inference(t *testing.T) {
+	code := `
+func num(x: number): number { return x }
+class Animal { speak(): string { return "" } }
+class Dog extends Animal { }
+interface Speaker { speak() }
+func talk(s: Speaker, a: Animal) { return s.speak() + a.speak() }
+
+let xs = [1, 2, 3]
+num(xs[0])
+talk(Dog(), Dog())
+let x = nil
+while (true) {
+	if (x != nil) { num(x) }
+	x = 5
+}
+let y = "a"
+if (true) { y = 1 }
+num(y)
+let z = "a"
+func later() { return num(z) }
+num(std.len(xs))
+num(-xs[1])
+import "./util.r2" as u
+let p: u = nil
+class AppError extends Error { }
+func fail(e: Error) { throw e }
+try { fail(AppError("x")) } catch (e: TypeError | AppError) { fail(e) }
+`
+	if got := typeCheck(t, code); len(got) != 0 {
+		t.Errorf("expected no errors, got:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,229 @@
+package r2core
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// TypeAnnotation es una anotación de tipo opcional:
+//
+//	let total: number = 0
+//	func find(items: Item[], name?: string): Item | nil { ... }
+//
+// Types son las alternativas de la unión; cada "[]" es un array de lo que
+// lo precede. Al ejecutar las anotaciones se ignoran salvo en modo estricto
+// (SetStrictTypes), que comprueba los argumentos y el resultado de cada
+// llamada y el valor inicial de let y const; TypeCheck las comprueba sin
+// ejecutar.
+type TypeAnnotation struct {
+	Position *PositionInfo
+	Types    []string
+}
+
+func (t *TypeAnnotation) String() string {
+	return strings.Join(t.Types, " | ")
+}
+
+// builtinTypes son los nombres de tipo que no son clases, interfaces ni
+// enums.
+var builtinTypes = map[string]bool{
+	"any": true, "number": true, "string": true, "bool": true, "nil": true,
+	"array": true, "map": true, "function": true, "bigint": true, "decimal": true,
+}
+
+// elementType devuelve el tipo de los elementos de name si es un array
+// ("number[]" -> "number").
+func elementType(name string) (string, bool) {
+	return strings.CutSuffix(name, "[]")
+}
+
+// SetStrictTypes activa o desactiva la comprobación de las anotaciones de
+// tipo en cada llamada.
+func (e *Environment) SetStrictTypes(strict bool) {
+	e.strictTypes = &strict
+}
+
+// StrictTypes indica si este entorno, o el exterior que lo define, comprueba
+// las anotaciones de tipo al llamar.
+func (e *Environment) StrictTypes() bool {
+	for env := e; env != nil; env = env.outer {
+		if env.strictTypes != nil {
+			return *env.strictTypes
+		}
+	}
+	return false
+}
+
+// hasType indica si value es de alguno de los tipos de t. Los nombres que no
+// son de builtinTypes se buscan en env: una clase, una interface o un enum.
+func (t *TypeAnnotation) hasType(value interface{}, env *Environment) bool {
+	for _, name := range t.Types {
+		if valueHasType(value, name, env) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueHasType(value interface{}, name string, env *Environment) bool {
+	if elem, isArray := elementType(name); isArray {
+		items, ok := arrayItems(value)
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			if !valueHasType(item, elem, env) {
+				return false
+			}
+		}
+		return true
+	}
+	switch name {
+	case "any":
+		return true
+	case "nil":
+		return value == nil
+	case "number":
+		switch value.(type) {
+		case float64, int, int64:
+			return true
+		}
+		return false
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := arrayItems(value)
+		return ok
+	case "map":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "function":
+		switch value.(type) {
+		case *UserFunction, BuiltinFunction:
+			return true
+		}
+		return value != nil && reflect.TypeOf(value).Kind() == reflect.Func
+	case "bigint":
+		_, ok := value.(*big.Int)
+		return ok
+	case "decimal":
+		_, ok := value.(*DecimalValue)
+		return ok
+	}
+	class, _ := env.Get(name)
+	switch class.(type) {
+	case map[string]interface{}, *InterfaceType, *EnumType, *CollectionType:
+		return value != nil && instanceOf(value, class)
+	}
+	panic(fmt.Sprintf("Unknown type %s", name))
+}
+
+// arrayItems devuelve los elementos de un array de R2.
+func arrayItems(value interface{}) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case InterfaceSlice:
+		return v, true
+	}
+	return nil, false
+}
+
+// checkArgs comprueba, en modo estricto, los count argumentos de la llamada
+// y los parámetros anotados de uf ya ligados en env. Sobrar o faltar un
+// argumento lanza un ArgumentError; uno del tipo equivocado, un TypeError.
+func (uf *UserFunction) checkArgs(name string, count int, env *Environment) {
+	if count > len(uf.Params) {
+		ThrowError(env, "ArgumentError", fmt.Sprintf("%s takes %d argument(s), got %d", name, len(uf.Params), count))
+	}
+	for i, param := range uf.Params {
+		if param.Type == nil {
+			continue
+		}
+		if i >= count && param.DefaultValue == nil && !param.Optional {
+			ThrowError(env, "ArgumentError", fmt.Sprintf("%s: missing argument %s: %s", name, param.Name, param.Type))
+		}
+		val, _ := env.Get(param.Name)
+		if val == nil && param.Optional {
+			continue
+		}
+		if !param.Type.hasType(val, env) {
+			ThrowError(env, "TypeError", fmt.Sprintf("%s: argument %s must be %s, got %s", name, param.Name, param.Type, typeName(val)))
+		}
+	}
+}
+
+// checkResult comprueba, en modo estricto, el resultado de uf contra su tipo
+// de retorno; si no lo cumple lanza un TypeError.
+func (uf *UserFunction) checkResult(name string, val interface{}, env *Environment) {
+	if !uf.ReturnType.hasType(val, env) {
+		ThrowError(env, "TypeError", fmt.Sprintf("%s: must return %s, got %s", name, uf.ReturnType, typeName(val)))
+	}
+}
+
+// checkDeclared comprueba, en modo estricto, el valor inicial de una variable
+// anotada (let nombre: tipo = valor); si no lo cumple lanza un TypeError.
+func checkDeclared(env *Environment, name string, typ *TypeAnnotation, val interface{}) {
+	if typ != nil && env.StrictTypes() && !typ.hasType(val, env) {
+		ThrowError(env, "TypeError", fmt.Sprintf("Cannot assign %s to %s: %s", typeName(val), name, typ))
+	}
+}
+
+// typed indica si uf tiene alguna anotación de tipo que comprobar.
+func (uf *UserFunction) typed() bool {
+	if uf.ReturnType != nil {
+		return true
+	}
+	for _, param := range uf.Params {
+		if param.Type != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName es el nombre de tipo de value en los mensajes de error: el de
+// una anotación que lo describe.
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case float64, int, int64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []interface{}, InterfaceSlice:
+		return "array"
+	case map[string]interface{}:
+		return "map"
+	case *UserFunction, BuiltinFunction:
+		return "function"
+	case *big.Int:
+		return "bigint"
+	case *DecimalValue:
+		return "decimal"
+	case *ObjectInstance:
+		if name, ok := v.Class["ClassName"].(string); ok {
+			return name
+		}
+	case *EnumValue:
+		return v.Enum.Name
+	case *Set:
+		return "Set"
+	case *OrderedMap:
+		return "Map"
+	case *ImmutableList:
+		return "ImmutableList"
+	case *ImmutableMap:
+		return "ImmutableMap"
+	}
+	return typeof(value)
+}
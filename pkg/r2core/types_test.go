@@ -0,0 +1,134 @@
+package r2core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func evalTyped(t *testing.T, code string, strict bool) interface{} {
+	t.Helper()
+	env := newTestEnv()
+	env.SetStrictTypes(strict)
+	return NewParser(code).ParseProgram().Eval(env)
+}
+
+const typedCode = `
+	class Circle {
+		let r: number
+		constructor(r: number) { this.r = r }
+		area(): number { return this.r * this.r }
+	}
+	interface Shape { area() }
+	enum Color { Red, Green }
+	func total(shapes: Shape[], scale?: number): number {
+		let sum = 0
+		for (i in shapes) { sum = sum + $v.area() }
+		return sum * (scale ?? 1)
+	}
+	func paint(c: Color, label: string | nil = nil): string { return c.name }
+`
+
+func TestTypes_Parse(t *testing.T) {
+	prog := NewParser(`func f(x: number, y?: string[] | nil = nil): map { return {} }
+		let a: Item = nil, b = 1
+		const c: bool = true`).ParseProgram()
+	fd := prog.Statements[0].(*FunctionDeclaration)
+	if p := fd.Params[1]; p.Name != "y" || !p.Optional || p.Type.String() != "string[] | nil" || p.DefaultValue == nil {
+		t.Errorf("unexpected parameter %+v", p)
+	}
+	if fd.Params[0].Type.String() != "number" || fd.Params[0].Optional || fd.Return.String() != "map" {
+		t.Errorf("unexpected signature %+v -> %v", fd.Params[0], fd.Return)
+	}
+	let := prog.Statements[1].(*MultipleLetStatement)
+	if let.Declarations[0].Type.String() != "Item" || let.Declarations[1].Type != nil {
+		t.Errorf("unexpected let types %+v", let.Declarations)
+	}
+	if cs := prog.Statements[2].(*ConstStatement); cs.Type.String() != "bool" {
+		t.Errorf("unexpected const type %v", cs.Type)
+	}
+}
+
+func TestTypes_IgnoredByDefault(t *testing.T) {
+	got := evalTyped(t, typedCode+`
+		func wrong(x: number): string { return x }
+		[wrong("a"), Circle("r").r]
+	`, false)
+	if s := strings.Join(toStrings(got.([]interface{})), ","); s != "a,r" {
+		t.Errorf("expected the annotations to be ignored, got %s", s)
+	}
+}
+
+func TestTypes_Strict(t *testing.T) {
+	got := evalTyped(t, typedCode+`
+		[total([Circle(2), Circle(1)], 2), total([]), paint(Color.Green), paint(Color.Red, "r")]
+	`, true)
+	if s := strings.Join(toStrings(got.([]interface{})), ","); s != "10,0,Green,Red" {
+		t.Errorf("expected the well-typed calls to run, got %s", s)
+	}
+
+	tests := []struct {
+		call string
+		want string
+	}{
+		{`total([Circle(1)], "2")`, "total: argument scale must be number, got string"},
+		{`total([1])`, "total: argument shapes must be Shape[], got array"},
+		{`total({})`, "total: argument shapes must be Shape[], got map"},
+		{`Circle("r")`, "argument r must be number, got string"},
+		{`paint("Red")`, "paint: argument c must be Color, got string"},
+		{`paint(Color.Red, 1)`, "paint: argument label must be string | nil, got number"},
+		{`func wrong(): string { return 1 } wrong()`, "wrong: must return string, got number"},
+		{`func box(): Circle { return nil } box()`, "box: must return Circle, got nil"},
+		{`func f(x: Widget) {} f(1)`, "Unknown type Widget"},
+	}
+	for _, tt := range tests {
+		msg := recoverMessage(func() { evalTyped(t, typedCode+tt.call, true) })
+		if !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.call, tt.want, msg)
+		}
+	}
+}
+
+// En modo estricto el valor inicial de un let o const anotado se comprueba
+// igual en el tree-walker y en la VM, también dentro de un frame.
+func TestTypes_StrictDeclarations(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`let bad: number[] = [1, "x"]`, "Cannot assign array to bad: number[]"},
+		{`const c: string = 1`, "Cannot assign number to c: string"},
+		{`let a = 1, b: string = 2`, "Cannot assign number to b: string"},
+		{`const x: number = 1, y: bool = "no"`, "Cannot assign string to y: bool"},
+		{`func f() { let n: number = "s"; return n } f()`, "Cannot assign string to n: number"},
+	}
+	for _, tt := range tests {
+		for _, compiled := range []bool{false, true} {
+			msg := recoverMessage(func() {
+				env := newTestEnv()
+				env.SetStrictTypes(true)
+				prog := NewParser(tt.code).ParseProgram()
+				if compiled {
+					Compile(prog).Eval(env)
+				} else {
+					prog.Eval(env)
+				}
+			})
+			if !strings.Contains(msg, tt.want) {
+				t.Errorf("%s (compiled %v): expected %q, got %q", tt.code, compiled, tt.want, msg)
+			}
+		}
+	}
+	got := evalTyped(t, `let xs: number[] = [1, 2]; let s: string | nil = nil; let later: number; [xs, s, later]`, true)
+	if fmt.Sprint(got) != "[[1 2] <nil> <nil>]" {
+		t.Errorf("expected the well-typed declarations to run, got %v", got)
+	}
+}
+
+func toStrings(values []interface{}) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
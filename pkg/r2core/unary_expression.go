@@ -1,6 +1,9 @@
 package r2core
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+)
 
 // UnaryExpression represents unary operations like !expr, -expr, +expr
 type UnaryExpression struct {
@@ -9,8 +12,11 @@ type UnaryExpression struct {
 }
 
 func (ue *UnaryExpression) Eval(env *Environment) interface{} {
-	right := ue.Right.Eval(env)
+	return ue.apply(ue.Right.Eval(env))
+}
 
+// apply aplica el operador a un operando ya evaluado (compartido con la VM).
+func (ue *UnaryExpression) apply(right interface{}) interface{} {
 	switch ue.Operator {
 	case "!":
 		return !isTruthy(right)
@@ -21,6 +27,8 @@ func (ue *UnaryExpression) Eval(env *Environment) interface{} {
 			return -val
 		case int:
 			return -float64(val)
+		case int64, *big.Int, *DecimalValue:
+			return negateNumber(val)
 		default:
 			panic(fmt.Sprintf("Invalid operand for unary minus: expected number, got %s (value: %v)", typeof(val), val))
 		}
@@ -31,6 +39,8 @@ func (ue *UnaryExpression) Eval(env *Environment) interface{} {
 			return val
 		case int:
 			return float64(val)
+		case int64, *big.Int, *DecimalValue:
+			return val
 		case string:
 			// Try to convert string to number using existing toFloat function
 			return toFloat(val)
@@ -44,6 +54,10 @@ func (ue *UnaryExpression) Eval(env *Environment) interface{} {
 			return float64(^int64(val))
 		case int:
 			return float64(^int64(val))
+		case int64:
+			return ^val
+		case *big.Int:
+			return new(big.Int).Not(val)
 		default:
 			// Try to convert to number first
 			num := toFloat(val)
@@ -65,6 +79,8 @@ func isTruthy(obj interface{}) bool {
 		return obj != 0
 	case float64:
 		return obj != 0.0
+	case int64, *big.Int, *DecimalValue:
+		return !exactZero(obj)
 	case string:
 		return obj != ""
 	case []interface{}:
@@ -3,72 +3,85 @@ package r2core
 // Parameter represents a function parameter with optional default value
 type Parameter struct {
 	Name         string
-	DefaultValue Node // nil if no default value
+	DefaultValue Node            // nil if no default value
+	Type         *TypeAnnotation // nil if not annotated
+	Optional     bool            // name?: type
 }
 
 type UserFunction struct {
-	Args     []string    // For backward compatibility
-	Params   []Parameter // New parameter structure with default values
-	Body     *BlockStatement
-	Env      *Environment
-	IsMethod bool
-	code     string
-	position *PositionInfo
+	Args        []string    // For backward compatibility
+	Params      []Parameter // New parameter structure with default values
+	Body        *BlockStatement
+	Env         *Environment
+	IsMethod    bool
+	IsAsync     bool            // async func: cada llamada devuelve una Promise
+	IsGenerator bool            // func*: cada llamada devuelve un GeneratorObject
+	ReturnType  *TypeAnnotation // func f(): type; nil si no está anotado
+	code        string
+	position    *PositionInfo
 }
 
 func (uf *UserFunction) NativeCall(currentEnv *Environment, args ...interface{}) interface{} {
+	if uf.IsGenerator {
+		// El cuerpo no corre hasta el primer next()
+		return newGenerator(uf, currentEnv, args)
+	}
+	if uf.IsAsync {
+		// El cuerpo corre en su propio goroutine; el llamador recibe la promesa
+		return Async(func() interface{} {
+			return uf.call(currentEnv, args)
+		})
+	}
+	return uf.call(currentEnv, args)
+}
+
+// call ejecuta el cuerpo de uf y, como un trampolín, las llamadas en
+// posición de cola que devuelva (ver tailCall): cada una reemplaza al frame
+// anterior en lugar de anidarse.
+func (uf *UserFunction) call(currentEnv *Environment, args []interface{}) interface{} {
+	return trampoline(uf.activate(currentEnv, args))
+}
+
+// trampoline ejecuta las llamadas en posición de cola que devuelve val, hasta
+// llegar a un valor que no lo es.
+func trampoline(val interface{}) interface{} {
+	for {
+		tc, ok := val.(*tailCall)
+		if !ok {
+			return val
+		}
+		val = tc.fn.activate(nil, tc.args)
+	}
+}
+
+// activate ejecuta una llamada a uf con su frame. Puede devolver el
+// *tailCall de un return en posición de cola, que ejecuta call.
+func (uf *UserFunction) activate(currentEnv *Environment, args []interface{}) interface{} {
+	if currentEnv == nil {
+		if code := uf.frameCode(); code != nil {
+			vs := &vmStack{values: append(make([]interface{}, 0, 16), args...)}
+			return uf.runFrame(vs, code, 0, args)
+		}
+	}
+
 	newEnv := currentEnv
 	if newEnv == nil {
-		newEnv = NewInnerEnv(uf.Env)
+		newEnv = newScopedEnv(uf.Env, uf.Body.scope)
 	} else {
 		// currentEnv is only passed here for super calls; a fresh scope keeps
 		// parameter bindings and the self/super rebinding below from leaking
 		// back into the caller's environment.
-		newEnv = NewInnerEnv(currentEnv)
+		newEnv = newScopedEnv(currentEnv, uf.Body.scope)
 	}
 
 	// Add function to R2Lang call stack for error tracing
-	functionName := uf.code
-	if functionName == "" {
-		if uf.IsMethod {
-			functionName = "<method>"
-		} else {
-			functionName = "<anonymous>"
-		}
-	}
-
-	// Use function position if available, otherwise create basic position info
-	pos := uf.position
-	if pos == nil && newEnv.CurrentFile != "" {
-		pos = &PositionInfo{
-			Filename: newEnv.CurrentFile,
-			Line:     0,
-			Col:      0,
-		}
-	}
-
-	// Push frame to call stack
+	functionName, pos := uf.frameInfo(newEnv)
 	newEnv.callStack.PushFrame(functionName, pos, args)
 	defer newEnv.callStack.PopFrame()
 
-	// Detección de recursión infinita
 	limiter := newEnv.GetLimiter()
 	if limiter.Enabled {
-		// Verificar límite de profundidad de recursión
-		if limiter.CheckRecursionDepth() {
-			panic(NewRecursionError("max_depth", limiter.CallDepth()))
-		}
-
-		// Verificar timeout global
-		if limiter.CheckTimeLimit() {
-			panic(NewTimeoutError("function_timeout", newEnv.GetContext()))
-		}
-
-		// Verificar context cancelation
-		if limiter.CheckContext() {
-			panic(NewTimeoutError("function_context_canceled", newEnv.GetContext()))
-		}
-
+		uf.checkLimits(limiter, newEnv)
 		// Entrar en función (incrementar stack)
 		limiter.EnterFunction(uf.code)
 		defer limiter.ExitFunction()
@@ -118,9 +131,139 @@ func (uf *UserFunction) NativeCall(currentEnv *Environment, args ...interface{})
 			}
 		}
 	}
+	strict := newEnv.StrictTypes() && uf.typed()
+	if strict {
+		uf.checkArgs(functionName, len(args), newEnv)
+	}
 	val := uf.Body.Eval(newEnv)
 	if rv, ok := val.(ReturnValue); ok {
-		return rv.Value
+		val = rv.Value
+	}
+	if strict && uf.ReturnType != nil {
+		// El resultado se comprueba en este frame: la llamada de cola se
+		// hace aquí adentro
+		if tc, ok := val.(*tailCall); ok {
+			val = tc.fn.call(nil, tc.args)
+		}
+		uf.checkResult(functionName, val, newEnv)
+	}
+	return val
+}
+
+// frameInfo devuelve el nombre y la posición con los que una llamada a uf
+// aparece en la pila de llamadas.
+func (uf *UserFunction) frameInfo(env *Environment) (string, *PositionInfo) {
+	functionName := uf.code
+	if functionName == "" {
+		if uf.IsMethod {
+			functionName = "<method>"
+		} else {
+			functionName = "<anonymous>"
+		}
+	}
+
+	// Use function position if available, otherwise create basic position info
+	pos := uf.position
+	if pos == nil && env.CurrentFile != "" {
+		pos = &PositionInfo{
+			Filename: env.CurrentFile,
+			Line:     0,
+			Col:      0,
+		}
+	}
+	return functionName, pos
+}
+
+// checkLimits aplica los límites del ExecutionLimiter antes de una llamada.
+func (uf *UserFunction) checkLimits(limiter *ExecutionLimiter, env *Environment) {
+	// Verificar límite de profundidad de recursión
+	if limiter.CheckRecursionDepth() {
+		panic(NewRecursionError("max_depth", limiter.CallDepth()))
+	}
+
+	// Verificar timeout global
+	if limiter.CheckTimeLimit() {
+		panic(NewTimeoutError("function_timeout", env.GetContext()))
+	}
+
+	// Verificar context cancelation
+	if limiter.CheckContext() {
+		panic(NewTimeoutError("function_context_canceled", env.GetContext()))
+	}
+
+	// Verificar límite de memoria
+	if limiter.CheckMemoryLimit() {
+		panic(limiter.memoryLimitError())
+	}
+}
+
+// paramCount es la cantidad de parámetros que liga una llamada.
+func (uf *UserFunction) paramCount() int {
+	if len(uf.Params) > 0 {
+		return len(uf.Params)
+	}
+	return len(uf.Args)
+}
+
+// frameCode devuelve el cuerpo compilado de uf si sus llamadas pueden correr
+// como frames en la pila de la VM (ver CompiledCode.Frame): no es un método
+// (que liga self y this en su entorno) ni hay tipos que comprobar en strict.
+func (uf *UserFunction) frameCode() *CompiledCode {
+	if uf.IsMethod || uf.Env == nil || uf.Body == nil || len(uf.Body.Statements) != 1 {
+		return nil
+	}
+	code, ok := uf.Body.Statements[0].(*CompiledCode)
+	if !ok || code.Frame == nil || uf.paramCount() > len(code.Frame.names) || code.Locals < len(code.Frame.names) {
+		return nil
+	}
+	if uf.typed() && uf.Env.StrictTypes() {
+		return nil
+	}
+	return code
+}
+
+// frameCall hace la llamada directa de OpCall a uf, cuyos n argumentos están
+// en vs desde base, y las llamadas de cola que devuelva. Al terminar, vs
+// vuelve a tener sólo lo que había debajo de base.
+func (uf *UserFunction) frameCall(vs *vmStack, code *CompiledCode, base, n int) interface{} {
+	// La pila de llamadas guarda los argumentos, que en vs se reemplazan
+	args := make([]interface{}, n)
+	copy(args, vs.values[base:])
+	val := uf.runFrame(vs, code, base, args)
+	clear(vs.values[base:])
+	vs.values = vs.values[:base]
+	return trampoline(val)
+}
+
+// runFrame es activate para una función que corre como frame: los argumentos
+// están en vs desde base y pasan a ser los slots de los parámetros; los de las
+// demás variables locales se agregan detrás.
+func (uf *UserFunction) runFrame(vs *vmStack, code *CompiledCode, base int, args []interface{}) interface{} {
+	env := uf.Env
+	functionName, pos := uf.frameInfo(env)
+	env.callStack.PushFrame(functionName, pos, args)
+	defer env.callStack.PopFrame()
+
+	limiter := env.GetLimiter()
+	if limiter.Enabled {
+		uf.checkLimits(limiter, env)
+		limiter.EnterFunction(uf.code)
+		defer limiter.ExitFunction()
+	}
+
+	params := uf.paramCount()
+	locals := vs.values[:base+min(len(args), params)]
+	for len(locals) < base+params {
+		locals = append(locals, nil)
+	}
+	for len(locals) < base+code.Locals {
+		locals = append(locals, unsetLocal{})
+	}
+	vs.values = locals
+
+	val := code.run(vs, env, base)
+	if rv, ok := val.(ReturnValue); ok {
+		val = rv.Value
 	}
 	return val
 }
@@ -152,23 +295,43 @@ func (uf *UserFunction) CallStep(env *Environment, args ...interface{}) interfac
 type BuiltinFunction func(args ...interface{}) interface{}
 
 type ObjectInstance struct {
-	Env *Environment
+	Env   *Environment
+	Class map[string]interface{} // blueprint con el que se creó
+	err   error                  // error de Go que representa una instancia de Error
+	text  string                 // texto original de un error del intérprete
+}
+
+// bindTo devuelve una copia del método ligada a env (el de una instancia o
+// el de una clase). Un método nil sigue siendo nil.
+func (uf *UserFunction) bindTo(env *Environment) *UserFunction {
+	if uf == nil {
+		return nil
+	}
+	return &UserFunction{
+		Args:        uf.Args,
+		Params:      uf.Params,
+		Body:        uf.Body,
+		Env:         env,
+		IsMethod:    true,
+		IsAsync:     uf.IsAsync,
+		IsGenerator: uf.IsGenerator,
+		ReturnType:  uf.ReturnType,
+	}
 }
 
 func instantiateObject(env *Environment, blueprint map[string]interface{}, argVals []interface{}) *ObjectInstance {
 	objEnv := NewInnerEnv(env)
-	instance := &ObjectInstance{Env: objEnv}
+	instance := &ObjectInstance{Env: objEnv, Class: blueprint}
+	static := staticSet(blueprint)
 	for k, v := range blueprint {
+		if k == staticsKey || k == errorKey || static[k] {
+			continue
+		}
 		switch vv := v.(type) {
 		case *UserFunction:
-			newFn := &UserFunction{
-				Args:     vv.Args,
-				Params:   vv.Params,
-				Body:     vv.Body,
-				Env:      objEnv,
-				IsMethod: true,
-			}
-			objEnv.Set(k, newFn)
+			objEnv.Set(k, vv.bindTo(objEnv))
+		case *Accessor:
+			objEnv.Set(k, vv.bindTo(objEnv))
 		default:
 			objEnv.Set(k, vv)
 		}
@@ -35,6 +35,11 @@ func (ws *WhileStatement) Eval(env *Environment) interface{} {
 				panic(NewTimeoutError("while_context_canceled", env.GetContext()))
 			}
 
+			// Verificar límite de memoria
+			if limiter.CheckMemoryLimit() {
+				panic(limiter.memoryLimitError())
+			}
+
 			// Verificar límite de iteraciones del bucle
 			if loopCtx.Iterations >= loopCtx.MaxIterations {
 				panic(NewInfiniteLoopError("while", loopCtx))
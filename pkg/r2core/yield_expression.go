@@ -0,0 +1,25 @@
+package r2core
+
+// YieldExpression entrega un valor desde una función generadora: yield expr
+// Su valor es el que el consumidor pase a next(v) (nil con next()). Con
+// Delegate (yield* iterable) entrega todos los elementos de otro iterable y
+// vale lo que éste devuelva al terminar.
+type YieldExpression struct {
+	Value    Node // nil en un yield sin valor
+	Delegate bool
+}
+
+func (ye *YieldExpression) Eval(env *Environment) interface{} {
+	run := currentGenerator(env)
+	if run == nil {
+		panic("yield can only be used inside a generator function (func*)")
+	}
+	var val interface{}
+	if ye.Value != nil {
+		val = ye.Value.Eval(env)
+	}
+	if ye.Delegate {
+		return run.delegate(val)
+	}
+	return run.yield(val)
+}
@@ -0,0 +1,312 @@
+// Package r2debug implements the front ends of the R2Lang debugger (see
+// r2core.Debugger): a command console for the terminal and a Debug Adapter
+// Protocol server for editors.
+//
+// The r2 command runs them with "r2 debug FILE" and "r2 debug -dap". The
+// console starts the program stopped at its first statement; the adapter
+// speaks DAP on stdin and stdout and sends the output of the program to the
+// editor as output events.
+package r2debug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+const consoleHelp = `Commands:
+  break, b [FILE:]LINE    set a breakpoint
+  delete, d [FILE:]LINE   remove a breakpoint (all of them without LINE)
+  breakpoints             list the breakpoints
+  catch on|off            stop where exceptions are thrown
+  continue, c             run until the next breakpoint
+  step, s                 run to the next statement, entering calls
+  next, n                 run to the next statement, over calls
+  out, o                  run until the current function returns
+  locals, l               show the local variables of the frame
+  globals                 show the global variables of the program
+  stack, bt               show the call stack
+  frame, f N              select frame N of the stack
+  print, p EXPR           evaluate EXPR in the frame
+  list                    show the source around the current line
+  help, h                 show this help
+  quit, q                 stop the program and exit
+`
+
+// Console is the terminal front end of the debugger: it runs a program
+// stopped before its first statement and reads commands at every stop.
+type Console struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	d      *r2core.Debugger
+	main   string              // Archivo del programa, para los breakpoints sin archivo
+	stop   *r2core.DebugStop   // Parada actual
+	frame  int                 // Frame seleccionado de la parada
+	files  map[string][]string // Líneas de los archivos mostrados
+	broken map[string]bool     // Archivos con breakpoints, para listarlos
+}
+
+// NewConsole returns a console that reads commands from in and writes to
+// out. The program writes to the Stdout and Stderr of the options given to
+// Run.
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	return &Console{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		files:  map[string][]string{},
+		broken: map[string]bool{},
+	}
+}
+
+// Run debugs the program in filename until it ends or the user quits. It
+// returns the error that ended the program, or nil if it finished or the
+// user stopped it.
+func (c *Console) Run(filename string, opts r2lang.Options) error {
+	c.d = r2core.NewDebugger()
+	c.main = filename
+	c.d.Pause()
+	opts.Debugger = c.d
+	done := make(chan error, 1)
+	go func() { done <- r2lang.Debug(filename, opts) }()
+	fmt.Fprintf(c.out, "Debugging %s. Type help for the commands.\n", filename)
+
+	for {
+		select {
+		case stop := <-c.d.Stops():
+			c.stop, c.frame = stop, 0
+			c.showStop()
+			c.commands()
+			c.stop = nil
+		case err := <-done:
+			if errors.Is(err, r2core.ErrDebugTerminated) {
+				return nil
+			}
+			if err != nil {
+				fmt.Fprintf(c.out, "The program ended with an error:\n%v\n", err)
+				return err
+			}
+			fmt.Fprintln(c.out, "The program finished.")
+			return nil
+		}
+	}
+}
+
+// commands reads commands until one of them resumes the program.
+func (c *Console) commands() {
+	for {
+		fmt.Fprint(c.out, "(r2db) ")
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			c.d.Terminate()
+			return
+		}
+		fields := strings.Fields(c.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		cmd, args := fields[0], fields[1:]
+		switch cmd {
+		case "continue", "c":
+			c.d.Continue()
+			return
+		case "step", "s":
+			c.d.StepIn()
+			return
+		case "next", "n":
+			c.d.StepOver()
+			return
+		case "out", "o":
+			c.d.StepOut()
+			return
+		case "quit", "q":
+			c.d.Terminate()
+			return
+		case "break", "b", "delete", "d":
+			c.breakpoint(cmd == "break" || cmd == "b", args)
+		case "breakpoints":
+			c.listBreakpoints()
+		case "catch":
+			if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+				fmt.Fprintln(c.out, "Usage: catch on|off")
+				continue
+			}
+			c.d.SetBreakOnExceptions(args[0] == "on")
+		case "locals", "l":
+			c.showScope(0)
+		case "globals":
+			c.showScope(1)
+		case "stack", "bt":
+			c.showStack()
+		case "frame", "f":
+			n, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || n < 0 || n >= len(c.stop.Frames) {
+				fmt.Fprintf(c.out, "Usage: frame N, with N from 0 to %d\n", len(c.stop.Frames)-1)
+				continue
+			}
+			c.frame = n
+			c.showStack()
+		case "print", "p":
+			c.print(strings.TrimSpace(strings.TrimPrefix(c.in.Text(), cmd)))
+		case "list":
+			c.list(5)
+		case "help", "h":
+			fmt.Fprint(c.out, consoleHelp)
+		default:
+			fmt.Fprintf(c.out, "Unknown command %q. Type help for the commands.\n", cmd)
+		}
+	}
+}
+
+func (c *Console) showStop() {
+	frame := c.stop.Frames[0]
+	pos := frame.Position
+	where := fmt.Sprintf("%s:%d in %s", pos.Filename, pos.Line, frameName(frame))
+	if c.stop.Reason == "exception" {
+		text, _ := r2core.DescribeValue(c.stop.Exception)
+		fmt.Fprintf(c.out, "Exception at %s: %s\n", where, text)
+	} else {
+		fmt.Fprintf(c.out, "Stopped at %s (%s)\n", where, c.stop.Reason)
+	}
+	c.list(0)
+}
+
+// list muestra las líneas del archivo del frame seleccionado a context
+// líneas de distancia de la actual, marcando la actual.
+func (c *Console) list(context int) {
+	pos := c.stop.Frames[c.frame].Position
+	lines := c.source(pos.Filename)
+	for n := pos.Line - context; n <= pos.Line+context; n++ {
+		if n < 1 || n > len(lines) {
+			continue
+		}
+		marker := " "
+		if n == pos.Line {
+			marker = ">"
+		}
+		fmt.Fprintf(c.out, "%s%4d  %s\n", marker, n, lines[n-1])
+	}
+}
+
+func (c *Console) source(file string) []string {
+	lines, ok := c.files[file]
+	if !ok {
+		data, _ := os.ReadFile(file)
+		lines = strings.Split(strings.ReplaceAll(string(data), "\t", "    "), "\n")
+		c.files[file] = lines
+	}
+	return lines
+}
+
+func (c *Console) breakpoint(set bool, args []string) {
+	if !set && len(args) == 0 {
+		for file := range c.broken {
+			c.d.SetBreakpoints(file, nil)
+		}
+		c.broken = map[string]bool{}
+		return
+	}
+	file, line, ok := c.location(args)
+	if !ok {
+		fmt.Fprintln(c.out, "Usage: break [FILE:]LINE")
+		return
+	}
+	lines := c.d.Breakpoints(file)
+	if set {
+		lines = append(lines, line)
+	} else {
+		for i, l := range lines {
+			if l == line {
+				lines = append(lines[:i], lines[i+1:]...)
+				break
+			}
+		}
+	}
+	c.d.SetBreakpoints(file, lines)
+	c.broken[file] = true
+	if set {
+		fmt.Fprintf(c.out, "Breakpoint at %s:%d\n", file, line)
+	}
+}
+
+// location lee un "[ARCHIVO:]LÍNEA"; sin archivo es el de la parada actual.
+func (c *Console) location(args []string) (file string, line int, ok bool) {
+	if len(args) != 1 {
+		return "", 0, false
+	}
+	spec := args[0]
+	file = c.main
+	if c.stop != nil {
+		file = c.stop.Frames[c.frame].Position.Filename
+	}
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		file, spec = spec[:i], spec[i+1:]
+	}
+	line, err := strconv.Atoi(spec)
+	return file, line, err == nil && line > 0
+}
+
+func (c *Console) listBreakpoints() {
+	found := false
+	for file := range c.broken {
+		for _, line := range c.d.Breakpoints(file) {
+			fmt.Fprintf(c.out, "  %s:%d\n", file, line)
+			found = true
+		}
+	}
+	if !found {
+		fmt.Fprintln(c.out, "No breakpoints.")
+	}
+}
+
+func (c *Console) showScope(n int) {
+	scope := c.stop.Frames[c.frame].Scopes()[n]
+	if len(scope.Vars) == 0 {
+		fmt.Fprintf(c.out, "No %s variables.\n", strings.ToLower(scope.Name))
+	}
+	for _, v := range scope.Vars {
+		text, _ := r2core.DescribeValue(v.Value)
+		fmt.Fprintf(c.out, "  %s = %s\n", v.Name, text)
+	}
+}
+
+func (c *Console) showStack() {
+	for i, frame := range c.stop.Frames {
+		marker := " "
+		if i == c.frame {
+			marker = ">"
+		}
+		pos := frame.Position
+		fmt.Fprintf(c.out, "%s#%d %s at %s:%d\n", marker, i, frameName(frame), filepath.ToSlash(pos.Filename), pos.Line)
+	}
+}
+
+func (c *Console) print(expr string) {
+	if expr == "" {
+		fmt.Fprintln(c.out, "Usage: print EXPR")
+		return
+	}
+	value, err := c.stop.Frames[c.frame].Evaluate(expr)
+	if err != nil {
+		fmt.Fprintf(c.out, "Error: %v\n", err)
+		return
+	}
+	text, _ := r2core.DescribeValue(value)
+	fmt.Fprintln(c.out, text)
+}
+
+// frameName es el nombre con el que se muestra un frame: la función, o
+// main para el programa principal.
+func frameName(frame *r2core.DebugFrame) string {
+	if frame.Name == "<main>" {
+		return "main"
+	}
+	return frame.Name + "()"
+}
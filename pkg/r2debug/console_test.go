@@ -0,0 +1,103 @@
+package r2debug
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+const program = `let total = 0
+func add(n) {
+    let doubled = n * 2
+    total = total + doubled
+    return doubled
+}
+for (let i = 0; i < 2; i++) {
+    add(i)
+}
+std.print("total", total)
+`
+
+// writeProgram writes source to main.r2 in a temporary directory and
+// returns its path.
+func writeProgram(t *testing.T, source string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "main.r2")
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// runConsole debugs file with the commands, one per line, and returns what
+// the console and the program wrote.
+func runConsole(t *testing.T, file string, commands ...string) (console, output string, err error) {
+	t.Helper()
+	var out, stdout bytes.Buffer
+	in := strings.NewReader(strings.Join(commands, "\n") + "\n")
+	err = NewConsole(in, &out).Run(file, r2lang.Options{Stdout: &stdout, Stderr: &stdout})
+	return out.String(), stdout.String(), err
+}
+
+func TestConsole_Session(t *testing.T) {
+	file := writeProgram(t, program)
+	console, output, err := runConsole(t, file, "b 4", "c", "locals", "p doubled + 100", "bt", "n", "n", "d", "c")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	for _, want := range []string{
+		"Stopped at " + file + ":1 in main (pause)",
+		"Breakpoint at " + file + ":4",
+		"Stopped at " + file + ":4 in add() (breakpoint)",
+		">   4      total = total + doubled",
+		"  doubled = 0\n  n = 0\n",
+		"(r2db) 100\n",
+		">#0 add() at ",
+		" #1 main at ",
+		"Stopped at " + file + ":5 in add() (step)",
+		"Stopped at " + file + ":8 in main (step)",
+		"The program finished.",
+	} {
+		if !strings.Contains(console, want) {
+			t.Errorf("expected the console to contain %q, got:\n%s", want, console)
+		}
+	}
+	// Sin breakpoints después de delete, la segunda vuelta no se detiene
+	if n := strings.Count(console, "(breakpoint)"); n != 1 {
+		t.Errorf("expected 1 breakpoint stop, got %d", n)
+	}
+	if output != "total 2\n" {
+		t.Errorf("unexpected program output %q", output)
+	}
+}
+
+func TestConsole_Exceptions(t *testing.T) {
+	file := writeProgram(t, `func check(n) {
+    if (n > 1) { throw ArgumentError("too big") }
+}
+check(5)
+`)
+	console, _, err := runConsole(t, file, "catch on", "c", "p n", "c")
+	if err == nil || !strings.Contains(err.Error(), "too big") {
+		t.Errorf("expected the uncaught exception as the error, got %v", err)
+	}
+	if !strings.Contains(console, "Exception at "+file+":2 in check(): ArgumentError: too big") ||
+		!strings.Contains(console, "(r2db) 5\n") {
+		t.Errorf("unexpected console:\n%s", console)
+	}
+}
+
+func TestConsole_Quit(t *testing.T) {
+	file := writeProgram(t, program)
+	console, output, err := runConsole(t, file, "nope", "q")
+	if err != nil {
+		t.Errorf("expected quitting to end without error, got %v", err)
+	}
+	if !strings.Contains(console, `Unknown command "nope"`) || output != "" {
+		t.Errorf("unexpected console %q and output %q", console, output)
+	}
+}
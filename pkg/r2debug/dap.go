@@ -0,0 +1,491 @@
+package r2debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+// threadID es el único hilo que el adaptador informa: el depurador sigue una
+// sola pila de llamadas.
+const threadID = 1
+
+// terminateGrace es cuánto espera disconnect a que termine el programa.
+const terminateGrace = 2 * time.Second
+
+// dapMessage is a DAP request, response or event.
+type dapMessage struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command,omitempty"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Event      string          `json:"event,omitempty"`
+	RequestSeq int             `json:"request_seq,omitempty"`
+	Success    *bool           `json:"success,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Body       interface{}     `json:"body,omitempty"`
+}
+
+// readDAP reads one message framed with a Content-Length header.
+func readDAP(r *bufio.Reader) (*dapMessage, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	msg := &dapMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeDAP writes msg framed with a Content-Length header.
+func writeDAP(w io.Writer, msg *dapMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// Argumentos de las peticiones DAP: sólo los campos que usa el adaptador
+
+type launchArguments struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	StopOnEntry bool     `json:"stopOnEntry"`
+}
+
+type setBreakpointsArguments struct {
+	Source struct {
+		Path string `json:"path"`
+	} `json:"source"`
+	Breakpoints []struct {
+		Line int `json:"line"`
+	} `json:"breakpoints"`
+}
+
+type setExceptionBreakpointsArguments struct {
+	Filters []string `json:"filters"`
+}
+
+type frameArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+type variablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type evaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    *int   `json:"frameId"`
+}
+
+// Adapter is a Debug Adapter Protocol server: it debugs the program of the
+// launch request, reporting its stops as stopped events and its output as
+// output events. A single thread represents the program.
+type Adapter struct {
+	in    *bufio.Reader
+	out   io.Writer
+	outMu sync.Mutex
+	seq   int
+
+	opts   r2lang.Options
+	d      *r2core.Debugger
+	launch *launchArguments
+	done   chan struct{} // Se cierra cuando termina el programa
+
+	resume func() // Cómo arranca o sigue el programa, después de responder
+
+	mu    sync.Mutex
+	stop  *r2core.DebugStop
+	refs  [][]r2core.DebugVar // Variables de cada variablesReference (desde 1) de la parada
+	entry bool                // La próxima parada es la de stopOnEntry
+}
+
+// NewAdapter returns an adapter that reads requests from in and writes
+// responses and events to out.
+func NewAdapter(in io.Reader, out io.Writer) *Adapter {
+	return &Adapter{
+		in:  bufio.NewReader(in),
+		out: out,
+		d:   r2core.NewDebugger(),
+	}
+}
+
+// Serve handles requests until the client disconnects or closes the input.
+// The program runs with opts, plus the arguments of the launch request; its
+// Stdout and Stderr are replaced by output events.
+func (a *Adapter) Serve(opts r2lang.Options) error {
+	a.opts = opts
+	for {
+		msg, err := readDAP(a.in)
+		if err != nil {
+			a.terminate()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Type != "request" {
+			continue
+		}
+		body, err := a.handle(msg)
+		a.respond(msg, body, err)
+		if a.resume != nil {
+			// El programa arranca o sigue después de la respuesta, para
+			// que su próxima parada no llegue antes que ella
+			a.resume()
+			a.resume = nil
+		}
+		if msg.Command == "initialize" && err == nil {
+			a.event("initialized", nil)
+		}
+		if msg.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+func (a *Adapter) send(msg *dapMessage) {
+	a.outMu.Lock()
+	defer a.outMu.Unlock()
+	a.seq++
+	msg.Seq = a.seq
+	writeDAP(a.out, msg)
+}
+
+func (a *Adapter) respond(req *dapMessage, body interface{}, err error) {
+	success := err == nil
+	resp := &dapMessage{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: &success, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	a.send(resp)
+}
+
+func (a *Adapter) event(name string, body interface{}) {
+	a.send(&dapMessage{Type: "event", Event: name, Body: body})
+}
+
+// handle runs the request msg and returns the body of its response.
+func (a *Adapter) handle(msg *dapMessage) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if len(msg.Arguments) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Arguments, v)
+	}
+
+	switch msg.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+			"exceptionBreakpointFilters": []map[string]interface{}{
+				{"filter": "all", "label": "All Exceptions", "default": false},
+			},
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(args.Program); err != nil {
+			return nil, fmt.Errorf("cannot launch %s: %v", args.Program, err)
+		}
+		a.launch = &args
+		return nil, nil
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		lines := make([]int, len(args.Breakpoints))
+		verified := make([]map[string]interface{}, len(args.Breakpoints))
+		for i, bp := range args.Breakpoints {
+			lines[i] = bp.Line
+			verified[i] = map[string]interface{}{"verified": true, "line": bp.Line}
+		}
+		a.d.SetBreakpoints(args.Source.Path, lines)
+		return map[string]interface{}{"breakpoints": verified}, nil
+	case "setExceptionBreakpoints":
+		var args setExceptionBreakpointsArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		all := false
+		for _, filter := range args.Filters {
+			all = all || filter == "all"
+		}
+		a.d.SetBreakOnExceptions(all)
+		return nil, nil
+	case "configurationDone":
+		if a.launch == nil {
+			return nil, errors.New("configurationDone before launch")
+		}
+		if a.done == nil {
+			a.resume = a.start
+		}
+		return nil, nil
+	case "threads":
+		return map[string]interface{}{
+			"threads": []map[string]interface{}{{"id": threadID, "name": "main"}},
+		}, nil
+
+	case "stackTrace":
+		stop, err := a.paused()
+		if err != nil {
+			return nil, err
+		}
+		frames := make([]map[string]interface{}, len(stop.Frames))
+		for i, frame := range stop.Frames {
+			frames[i] = map[string]interface{}{
+				"id":     i,
+				"name":   frameName(frame),
+				"line":   frame.Position.Line,
+				"column": frame.Position.Col,
+				"source": source(frame.Position.Filename),
+			}
+		}
+		return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+	case "scopes":
+		var args frameArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		frame, err := a.frame(&args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		var scopes []map[string]interface{}
+		for _, scope := range frame.Scopes() {
+			scopes = append(scopes, map[string]interface{}{
+				"name":               scope.Name,
+				"variablesReference": a.reference(scope.Vars),
+				"expensive":          false,
+			})
+		}
+		return map[string]interface{}{"scopes": scopes}, nil
+	case "variables":
+		var args variablesArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		var vars []r2core.DebugVar
+		if ref := args.VariablesReference; ref > 0 && ref <= len(a.refs) {
+			vars = a.refs[ref-1]
+		}
+		a.mu.Unlock()
+		variables := make([]map[string]interface{}, len(vars))
+		for i, v := range vars {
+			variables[i] = a.variable(v.Name, v.Value)
+		}
+		return map[string]interface{}{"variables": variables}, nil
+	case "evaluate":
+		var args evaluateArguments
+		if err := decode(&args); err != nil {
+			return nil, err
+		}
+		frame, err := a.frame(args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		value, err := frame.Evaluate(args.Expression)
+		if err != nil {
+			return nil, err
+		}
+		result := a.variable("", value)
+		result["result"] = result["value"]
+		delete(result, "value")
+		delete(result, "name")
+		return result, nil
+
+	case "continue", "next", "stepIn", "stepOut":
+		if _, err := a.paused(); err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		a.stop, a.refs = nil, nil
+		a.mu.Unlock()
+		switch msg.Command {
+		case "continue":
+			a.resume = func() { a.d.Continue() }
+		case "next":
+			a.resume = func() { a.d.StepOver() }
+		case "stepIn":
+			a.resume = func() { a.d.StepIn() }
+		default:
+			a.resume = func() { a.d.StepOut() }
+		}
+		return map[string]interface{}{"allThreadsContinued": true}, nil
+	case "pause":
+		a.d.Pause()
+		return nil, nil
+	case "terminate", "disconnect":
+		a.terminate()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request %q", msg.Command)
+}
+
+// start corre el programa de launch y reenvía sus paradas como eventos.
+func (a *Adapter) start() {
+	opts := a.opts
+	opts.Args = a.launch.Args
+	opts.Debugger = a.d
+	opts.Stdout = &outputWriter{a: a, category: "stdout"}
+	opts.Stderr = &outputWriter{a: a, category: "stderr"}
+	if a.launch.StopOnEntry {
+		a.entry = true
+		a.d.Pause()
+	}
+	a.done = make(chan struct{})
+	result := make(chan error, 1)
+	go func() { result <- r2lang.Debug(a.launch.Program, opts) }()
+	go func() {
+		for {
+			select {
+			case stop := <-a.d.Stops():
+				a.stopped(stop)
+			case err := <-result:
+				a.finished(err)
+				return
+			}
+		}
+	}()
+}
+
+func (a *Adapter) stopped(stop *r2core.DebugStop) {
+	a.mu.Lock()
+	a.stop, a.refs = stop, nil
+	reason := stop.Reason
+	if a.entry && reason == "pause" {
+		reason = "entry"
+	}
+	a.entry = false
+	a.mu.Unlock()
+	body := map[string]interface{}{"reason": reason, "threadId": threadID, "allThreadsStopped": true}
+	if stop.Reason == "exception" {
+		text, _ := r2core.DescribeValue(stop.Exception)
+		body["text"] = text
+		body["description"] = "Exception: " + text
+	}
+	a.event("stopped", body)
+}
+
+func (a *Adapter) finished(err error) {
+	exitCode := 0
+	if err != nil && !errors.Is(err, r2core.ErrDebugTerminated) {
+		a.event("output", map[string]interface{}{"category": "stderr", "output": err.Error() + "\n"})
+		exitCode = 1
+	}
+	a.event("exited", map[string]interface{}{"exitCode": exitCode})
+	a.event("terminated", nil)
+	close(a.done)
+}
+
+// terminate corta el programa, si corre, y espera a que termine.
+func (a *Adapter) terminate() {
+	if a.done == nil {
+		return
+	}
+	a.d.Terminate()
+	select {
+	case <-a.done:
+	case <-time.After(terminateGrace):
+	}
+}
+
+// paused devuelve la parada actual, o un error si el programa no está
+// detenido.
+func (a *Adapter) paused() (*r2core.DebugStop, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stop == nil {
+		return nil, errors.New("the program is not paused")
+	}
+	return a.stop, nil
+}
+
+// frame devuelve el frame id de la parada actual; sin id, el más reciente.
+func (a *Adapter) frame(id *int) (*r2core.DebugFrame, error) {
+	stop, err := a.paused()
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	if id != nil {
+		n = *id
+	}
+	if n < 0 || n >= len(stop.Frames) {
+		return nil, fmt.Errorf("unknown frame %d", n)
+	}
+	return stop.Frames[n], nil
+}
+
+// reference registra vars y devuelve su variablesReference.
+func (a *Adapter) reference(vars []r2core.DebugVar) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refs = append(a.refs, vars)
+	return len(a.refs)
+}
+
+// variable describe value como una variable de DAP; si tiene contenido,
+// con una variablesReference para desplegarlo.
+func (a *Adapter) variable(name string, value interface{}) map[string]interface{} {
+	text, typ := r2core.DescribeValue(value)
+	ref := 0
+	if children := r2core.DebugChildren(value); len(children) > 0 {
+		ref = a.reference(children)
+	}
+	return map[string]interface{}{"name": name, "value": text, "type": typ, "variablesReference": ref}
+}
+
+// source describe file como un Source de DAP.
+func source(file string) map[string]interface{} {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	return map[string]interface{}{"name": filepath.Base(file), "path": path}
+}
+
+// outputWriter envía lo que escribe el programa como eventos output.
+type outputWriter struct {
+	a        *Adapter
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.a.event("output", map[string]interface{}{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
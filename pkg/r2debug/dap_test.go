@@ -0,0 +1,222 @@
+package r2debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+// dapClient drives an Adapter over pipes, like an editor.
+type dapClient struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan *dapMessage
+	skipped  []*dapMessage // Recibidos mientras se esperaba otro
+	served   chan error
+	seq      int
+}
+
+func newDAPClient(t *testing.T) *dapClient {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	c := &dapClient{t: t, in: reqW, messages: make(chan *dapMessage, 100), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewAdapter(reqR, respW).Serve(r2lang.Options{})
+		respW.Close()
+	}()
+	go func() {
+		r := bufio.NewReader(respR)
+		for {
+			msg, err := readDAP(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+// request sends command and returns the body of its response, failing the
+// test if it was not successful.
+func (c *dapClient) request(command string, args interface{}) map[string]interface{} {
+	c.t.Helper()
+	resp := c.call(command, args)
+	if resp.Success == nil || !*resp.Success {
+		c.t.Fatalf("%s failed: %s", command, resp.Message)
+	}
+	body, _ := resp.Body.(map[string]interface{})
+	return body
+}
+
+// call sends command and returns its response.
+func (c *dapClient) call(command string, args interface{}) *dapMessage {
+	c.t.Helper()
+	c.seq++
+	req := &dapMessage{Seq: c.seq, Type: "request", Command: command}
+	if args != nil {
+		req.Arguments, _ = json.Marshal(args)
+	}
+	if err := writeDAP(c.in, req); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.next("response", command)
+}
+
+// next returns the next message of kind ("response" or "event") named
+// name. The others are kept for later calls.
+func (c *dapClient) next(kind, name string) *dapMessage {
+	c.t.Helper()
+	for i, msg := range c.skipped {
+		if msg.Type == kind && (msg.Command == name || msg.Event == name) {
+			c.skipped = append(c.skipped[:i], c.skipped[i+1:]...)
+			return msg
+		}
+	}
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("the adapter closed before %s %s", kind, name)
+			}
+			if msg.Type == kind && (msg.Command == name || msg.Event == name) {
+				return msg
+			}
+			c.skipped = append(c.skipped, msg)
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("timed out waiting for %s %s", kind, name)
+		}
+	}
+}
+
+func (c *dapClient) event(name string) map[string]interface{} {
+	c.t.Helper()
+	body, _ := c.next("event", name).Body.(map[string]interface{})
+	return body
+}
+
+func TestAdapter_Session(t *testing.T) {
+	file := writeProgram(t, program)
+	c := newDAPClient(t)
+
+	caps := c.request("initialize", map[string]interface{}{"adapterID": "r2"})
+	if caps["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("unexpected capabilities %v", caps)
+	}
+	c.next("event", "initialized")
+	c.request("launch", map[string]interface{}{"program": file})
+	bps := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": file},
+		"breakpoints": []map[string]interface{}{{"line": 4}},
+	})
+	if len(bps["breakpoints"].([]interface{})) != 1 {
+		t.Errorf("unexpected breakpoints %v", bps)
+	}
+	c.request("configurationDone", nil)
+
+	stopped := c.event("stopped")
+	if stopped["reason"] != "breakpoint" {
+		t.Errorf("expected a breakpoint stop, got %v", stopped)
+	}
+	frames := c.request("stackTrace", map[string]interface{}{"threadId": 1})["stackFrames"].([]interface{})
+	top := frames[0].(map[string]interface{})
+	if len(frames) != 2 || top["name"] != "add()" || top["line"] != 4.0 ||
+		top["source"].(map[string]interface{})["path"] != file {
+		t.Errorf("unexpected stack %v", frames)
+	}
+
+	scopes := c.request("scopes", map[string]interface{}{"frameId": 0})["scopes"].([]interface{})
+	locals := scopes[0].(map[string]interface{})
+	vars := c.request("variables", map[string]interface{}{"variablesReference": locals["variablesReference"]})["variables"].([]interface{})
+	var names []string
+	for _, v := range vars {
+		v := v.(map[string]interface{})
+		names = append(names, v["name"].(string)+"="+v["value"].(string))
+	}
+	if strings.Join(names, " ") != "doubled=0 n=0" {
+		t.Errorf("unexpected locals %v", names)
+	}
+
+	// Un valor compuesto se despliega con su variablesReference
+	result := c.request("evaluate", map[string]interface{}{"expression": `{a: [1, 2], b: "x"}`, "frameId": 0})
+	children := c.request("variables", map[string]interface{}{"variablesReference": result["variablesReference"]})["variables"].([]interface{})
+	if result["type"] != "map" || len(children) != 2 || children[1].(map[string]interface{})["value"] != `"x"` {
+		t.Errorf("unexpected evaluation %v with children %v", result, children)
+	}
+	if resp := c.call("evaluate", map[string]interface{}{"expression": "missing", "frameId": 0}); *resp.Success {
+		t.Errorf("expected evaluating an undeclared variable to fail")
+	}
+
+	c.request("next", map[string]interface{}{"threadId": 1})
+	if stopped := c.event("stopped"); stopped["reason"] != "step" {
+		t.Errorf("expected a step stop, got %v", stopped)
+	}
+	c.request("setBreakpoints", map[string]interface{}{"source": map[string]interface{}{"path": file}, "breakpoints": []interface{}{}})
+	c.request("continue", map[string]interface{}{"threadId": 1})
+
+	if exited := c.event("exited"); exited["exitCode"] != 0.0 {
+		t.Errorf("unexpected exit %v", exited)
+	}
+	output := ""
+	for _, msg := range c.skipped {
+		if body, _ := msg.Body.(map[string]interface{}); msg.Event == "output" && body["category"] == "stdout" {
+			output += body["output"].(string)
+		}
+	}
+	if output != "total 2\n" {
+		t.Errorf("unexpected output %q", output)
+	}
+	c.next("event", "terminated")
+	c.request("disconnect", nil)
+	if err := <-c.served; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
+
+func TestAdapter_EntryAndExceptions(t *testing.T) {
+	file := writeProgram(t, `let x = 1
+throw Error("boom")
+`)
+	c := newDAPClient(t)
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": file, "stopOnEntry": true})
+	c.request("setExceptionBreakpoints", map[string]interface{}{"filters": []string{"all"}})
+	c.request("configurationDone", nil)
+	if stopped := c.event("stopped"); stopped["reason"] != "entry" {
+		t.Errorf("expected the entry stop, got %v", stopped)
+	}
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	stopped := c.event("stopped")
+	if stopped["reason"] != "exception" || stopped["text"] != "Error: boom" {
+		t.Errorf("expected the exception stop, got %v", stopped)
+	}
+	c.request("continue", map[string]interface{}{"threadId": 1})
+	if exited := c.event("exited"); exited["exitCode"] != 1.0 {
+		t.Errorf("expected exit code 1, got %v", exited)
+	}
+	c.request("disconnect", nil)
+}
+
+func TestAdapter_Disconnect(t *testing.T) {
+	file := writeProgram(t, "while (true) {\n    let x = 1\n}\n")
+	c := newDAPClient(t)
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": file})
+	c.request("configurationDone", nil)
+	c.request("pause", map[string]interface{}{"threadId": 1})
+	if stopped := c.event("stopped"); stopped["reason"] != "pause" {
+		t.Errorf("expected a pause stop, got %v", stopped)
+	}
+	c.request("disconnect", nil)
+	c.next("event", "terminated")
+	if err := <-c.served; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
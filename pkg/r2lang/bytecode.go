@@ -0,0 +1,53 @@
+package r2lang
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+// CompileFile parses filename and writes its bytecode to output in the .r2c
+// format. Syntax errors are returned without writing anything. Imported
+// modules are not bundled: they are still loaded from source when the
+// program runs. The file records filename, which runtime errors report.
+func CompileFile(filename, output string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	prog, errs := r2core.ParseWithErrors(string(data), filename)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	code := r2core.Compile(prog)
+	code.Source = filename
+	data, err = r2core.EncodeBytecode(code)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, data, 0644)
+}
+
+// RunBytecode executes a .r2c file produced by CompileFile with the same
+// global environment as RunCodeWithOptions. Errors are reported against the
+// source file it was compiled from; imports resolve next to the .r2c file.
+func RunBytecode(filename string, opts Options) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("Error reading the file %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+	code, err := r2core.DecodeBytecode(data)
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	env := newEnvironment(filename, opts)
+	if code.Source != "" {
+		env.CurrentFile = code.Source
+	}
+	defer applyOptions(env, opts)()
+	env.RunCompiled(code)
+}
@@ -0,0 +1,28 @@
+package r2lang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBytecode_ReportsSourceFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "let a = 1\nstd.print(nope)\n",
+	})
+	source := filepath.Join(dir, "main.r2")
+	output := filepath.Join(t.TempDir(), "app.r2c")
+	if err := CompileFile(source, output); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg string
+	func() {
+		defer func() { msg = fmt.Sprint(recover()) }()
+		RunBytecode(output, Options{})
+	}()
+	if !strings.Contains(msg, source+":2:") || strings.Contains(msg, ".r2c") {
+		t.Errorf("expected the error at %s:2, got %q", source, msg)
+	}
+}
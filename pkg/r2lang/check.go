@@ -0,0 +1,218 @@
+package r2lang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2mod"
+)
+
+// CheckResult is the outcome of statically checking a module graph.
+type CheckResult struct {
+	Files       []string             // Files checked, in the order they were visited
+	Diagnostics []*r2core.ParseError // Every problem found, grouped by file
+}
+
+// CheckFile parses filename and every module it imports (transitively)
+// without evaluating anything. Parse errors, unreadable imports and import
+// cycles are all reported as diagnostics; the returned error is only set when
+// filename itself cannot be read.
+func CheckFile(filename string) (*CheckResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return CheckSource(filename, string(data)), nil
+}
+
+// CheckSource is CheckFile for the source of filename given in src, for
+// tools that check unsaved buffers. The imported modules are read from disk.
+func CheckSource(filename, src string) *CheckResult {
+	return check(filename, src, false)
+}
+
+// TypeCheckFile is CheckFile that also checks the type annotations of every
+// file without parse errors (see r2core.TypeCheck). Names imported from
+// other modules are not typed.
+func TypeCheckFile(filename string) (*CheckResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return TypeCheckSource(filename, string(data)), nil
+}
+
+// TypeCheckSource is TypeCheckFile for the source of filename given in src.
+func TypeCheckSource(filename, src string) *CheckResult {
+	return check(filename, src, true)
+}
+
+func check(filename, src string, types bool) *CheckResult {
+	c := &checker{result: &CheckResult{}, visited: map[string]bool{}, types: types}
+	filename = filepath.Clean(filename)
+	project, err := r2mod.FindProject(filepath.Dir(filename))
+	if err != nil {
+		c.result.Diagnostics = append(c.result.Diagnostics, &r2core.ParseError{
+			Position: &r2core.PositionInfo{Filename: filename, Line: 1, Col: 1},
+			Message:  fmt.Sprintf("Error loading %s: %v", r2mod.ManifestFile, err)})
+	}
+	c.project = project
+	c.checkSource(filename, src)
+	return c.result
+}
+
+type checker struct {
+	result  *CheckResult
+	visited map[string]bool
+	stack   []string                   // Import chain being checked, for cycle detection
+	project *r2mod.Project             // Packages bare imports resolve to; nil outside a project
+	types   bool                       // Also check type annotations
+	exports map[string]map[string]bool // Names each module exports; nil when unknown
+}
+
+type pendingImport struct {
+	path string
+	src  string
+}
+
+func (c *checker) checkSource(filename string, src string) {
+	c.visited[filename] = true
+	c.result.Files = append(c.result.Files, filename)
+	c.stack = append(c.stack, filename)
+	defer func() { c.stack = c.stack[:len(c.stack)-1] }()
+
+	prog, errs := r2core.ParseWithErrors(src, filename)
+	diags := append([]*r2core.ParseError{}, errs...)
+	if c.types && len(errs) == 0 {
+		diags = append(diags, r2core.TypeCheck(prog, filename)...)
+	}
+
+	// Same resolution as ImportStatement.Eval: relative to the importing file.
+	dir := filepath.Dir(filename)
+	var imports []pendingImport
+	for _, imp := range importsOf(prog) {
+		if name, isStd := strings.CutPrefix(imp.Path, "std:"); isStd {
+			if !isLibrary(name) {
+				diags = append(diags, &r2core.ParseError{Position: imp.Position,
+					Message: fmt.Sprintf("Unknown standard library %q (available: %s)", imp.Path, strings.Join(LibraryNames(), ", "))})
+			}
+			continue
+		}
+		path, err := c.resolve(imp.Path, dir)
+		if err != nil {
+			diags = append(diags, &r2core.ParseError{Position: imp.Position,
+				Message: fmt.Sprintf("Error importing %s: %v", imp.Path, err)})
+			continue
+		}
+
+		if c.inStack(path) {
+			chain := append(append([]string{}, c.stack...), path)
+			diags = append(diags, &r2core.ParseError{Position: imp.Position,
+				Message: fmt.Sprintf("Cyclic import detected: %s", strings.Join(chain, " -> "))})
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			diags = append(diags, &r2core.ParseError{Position: imp.Position,
+				Message: fmt.Sprintf("Error reading imported file: %s", err.Error())})
+			continue
+		}
+		if imp.Names != nil {
+			exports := c.exportsOf(path, string(data))
+			for _, n := range imp.Names {
+				if exports != nil && !exports[n.Name] {
+					diags = append(diags, &r2core.ParseError{Position: imp.Position,
+						Message: fmt.Sprintf("%s does not export %s", imp.Path, n.Name)})
+				}
+			}
+		}
+		if !c.visited[path] {
+			imports = append(imports, pendingImport{path: path, src: string(data)})
+		}
+	}
+
+	// A file's own diagnostics are reported together and in line order,
+	// before those of the modules it imports.
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Position, diags[j].Position
+		return a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col)
+	})
+	c.result.Diagnostics = append(c.result.Diagnostics, diags...)
+
+	for _, imp := range imports {
+		// Two imports in the same file may name the same module.
+		if !c.visited[imp.path] {
+			c.checkSource(imp.path, imp.src)
+		}
+	}
+}
+
+// exportsOf returns the names the module at path lets importers see, or nil
+// when they cannot be known without running it (it does not parse, or takes
+// names from a module imported without an alias).
+func (c *checker) exportsOf(path, src string) map[string]bool {
+	if names, ok := c.exports[path]; ok {
+		return names
+	}
+	var names map[string]bool
+	if prog, errs := r2core.ParseWithErrors(src, path); len(errs) == 0 {
+		if exported, ok := r2core.ExportedNames(prog); ok {
+			names = exported
+		}
+	}
+	if c.exports == nil {
+		c.exports = map[string]map[string]bool{}
+	}
+	c.exports[path] = names
+	return names
+}
+
+// importsOf returns every import of prog in source order, including those
+// inside function bodies and blocks, which run when they are reached.
+func importsOf(prog *r2core.Program) []*r2core.ImportStatement {
+	var imports []*r2core.ImportStatement
+	r2core.Inspect(prog, func(n r2core.Node) bool {
+		if imp, ok := n.(*r2core.ImportStatement); ok {
+			imports = append(imports, imp)
+		}
+		return true
+	})
+	return imports
+}
+
+// resolve returns the file an import of path in dir reads: a package file
+// when the project has the package, else path relative to dir.
+func (c *checker) resolve(path, dir string) (string, error) {
+	if c.project != nil && r2core.IsPackageSpecifier(path) {
+		file, ok, err := c.project.Resolve(path)
+		if err != nil || ok {
+			return filepath.Clean(file), err
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+func isLibrary(name string) bool {
+	for _, lib := range libraries {
+		if lib.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) inStack(path string) bool {
+	for _, p := range c.stack {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
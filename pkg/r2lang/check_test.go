@@ -0,0 +1,206 @@
+package r2lang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheckFile_FollowsImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2":       "import \"lib/util.r2\" as util\nstd.print(util.twice(2))\n",
+		"lib/util.r2":   "import \"helper.r2\" as h\nfunc twice(x) { return h.add(x, x) }\n",
+		"lib/helper.r2": "func add(a, b) { return a + \n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 3 {
+		t.Errorf("expected 3 files checked, got %v", result.Files)
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", result.Diagnostics)
+	}
+	if got := result.Diagnostics[0].Error(); !strings.HasPrefix(got, filepath.Join(dir, "lib", "helper.r2")+":") {
+		t.Errorf("diagnostic should point at helper.r2, got %q", got)
+	}
+}
+
+func TestCheckFile_MissingImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "let a = 1\nimport \"nope.r2\" as n\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", result.Diagnostics)
+	}
+	d := result.Diagnostics[0]
+	if d.Position.Line != 2 || !strings.Contains(d.Message, "Error reading imported file") {
+		t.Errorf("unexpected diagnostic: %v", d)
+	}
+}
+
+func TestCheckFile_NestedImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2":   "func load() {\n  import \"broken.r2\" as b\n  return b\n}\nif (true) {\n  import \"nope.r2\" as n\n}\n",
+		"broken.r2": "let x = \n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || len(result.Diagnostics) != 2 {
+		t.Fatalf("expected the nested imports to be checked, got %v %v", result.Files, result.Diagnostics)
+	}
+	if d := result.Diagnostics[0]; d.Position.Line != 6 || !strings.Contains(d.Message, "Error reading imported file") {
+		t.Errorf("unexpected diagnostic for the missing module: %v", d)
+	}
+	if d := result.Diagnostics[1]; d.Position.Filename != filepath.Join(dir, "broken.r2") {
+		t.Errorf("expected a diagnostic in broken.r2, got %v", d)
+	}
+}
+
+func TestCheckFile_SelectiveImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2":  "import { twice, nope } from \"./util.r2\"\nimport { helper } from \"./plain.r2\"\nimport { secret } from \"./util.r2\"\n",
+		"util.r2":  "export func twice(x) { return x * 2 }\nfunc secret() { return 1 }\n",
+		"plain.r2": "func helper() { return 1 }\nif (true) { let other = 2 }\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", result.Diagnostics)
+	}
+	if d := result.Diagnostics[0]; d.Position.Line != 1 || !strings.Contains(d.Message, "./util.r2 does not export nope") {
+		t.Errorf("unexpected diagnostic for nope: %v", d)
+	}
+	if d := result.Diagnostics[1]; d.Position.Line != 3 || !strings.Contains(d.Message, "./util.r2 does not export secret") {
+		t.Errorf("unexpected diagnostic for secret: %v", d)
+	}
+}
+
+func TestCheckFile_ImportCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.r2": "import \"b.r2\" as b\n",
+		"b.r2": "import \"a.r2\" as a\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "a.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 1 || !strings.Contains(result.Diagnostics[0].Message, "Cyclic import detected") {
+		t.Fatalf("expected a cycle diagnostic, got %v", result.Diagnostics)
+	}
+}
+
+func TestCheckFile_StdImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "import \"std:json\" as j\nimport { twice } from \"util.r2\"\nimport \"std:nope\"\n",
+		"util.r2": "export func twice(x) { return x * 2 }\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 {
+		t.Errorf("expected main.r2 and util.r2 to be checked, got %v", result.Files)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Position.Line != 3 ||
+		!strings.Contains(result.Diagnostics[0].Message, "Unknown standard library \"std:nope\"") {
+		t.Fatalf("expected only the unknown library to be reported, got %v", result.Diagnostics)
+	}
+}
+
+func TestCheckFile_Packages(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"r2.mod":  "require colors 1.0.0\n",
+		"main.r2": "import \"colors\" as c\nimport \"util.r2\" as u\n",
+		"util.r2": "let x = 1\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Position.Line != 1 ||
+		!strings.Contains(result.Diagnostics[0].Message, "package colors is not installed: run r2 get") {
+		t.Fatalf("expected only the missing package to be reported, got %v", result.Diagnostics)
+	}
+}
+
+func TestCheckFile_Clean(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "func main() {\n  std.print(\"hi\")\n}\n",
+	})
+
+	result, err := CheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", result.Diagnostics)
+	}
+}
+
+func TestCheckFile_Unreadable(t *testing.T) {
+	if _, err := CheckFile(filepath.Join(t.TempDir(), "missing.r2")); err == nil {
+		t.Error("expected an error for a missing root file")
+	}
+}
+
+func TestTypeCheckFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "import { twice } from \"util.r2\"\nlet n: number = twice(2)\nlet s: string = 1\n",
+		"util.r2": "export func twice(x: number): number { return x * 2 }\ntwice(\"a\")\n",
+	})
+
+	result, err := TypeCheckFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, diag := range result.Diagnostics {
+		got = append(got, fmt.Sprintf("%s:%d: %s", filepath.Base(diag.Position.Filename), diag.Position.Line, diag.Message))
+	}
+	want := []string{
+		"main.r2:3: Cannot assign number to s: string",
+		"util.r2:2: twice: argument x must be number, got string",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	// CheckFile only checks the syntax
+	if result, _ := CheckFile(filepath.Join(dir, "main.r2")); len(result.Diagnostics) != 0 {
+		t.Errorf("expected CheckFile not to check types, got %v", result.Diagnostics)
+	}
+}
@@ -0,0 +1,34 @@
+package r2lang
+
+import (
+	"fmt"
+	"os"
+)
+
+// Debug runs the program in filename like RunCodeWithOptions under
+// opts.Debugger, which must be driven from another goroutine. Instead of
+// exiting it returns the error that ended the program: a read or parse
+// error, an uncaught exception, or r2core.ErrDebugTerminated when the
+// debugger cut it short.
+func Debug(filename string, opts Options) (err error) {
+	if opts.Debugger == nil {
+		return fmt.Errorf("debugging %s: no debugger", filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	prog, err := parse(string(data), filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	env := newEnvironment(filename, opts)
+	defer applyOptions(env, opts)()
+	env.RunProgram(prog)
+	return nil
+}
@@ -0,0 +1,39 @@
+package r2lang
+
+import "testing"
+
+func TestUnifiedDiff_Equal(t *testing.T) {
+	if d := UnifiedDiff("a", "b", "x\ny\n", "x\ny\n"); d != "" {
+		t.Errorf("expected empty diff, got %q", d)
+	}
+}
+
+func TestUnifiedDiff_Hunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := `--- a.r2
++++ b.r2
+@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if got := UnifiedDiff("a.r2", "b.r2", a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_FromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := UnifiedDiff("a", "b", "", "x\ny\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
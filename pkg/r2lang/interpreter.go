@@ -0,0 +1,376 @@
+package r2lang
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2libs"
+)
+
+// library is a standard library that can be registered in an environment.
+type library struct {
+	name     string
+	register func(env *r2core.Environment)
+}
+
+// libraryGlobals lists the globals of the libraries that define more than a
+// module with their own name.
+var libraryGlobals = map[string][]string{
+	"lib":      {"r2", "go"},
+	"math":     {"math", "E", "LN10", "LN2", "LOG10E", "LOG2E", "PHI", "PI", "SQRT2", "SQRT_E", "SQRT_PHI", "SQRT_PI"},
+	"encoding": {"encoding", "uuid"},
+}
+
+// globals returns the names lib defines when registered.
+func (lib library) globals() []string {
+	if names, ok := libraryGlobals[lib.name]; ok {
+		return names
+	}
+	return []string{lib.name}
+}
+
+// libraries lists the standard libraries in registration order. The name is
+// the module the library registers ("lib" holds the global builtins r2 and go).
+var libraries = []library{
+	{"lib", r2libs.RegisterLib},
+	{"std", r2libs.RegisterStd},
+	{"io", r2libs.RegisterIO},
+	{"httpclient", r2libs.RegisterHTTPClient},
+	{"request", r2libs.RegisterRequests},
+	{"string", r2libs.RegisterString},
+	{"regex", r2libs.RegisterRegex},
+	{"math", r2libs.RegisterMath},
+	{"rand", r2libs.RegisterRand},
+	{"test", r2libs.RegisterTest},
+	{"http", r2libs.RegisterHTTP},
+	{"r2printer", r2libs.RegisterPrint},
+	{"os", r2libs.RegisterOS},
+	{"hack", r2libs.RegisterHack},
+	{"encoding", r2libs.RegisterEncoding},
+	{"goroutine", r2libs.RegisterConcurrency},
+	{"promise", r2libs.RegisterPromise},
+	{"sync", r2libs.RegisterSync},
+	{"collections", r2libs.RegisterCollections},
+	{"validate", r2libs.RegisterValidate},
+	{"unicode", r2libs.RegisterUnicode},
+	{"date", r2libs.RegisterDate},
+	{"db", r2libs.RegisterDB},
+	{"soap", r2libs.RegisterSOAP},
+	{"grpc", r2libs.RegisterGRPC},
+	{"json", r2libs.RegisterJSON},
+	{"xml", r2libs.RegisterXML},
+	{"csv", r2libs.RegisterCSV},
+	{"jwt", r2libs.RegisterJWT},
+	{"console", r2libs.RegisterConsole},
+	{"web", r2libs.RegisterWeb},
+	{"native", r2libs.RegisterGoInterOp},
+	{"graph", r2libs.RegisterGraph},
+	{"flags", r2libs.RegisterFlags},
+}
+
+// LibraryNames returns the names accepted in Config.Libraries, sorted.
+func LibraryNames() []string {
+	names := make([]string, len(libraries))
+	for i, lib := range libraries {
+		names[i] = lib.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registerLibraries makes the libraries named in names, or all of them when
+// names is nil, available in env. Eagerly they are registered right away;
+// lazily each one is registered the first time the program uses one of its
+// globals. Either way they can be imported with import "std:name". Unknown
+// names are an error and nothing is registered.
+func registerLibraries(env *r2core.Environment, names []string, lazy bool) error {
+	if names == nil {
+		names = LibraryNames()
+	}
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	for _, lib := range libraries {
+		delete(selected, lib.name)
+	}
+	if len(selected) > 0 {
+		unknown := make([]string, 0, len(selected))
+		for name := range selected {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown libraries: %s (available: %s)",
+			strings.Join(unknown, ", "), strings.Join(LibraryNames(), ", "))
+	}
+	for _, name := range names {
+		selected[name] = true
+	}
+	libs := r2core.NewLibraries()
+	for _, lib := range libraries {
+		if !selected[lib.name] {
+			continue
+		}
+		if lazy {
+			libs.Add(lib.name, lib.register, lib.globals()...)
+		} else {
+			lib.register(env)
+			libs.Add(lib.name, lib.register)
+		}
+	}
+	env.SetLibraries(libs)
+	return nil
+}
+
+// Config configures an Interpreter. The zero value registers every library
+// and writes to os.Stdout and os.Stderr.
+type Config struct {
+	// Libraries are the names of the libraries to register (see
+	// LibraryNames). Nil registers all of them; an empty slice none.
+	Libraries []string
+	// Stdout and Stderr receive the output of the scripts. Nil means
+	// os.Stdout and os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+	// BaseDir is the directory relative imports are resolved against in
+	// Eval. Empty means the current directory.
+	BaseDir string
+	// Limits apply to each Eval, RunFile and Call separately. MaxMemory is
+	// the exception: it is checked against the heap of the whole process,
+	// so it is not safe when several interpreters run concurrently.
+	Limits Limits
+	// Args are the script arguments, exposed as argv and process.args.
+	Args []string
+	// Sandbox selects the sandbox profile: unless Libraries says otherwise
+	// only SandboxLibraries are registered, and the SandboxPolicy applies
+	// with Root (BaseDir when empty) as the root of the io module.
+	Sandbox bool
+	Root    string
+	// Strict checks the type annotations of every function called against
+	// its arguments and its result, and of every let and const against its
+	// initial value.
+	Strict bool
+	// Policy, when set, is the capability policy of the interpreter,
+	// replacing the one of the sandbox profile.
+	Policy *r2libs.CapabilityPolicy
+}
+
+// Interpreter is an R2 interpreter for embedding in Go programs. Unlike
+// RunCode it never exits the process: parse and runtime errors, including
+// exceeded limits, are returned as errors. Globals persist between calls, so
+// a script loaded with RunFile or Eval can later be called with Call.
+//
+// The methods of an Interpreter are safe for concurrent use, but run one at
+// a time; a Go builtin must not call back into the interpreter running it.
+type Interpreter struct {
+	mu     sync.Mutex
+	env    *r2core.Environment
+	config Config
+}
+
+// NewInterpreter creates an interpreter with the libraries and settings of
+// config.
+func NewInterpreter(config Config) (*Interpreter, error) {
+	env := r2core.NewEnvironment()
+	env.Set("true", true)
+	env.Set("false", false)
+	env.Set("nil", nil)
+	env.Set("null", nil)
+	env.Dir = config.BaseDir
+	if env.Dir == "" {
+		env.Dir = "."
+	}
+	env.CurrentFile = "<eval>"
+	env.SetOutput(config.Stdout, config.Stderr)
+	env.SetStrictTypes(config.Strict)
+
+	libraries := config.Libraries
+	if config.Sandbox {
+		root := config.Root
+		if root == "" {
+			root = env.Dir
+		}
+		r2libs.ApplyPolicy(env, SandboxPolicy(root, env.Dir))
+		if libraries == nil {
+			libraries = sandboxLibraries
+		}
+	}
+	if config.Policy != nil {
+		r2libs.ApplyPolicy(env, *config.Policy)
+	}
+	if err := registerLibraries(env, libraries, false); err != nil {
+		return nil, err
+	}
+	r2libs.RegisterArgs(env, "", config.Args)
+	if err := installPackages(env, env.Dir); err != nil {
+		return nil, err
+	}
+	return &Interpreter{env: env, config: config}, nil
+}
+
+// Eval runs src in the interpreter's global scope and returns the value of
+// its last statement. Unlike RunFile it does not call main().
+func (in *Interpreter) Eval(src string) (interface{}, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	prog, err := parse(src, in.env.CurrentFile)
+	if err != nil {
+		return nil, err
+	}
+	return in.run(func() interface{} { return prog.Eval(in.env) })
+}
+
+// RunFile runs the program in path like the r2 command does, calling main()
+// when the file defines it, and returns its result. Relative imports are
+// resolved against the directory of path.
+func (in *Interpreter) RunFile(path string) (interface{}, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := parse(string(data), path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, file := in.env.Dir, in.env.CurrentFile
+	in.env.Dir, in.env.CurrentFile = filepath.Dir(path), path
+	defer func() { in.env.Dir, in.env.CurrentFile = dir, file }()
+	return in.run(func() interface{} { return in.env.RunProgram(prog) })
+}
+
+// Call calls the global R2 function name with args and returns its result.
+// Go integers become exact R2 integers (int64, or a BigInt for a uint64
+// above math.MaxInt64) and float32 becomes an R2 number (float64).
+func (in *Interpreter) Call(name string, args ...interface{}) (interface{}, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fn, ok := in.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined function: %s", name)
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = toR2Value(arg)
+	}
+	switch f := fn.(type) {
+	case *r2core.UserFunction:
+		return in.run(func() interface{} { return f.Call(values...) })
+	case r2core.BuiltinFunction:
+		return in.run(func() interface{} { return f(values...) })
+	}
+	return nil, fmt.Errorf("%s is not a function (%T)", name, fn)
+}
+
+// Set defines or replaces the global name.
+func (in *Interpreter) Set(name string, value interface{}) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.env.Set(name, toR2Value(value))
+}
+
+// Get returns the value of the global name.
+func (in *Interpreter) Get(name string) (interface{}, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.env.Get(name)
+}
+
+// Environment returns the global environment, for registering additional
+// Go builtins or modules (see r2libs.RegisterModule).
+func (in *Interpreter) Environment() *r2core.Environment {
+	return in.env
+}
+
+// run evaluates fn under the configured limits and turns its panics into
+// errors.
+func (in *Interpreter) run(fn func() interface{}) (result interface{}, err error) {
+	limiter := in.env.GetLimiter()
+	if in.config.Limits.Timeout > 0 {
+		in.env.SetTimeout(in.config.Limits.Timeout)
+		defer limiter.Cancel()
+	} else {
+		// Cada ejecución empieza con el presupuesto completo
+		limiter.Reset()
+	}
+	if in.config.Limits.MaxMemory > 0 {
+		defer limiter.WatchMemory(in.config.Limits.MaxMemory, memoryCheckInterval)()
+	}
+	if in.config.Limits.MaxDepth > 0 {
+		limiter.MaxRecursionDepth = in.config.Limits.MaxDepth
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, panicError(r)
+		}
+	}()
+	return fn(), nil
+}
+
+// parse parses src and returns all of its syntax errors joined.
+func parse(src, filename string) (*r2core.Program, error) {
+	prog, errs := r2core.ParseWithErrors(src, filename)
+	if len(errs) == 0 {
+		return prog, nil
+	}
+	joined := make([]error, len(errs))
+	for i, e := range errs {
+		joined[i] = e
+	}
+	return nil, errors.Join(joined...)
+}
+
+// panicError converts the value of a panic raised by the interpreter into an
+// error, keeping the errors it already carries (such as *r2core.InfiniteLoopError).
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return errors.New(fmt.Sprint(r))
+}
+
+// toR2Value converts Go numbers to R2 numbers. Every Go integer becomes an
+// exact R2 integer, like int64: an int64, or a BigInt for an unsigned value
+// above math.MaxInt64. float32 becomes float64.
+func toR2Value(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case uint:
+		return toR2Value(uint64(n))
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case uint64:
+		if n > math.MaxInt64 {
+			return new(big.Int).SetUint64(n)
+		}
+		return int64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
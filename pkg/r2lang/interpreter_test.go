@@ -0,0 +1,329 @@
+package r2lang
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2mod"
+)
+
+func TestInterpreter_EvalAndCall(t *testing.T) {
+	var out bytes.Buffer
+	in, err := NewInterpreter(Config{Stdout: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := in.Eval("let base = 10\nfunc add(a, b) { return a + b + base }\nstd.print(\"loaded\")\nbase * 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != float64(20) {
+		t.Errorf("expected 20, got %v", val)
+	}
+	if out.String() != "loaded\n" {
+		t.Errorf("expected script output in the writer, got %q", out.String())
+	}
+
+	val, err = in.Call("add", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != int64(13) {
+		t.Errorf("expected int64 13, got %T %v", val, val)
+	}
+
+	in.Set("base", 100)
+	if val, _ := in.Call("add", 1, 2); val != int64(103) {
+		t.Errorf("expected Set to change the global, got %v", val)
+	}
+	if _, err := in.Call("missing"); err == nil {
+		t.Error("expected an error calling an undefined function")
+	}
+	if _, err := in.Call("base"); err == nil {
+		t.Error("expected an error calling a non-function")
+	}
+}
+
+func TestInterpreter_ExactIntegers(t *testing.T) {
+	in, err := NewInterpreter(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Eval("func next(n) { return n + 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := in.Call("next", int64(9007199254740993)); err != nil || val != int64(9007199254740994) {
+		t.Errorf("expected an int64 to stay exact, got %T %v (%v)", val, val, err)
+	}
+	val, err := in.Call("next", uint64(math.MaxUint64))
+	if b, ok := val.(*big.Int); err != nil || !ok || b.String() != "18446744073709551616" {
+		t.Errorf("expected a large uint64 to become a BigInt, got %T %v (%v)", val, val, err)
+	}
+	in.Set("small", uint64(7))
+	if val, _ := in.Eval("small"); val != int64(7) {
+		t.Errorf("expected a uint64 that fits to be an int64, got %T %v", val, val)
+	}
+	for _, v := range []interface{}{int(7), uint(7), int32(7), uint8(7)} {
+		if val, _ := in.Call("next", v); val != int64(8) {
+			t.Errorf("next(%T): expected int64 8, got %T %v", v, val, val)
+		}
+	}
+	if val, _ := in.Call("next", uint(math.MaxUint64)); fmt.Sprint(val) != "18446744073709551616" {
+		t.Errorf("expected a large uint to become a BigInt, got %T %v", val, val)
+	}
+}
+
+func TestInterpreter_Errors(t *testing.T) {
+	in, err := NewInterpreter(Config{Stdout: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := in.Eval("let x = (1 + \nlet y = ]"); err == nil {
+		t.Error("expected a parse error")
+	} else {
+		var perr *r2core.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("expected a *r2core.ParseError, got %T", err)
+		}
+	}
+
+	if _, err := in.Eval("undefinedVariable + 1"); err == nil {
+		t.Error("expected a runtime error")
+	}
+
+	in.Eval("func fail() { throw \"boom\" }")
+	if _, err := in.Call("fail"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the thrown value in the error, got %v", err)
+	}
+
+	// El intérprete sigue usable después de un error
+	if val, err := in.Eval("1 + 1"); err != nil || val != float64(2) {
+		t.Errorf("expected 2 after errors, got %v, %v", val, err)
+	}
+}
+
+func TestInterpreter_Libraries(t *testing.T) {
+	in, err := NewInterpreter(Config{Libraries: []string{"math"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Eval("math.sqrt(16)"); err != nil {
+		t.Errorf("expected math to be registered: %v", err)
+	}
+	if _, ok := in.Get("std"); ok {
+		t.Error("expected std not to be registered")
+	}
+	if _, ok := in.Get("os"); ok {
+		t.Error("expected os not to be registered")
+	}
+
+	if _, err := NewInterpreter(Config{Libraries: []string{"math", "nope"}}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected an error naming the unknown library, got %v", err)
+	}
+}
+
+func TestInterpreter_RunFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "import \"util.r2\" as util\nfunc main() { std.print(argv[0]); return util.twice(21) }\n",
+		"util.r2": "func twice(x) { return x * 2 }\n",
+	})
+	var out bytes.Buffer
+	in, err := NewInterpreter(Config{Stdout: &out, Args: []string{"hello"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := in.RunFile(filepath.Join(dir, "main.r2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != float64(42) {
+		t.Errorf("expected main's result 42, got %v", val)
+	}
+	if out.String() != "hello\n" {
+		t.Errorf("expected the script argument in the output, got %q", out.String())
+	}
+
+	if _, err := in.RunFile(filepath.Join(dir, "missing.r2")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestInterpreter_Timeout(t *testing.T) {
+	in, err := NewInterpreter(Config{Limits: Limits{Timeout: 50 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = in.Eval("let i = 0\nwhile (true) { i = i + 1 }")
+	if !errors.Is(err, r2core.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	// Cada llamada tiene su propio plazo
+	if val, err := in.Eval("1"); err != nil || val != float64(1) {
+		t.Errorf("expected a fresh deadline, got %v, %v", val, err)
+	}
+}
+
+func TestInterpreter_MaxDepth(t *testing.T) {
+	const deep = "func deep(n) { if (n == 0) { return 0 } return 1 + deep(n - 1) }\ndeep(3000)"
+	in, err := NewInterpreter(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Eval(deep); err == nil {
+		t.Error("expected the default depth limit to stop the recursion")
+	}
+
+	in, err = NewInterpreter(Config{Limits: Limits{MaxDepth: 5000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, err := in.Eval(deep); err != nil || val != float64(3000) {
+		t.Errorf("expected 3000, got %v, %v", val, err)
+	}
+}
+
+func TestInterpreter_Sandbox(t *testing.T) {
+	root := t.TempDir()
+	outside := writeFiles(t, map[string]string{"secret.r2": "let secret = 1\n"})
+	in, err := NewInterpreter(Config{Sandbox: true, Root: root, BaseDir: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := in.Eval(`io.writeFile("/../out.txt", "hi")`); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "out.txt")); err != nil || string(data) != "hi" {
+		t.Errorf("expected the write to land inside the root, got %q, %v", data, err)
+	}
+
+	if _, ok := in.Get("os"); ok {
+		t.Error("expected os not to be registered in the sandbox")
+	}
+	if _, err := in.Eval(`import "` + filepath.Join(outside, "secret.r2") + `" as s`); err == nil {
+		t.Error("expected an import from outside the sandbox to fail")
+	}
+}
+
+func TestLibraries_Globals(t *testing.T) {
+	for _, lib := range libraries {
+		env := r2core.NewEnvironment()
+		lib.register(env)
+		var defined []string
+		for name := range env.GetStore() {
+			defined = append(defined, name)
+		}
+		want := append([]string{}, lib.globals()...)
+		sort.Strings(defined)
+		sort.Strings(want)
+		if strings.Join(defined, ",") != strings.Join(want, ",") {
+			t.Errorf("library %s defines %v, but its globals are %v", lib.name, defined, want)
+		}
+	}
+}
+
+func TestNewEnvironment_LoadsLibrariesLazily(t *testing.T) {
+	env := newEnvironment("main.r2", Options{})
+	if _, loaded := env.GetStore()["json"]; loaded {
+		t.Fatal("expected json not to be registered before its first use")
+	}
+	if _, ok := env.Get("json"); !ok {
+		t.Fatal("expected json to be registered on first use")
+	}
+	if _, loaded := env.GetStore()["json"]; !loaded {
+		t.Error("expected json to stay registered in the global scope")
+	}
+	if _, ok := env.Get("PI"); !ok {
+		t.Error("expected PI to load the math library")
+	}
+	if _, ok := env.Get("nope"); ok {
+		t.Error("expected an unknown name to stay undeclared")
+	}
+}
+
+func TestInterpreter_StdImports(t *testing.T) {
+	in, err := NewInterpreter(Config{Libraries: []string{"std", "math"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := in.Eval("import { sqrt } from \"std:math\"\nimport \"std:math\" as m\nsqrt(16) + m.abs(-1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != float64(5) {
+		t.Errorf("expected 5, got %v", val)
+	}
+	if _, err := in.Eval("import \"std:json\""); err == nil || !strings.Contains(err.Error(), "std:json") {
+		t.Errorf("expected an error for a library that is not registered, got %v", err)
+	}
+	if _, err := in.Eval("import { nope } from \"std:math\""); err == nil || !strings.Contains(err.Error(), "does not export nope") {
+		t.Errorf("expected an error for a missing member, got %v", err)
+	}
+}
+
+func TestInterpreter_Packages(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app/r2.mod":          "module app\nrequire util ../libs/util\n",
+		"libs/util/index.r2":  "import \"./math.r2\" as m\nexport func twice(x) { return m.double(x) }\n",
+		"libs/util/math.r2":   "func double(x) { return x * 2 }\n",
+		"app/local/helper.r2": "export let name = \"local\"\n",
+	})
+	project, err := r2mod.LoadProject(filepath.Join(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := project.Get(nil, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	in, err := NewInterpreter(Config{BaseDir: filepath.Join(dir, "app")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := in.Eval("import { twice } from \"util\"\nimport { name } from \"local/helper.r2\"\ntwice(21) + name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "42local" {
+		t.Errorf("expected 42local, got %v", val)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "app", "r2.mod"), []byte("require\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewInterpreter(Config{BaseDir: filepath.Join(dir, "app")}); err == nil || !strings.Contains(err.Error(), "r2.mod:1") {
+		t.Errorf("expected an error for a broken r2.mod, got %v", err)
+	}
+}
+
+func TestInterpreter_Strict(t *testing.T) {
+	const src = "func half(x: number): number { return x / 2 }\nhalf(\"4\")"
+	loose, err := NewInterpreter(Config{Libraries: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loose.Eval(src); err != nil {
+		t.Errorf("expected the annotations to be ignored, got %v", err)
+	}
+	strict, err := NewInterpreter(Config{Libraries: []string{}, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strict.Eval(src); err == nil || !strings.Contains(err.Error(), "half: argument x must be number, got string") {
+		t.Errorf("expected the call to fail, got %v", err)
+	}
+}
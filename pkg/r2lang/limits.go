@@ -0,0 +1,126 @@
+package r2lang
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+// memoryCheckInterval is how often the live heap is compared against
+// Limits.MaxMemory.
+const memoryCheckInterval = 10 * time.Millisecond
+
+// timeoutGrace is how long a script may keep running past Limits.Timeout
+// before the process is terminated. Loops and function calls stop at the
+// deadline with a regular R2Lang error; the grace period only matters for a
+// script blocked inside a builtin (sleep, a network call, a server).
+const timeoutGrace = time.Second
+
+// Limits are the resource limits of a program run with RunCodeWithLimits,
+// RunCodeWithOptions or RunBytecode. The zero value keeps the interpreter defaults.
+type Limits struct {
+	// Timeout bounds the total run time, including goroutines started by
+	// the script. Zero keeps the default of the ExecutionLimiter.
+	Timeout time.Duration
+	// MaxMemory caps the live Go heap in bytes. Zero means no limit. Go
+	// cannot measure memory per script, so the heap checked is the one of
+	// the whole process: it is not safe for concurrent use, since a script
+	// can be stopped by memory that other goroutines allocated.
+	MaxMemory int64
+	// MaxDepth caps how deeply R2 calls may nest. Calls in tail position
+	// replace their caller and do not count. Zero keeps the default of the
+	// ExecutionLimiter (1000).
+	MaxDepth int
+}
+
+// Options configure a program run with RunCodeWithOptions or RunBytecode.
+type Options struct {
+	// Args are the script arguments, exposed as argv and process.args.
+	Args   []string
+	Limits Limits
+	// Profile, when set, receives a pprof profile of the time spent in
+	// each R2 function (see r2core.Profiler) once the program ends.
+	Profile io.Writer
+	// Sandbox runs the program with the sandbox profile: only the
+	// libraries of SandboxLibraries, under the SandboxPolicy rooted at Root
+	// (the current directory when empty).
+	Sandbox bool
+	Root    string
+	// Strict checks the type annotations of every function called against
+	// its arguments and its result, and of every let and const against its
+	// initial value (see r2core.Environment.SetStrictTypes).
+	Strict bool
+	// Stdout and Stderr receive the output of the program. Nil means
+	// os.Stdout and os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+	// Debugger, when set, stops the program at its breakpoints and steps
+	// (see r2core.Debugger). Another goroutine must drive it; see Debug.
+	Debugger *r2core.Debugger
+}
+
+// RunCodeWithLimits is RunCode with the given resource limits enforced.
+func RunCodeWithLimits(filename string, limits Limits) {
+	RunCodeWithOptions(filename, Options{Limits: limits})
+}
+
+// RunCodeWithOptions is RunCode with script arguments and resource limits.
+func RunCodeWithOptions(filename string, opts Options) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("Error reading the file %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	env := newEnvironment(filename, opts)
+	defer applyOptions(env, opts)()
+	env.Run(r2core.NewParserWithFile(string(data), filename))
+}
+
+// applyOptions configures env for opts and returns a function that releases
+// what it started and writes the requested profile. It runs even when the
+// program panics, so the profile covers failed runs too.
+func applyOptions(env *r2core.Environment, opts Options) (finish func()) {
+	release := applyLimits(env, opts.Limits)
+	var profiler *r2core.Profiler
+	if opts.Profile != nil {
+		profiler = r2core.StartProfile(env, r2core.DefaultProfilePeriod)
+	}
+	return func() {
+		release()
+		if profiler != nil {
+			if _, err := profiler.WriteTo(opts.Profile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing the R2 profile: %v\n", err)
+			}
+		}
+	}
+}
+
+// applyLimits configures env for limits and returns a function that releases
+// the monitors it started.
+func applyLimits(env *r2core.Environment, limits Limits) (release func()) {
+	var stops []func()
+	if limits.Timeout > 0 {
+		env.SetTimeout(limits.Timeout)
+		watchdog := time.AfterFunc(limits.Timeout+timeoutGrace, func() {
+			fmt.Fprintf(os.Stderr, "Error: execution timeout: the script was still running %v after the %v limit\n",
+				timeoutGrace, limits.Timeout)
+			os.Exit(1)
+		})
+		stops = append(stops, func() { watchdog.Stop() })
+	}
+	if limits.MaxMemory > 0 {
+		stops = append(stops, env.GetLimiter().WatchMemory(limits.MaxMemory, memoryCheckInterval))
+	}
+	if limits.MaxDepth > 0 {
+		env.GetLimiter().MaxRecursionDepth = limits.MaxDepth
+	}
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
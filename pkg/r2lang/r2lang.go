@@ -2,22 +2,20 @@ package r2lang
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/arturoeanton/go-r2lang/pkg/r2core"
 	"github.com/arturoeanton/go-r2lang/pkg/r2libs"
+	"github.com/arturoeanton/go-r2lang/pkg/r2mod"
 )
 
 func RunCode(filename string) {
+	RunCodeWithLimits(filename, Limits{})
+}
 
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("Error reading the file %s: %v\n", filename, err)
-		os.Exit(1)
-	}
-	code := string(data)
-
+// newEnvironment crea el entorno global de un programa con las librerías y
+// los argumentos de opts: todas las librerías, o las del perfil sandbox.
+func newEnvironment(filename string, opts Options) *r2core.Environment {
 	env := r2core.NewEnvironment()
 	env.Set("true", true)
 	env.Set("false", false)
@@ -25,40 +23,31 @@ func RunCode(filename string) {
 	env.Set("null", nil)
 	env.Dir = filepath.Dir(filename)
 	env.CurrentFile = filename // Set for position-aware errors
+	env.SetOutput(opts.Stdout, opts.Stderr)
+	env.SetStrictTypes(opts.Strict)
+	env.SetDebugger(opts.Debugger)
 
-	// Registrar otras librerías si las tienes:
-	r2libs.RegisterLib(env)
-	r2libs.RegisterStd(env)
-	r2libs.RegisterIO(env)
-	r2libs.RegisterHTTPClient(env)
-	r2libs.RegisterRequests(env)
-	r2libs.RegisterString(env)
-	r2libs.RegisterRegex(env)
-	r2libs.RegisterMath(env)
-	r2libs.RegisterRand(env)
-	r2libs.RegisterTest(env)
-	r2libs.RegisterHTTP(env)
-	r2libs.RegisterPrint(env)
-	r2libs.RegisterOS(env)
-	r2libs.RegisterHack(env)
-	r2libs.RegisterEncoding(env)
-	r2libs.RegisterConcurrency(env)
-	r2libs.RegisterSync(env)
-	r2libs.RegisterCollections(env)
-	r2libs.RegisterValidate(env)
-	r2libs.RegisterUnicode(env)
-	r2libs.RegisterDate(env)
-	r2libs.RegisterDB(env)
-	r2libs.RegisterSOAP(env)
-	r2libs.RegisterGRPC(env)
-	r2libs.RegisterJSON(env)
-	r2libs.RegisterXML(env)
-	r2libs.RegisterCSV(env)
-	r2libs.RegisterJWT(env)
-	r2libs.RegisterConsole(env)
-	r2libs.RegisterWeb(env)
-	r2libs.RegisterGoInterOp(env)
-	r2libs.RegisterGraph(env)
-	parser := r2core.NewParserWithFile(code, filename)
-	env.Run(parser)
+	if opts.Sandbox {
+		applySandbox(env, opts.Root, env.Dir)
+	} else {
+		registerLibraries(env, nil, true)
+	}
+	r2libs.RegisterArgs(env, filename, opts.Args)
+	if err := installPackages(env, env.Dir); err != nil {
+		panic(err.Error())
+	}
+	return env
+}
+
+// installPackages makes the packages of the project of dir (see r2mod)
+// importable by name in env. Outside a project it does nothing.
+func installPackages(env *r2core.Environment, dir string) error {
+	project, err := r2mod.FindProject(dir)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", r2mod.ManifestFile, err)
+	}
+	if project != nil {
+		env.SetModuleResolver(project)
+	}
+	return nil
 }
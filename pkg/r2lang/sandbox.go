@@ -0,0 +1,50 @@
+package r2lang
+
+import (
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2libs"
+)
+
+// sandboxLibraries are the libraries of the sandbox profile: the pure ones,
+// plus io and csv, whose files are confined to the sandbox root. os, hack, db
+// and every network library are left out.
+var sandboxLibraries = []string{
+	"lib", "std", "io", "string", "regex", "math", "rand", "test", "r2printer",
+	"encoding", "goroutine", "promise", "sync", "collections", "validate", "unicode",
+	"date", "json", "xml", "csv", "jwt", "console", "graph", "flags",
+}
+
+// SandboxLibraries returns the names of the libraries registered by the
+// sandbox profile.
+func SandboxLibraries() []string {
+	return append([]string{}, sandboxLibraries...)
+}
+
+// SandboxPolicy returns the capability policy of the sandbox profile. The io
+// module sees root as "/" and can read and write anything below it, imports
+// can also read from importDirs (usually the script's directory), and no
+// process can be started and no network connection opened.
+func SandboxPolicy(root string, importDirs ...string) r2libs.CapabilityPolicy {
+	if root == "" {
+		root = "."
+	}
+	return r2libs.CapabilityPolicy{
+		CommandPolicy:   r2libs.CommandPolicy{DisableShell: true},
+		DisableExec:     true,
+		FSRoot:          root,
+		RestrictFS:      true,
+		ReadRoots:       append([]string{}, importDirs...),
+		WriteRoots:      []string{root},
+		RestrictNetwork: true,
+	}
+}
+
+// applySandbox registers the sandbox libraries in env and installs the
+// sandbox policy rooted at root. Imports may read from importDir.
+func applySandbox(env *r2core.Environment, root, importDir string) {
+	r2libs.ApplyPolicy(env, SandboxPolicy(root, importDir))
+	// Los nombres son fijos: un error aquí es un bug de sandboxLibraries
+	if err := registerLibraries(env, sandboxLibraries, true); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,214 @@
+package r2lang
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+const (
+	defaultWatchInterval = 250 * time.Millisecond
+	defaultWatchDebounce = 100 * time.Millisecond
+	// watchStopGrace is how long a restart waits for the previous run to
+	// stop. Loops, calls and servers stop at once; a script blocked inside
+	// another builtin (sleep, a network call) is abandoned after it.
+	watchStopGrace = 5 * time.Second
+)
+
+// WatchOptions configure Watch. The zero value checks the files every 250ms
+// and restarts 100ms after the last change.
+type WatchOptions struct {
+	// Interval is how often the watched files are checked for changes.
+	Interval time.Duration
+	// Debounce is how long the files must stay unchanged before the
+	// program is restarted, so that a burst of saves restarts it once.
+	Debounce time.Duration
+}
+
+// Watch runs the program in filename like RunCodeWithOptions, and runs it
+// again every time filename or one of the files it imports changes, until
+// ctx is done. Every run starts from a fresh global environment. Before a
+// restart the previous run is cancelled: its loops and calls stop, and the
+// servers started by http.serve and web.listen are shut down gracefully.
+//
+// Parse and runtime errors are written to opts.Stderr and do not stop the
+// watcher; os.exit still exits the process. opts.Profile is ignored.
+func Watch(ctx context.Context, filename string, opts Options, wopts WatchOptions) {
+	if wopts.Interval <= 0 {
+		wopts.Interval = defaultWatchInterval
+	}
+	if wopts.Debounce <= 0 {
+		wopts.Debounce = defaultWatchDebounce
+	}
+	log := opts.Stderr
+	if log == nil {
+		log = os.Stderr
+	}
+
+	ticker := time.NewTicker(wopts.Interval)
+	defer ticker.Stop()
+
+	run := startWatchedRun(filename, opts)
+	runDone := run.done
+	last := snapshotFiles(run.files())
+	for {
+		select {
+		case <-ctx.Done():
+			run.stop(watchStopGrace)
+			return
+		case <-runDone:
+			runDone = nil
+			fmt.Fprintf(log, "[watch] %s finished, waiting for changes\n", filename)
+			continue
+		case <-ticker.C:
+		}
+
+		current := snapshotFiles(run.files())
+		changed := changedFiles(last, current, run.started)
+		if len(changed) == 0 {
+			last = current
+			continue
+		}
+		// Esperamos a que los archivos dejen de cambiar
+		for {
+			select {
+			case <-ctx.Done():
+				run.stop(watchStopGrace)
+				return
+			case <-time.After(wopts.Debounce):
+			}
+			next := snapshotFiles(run.files())
+			if len(changedFiles(current, next, run.started)) == 0 {
+				break
+			}
+			current = next
+		}
+
+		fmt.Fprintf(log, "[watch] %s changed, restarting\n", strings.Join(changed, ", "))
+		if !run.stop(watchStopGrace) {
+			fmt.Fprintf(log, "[watch] the previous run did not stop within %v, starting anyway\n", watchStopGrace)
+		}
+		run = startWatchedRun(filename, opts)
+		runDone = run.done
+		last = current
+	}
+}
+
+// watchedRun is one execution of the program under Watch.
+type watchedRun struct {
+	filename string
+	env      *r2core.Environment
+	started  time.Time
+	done     chan struct{}
+	stopped  atomic.Bool
+}
+
+// startWatchedRun runs filename in a new environment in the background.
+func startWatchedRun(filename string, opts Options) *watchedRun {
+	run := &watchedRun{
+		filename: filename,
+		env:      newEnvironment(filename, opts),
+		started:  time.Now(),
+		done:     make(chan struct{}),
+	}
+	// Los límites se configuran antes de arrancar para que stop cancele
+	// siempre el contexto vigente
+	if opts.Limits.Timeout > 0 {
+		run.env.SetTimeout(opts.Limits.Timeout)
+	}
+	var stopMemory func()
+	if opts.Limits.MaxMemory > 0 {
+		stopMemory = run.env.GetLimiter().WatchMemory(opts.Limits.MaxMemory, memoryCheckInterval)
+	}
+	if opts.Limits.MaxDepth > 0 {
+		run.env.GetLimiter().MaxRecursionDepth = opts.Limits.MaxDepth
+	}
+
+	go func() {
+		defer close(run.done)
+		if stopMemory != nil {
+			defer stopMemory()
+		}
+		defer func() {
+			if r := recover(); r != nil && !run.stopped.Load() {
+				fmt.Fprintf(run.env.Stderr(), "Error: %v\n", panicError(r))
+			}
+		}()
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			panic(err)
+		}
+		prog, err := parse(string(data), filename)
+		if err != nil {
+			panic(err)
+		}
+		run.env.RunProgram(prog)
+	}()
+	return run
+}
+
+// stop cancels the run and waits up to grace for it to finish. It reports
+// whether the run finished.
+func (run *watchedRun) stop(grace time.Duration) bool {
+	run.stopped.Store(true)
+	run.env.GetLimiter().Cancel()
+	select {
+	case <-run.done:
+		return true
+	case <-time.After(grace):
+		return false
+	}
+}
+
+// files returns the program file and the files imported by the run so far.
+func (run *watchedRun) files() []string {
+	return append([]string{run.filename}, run.env.ImportedFiles()...)
+}
+
+// fileState is what Watch compares to detect a change in a file.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func snapshotFiles(files []string) map[string]fileState {
+	states := make(map[string]fileState, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			states[file] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+		} else {
+			states[file] = fileState{}
+		}
+	}
+	return states
+}
+
+// changedFiles returns the files of current whose state differs from the one
+// in last. A file that is not in last was imported after the last check, and
+// counts as changed only if it was modified after since, when the run started.
+func changedFiles(last, current map[string]fileState, since time.Time) []string {
+	var changed []string
+	for file, state := range current {
+		prev, ok := last[file]
+		if !ok {
+			if state.modTime.After(since) {
+				changed = append(changed, filepath.Base(file))
+			}
+			continue
+		}
+		if prev.exists != state.exists || prev.size != state.size || !prev.modTime.Equal(state.modTime) {
+			changed = append(changed, filepath.Base(file))
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,124 @@
+package r2lang
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a run.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// startWatch runs Watch on dir/main.r2 until the test ends.
+func startWatch(t *testing.T, dir string, out, errOut io.Writer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Watch(ctx, filepath.Join(dir, "main.r2"), Options{Stdout: out, Stderr: errOut},
+			WatchOptions{Interval: 20 * time.Millisecond, Debounce: 20 * time.Millisecond})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatch_RestartsOnImportChange(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.r2": "import \"util.r2\" as util\nlet count = 0\ncount = count + 1\nstd.print(util.name() + \" \" + count)\n",
+		"util.r2": "func name() { return \"one\" }\n",
+	})
+	out, errOut := &syncBuffer{}, &syncBuffer{}
+	startWatch(t, dir, out, errOut)
+	waitFor(t, "the first run", func() bool { return strings.Contains(errOut.String(), "finished") })
+
+	if err := os.WriteFile(filepath.Join(dir, "util.r2"), []byte("func name() { return \"two\" }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Cada ejecución empieza con un entorno nuevo: count vuelve a 1
+	waitFor(t, "the restart", func() bool { return strings.Contains(out.String(), "two 1") })
+	if !strings.Contains(out.String(), "one 1") {
+		t.Errorf("expected the first run's output, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "util.r2 changed") {
+		t.Errorf("expected the changed file in the log, got %q", errOut.String())
+	}
+
+	// Un error de sintaxis se informa y el watcher sigue esperando cambios
+	if err := os.WriteFile(filepath.Join(dir, "main.r2"), []byte("let x = (\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the parse error", func() bool { return strings.Contains(errOut.String(), "Error: ") })
+	if err := os.WriteFile(filepath.Join(dir, "main.r2"), []byte("std.print(\"fixed\")\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the fixed run", func() bool { return strings.Contains(out.String(), "fixed") })
+}
+
+func TestWatch_RestartsServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	server := func(body string) string {
+		return "http.handler(\"GET\", \"/\", func(vars, method, body) { return \"" + body + "\" })\n" +
+			"http.serve(\"" + addr + "\")\n"
+	}
+	dir := writeFiles(t, map[string]string{"main.r2": server("first")})
+	startWatch(t, dir, &syncBuffer{}, &syncBuffer{})
+
+	get := func() string {
+		resp, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	waitFor(t, "the first server", func() bool { return get() == "first" })
+
+	if err := os.WriteFile(filepath.Join(dir, "main.r2"), []byte(server("second")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the restarted server", func() bool { return get() == "second" })
+}
@@ -1,6 +1,7 @@
 package r2libs
 
 import (
+	"math/big"
 	"strconv"
 
 	"github.com/arturoeanton/go-r2lang/pkg/r2core"
@@ -12,6 +13,13 @@ func toFloat(val interface{}) float64 {
 		return v
 	case int:
 		return float64(v)
+	case int64:
+		return float64(v)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case *r2core.DecimalValue:
+		return v.Float64()
 	case bool:
 		if v {
 			return 1
@@ -22,12 +30,34 @@ func toFloat(val interface{}) float64 {
 	case string:
 		f, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			panic("Cannot convert string to number:" + v)
+			panic(&r2core.RuntimeError{Class: "TypeError", Message: "Cannot convert string to number:" + v})
 		}
 		return f
 	}
-	panic("Cannot convert value to number")
+	panic(&r2core.RuntimeError{Class: "TypeError", Message: "Cannot convert value to number"})
+}
+
+// asNumber es la comprobación de los argumentos numéricos de las librerías:
+// acepta los cuatro tipos numéricos (number, int, bigint, decimal) y devuelve
+// su valor como float64. A diferencia de toFloat, no convierte strings,
+// booleanos ni nil.
+func asNumber(val interface{}) (float64, bool) {
+	if !isNumeric(val) {
+		return 0, false
+	}
+	return toFloat(val), true
 }
+
+// lessNumbers compara a y b si los dos son numéricos, con las reglas de
+// promoción de r2core.
+func lessNumbers(a, b interface{}) (less, ok bool) {
+	if !isNumeric(a) || !isNumeric(b) {
+		return false, false
+	}
+	cmp, ok := r2core.CompareNumbers(a, b)
+	return cmp < 0, ok
+}
+
 func toBool(val interface{}) bool {
 	if val == nil {
 		return false
@@ -39,6 +69,12 @@ func toBool(val interface{}) bool {
 		return v != 0
 	case int:
 		return v != 0
+	case int64:
+		return v != 0
+	case *big.Int:
+		return v.Sign() != 0
+	case *r2core.DecimalValue:
+		return v.Sign() != 0
 	case string:
 		return v != ""
 	}
@@ -48,7 +84,7 @@ func toBool(val interface{}) bool {
 // Para unificar la lógica numérica en "=="
 func isNumeric(v interface{}) bool {
 	switch v.(type) {
-	case float64, int:
+	case float64, int, int64, *big.Int, *r2core.DecimalValue:
 		return true
 	}
 	return false
@@ -56,9 +92,10 @@ func isNumeric(v interface{}) bool {
 
 // Corrige la comparación "=="
 func equals(a, b interface{}) bool {
-	// Si ambos son numéricos, compare con toFloat
+	// Si ambos son numéricos, compare con las reglas de promoción de r2core
 	if isNumeric(a) && isNumeric(b) {
-		return toFloat(a) == toFloat(b)
+		cmp, _ := r2core.CompareNumbers(a, b)
+		return cmp == 0
 	}
 	// sino comparamos string/bool/nil
 	switch aa := a.(type) {
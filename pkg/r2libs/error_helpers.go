@@ -3,28 +3,33 @@ package r2libs
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
 )
 
-// ArgumentError generates an improved argument error
+// ArgumentError generates an improved argument error, caught as an
+// ArgumentError
 func ArgumentError(function string, expected string, received int) {
 	msg := fmt.Sprintf("Function '%s': expected %s, but received %d arguments",
 		function, expected, received)
-	panic(msg)
+	panic(&r2core.RuntimeError{Class: "ArgumentError", Message: msg})
 }
 
-// TypeArgumentError generates an improved type argument error
+// TypeArgumentError generates an improved type argument error, caught as a
+// TypeError
 func TypeArgumentError(function string, argIndex int, expected string, received interface{}) {
 	receivedType := getTypeName(received)
 	msg := fmt.Sprintf("Function '%s': argument %d must be %s, but got %s (value: %v)",
 		function, argIndex+1, expected, receivedType, received)
-	panic(msg)
+	panic(&r2core.RuntimeError{Class: "TypeError", Message: msg})
 }
 
-// MathError generates specific mathematical errors
+// MathError generates specific mathematical errors, caught as an
+// ArithmeticError
 func MathError(function string, operation string, value interface{}) {
 	msg := fmt.Sprintf("Math error in function '%s': %s (value: %v)",
 		function, operation, value)
-	panic(msg)
+	panic(&r2core.RuntimeError{Class: "ArithmeticError", Message: msg})
 }
 
 // getTypeName returns the name of a value's type
@@ -3,12 +3,18 @@ package r2libs
 import (
 	"strings"
 	"testing"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
 )
 
 func TestArgumentError(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
-			msg := r.(string)
+			err, ok := r.(*r2core.RuntimeError)
+			if !ok || err.Class != "ArgumentError" {
+				t.Fatalf("Expected a ArgumentError, got: %#v", r)
+			}
+			msg := err.Message
 			if !strings.Contains(msg, "Function 'sin'") {
 				t.Errorf("Expected function name in error, got: %s", msg)
 			}
@@ -29,7 +35,11 @@ func TestArgumentError(t *testing.T) {
 func TestTypeArgumentError(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
-			msg := r.(string)
+			err, ok := r.(*r2core.RuntimeError)
+			if !ok || err.Class != "TypeError" {
+				t.Fatalf("Expected a TypeError, got: %#v", r)
+			}
+			msg := err.Message
 			if !strings.Contains(msg, "Function 'sqrt'") {
 				t.Errorf("Expected function name in error, got: %s", msg)
 			}
@@ -53,7 +63,11 @@ func TestTypeArgumentError(t *testing.T) {
 func TestMathError(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
-			msg := r.(string)
+			err, ok := r.(*r2core.RuntimeError)
+			if !ok || err.Class != "ArithmeticError" {
+				t.Fatalf("Expected a ArithmeticError, got: %#v", r)
+			}
+			msg := err.Message
 			if !strings.Contains(msg, "Math error in function 'sqrt'") {
 				t.Errorf("Expected math error for sqrt, got: %s", msg)
 			}
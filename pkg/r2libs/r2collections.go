@@ -31,7 +31,7 @@ func (s *sortableSlice) Less(i, j int) bool {
 			return b
 		}
 		// If it returns a number, interpret < 0 as true
-		if f, ok := result.(float64); ok {
+		if f, ok := asNumber(result); ok {
 			return f < 0
 		}
 		panic("Comparison function must return boolean or number")
@@ -42,10 +42,8 @@ func (s *sortableSlice) Less(i, j int) bool {
 	valJ := s.slice[j]
 
 	// Try to compare as numbers
-	if fI, okI := valI.(float64); okI {
-		if fJ, okJ := valJ.(float64); okJ {
-			return fI < fJ
-		}
+	if less, ok := lessNumbers(valI, valJ); ok {
+		return less
 	}
 
 	// Fallback to string comparison
@@ -61,8 +59,8 @@ func RegisterCollections(env *r2core.Environment) {
 			if len(args) != 2 {
 				panic("range: solo se aceptan 2 argumentos")
 			}
-			startF, ok1 := args[0].(float64)
-			endF, ok2 := args[1].(float64)
+			startF, ok1 := asNumber(args[0])
+			endF, ok2 := asNumber(args[1])
 			if !ok1 || !ok2 {
 				panic("range: los argumentos deben ser numéricos")
 			}
@@ -82,7 +80,7 @@ func RegisterCollections(env *r2core.Environment) {
 			if len(args) != 2 {
 				panic("repeat: solo se aceptan 2 argumentos")
 			}
-			endF, ok := args[0].(float64)
+			endF, ok := asNumber(args[0])
 			if !ok {
 				panic("repeat: el primer argumento debe ser numérico")
 			}
@@ -122,8 +120,8 @@ func RegisterCollections(env *r2core.Environment) {
 			if !ok {
 				panic("slice: el primer argumento debe ser un array")
 			}
-			startF, ok1 := args[1].(float64)
-			endF, ok2 := args[2].(float64)
+			startF, ok1 := asNumber(args[1])
+			endF, ok2 := asNumber(args[2])
 			if !ok1 || !ok2 {
 				panic("slice: start y end deben ser numéricos")
 			}
@@ -155,6 +153,16 @@ func RegisterCollections(env *r2core.Environment) {
 			}
 			arr, ok1 := args[0].([]interface{})
 			fn, ok2 := args[1].(*r2core.UserFunction)
+			if it, isIter := r2core.GetIterator(args[0]); isIter && ok2 {
+				// Sobre un iterador el resultado también es perezoso
+				return r2core.NewIterator(func() (interface{}, bool) {
+					v, done := it.Next()
+					if done {
+						return nil, true
+					}
+					return fn.Call(v), false
+				}, func() { r2core.CloseIterator(it) })
+			}
 			if !ok1 || !ok2 {
 				panic("map: los argumentos deben ser (array, funcion)")
 			}
@@ -170,6 +178,19 @@ func RegisterCollections(env *r2core.Environment) {
 			}
 			arr, ok1 := args[0].([]interface{})
 			fn, ok2 := args[1].(*r2core.UserFunction)
+			if it, isIter := r2core.GetIterator(args[0]); isIter && ok2 {
+				return r2core.NewIterator(func() (interface{}, bool) {
+					for {
+						v, done := it.Next()
+						if done {
+							return nil, true
+						}
+						if toBool(fn.Call(v)) {
+							return v, false
+						}
+					}
+				}, func() { r2core.CloseIterator(it) })
+			}
 			if !ok1 || !ok2 {
 				panic("filter: los argumentos deben ser (array, funcion)")
 			}
@@ -185,7 +206,7 @@ func RegisterCollections(env *r2core.Environment) {
 			if len(args) != 3 {
 				panic("reduce: se aceptan 3 argumentos (array, funcion, inicial)")
 			}
-			arr, ok1 := args[0].([]interface{})
+			arr, ok1 := iterableArg(args[0])
 			fn, ok2 := args[1].(*r2core.UserFunction)
 			if !ok1 || !ok2 {
 				panic("reduce: los argumentos deben ser (array, funcion, inicial)")
@@ -221,8 +242,21 @@ func RegisterCollections(env *r2core.Environment) {
 			if len(args) != 2 {
 				panic("find: se aceptan 2 argumentos (array, funcion)")
 			}
-			arr, ok1 := args[0].([]interface{})
 			fn, ok2 := args[1].(*r2core.UserFunction)
+			if it, isIter := r2core.GetIterator(args[0]); isIter && ok2 {
+				// Se detiene en el primero: no consume el resto
+				defer r2core.CloseIterator(it)
+				for {
+					v, done := it.Next()
+					if done {
+						return nil
+					}
+					if toBool(fn.Call(v)) {
+						return v
+					}
+				}
+			}
+			arr, ok1 := args[0].([]interface{})
 			if !ok1 || !ok2 {
 				panic("find: los argumentos deben ser (array, funcion)")
 			}
@@ -237,11 +271,23 @@ func RegisterCollections(env *r2core.Environment) {
 			if len(args) != 2 {
 				panic("contains: se aceptan 2 argumentos (array, valor)")
 			}
+			val := args[1]
+			if it, isIter := r2core.GetIterator(args[0]); isIter {
+				defer r2core.CloseIterator(it)
+				for {
+					v, done := it.Next()
+					if done {
+						return false
+					}
+					if equals(v, val) {
+						return true
+					}
+				}
+			}
 			arr, ok := args[0].([]interface{})
 			if !ok {
 				panic("contains: el primer argumento debe ser un array")
 			}
-			val := args[1]
 			for _, v := range arr {
 				if equals(v, val) {
 					return true
@@ -318,7 +364,7 @@ func RegisterCollections(env *r2core.Environment) {
 			}
 			depth := 1
 			if len(args) == 2 {
-				d, ok := args[1].(float64)
+				d, ok := asNumber(args[1])
 				if !ok {
 					panic("flatten: depth debe ser numérico")
 				}
@@ -338,7 +384,7 @@ func RegisterCollections(env *r2core.Environment) {
 			if !ok {
 				panic("chunk: el primer argumento debe ser un array")
 			}
-			sizeF, ok := args[1].(float64)
+			sizeF, ok := asNumber(args[1])
 			if !ok {
 				panic("chunk: size debe ser numérico")
 			}
@@ -447,10 +493,8 @@ func RegisterCollections(env *r2core.Environment) {
 			}
 			sort.SliceStable(pairs, func(i, j int) bool {
 				ki, kj := pairs[i].key, pairs[j].key
-				if fi, ok := ki.(float64); ok {
-					if fj, ok := kj.(float64); ok {
-						return fi < fj
-					}
+				if less, ok := lessNumbers(ki, kj); ok {
+					return less
 				}
 				return fmt.Sprintf("%v", ki) < fmt.Sprintf("%v", kj)
 			})
@@ -473,11 +517,79 @@ func RegisterCollections(env *r2core.Environment) {
 			}
 			return deepCopy(args[0])
 		}),
+
+		"iter": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("iter: solo se acepta un argumento")
+			}
+			it, ok := r2core.ToIterator(args[0])
+			if !ok {
+				panic("iter: el argumento debe ser un array o un iterador")
+			}
+			return it
+		}),
+		"take": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			it, n := iteratorAndCount("take", args)
+			taken := 0
+			return r2core.NewIterator(func() (interface{}, bool) {
+				if taken >= n {
+					return nil, true
+				}
+				taken++
+				return it.Next()
+			}, func() { r2core.CloseIterator(it) })
+		}),
+		"skip": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			it, n := iteratorAndCount("skip", args)
+			return r2core.NewIterator(func() (interface{}, bool) {
+				for ; n > 0; n-- {
+					if _, done := it.Next(); done {
+						return nil, true
+					}
+				}
+				return it.Next()
+			}, func() { r2core.CloseIterator(it) })
+		}),
+		"toArray": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("toArray: solo se acepta un argumento")
+			}
+			arr, ok := iterableArg(args[0])
+			if !ok {
+				panic("toArray: el argumento debe ser un array o un iterador")
+			}
+			return arr
+		}),
 	}
 
 	RegisterModule(env, "collections", functions)
 }
 
+// iterableArg devuelve los elementos de un array, o los de un iterador
+// consumiéndolo entero.
+func iterableArg(v interface{}) ([]interface{}, bool) {
+	if arr, ok := toGenericSlice(v); ok {
+		return arr, true
+	}
+	if it, ok := r2core.GetIterator(v); ok {
+		return r2core.CollectIterator(it), true
+	}
+	return nil, false
+}
+
+// iteratorAndCount valida los argumentos (iterable, n) de take y skip.
+func iteratorAndCount(name string, args []interface{}) (r2core.Iterator, int) {
+	if len(args) != 2 {
+		panic(name + ": se aceptan 2 argumentos (iterable, cantidad)")
+	}
+	it, ok1 := r2core.ToIterator(args[0])
+	n, ok2 := asNumber(args[1])
+	if !ok1 || !ok2 {
+		panic(name + ": los argumentos deben ser (iterable, numero)")
+	}
+	return it, int(n)
+}
+
 func toGenericSlice(v interface{}) ([]interface{}, bool) {
 	switch s := v.(type) {
 	case []interface{}:
@@ -541,9 +653,69 @@ func deepEqualValues(a, b interface{}, seen map[[2]uintptr]bool) bool {
 		return true
 	}
 
+	if _, ok := a.(r2core.KeyedCollection); ok {
+		return deepEqualKeyed(a, b, seen)
+	}
+	switch av := a.(type) {
+	case *r2core.Set:
+		// Members are compared the way has() does, not deeply
+		bv, ok := b.(*r2core.Set)
+		if !ok || av.Len() != bv.Len() {
+			return false
+		}
+		for _, v := range av.Values() {
+			if !bv.Has(v) {
+				return false
+			}
+		}
+		return true
+	case *r2core.ImmutableList:
+		bv, ok := b.(*r2core.ImmutableList)
+		if !ok || av.Len() != bv.Len() {
+			return false
+		}
+		key := [2]uintptr{reflect.ValueOf(av).Pointer(), reflect.ValueOf(bv).Pointer()}
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		defer delete(seen, key)
+		for i := 0; i < av.Len(); i++ {
+			if !deepEqualValues(av.Get(i), bv.Get(i), seen) {
+				return false
+			}
+		}
+		return true
+	}
+
 	return equals(a, b)
 }
 
+// deepEqualKeyed compares a Map or ImmutableMap with another of the same
+// kind: same keys (in any order) with deeply equal values.
+func deepEqualKeyed(a, b interface{}, seen map[[2]uintptr]bool) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	ak, bk := a.(r2core.KeyedCollection), b.(r2core.KeyedCollection)
+	if ak.Len() != bk.Len() {
+		return false
+	}
+	key := [2]uintptr{reflect.ValueOf(a).Pointer(), reflect.ValueOf(b).Pointer()}
+	if seen[key] {
+		return true
+	}
+	seen[key] = true
+	defer delete(seen, key)
+	equal := true
+	ak.Range(func(k, av interface{}) bool {
+		bv, ok := bk.Get(k)
+		equal = ok && deepEqualValues(av, bv, seen)
+		return equal
+	})
+	return equal
+}
+
 // maxFlattenDepth bounds recursion in flattenArray so that a deeply nested
 // (or self-referential, e.g. built via `a[0] = a`) array cannot trigger an
 // unrecoverable Go "stack overflow" fatal error, which panic/recover cannot
@@ -161,3 +161,44 @@ func TestDeepCloneAliasesDeepCopy(t *testing.T) {
 		t.Fatal("mutating clone mutated original: deepClone is not a deep copy")
 	}
 }
+
+func TestDeepEqualCollections(t *testing.T) {
+	mod := collectionsModuleForTest(t)
+	deepEqual := mod["deepEqual"].(r2core.BuiltinFunction)
+
+	mapOf := func(pairs ...interface{}) *r2core.OrderedMap {
+		m := r2core.NewOrderedMap()
+		for i := 0; i < len(pairs); i += 2 {
+			m.Set(pairs[i], pairs[i+1])
+		}
+		return m
+	}
+	cases := []struct {
+		name     string
+		a, b     interface{}
+		expected bool
+	}{
+		{"sets in any order", r2core.NewSet(1.0, 2.0), r2core.NewSet(2.0, 1.0), true},
+		{"different sets", r2core.NewSet(1.0, 2.0), r2core.NewSet(1.0, 3.0), false},
+		{"maps in any order", mapOf(1.0, []interface{}{"a"}, "b", 2.0), mapOf("b", 2.0, 1.0, []interface{}{"a"}), true},
+		{"maps with different values", mapOf(1.0, []interface{}{"a"}), mapOf(1.0, []interface{}{"b"}), false},
+		{"map and immutable map", mapOf("a", 1.0), r2core.NewImmutableMap().Set("a", 1.0), false},
+		{"immutable lists", r2core.NewImmutableList(1.0, []interface{}{2.0}), r2core.NewImmutableList(1.0, []interface{}{2.0}), true},
+		{"immutable list and array", r2core.NewImmutableList(1.0), []interface{}{1.0}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := deepEqual(tc.a, tc.b).(bool); got != tc.expected {
+				t.Errorf("deepEqual(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.expected)
+			}
+		})
+	}
+
+	// A Map that contains itself must not recurse forever
+	a, b := r2core.NewOrderedMap(), r2core.NewOrderedMap()
+	a.Set("self", a)
+	b.Set("self", b)
+	if !deepEqual(a, b).(bool) {
+		t.Errorf("deepEqual on identically-shaped cyclic Maps = false, want true")
+	}
+}
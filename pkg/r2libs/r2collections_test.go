@@ -1,6 +1,7 @@
 package r2libs
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/arturoeanton/go-r2lang/pkg/r2core"
@@ -409,6 +410,45 @@ func TestCollectionsSlice_EndInclusiveOfLastElement(t *testing.T) {
 	}()
 }
 
+func TestCollections_LazyIterators(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterCollections(env)
+	env.Set("true", true)
+	env.Set("false", false)
+
+	code := `
+		let pulled = 0
+		func* naturals() {
+			let n = 0
+			while (true) {
+				pulled = pulled + 1
+				yield n
+				n = n + 1
+			}
+		}
+		let evens = collections.filter(naturals(), func(x) { return x % 2 == 0 })
+		let squares = collections.map(evens, func(x) { return x * x })
+		let first = collections.toArray(collections.take(squares, 3))
+		let pulledAfterTake = pulled
+		[
+			first,
+			pulledAfterTake,
+			collections.reduce(collections.take(naturals(), 5), func(a, b) { return a + b }, 0),
+			collections.find(naturals(), func(x) { return x > 10 }),
+			collections.contains(collections.take(naturals(), 3), 2),
+			collections.toArray(collections.skip(collections.iter([1, 2, 3, 4]), 2)),
+			collections.map([1, 2], func(x) { return x + 1 })
+		]
+	`
+	got := r2core.NewParser(code).ParseProgram().Eval(env).([]interface{})
+	want := []string{"[0 4 16]", "5", "10", "11", "true", "[3 4]", "[2 3]"}
+	for i, w := range want {
+		if s := fmt.Sprint(got[i]); s != w {
+			t.Errorf("item %d: expected %s, got %s", i, w, s)
+		}
+	}
+}
+
 func mustGetModule(t *testing.T, env *r2core.Environment, name string) map[string]interface{} {
 	t.Helper()
 	obj, ok := env.Get(name)
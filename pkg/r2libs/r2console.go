@@ -3,6 +3,7 @@ package r2libs
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
@@ -15,36 +16,36 @@ func RegisterConsole(env *r2core.Environment) {
 	functions := map[string]r2core.BuiltinFunction{
 		"log": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			timestamp := time.Now().Format("15:04:05")
-			consoleWrite(fmt.Sprintf("[%s] %s\n", timestamp, joinConsoleArgs(args)))
+			consoleWrite(env.Stdout(), fmt.Sprintf("[%s] %s\n", timestamp, joinConsoleArgs(args)))
 			return nil
 		}),
 
 		"info": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			timestamp := time.Now().Format("15:04:05")
-			consoleWrite(fmt.Sprintf("\033[36m[%s] INFO:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
+			consoleWrite(env.Stdout(), fmt.Sprintf("\033[36m[%s] INFO:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
 			return nil
 		}),
 
 		"warn": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			timestamp := time.Now().Format("15:04:05")
-			consoleWrite(fmt.Sprintf("\033[33m[%s] WARN:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
+			consoleWrite(env.Stdout(), fmt.Sprintf("\033[33m[%s] WARN:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
 			return nil
 		}),
 
 		"error": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			timestamp := time.Now().Format("15:04:05")
-			consoleWrite(fmt.Sprintf("\033[31m[%s] ERROR:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
+			consoleWrite(env.Stdout(), fmt.Sprintf("\033[31m[%s] ERROR:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
 			return nil
 		}),
 
 		"debug": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			timestamp := time.Now().Format("15:04:05")
-			consoleWrite(fmt.Sprintf("\033[35m[%s] DEBUG:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
+			consoleWrite(env.Stdout(), fmt.Sprintf("\033[35m[%s] DEBUG:\033[0m %s\n", timestamp, joinConsoleArgs(args)))
 			return nil
 		}),
 
 		"clear": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Print("\033[2J\033[H")
+			fmt.Fprint(env.Stdout(), "\033[2J\033[H")
 			return nil
 		}),
 
@@ -53,12 +54,12 @@ func RegisterConsole(env *r2core.Environment) {
 			if len(args) > 0 {
 				label = fmt.Sprintf("%v", args[0])
 			}
-			fmt.Printf("\033[1m▼ %s\033[0m\n", label)
+			fmt.Fprintf(env.Stdout(), "\033[1m▼ %s\033[0m\n", label)
 			return nil
 		}),
 
 		"groupEnd": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Println()
+			fmt.Fprintln(env.Stdout())
 			return nil
 		}),
 
@@ -69,11 +70,11 @@ func RegisterConsole(env *r2core.Environment) {
 
 			switch data := args[0].(type) {
 			case []interface{}:
-				printArrayTable(data)
+				printArrayTable(env.Stdout(), data)
 			case map[string]interface{}:
-				printObjectTable(data)
+				printObjectTable(env.Stdout(), data)
 			default:
-				fmt.Printf("| %v |\n", data)
+				fmt.Fprintf(env.Stdout(), "| %v |\n", data)
 			}
 			return nil
 		}),
@@ -85,7 +86,7 @@ func RegisterConsole(env *r2core.Environment) {
 			}
 			startTime := time.Now()
 			setConsoleTimer(label, startTime)
-			fmt.Printf("Timer '%s' started\n", label)
+			fmt.Fprintf(env.Stdout(), "Timer '%s' started\n", label)
 			return nil
 		}),
 
@@ -97,10 +98,10 @@ func RegisterConsole(env *r2core.Environment) {
 			startTime := getConsoleTimer(label)
 			if startTime != nil {
 				elapsed := time.Since(*startTime)
-				fmt.Printf("Timer '%s': %v\n", label, elapsed)
+				fmt.Fprintf(env.Stdout(), "Timer '%s': %v\n", label, elapsed)
 				removeConsoleTimer(label)
 			} else {
-				fmt.Printf("Timer '%s' not found\n", label)
+				fmt.Fprintf(env.Stdout(), "Timer '%s' not found\n", label)
 			}
 			return nil
 		}),
@@ -111,7 +112,7 @@ func RegisterConsole(env *r2core.Environment) {
 				label = fmt.Sprintf("%v", args[0])
 			}
 			count := incrementConsoleCounter(label)
-			fmt.Printf("%s: %d\n", label, count)
+			fmt.Fprintf(env.Stdout(), "%s: %d\n", label, count)
 			return nil
 		}),
 
@@ -121,7 +122,7 @@ func RegisterConsole(env *r2core.Environment) {
 				label = fmt.Sprintf("%v", args[0])
 			}
 			resetConsoleCounter(label)
-			fmt.Printf("Counter '%s' reset\n", label)
+			fmt.Fprintf(env.Stdout(), "Counter '%s' reset\n", label)
 			return nil
 		}),
 
@@ -133,7 +134,7 @@ func RegisterConsole(env *r2core.Environment) {
 			condition := false
 			if val, ok := args[0].(bool); ok {
 				condition = val
-			} else if val, ok := args[0].(float64); ok {
+			} else if val, ok := asNumber(args[0]); ok {
 				condition = val != 0
 			} else if val, ok := args[0].(string); ok {
 				condition = val != ""
@@ -147,7 +148,7 @@ func RegisterConsole(env *r2core.Environment) {
 					b.WriteString(joinConsoleArgs(args[1:]))
 				}
 				b.WriteString("\033[0m\n")
-				consoleWrite(b.String())
+				consoleWrite(env.Stdout(), b.String())
 			}
 			return nil
 		}),
@@ -175,12 +176,12 @@ func RegisterConsole(env *r2core.Environment) {
 			default:
 				fmt.Fprintf(&b, "%T: %v\n", obj, obj)
 			}
-			consoleWrite(b.String())
+			consoleWrite(env.Stdout(), b.String())
 			return nil
 		}),
 
 		"trace": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			consoleWrite("\033[35mTrace:" + joinConsoleArgs(args) + "\033[0m\n")
+			consoleWrite(env.Stdout(), "\033[35mTrace:"+joinConsoleArgs(args)+"\033[0m\n")
 			return nil
 		}),
 
@@ -190,7 +191,7 @@ func RegisterConsole(env *r2core.Environment) {
 				message = fmt.Sprintf("%v", args[0])
 			}
 
-			fmt.Print(message + " ")
+			fmt.Fprint(env.Stdout(), message+" ")
 
 			reader := bufio.NewReader(os.Stdin)
 			input, err := reader.ReadString('\n')
@@ -207,7 +208,7 @@ func RegisterConsole(env *r2core.Environment) {
 				message = fmt.Sprintf("%v", args[0])
 			}
 
-			fmt.Print(message + " ")
+			fmt.Fprint(env.Stdout(), message+" ")
 
 			reader := bufio.NewReader(os.Stdin)
 			input, err := reader.ReadString('\n')
@@ -226,7 +227,7 @@ func RegisterConsole(env *r2core.Environment) {
 			}
 
 			if prompt != "" {
-				fmt.Print(prompt)
+				fmt.Fprint(env.Stdout(), prompt)
 			}
 
 			reader := bufio.NewReader(os.Stdin)
@@ -254,7 +255,7 @@ func RegisterConsole(env *r2core.Environment) {
 				prompt = fmt.Sprintf("%v", args[0])
 			}
 
-			fmt.Print(prompt + " ")
+			fmt.Fprint(env.Stdout(), prompt+" ")
 
 			// Simple implementation - in production, you'd want to use terminal libraries
 			// for proper password hiding
@@ -268,12 +269,12 @@ func RegisterConsole(env *r2core.Environment) {
 		}),
 
 		"print": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			consoleWrite(joinConsoleArgs(args))
+			consoleWrite(env.Stdout(), joinConsoleArgs(args))
 			return nil
 		}),
 
 		"println": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			consoleWrite(joinConsoleArgs(args) + "\n")
+			consoleWrite(env.Stdout(), joinConsoleArgs(args)+"\n")
 			return nil
 		}),
 
@@ -288,9 +289,9 @@ func RegisterConsole(env *r2core.Environment) {
 			}
 
 			if len(args) > 1 {
-				fmt.Printf(format, args[1:]...)
+				fmt.Fprintf(env.Stdout(), format, args[1:]...)
 			} else {
-				fmt.Print(format)
+				fmt.Fprint(env.Stdout(), format)
 			}
 			return nil
 		}),
@@ -311,14 +312,14 @@ func RegisterConsole(env *r2core.Environment) {
 				message = fmt.Sprintf("%v", args[0])
 			}
 
-			fmt.Print(message)
+			fmt.Fprint(env.Stdout(), message)
 			reader := bufio.NewReader(os.Stdin)
 			reader.ReadString('\n')
 			return nil
 		}),
 
 		"beep": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Print("\a")
+			fmt.Fprint(env.Stdout(), "\a")
 			return nil
 		}),
 
@@ -327,7 +328,7 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 			title := fmt.Sprintf("%v", args[0])
-			fmt.Printf("\033]0;%s\007", title)
+			fmt.Fprintf(env.Stdout(), "\033]0;%s\007", title)
 			return nil
 		}),
 
@@ -341,9 +342,9 @@ func RegisterConsole(env *r2core.Environment) {
 
 			colorCode := getColorCode(color)
 			if colorCode != "" {
-				fmt.Printf("%s%s\033[0m", colorCode, text)
+				fmt.Fprintf(env.Stdout(), "%s%s\033[0m", colorCode, text)
 			} else {
-				fmt.Print(text)
+				fmt.Fprint(env.Stdout(), text)
 			}
 			return nil
 		}),
@@ -358,9 +359,9 @@ func RegisterConsole(env *r2core.Environment) {
 
 			colorCode := getColorCode(color)
 			if colorCode != "" {
-				fmt.Printf("%s%s\033[0m\n", colorCode, text)
+				fmt.Fprintf(env.Stdout(), "%s%s\033[0m\n", colorCode, text)
 			} else {
-				fmt.Println(text)
+				fmt.Fprintln(env.Stdout(), text)
 			}
 			return nil
 		}),
@@ -370,7 +371,7 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 			text := fmt.Sprintf("%v", args[0])
-			fmt.Printf("\033[1m%s\033[0m", text)
+			fmt.Fprintf(env.Stdout(), "\033[1m%s\033[0m", text)
 			return nil
 		}),
 
@@ -379,7 +380,7 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 			text := fmt.Sprintf("%v", args[0])
-			fmt.Printf("\033[3m%s\033[0m", text)
+			fmt.Fprintf(env.Stdout(), "\033[3m%s\033[0m", text)
 			return nil
 		}),
 
@@ -388,7 +389,7 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 			text := fmt.Sprintf("%v", args[0])
-			fmt.Printf("\033[4m%s\033[0m", text)
+			fmt.Fprintf(env.Stdout(), "\033[4m%s\033[0m", text)
 			return nil
 		}),
 
@@ -397,28 +398,28 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 
-			row, ok1 := args[0].(float64)
-			col, ok2 := args[1].(float64)
+			row, ok1 := asNumber(args[0])
+			col, ok2 := asNumber(args[1])
 			if !ok1 || !ok2 {
 				return nil
 			}
 
-			fmt.Printf("\033[%d;%dH", int(row), int(col))
+			fmt.Fprintf(env.Stdout(), "\033[%d;%dH", int(row), int(col))
 			return nil
 		}),
 
 		"clearLine": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Print("\033[2K\r")
+			fmt.Fprint(env.Stdout(), "\033[2K\r")
 			return nil
 		}),
 
 		"hideCursor": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Print("\033[?25l")
+			fmt.Fprint(env.Stdout(), "\033[?25l")
 			return nil
 		}),
 
 		"showCursor": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			fmt.Print("\033[?25h")
+			fmt.Fprint(env.Stdout(), "\033[?25h")
 			return nil
 		}),
 
@@ -427,14 +428,14 @@ func RegisterConsole(env *r2core.Environment) {
 				return nil
 			}
 
-			progress, ok := args[0].(float64)
+			progress, ok := asNumber(args[0])
 			if !ok {
 				return nil
 			}
 
 			width := 40
 			if len(args) > 1 {
-				if w, ok := args[1].(float64); ok {
+				if w, ok := asNumber(args[1]); ok {
 					width = int(w)
 				}
 			}
@@ -454,7 +455,7 @@ func RegisterConsole(env *r2core.Environment) {
 				}
 			}
 			fmt.Fprintf(&b, "] %.1f%%", progress*100)
-			consoleWrite(b.String())
+			consoleWrite(env.Stdout(), b.String())
 			return nil
 		}),
 
@@ -462,7 +463,7 @@ func RegisterConsole(env *r2core.Environment) {
 			spinChars := []string{"|", "/", "-", "\\"}
 			step := 0
 			if len(args) > 0 {
-				if s, ok := args[0].(float64); ok {
+				if s, ok := asNumber(args[0]); ok {
 					step = int(s) % len(spinChars)
 					if step < 0 {
 						step += len(spinChars)
@@ -470,7 +471,7 @@ func RegisterConsole(env *r2core.Environment) {
 				}
 			}
 
-			fmt.Printf("\r%s", spinChars[step])
+			fmt.Fprintf(env.Stdout(), "\r%s", spinChars[step])
 			return nil
 		}),
 	}
@@ -485,12 +486,12 @@ var (
 	consoleCounters = make(map[string]int)
 )
 
-// consoleWrite serializes writes to stdout so that a single log/table/etc.
+// consoleWrite serializes writes to the script output so that a single log/table/etc.
 // call is never torn apart by another goroutine's concurrent console output.
-func consoleWrite(s string) {
+func consoleWrite(w io.Writer, s string) {
 	consoleStateMu.Lock()
 	defer consoleStateMu.Unlock()
-	fmt.Print(s)
+	fmt.Fprint(w, s)
 }
 
 func joinConsoleArgs(args []interface{}) string {
@@ -521,11 +522,14 @@ func formatValue(value interface{}) string {
 	case *r2core.DateValue:
 		return v.Time.Format("2006-01-02 15:04:05")
 	default:
+		if text, ok := r2core.ErrorText(v); ok {
+			return text
+		}
 		return fmt.Sprintf("%v", v)
 	}
 }
 
-func printArrayTable(data []interface{}) {
+func printArrayTable(w io.Writer, data []interface{}) {
 	if len(data) == 0 {
 		return
 	}
@@ -540,10 +544,10 @@ func printArrayTable(data []interface{}) {
 	}
 
 	b.WriteString("└─────────┴─────────────────────────┘\n")
-	consoleWrite(b.String())
+	consoleWrite(w, b.String())
 }
 
-func printObjectTable(data map[string]interface{}) {
+func printObjectTable(w io.Writer, data map[string]interface{}) {
 	if len(data) == 0 {
 		return
 	}
@@ -558,7 +562,7 @@ func printObjectTable(data map[string]interface{}) {
 	}
 
 	b.WriteString("└─────────────────────────┴─────────────────────────┘\n")
-	consoleWrite(b.String())
+	consoleWrite(w, b.String())
 }
 
 func setConsoleTimer(label string, startTime time.Time) {
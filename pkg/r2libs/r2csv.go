@@ -182,7 +182,7 @@ func RegisterCSV(env *r2core.Environment) {
 				}
 			}
 
-			content, err := os.ReadFile(filePath)
+			content, err := os.ReadFile(resolvePath(env, "csv.readFile", filePath, false))
 			if err != nil {
 				panic(fmt.Sprintf("CSV.readFile: error opening file '%s': %v", filePath, err))
 			}
@@ -254,7 +254,7 @@ func RegisterCSV(env *r2core.Environment) {
 				}
 			}
 
-			file, err := os.Create(filePath)
+			file, err := os.Create(resolvePath(env, "csv.writeFile", filePath, true))
 			if err != nil {
 				panic(fmt.Sprintf("CSV.writeFile: error creating file '%s': %v", filePath, err))
 			}
@@ -446,8 +446,8 @@ func RegisterCSV(env *r2core.Environment) {
 					shouldSwap := false
 
 					// Try to compare as numbers first
-					if num1, ok1 := val1.(float64); ok1 {
-						if num2, ok2 := val2.(float64); ok2 {
+					if num1, ok1 := asNumber(val1); ok1 {
+						if num2, ok2 := asNumber(val2); ok2 {
 							if ascending {
 								shouldSwap = num1 > num2
 							} else {
@@ -518,7 +518,7 @@ func RegisterCSV(env *r2core.Environment) {
 			for _, row := range data {
 				if rowMap, ok := row.(map[string]interface{}); ok {
 					if value, exists := rowMap[columnName]; exists {
-						if num, ok := value.(float64); ok {
+						if num, ok := asNumber(value); ok {
 							values = append(values, num)
 						} else if str, ok := value.(string); ok {
 							if parsed, err := strconv.ParseFloat(str, 64); err == nil {
@@ -23,7 +23,7 @@ func RegisterDate(env *r2core.Environment) {
 					}
 					return &r2core.DateValue{Time: t}
 				}
-				if ts, ok := args[0].(float64); ok {
+				if ts, ok := asNumber(args[0]); ok {
 					return &r2core.DateValue{Time: time.Unix(int64(ts/1000), int64(ts)%1000*1000000)}
 				}
 			}
@@ -97,7 +97,7 @@ func createDateObject() map[string]interface{} {
 				}
 				return &r2core.DateValue{Time: t}
 			}
-			if ts, ok := args[0].(float64); ok {
+			if ts, ok := asNumber(args[0]); ok {
 				return &r2core.DateValue{Time: time.Unix(int64(ts/1000), int64(ts)%1000*1000000)}
 			}
 		}
@@ -3,6 +3,10 @@ package r2libs
 import (
 	"database/sql"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -69,7 +73,8 @@ func adaptPlaceholders(driver, query string) string {
 
 // sqlValueToR2 converts a value scanned from a *sql.Rows into R2Lang's
 // native value model (float64 for numbers, string, bool, nil, or
-// *r2core.DateValue for dates/timestamps). Left unconverted, driver-returned
+// *r2core.DateValue for dates/timestamps). Integers too large for a float64
+// to hold exactly stay int64 (or BigInt) so IDs don't lose digits. Left unconverted, driver-returned
 // types such as int64 or time.Time slip past R2Lang's arithmetic helpers
 // (which only recognize float64/int/bool/string/nil), causing numeric
 // operations on query results to either panic or silently fall back to
@@ -81,11 +86,14 @@ func sqlValueToR2(v interface{}) interface{} {
 	case []byte:
 		return string(val)
 	case int64:
-		return float64(val)
+		return r2core.IntegerValue(val)
 	case int32:
 		return float64(val)
 	case uint64:
-		return float64(val)
+		if val > math.MaxInt64 {
+			return new(big.Int).SetUint64(val)
+		}
+		return r2core.IntegerValue(int64(val))
 	case float32:
 		return float64(val)
 	case time.Time:
@@ -95,6 +103,64 @@ func sqlValueToR2(v interface{}) interface{} {
 	}
 }
 
+// sqlColumnValueToR2 is sqlValueToR2 for a value read from a DECIMAL or
+// NUMERIC column, which becomes an exact *r2core.DecimalValue (with the
+// column's scale, when the driver reports one) instead of a float64 or the
+// raw text some drivers return.
+func sqlColumnValueToR2(v interface{}, ct *sql.ColumnType) interface{} {
+	scale, isDecimal := decimalColumnScale(ct)
+	if v == nil || !isDecimal {
+		return sqlValueToR2(v)
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	} else {
+		v = sqlValueToR2(v)
+	}
+	d := r2core.ToDecimal(v)
+	if scale >= 0 {
+		d = d.Round(scale)
+	}
+	return d
+}
+
+// decimalColumnScale reports whether ct is a DECIMAL/NUMERIC column and its
+// scale, or -1 if unknown. SQLite only exposes the declared type, so the
+// scale is read from "DECIMAL(10,2)" when DecimalSize has nothing.
+func decimalColumnScale(ct *sql.ColumnType) (int, bool) {
+	if ct == nil {
+		return -1, false
+	}
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	if !strings.HasPrefix(name, "DECIMAL") && !strings.HasPrefix(name, "NUMERIC") {
+		return -1, false
+	}
+	if _, scale, ok := ct.DecimalSize(); ok {
+		return int(scale), true
+	}
+	if open := strings.IndexByte(name, '('); open >= 0 {
+		if comma := strings.IndexByte(name[open:], ','); comma >= 0 {
+			if scale, err := strconv.Atoi(strings.Trim(name[open+comma+1:], " )")); err == nil {
+				return scale, true
+			}
+		}
+	}
+	return -1, true
+}
+
+// r2ValueToSQL converts a query argument to a value every driver accepts:
+// BigInt and Decimal are sent as their exact decimal text, which DECIMAL and
+// NUMERIC columns parse without going through float64.
+func r2ValueToSQL(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *big.Int:
+		return val.String()
+	case *r2core.DecimalValue:
+		return val.String()
+	}
+	return v
+}
+
 func RegisterDB(env *r2core.Environment) {
 	functions := map[string]r2core.BuiltinFunction{
 		"dbConnect": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -117,6 +183,7 @@ func RegisterDB(env *r2core.Environment) {
 				panic(fmt.Sprintf("dbConnect: unsupported driver '%s'. Supported: %v", driver, supportedDrivers))
 			}
 
+			dsn = checkDSNAllowed(env, driver, dsn)
 			db, err := sql.Open(driver, dsn)
 			if err != nil {
 				panic(fmt.Sprintf("dbConnect: failed to open database: %v", err))
@@ -158,7 +225,7 @@ func RegisterDB(env *r2core.Environment) {
 			// Prepare arguments for query
 			queryArgs := make([]interface{}, len(args)-2)
 			for i, arg := range args[2:] {
-				queryArgs[i] = arg
+				queryArgs[i] = r2ValueToSQL(arg)
 			}
 
 			rows, err := conn.db.Query(adaptPlaceholders(conn.driver, query), queryArgs...)
@@ -172,6 +239,10 @@ func RegisterDB(env *r2core.Environment) {
 			if err != nil {
 				panic(fmt.Sprintf("dbQuery: failed to get columns: %v", err))
 			}
+			columnTypes, err := rows.ColumnTypes()
+			if err != nil {
+				panic(fmt.Sprintf("dbQuery: failed to get column types: %v", err))
+			}
 
 			// Prepare result slice
 			var results []interface{}
@@ -192,7 +263,7 @@ func RegisterDB(env *r2core.Environment) {
 				// Create map for this row
 				rowMap := make(map[string]interface{})
 				for i, col := range columns {
-					rowMap[col] = sqlValueToR2(values[i])
+					rowMap[col] = sqlColumnValueToR2(values[i], columnTypes[i])
 				}
 
 				results = append(results, rowMap)
@@ -222,7 +293,7 @@ func RegisterDB(env *r2core.Environment) {
 			// Prepare arguments for query
 			queryArgs := make([]interface{}, len(args)-2)
 			for i, arg := range args[2:] {
-				queryArgs[i] = arg
+				queryArgs[i] = r2ValueToSQL(arg)
 			}
 
 			result, err := conn.db.Exec(adaptPlaceholders(conn.driver, query), queryArgs...)
@@ -311,7 +382,7 @@ func RegisterDB(env *r2core.Environment) {
 			// Prepare arguments for query
 			queryArgs := make([]interface{}, len(args)-2)
 			for i, arg := range args[2:] {
-				queryArgs[i] = arg
+				queryArgs[i] = r2ValueToSQL(arg)
 			}
 
 			result, err := conn.db.Exec(adaptPlaceholders(conn.driver, query), queryArgs...)
@@ -385,3 +456,48 @@ func toString(v interface{}) string {
 		return fmt.Sprintf("%v", val)
 	}
 }
+
+// checkDSNAllowed applies the CapabilityPolicy to a connection and returns the
+// DSN to use: sqlite3 databases are files (resolved for writing, except
+// in-memory ones) and postgres/mysql ones are network connections to their host.
+func checkDSNAllowed(env *r2core.Environment, driver, dsn string) string {
+	switch driver {
+	case "sqlite3":
+		path, query, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+		if path == "" || path == ":memory:" || strings.Contains(query, "mode=memory") {
+			return dsn
+		}
+		resolved := resolvePath(env, "dbConnect", path, true)
+		if query != "" {
+			return "file:" + resolved + "?" + query
+		}
+		return resolved
+	case "postgres":
+		if u, err := url.Parse(dsn); err == nil && u.Host != "" {
+			checkHostAllowed(env, "dbConnect", u.Host)
+			return dsn
+		}
+		host, port := "localhost", ""
+		for _, field := range strings.Fields(dsn) {
+			if v, ok := strings.CutPrefix(field, "host="); ok {
+				host = v
+			} else if v, ok := strings.CutPrefix(field, "port="); ok {
+				port = v
+			}
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		checkHostAllowed(env, "dbConnect", host)
+	case "mysql":
+		// user:password@tcp(host:port)/dbname
+		host := "localhost:3306"
+		if i := strings.Index(dsn, "("); i >= 0 {
+			if j := strings.Index(dsn[i:], ")"); j >= 0 {
+				host = dsn[i+1 : i+j]
+			}
+		}
+		checkHostAllowed(env, "dbConnect", host)
+	}
+	return dsn
+}
@@ -357,6 +357,36 @@ func TestDatabaseFunctions(t *testing.T) {
 	})
 }
 
+func TestDBExactNumbersRoundTrip(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterDB(env)
+	dbModuleObj, _ := env.Get("db")
+	dbModule := dbModuleObj.(map[string]interface{})
+	connectFunc := dbModule["dbConnect"].(r2core.BuiltinFunction)
+	execFunc := dbModule["dbExec"].(r2core.BuiltinFunction)
+	queryFunc := dbModule["dbQuery"].(r2core.BuiltinFunction)
+	closeFunc := dbModule["dbClose"].(r2core.BuiltinFunction)
+
+	connId := connectFunc("sqlite3", ":memory:").(string)
+	defer closeFunc(connId)
+
+	execFunc(connId, "CREATE TABLE ledger (id BIGINT, amount DECIMAL(12,2), qty INTEGER)")
+	amount, _ := r2core.ParseDecimal("1234.50")
+	execFunc(connId, "INSERT INTO ledger VALUES (?, ?, ?)", int64(9007199254740993), amount, 2.0)
+
+	rows := queryFunc(connId, "SELECT id, amount, qty FROM ledger").([]interface{})
+	row := rows[0].(map[string]interface{})
+	if row["id"] != int64(9007199254740993) {
+		t.Errorf("Expected id to keep all its digits as int64, got %T %v", row["id"], row["id"])
+	}
+	if d, ok := row["amount"].(*r2core.DecimalValue); !ok || d.String() != "1234.50" {
+		t.Errorf("Expected amount to come back as the decimal 1234.50, got %T %v", row["amount"], row["amount"])
+	}
+	if row["qty"] != 2.0 {
+		t.Errorf("Expected qty to stay float64 2, got %T %v", row["qty"], row["qty"])
+	}
+}
+
 // Regression test: postgres uses $1/$2/... placeholders, not the `?` syntax
 // mysql/sqlite3 accept. Without translation, lib/pq's NumInput() sees zero
 // placeholders in a `?`-based query and database/sql rejects any supplied
@@ -0,0 +1,378 @@
+package r2libs
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+// RegisterArgs exposes the command line arguments of a script: argv is the
+// array of arguments (without the interpreter or the script name) and
+// process is a map with the same array under "args" and the script path
+// under "script".
+func RegisterArgs(env *r2core.Environment, script string, args []string) {
+	argv := make([]interface{}, len(args))
+	for i, a := range args {
+		argv[i] = a
+	}
+	env.Set("argv", argv)
+	env.Set("process", map[string]interface{}{
+		"args":   argv,
+		"script": script,
+	})
+}
+
+// flagOption is one option declared in the spec given to flags.parse.
+type flagOption struct {
+	name  string
+	kind  string // "string", "number", "int", "bool" o "list"
+	short string
+	usage string
+	def   interface{}
+}
+
+// RegisterFlags registers the flags module, a small command line parser for
+// scripts. An option spec is a map from option name to either its default
+// value (the type is taken from it) or a map with type, default, short and
+// usage:
+//
+//	let opts = flags.parse({
+//	    name: {type: "string", default: "world", short: "n", usage: "who to greet"},
+//	    loud: false,
+//	})
+//
+// parse reads argv unless an array of arguments is given as second argument.
+// It accepts -name value, --name value, --name=value and, for bools, a bare
+// --name; "--" ends the options. The result maps each option to its value,
+// "_" to the remaining positional arguments and "help" to whether -h/--help
+// was given (unless the spec declares its own help option).
+func RegisterFlags(env *r2core.Environment) {
+	functions := map[string]r2core.BuiltinFunction{
+		"parse": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 || len(args) > 2 {
+				panic("flags.parse: se aceptan 1 o 2 argumentos (spec, [args])")
+			}
+			options := flagSpec("flags.parse", args[0])
+
+			var argv []interface{}
+			if len(args) == 2 {
+				argv = flagArgs("flags.parse", args[1])
+			} else if val, ok := env.Get("argv"); ok {
+				argv = flagArgs("flags.parse", val)
+			}
+			return parseFlags(options, argv)
+		}),
+
+		"usage": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 || len(args) > 2 {
+				panic("flags.usage: se aceptan 1 o 2 argumentos (spec, [header])")
+			}
+			options := flagSpec("flags.usage", args[0])
+			header := ""
+			if len(args) == 2 {
+				s, ok := args[1].(string)
+				if !ok {
+					panic("flags.usage: el encabezado debe ser un string")
+				}
+				header = s
+			}
+			return flagUsage(options, header)
+		}),
+	}
+
+	RegisterModule(env, "flags", functions)
+}
+
+func flagArgs(fn string, val interface{}) []interface{} {
+	switch v := val.(type) {
+	case []interface{}:
+		return v
+	case r2core.InterfaceSlice:
+		return v
+	case nil:
+		return nil
+	}
+	panic(fmt.Sprintf("%s: los argumentos deben ser un array", fn))
+}
+
+// flagSpec valida la especificación de opciones y las devuelve ordenadas por nombre.
+func flagSpec(fn string, val interface{}) []*flagOption {
+	spec, ok := val.(map[string]interface{})
+	if !ok {
+		panic(fmt.Sprintf("%s: la especificación debe ser un mapa {nombre: opción}", fn))
+	}
+	names := make([]string, 0, len(spec))
+	for name := range spec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make([]*flagOption, 0, len(names))
+	shorts := map[string]string{}
+	for _, name := range names {
+		if name == "" || name == "_" || strings.HasPrefix(name, "-") {
+			panic(fmt.Sprintf("%s: nombre de opción inválido %q", fn, name))
+		}
+		opt := &flagOption{name: name}
+		if m, ok := spec[name].(map[string]interface{}); ok {
+			opt.def = m["default"]
+			if t, ok := m["type"].(string); ok {
+				opt.kind = t
+			} else if m["type"] != nil {
+				panic(fmt.Sprintf("%s: el tipo de --%s debe ser un string", fn, name))
+			}
+			if s, ok := m["short"].(string); ok {
+				opt.short = s
+			}
+			if u, ok := m["usage"].(string); ok {
+				opt.usage = u
+			}
+		} else {
+			opt.def = spec[name]
+		}
+		if opt.kind == "" {
+			opt.kind = flagKindOf(opt.def)
+		}
+		switch opt.kind {
+		case "string", "number", "int", "bool", "list":
+		default:
+			panic(fmt.Sprintf("%s: tipo desconocido %q para --%s (string, number, int, bool o list)", fn, opt.kind, name))
+		}
+		if opt.def == nil {
+			opt.def = flagZero(opt.kind)
+		} else {
+			opt.def = convertFlag(fn, opt, opt.def)
+		}
+		if opt.short != "" {
+			if len([]rune(opt.short)) != 1 {
+				panic(fmt.Sprintf("%s: la forma corta de --%s debe ser una sola letra", fn, name))
+			}
+			if other, dup := shorts[opt.short]; dup {
+				panic(fmt.Sprintf("%s: -%s está asignada a --%s y a --%s", fn, opt.short, other, name))
+			}
+			shorts[opt.short] = name
+		}
+		options = append(options, opt)
+	}
+	return options
+}
+
+func flagKindOf(def interface{}) string {
+	switch def.(type) {
+	case bool:
+		return "bool"
+	case float64, int:
+		return "number"
+	case []interface{}, r2core.InterfaceSlice:
+		return "list"
+	}
+	return "string"
+}
+
+func flagZero(kind string) interface{} {
+	switch kind {
+	case "number", "int":
+		return float64(0)
+	case "bool":
+		return false
+	case "list":
+		return []interface{}{}
+	}
+	return ""
+}
+
+// convertFlag convierte un valor (de la línea de comandos o un default) al
+// tipo de la opción.
+func convertFlag(fn string, opt *flagOption, val interface{}) interface{} {
+	invalid := func() {
+		panic(fmt.Sprintf("%s: valor inválido %v para --%s (se esperaba %s)", fn, val, opt.name, opt.kind))
+	}
+	switch opt.kind {
+	case "string":
+		if s, ok := val.(string); ok {
+			return s
+		}
+		return fmt.Sprint(val)
+	case "number", "int":
+		var f float64
+		switch v := val.(type) {
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				invalid()
+			}
+			f = parsed
+		default:
+			invalid()
+		}
+		if opt.kind == "int" && f != math.Trunc(f) {
+			invalid()
+		}
+		return f
+	case "bool":
+		switch v := val.(type) {
+		case bool:
+			return v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				invalid()
+			}
+			return b
+		}
+		invalid()
+	case "list":
+		switch v := val.(type) {
+		case []interface{}:
+			return append([]interface{}{}, v...)
+		case r2core.InterfaceSlice:
+			return append([]interface{}{}, v...)
+		}
+		return []interface{}{fmt.Sprint(val)}
+	}
+	return val
+}
+
+func parseFlags(options []*flagOption, argv []interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(options)+2)
+	byName := make(map[string]*flagOption, len(options))
+	for _, opt := range options {
+		byName[opt.name] = opt
+		if opt.short != "" {
+			byName[opt.short] = opt
+		}
+		result[opt.name] = opt.def
+	}
+	if _, declared := byName["help"]; !declared {
+		result["help"] = false
+	}
+
+	positional := []interface{}{}
+	seenLists := map[string]bool{}
+	for i := 0; i < len(argv); i++ {
+		arg := fmt.Sprint(argv[i])
+		if arg == "--" {
+			positional = append(positional, argv[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' || isNumberArg(arg) {
+			positional = append(positional, argv[i])
+			continue
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, hasValue := "", false
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		opt, ok := byName[name]
+		if !ok {
+			if name == "help" || name == "h" {
+				result["help"] = true
+				continue
+			}
+			panic(fmt.Sprintf("flags.parse: opción desconocida %s", arg))
+		}
+
+		if !hasValue {
+			if opt.kind == "bool" {
+				result[opt.name] = true
+				continue
+			}
+			if i+1 >= len(argv) {
+				panic(fmt.Sprintf("flags.parse: falta el valor de %s", arg))
+			}
+			i++
+			value = fmt.Sprint(argv[i])
+		}
+
+		if opt.kind == "list" {
+			// El primer uso reemplaza el default; los siguientes acumulan
+			var list []interface{}
+			if seenLists[opt.name] {
+				list = result[opt.name].([]interface{})
+			}
+			seenLists[opt.name] = true
+			result[opt.name] = append(list, value)
+			continue
+		}
+		result[opt.name] = convertFlag("flags.parse", opt, value)
+	}
+	result["_"] = positional
+	return result
+}
+
+func flagUsage(options []*flagOption, header string) string {
+	var sb strings.Builder
+	if header != "" {
+		sb.WriteString(header)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Options:\n")
+
+	left := make([]string, len(options))
+	width := 0
+	for i, opt := range options {
+		l := "      --" + opt.name
+		if opt.short != "" {
+			l = "  -" + opt.short + ", --" + opt.name
+		}
+		if opt.kind != "bool" {
+			l += " " + opt.kind
+		}
+		left[i] = l
+		width = max(width, len(l))
+	}
+	for i, opt := range options {
+		line := left[i]
+		desc := opt.usage
+		if def := flagDefaultText(opt); def != "" {
+			if desc != "" {
+				desc += " "
+			}
+			desc += "(default " + def + ")"
+		}
+		if desc != "" {
+			line += strings.Repeat(" ", width-len(line)+3) + desc
+		}
+		sb.WriteString(strings.TrimRight(line, " "))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func flagDefaultText(opt *flagOption) string {
+	switch v := opt.def.(type) {
+	case string:
+		if v != "" {
+			return strconv.Quote(v)
+		}
+	case float64:
+		if v != 0 {
+			return fmt.Sprint(v)
+		}
+	case bool:
+		if v {
+			return "true"
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// isNumberArg reports whether arg is a negative number rather than an option.
+func isNumberArg(arg string) bool {
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
@@ -0,0 +1,135 @@
+package r2libs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+func flagsModuleForTest(t *testing.T, args ...string) (map[string]interface{}, *r2core.Environment) {
+	t.Helper()
+	env := r2core.NewEnvironment()
+	RegisterFlags(env)
+	RegisterArgs(env, "tool.r2", args)
+	modObj, ok := env.Get("flags")
+	if !ok {
+		t.Fatal("flags module not found")
+	}
+	return modObj.(map[string]interface{}), env
+}
+
+func flagsTestSpec() map[string]interface{} {
+	return map[string]interface{}{
+		"name":  map[string]interface{}{"type": "string", "default": "world", "short": "n", "usage": "who to greet"},
+		"times": map[string]interface{}{"type": "int", "default": float64(1)},
+		"ratio": float64(0.5),
+		"loud":  false,
+		"tag":   map[string]interface{}{"type": "list"},
+	}
+}
+
+func TestRegisterArgs(t *testing.T) {
+	_, env := flagsModuleForTest(t, "a", "b")
+	argv, _ := env.Get("argv")
+	if !reflect.DeepEqual(argv, []interface{}{"a", "b"}) {
+		t.Errorf("argv = %v", argv)
+	}
+	process, _ := env.Get("process")
+	p := process.(map[string]interface{})
+	if !reflect.DeepEqual(p["args"], argv) || p["script"] != "tool.r2" {
+		t.Errorf("process = %v", p)
+	}
+}
+
+func TestFlagsParse(t *testing.T) {
+	mod, _ := flagsModuleForTest(t, "-n", "Ana", "--times=3", "--loud", "--ratio", "2.5",
+		"--tag", "x", "-tag=y", "file.txt", "-7", "--", "--not-a-flag")
+	parse := mod["parse"].(r2core.BuiltinFunction)
+
+	got := parse(flagsTestSpec()).(map[string]interface{})
+	want := map[string]interface{}{
+		"name":  "Ana",
+		"times": float64(3),
+		"ratio": float64(2.5),
+		"loud":  true,
+		"tag":   []interface{}{"x", "y"},
+		"help":  false,
+		"_":     []interface{}{"file.txt", "-7", "--not-a-flag"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parse() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestFlagsParseDefaultsAndExplicitArgs(t *testing.T) {
+	mod, _ := flagsModuleForTest(t, "--loud")
+	parse := mod["parse"].(r2core.BuiltinFunction)
+
+	got := parse(flagsTestSpec(), []interface{}{"-h", "--loud=false"}).(map[string]interface{})
+	want := map[string]interface{}{
+		"name":  "world",
+		"times": float64(1),
+		"ratio": float64(0.5),
+		"loud":  false,
+		"tag":   []interface{}{},
+		"help":  true,
+		"_":     []interface{}{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parse() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestFlagsParseErrors(t *testing.T) {
+	mod, _ := flagsModuleForTest(t)
+	parse := mod["parse"].(r2core.BuiltinFunction)
+
+	tests := []struct {
+		spec interface{}
+		args []interface{}
+		want string
+	}{
+		{flagsTestSpec(), []interface{}{"--nope"}, "opción desconocida --nope"},
+		{flagsTestSpec(), []interface{}{"--times", "1.5"}, "valor inválido 1.5 para --times"},
+		{flagsTestSpec(), []interface{}{"--ratio", "abc"}, "valor inválido abc para --ratio"},
+		{flagsTestSpec(), []interface{}{"--name"}, "falta el valor de --name"},
+		{map[string]interface{}{"x": map[string]interface{}{"type": "date"}}, nil, "tipo desconocido"},
+		{map[string]interface{}{
+			"a": map[string]interface{}{"short": "x"},
+			"b": map[string]interface{}{"short": "x"},
+		}, nil, "-x está asignada a --a y a --b"},
+		{"not a map", nil, "la especificación debe ser un mapa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(r.(string), tt.want) {
+					t.Errorf("expected panic containing %q, got %v", tt.want, r)
+				}
+			}()
+			parse(tt.spec, tt.args)
+		})
+	}
+}
+
+func TestFlagsUsage(t *testing.T) {
+	mod, _ := flagsModuleForTest(t)
+	usage := mod["usage"].(r2core.BuiltinFunction)
+
+	got := usage(flagsTestSpec(), "usage: tool [options]")
+	want := `usage: tool [options]
+
+Options:
+      --loud
+  -n, --name string    who to greet (default "world")
+      --ratio number   (default 0.5)
+      --tag list
+      --times int      (default 1)
+`
+	if got != want {
+		t.Errorf("usage() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -34,7 +34,7 @@ func builtinSemaphore(args ...interface{}) interface{} {
 	if len(args) != 1 {
 		panic("semaphore necesita exactamente un argumento: número de permisos")
 	}
-	permitCount, ok := args[0].(float64)
+	permitCount, ok := asNumber(args[0])
 	if !ok {
 		panic("semaphore: el argumento debe ser un número")
 	}
@@ -117,7 +117,8 @@ func RegisterGRPC(env *r2core.Environment) {
 				}
 			}
 
-			client, err := createGRPCClient(protoFile, serverAddr, customMetadata)
+			checkHostAllowed(env, "grpc.client", serverAddr)
+			client, err := createGRPCClient(resolvePath(env, "grpc.client", protoFile, false), serverAddr, customMetadata)
 			if err != nil {
 				errorMsg := fmt.Sprintf("grpcClient: failed to create client from '%s' to '%s'", protoFile, serverAddr)
 				if strings.Contains(err.Error(), "connection refused") {
@@ -468,7 +469,7 @@ func grpcClientToMap(client *GRPCClient) map[string]interface{} {
 			panic("setTimeout requires (seconds)")
 		}
 
-		seconds, ok := args[0].(float64)
+		seconds, ok := asNumber(args[0])
 		if !ok {
 			panic("setTimeout: seconds must be a number")
 		}
@@ -1437,7 +1438,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return fmt.Sprintf("%v", value), nil
 
 	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return int32(num), nil
 		}
 		if str, ok := value.(string); ok {
@@ -1448,7 +1449,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return nil, fmt.Errorf("cannot convert %v to int32", value)
 
 	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return int64(num), nil
 		}
 		if str, ok := value.(string); ok {
@@ -1459,7 +1460,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return nil, fmt.Errorf("cannot convert %v to int64", value)
 
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return uint32(num), nil
 		}
 		if str, ok := value.(string); ok {
@@ -1470,7 +1471,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return nil, fmt.Errorf("cannot convert %v to uint32", value)
 
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return uint64(num), nil
 		}
 		if str, ok := value.(string); ok {
@@ -1481,7 +1482,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return nil, fmt.Errorf("cannot convert %v to uint64", value)
 
 	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return float32(num), nil
 		}
 		if str, ok := value.(string); ok {
@@ -1492,7 +1493,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 		return nil, fmt.Errorf("cannot convert %v to float32", value)
 
 	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return num, nil
 		}
 		if str, ok := value.(string); ok {
@@ -1537,7 +1538,7 @@ func convertScalarValueToProto(value interface{}, field *desc.FieldDescriptor) (
 				return enumValue.GetNumber(), nil
 			}
 		}
-		if num, ok := value.(float64); ok {
+		if num, ok := asNumber(value); ok {
 			return int32(num), nil
 		}
 		return nil, fmt.Errorf("cannot convert %v to enum", value)
@@ -46,9 +46,33 @@ func RegisterHack(env *r2core.Environment) {
 		"rsaDecrypt":    rsaDecrypt,
 	}
 
+	// Las funciones de red respetan la CapabilityPolicy: el destino es el host
+	// recibido como primer argumento (whois siempre consulta a Verisign)
+	networkTargets := map[string]func(args []interface{}) string{
+		"portScan":      hackHostArg,
+		"dnsLookup":     hackHostArg,
+		"dnsLookupAddr": hackHostArg,
+		"simplePing":    func(args []interface{}) string { return net.JoinHostPort(hackHostArg(args), "80") },
+		"whois":         func(args []interface{}) string { return "whois.verisign-grs.com:43" },
+	}
+	for name, target := range networkTargets {
+		fn, label := functions[name], "hack."+name
+		functions[name] = func(args ...interface{}) interface{} {
+			checkHostAllowed(env, label, target(args))
+			return fn(args...)
+		}
+	}
+
 	RegisterModule(env, "hack", functions)
 }
 
+func hackHostArg(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return fmt.Sprint(args[0])
+}
+
 var hashMD5 = r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 	if len(args) < 1 {
 		panic("hashMD5 needs (str)")
@@ -2,8 +2,10 @@ package r2libs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,10 +29,13 @@ type r2Route struct {
 	// Podrías también almacenar un compilado de regexp, si quisieras
 }
 
-// Guardamos las rutas en un slice (o array global) para simplificar
-var r2Routes []r2Route
+// serverShutdownTimeout is how long a server started by serve or web.listen
+// waits for in-flight requests once the execution of its script is cancelled.
+const serverShutdownTimeout = 3 * time.Second
 
-func httpHandler(args []interface{}) interface{} {
+// Cada RegisterHTTP guarda sus rutas en su propio slice, así un programa
+// nuevo (por ejemplo al reiniciar con r2 -watch) no hereda las anteriores
+func httpHandler(routes *[]r2Route, args []interface{}) interface{} {
 	if len(args) < 3 {
 		panic("handler necesita 3 argumentos: (method, pattern, fx)")
 	}
@@ -46,7 +51,7 @@ func httpHandler(args []interface{}) interface{} {
 	}
 
 	// Agregamos la ruta a la tabla
-	r2Routes = append(r2Routes, r2Route{
+	*routes = append(*routes, r2Route{
 		method:    strings.ToUpper(method),
 		pattern:   pattern,
 		handlerfx: handler,
@@ -54,10 +59,38 @@ func httpHandler(args []interface{}) interface{} {
 	return nil
 }
 
+// serveUntilDone runs srv until it fails or the execution of env is cancelled
+// (r2 -watch restarting the script, an -timeout deadline), in which case the
+// server is shut down gracefully and nil is returned.
+func serveUntilDone(env *r2core.Environment, srv *http.Server) error {
+	done := env.GetLimiter().Context.Done()
+	stopped := make(chan struct{})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		select {
+		case <-done:
+			ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			defer cancel()
+			srv.Shutdown(ctx)
+		case <-stopped:
+		}
+	}()
+
+	err := srv.ListenAndServe()
+	close(stopped)
+	<-closed
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
 func RegisterHTTP(env *r2core.Environment) {
+	var routes []r2Route
 	functions := map[string]r2core.BuiltinFunction{
 		"handler": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
-			return httpHandler(args)
+			return httpHandler(&routes, args)
 		}),
 
 		"serve": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -70,7 +103,8 @@ func RegisterHTTP(env *r2core.Environment) {
 			}
 
 			// Definimos un único handler en Go para todas las rutas
-			http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 				// Leemos body (simple, para requests tipo POST/PUT)
 				var bodyStr string
 				if r.Method == "POST" || r.Method == "PUT" {
@@ -85,7 +119,7 @@ func RegisterHTTP(env *r2core.Environment) {
 					}
 				}
 				// Buscamos una ruta que coincida con r.Method y r.URL.Path
-				route, pathVars := matchRoute(r2Routes, r.Method, r.URL.Path)
+				route, pathVars := matchRoute(routes, r.Method, r.URL.Path)
 				if route == nil {
 					// No match
 					w.WriteHeader(http.StatusNotFound)
@@ -148,16 +182,18 @@ func RegisterHTTP(env *r2core.Environment) {
 				fmt.Fprint(w, respStr)
 			})
 
+			checkHostAllowed(env, "http.serve", addr)
 			// Arrancamos el servidor (bloqueante)
-			fmt.Println("Listening on ", addr)
+			fmt.Fprintln(env.Stdout(), "Listening on ", addr)
 			// ReadHeaderTimeout guards against slow-header (Slowloris) style
 			// connections without limiting handlers that legitimately need to
 			// read/write large or slow bodies.
 			srv := &http.Server{
 				Addr:              addr,
+				Handler:           mux,
 				ReadHeaderTimeout: 15 * time.Second,
 			}
-			err := srv.ListenAndServe()
+			err := serveUntilDone(env, srv)
 			if err != nil {
 				panic(fmt.Sprintf("serve: error in ListenAndServe: %v", err))
 			}
@@ -215,7 +251,7 @@ func RegisterHTTP(env *r2core.Environment) {
 			if len(args) < 1 {
 				panic("HttpResponse needs at least 1 argument")
 			}
-			status, ok := args[0].(float64)
+			status, ok := asNumber(args[0])
 			posArgs := 1
 			if !ok {
 				status = 200
@@ -390,7 +426,7 @@ func removeBehavior(instance map[string]interface{}) map[string]interface{} {
 
 }
 
-// matchRoute busca en r2Routes la primera que coincida con method y path
+// matchRoute busca en routes la primera que coincida con method y path
 // y retorna (rutaEncontrada, mapDeVariables). Si no hay match, retorna (nil, nil).
 func matchRoute(routes []r2Route, method, path string) (*r2Route, map[string]string) {
 	for _, rt := range routes {
@@ -55,7 +55,7 @@ func RegisterHTTPClient(env *r2core.Environment) {
 			if !ok {
 				panic("clientHttpGet: url debe ser string")
 			}
-			resp, err := httpClientDefault.Get(url)
+			resp, err := guardHTTPClient(env, httpClientDefault).Get(url)
 			if err != nil {
 				panic(fmt.Sprintf("clientHttpGet: error en GET '%s': %v", url, err))
 			}
@@ -74,7 +74,7 @@ func RegisterHTTPClient(env *r2core.Environment) {
 				panic("clientHttpPost: (url, bodyString) deben ser strings")
 			}
 
-			resp, err := httpClientDefault.Post(url, "text/plain", bytes.NewBufferString(bodyStr))
+			resp, err := guardHTTPClient(env, httpClientDefault).Post(url, "text/plain", bytes.NewBufferString(bodyStr))
 			if err != nil {
 				panic(fmt.Sprintf("clientHttpPost: error en POST '%s': %v", url, err))
 			}
@@ -121,7 +121,7 @@ func RegisterHTTPClient(env *r2core.Environment) {
 			if !ok {
 				panic("httpGetJSON: url debe ser string")
 			}
-			resp, err := httpClientDefault.Get(url)
+			resp, err := guardHTTPClient(env, httpClientDefault).Get(url)
 			if err != nil {
 				panic(fmt.Sprintf("httpGetJSON: error en GET '%s': %v", url, err))
 			}
@@ -150,7 +150,7 @@ func RegisterHTTPClient(env *r2core.Environment) {
 				panic(fmt.Sprintf("httpPostJSON: error al serializar: %v", err))
 			}
 
-			resp, err := httpClientDefault.Post(url, "application/json", bytes.NewReader(jsData))
+			resp, err := guardHTTPClient(env, httpClientDefault).Post(url, "application/json", bytes.NewReader(jsData))
 			if err != nil {
 				panic(fmt.Sprintf("httpPostJSON: error en POST '%s': %v", url, err))
 			}
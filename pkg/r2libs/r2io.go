@@ -28,6 +28,7 @@ func (nf *NativeFunction) Eval(env *r2core.Environment) interface{} {
 // PathObject represents a file system path with a fluent API.
 type PathObject struct {
 	Path string
+	env  *r2core.Environment // Para aplicar la CapabilityPolicy
 }
 
 func (p *PathObject) Eval(env *r2core.Environment) interface{} {
@@ -39,6 +40,7 @@ func (p *PathObject) Eval(env *r2core.Environment) interface{} {
 
 // FileStreamObject represents a stream of lines from a file with fluent operations.
 type FileStreamObject struct {
+	env     *r2core.Environment // Para aplicar la CapabilityPolicy
 	path    string
 	filters []*r2core.UserFunction
 	mappers []*r2core.UserFunction
@@ -88,7 +90,7 @@ func (fs *FileStreamObject) Getattr(name string) (r2core.Node, bool) {
 			if len(args) < 1 {
 				panic("FileStream.limit needs a number")
 			}
-			limit, ok := args[0].(float64)
+			limit, ok := asNumber(args[0])
 			if !ok {
 				panic("FileStream.limit: argument must be a number")
 			}
@@ -98,7 +100,7 @@ func (fs *FileStreamObject) Getattr(name string) (r2core.Node, bool) {
 		}}, true
 	case "toArray":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			file, err := os.Open(fs.path)
+			file, err := os.Open(resolvePath(fs.env, "FileStream.toArray", fs.path, false))
 			if err != nil {
 				panic(fmt.Sprintf("FileStream.toArray: error opening '%s': %v", fs.path, err))
 			}
@@ -149,13 +151,13 @@ func (fs *FileStreamObject) Getattr(name string) (r2core.Node, bool) {
 				panic("FileStream.saveTo: argument must be a string")
 			}
 
-			file, err := os.Open(fs.path)
+			file, err := os.Open(resolvePath(fs.env, "FileStream.saveTo", fs.path, false))
 			if err != nil {
 				panic(fmt.Sprintf("FileStream.saveTo: error opening source '%s': %v", fs.path, err))
 			}
 			defer file.Close()
 
-			destFile, err := os.Create(destPath)
+			destFile, err := os.Create(resolvePath(fs.env, "FileStream.saveTo", destPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("FileStream.saveTo: error creating destination '%s': %v", destPath, err))
 			}
@@ -204,12 +206,52 @@ func (fs *FileStreamObject) Getattr(name string) (r2core.Node, bool) {
 	return nil, false
 }
 
+// Iterator implementa r2core.Iterable: for-in, el spread y collections
+// recorren el archivo línea a línea, aplicando filters/mappers/limit, sin
+// cargarlo entero. Cada recorrido abre el archivo de nuevo.
+func (fs *FileStreamObject) Iterator() r2core.Iterator {
+	file, err := os.Open(resolvePath(fs.env, "FileStream", fs.path, false))
+	if err != nil {
+		panic(fmt.Sprintf("FileStream: error opening '%s': %v", fs.path, err))
+	}
+	scanner := bufio.NewScanner(file)
+	linesRead := 0
+	return r2core.NewIterator(func() (interface{}, bool) {
+		for fs.limit <= 0 || linesRead < fs.limit {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					panic(fmt.Sprintf("FileStream: error scanning '%s': %v", fs.path, err))
+				}
+				break
+			}
+			line := scanner.Text()
+			shouldInclude := true
+			for _, filter := range fs.filters {
+				if !toBool(filter.Call(line)) {
+					shouldInclude = false
+					break
+				}
+			}
+			if !shouldInclude {
+				continue
+			}
+			mappedLine := interface{}(line)
+			for _, mapper := range fs.mappers {
+				mappedLine = mapper.Call(mappedLine)
+			}
+			linesRead++
+			return mappedLine, false
+		}
+		return nil, true
+	}, func() { file.Close() })
+}
+
 func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 
 	switch name {
 	case "readText":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			data, err := os.ReadFile(p.Path)
+			data, err := os.ReadFile(resolvePath(p.env, "Path.readText", p.Path, false))
 			if err != nil {
 				panic(fmt.Sprintf("Path.readText: error reading '%s': %v", p.Path, err))
 			}
@@ -224,7 +266,7 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 			if !ok {
 				panic("Path.writeText: contents must be a string")
 			}
-			err := os.WriteFile(p.Path, []byte(contents), 0644)
+			err := os.WriteFile(resolvePath(p.env, "Path.writeText", p.Path, true), []byte(contents), 0644)
 			if err != nil {
 				panic(fmt.Sprintf("Path.writeText: error writing '%s': %v", p.Path, err))
 			}
@@ -232,12 +274,12 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 		}}, true
 	case "exists":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			_, err := os.Stat(p.Path)
+			_, err := os.Stat(resolvePath(p.env, "Path.exists", p.Path, false))
 			return !os.IsNotExist(err)
 		}}, true
 	case "isDir":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			info, err := os.Stat(p.Path)
+			info, err := os.Stat(resolvePath(p.env, "Path.isDir", p.Path, false))
 			if err != nil {
 				return false
 			}
@@ -245,7 +287,7 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 		}}, true
 	case "isFile":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			info, err := os.Stat(p.Path)
+			info, err := os.Stat(resolvePath(p.env, "Path.isFile", p.Path, false))
 			if err != nil {
 				return false
 			}
@@ -267,11 +309,11 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 		}}, true
 	case "dir":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			return &PathObject{Path: filepath.Dir(p.Path)}
+			return &PathObject{Path: filepath.Dir(p.Path), env: p.env}
 		}}, true
 	case "remove":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			err := os.Remove(p.Path)
+			err := os.Remove(resolvePath(p.env, "Path.remove", p.Path, true))
 			if err != nil {
 				panic(fmt.Sprintf("Path.remove: error removing '%s': %v", p.Path, err))
 			}
@@ -291,11 +333,11 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 			default:
 				panic("Path.copyTo: destination must be a string or a Path object")
 			}
-			err := copyFileInternal(p.Path, destPath)
+			err := copyFileInternal(resolvePath(p.env, "Path.copyTo", p.Path, false), resolvePath(p.env, "Path.copyTo", destPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("Path.copyTo: error copying to '%s': %v", destPath, err))
 			}
-			return &PathObject{Path: destPath}
+			return &PathObject{Path: destPath, env: p.env}
 		}}, true
 	case "moveTo":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
@@ -311,7 +353,7 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 			default:
 				panic("Path.moveTo: destination must be a string or a Path object")
 			}
-			err := os.Rename(p.Path, destPath)
+			err := os.Rename(resolvePath(p.env, "Path.moveTo", p.Path, true), resolvePath(p.env, "Path.moveTo", destPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("Path.moveTo: error moving to '%s': %v", destPath, err))
 			}
@@ -327,7 +369,7 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 			if !ok {
 				panic("Path.sibling: name must be a string")
 			}
-			return &PathObject{Path: filepath.Join(filepath.Dir(p.Path), name)}
+			return &PathObject{Path: filepath.Join(filepath.Dir(p.Path), name), env: p.env}
 		}}, true
 	case "withSuffix":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
@@ -339,24 +381,24 @@ func (p *PathObject) Getattr(name string) (r2core.Node, bool) {
 				panic("Path.withSuffix: suffix must be a string")
 			}
 			base := strings.TrimSuffix(p.Path, filepath.Ext(p.Path))
-			return &PathObject{Path: base + suffix}
+			return &PathObject{Path: base + suffix, env: p.env}
 		}}, true
 	case "iter":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			info, err := os.Stat(p.Path)
+			info, err := os.Stat(resolvePath(p.env, "Path.iter", p.Path, false))
 			if err != nil {
 				panic(fmt.Sprintf("Path.iter: cannot read path '%s': %v", p.Path, err))
 			}
 			if !info.IsDir() {
 				panic("Path.iter can only be called on a directory")
 			}
-			files, err := os.ReadDir(p.Path)
+			files, err := os.ReadDir(resolvePath(p.env, "Path.iter", p.Path, false))
 			if err != nil {
 				panic(fmt.Sprintf("Path.iter: error reading directory '%s': %v", p.Path, err))
 			}
 			var result []interface{}
 			for _, f := range files {
-				result = append(result, &PathObject{Path: filepath.Join(p.Path, f.Name())})
+				result = append(result, &PathObject{Path: filepath.Join(p.Path, f.Name()), env: p.env})
 			}
 			return result
 		}}, true
@@ -374,7 +416,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("io.Path: argument must be a string")
 			}
-			return &PathObject{Path: path}
+			return &PathObject{Path: path, env: env}
 		}),
 		"FileStream": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			if len(args) < 1 {
@@ -384,7 +426,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("io.FileStream: argument must be a string")
 			}
-			return &FileStreamObject{path: path}
+			return &FileStreamObject{env: env, path: path}
 		}),
 
 		"readFile": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -395,7 +437,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("readFile: first argument must be string (path)")
 			}
-			data, err := os.ReadFile(path)
+			data, err := os.ReadFile(resolvePath(env, "readFile", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("readFile: error reading '%s': %v", path, err))
 			}
@@ -410,7 +452,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("readFileBytes: first argument must be string (path)")
 			}
-			data, err := os.ReadFile(path)
+			data, err := os.ReadFile(resolvePath(env, "readFileBytes", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("readFileBytes: error reading '%s': %v", path, err))
 			}
@@ -429,7 +471,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("readLines: first argument must be string (path)")
 			}
-			file, err := os.Open(path)
+			file, err := os.Open(resolvePath(env, "readLines", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("readLines: error opening '%s': %v", path, err))
 			}
@@ -457,11 +499,11 @@ func RegisterIO(env *r2core.Environment) {
 			}
 			permissions := os.FileMode(0644)
 			if len(args) > 2 {
-				if perm, ok := args[2].(float64); ok {
+				if perm, ok := asNumber(args[2]); ok {
 					permissions = os.FileMode(perm)
 				}
 			}
-			err := os.WriteFile(path, []byte(contents), permissions)
+			err := os.WriteFile(resolvePath(env, "writeFile", path, true), []byte(contents), permissions)
 			if err != nil {
 				panic(fmt.Sprintf("writeFile: error writing '%s': %v", path, err))
 			}
@@ -483,7 +525,7 @@ func RegisterIO(env *r2core.Environment) {
 
 			data := make([]byte, len(bytesArray))
 			for i, b := range bytesArray {
-				if num, ok := b.(float64); ok {
+				if num, ok := asNumber(b); ok {
 					data[i] = byte(num)
 				} else {
 					panic("writeFileBytes: array must contain numbers")
@@ -492,12 +534,12 @@ func RegisterIO(env *r2core.Environment) {
 
 			permissions := os.FileMode(0644)
 			if len(args) > 2 {
-				if perm, ok := args[2].(float64); ok {
+				if perm, ok := asNumber(args[2]); ok {
 					permissions = os.FileMode(perm)
 				}
 			}
 
-			err := os.WriteFile(path, data, permissions)
+			err := os.WriteFile(resolvePath(env, "writeFileBytes", path, true), data, permissions)
 			if err != nil {
 				panic(fmt.Sprintf("writeFileBytes: error writing '%s': %v", path, err))
 			}
@@ -529,12 +571,12 @@ func RegisterIO(env *r2core.Environment) {
 			content := strings.Join(lines, "\n")
 			permissions := os.FileMode(0644)
 			if len(args) > 2 {
-				if perm, ok := args[2].(float64); ok {
+				if perm, ok := asNumber(args[2]); ok {
 					permissions = os.FileMode(perm)
 				}
 			}
 
-			err := os.WriteFile(path, []byte(content), permissions)
+			err := os.WriteFile(resolvePath(env, "writeLines", path, true), []byte(content), permissions)
 			if err != nil {
 				panic(fmt.Sprintf("writeLines: error writing '%s': %v", path, err))
 			}
@@ -551,7 +593,7 @@ func RegisterIO(env *r2core.Environment) {
 				panic("appendFile: (path, contents) must be strings")
 			}
 
-			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			f, err := os.OpenFile(resolvePath(env, "appendFile", path, true), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 			if err != nil {
 				panic(fmt.Sprintf("appendFile: error opening '%s': %v", path, err))
 			}
@@ -574,13 +616,13 @@ func RegisterIO(env *r2core.Environment) {
 				panic("copyFile: (srcPath, destPath) must be strings")
 			}
 
-			srcFile, err := os.Open(srcPath)
+			srcFile, err := os.Open(resolvePath(env, "copyFile", srcPath, false))
 			if err != nil {
 				panic(fmt.Sprintf("copyFile: error opening source '%s': %v", srcPath, err))
 			}
 			defer srcFile.Close()
 
-			destFile, err := os.Create(destPath)
+			destFile, err := os.Create(resolvePath(env, "copyFile", destPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("copyFile: error creating destination '%s': %v", destPath, err))
 			}
@@ -602,7 +644,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok1 || !ok2 {
 				panic("moveFile: (srcPath, destPath) must be strings")
 			}
-			err := os.Rename(srcPath, destPath)
+			err := os.Rename(resolvePath(env, "moveFile", srcPath, true), resolvePath(env, "moveFile", destPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("moveFile: error moving '%s' to '%s': %v", srcPath, destPath, err))
 			}
@@ -617,7 +659,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("rmFile: path must be string")
 			}
-			err := os.Remove(path)
+			err := os.Remove(resolvePath(env, "rmFile", path, true))
 			if err != nil {
 				panic(fmt.Sprintf("rmFile: error removing '%s': %v", path, err))
 			}
@@ -632,7 +674,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("rmDir: path must be string")
 			}
-			err := os.RemoveAll(path)
+			err := os.RemoveAll(resolvePath(env, "rmDir", path, true))
 			if err != nil {
 				panic(fmt.Sprintf("rmDir: error removing directory '%s': %v", path, err))
 			}
@@ -648,7 +690,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok1 || !ok2 {
 				panic("renameFile: (oldPath, newPath) must be strings")
 			}
-			err := os.Rename(oldP, newP)
+			err := os.Rename(resolvePath(env, "renameFile", oldP, true), resolvePath(env, "renameFile", newP, true))
 			if err != nil {
 				panic(fmt.Sprintf("renameFile: error renaming '%s' to '%s': %v", oldP, newP, err))
 			}
@@ -663,7 +705,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("listDir: path must be string")
 			}
-			files, err := os.ReadDir(dir)
+			files, err := os.ReadDir(resolvePath(env, "listDir", dir, false))
 			if err != nil {
 				panic(fmt.Sprintf("listDir: error reading directory '%s': %v", dir, err))
 			}
@@ -682,7 +724,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("listDirDetailed: path must be string")
 			}
-			files, err := os.ReadDir(dir)
+			files, err := os.ReadDir(resolvePath(env, "listDirDetailed", dir, false))
 			if err != nil {
 				panic(fmt.Sprintf("listDirDetailed: error reading directory '%s': %v", dir, err))
 			}
@@ -714,11 +756,11 @@ func RegisterIO(env *r2core.Environment) {
 			}
 			permissions := os.FileMode(0755)
 			if len(args) > 1 {
-				if perm, ok := args[1].(float64); ok {
+				if perm, ok := asNumber(args[1]); ok {
 					permissions = os.FileMode(perm)
 				}
 			}
-			err := os.Mkdir(dir, permissions)
+			err := os.Mkdir(resolvePath(env, "mkdir", dir, true), permissions)
 			if err != nil {
 				panic(fmt.Sprintf("mkdir: error creating directory '%s': %v", dir, err))
 			}
@@ -735,11 +777,11 @@ func RegisterIO(env *r2core.Environment) {
 			}
 			permissions := os.FileMode(0755)
 			if len(args) > 1 {
-				if perm, ok := args[1].(float64); ok {
+				if perm, ok := asNumber(args[1]); ok {
 					permissions = os.FileMode(perm)
 				}
 			}
-			err := os.MkdirAll(dir, permissions)
+			err := os.MkdirAll(resolvePath(env, "mkdirAll", dir, true), permissions)
 			if err != nil {
 				panic(fmt.Sprintf("mkdirAll: error creating directories '%s': %v", dir, err))
 			}
@@ -754,7 +796,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("absPath: path must be string")
 			}
-			abs, err := filepath.Abs(p)
+			abs, err := scriptAbs(env, p)
 			if err != nil {
 				panic(fmt.Sprintf("absPath: error with '%s': %v", p, err))
 			}
@@ -817,7 +859,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("exists: path must be string")
 			}
-			_, err := os.Stat(p)
+			_, err := os.Stat(resolvePath(env, "exists", p, false))
 			return !os.IsNotExist(err)
 		}),
 
@@ -829,7 +871,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("isDir: path must be string")
 			}
-			info, err := os.Stat(p)
+			info, err := os.Stat(resolvePath(env, "isDir", p, false))
 			if os.IsNotExist(err) {
 				return false
 			}
@@ -847,7 +889,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("isFile: path must be string")
 			}
-			info, err := os.Stat(p)
+			info, err := os.Stat(resolvePath(env, "isFile", p, false))
 			if os.IsNotExist(err) {
 				return false
 			}
@@ -865,7 +907,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("fileSize: path must be string")
 			}
-			info, err := os.Stat(p)
+			info, err := os.Stat(resolvePath(env, "fileSize", p, false))
 			if err != nil {
 				panic(fmt.Sprintf("fileSize: error getting info for '%s': %v", p, err))
 			}
@@ -880,7 +922,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("fileMode: path must be string")
 			}
-			info, err := os.Stat(p)
+			info, err := os.Stat(resolvePath(env, "fileMode", p, false))
 			if err != nil {
 				panic(fmt.Sprintf("fileMode: error getting info for '%s': %v", p, err))
 			}
@@ -895,7 +937,7 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("fileModTime: path must be string")
 			}
-			info, err := os.Stat(p)
+			info, err := os.Stat(resolvePath(env, "fileModTime", p, false))
 			if err != nil {
 				panic(fmt.Sprintf("fileModTime: error getting info for '%s': %v", p, err))
 			}
@@ -907,11 +949,11 @@ func RegisterIO(env *r2core.Environment) {
 				panic("chmod needs (path, mode)")
 			}
 			p, ok1 := args[0].(string)
-			mode, ok2 := args[1].(float64)
+			mode, ok2 := asNumber(args[1])
 			if !ok1 || !ok2 {
 				panic("chmod: (path, mode) must be (string, number)")
 			}
-			err := os.Chmod(p, os.FileMode(mode))
+			err := os.Chmod(resolvePath(env, "chmod", p, true), os.FileMode(mode))
 			if err != nil {
 				panic(fmt.Sprintf("chmod: error changing mode for '%s': %v", p, err))
 			}
@@ -928,12 +970,12 @@ func RegisterIO(env *r2core.Environment) {
 			}
 
 			var result []interface{}
-			err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			err := filepath.Walk(resolvePath(env, "walk", root, false), func(path string, info os.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
 				fileInfo := map[string]interface{}{
-					"path":    path,
+					"path":    scriptPath(env, path),
 					"name":    info.Name(),
 					"size":    float64(info.Size()),
 					"isDir":   info.IsDir(),
@@ -958,13 +1000,13 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("glob: pattern must be string")
 			}
-			matches, err := filepath.Glob(pattern)
+			matches, err := filepath.Glob(resolvePath(env, "glob", pattern, false))
 			if err != nil {
 				panic(fmt.Sprintf("glob: error with pattern '%s': %v", pattern, err))
 			}
 			result := make([]interface{}, len(matches))
 			for i, match := range matches {
-				result[i] = match
+				result[i] = scriptPath(env, match)
 			}
 			return result
 		}),
@@ -980,7 +1022,7 @@ func RegisterIO(env *r2core.Environment) {
 			}
 
 			var result []interface{}
-			err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			err := filepath.Walk(resolvePath(env, "findFiles", root, false), func(path string, info os.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
@@ -990,7 +1032,7 @@ func RegisterIO(env *r2core.Environment) {
 						return err
 					}
 					if matched {
-						result = append(result, path)
+						result = append(result, scriptPath(env, path))
 					}
 				}
 				return nil
@@ -1030,8 +1072,8 @@ func RegisterIO(env *r2core.Environment) {
 			switch sortBy {
 			case "size":
 				sort.Slice(files, func(i, j int) bool {
-					info1, err1 := os.Stat(files[i])
-					info2, err2 := os.Stat(files[j])
+					info1, err1 := os.Stat(resolvePath(env, "sortFiles", files[i], false))
+					info2, err2 := os.Stat(resolvePath(env, "sortFiles", files[j], false))
 					if err1 != nil || err2 != nil {
 						return files[i] < files[j]
 					}
@@ -1039,8 +1081,8 @@ func RegisterIO(env *r2core.Environment) {
 				})
 			case "time":
 				sort.Slice(files, func(i, j int) bool {
-					info1, err1 := os.Stat(files[i])
-					info2, err2 := os.Stat(files[j])
+					info1, err1 := os.Stat(resolvePath(env, "sortFiles", files[i], false))
+					info2, err2 := os.Stat(resolvePath(env, "sortFiles", files[j], false))
 					if err1 != nil || err2 != nil {
 						return files[i] < files[j]
 					}
@@ -1058,6 +1100,9 @@ func RegisterIO(env *r2core.Environment) {
 		}),
 
 		"tempDir": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if policyFor(env).FSRoot != "" {
+				return scriptPath(env, resolvePath(env, "tempDir", "/", true))
+			}
 			return os.TempDir()
 		}),
 
@@ -1076,16 +1121,24 @@ func RegisterIO(env *r2core.Environment) {
 				}
 			}
 
+			if dir != "" || policyFor(env).FSRoot != "" {
+				dir = resolvePath(env, "tempFile", dir, true)
+			} else {
+				resolvePath(env, "tempFile", os.TempDir(), true)
+			}
 			file, err := os.CreateTemp(dir, pattern)
 			if err != nil {
 				panic(fmt.Sprintf("tempFile: error creating temp file: %v", err))
 			}
 			defer file.Close()
 
-			return file.Name()
+			return scriptPath(env, file.Name())
 		}),
 
 		"workingDir": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if policyFor(env).FSRoot != "" {
+				return string(filepath.Separator)
+			}
 			wd, err := os.Getwd()
 			if err != nil {
 				panic(fmt.Sprintf("workingDir: error getting working directory: %v", err))
@@ -1101,7 +1154,8 @@ func RegisterIO(env *r2core.Environment) {
 			if !ok {
 				panic("changeDir: path must be string")
 			}
-			err := os.Chdir(dir)
+			checkProcessAllowed(env, "changeDir")
+			err := os.Chdir(resolvePath(env, "changeDir", dir, false))
 			if err != nil {
 				panic(fmt.Sprintf("changeDir: error changing directory to '%s': %v", dir, err))
 			}
@@ -1120,12 +1174,12 @@ func RegisterIO(env *r2core.Environment) {
 
 			batchSize := 1024
 			if len(args) > 1 {
-				if size, ok := args[1].(float64); ok {
+				if size, ok := asNumber(args[1]); ok {
 					batchSize = int(size)
 				}
 			}
 
-			file, err := os.Open(path)
+			file, err := os.Open(resolvePath(env, "readStream", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("readStream: error opening '%s': %v", path, err))
 			}
@@ -1158,7 +1212,7 @@ func RegisterIO(env *r2core.Environment) {
 				panic("writeStream: (path, chunks) must be (string, array)")
 			}
 
-			file, err := os.Create(path)
+			file, err := os.Create(resolvePath(env, "writeStream", path, true))
 			if err != nil {
 				panic(fmt.Sprintf("writeStream: error creating '%s': %v", path, err))
 			}
@@ -1188,11 +1242,11 @@ func RegisterIO(env *r2core.Environment) {
 				panic("compareFiles: paths must be strings")
 			}
 
-			data1, err := os.ReadFile(path1)
+			data1, err := os.ReadFile(resolvePath(env, "compareFiles", path1, false))
 			if err != nil {
 				panic(fmt.Sprintf("compareFiles: error reading '%s': %v", path1, err))
 			}
-			data2, err := os.ReadFile(path2)
+			data2, err := os.ReadFile(resolvePath(env, "compareFiles", path2, false))
 			if err != nil {
 				panic(fmt.Sprintf("compareFiles: error reading '%s': %v", path2, err))
 			}
@@ -1226,7 +1280,7 @@ func RegisterIO(env *r2core.Environment) {
 				}
 			}
 
-			data, err := os.ReadFile(path)
+			data, err := os.ReadFile(resolvePath(env, "checksum", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("checksum: error reading '%s': %v", path, err))
 			}
@@ -1256,7 +1310,7 @@ func RegisterIO(env *r2core.Environment) {
 				panic("createPath: path must be string")
 			}
 
-			err := os.MkdirAll(filepath.Dir(path), 0755)
+			err := os.MkdirAll(filepath.Dir(resolvePath(env, "createPath", path, true)), 0755)
 			if err != nil {
 				panic(fmt.Sprintf("createPath: error creating directories for '%s': %v", path, err))
 			}
@@ -1278,7 +1332,7 @@ func RegisterIO(env *r2core.Environment) {
 			base := strings.TrimSuffix(path, ext)
 			backupPath := fmt.Sprintf("%s_backup_%s%s", base, timestamp, ext)
 
-			err := copyFileInternal(path, backupPath)
+			err := copyFileInternal(resolvePath(env, "backup", path, false), resolvePath(env, "backup", backupPath, true))
 			if err != nil {
 				panic(fmt.Sprintf("backup: error creating backup: %v", err))
 			}
@@ -1295,7 +1349,7 @@ func RegisterIO(env *r2core.Environment) {
 				panic("watchFile: path must be string")
 			}
 
-			info, err := os.Stat(path)
+			info, err := os.Stat(resolvePath(env, "watchFile", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("watchFile: error getting info for '%s': %v", path, err))
 			}
@@ -1319,17 +1373,18 @@ func RegisterIO(env *r2core.Environment) {
 				panic("batchCopy: arguments must be strings")
 			}
 
-			matches, err := filepath.Glob(pattern)
+			matches, err := filepath.Glob(resolvePath(env, "batchCopy", pattern, false))
 			if err != nil {
 				panic(fmt.Sprintf("batchCopy: error with pattern '%s': %v", pattern, err))
 			}
 
 			var results []interface{}
-			for _, srcPath := range matches {
+			for _, match := range matches {
+				srcPath := scriptPath(env, match)
 				filename := filepath.Base(srcPath)
 				destPath := filepath.Join(destDir, filename)
 
-				err := copyFileInternal(srcPath, destPath)
+				err := copyFileInternal(resolvePath(env, "batchCopy", srcPath, false), resolvePath(env, "batchCopy", destPath, true))
 				if err == nil {
 					results = append(results, map[string]interface{}{
 						"src":    srcPath,
@@ -1358,7 +1413,7 @@ func RegisterIO(env *r2core.Environment) {
 				panic("getMetadata: path must be string")
 			}
 
-			info, err := os.Stat(path)
+			info, err := os.Stat(resolvePath(env, "getMetadata", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("getMetadata: error getting info for '%s': %v", path, err))
 			}
@@ -1369,7 +1424,7 @@ func RegisterIO(env *r2core.Environment) {
 				"mode":    float64(info.Mode()),
 				"modTime": info.ModTime().Format(time.RFC3339),
 				"isDir":   info.IsDir(),
-				"abs":     func() string { abs, _ := filepath.Abs(path); return abs }(),
+				"abs":     func() string { abs, _ := scriptAbs(env, path); return abs }(),
 				"ext":     filepath.Ext(path),
 				"dir":     filepath.Dir(path),
 				"base":    filepath.Base(path),
@@ -1,6 +1,7 @@
 package r2libs
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -145,3 +146,28 @@ func TestIOFunctions(t *testing.T) {
 		t.Errorf("rmDir: expected deep directory to be removed")
 	}
 }
+
+func TestFileStream_IsIterable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(path, []byte("a\nbb\nccc\ndddd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := r2core.NewEnvironment()
+	RegisterIO(env)
+	env.Set("path", path)
+
+	code := `
+		let long = []
+		for (line in io.FileStream(path).filter(func(l) { return l != "a" }).limit(2)) {
+			long = long.push(line)
+		}
+		[long, [...io.FileStream(path)]]
+	`
+	got := r2core.NewParser(code).ParseProgram().Eval(env).([]interface{})
+	if s := fmt.Sprint(got[0]); s != "[bb ccc]" {
+		t.Errorf("expected [bb ccc], got %s", s)
+	}
+	if s := fmt.Sprint(got[1]); s != "[a bb ccc dddd]" {
+		t.Errorf("expected every line, got %s", s)
+	}
+}
@@ -1,8 +1,10 @@
 package r2libs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -21,13 +23,20 @@ func RegisterJSON(env *r2core.Environment) {
 				panic("JSON.parse: first argument must be string")
 			}
 
+			exact := false
+			if len(args) > 1 {
+				if opts, ok := args[1].(map[string]interface{}); ok {
+					exact = toBool(opts["exact"])
+				}
+			}
+
 			var result interface{}
-			err := json.Unmarshal([]byte(jsonStr), &result)
+			err := unmarshalJSONNumbers(jsonStr, &result)
 			if err != nil {
 				panic(fmt.Sprintf("JSON.parse: error parsing JSON: %v", err))
 			}
 
-			return convertJSONToR2(result)
+			return convertJSONToR2(result, exact)
 		}),
 
 		"stringify": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -46,7 +55,7 @@ func RegisterJSON(env *r2core.Environment) {
 			}
 
 			if len(args) > 2 {
-				if space, ok := args[2].(float64); ok {
+				if space, ok := asNumber(args[2]); ok {
 					indent = strings.Repeat(" ", int(space))
 				} else if space, ok := args[2].(string); ok {
 					indent = space
@@ -79,7 +88,7 @@ func RegisterJSON(env *r2core.Environment) {
 			}
 
 			var result []interface{}
-			err := json.Unmarshal([]byte(jsonStr), &result)
+			err := unmarshalJSONNumbers(jsonStr, &result)
 			if err != nil {
 				panic(fmt.Sprintf("JSON.parseArray: error parsing JSON array: %v", err))
 			}
@@ -97,7 +106,7 @@ func RegisterJSON(env *r2core.Environment) {
 			}
 
 			var result map[string]interface{}
-			err := json.Unmarshal([]byte(jsonStr), &result)
+			err := unmarshalJSONNumbers(jsonStr, &result)
 			if err != nil {
 				panic(fmt.Sprintf("JSON.parseObject: error parsing JSON object: %v", err))
 			}
@@ -159,7 +168,7 @@ func RegisterJSON(env *r2core.Environment) {
 			}
 
 			if value, exists := result[key]; exists {
-				return convertJSONToR2(value)
+				return convertJSONToR2(value, false)
 			}
 
 			return nil
@@ -394,7 +403,7 @@ func RegisterJSON(env *r2core.Environment) {
 			}
 
 			result := queryJSONPath(obj, path)
-			return convertJSONToR2(result)
+			return convertJSONToR2(result, false)
 		}),
 
 		"size": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -490,20 +499,42 @@ func RegisterJSON(env *r2core.Environment) {
 	RegisterModule(env, "json", functions)
 }
 
-func convertJSONToR2(value interface{}) interface{} {
+// unmarshalJSONNumbers works like json.Unmarshal but keeps numbers as
+// json.Number, so convertJSONToR2 can pick their type before any precision
+// is lost to float64.
+func unmarshalJSONNumbers(jsonStr string, target interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(jsonStr))
+	dec.UseNumber()
+	if err := dec.Decode(target); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+	return nil
+}
+
+// convertJSONToR2 converts a decoded value into R2's value model. Numbers
+// become float64, except integers too large for a float64 to hold exactly,
+// which stay int64 or BigInt. With exact, every integer is an int64 (or
+// BigInt) and every number with a fraction is a Decimal, so 12.50 is written
+// back as 12.50 by stringify.
+func convertJSONToR2(value interface{}, exact bool) interface{} {
 	switch v := value.(type) {
 	case map[string]interface{}:
 		result := make(map[string]interface{})
 		for key, val := range v {
-			result[key] = convertJSONToR2(val)
+			result[key] = convertJSONToR2(val, exact)
 		}
 		return result
 	case []interface{}:
 		result := make([]interface{}, len(v))
 		for i, val := range v {
-			result[i] = convertJSONToR2(val)
+			result[i] = convertJSONToR2(val, exact)
 		}
 		return result
+	case json.Number:
+		return jsonNumberToR2(v, exact)
 	case float64:
 		return v
 	case string:
@@ -517,10 +548,28 @@ func convertJSONToR2(value interface{}) interface{} {
 	}
 }
 
+func jsonNumberToR2(n json.Number, exact bool) interface{} {
+	text := n.String()
+	if exact {
+		if strings.ContainsAny(text, ".eE") {
+			return r2core.ToDecimal(text)
+		}
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		return r2core.ToBigInt(text)
+	}
+	if v, err := r2core.ParseNumber(text); err == nil {
+		return v
+	}
+	f, _ := n.Float64()
+	return f
+}
+
 func convertJSONArrayToR2(arr []interface{}) []interface{} {
 	result := make([]interface{}, len(arr))
 	for i, val := range arr {
-		result[i] = convertJSONToR2(val)
+		result[i] = convertJSONToR2(val, false)
 	}
 	return result
 }
@@ -528,7 +577,7 @@ func convertJSONArrayToR2(arr []interface{}) []interface{} {
 func convertJSONObjectToR2(obj map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
 	for key, val := range obj {
-		result[key] = convertJSONToR2(val)
+		result[key] = convertJSONToR2(val, false)
 	}
 	return result
 }
@@ -568,8 +617,12 @@ func convertR2ToJSONSeen(value interface{}, seen map[uintptr]bool) interface{} {
 			result[i] = convertR2ToJSONSeen(val, seen)
 		}
 		return result
-	case float64:
+	case float64, int64:
 		return v
+	case *big.Int:
+		return json.Number(v.String())
+	case *r2core.DecimalValue:
+		return json.Number(v.String())
 	case string:
 		return v
 	case bool:
@@ -578,11 +631,90 @@ func convertR2ToJSONSeen(value interface{}, seen map[uintptr]bool) interface{} {
 		return nil
 	case *r2core.DateValue:
 		return v.Time.Format("2006-01-02T15:04:05Z07:00")
+	case *r2core.EnumValue:
+		// Enum members are written by name; Status.valueOf(name) reads them back
+		return v.Name
+	case *r2core.Set, *r2core.ImmutableList, r2core.KeyedCollection:
+		ptr := reflect.ValueOf(v).Pointer()
+		if seen[ptr] {
+			panic("JSON.stringify: circular reference detected")
+		}
+		seen[ptr] = true
+		defer delete(seen, ptr)
+		return convertCollectionToJSON(v, seen)
 	default:
 		panic(fmt.Sprintf("JSON.stringify: cannot convert value of type %T to JSON", v))
 	}
 }
 
+// convertCollectionToJSON writes Sets and ImmutableLists as arrays, and Maps
+// and ImmutableMaps as objects that keep their insertion order.
+func convertCollectionToJSON(value interface{}, seen map[uintptr]bool) interface{} {
+	switch v := value.(type) {
+	case *r2core.Set:
+		return convertR2ToJSONSeen(v.Values(), seen)
+	case *r2core.ImmutableList:
+		return convertR2ToJSONSeen(v.Values(), seen)
+	}
+	keyed := value.(r2core.KeyedCollection)
+	obj := orderedJSONObject{values: make(map[string]interface{}, keyed.Len())}
+	keyed.Range(func(k, val interface{}) bool {
+		key := jsonKey(k)
+		if _, dup := obj.values[key]; dup {
+			panic(fmt.Sprintf("JSON.stringify: two Map keys become the JSON property name %q", key))
+		}
+		obj.keys = append(obj.keys, key)
+		obj.values[key] = convertR2ToJSONSeen(val, seen)
+		return true
+	})
+	return obj
+}
+
+// jsonKey converts a Map key to the name of a JSON property. Distinct keys
+// that become the same name (1 and "1") are an error in
+// convertCollectionToJSON.
+func jsonKey(k interface{}) string {
+	switch v := k.(type) {
+	case string:
+		return v
+	case float64, int64, bool, *big.Int, *r2core.DecimalValue:
+		return toString(v)
+	case *r2core.DateValue, *r2core.EnumValue:
+		return convertR2ToJSON(v).(string)
+	}
+	panic(fmt.Sprintf("JSON.stringify: a Map key of type %T cannot be a JSON property name", k))
+}
+
+// orderedJSONObject is a JSON object whose properties are written in the
+// order of keys instead of sorted.
+type orderedJSONObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o orderedJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 func deepMergeObjects(obj1, obj2 map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
 
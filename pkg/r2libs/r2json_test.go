@@ -1,6 +1,8 @@
 package r2libs
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -386,3 +388,97 @@ func TestJSONStringifyUnsupportedType(t *testing.T) {
 	fn := &r2core.UserFunction{}
 	stringifyFunc(map[string]interface{}{"f": fn})
 }
+
+func TestJSONExactNumbers(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterJSON(env)
+
+	jsonModule, _ := env.Get("json")
+	module := jsonModule.(map[string]interface{})
+	parseFunc := module["parse"].(r2core.BuiltinFunction)
+	stringifyFunc := module["stringify"].(r2core.BuiltinFunction)
+
+	src := `{"id":9007199254740993,"price":12.50,"qty":3}`
+
+	// Por defecto sólo los enteros que no entran en un float64 cambian de tipo
+	obj := parseFunc(src).(map[string]interface{})
+	if obj["id"] != int64(9007199254740993) {
+		t.Errorf("Expected id to keep all its digits as int64, got %T %v", obj["id"], obj["id"])
+	}
+	if obj["price"] != 12.5 || obj["qty"] != 3.0 {
+		t.Errorf("Expected ordinary numbers to stay float64, got %v %v", obj["price"], obj["qty"])
+	}
+
+	exact := parseFunc(src, map[string]interface{}{"exact": true}).(map[string]interface{})
+	if d, ok := exact["price"].(*r2core.DecimalValue); !ok || d.String() != "12.50" {
+		t.Errorf("Expected price to be the decimal 12.50, got %T %v", exact["price"], exact["price"])
+	}
+	if exact["qty"] != int64(3) {
+		t.Errorf("Expected qty to be int64 3, got %T %v", exact["qty"], exact["qty"])
+	}
+	if out := stringifyFunc(exact); out != src {
+		t.Errorf("Expected the exact round trip to give back %s, got %s", src, out)
+	}
+
+	big := parseFunc(`123456789012345678901234567890`)
+	if out := stringifyFunc(big); out != "123456789012345678901234567890" {
+		t.Errorf("Expected a BigInt to round trip, got %v", out)
+	}
+}
+
+func TestJSONEnumsByName(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterJSON(env)
+	r2core.NewParser(`enum Status { Active, Suspended = "S" }`).ParseProgram().Eval(env)
+
+	jsonModule, _ := env.Get("json")
+	stringifyFunc := jsonModule.(map[string]interface{})["stringify"].(r2core.BuiltinFunction)
+	enum, _ := env.Get("Status")
+	status := enum.(*r2core.EnumType)
+
+	value := map[string]interface{}{
+		"status":  status.Lookup("Suspended"),
+		"history": []interface{}{status.Lookup("Active")},
+	}
+	if out := stringifyFunc(value); out != `{"history":["Active"],"status":"Suspended"}` {
+		t.Errorf("Expected enum members to be written by name, got %s", out)
+	}
+	// encoding/json, used by other libraries, writes them the same way
+	if data, err := json.Marshal(value); err != nil || string(data) != `{"history":["Active"],"status":"Suspended"}` {
+		t.Errorf("Expected json.Marshal to write enum members by name, got %s %v", data, err)
+	}
+}
+
+func TestJSONCollections(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterJSON(env)
+
+	jsonModule, _ := env.Get("json")
+	stringifyFunc := jsonModule.(map[string]interface{})["stringify"].(r2core.BuiltinFunction)
+
+	m := r2core.NewOrderedMap()
+	m.Set("z", r2core.NewSet(2.0, 1.0, 2.0))
+	m.Set(1.5, r2core.NewImmutableList("a", "b"))
+	m.Set("a", r2core.NewImmutableMap().Set("y", 1.0).Set("x", true))
+	want := `{"z":[2,1],"1.5":["a","b"],"a":{"y":1,"x":true}}`
+	if out := stringifyFunc(m); out != want {
+		t.Errorf("Expected Maps to keep their insertion order, got %s", out)
+	}
+
+	stringifyPanic := func(m *r2core.OrderedMap) (r interface{}) {
+		defer func() { r = recover() }()
+		stringifyFunc(m)
+		return nil
+	}
+	bad := r2core.NewOrderedMap()
+	bad.Set(map[string]interface{}{}, 1.0)
+	if r := stringifyPanic(bad); r == nil || !strings.Contains(fmt.Sprint(r), "Map key") {
+		t.Errorf("Expected an object key to be rejected, got %v", r)
+	}
+	clash := r2core.NewOrderedMap()
+	clash.Set(1.0, "number")
+	clash.Set("1", "string")
+	if r := stringifyPanic(clash); r == nil || !strings.Contains(fmt.Sprint(r), `property name "1"`) {
+		t.Errorf("Expected keys 1 and \"1\" to be rejected, got %v", r)
+	}
+}
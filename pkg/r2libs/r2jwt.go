@@ -157,7 +157,7 @@ func RegisterJWT(env *r2core.Environment) {
 			// Default expiration: 1 hour
 			expireInSeconds := 3600
 			if len(args) > 1 {
-				if exp, ok := args[1].(float64); ok {
+				if exp, ok := asNumber(args[1]); ok {
 					expireInSeconds = int(exp)
 				}
 			}
@@ -229,7 +229,7 @@ func RegisterJWT(env *r2core.Environment) {
 			}
 
 			if exp, exists := payload["exp"]; exists {
-				if expFloat, ok := exp.(float64); ok {
+				if expFloat, ok := asNumber(exp); ok {
 					return time.Now().Unix() > int64(expFloat)
 				}
 			}
@@ -380,7 +380,7 @@ func RegisterJWT(env *r2core.Environment) {
 			// Refresh tokens typically have longer expiration (30 days)
 			expireInSeconds := 30 * 24 * 3600
 			if len(args) > 2 {
-				if exp, ok := args[2].(float64); ok {
+				if exp, ok := asNumber(args[2]); ok {
 					expireInSeconds = int(exp)
 				}
 			}
@@ -533,7 +533,7 @@ func verifyTokenInternal(token, secret string) interface{} {
 	// so jwt.refresh(expiredToken, secret) happily reissued a fresh token
 	// for an already-expired (or not-yet-valid) token forever.
 	if exp, exists := payload["exp"]; exists {
-		if expFloat, ok := exp.(float64); ok {
+		if expFloat, ok := asNumber(exp); ok {
 			if time.Now().Unix() > int64(expFloat) {
 				return map[string]interface{}{
 					"valid":   false,
@@ -546,7 +546,7 @@ func verifyTokenInternal(token, secret string) interface{} {
 
 	// Check not before
 	if nbf, exists := payload["nbf"]; exists {
-		if nbfFloat, ok := nbf.(float64); ok {
+		if nbfFloat, ok := asNumber(nbf); ok {
 			if time.Now().Unix() < int64(nbfFloat) {
 				return map[string]interface{}{
 					"valid":   false,
@@ -17,18 +17,9 @@ func RegisterLib(env *r2core.Environment) {
 			if !ok {
 				panic("r2 first argument must be a function")
 			}
+			// El programa espera a que termine antes de salir
 			r2core.Add()
-			// Ejecutar la función en una goroutine
-			go func() {
-				defer r2core.Done()
-				defer func() {
-					if r := recover(); r != nil {
-						fmt.Println("Error en goroutine:", r)
-					}
-				}()
-				fn.Call(args[1:]...)
-			}()
-			return nil
+			return spawn(env, r2core.Done, fn, args[1:])
 		},
 
 		"go": func(args ...interface{}) interface{} {
@@ -40,19 +31,28 @@ func RegisterLib(env *r2core.Environment) {
 			if !ok {
 				panic("go first argument must be a function")
 			}
-			// Ejecutar la función en una goroutine
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						fmt.Println("Error en goroutine:", r)
-					}
-				}()
-				fn.Call(args[1:]...)
-			}()
-			return nil
+			return spawn(env, func() {}, fn, args[1:])
 		},
 	}
 	for name, fn := range builtins {
 		env.Set(name, fn)
 	}
 }
+
+// spawn ejecuta fn en un goroutine y devuelve la promesa de su resultado, que
+// se puede esperar con await. Un error se sigue mostrando como siempre, para
+// quien no espera la promesa, además de rechazarla.
+func spawn(env *r2core.Environment, done func(), fn *r2core.UserFunction, args []interface{}) *r2core.Promise {
+	p := r2core.NewPromise()
+	go func() {
+		defer done()
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintln(env.Stdout(), "Error en goroutine:", r)
+				p.Reject(r)
+			}
+		}()
+		p.Resolve(fn.Call(args...))
+	}()
+	return p
+}
@@ -18,6 +18,7 @@ import (
 
 // CommandObject represents an external command with a fluent API.
 type CommandObject struct {
+	env     *r2core.Environment // Para consultar la CapabilityPolicy al ejecutar
 	command string
 	args    []string
 	stdout  bytes.Buffer
@@ -35,7 +36,7 @@ func (c *CommandObject) Getattr(name string) (r2core.Node, bool) {
 	switch name {
 	case "run":
 		return &NativeFunction{Fn: func(args ...interface{}) interface{} {
-			checkCommandAllowed("Command.run", c.command)
+			checkCommandAllowed(c.env, "Command.run", c.command)
 			parts := strings.Fields(c.command)
 			if len(parts) == 0 {
 				panic("Command.run: command is empty")
@@ -44,7 +45,7 @@ func (c *CommandObject) Getattr(name string) (r2core.Node, bool) {
 			c.cmd.Stderr = &c.stderr
 
 			if c.pipeTo != nil {
-				checkCommandAllowed("Command.run", c.pipeTo.command)
+				checkCommandAllowed(c.env, "Command.run", c.pipeTo.command)
 				pipe, err := c.cmd.StdoutPipe()
 				if err != nil {
 					panic(fmt.Sprintf("Command.run: failed to create pipe: %v", err))
@@ -137,13 +138,14 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok {
 				panic("os.Command: argument must be a string")
 			}
-			return &CommandObject{command: command, status: -1}
+			return &CommandObject{env: env, command: command, status: -1}
 		}),
 		"exit": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "exit")
 			if len(args) < 1 {
 				os.Exit(0)
 			}
-			code, ok := args[0].(float64)
+			code, ok := asNumber(args[0])
 			if !ok {
 				panic("exit: arg should be int")
 			}
@@ -163,6 +165,9 @@ func RegisterOS(env *r2core.Environment) {
 		}),
 
 		"currentDir": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if policyFor(env).FSRoot != "" {
+				return string(filepath.Separator)
+			}
 			dir, err := os.Getwd()
 			if err != nil {
 				panic("currentDir: error " + err.Error())
@@ -171,6 +176,7 @@ func RegisterOS(env *r2core.Environment) {
 		}),
 
 		"chDir": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "chDir")
 			if len(args) < 1 {
 				panic("chDir necesita (path)")
 			}
@@ -186,6 +192,7 @@ func RegisterOS(env *r2core.Environment) {
 		}),
 
 		"setEnv": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "setEnv")
 			if len(args) < 2 {
 				panic("setEnv(key, value)")
 			}
@@ -236,7 +243,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok {
 				panic("listDir: arg should be string")
 			}
-			f, err := os.Open(path)
+			f, err := os.Open(resolvePath(env, "listDir", path, false))
 			if err != nil {
 				panic(fmt.Sprintf("listDir: error '%s': %v", path, err))
 			}
@@ -260,7 +267,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok {
 				panic("absPath: arg should be string")
 			}
-			abs, err := filepath.Abs(path)
+			abs, err := scriptAbs(env, path)
 			if err != nil {
 				panic(fmt.Sprintf("absPath: error => %v", err))
 			}
@@ -275,7 +282,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok {
 				panic("execCmd: arg should be string")
 			}
-			checkShellAllowed("execCmd")
+			checkShellAllowed(env, "execCmd")
 			out, err := exec.Command("sh", "-c", cmdLine).CombinedOutput()
 			if err != nil {
 				return fmt.Sprintf("Error:%v\nOutput:\n%s", err, out)
@@ -291,7 +298,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok {
 				panic("runProcess: arg debe ser string")
 			}
-			checkShellAllowed("runProcess")
+			checkShellAllowed(env, "runProcess")
 			// parse: naive approach => "sh -c <cmd>"
 			cmd := exec.Command("sh", "-c", cmdLine)
 			// Iniciar
@@ -398,6 +405,7 @@ func RegisterOS(env *r2core.Environment) {
 		}),
 
 		"killPid": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "killPid")
 			if len(args) < 1 {
 				panic("killPid needs (pid)")
 			}
@@ -416,6 +424,7 @@ func RegisterOS(env *r2core.Environment) {
 		}),
 
 		"signalProcess": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "signalProcess")
 			if len(args) < 2 {
 				panic("signalProcess needs (pid, signal)")
 			}
@@ -465,7 +474,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok1 {
 				panic("execWithTimeout: cmd should be string")
 			}
-			checkShellAllowed("execWithTimeout")
+			checkShellAllowed(env, "execWithTimeout")
 
 			cmd := exec.Command("sh", "-c", cmdLine)
 
@@ -511,7 +520,7 @@ func RegisterOS(env *r2core.Environment) {
 			if !ok1 || !ok2 {
 				panic("execWithEnv: arguments should be (string, map)")
 			}
-			checkShellAllowed("execWithEnv")
+			checkShellAllowed(env, "execWithEnv")
 
 			cmd := exec.Command("sh", "-c", cmdLine)
 
@@ -540,6 +549,7 @@ func RegisterOS(env *r2core.Environment) {
 
 		// Network and System State
 		"getLoadAvg": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			checkProcessAllowed(env, "getLoadAvg")
 			if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
 				return map[string]interface{}{"error": "Load average not available on this platform"}
 			}
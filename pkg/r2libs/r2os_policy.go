@@ -2,8 +2,15 @@ package r2libs
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
 )
 
 // CommandPolicy restricts what os.Command/execCmd/runProcess/execWithTimeout/
@@ -35,35 +42,96 @@ type CommandPolicy struct {
 	DisableShell bool
 }
 
+// CapabilityPolicy extends CommandPolicy to every side effect a script can
+// have: running processes, touching the filesystem and using the network.
+// Like CommandPolicy, the zero value is fully unrestricted.
+//
+// The policy is enforced when a builtin runs, not when it is registered. It is
+// taken from the script's environment when one was installed with ApplyPolicy,
+// and otherwise from the process-wide policy set with SetCapabilityPolicy.
+type CapabilityPolicy struct {
+	CommandPolicy
+
+	// DisableExec blocks every builtin that starts, signals or kills a
+	// process, or changes the process state (exit, chDir, setEnv).
+	// AllowedCommands and DisableShell only matter when it is false.
+	DisableExec bool
+
+	// FSRoot, if set, is a virtual root directory for the io module: every
+	// path a script uses, absolute or relative, is resolved inside it
+	// ("/data/x.txt" and "data/x.txt" both name FSRoot/data/x.txt) and paths
+	// returned to the script are relative to it. Symlinks that point
+	// outside FSRoot are rejected.
+	FSRoot string
+
+	// RestrictFS limits file access (io, os.listDir, imports) to ReadRoots
+	// for reads and WriteRoots for writes; a write root is readable too.
+	// With RestrictFS and no roots no file can be accessed.
+	RestrictFS bool
+	ReadRoots  []string
+	WriteRoots []string
+
+	// RestrictNetwork limits outgoing connections (HTTP clients, SOAP, gRPC,
+	// databases, hack) and listening servers to AllowedHosts. An entry is a
+	// host name or IP ("api.example.com"), optionally with a port
+	// ("localhost:8080"). With RestrictNetwork and no hosts there is no
+	// network access.
+	RestrictNetwork bool
+	AllowedHosts    []string
+}
+
 var (
 	commandPolicyMu sync.RWMutex
-	commandPolicy   CommandPolicy // zero value: fully unrestricted (backward compatible default)
+	commandPolicy   CapabilityPolicy // zero value: fully unrestricted (backward compatible default)
 )
 
 // SetCommandPolicy installs the active CommandPolicy for every Environment
 // registered afterward via RegisterOS in this process. Intended to be
 // called once, early, by the host Go program — before running any script
 // that isn't fully trusted. Passing the zero value (CommandPolicy{})
-// restores the default, unrestricted behavior.
+// restores the default, unrestricted behavior. The rest of the process-wide
+// CapabilityPolicy is left as is.
 func SetCommandPolicy(policy CommandPolicy) {
+	commandPolicyMu.Lock()
+	defer commandPolicyMu.Unlock()
+	commandPolicy.CommandPolicy = policy
+}
+
+// SetCapabilityPolicy installs the process-wide CapabilityPolicy, used by
+// every environment without its own policy (see ApplyPolicy). Passing the
+// zero value restores the default, unrestricted behavior.
+func SetCapabilityPolicy(policy CapabilityPolicy) {
 	commandPolicyMu.Lock()
 	defer commandPolicyMu.Unlock()
 	commandPolicy = policy
 }
 
-// getCommandPolicy returns a snapshot of the active policy.
-func getCommandPolicy() CommandPolicy {
+// ApplyPolicy installs policy for env and every environment derived from it,
+// overriding the process-wide policy. Embedders running several scripts in
+// one process use it to restrict each script separately.
+func ApplyPolicy(env *r2core.Environment, policy CapabilityPolicy) {
+	env.SetPolicy(&policy)
+}
+
+// policyFor returns a snapshot of the policy in effect for env.
+func policyFor(env *r2core.Environment) CapabilityPolicy {
+	if env != nil {
+		if p, ok := env.Policy().(*CapabilityPolicy); ok {
+			return *p
+		}
+	}
 	commandPolicyMu.RLock()
 	defer commandPolicyMu.RUnlock()
 	return commandPolicy
 }
 
-// checkCommandAllowed enforces AllowedCommands against a non-shell command
-// line (e.g. os.Command's argument), panicking with a clear message if the
-// resolved executable isn't on the allowlist. No-op when the policy's
-// AllowedCommands is empty (the default).
-func checkCommandAllowed(funcName, cmdLine string) {
-	policy := getCommandPolicy()
+// checkCommandAllowed enforces DisableExec and AllowedCommands against a
+// non-shell command line (e.g. os.Command's argument), panicking with a clear
+// message if the resolved executable isn't allowed. No-op under the default
+// policy.
+func checkCommandAllowed(env *r2core.Environment, funcName, cmdLine string) {
+	policy := policyFor(env)
+	checkExecAllowed(policy, funcName)
 	if len(policy.AllowedCommands) == 0 {
 		return
 	}
@@ -76,11 +144,206 @@ func checkCommandAllowed(funcName, cmdLine string) {
 	}
 }
 
-// checkShellAllowed enforces DisableShell for the sh-based execution
-// builtins, panicking with a clear message if shell execution has been
-// disabled by the embedder.
-func checkShellAllowed(funcName string) {
-	if getCommandPolicy().DisableShell {
+// checkShellAllowed enforces DisableExec and DisableShell for the sh-based
+// execution builtins, panicking with a clear message if shell execution has
+// been disabled by the embedder.
+func checkShellAllowed(env *r2core.Environment, funcName string) {
+	policy := policyFor(env)
+	checkExecAllowed(policy, funcName)
+	if policy.DisableShell {
 		panic(fmt.Sprintf("%s: shell command execution is disabled by the host's CommandPolicy", funcName))
 	}
 }
+
+// checkProcessAllowed enforces DisableExec for builtins that act on processes
+// or on the process state without running a command.
+func checkProcessAllowed(env *r2core.Environment, funcName string) {
+	checkExecAllowed(policyFor(env), funcName)
+}
+
+func checkExecAllowed(policy CapabilityPolicy, funcName string) {
+	if policy.DisableExec {
+		panic(fmt.Sprintf("%s: process execution is disabled by the host's CapabilityPolicy", funcName))
+	}
+}
+
+// resolvePath maps a path given by a script to the real path to use,
+// applying FSRoot, and panics if the policy does not allow reading it (or
+// writing it, when write is true).
+func resolvePath(env *r2core.Environment, funcName, path string, write bool) string {
+	policy := policyFor(env)
+	real, err := policy.resolve(path, write)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", funcName, err))
+	}
+	return real
+}
+
+// scriptPath is the inverse of resolvePath: it converts a real path into the
+// path the script sees, relative to FSRoot when one is set.
+func scriptPath(env *r2core.Environment, real string) string {
+	policy := policyFor(env)
+	if policy.FSRoot == "" {
+		return real
+	}
+	root, err := filepath.Abs(policy.FSRoot)
+	if err != nil {
+		return real
+	}
+	abs, err := filepath.Abs(real)
+	if err != nil {
+		return real
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || !isLocal(rel) {
+		return real
+	}
+	return filepath.Join(string(filepath.Separator), rel)
+}
+
+// scriptAbs is filepath.Abs for the paths a script sees: under FSRoot the
+// working directory is the root itself.
+func scriptAbs(env *r2core.Environment, path string) (string, error) {
+	if policyFor(env).FSRoot != "" {
+		return filepath.Clean(string(filepath.Separator) + path), nil
+	}
+	return filepath.Abs(path)
+}
+
+func (p CapabilityPolicy) resolve(path string, write bool) (string, error) {
+	orig := path
+	if p.FSRoot != "" {
+		path = filepath.Join(p.FSRoot, filepath.Clean(string(filepath.Separator)+path))
+		if !within(path, []string{p.FSRoot}) {
+			return "", fmt.Errorf("path %q escapes the sandbox root", orig)
+		}
+	}
+	if p.RestrictFS {
+		roots := p.WriteRoots
+		if !write {
+			roots = append(append([]string{}, p.ReadRoots...), p.WriteRoots...)
+		}
+		if !within(path, roots) {
+			access := "read"
+			if write {
+				access = "write"
+			}
+			return "", fmt.Errorf("%s access to %q is not allowed by the host's CapabilityPolicy", access, orig)
+		}
+	}
+	return path, nil
+}
+
+// CheckRead implements r2core.FileGuard, so imports obey the policy too.
+func (p *CapabilityPolicy) CheckRead(path string) error {
+	if !p.RestrictFS {
+		return nil
+	}
+	_, err := CapabilityPolicy{RestrictFS: true, ReadRoots: p.ReadRoots, WriteRoots: p.WriteRoots}.resolve(path, false)
+	return err
+}
+
+// within reports whether path is inside one of roots once symlinks are
+// resolved. Paths that do not exist yet are resolved through their nearest
+// existing parent.
+func within(path string, roots []string) bool {
+	real := realPath(path)
+	for _, root := range roots {
+		rel, err := filepath.Rel(realPath(root), real)
+		if err == nil && isLocal(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func realPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rest := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		} else if !os.IsNotExist(err) {
+			return abs
+		}
+		if dir == filepath.Dir(dir) {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+func isLocal(rel string) bool {
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel))
+}
+
+// checkHostAllowed enforces RestrictNetwork for a "host", "host:port" or URL
+// target, panicking with a clear message if the host is not allowed.
+func checkHostAllowed(env *r2core.Environment, funcName, target string) {
+	if err := policyFor(env).hostAllowed(target); err != nil {
+		panic(fmt.Sprintf("%s: %v", funcName, err))
+	}
+}
+
+func (p CapabilityPolicy) hostAllowed(target string) error {
+	if !p.RestrictNetwork {
+		return nil
+	}
+	host, port := splitTarget(target)
+	for _, allowed := range p.AllowedHosts {
+		h, ap := splitTarget(allowed)
+		if strings.EqualFold(h, host) && (ap == "" || ap == port) {
+			return nil
+		}
+	}
+	return fmt.Errorf("network access to %q is not allowed by the host's CapabilityPolicy", target)
+}
+
+// guardHTTPClient returns client itself under an unrestricted network policy,
+// and otherwise a copy whose transport checks the host of every request,
+// redirects included.
+func guardHTTPClient(env *r2core.Environment, client *http.Client) *http.Client {
+	policy := policyFor(env)
+	if !policy.RestrictNetwork {
+		return client
+	}
+	guarded := *client
+	guarded.Transport = &policyTransport{policy: policy, base: client.Transport}
+	return &guarded
+}
+
+type policyTransport struct {
+	policy CapabilityPolicy
+	base   http.RoundTripper
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.hostAllowed(req.URL.Host); err != nil {
+		if req.Body != nil {
+			req.Body.Close() // RoundTrip always closes the body
+		}
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// splitTarget extracts the host and the port (if any) of a URL or address.
+// A server address such as ":8080" listens on every interface and has host "".
+func splitTarget(target string) (host, port string) {
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			return u.Hostname(), u.Port()
+		}
+	}
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		return strings.Trim(h, "[]"), p
+	}
+	return strings.Trim(target, "[]"), ""
+}
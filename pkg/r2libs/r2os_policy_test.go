@@ -1,6 +1,10 @@
 package r2libs
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -26,6 +30,11 @@ func runR2OSScript(t *testing.T, code string) (result interface{}, panicked inte
 
 	defer func() {
 		panicked = recover()
+		// Library errors come back located, as *r2core.RuntimeError; the
+		// tests match on their message
+		if err, ok := panicked.(*r2core.RuntimeError); ok {
+			panicked = err.Message
+		}
 	}()
 	parser := r2core.NewParser(code)
 	program := parser.ParseProgram()
@@ -112,3 +121,150 @@ cmd.run()
 		t.Fatalf("expected DisableShell to not affect the non-shell os.Command path, got panic: %v", panicked)
 	}
 }
+
+// runPolicyScript runs code in an environment with io, os, request and
+// httpclient registered and policy installed with ApplyPolicy.
+func runPolicyScript(t *testing.T, policy CapabilityPolicy, code string) (result interface{}, panicked interface{}) {
+	t.Helper()
+	env := r2core.NewEnvironment()
+	env.Set("true", true)
+	env.Set("false", false)
+	env.Set("nil", nil)
+	RegisterStd(env)
+	RegisterIO(env)
+	RegisterOS(env)
+	RegisterRequests(env)
+	RegisterHTTPClient(env)
+	ApplyPolicy(env, policy)
+
+	defer func() {
+		panicked = recover()
+		if err, ok := panicked.(*r2core.RuntimeError); ok {
+			panicked = err.Message
+		}
+	}()
+	result = r2core.NewParser(code).ParseProgram().Eval(env)
+	return
+}
+
+func TestCapabilityPolicy_DisableExec(t *testing.T) {
+	resetCommandPolicy(t)
+	for _, code := range []string{
+		`os.Command("echo hello").run()`,
+		`os.execCmd("echo hello")`,
+		`os.setEnv("R2_POLICY_TEST", "1")`,
+		`os.exit(3)`,
+	} {
+		_, panicked := runPolicyScript(t, CapabilityPolicy{DisableExec: true}, code)
+		msg, _ := panicked.(string)
+		if !strings.Contains(msg, "process execution is disabled") {
+			t.Errorf("%s: expected a process-disabled panic, got %v", code, panicked)
+		}
+	}
+}
+
+func TestCapabilityPolicy_EnvPolicyOverridesGlobal(t *testing.T) {
+	resetCommandPolicy(t)
+	SetCapabilityPolicy(CapabilityPolicy{DisableExec: true})
+	t.Cleanup(func() { SetCapabilityPolicy(CapabilityPolicy{}) })
+
+	// La política del entorno reemplaza a la global
+	if _, panicked := runPolicyScript(t, CapabilityPolicy{}, `os.Command("echo hello").run()`); panicked != nil {
+		t.Errorf("expected the environment policy to allow the command, got %v", panicked)
+	}
+	if _, panicked := runR2OSScript(t, `os.Command("echo hello").run()`); panicked == nil {
+		t.Error("expected the process-wide policy to block the command")
+	}
+}
+
+func TestCapabilityPolicy_FSRoot(t *testing.T) {
+	resetCommandPolicy(t)
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	policy := CapabilityPolicy{FSRoot: root}
+
+	result, panicked := runPolicyScript(t, policy, `
+io.mkdirAll("/data/sub")
+io.writeFile("data/sub/a.txt", "hello")
+io.readFile("/data/sub/a.txt") + " " + io.absPath("data") + " " + io.glob("/data/sub/*.txt")[0]
+`)
+	if panicked != nil {
+		t.Fatal(panicked)
+	}
+	if result != "hello /data /data/sub/a.txt" {
+		t.Errorf("unexpected result %q", result)
+	}
+	if _, err := os.Stat(filepath.Join(root, "data", "sub", "a.txt")); err != nil {
+		t.Errorf("expected the file inside the root: %v", err)
+	}
+
+	// ".." no sale de la raíz y los symlinks hacia afuera se rechazan
+	_, panicked = runPolicyScript(t, policy, `io.readFile("../../../../../../`+filepath.Join(outside, "secret.txt")+`")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "no such file") {
+		t.Errorf("expected the path to stay inside the root, got %v", panicked)
+	}
+	_, panicked = runPolicyScript(t, policy, `io.readFile("/link.txt")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "escapes the sandbox root") {
+		t.Errorf("expected the symlink to be rejected, got %v", panicked)
+	}
+}
+
+func TestCapabilityPolicy_RestrictFS(t *testing.T) {
+	resetCommandPolicy(t)
+	readable := t.TempDir()
+	writable := t.TempDir()
+	if err := os.WriteFile(filepath.Join(readable, "in.txt"), []byte("in"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy := CapabilityPolicy{RestrictFS: true, ReadRoots: []string{readable}, WriteRoots: []string{writable}}
+
+	if _, panicked := runPolicyScript(t, policy, `io.readFile("`+filepath.Join(readable, "in.txt")+`")`); panicked != nil {
+		t.Errorf("expected a read under a read root, got %v", panicked)
+	}
+	if _, panicked := runPolicyScript(t, policy, `io.writeFile("`+filepath.Join(writable, "out.txt")+`", "x")`); panicked != nil {
+		t.Errorf("expected a write under a write root, got %v", panicked)
+	}
+	_, panicked := runPolicyScript(t, policy, `io.writeFile("`+filepath.Join(readable, "out.txt")+`", "x")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "write access") {
+		t.Errorf("expected a write outside the write roots to panic, got %v", panicked)
+	}
+	_, panicked = runPolicyScript(t, policy, `os.listDir("/")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "read access") {
+		t.Errorf("expected os.listDir outside the roots to panic, got %v", panicked)
+	}
+}
+
+func TestCapabilityPolicy_Network(t *testing.T) {
+	resetCommandPolicy(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://blocked.invalid/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	allowed := CapabilityPolicy{RestrictNetwork: true, AllowedHosts: []string{host}}
+	if result, panicked := runPolicyScript(t, allowed, `httpclient.clientHttpGet("`+server.URL+`")`); panicked != nil || result != "ok" {
+		t.Errorf("expected an allowed host to be reachable, got %v, %v", result, panicked)
+	}
+
+	_, panicked := runPolicyScript(t, CapabilityPolicy{RestrictNetwork: true}, `httpclient.clientHttpGet("`+server.URL+`")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "network access") {
+		t.Errorf("expected the request to be blocked, got %v", panicked)
+	}
+
+	// Las redirecciones también pasan por la política
+	_, panicked = runPolicyScript(t, allowed, `httpclient.clientHttpGet("`+server.URL+`/redirect")`)
+	if msg, _ := panicked.(string); !strings.Contains(msg, "blocked.invalid") {
+		t.Errorf("expected the redirect to be blocked, got %v", panicked)
+	}
+}
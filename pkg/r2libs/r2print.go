@@ -25,9 +25,9 @@ func RegisterPrint(env *r2core.Environment) {
 				panic("printRepeat: primer arg should be string")
 			}
 			for i := 0; i < count; i++ {
-				fmt.Print(s)
+				fmt.Fprint(env.Stdout(), s)
 			}
-			fmt.Println() // salto de línea
+			fmt.Fprintln(env.Stdout()) // salto de línea
 			return nil
 		}),
 
@@ -45,14 +45,14 @@ func RegisterPrint(env *r2core.Environment) {
 				width = textLen + 2
 			}
 			// Cabecera
-			fmt.Println("+" + strings.Repeat("-", width) + "+")
+			fmt.Fprintln(env.Stdout(), "+"+strings.Repeat("-", width)+"+")
 			// Texto centrado
 			space := width - textLen
 			leftPad := space / 2
 			rightPad := space - leftPad
-			fmt.Printf("|%s%s%s|\n", strings.Repeat(" ", leftPad), text, strings.Repeat(" ", rightPad))
+			fmt.Fprintf(env.Stdout(), "|%s%s%s|\n", strings.Repeat(" ", leftPad), text, strings.Repeat(" ", rightPad))
 			// Pie
-			fmt.Println("+" + strings.Repeat("-", width) + "+")
+			fmt.Fprintln(env.Stdout(), "+"+strings.Repeat("-", width)+"+")
 			return nil
 		}),
 
@@ -61,7 +61,7 @@ func RegisterPrint(env *r2core.Environment) {
 				panic("debugInspect needs (value)")
 			}
 			val := args[0]
-			fmt.Printf("[debugInspect] Value = %v (type=%T)\n", val, val)
+			fmt.Fprintf(env.Stdout(), "[debugInspect] Value = %v (type=%T)\n", val, val)
 			return nil
 		}),
 
@@ -90,7 +90,7 @@ func RegisterPrint(env *r2core.Environment) {
 				colorCode = "\033[0m" // reset
 			}
 			// Imprimir con color, y reset al final
-			fmt.Print(colorCode, txt, "\033[0m\n")
+			fmt.Fprint(env.Stdout(), colorCode, txt, "\033[0m\n")
 			return nil
 		}),
 
@@ -108,10 +108,10 @@ func RegisterPrint(env *r2core.Environment) {
 			for i := 0; i <= total; i++ {
 				pct := float64(i) / float64(total) * 100.0
 				bar := strings.Repeat("#", i) + strings.Repeat(" ", total-i)
-				fmt.Printf("\r%s [%s] %.0f%%", label, bar, pct)
+				fmt.Fprintf(env.Stdout(), "\r%s [%s] %.0f%%", label, bar, pct)
 				time.Sleep(time.Duration(delayMs) * time.Millisecond)
 			}
-			fmt.Println() // salto línea final
+			fmt.Fprintln(env.Stdout()) // salto línea final
 			return nil
 		}),
 
@@ -155,9 +155,9 @@ func RegisterPrint(env *r2core.Environment) {
 			for i := 0; i < len(tableData); i++ {
 				row := tableData[i]
 				for j := 0; j < len(row); j++ {
-					fmt.Printf("%-*s ", colWidths[j], row[j])
+					fmt.Fprintf(env.Stdout(), "%-*s ", colWidths[j], row[j])
 				}
-				fmt.Println()
+				fmt.Fprintln(env.Stdout())
 			}
 			return nil
 		}),
@@ -180,14 +180,14 @@ func RegisterPrint(env *r2core.Environment) {
 			switch strings.ToLower(alignOpt) {
 			case "left":
 				// s + spaces
-				fmt.Println(s + strings.Repeat(" ", space))
+				fmt.Fprintln(env.Stdout(), s+strings.Repeat(" ", space))
 			case "right":
 				// spaces + s
-				fmt.Println(strings.Repeat(" ", space) + s)
+				fmt.Fprintln(env.Stdout(), strings.Repeat(" ", space)+s)
 			case "center":
 				leftPad := space / 2
 				rightPad := space - leftPad
-				fmt.Println(strings.Repeat(" ", leftPad) + s + strings.Repeat(" ", rightPad))
+				fmt.Fprintln(env.Stdout(), strings.Repeat(" ", leftPad)+s+strings.Repeat(" ", rightPad))
 			default:
 				panic("printAlign: align debe ser 'left','right' o 'center'")
 			}
@@ -197,11 +197,11 @@ func RegisterPrint(env *r2core.Environment) {
 		"println": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			for i, arg := range args {
 				if i > 0 {
-					fmt.Print(" ")
+					fmt.Fprint(env.Stdout(), " ")
 				}
-				fmt.Print(arg)
+				fmt.Fprint(env.Stdout(), printSafeArg(arg))
 			}
-			fmt.Println()
+			fmt.Fprintln(env.Stdout())
 			return nil
 		}),
 
@@ -215,9 +215,9 @@ func RegisterPrint(env *r2core.Environment) {
 			}
 			var formatArgs []interface{}
 			if len(args) > 1 {
-				formatArgs = args[1:]
+				formatArgs = printSafeArgs(args[1:])
 			}
-			fmt.Printf(format, formatArgs...)
+			fmt.Fprintf(env.Stdout(), format, formatArgs...)
 			return nil
 		}),
 
@@ -231,7 +231,7 @@ func RegisterPrint(env *r2core.Environment) {
 			}
 			var formatArgs []interface{}
 			if len(args) > 1 {
-				formatArgs = args[1:]
+				formatArgs = printSafeArgs(args[1:])
 			}
 			return fmt.Sprintf(format, formatArgs...)
 		}),
@@ -240,7 +240,7 @@ func RegisterPrint(env *r2core.Environment) {
 			if len(args) < 1 {
 				panic("sprint needs at least one argument")
 			}
-			return fmt.Sprint(args...)
+			return fmt.Sprint(printSafeArgs(args)...)
 		}),
 
 		"printError": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -251,7 +251,7 @@ func RegisterPrint(env *r2core.Environment) {
 			if !ok {
 				panic("printError: el argumento debe ser una cadena de texto")
 			}
-			fmt.Println("\033[31m" + str + "\033[0m") // Rojo
+			fmt.Fprintln(env.Stdout(), "\033[31m"+str+"\033[0m") // Rojo
 			return nil
 		}),
 
@@ -263,7 +263,7 @@ func RegisterPrint(env *r2core.Environment) {
 			if !ok {
 				panic("printWarning: el argumento debe ser una cadena de texto")
 			}
-			fmt.Println("\033[33m" + str + "\033[0m") // Amarillo
+			fmt.Fprintln(env.Stdout(), "\033[33m"+str+"\033[0m") // Amarillo
 			return nil
 		}),
 
@@ -275,7 +275,7 @@ func RegisterPrint(env *r2core.Environment) {
 			if !ok {
 				panic("printSuccess: el argumento debe ser una cadena de texto")
 			}
-			fmt.Println("\033[32m" + str + "\033[0m") // Verde
+			fmt.Fprintln(env.Stdout(), "\033[32m"+str+"\033[0m") // Verde
 			return nil
 		}),
 
@@ -288,19 +288,19 @@ func RegisterPrint(env *r2core.Environment) {
 			if err != nil {
 				panic("printJSON: error al formatear JSON")
 			}
-			fmt.Println(string(jsonBytes))
+			fmt.Fprintln(env.Stdout(), string(jsonBytes))
 			return nil
 		}),
 
 		"clearScreen": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			// Código ANSI para limpiar la pantalla
-			fmt.Print("\033[H\033[2J")
+			fmt.Fprint(env.Stdout(), "\033[H\033[2J")
 			return nil
 		}),
 
 		"printTimestamp": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			currentTime := time.Now().Format(time.RFC1123)
-			fmt.Println(currentTime)
+			fmt.Fprintln(env.Stdout(), currentTime)
 			return nil
 		}),
 
@@ -313,16 +313,16 @@ func RegisterPrint(env *r2core.Environment) {
 				panic("printHeader: el argumento debe ser una cadena de texto")
 			}
 			separator := strings.Repeat("=", utf8.RuneCountInString(str))
-			fmt.Println(separator)
-			fmt.Println(str)
-			fmt.Println(separator)
+			fmt.Fprintln(env.Stdout(), separator)
+			fmt.Fprintln(env.Stdout(), str)
+			fmt.Fprintln(env.Stdout(), separator)
 			return nil
 		}),
 
 		"printSeparator": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			width := 40 // Valor por defecto
 			if len(args) >= 1 {
-				w, ok := args[0].(float64)
+				w, ok := asNumber(args[0])
 				if !ok {
 					panic("printSeparator: el argumento debe ser un número")
 				}
@@ -331,7 +331,7 @@ func RegisterPrint(env *r2core.Environment) {
 			if width < 0 {
 				width = 0
 			}
-			fmt.Println(strings.Repeat("-", width))
+			fmt.Fprintln(env.Stdout(), strings.Repeat("-", width))
 			return nil
 		}),
 	}
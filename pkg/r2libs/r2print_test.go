@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"unicode/utf8"
 
@@ -54,3 +55,32 @@ func TestPrintHeaderMultibyteWidth(t *testing.T) {
 		}
 	}
 }
+
+// TestPrintErrors checks that a caught runtime error prints as its message
+// with the position, as it did when catch received a string.
+func TestPrintErrors(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterStd(env)
+	RegisterConsole(env)
+	RegisterPrint(env)
+	var out bytes.Buffer
+	env.SetOutput(&out, &out)
+
+	r2core.NewParserWithFile(`try { 1 / 0 } catch (e) {
+		std.print("std:", e)
+		console.log("console:", e)
+		r2printer.println("println:", e)
+		r2printer.printf("printf: %v\n", e)
+	}`, "main.r2").ParseProgram().Eval(env)
+
+	for _, want := range []string{
+		"std: main.r2:1:9: Division by zero\n",
+		"console: main.r2:1:9: Division by zero\n",
+		"println: main.r2:1:9: Division by zero\n",
+		"printf: main.r2:1:9: Division by zero\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the output, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package r2libs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+// RegisterPromise registra el módulo promise: los combinadores de las
+// promesas que devuelven las funciones async, r2() y go().
+func RegisterPromise(env *r2core.Environment) {
+	functions := map[string]r2core.BuiltinFunction{
+		"all": func(args ...interface{}) interface{} {
+			return r2core.PromiseAll(promiseList("all", args))
+		},
+		"race": func(args ...interface{}) interface{} {
+			return r2core.PromiseRace(promiseList("race", args))
+		},
+		"any": func(args ...interface{}) interface{} {
+			return r2core.PromiseAny(promiseList("any", args))
+		},
+		"timeout": func(args ...interface{}) interface{} {
+			if len(args) != 2 {
+				panic("timeout needs (promise, seconds)")
+			}
+			return r2core.PromiseTimeout(env, r2core.ToPromise(args[0]), promiseSeconds("timeout", args[1]))
+		},
+		"delay": func(args ...interface{}) interface{} {
+			if len(args) < 1 || len(args) > 2 {
+				panic("delay needs (seconds, [value])")
+			}
+			d := promiseSeconds("delay", args[0])
+			var value interface{}
+			if len(args) == 2 {
+				value = args[1]
+			}
+			p := r2core.NewPromise()
+			time.AfterFunc(d, func() { p.Resolve(value) })
+			return p
+		},
+		"resolve": func(args ...interface{}) interface{} {
+			var value interface{}
+			if len(args) > 0 {
+				value = args[0]
+			}
+			return r2core.ToPromise(value)
+		},
+		"reject": func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("reject needs (reason)")
+			}
+			return r2core.RejectedPromise(args[0])
+		},
+		"isPromise": func(args ...interface{}) interface{} {
+			if len(args) != 1 {
+				panic("isPromise needs (value)")
+			}
+			_, ok := args[0].(*r2core.Promise)
+			return ok
+		},
+	}
+
+	RegisterModule(env, "promise", functions)
+}
+
+// promiseList devuelve el array de promesas (o valores) de un combinador.
+func promiseList(name string, args []interface{}) []interface{} {
+	if len(args) != 1 {
+		panic(name + " needs (array of promises)")
+	}
+	switch list := args[0].(type) {
+	case []interface{}:
+		return list
+	case r2core.InterfaceSlice:
+		return list
+	}
+	panic(fmt.Sprintf("%s: the argument must be an array, got %T", name, args[0]))
+}
+
+// promiseSeconds convierte un número de segundos, como en std.sleep, en una
+// duración.
+func promiseSeconds(name string, v interface{}) time.Duration {
+	secs, ok := asNumber(v)
+	if !ok || secs < 0 {
+		panic(name + ": seconds must be a non-negative number")
+	}
+	return time.Duration(secs * float64(time.Second))
+}
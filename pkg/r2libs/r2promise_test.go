@@ -0,0 +1,90 @@
+package r2libs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+)
+
+func runPromiseScript(t *testing.T, code string) (interface{}, string) {
+	t.Helper()
+	var out bytes.Buffer
+	env := r2core.NewEnvironment()
+	env.SetOutput(&out, &out)
+	env.Set("true", true)
+	env.Set("false", false)
+	env.Set("nil", nil)
+	RegisterLib(env)
+	RegisterPromise(env)
+	result := env.Run(r2core.NewParser(code))
+	return result, out.String()
+}
+
+func TestPromiseModule_Combinators(t *testing.T) {
+	code := `
+		async func twice(x) { return x * 2 }
+		async func fail() { throw "failed" }
+		let all = await promise.all([twice(1), 5, promise.resolve(3)])
+		let race = await promise.race([promise.delay(0.5, "slow"), promise.delay(0.01, "fast")])
+		let anyv = await promise.any([fail(), twice(4)])
+		let timedOut = nil
+		try {
+			await promise.timeout(promise.delay(1), 0.02)
+		} catch (e: TimeoutError) {
+			timedOut = e.name + ": " + e.message
+		}
+		let rejected = nil
+		try {
+			await promise.all([twice(1), promise.reject("nope")])
+		} catch (e) {
+			rejected = e
+		}
+		[all, race, anyv, timedOut, rejected, promise.isPromise(twice(1)), promise.isPromise(1)]
+	`
+	result, _ := runPromiseScript(t, code)
+	got := result.([]interface{})
+	all := got[0].([]interface{})
+	if len(all) != 3 || all[0] != 2.0 || all[1] != 5.0 || all[2] != 3.0 {
+		t.Errorf("all: expected [2 5 3], got %v", all)
+	}
+	if got[1] != "fast" {
+		t.Errorf("race: expected fast, got %v", got[1])
+	}
+	if got[2] != 8.0 {
+		t.Errorf("any: expected 8, got %v", got[2])
+	}
+	if msg, _ := got[3].(string); !strings.HasPrefix(msg, "TimeoutError: promise timed out") {
+		t.Errorf("timeout: expected a timeout rejection, got %v", got[3])
+	}
+	if got[4] != "nope" {
+		t.Errorf("all: expected the rejection nope, got %v", got[4])
+	}
+	if got[5] != true || got[6] != false {
+		t.Errorf("isPromise: got %v %v", got[5], got[6])
+	}
+}
+
+func TestLib_GoroutinesReturnPromises(t *testing.T) {
+	code := `
+		let a = r2(func(x) { return x + 1 }, 41)
+		let b = go(func() { return "go" })
+		let failed = nil
+		try {
+			await r2(func() { throw "inside" })
+		} catch (e) {
+			failed = e
+		}
+		[await a, await b, failed]
+	`
+	result, out := runPromiseScript(t, code)
+	got := result.([]interface{})
+	if got[0] != 42.0 || got[1] != "go" || got[2] != "inside" {
+		t.Errorf("unexpected results %v", got)
+	}
+	// El error se sigue mostrando para quien no espera la promesa
+	if !strings.Contains(out, "Error en goroutine: inside") {
+		t.Errorf("expected the goroutine error in the output, got %q", out)
+	}
+}
@@ -47,6 +47,7 @@ type Response struct {
 
 // Session represents an HTTP session for reusing connections and settings
 type Session struct {
+	env        *r2core.Environment // Para aplicar la CapabilityPolicy
 	Client     *http.Client
 	Headers    map[string]string
 	Auth       *BasicAuth
@@ -68,14 +69,16 @@ func RegisterRequests(env *r2core.Environment) {
 	initGlobalCookieJar()
 
 	functions := map[string]r2core.BuiltinFunction{
-		"get":       r2core.BuiltinFunction(globalGet),
-		"post":      r2core.BuiltinFunction(globalPost),
-		"put":       r2core.BuiltinFunction(globalPut),
-		"delete":    r2core.BuiltinFunction(globalDelete),
-		"patch":     r2core.BuiltinFunction(globalPatch),
-		"head":      r2core.BuiltinFunction(globalHead),
-		"options":   r2core.BuiltinFunction(globalOptions),
-		"session":   r2core.BuiltinFunction(createSession),
+		"get":     requestFunc(env, "GET"),
+		"post":    requestFunc(env, "POST"),
+		"put":     requestFunc(env, "PUT"),
+		"delete":  requestFunc(env, "DELETE"),
+		"patch":   requestFunc(env, "PATCH"),
+		"head":    requestFunc(env, "HEAD"),
+		"options": requestFunc(env, "OPTIONS"),
+		"session": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			return createSession(env, args...)
+		}),
 		"urlencode": r2core.BuiltinFunction(urlEncode),
 		"urldecode": r2core.BuiltinFunction(urlDecode),
 	}
@@ -83,37 +86,16 @@ func RegisterRequests(env *r2core.Environment) {
 	RegisterModule(env, "request", functions)
 }
 
-// Global request functions that create a new session for each request
-func globalGet(args ...interface{}) interface{} {
-	return makeRequest("GET", args...)
-}
-
-func globalPost(args ...interface{}) interface{} {
-	return makeRequest("POST", args...)
-}
-
-func globalPut(args ...interface{}) interface{} {
-	return makeRequest("PUT", args...)
-}
-
-func globalDelete(args ...interface{}) interface{} {
-	return makeRequest("DELETE", args...)
-}
-
-func globalPatch(args ...interface{}) interface{} {
-	return makeRequest("PATCH", args...)
-}
-
-func globalHead(args ...interface{}) interface{} {
-	return makeRequest("HEAD", args...)
-}
-
-func globalOptions(args ...interface{}) interface{} {
-	return makeRequest("OPTIONS", args...)
+// requestFunc returns the module function for method, which creates a new
+// session for each request
+func requestFunc(env *r2core.Environment, method string) r2core.BuiltinFunction {
+	return func(args ...interface{}) interface{} {
+		return makeRequest(env, method, args...)
+	}
 }
 
 // makeRequest creates a temporary session and makes a request
-func makeRequest(method string, args ...interface{}) interface{} {
+func makeRequest(env *r2core.Environment, method string, args ...interface{}) interface{} {
 	if len(args) == 0 {
 		panic(fmt.Sprintf("%s requires at least a URL", method))
 	}
@@ -125,6 +107,7 @@ func makeRequest(method string, args ...interface{}) interface{} {
 
 	// Create temporary session
 	session := &Session{
+		env:        env,
 		Client:     &http.Client{Jar: globalCookieJar, Timeout: 30 * time.Second},
 		Headers:    make(map[string]string),
 		Timeout:    30 * time.Second,
@@ -146,9 +129,10 @@ func makeRequest(method string, args ...interface{}) interface{} {
 }
 
 // createSession creates a new Session object
-func createSession(args ...interface{}) interface{} {
+func createSession(env *r2core.Environment, args ...interface{}) interface{} {
 	jar, _ := cookiejar.New(nil)
 	session := &Session{
+		env:        env,
 		Client:     &http.Client{Jar: jar, Timeout: 30 * time.Second},
 		Headers:    make(map[string]string),
 		Timeout:    30 * time.Second,
@@ -265,7 +249,7 @@ func (s *Session) request(method, reqURL string, params map[string]interface{})
 				writer := multipart.NewWriter(&b)
 				for key, value := range filesMap {
 					if filePath, ok := value.(string); ok {
-						file, err := os.Open(filePath)
+						file, err := os.Open(resolvePath(s.env, "request", filePath, false))
 						if err != nil {
 							panic(fmt.Sprintf("Failed to open file: %v", err))
 						}
@@ -349,10 +333,10 @@ func (s *Session) request(method, reqURL string, params map[string]interface{})
 		}
 		if retries, exists := params["retries"]; exists {
 			if retriesMap, ok := retries.(map[string]interface{}); ok {
-				if max, ok := retriesMap["max"].(float64); ok {
+				if max, ok := asNumber(retriesMap["max"]); ok {
 					maxRetries = int(max)
 				}
-				if delay, ok := retriesMap["delay"].(float64); ok {
+				if delay, ok := asNumber(retriesMap["delay"]); ok {
 					retryDelay = time.Duration(delay) * time.Second
 				}
 			}
@@ -371,7 +355,7 @@ func (s *Session) request(method, reqURL string, params map[string]interface{})
 		}
 		if params != nil {
 			if timeout, exists := params["timeout"]; exists {
-				if timeoutVal, ok := timeout.(float64); ok {
+				if timeoutVal, ok := asNumber(timeout); ok {
 					duration := time.Duration(timeoutVal) * time.Second
 					var cancel context.CancelFunc
 					ctx, cancel = context.WithTimeout(ctx, duration)
@@ -419,7 +403,7 @@ func (s *Session) request(method, reqURL string, params map[string]interface{})
 			}
 		}
 
-		resp, err = requestClient.Do(req)
+		resp, err = guardHTTPClient(s.env, requestClient).Do(req)
 		if err == nil && resp.StatusCode < 500 {
 			break
 		}
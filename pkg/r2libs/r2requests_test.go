@@ -907,14 +907,14 @@ func TestCookieJarAutomatic(t *testing.T) {
 	// Test with global functions (should now handle cookies automatically)
 	t.Run("GlobalFunctionsCookieHandling", func(t *testing.T) {
 		// First request to login endpoint
-		loginResult := globalGet(server.URL + "/login")
+		loginResult := makeRequest(nil, "GET", server.URL+"/login")
 		loginResponse := loginResult.(map[string]interface{})
 		if loginResponse["status_code"] != 200 {
 			t.Fatalf("Expected login status 200, got %v", loginResponse["status_code"])
 		}
 
 		// Second request to protected endpoint
-		protectedResult := globalGet(server.URL + "/protected")
+		protectedResult := makeRequest(nil, "GET", server.URL+"/protected")
 		protectedResponse := protectedResult.(map[string]interface{})
 		if protectedResponse["status_code"] != 200 {
 			t.Errorf("Expected protected status 200, got %v", protectedResponse["status_code"])
@@ -31,6 +31,10 @@ type SOAPClient struct {
 	SkipTLSVerify bool
 	Auth          *SOAPAuth
 
+	// env is the environment that created the client, whose CapabilityPolicy
+	// applies to its requests (nil means the process-wide policy).
+	env *r2core.Environment
+
 	// mu guards the mutable fields above (Headers, TLSConfig, SkipTLSVerify,
 	// Auth, HTTPTimeout) since a single SOAPClient returned to R2Lang can be
 	// shared and mutated concurrently across "r2"/goroutine calls.
@@ -172,6 +176,7 @@ func RegisterSOAP(env *r2core.Environment) {
 				}
 			}
 
+			checkHostAllowed(env, "soap.client", wsdlURL)
 			client, err := createSOAPClient(wsdlURL, customHeaders)
 			if err != nil {
 				// Provide more detailed error information
@@ -187,6 +192,7 @@ func RegisterSOAP(env *r2core.Environment) {
 				panic(errorMsg)
 			}
 
+			client.env = env
 			return soapClientToMap(client)
 		}),
 
@@ -220,6 +226,7 @@ func RegisterSOAP(env *r2core.Environment) {
 				panic("soapRequest: all parameters must be strings")
 			}
 
+			checkHostAllowed(env, "soap.request", url)
 			response, err := sendSOAPRequest(url, soapAction, envelope)
 			if err != nil {
 				panic(fmt.Sprintf("soapRequest: %v", err))
@@ -492,7 +499,7 @@ func soapClientToMap(client *SOAPClient) map[string]interface{} {
 			panic("setTimeout requires (seconds)")
 		}
 
-		seconds, ok := args[0].(float64)
+		seconds, ok := asNumber(args[0])
 		if !ok {
 			panic("setTimeout: seconds must be a number")
 		}
@@ -775,6 +782,9 @@ func (client *SOAPClient) sendRequest(soapAction, envelope string) (string, erro
 		Transport: transport,
 	}
 
+	if err := policyFor(client.env).hostAllowed(serviceURL); err != nil {
+		return "", err
+	}
 	req, err := http.NewRequest("POST", serviceURL, strings.NewReader(envelope))
 	if err != nil {
 		return "", err
@@ -2,6 +2,7 @@ package r2libs
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -18,8 +19,24 @@ func RegisterStd(env *r2core.Environment) {
 			if len(args) < 1 {
 				return "nil"
 			}
-			val := args[0]
-			return fmt.Sprintf("%T", val)
+			switch val := args[0].(type) {
+			case *big.Int:
+				return "bigint"
+			case *r2core.DecimalValue:
+				return "decimal"
+			case *r2core.EnumValue:
+				return "enum"
+			case *r2core.Set:
+				return "Set"
+			case *r2core.OrderedMap:
+				return "Map"
+			case *r2core.ImmutableList:
+				return "ImmutableList"
+			case *r2core.ImmutableMap:
+				return "ImmutableMap"
+			default:
+				return fmt.Sprintf("%T", val)
+			}
 		}),
 
 		"len": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -44,7 +61,7 @@ func RegisterStd(env *r2core.Environment) {
 			if len(args) < 1 {
 				panic("sleep needs 1 argument (seconds)")
 			}
-			secs, ok := args[0].(float64)
+			secs, ok := asNumber(args[0])
 			if !ok {
 				panic("sleep: arg should be a number")
 			}
@@ -82,6 +99,38 @@ func RegisterStd(env *r2core.Environment) {
 			return f
 		}),
 
+		"int": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("int needs 1 argument")
+			}
+			return r2core.ToInt64(args[0])
+		}),
+
+		"bigint": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("bigint needs 1 argument")
+			}
+			return r2core.ToBigInt(args[0])
+		}),
+
+		"decimal": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("decimal needs 1 or 2 arguments (value, [scale])")
+			}
+			d := r2core.ToDecimal(args[0])
+			if len(args) > 1 {
+				d = d.Round(int(toFloat(args[1])))
+			}
+			return d
+		}),
+
+		"float": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
+			if len(args) < 1 {
+				panic("float needs 1 argument")
+			}
+			return toFloat(args[0])
+		}),
+
 		"toString": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			if len(args) < 1 {
 				panic("toString needs 1 argument")
@@ -112,8 +161,8 @@ func RegisterStd(env *r2core.Environment) {
 			if len(args) < 2 {
 				panic("range needs 2 arguments: (start, end)")
 			}
-			start, ok1 := args[0].(float64)
-			end, ok2 := args[1].(float64)
+			start, ok1 := asNumber(args[0])
+			end, ok2 := asNumber(args[1])
 			if !ok1 || !ok2 {
 				panic("range: arg should be number, number")
 			}
@@ -256,11 +305,11 @@ func RegisterStd(env *r2core.Environment) {
 		"print": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			for i, arg := range args {
 				if i > 0 {
-					fmt.Print(" ")
+					fmt.Fprint(env.Stdout(), " ")
 				}
-				fmt.Print(printSafeArg(arg))
+				fmt.Fprint(env.Stdout(), printSafeArg(arg))
 			}
-			fmt.Println()
+			fmt.Fprintln(env.Stdout())
 			return nil
 		}),
 
@@ -275,7 +324,8 @@ func RegisterStd(env *r2core.Environment) {
 // printSafeArg substitutes a stable "<function>" placeholder for any Go func
 // value (BuiltinFunction, *UserFunction, or closures returned by member
 // access) so std.print never leaks a raw Go pointer address to the user via
-// fmt's default %v formatting.
+// fmt's default %v formatting. Error instances print as their text, the way
+// they convert in string concatenation.
 func printSafeArg(arg interface{}) interface{} {
 	if arg == nil {
 		return arg
@@ -283,9 +333,21 @@ func printSafeArg(arg interface{}) interface{} {
 	if reflect.TypeOf(arg).Kind() == reflect.Func {
 		return "<function>"
 	}
+	if text, ok := r2core.ErrorText(arg); ok {
+		return text
+	}
 	return arg
 }
 
+// printSafeArgs applies printSafeArg to every argument of a print call.
+func printSafeArgs(args []interface{}) []interface{} {
+	safe := make([]interface{}, len(args))
+	for i, arg := range args {
+		safe[i] = printSafeArg(arg)
+	}
+	return safe
+}
+
 // Helper for deepCopy (recursive)
 func deepCopy(value interface{}) interface{} {
 	return deepCopyRec(value, make(map[uintptr]interface{}))
@@ -365,6 +427,15 @@ func isType(value interface{}, typeString string) bool {
 	case "number", "float", "float64":
 		_, ok := value.(float64)
 		return ok
+	case "int", "int64":
+		_, ok := value.(int64)
+		return ok
+	case "bigint":
+		_, ok := value.(*big.Int)
+		return ok
+	case "decimal":
+		_, ok := value.(*r2core.DecimalValue)
+		return ok
 	case "string":
 		_, ok := value.(string)
 		return ok
@@ -1,6 +1,8 @@
 package r2libs
 
 import (
+	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 	"time"
@@ -205,3 +207,53 @@ func TestStdFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestStdNumericConversions(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterStd(env)
+	stdModuleObj, _ := env.Get("std")
+	stdModule := stdModuleObj.(map[string]interface{})
+	call := func(name string, args ...interface{}) interface{} {
+		return stdModule[name].(r2core.BuiltinFunction)(args...)
+	}
+
+	if got := call("int", 3.9); got != int64(3) {
+		t.Errorf("int(3.9): expected int64 3, got %T %v", got, got)
+	}
+	if got := call("bigint", "123456789012345678901234567890"); call("typeOf", got) != "bigint" {
+		t.Errorf("bigint: expected a bigint, got %T", got)
+	}
+	if got := call("decimal", 12.5, 2.0); call("typeOf", got) != "decimal" || got.(*r2core.DecimalValue).String() != "12.50" {
+		t.Errorf("decimal(12.5, 2): expected 12.50, got %v", got)
+	}
+	if got := call("float", call("decimal", "0.25")); got != 0.25 {
+		t.Errorf("float(decimal): expected 0.25, got %v", got)
+	}
+	if call("is", int64(1), "int") != true || call("is", call("decimal", "1"), "decimal") != true {
+		t.Error("is: expected int and decimal to be recognized")
+	}
+}
+
+// Functions that take a number accept the exact numeric types too.
+func TestNumericArgumentsAcceptExactTypes(t *testing.T) {
+	env := r2core.NewEnvironment()
+	RegisterStd(env)
+	RegisterString(env)
+	RegisterCollections(env)
+	call := func(module, name string, args ...interface{}) interface{} {
+		mod, _ := env.Get(module)
+		return mod.(map[string]interface{})[name].(r2core.BuiltinFunction)(args...)
+	}
+
+	call("std", "sleep", int64(0))
+	if got := call("string", "repeat", "ab", big.NewInt(2)); got != "abab" {
+		t.Errorf("repeat(\"ab\", 2n): expected abab, got %v", got)
+	}
+	if got := call("collections", "range", 0.0, r2core.ToDecimal(3)); fmt.Sprint(got) != "[0 1 2]" {
+		t.Errorf("range(0, 3d): expected [0 1 2], got %v", got)
+	}
+	sorted := call("collections", "sort", []interface{}{big.NewInt(3), 1.0, r2core.ToDecimal(2.5)})
+	if fmt.Sprint(sorted) != "[1 2.5 3]" {
+		t.Errorf("sort: expected [1 2.5 3], got %v", sorted)
+	}
+}
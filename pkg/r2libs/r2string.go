@@ -58,8 +58,8 @@ func RegisterString(env *r2core.Environment) {
 				panic("substring necesita (str, start, length)")
 			}
 			s, okS := args[0].(string)
-			startF, ok1 := args[1].(float64)
-			lengthF, ok2 := args[2].(float64)
+			startF, ok1 := asNumber(args[1])
+			lengthF, ok2 := asNumber(args[2])
 			if !(okS && ok1 && ok2) {
 				panic("substring: (str, start, length) => str y numéricos")
 			}
@@ -207,7 +207,7 @@ func RegisterString(env *r2core.Environment) {
 				panic("repeat necesita (str, count)")
 			}
 			s, okS := args[0].(string)
-			countF, okC := args[1].(float64)
+			countF, okC := asNumber(args[1])
 			if !(okS && okC) {
 				panic("repeat: (str, count) => str string y count numérico")
 			}
@@ -223,7 +223,7 @@ func RegisterString(env *r2core.Environment) {
 				panic("padStart necesita (str, targetLength, padStr)")
 			}
 			s, okS := args[0].(string)
-			targetF, okT := args[1].(float64)
+			targetF, okT := asNumber(args[1])
 			pad, okP := args[2].(string)
 			if !(okS && okT && okP) {
 				panic("padStart: (str, targetLength, padStr) => str y padStr strings, targetLength numérico")
@@ -247,7 +247,7 @@ func RegisterString(env *r2core.Environment) {
 				panic("padEnd necesita (str, targetLength, padStr)")
 			}
 			s, okS := args[0].(string)
-			targetF, okT := args[1].(float64)
+			targetF, okT := asNumber(args[1])
 			pad, okP := args[2].(string)
 			if !(okS && okT && okP) {
 				panic("padEnd: (str, targetLength, padStr) => str y padStr strings, targetLength numérico")
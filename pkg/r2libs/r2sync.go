@@ -73,7 +73,7 @@ func (w *WaitGroupObject) Getattr(name string) (r2core.Node, bool) {
 			if len(args) != 1 {
 				panic("WaitGroup.add needs exactly one argument: delta")
 			}
-			delta, ok := args[0].(float64)
+			delta, ok := asNumber(args[0])
 			if !ok {
 				panic("WaitGroup.add: argument must be a number")
 			}
@@ -176,7 +176,7 @@ func RegisterSync(env *r2core.Environment) {
 			if len(args) != 1 {
 				panic("sync.Semaphore needs exactly one argument: permit count")
 			}
-			permits, ok := args[0].(float64)
+			permits, ok := asNumber(args[0])
 			if !ok {
 				panic("sync.Semaphore: argument must be a number")
 			}
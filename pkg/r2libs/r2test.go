@@ -75,7 +75,7 @@ func RegisterTest(env *r2core.Environment) {
 				}
 			}
 			if len(testNames) == 0 {
-				fmt.Println("No se encontraron funciones test* en este script.")
+				fmt.Fprintln(env.Stdout(), "No se encontraron funciones test* en este script.")
 				return nil
 			}
 			// Ordenar alfabéticamente, si quieres
@@ -122,12 +122,12 @@ func RegisterTest(env *r2core.Environment) {
 				if !r.passed {
 					status = "FAILED"
 				}
-				fmt.Printf("[%s] %s (%.2f ms)\n", status, r.name, float64(r.elapsed.Microseconds())/1000.0)
+				fmt.Fprintf(env.Stdout(), "[%s] %s (%.2f ms)\n", status, r.name, float64(r.elapsed.Microseconds())/1000.0)
 				if !r.passed {
 					// indent el message
 					lines := strings.Split(r.message, "\n")
 					for _, ln := range lines {
-						fmt.Printf("   %s\n", ln)
+						fmt.Fprintf(env.Stdout(), "   %s\n", ln)
 					}
 				} else {
 					passedCount++
@@ -135,7 +135,7 @@ func RegisterTest(env *r2core.Environment) {
 			}
 			total := len(results)
 			failedCount := total - passedCount
-			fmt.Printf("\nResumen: %d PASSED, %d FAILED, %d TOTAL (%.2f ms)\n",
+			fmt.Fprintf(env.Stdout(), "\nResumen: %d PASSED, %d FAILED, %d TOTAL (%.2f ms)\n",
 				passedCount, failedCount, total, float64(endGlobal.Microseconds())/1000.0)
 
 			return nil
@@ -143,9 +143,9 @@ func RegisterTest(env *r2core.Environment) {
 
 		"printStep": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
 			for _, arg := range args {
-				fmt.Print(arg, " ")
+				fmt.Fprint(env.Stdout(), arg, " ")
 			}
-			fmt.Println()
+			fmt.Fprintln(env.Stdout())
 			return nil
 		}),
 
@@ -70,7 +70,7 @@ func unicodeSubstring(args ...interface{}) interface{} {
 		panic("usubstr() requiere un string como primer argumento")
 	}
 
-	start, ok := args[1].(float64)
+	start, ok := asNumber(args[1])
 	if !ok {
 		panic("usubstr() requiere un número como segundo argumento")
 	}
@@ -87,7 +87,7 @@ func unicodeSubstring(args ...interface{}) interface{} {
 
 	endIdx := len(runes)
 	if len(args) == 3 {
-		length, ok := args[2].(float64)
+		length, ok := asNumber(args[2])
 		if !ok {
 			panic("usubstr() requiere un número como tercer argumento")
 		}
@@ -281,7 +281,7 @@ func fromCharCode(args ...interface{}) interface{} {
 		panic("ufromcode() requiere exactamente 1 argumento")
 	}
 
-	code, ok := args[0].(float64)
+	code, ok := asNumber(args[0])
 	if !ok {
 		panic("ufromcode() requiere un número")
 	}
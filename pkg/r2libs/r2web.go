@@ -61,8 +61,8 @@ func RegisterWeb(env *r2core.Environment) {
 				"post":   webRouteRegistrar(app, "POST"),
 				"put":    webRouteRegistrar(app, "PUT"),
 				"delete": webRouteRegistrar(app, "DELETE"),
-				"static": webStaticRegistrar(app),
-				"listen": webListenRegistrar(app),
+				"static": webStaticRegistrar(env, app),
+				"listen": webListenRegistrar(env, app),
 				"use":    webUseRegistrar(app),
 			}
 		}),
@@ -72,8 +72,8 @@ func RegisterWeb(env *r2core.Environment) {
 		"post":   webRouteRegistrar(globalApp, "POST"),
 		"put":    webRouteRegistrar(globalApp, "PUT"),
 		"delete": webRouteRegistrar(globalApp, "DELETE"),
-		"static": webStaticRegistrar(globalApp),
-		"listen": webListenRegistrar(globalApp),
+		"static": webStaticRegistrar(env, globalApp),
+		"listen": webListenRegistrar(env, globalApp),
 
 		// Response helpers
 		"json": r2core.BuiltinFunction(func(args ...interface{}) interface{} {
@@ -122,7 +122,7 @@ func RegisterWeb(env *r2core.Environment) {
 			if len(args) < 1 {
 				panic("web: status() requires (code)")
 			}
-			code, ok := args[0].(float64)
+			code, ok := asNumber(args[0])
 			if !ok {
 				panic(fmt.Sprintf("web: status() expected number for argument 1, got %T", args[0]))
 			}
@@ -173,7 +173,7 @@ func webRouteRegistrar(app *WebApp, method string) r2core.BuiltinFunction {
 	}
 }
 
-func webStaticRegistrar(app *WebApp) r2core.BuiltinFunction {
+func webStaticRegistrar(env *r2core.Environment, app *WebApp) r2core.BuiltinFunction {
 	return func(args ...interface{}) interface{} {
 		if len(args) < 2 {
 			panic("web: static() requires (path, dir)")
@@ -188,12 +188,12 @@ func webStaticRegistrar(app *WebApp) r2core.BuiltinFunction {
 		}
 		app.mu.Lock()
 		defer app.mu.Unlock()
-		app.static[path] = dir
+		app.static[path] = resolvePath(env, "web.static", dir, false)
 		return nil
 	}
 }
 
-func webListenRegistrar(app *WebApp) r2core.BuiltinFunction {
+func webListenRegistrar(env *r2core.Environment, app *WebApp) r2core.BuiltinFunction {
 	return func(args ...interface{}) interface{} {
 		if len(args) < 1 {
 			panic("web: listen() requires (port)")
@@ -202,7 +202,8 @@ func webListenRegistrar(app *WebApp) r2core.BuiltinFunction {
 		if !ok {
 			panic(fmt.Sprintf("web: listen() expected string for argument 1 (port), got %T", args[0]))
 		}
-		webListenForApp(app, port)
+		checkHostAllowed(env, "web.listen", port)
+		webListenForApp(env, app, port)
 		return nil
 	}
 }
@@ -235,7 +236,7 @@ func registerRouteForApp(app *WebApp, method, path string, handler interface{})
 	}
 }
 
-func webListenForApp(app *WebApp, port string) {
+func webListenForApp(env *r2core.Environment, app *WebApp, port string) {
 	mux := http.NewServeMux()
 
 	// Snapshot routes/static under the read lock so concurrently-registered
@@ -268,13 +269,13 @@ func webListenForApp(app *WebApp, port string) {
 	// "/users" and POST "/users").
 	mux.HandleFunc("/", createRouteDispatcher(app, routesCopy))
 
-	fmt.Printf("🚀 Web server listening on %s\n", port)
+	fmt.Fprintf(env.Stdout(), "🚀 Web server listening on %s\n", port)
 	srv := &http.Server{
 		Addr:              port,
 		Handler:           mux,
 		ReadHeaderTimeout: 15 * time.Second,
 	}
-	if err := srv.ListenAndServe(); err != nil {
+	if err := serveUntilDone(env, srv); err != nil {
 		panic(fmt.Sprintf("web: failed to start server: %v", err))
 	}
 }
@@ -356,7 +357,7 @@ func serveRoute(app *WebApp, handler *r2core.UserFunction, pathParams map[string
 			if len(args) < 1 {
 				panic("web: ctx.status() requires (code)")
 			}
-			code, ok := args[0].(float64)
+			code, ok := asNumber(args[0])
 			if !ok {
 				panic(fmt.Sprintf("web: ctx.status() expected number for argument 1, got %T", args[0]))
 			}
@@ -0,0 +1,516 @@
+// Code generated by gen_docs.go from docs/en/stdlib-reference.md; DO NOT EDIT.
+
+package r2lsp
+
+// builtinDocs maps module.member to its signature and description.
+var builtinDocs = map[string]builtinDoc{
+	"collections.chunk":             {"collections.chunk(arr: array, size: number) -> array of arrays", "Splits into `size`-length pieces (last chunk may be shorter). Panics if `size <= 0`."},
+	"collections.compact":           {"collections.compact(arr: array) -> array", "Removes elements that are `nil` or falsy per `toBool` (so `0`, `\"\"`, and `false` are all dropped too, not just `nil`)."},
+	"collections.contains":          {"collections.contains(arr: array | iterator, value: any) -> bool", "Uses the shallow `equals` helper — **cannot detect array/map elements structurally equal to `value`** (nested composite values never match). Stops at the first match on an iterator."},
+	"collections.copy":              {"collections.copy(arr: array) -> array", "Shallow copy (new backing array, same element references)."},
+	"collections.deepClone":         {"collections.deepClone(v: any) -> any", "Alias for the same recursive copy as `std.deepCopy` (identical implementation, cycle-safe)."},
+	"collections.deepEqual":         {"collections.deepEqual(a: any, b: any) -> bool", "**Recursive** structural equality for maps/arrays (falls back to shallow `equals` for scalars), with cycle detection so self-referential structures (e.g. `a[0] = a`) compare without infinite recursion. This is the function to use instead of `==`/`contains`/`indexOf` when comparing composite values."},
+	"collections.filter":            {"collections.filter(arr: array | iterator, fn: function) -> array | iterator", "Keeps elements where `toBool(fn(v))` is true. Same `UserFunction`-only restriction. Lazy on iterators, like `map`."},
+	"collections.find":              {"collections.find(arr: array | iterator, fn: function) -> any", "First element where `fn(v)` is truthy, else `nil`. On an iterator it stops at the first match and closes it."},
+	"collections.flatten":           {"collections.flatten(arr: array, [depth=1]) -> array", "Recursively flattens nested `[]interface{}` up to `depth` levels. Panics if `depth > 10000` (guards against runaway/self-referential recursion)."},
+	"collections.groupBy":           {"collections.groupBy(arr: array, fn: function) -> map", "Groups elements by `fmt.Sprintf(\"%v\", fn(v))` string key. Insertion order of first-seen keys is tracked internally, but the return type is `map[string]interface{}` whose own iteration order in R2Lang is not guaranteed."},
+	"collections.indexOf":           {"collections.indexOf(arr: array, value: any) -> number", "Same shallow-`equals` caveat as `contains`; `-1` if not found."},
+	"collections.iter":              {"collections.iter(v: array | iterator) -> iterator", "Wraps an array in an iterator (iterators are returned as they are), so it can be fed to `take`/`skip` or consumed with `next()`."},
+	"collections.map":               {"collections.map(arr: array | iterator, fn: function) -> array | iterator", "Applies `fn` to each element, returns a new array. `fn` **must be a `*r2core.UserFunction`** (an R2Lang `func(x){...}` literal) — passing a built-in module function (e.g. `std.toString`) panics the type assertion. Given an iterator (a generator, `io.FileStream`, another lazy result), returns a **lazy iterator** that calls `fn` only as elements are requested."},
+	"collections.partition":         {"collections.partition(arr: array, fn: function) -> [matched, rest]", "2-element array: elements where `fn(v)` is truthy, then the rest."},
+	"collections.range":             {"collections.range(start: number, end: number) -> array", "Builds `[start, end)`. **Panics** if `start > end` (contrast `std.range`, which silently returns `[]`)."},
+	"collections.reduce":            {"collections.reduce(arr: array | iterator, fn: function, initial: any) -> any", "Left fold: `acc = fn(acc, v)` for each `v`. Same restriction. Consumes an iterator to the end, so never pass an infinite one."},
+	"collections.repeat":            {"collections.repeat(count: number, value: any) -> array", "Returns an array of `count` copies of `value`. Confusingly, the numeric arg comes **first** here (`repeat(count, value)`), the opposite order from `string.repeat(str, count)`."},
+	"collections.skip":              {"collections.skip(v: array | iterator, n: number) -> iterator", "Lazy iterator that drops the first `n` elements."},
+	"collections.slice":             {"collections.slice(arr: array, start: number, end: number) -> array", "Go-style `arr[start:end]`; `end == len(arr)` is valid (includes the last element)."},
+	"collections.sort":              {"collections.sort(arr: array, [compareFn: function]) -> array", "In-place `sort.Sort` on (a copy of the slice header referencing) `arr`'s backing array, then returns it. Default comparator: numeric-first, else string comparison via `fmt.Sprintf(\"%v\", ...)`. Custom `compareFn(a, b)` may return a `bool` (true = \"a before b\") or a `number` (negative = \"a before b\"); any other return type panics."},
+	"collections.sortBy":            {"collections.sortBy(arr: array, fn: function) -> array", "Stable sort keyed by `fn(v)` (numeric-first, else string comparison of the key)."},
+	"collections.take":              {"collections.take(v: array | iterator, n: number) -> iterator", "Lazy iterator over the first `n` elements. The usual way to bound an infinite generator."},
+	"collections.toArray":           {"collections.toArray(v: array | iterator) -> array", "Consumes an iterator into an array (arrays are returned as they are)."},
+	"collections.unique":            {"collections.unique(arr: array) -> array", "O(n²) dedup via shallow `equals` — again, composite (array/map) elements are never considered duplicates of each other even if identical in content."},
+	"collections.zip":               {"collections.zip(arr1, arr2: array) -> array of [a,b] pairs", "Truncates to the shorter array's length."},
+	"console.assert":                {"console.assert(condition: any, ...msgArgs) -> nil", "If `condition` is falsy (`false`, `0`, `\"\"`, or any non-bool/number/string type treated as falsy) prints a red \"Assertion failed: ...\" line; does **not** throw/panic."},
+	"console.beep":                  {"console.beep() -> nil", "Prints the BEL character (`\\a`)."},
+	"console.bold":                  {"console.bold(text: string) -> nil", "ANSI bold, no newline."},
+	"console.clear":                 {"console.clear() -> nil", "ANSI clear-screen + cursor-home (`\\033[2J\\033[H`)."},
+	"console.clearLine":             {"console.clearLine() -> nil", "Clears the current terminal line and returns cursor to column 0."},
+	"console.color":                 {"console.color(colorName: string, text: string) -> nil", "Prints `text` wrapped in the ANSI code for `colorName` (`black/red/green/yellow/blue/magenta/cyan/white/reset`, case-insensitive), no trailing newline. Unknown color names print the text uncolored."},
+	"console.colorLine":             {"console.colorLine(colorName: string, text: string) -> nil", "Same as `color` but appends a newline."},
+	"console.confirm":               {"console.confirm(message?: string) -> bool", "Reads a line from stdin; `true` only if the trimmed, lowercased input is `\"y\"` or `\"yes\"`."},
+	"console.count":                 {"console.count(label?: string) -> nil", "Increments and prints a named counter (starts at 1)."},
+	"console.countReset":            {"console.countReset(label?: string) -> nil", "Resets a named counter to 0."},
+	"console.debug":                 {"console.debug(...args) -> nil", "Magenta `DEBUG:` prefix."},
+	"console.dir":                   {"console.dir(obj: any) -> nil", "Pretty-prints a map as `Object {...}` or array as `Array [...]`, one entry per line via `formatValue`; other types print as `%T: %v`."},
+	"console.error":                 {"console.error(...args) -> nil", "Red `ERROR:` prefix."},
+	"console.getChar":               {"console.getChar() -> string", "Reads a single rune from stdin (line-buffered by the terminal, so still waits for Enter in a normal TTY)."},
+	"console.group":                 {"console.group(label?: string) -> nil", "Prints `▼ <label>` (default `\"Group\"`), bold. Purely cosmetic — does not indent subsequent output."},
+	"console.groupEnd":              {"console.groupEnd() -> nil", "Prints a blank line."},
+	"console.hideCursor":            {"console.hideCursor() -> nil", "ANSI hide-cursor."},
+	"console.info":                  {"console.info(...args) -> nil", "Same as `log` but cyan `INFO:` prefix (ANSI color codes are always emitted, no TTY detection)."},
+	"console.italic":                {"console.italic(text: string) -> nil", "ANSI italic, no newline."},
+	"console.log":                   {"console.log(...args) -> nil", "Prints `[HH:MM:SS] <args joined by space>\\n`. Args are formatted via `formatValue` (see below)."},
+	"console.moveCursor":            {"console.moveCursor(row: number, col: number) -> nil", "ANSI cursor-position escape sequence. Silently no-ops if either arg isn't a number."},
+	"console.pause":                 {"console.pause(message?: string) -> nil", "Prints a message (default \"Press Enter to continue...\") and blocks until a line is read."},
+	"console.print":                 {"console.print(...args) -> nil", "Prints args space-joined, no trailing newline, no timestamp."},
+	"console.printf":                {"console.printf(format: string, ...args) -> nil", "Go `fmt.Printf(format, args...)` — uses **Go format verbs** (`%s`, `%d`, `%v`, etc.), not `printf`-in-JS/C semantics beyond that overlap. Silently no-ops if `format` isn't a string."},
+	"console.println":               {"console.println(...args) -> nil", "Same as `print` but with a trailing newline."},
+	"console.progressBar":           {"console.progressBar(progress: number, width?: number) -> nil", "Prints a `\\r[====    ] NN.N%` bar in place (default width 40). `progress` is a 0–1 fraction; values `>1` are clamped to full width but *not* clamped for the printed percentage text (e.g. `progress=1.5` prints `150.0%` with a full bar)."},
+	"console.prompt":                {"console.prompt(message?: string) -> string", "Prints `message` then reads one line from stdin (trimmed); returns `\"\"` on read error."},
+	"console.read":                  {"console.read(prompt?: string) -> string", "Like `prompt` but only prints the prompt text if non-empty (no default message printed)."},
+	"console.readLine":              {"console.readLine() -> string", "Reads one line from stdin, no prompt."},
+	"console.readPassword":          {"console.readPassword(prompt?: string) -> string", "**Does not actually hide input** — reads a plain line from stdin like `prompt`; the comment in the source acknowledges this is a placeholder, not real terminal echo-suppression."},
+	"console.setTitle":              {"console.setTitle(title: string) -> nil", "Emits the ANSI/xterm OSC sequence to set the terminal window title."},
+	"console.showCursor":            {"console.showCursor() -> nil", "ANSI show-cursor."},
+	"console.spinner":               {"console.spinner(step?: number) -> nil", "Prints one of `| / - \\` at the cursor position based on `step` (default 0), wrapped modulo 4 (handles negative `step`). Caller is responsible for calling this repeatedly with an incrementing step to animate it."},
+	"console.table":                 {"console.table(data: array | map | any) -> nil", "Renders a box-drawing ASCII table: array → `(index)"},
+	"console.time":                  {"console.time(label?: string) -> nil", "Starts a named timer (default label `\"default\"`), stored in shared global state."},
+	"console.timeEnd":               {"console.timeEnd(label?: string) -> nil", "Prints elapsed duration since `console.time(label)` and removes the timer; prints \"not found\" message if never started."},
+	"console.trace":                 {"console.trace(...args) -> nil", "Prints a magenta `\"Trace:<args>\"` line — **not** an actual stack trace (no call-stack info is captured)."},
+	"console.underline":             {"console.underline(text: string) -> nil", "ANSI underline, no newline."},
+	"console.warn":                  {"console.warn(...args) -> nil", "Yellow `WARN:` prefix."},
+	"csv.aggregate":                 {"csv.aggregate(data: array, columnName: string, operation: string) -> number|nil", "Computes `\"sum\"`, `\"avg\"`/`\"average\"`, `\"min\"`, `\"max\"`, or `\"count\"` over the numeric values of `columnName` (numbers or numeric strings). Returns `nil` if there are no numeric values. Panics for an unrecognized `operation` string."},
+	"csv.filter":                    {"csv.filter(data: array, fn: function) -> array", "Keeps rows for which `fn(row)` is truthy. `fn` must be an R2Lang user function (`*r2core.UserFunction`), not a native builtin."},
+	"csv.getColumn":                 {"csv.getColumn(data: array, columnName: string) -> array", "Returns the value of `columnName` from each row (map rows only); `nil` for rows missing that key."},
+	"csv.getHeaders":                {"csv.getHeaders(data: array) -> array", "Returns the sorted keys of `data[0]` if it's a map (i.e., \"array of objects\" shape); returns `[]` otherwise (including for \"array of arrays\" input)."},
+	"csv.groupBy":                   {"csv.groupBy(data: array, columnName: string) -> map", "Groups map-rows into a `map[string]array` keyed by `fmt.Sprintf(\"%v\", value)` of `columnName`."},
+	"csv.map":                       {"csv.map(data: array, fn: function) -> array", "Returns `fn(row)` applied to each row, collected into a new array."},
+	"csv.parse":                     {"csv.parse(csvString: string, delimiter?: string, hasHeader?: bool) -> array", "Parses CSV text. Strips a leading UTF-8 BOM first. `delimiter` defaults to `\",\"` (only its first rune is used). `hasHeader` defaults to `true`: if true (and there's more than one record), returns an array of `map[string]interface{}` keyed by header row; otherwise returns an array of arrays (skipping the header row only if `hasHeader` was true but there was just 1 record... see gotcha below). Each cell is type-converted (see `convertCSVValue` below). Panics on malformed CSV."},
+	"csv.readFile":                  {"csv.readFile(filePath: string, delimiter?: string, hasHeader?: bool) -> array", "Same parsing behavior as `csv.parse`, reading from a file on disk. Panics if the file can't be opened or the CSV is malformed."},
+	"csv.sort":                      {"csv.sort(data: array, columnName: string, ascending?: bool) -> array", "Returns a **new** sorted array (bubble sort) comparing `columnName` numerically if both values are numbers, else as strings. `ascending` defaults to `true`. Rows that aren't maps, or missing the column, are left in place (skipped during comparison)."},
+	"csv.stringify":                 {"csv.stringify(data: array, delimiter?: string, includeHeaders?: bool) -> string", "Serializes an array of objects (headers = sorted map keys, union not computed — uses only the **first row's** keys) or an array of arrays into CSV text. Returns `\"\"` for empty input."},
+	"csv.validate":                  {"csv.validate(csvString: string, delimiter?: string) -> map", "Returns `{valid: bool, errors: array}`, where each error is `{line, error}` for parse errors and `{line, error:\"Column count mismatch\", expected, actual}` for rows whose column count doesn't match the first row."},
+	"csv.writeFile":                 {"csv.writeFile(filePath: string, data: array, delimiter?: string, includeHeaders?: bool) -> nil", "Same serialization behavior as `csv.stringify`, writing to (creating/truncating) a file. Panics on file or CSV write errors."},
+	"date.Date":                     {"date.Date() -> DateMethods` <br> `date.Date(isoOrYYYYMMDD: string) -> Date | nil` <br> `date.Date(unixMillis: number) -> Date` <br> `date.Date(year, month0based, day?, hour?, minute?, second?) -> Date", "Overloaded constructor/namespace accessor (see gotcha below): **zero args** returns the methods-namespace map documented below (not a date!); **one string arg** parses RFC3339 first, falling back to `\"2006-01-02\"` (returns `nil`, not a panic, if neither parse succeeds); **one number arg** treats it as Unix milliseconds; **2+ numeric args** build a date from year/month(0-based, JS-style)/day/hour/minute/second (all local time, missing fields default to `January 1st, 00:00:00`)."},
+	"date.format":                   {"date.format(date: Date, pattern: string) -> string | nil", "Formats `date` using a custom token syntax converted to Go's reference-time format internally (see token table below). Returns `nil` (not panic) if either argument has the wrong type."},
+	"db.dbBegin":                    {"db.dbBegin(connId: string) -> txId: string", "**Caveat:** opens a transaction via `conn.db.Begin()` but immediately rolls it back before returning (see Notes) — the returned `\"tx_<connId>_<n>\"` string is effectively a placeholder ID, not a usable, open transaction handle. Panics if connection unknown or begin/rollback fails."},
+	"db.dbClose":                    {"db.dbClose(connId: string) -> true", "Closes the underlying `*sql.DB` and removes it from the connection map. Panics if the connection ID is unknown or `Close()` errors."},
+	"db.dbConnect":                  {"db.dbConnect(driver: string, dsn: string) -> connId: string", "Opens a connection. `driver` must be one of `\"sqlite3\"`, `\"postgres\"`, `\"mysql\"` (panics otherwise). Calls `sql.Open` then `db.Ping()` to verify connectivity — panics (and closes the handle) if either fails. Returns a connection ID like `\"conn_1\"`, `\"conn_2\"`, ... from a monotonically increasing counter (not `len(map)`, so IDs never collide with a previously closed connection)."},
+	"db.dbEscape":                   {"db.dbEscape(value) -> string", "Naive SQL string escaping: converts `value` to a string and doubles every single quote (`'` → `''`). Does **not** guard against all injection vectors — prefer parameterized queries (`?`/`args...` in `dbQuery`/`dbExec`) instead."},
+	"db.dbExec":                     {"db.dbExec(connId: string, query: string, ...args) -> number", "Runs an `INSERT`/`UPDATE`/`DELETE`-style statement, returns `RowsAffected()` as a float64. Panics on unknown connection or SQL error."},
+	"db.dbGetConnections":           {"db.dbGetConnections() -> array<string>", "Returns all currently open connection IDs, excluding any `\"tx_\"`-prefixed placeholder IDs from `dbBegin`."},
+	"db.dbLastInsertId":             {"db.dbLastInsertId(connId: string, query: string, ...args) -> number", "Executes an insert statement via `Exec` and returns `LastInsertId()` as a float64. Panics on unknown connection, SQL error, or if the driver doesn't support last-insert-id."},
+	"db.dbPing":                     {"db.dbPing(connId: string) -> bool", "Pings the connection; returns `true`/`false` for success/failure (does not panic on ping failure, only on unknown connection ID)."},
+	"db.dbQuery":                    {"db.dbQuery(connId: string, query: string, ...args) -> array<map>", "Runs a `SELECT`-style query with positional args, returns each row as a `map[string]interface{}` keyed by column name, collected into an array. Panics if the connection ID is unknown or on any SQL/scan error."},
+	"encoding.base64Decode":         {"encoding.base64Decode(str: string) -> string", "Decodes standard base64. Panics on invalid input."},
+	"encoding.base64Encode":         {"encoding.base64Encode(str: string) -> string", "Standard base64 (`encoding/base64` `StdEncoding`, with padding)."},
+	"encoding.base64UrlDecode":      {"encoding.base64UrlDecode(str: string) -> string", "Decodes URL-safe base64 (`URLEncoding`). Panics on invalid input."},
+	"encoding.base64UrlEncode":      {"encoding.base64UrlEncode(str: string) -> string", "URL-safe base64 (`URLEncoding`, `-`/`_` alphabet, **with padding**, i.e. not \"raw\")."},
+	"encoding.hexDecode":            {"encoding.hexDecode(str: string) -> string", "Decodes a hex string back to bytes/string. Panics on invalid hex."},
+	"encoding.hexEncode":            {"encoding.hexEncode(str: string) -> string", "Lowercase hex encoding of the string's bytes."},
+	"encoding.urlDecode":            {"encoding.urlDecode(str: string) -> string", "`net/url.QueryUnescape`. Panics on malformed percent-encoding."},
+	"encoding.urlEncode":            {"encoding.urlEncode(str: string) -> string", "`net/url.QueryEscape` (space becomes `+`, form-encoding style, not path escaping)."},
+	"encoding.urlParse":             {"encoding.urlParse(urlString: string) -> map", "Parses a URL and returns `{scheme, host, path, query}` where `query` is a map of **first value only** per query-string key (repeated keys lose all but the first). Panics on unparseable URLs."},
+	"flags.parse":                   {"flags.parse(spec: map, [args: array]) -> map", "Parses `args`, or `argv` if omitted. Accepts `-name value`, `--name value`, `--name=value` and the one-letter `short` form. Bools accept a bare `--name` or `--name=false`. `list` options collect every occurrence. `--` ends the options. A negative number such as `-5` is positional. The result has one key per option (its default when not given), `_` with the positional arguments and `help` (true for `-h`/`--help` unless the spec declares `help`)."},
+	"flags.usage":                   {"flags.usage(spec: map, [header: string]) -> string", "Help text with one line per option, sorted by name: the short form, the type, `usage` and the non-zero default."},
+	"goroutine.acquire":             {"goroutine.acquire(sem: *Semaphore) -> nil", "Acquires (blocking) one permit from the semaphore — sends on the internal channel. Blocks if no permits are free. Panics if arg count != 1 or arg is not a `*Semaphore`."},
+	"goroutine.broadcast":           {"goroutine.broadcast(mon: *Monitor) -> nil", "Wakes all goroutines blocked in `wait()` on this monitor (`Cond.Broadcast()`)."},
+	"goroutine.lock":                {"goroutine.lock(mon: *Monitor) -> nil", "Locks the monitor's internal mutex. Panics if arg count != 1 or not a `*Monitor`."},
+	"goroutine.monitor":             {"goroutine.monitor() -> *Monitor", "Creates a monitor object wrapping a `sync.Mutex` + `sync.Cond`. Panics if called with any arguments."},
+	"goroutine.release":             {"goroutine.release(sem: *Semaphore) -> nil", "Releases one permit — receives from the internal channel. **Blocks** (rather than erroring) if the channel is empty, since `Release()` does `<-s.ch`; releasing more times than acquired will hang, not panic. Panics only on wrong arg shape."},
+	"goroutine.semaphore":           {"goroutine.semaphore(permits: number) -> *Semaphore", "Creates a counting semaphore backed by a buffered channel of capacity `permits`. Panics if not exactly 1 arg, if the arg isn't a number, or if `permits < 1`."},
+	"goroutine.signal":              {"goroutine.signal(mon: *Monitor) -> nil", "Wakes one goroutine blocked in `wait()` on this monitor (`Cond.Signal()`). Panics only on wrong arg shape; does not require the monitor to be locked."},
+	"goroutine.unlock":              {"goroutine.unlock(mon: *Monitor) -> nil", "Unlocks the monitor. Internally tracks lock ownership via an atomic flag so that unlocking an already-unlocked monitor raises a normal, catchable R2Lang panic (`\"unlock: monitor is not locked; call lock() before unlock()\"`) instead of crashing the whole process — see Notes."},
+	"goroutine.wait":                {"goroutine.wait(mon: *Monitor) -> nil", "Calls `Cond.Wait()`: releases the monitor's lock and blocks until `signal`/`broadcast` is called, then re-acquires the lock before returning. **Must be called while the monitor is locked**, otherwise panics with `\"wait: monitor must be locked before calling wait(); call lock() first\"`."},
+	"goroutine.waitAll":             {"goroutine.waitAll(monOrSem) -> nil", "Overloaded: if given a `*Monitor`, calls `Broadcast()` (despite the name, this does **not** wait — it wakes waiters). If given a `*Semaphore`, calls blocking `Acquire()` on it. Panics if the argument is neither a monitor nor a semaphore."},
+	"graph.new":                     {"graph.new() -> GraphObject", "Creates a new empty directed graph. All other operations are methods on the returned object."},
+	"grpc.grpcClient":               {"grpc.grpcClient(protoFile: string, serverAddr: string, metadata?: map) -> map", "Parses `protoFile` with `protoparse.Parser` (import path = the proto's own directory), builds a service/method table from all `service`/`rpc` declarations, and returns a **client object**. The actual network connection is deferred (lazy `grpc.Dial` on first call). `metadata` seeds default outgoing gRPC metadata (merged over `user-agent: R2Lang-gRPC-Client/1.0`, `accept: application/grpc`). Panics with a decorated error (connection refused / DNS / timeout / proto-parse hints) on failure."},
+	"hack.aesDecrypt":               {"hack.aesDecrypt(key: string, hexCipher: string) -> string", "Reverses `aesEncrypt`: hex-decodes, splits off the leading `aes.BlockSize` bytes as IV, decrypts the rest with `cipher.NewCFBDecrypter`. Returns descriptive error strings (not panics) for decode errors, too-short data, or bad key."},
+	"hack.aesEncrypt":               {"hack.aesEncrypt(key: string, plaintext: string) -> string", "AES-CFB encryption: generates a random IV (`crypto/rand`), encrypts with `cipher.NewCFBEncrypter`, and returns `hex(IV"},
+	"hack.base64Decode":             {"hack.base64Decode(str: string) -> string", "Standard base64 decode. **Does not panic** on invalid input — returns the string `\"base64Decode: error => <err>\"` instead (inconsistent with the rest of the module, which mostly panics on bad args)."},
+	"hack.base64Encode":             {"hack.base64Encode(str: string) -> string", "Standard base64 encode (duplicate of `encoding.base64Encode`)."},
+	"hack.dnsLookup":                {"hack.dnsLookup(host: string) -> array", "`net.LookupIP` — returns an array of IP address strings. Returns an error string (not panic, not array) on failure."},
+	"hack.dnsLookupAddr":            {"hack.dnsLookupAddr(ip: string) -> array", "Reverse DNS (`net.LookupAddr`) — returns an array of hostnames. Returns an error string on failure."},
+	"hack.hashMD5":                  {"hack.hashMD5(str: string) -> string", "MD5 digest, returned as lowercase hex."},
+	"hack.hashSHA1":                 {"hack.hashSHA1(str: string) -> string", "SHA-1 digest, lowercase hex."},
+	"hack.hashSHA256":               {"hack.hashSHA256(str: string) -> string", "SHA-256 digest, lowercase hex."},
+	"hack.hexdump":                  {"hack.hexdump(str: string) -> string", "Produces a classic hex+ASCII dump (16 bytes/line, offset prefix, non-printable bytes shown as `.`) of the string's bytes."},
+	"hack.hmacSHA256":               {"hack.hmacSHA256(key: string, message: string) -> string", "HMAC-SHA256 of `message` keyed by `key`, lowercase hex."},
+	"hack.portScan":                 {"hack.portScan(host: string, startPort: number, endPort: number) -> array", "Sequentially TCP-dials `host:port` for every port in `[startPort, endPort]` with a 300ms timeout each; returns the array of ports (numbers) that accepted a connection. Panics if `startPort < 1`, `endPort > 65535`, or `endPort < startPort`. **Purely sequential** — scanning a wide range is slow (300ms × range size in the worst case)."},
+	"hack.quickRSA":                 {"hack.quickRSA(bitSize?: number) -> array", "Generates an RSA keypair (default 2048 bits) and returns `[pubStr, privStr]` as human-readable toy strings: `\"RSA-PUB (N=<big int>, E=<int>)\"` and `\"RSA-PRIV (N=..., E=..., D=<big int>)\"`. Returns an error string on keygen failure (not a panic)."},
+	"hack.rsaDecrypt":               {"hack.rsaDecrypt(privStr: string, hexCipher: string) -> string", "Parses the `RSA-PRIV(...)` string, RSA-OAEP (SHA-256) decrypts a hex ciphertext. The reconstructed `rsa.PrivateKey` only has `N`, `E`, `D` (no `Primes`/precomputed CRT values), which Go's RSA implementation can still decrypt with, just without the CRT speed optimization. Returns an error string on failure."},
+	"hack.rsaEncrypt":               {"hack.rsaEncrypt(pubStr: string, plaintext: string) -> string", "Parses the `RSA-PUB(...)` string produced by `quickRSA`, RSA-OAEP (SHA-256) encrypts, returns hex ciphertext. Returns an error string if the pub-key string can't be parsed or encryption fails."},
+	"hack.simplePing":               {"hack.simplePing(host: string) -> bool", "\"Ping\" implemented as a TCP dial to `host:80` with a 1s timeout; returns `true`/`false` for connect success — this is **not ICMP ping**, so a host with port 80 closed/filtered (but otherwise reachable) reports `false`."},
+	"hack.whois":                    {"hack.whois(domain: string) -> string", "Opens a raw TCP connection to `whois.verisign-grs.com:43`, sends the domain, and returns whatever text comes back (no timeout set on this connection). Returns an error string (not a panic) if the connection fails."},
+	"http.HttpResponse":             {"http.HttpResponse(status?: number, header?: map|string, body?: string) -> map", "Builds a `{status, header, body}` response map for a handler to return. Overloaded: `(status, headerMap, body)`, `(status, contentTypeString, body)` (2-arg + implicit type), or `(status, bodyStringOnly)` (auto-detects `Content-Type` via `DetectContentType`). Default status is `200` if the first arg isn't a number."},
+	"http.JSON":                     {"http.JSON(value: object | map) -> string", "`json.Marshal` after stripping behavior fields (same cleanup as `XML`). Panics on marshal failure (e.g. unsupported types/cycles)."},
+	"http.XML":                      {"http.XML(rootElementName: string, value: object | map) -> string", "Serializes an object/map to XML with `rootElementName` as the root tag (indented 4 spaces). Internal-only fields (`self`, `this`, function-valued entries) are stripped first via `removeBehavior`. Nested objects/maps become nested elements; arrays become repeated sibling elements."},
+	"http.handler":                  {"http.handler(method: string, pattern: string, fn: function(pathVars: map, method: string, body: string) -> any) -> nil", "Registers a route in a **global, package-level** `r2Routes` slice (shared across every `Environment`/script in the process — not per-`http` instance). `pattern` uses `:name` segments for variables (see `matchPattern`); segment counts must match exactly (no wildcards, no trailing-slash flexibility). `method` is upper-cased for comparison."},
+	"http.serve":                    {"http.serve(addr: string) -> never (blocks)", "Starts a blocking `net/http` server on `addr` with a single catch-all handler that dispatches to the first matching registered route (first-match-wins, in registration order). POST/PUT bodies are capped at 64MB (`maxRequestBodyBytes`); oversized bodies get an immediate `413`. No match returns `404`. `ReadHeaderTimeout` is 15s (Slowloris mitigation) but there's no timeout on reading/writing the body itself. Panics (crashing the process) if `ListenAndServe` fails, e.g. port already in use."},
+	"http.vars":                     {"http.vars(varsMap: map, key: string) -> any | nil", "Simple map lookup helper for the `pathVars` map passed into handlers; `nil` if missing."},
+	"httpclient.clientHttpGet":      {"httpclient.clientHttpGet(url: string) -> string", "`GET` request, returns the raw response body as a string. Uses a shared client with a 30s timeout (`httpClientDefault`). Response bodies over 64MB (`maxHTTPClientResponseBytes`) cause a panic instead of silent truncation."},
+	"httpclient.clientHttpGetJSON":  {"httpclient.clientHttpGetJSON(url: string) -> any", "`GET` + parse the response body as JSON directly."},
+	"httpclient.clientHttpPost":     {"httpclient.clientHttpPost(url: string, bodyString: string) -> string", "`POST` with `Content-Type: text/plain`, returns raw body string."},
+	"httpclient.clientHttpPostJSON": {"httpclient.clientHttpPostJSON(url: string, value: any) -> any", "Serializes `value` to JSON, `POST`s with `Content-Type: application/json`, parses the JSON response and returns it."},
+	"httpclient.parseJSON":          {"httpclient.parseJSON(jsonString: string) -> any", "`json.Unmarshal` into a generic value (map/array/string/number/bool/nil as appropriate). Panics on invalid JSON."},
+	"httpclient.parseXML":           {"httpclient.parseXML(xmlString: string) -> map", "Parses XML into a generic nested-map representation: each node becomes `{_name, _content?, _attrs?, <childTagName>: <child or array of children>}`. Repeated child tags become an array under that tag's key."},
+	"httpclient.stringifyJSON":      {"httpclient.stringifyJSON(value: any) -> string", "`json.Marshal`. Panics on unmarshalable values."},
+	"httpclient.stringifyXML":       {"httpclient.stringifyXML(value: map) -> string", "Inverse of `parseXML`'s shape: expects a **single-root-key** map (`{rootTag: {...}}`), recursively rebuilding XML nodes (`_content`/`_attrs` special keys, everything else becomes child elements; arrays become repeated elements). Detects and panics on circular references (self-referential maps/arrays) instead of infinite-looping/crashing the process. Panics if the top-level map doesn't have exactly one key."},
+	"io.FileStream":                 {"io.FileStream(path: string) -> FileStream", "Creates a lazy line-stream object over `path` (see FileStream methods below). File is not opened until a terminal op (`toArray`/`saveTo`) runs."},
+	"io.Path":                       {"io.Path(path: string) -> Path", "Wraps a path string in a `Path` object exposing the fluent methods documented below."},
+	"io.absPath":                    {"io.absPath(path: string) -> string", "Absolute form of `path`, resolved against the current working directory."},
+	"io.appendFile":                 {"io.appendFile(path: string, contents: string) -> nil", "Opens with `O_APPEND|O_CREATE|O_WRONLY` (mode `0644`) and appends `contents`."},
+	"io.backup":                     {"io.backup(path: string) -> string", "Copies `path` to `<base>_backup_<YYYYMMDD_HHMMSS><ext>` next to it and returns the new path."},
+	"io.baseName":                   {"io.baseName(path: string) -> string", "`filepath.Base`."},
+	"io.batchCopy":                  {"io.batchCopy(srcPattern: string, destDir: string) -> array<map>", "Globs `srcPattern`, copies each match into `destDir` (flat, using the base filename), and returns one status map per file: `{src, dest, status: \"success\""},
+	"io.changeDir":                  {"io.changeDir(path: string) -> nil", "`os.Chdir` — changes the process-wide CWD (affects the whole interpreter process, not just `io` calls)."},
+	"io.checksum":                   {"io.checksum(path: string, algorithm?: \"md5\"|\"sha1\"|\"sha256\") -> string", "Hex digest of the file contents. Default algorithm `\"sha256\"`; panics on an unsupported algorithm name."},
+	"io.chmod":                      {"io.chmod(path: string, mode: number) -> nil", "`os.Chmod(path, FileMode(mode))`."},
+	"io.compareFiles":               {"io.compareFiles(path1: string, path2: string) -> bool", "Byte-for-byte equality after loading both files fully into memory."},
+	"io.copyFile":                   {"io.copyFile(srcPath: string, destPath: string) -> nil", "Streams `srcPath` into `destPath` (creates/truncates destination)."},
+	"io.createPath":                 {"io.createPath(path: string) -> nil", "`mkdirAll` on `filepath.Dir(path)` (i.e. ensures the parent directory of `path` exists, mode `0755`) — does **not** create `path` itself as a file."},
+	"io.dirName":                    {"io.dirName(path: string) -> string", "`filepath.Dir`."},
+	"io.exists":                     {"io.exists(path: string) -> bool", "`true` unless `os.Stat` reports \"not exist\" (other stat errors — e.g. permission — are treated as \"exists\")."},
+	"io.extName":                    {"io.extName(path: string) -> string", "`filepath.Ext` (includes the leading dot, e.g. `.txt`)."},
+	"io.fileModTime":                {"io.fileModTime(path: string) -> Date", "Returns an `r2core.DateValue` (native date object), usable with `date.*` functions/comparison operators."},
+	"io.fileMode":                   {"io.fileMode(path: string) -> number", "Raw `os.FileMode` bits as a number (includes type bits, not just permission bits)."},
+	"io.fileSize":                   {"io.fileSize(path: string) -> number", "Size in bytes. Panics if the path doesn't exist."},
+	"io.findFiles":                  {"io.findFiles(root: string, pattern: string) -> array<string>", "Walks `root` recursively and returns full paths of **files** (not dirs) whose base name matches `pattern` via `filepath.Match`."},
+	"io.getMetadata":                {"io.getMetadata(path: string) -> map", "`{name, size, mode, modTime, isDir, abs, ext, dir, base}` — a superset of `listDirDetailed`'s per-entry fields plus path-derived fields."},
+	"io.glob":                       {"io.glob(pattern: string) -> array<string>", "`filepath.Glob` shell-style matching (no `**`; single `*` doesn't cross `/`)."},
+	"io.isDir":                      {"io.isDir(path: string) -> bool", "`false` if the path doesn't exist; panics on other stat errors."},
+	"io.isFile":                     {"io.isFile(path: string) -> bool", "`false` if the path doesn't exist; panics on other stat errors."},
+	"io.joinPath":                   {"io.joinPath(...parts: string) -> string", "`filepath.Join` over all arguments (all must be strings)."},
+	"io.listDir":                    {"io.listDir(path: string) -> array<string>", "Returns entry names (not full paths) of a directory."},
+	"io.listDirDetailed":            {"io.listDirDetailed(path: string) -> array<map>", "Each entry: `{name, size, isDir, mode, modTime}` (`modTime` is an RFC3339 string, `mode`/`size` are numbers)."},
+	"io.mkdir":                      {"io.mkdir(path: string, perm?: number) -> nil", "Creates a single directory level (`os.Mkdir`, default perm `0755`); fails if the parent doesn't exist."},
+	"io.mkdirAll":                   {"io.mkdirAll(path: string, perm?: number) -> nil", "Creates all necessary parent directories (`os.MkdirAll`, default perm `0755`)."},
+	"io.moveFile":                   {"io.moveFile(srcPath: string, destPath: string) -> nil", "`os.Rename` — same-filesystem move/rename; fails across filesystems/devices like Go's `os.Rename`."},
+	"io.readFile":                   {"io.readFile(path: string) -> string", "Reads the whole file as a string. Panics if the file can't be read."},
+	"io.readFileBytes":              {"io.readFileBytes(path: string) -> array<number>", "Reads the whole file and returns an array of byte values (0-255, each as float64)."},
+	"io.readLines":                  {"io.readLines(path: string) -> array<string>", "Reads the file and returns an array of lines (split via `bufio.Scanner`, default line-oriented split, no trailing newline)."},
+	"io.readStream":                 {"io.readStream(path: string, batchSize?: number) -> array<string>", "Reads the file in `batchSize`-byte chunks (default 1024) and returns each chunk as a string element; **not** line-oriented, and a multi-byte UTF-8 sequence can be split across chunk boundaries."},
+	"io.renameFile":                 {"io.renameFile(oldPath: string, newPath: string) -> nil", "Same as `moveFile`, `os.Rename` under the hood."},
+	"io.rmDir":                      {"io.rmDir(path: string) -> nil", "Recursively removes a directory tree (`os.RemoveAll`)."},
+	"io.rmFile":                     {"io.rmFile(path: string) -> nil", "Removes a single file (`os.Remove`; fails on non-empty directories)."},
+	"io.sortFiles":                  {"io.sortFiles(files: array<string>, sortBy?: \"name\"|\"size\"|\"time\") -> array<string>", "Sorts a list of *existing* file paths in place-ish (returns new array). Default `\"name\"` is a lexical string sort; `\"size\"`/`\"time\"` `os.Stat` each file (falls back to name sort if `Stat` fails on either operand)."},
+	"io.tempDir":                    {"io.tempDir() -> string", "`os.TempDir()`."},
+	"io.tempFile":                   {"io.tempFile(dir?: string, pattern?: string) -> string", "Creates (and immediately closes) a temp file via `os.CreateTemp`; returns its path. Default `dir=\"\"` (system temp dir), `pattern=\"temp*\"`. The file is **not** deleted automatically."},
+	"io.walk":                       {"io.walk(root: string) -> array<map>", "Recursively walks `root` (`filepath.Walk`); each entry is `{path, name, size, isDir, mode, modTime}`, including `root` itself. Panics on any walk error."},
+	"io.watchFile":                  {"io.watchFile(path: string) -> map", "**Not a real watcher** — takes a single `os.Stat` snapshot and returns `{path, size, modTime, mode}`. To detect changes, a script must poll this repeatedly and diff `modTime`/`size` itself."},
+	"io.workingDir":                 {"io.workingDir() -> string", "`os.Getwd()`."},
+	"io.writeFile":                  {"io.writeFile(path: string, contents: string, perm?: number) -> nil", "Overwrites/creates the file with `contents`. `perm` defaults to `0644` (octal file mode, pass as a number e.g. `0644`... but R2 numeric literals are decimal, so pass the decimal equivalent, e.g. `420`)."},
+	"io.writeFileBytes":             {"io.writeFileBytes(path: string, bytes: array<number>, perm?: number) -> nil", "Writes an array of byte values (each truncated to a `byte`) to `path`. Panics if the array contains non-numbers."},
+	"io.writeLines":                 {"io.writeLines(path: string, lines: array, perm?: number) -> nil", "Joins `lines` with `\\n` (non-string elements are stringified via `%v`) and writes them; no trailing newline is appended after the last line."},
+	"io.writeStream":                {"io.writeStream(path: string, chunks: array<string>) -> nil", "Creates/truncates `path` and writes each string chunk in order (concatenation). Panics if any chunk isn't a string."},
+	"json.deepMerge":                {"json.deepMerge(obj1: string, obj2: string, ...) -> string", "Like `merge` but recursively merges nested objects instead of overwriting them wholesale. Arrays are still replaced, not merged."},
+	"json.deleteKey":                {"json.deleteKey(objText: string, key: string) -> string", "Parses the JSON object, deletes `key` (no-op if missing), and returns the re-serialized JSON string."},
+	"json.flatten":                  {"json.flatten(objText: string, separator?: string) -> string", "Flattens nested objects/arrays into a single-level object with compound keys (default separator `\".\"`, e.g. `a.b.0`), returned as a JSON string."},
+	"json.getKeys":                  {"json.getKeys(objText: string) -> array", "Parses a JSON object string and returns its top-level keys as an array of strings. **Key order is unspecified** (Go map iteration order)."},
+	"json.getValue":                 {"json.getValue(objText: string, key: string) -> any|nil", "Parses a JSON object string and returns the value at `key`, or `nil` if absent."},
+	"json.hasKey":                   {"json.hasKey(objText: string, key: string) -> bool", "Returns whether `key` exists at the top level of the JSON object string."},
+	"json.merge":                    {"json.merge(obj1: string, obj2: string, ...) -> string", "Shallow-merges 2+ JSON object strings left-to-right (later keys overwrite earlier ones) and returns the merged JSON string."},
+	"json.minify":                   {"json.minify(text: string) -> string", "Re-serializes JSON with no extraneous whitespace."},
+	"json.parse":                    {"json.parse(text: string, options?: map) -> any", "Parses a JSON string into R2Lang native values (map/array/number/string/bool/nil). With `{exact: true}`, every integer becomes an int (or a bigint) and every number with a fraction becomes a decimal, keeping its digits (`12.50` stays `12.50`). Panics on invalid JSON or non-string arg."},
+	"json.parseArray":               {"json.parseArray(text: string) -> array", "Parses JSON that must be a top-level array. Panics if the JSON does not decode into a JSON array."},
+	"json.parseObject":              {"json.parseObject(text: string) -> map", "Parses JSON that must be a top-level object. Panics if the JSON does not decode into a JSON object."},
+	"json.pretty":                   {"json.pretty(text: string, indent?: string) -> string", "Re-serializes JSON with indentation (default two spaces)."},
+	"json.query":                    {"json.query(text: string, path: string) -> any", "Minimal JSONPath-like lookup. Supports `$` / `$.` prefix, dotted field access, and `field[index]` array indexing (e.g. `$.items[0].name`). Returns `nil` if any segment doesn't resolve."},
+	"json.setValue":                 {"json.setValue(objText: string, key: string, value: any) -> string", "Parses the JSON object, sets/overwrites `key`, and returns the **re-serialized JSON string** (not an R2Lang object)."},
+	"json.size":                     {"json.size(text: string) -> number", "Returns element count: length for maps/arrays/strings, `1` for scalars."},
+	"json.stringify":                {"json.stringify(value: any, replacer?: array, space?: number|string) -> string", "Serializes an R2Lang value to a JSON string. `replacer` array argument is accepted but **ignored** (parsed, never applied — placeholder). `space` as a number repeats that many spaces for indentation; as a string, uses it verbatim as indent. Panics on circular references or unsupported value types (e.g. functions)."},
+	"json.type":                     {"json.type(text: string) -> string", "Returns one of `\"object\"`, `\"array\"`, `\"number\"`, `\"string\"`, `\"boolean\"`, `\"null\"`, `\"unknown\"` describing the parsed JSON's top-level type."},
+	"json.unflatten":                {"json.unflatten(objText: string, separator?: string) -> string", "Inverse of `flatten`: expands dotted/compound keys back into nested objects. **Note:** it rebuilds only nested objects, not arrays — an unflattened numeric-looking segment (e.g. `items.0`) becomes an object key `\"0\"`, not an array index."},
+	"json.validate":                 {"json.validate(text: string) -> bool", "Returns `true`/`false` for whether the string is syntactically valid JSON (any type, not just objects)."},
+	"jwt.createPayload":             {"jwt.createPayload(data: map, expireInSeconds?: number, issuer?: string, subject?: string, audience?: string) -> map", "Builds a claims payload: copies `data`, then sets `iat`/`nbf` = now, `exp` = now + `expireInSeconds` (default `3600`), and optionally `iss`/`sub`/`aud` if those args are non-empty strings. Returns a plain R2Lang map (not yet signed)."},
+	"jwt.createRefreshToken":        {"jwt.createRefreshToken(userId: string, secret: string, expireInSeconds?: number) -> string", "Convenience for a long-lived token: payload `{sub: userId, type: \"refresh\", iat, nbf, exp}`, default expiry 30 days (`30*24*3600` seconds), always signed HS256."},
+	"jwt.decode":                    {"jwt.decode(token: string) -> map", "Decodes header and payload **without verifying the signature**. Returns `{header, payload, error}` (`error` is `nil` on success, a string describing what failed otherwise). Never panics."},
+	"jwt.getClaims":                 {"jwt.getClaims(token: string) -> map|nil", "Returns only the standard registered claims present in the payload: `iss, sub, aud, exp, nbf, iat, jti`. Custom claims are **not** included."},
+	"jwt.getExpiration":             {"jwt.getExpiration(token: string) -> number|nil", "Returns the raw `exp` claim (Unix seconds as float64), or `nil` if absent/undecodable."},
+	"jwt.getHeader":                 {"jwt.getHeader(token: string) -> map|nil", "Returns the decoded JWT header (`{alg, typ}`)."},
+	"jwt.isExpired":                 {"jwt.isExpired(token: string) -> bool", "Decodes (no signature check) and returns `true` if `exp` is in the past, or if the token can't be decoded at all, or has no numeric `payload`. Returns `false` only if `exp` exists, decodes, and is still in the future; also `false` if there's no `exp` claim at all."},
+	"jwt.refresh":                   {"jwt.refresh(token: string, secret: string) -> map", "Verifies `token` (including expiration check) via the same logic as `jwt.verify`; if invalid, returns `{success:false, error, token:nil}`. If valid, strips `iat`/`nbf`/`exp` from the payload and re-signs a brand-new HS256 token with fresh timestamps (no explicit new `exp` — see gotcha). Returns `{success:true, error:nil, token:string}`."},
+	"jwt.sign":                      {"jwt.sign(payload: map, secret: string, algorithm?: string) -> string", "Builds a JWT: header `{alg, typ:\"JWT\"}`, adds `iat`/`nbf` (Unix seconds) to the payload if not already present, base64url-encodes (`RawURLEncoding`, unpadded) header and payload, then HMAC-SHA256-signs `header.payload` with `secret` (also `RawURLEncoding`). `algorithm` defaults to `\"HS256\"`; any other value panics."},
+	"jwt.verify":                    {"jwt.verify(token: string, secret: string) -> map", "Splits the token into 3 parts, decodes header/payload, requires an `alg` field (panics->returns error map if absent), recomputes the HMAC-SHA256 signature and compares with `hmac.Equal` (constant-time), then checks `exp` (must not be in the past) and `nbf` (must not be in the future). Returns `{valid: bool, error: string|nil, payload: map|nil}`. Never panics — always returns a result map, even for malformed tokens."},
+	"math.abs":                      {"math.abs(x: number) -> number", "`math.Abs`."},
+	"math.acos":                     {"math.acos(x: number) -> number", "Inverse trig; no domain-error panic — out-of-domain input yields `NaN` per Go's `math` package."},
+	"math.asin":                     {"math.asin(x: number) -> number", "Inverse trig; no domain-error panic — out-of-domain input yields `NaN` per Go's `math` package."},
+	"math.atan":                     {"math.atan(x: number) -> number", "Inverse trig; no domain-error panic — out-of-domain input yields `NaN` per Go's `math` package."},
+	"math.atan2":                    {"math.atan2(y: number, x: number) -> number", "`math.Atan2`."},
+	"math.autocorrelation":          {"math.autocorrelation(arr, [maxLag]) -> array", "Autocorrelation at lags `0..maxLag` (default `maxLag = len(arr)-1`, clamped into range)."},
+	"math.cbrt":                     {"math.cbrt(x: number) -> number", "Cube root, defined for negatives too (`math.Cbrt`)."},
+	"math.ceil":                     {"math.ceil(x: number) -> number", "Standard rounding modes."},
+	"math.clamp":                    {"math.clamp(value, min, max: number) -> number", "Clamps `value` into `[min, max]`."},
+	"math.combination":              {"math.combination(n, k: number) -> number", "`n! / (k!(n-k)!)`; returns `0` (not a panic) for invalid `k`/`n`."},
+	"math.correlation":              {"math.correlation(arr1, arr2: array) -> number", "Pearson correlation coefficient; returns `0` if lengths mismatch/empty/zero-variance rather than panicking."},
+	"math.cos":                      {"math.cos(x: number) -> number", "`math.Sin/Cos/Tan`."},
+	"math.cosh":                     {"math.cosh(x: number) -> number", "Hyperbolic functions."},
+	"math.covariance":               {"math.covariance(arr1, arr2: array) -> number", "Population covariance."},
+	"math.cumulative":               {"math.cumulative(arr) -> array", "Running sum."},
+	"math.dataQuality":              {"math.dataQuality(arr) -> map", "Counts `nil` entries as \"missing\"; uniqueness/duplicates computed via string-keyed dedup. Returns `{total_count, missing_count, missing_percent, unique_count, duplicates, completeness}`."},
+	"math.degToRad":                 {"math.degToRad(n: number) -> number", "Angle unit conversion."},
+	"math.determinant":              {"math.determinant(matrix) -> number | nil", "Recursive cofactor expansion; returns `nil` for a non-square matrix. Only practical for small matrices (no pivoting/optimization)."},
+	"math.differencing":             {"math.differencing(arr, [order=1]) -> array", "Repeated first-differencing; `[]` if `order <= 0` or `len(arr) <= order`."},
+	"math.distance":                 {"math.distance(x1, y1, x2, y2: number) -> number", "Euclidean distance."},
+	"math.exp":                      {"math.exp(x: number) -> number", "`e^x` / `2^x`."},
+	"math.exp2":                     {"math.exp2(x: number) -> number", "`e^x` / `2^x`."},
+	"math.exponentialSmoothing":     {"math.exponentialSmoothing(arr, alpha: number) -> array", "`[]` if `alpha` outside `(0, 1]` or array empty."},
+	"math.factorial":                {"math.factorial(n: number) -> number", "Computed iteratively in `float64` (not recursive, not `int`), so it does not overflow at `int64`'s limit — large results saturate to `+Inf` instead of wrapping/crashing. Panics if `n < 0`."},
+	"math.floor":                    {"math.floor(x: number) -> number", "Standard rounding modes."},
+	"math.frequency":                {"math.frequency(arr) -> map", "Value → count/percentage breakdown, keyed by `fmt.Sprintf(\"%v\", v)` string form of each element."},
+	"math.gcd":                      {"math.gcd(a, b: number) -> number", "Euclidean algorithm; handles negatives via `abs`."},
+	"math.histogram":                {"math.histogram(arr, [bins=10]) -> map", "Returns `{bins (centers), counts, edges}`."},
+	"math.hypot":                    {"math.hypot(x, y: number) -> number", "`math.Hypot`."},
+	"math.interpolate":              {"math.interpolate(xArr, yArr, targetX: number, [method=\"linear\"]) -> number|nil", "`method` `\"linear\"` or `\"nearest\"` (default falls back to linear). Returns `nil` if `targetX` falls outside the range spanned by `xArr`."},
+	"math.isEven":                   {"math.isEven(n: number) -> bool", "Same finiteness check/panic as `isPrime`."},
+	"math.isFinite":                 {"math.isFinite(x: number) -> bool", "`isInf` checks either sign of infinity (`math.IsInf(x, 0)`)."},
+	"math.isInf":                    {"math.isInf(x: number) -> bool", "`isInf` checks either sign of infinity (`math.IsInf(x, 0)`)."},
+	"math.isNaN":                    {"math.isNaN(x: number) -> bool", "`isInf` checks either sign of infinity (`math.IsInf(x, 0)`)."},
+	"math.isOdd":                    {"math.isOdd(n: number) -> bool", "Same finiteness check/panic as `isPrime`."},
+	"math.isPrime":                  {"math.isPrime(n: number) -> bool", "Trial division. Panics if `n` is `NaN`/`Inf` (finite check before truncation to `int`)."},
+	"math.lcm":                      {"math.lcm(a, b: number) -> number", "`0` if either input is `0`."},
+	"math.lerp":                     {"math.lerp(a, b, t: number) -> number", "Linear interpolation `a + t*(b-a)` (t is not clamped to `[0,1]`)."},
+	"math.log":                      {"math.log(x: number) -> number", "Natural/base-10/base-2 log; panics `\"could not calculate log of zero or negative number\"` if `x <= 0`."},
+	"math.log10":                    {"math.log10(x: number) -> number", "Natural/base-10/base-2 log; panics `\"could not calculate log of zero or negative number\"` if `x <= 0`."},
+	"math.log2":                     {"math.log2(x: number) -> number", "Natural/base-10/base-2 log; panics `\"could not calculate log of zero or negative number\"` if `x <= 0`."},
+	"math.manhattanDistance":        {"math.manhattanDistance(x1, y1, x2, y2: number) -> number", "L1 distance."},
+	"math.map":                      {"math.map(value, inMin, inMax, outMin, outMax: number) -> number", "Remaps `value` from one range to another. **Unrelated to `collections.map`** — takes 5 numbers, not an array + callback."},
+	"math.matrix":                   {"math.matrix(rows, cols: number, [fillValue=0]) -> array of arrays", "Panics if `rows`/`cols` negative."},
+	"math.matrixMultiply":           {"math.matrixMultiply(a, b: array of arrays) -> array of arrays | nil", "Returns `nil` (not a panic) on dimension mismatch or malformed input."},
+	"math.max":                      {"math.max(...numbers)` OR `(array)` -> `number", "Variadic form compares all args; **single-array-argument form** (`math.max(arr)`) computes max/min over the array elements instead. Panics with 0 args."},
+	"math.mean":                     {"math.mean(arr: array) -> number", "Arithmetic mean; `0` for empty array."},
+	"math.median":                   {"math.median(arr: array) -> number", "Sorted-array median (average of two middle values if even length)."},
+	"math.min":                      {"math.min(...numbers)` OR `(array)` -> `number", "Variadic form compares all args; **single-array-argument form** (`math.max(arr)`) computes max/min over the array elements instead. Panics with 0 args."},
+	"math.mod":                      {"math.mod(x: number, y: number) -> number", "`math.Mod` (result has same sign as `x`, IEEE remainder-of-division-by-truncation)."},
+	"math.mode":                     {"math.mode(arr: array) -> number", "Most frequent value; ties broken by Go map iteration order (**non-deterministic** for multi-modal input)."},
+	"math.movingAverage":            {"math.movingAverage(arr, windowSize: number) -> array", "Simple moving average; `[]` if `windowSize <= 0` or `> len(arr)`."},
+	"math.normalize":                {"math.normalize(arr: array) -> array", "Min-max scales values into `[0, 1]`; `0` for all if `max == min`."},
+	"math.nthRoot":                  {"math.nthRoot(x, n: number) -> number", "Panics `MathError` if `n == 0`; for negative `x`, only defined (and computed) when `n` is an odd integer, else panics `\"cannot calculate even root of negative number\"`."},
+	"math.outlierDetection":         {"math.outlierDetection(arr, [method=\"iqr\"]) -> map", "`method` is `\"iqr\"` (1.5×IQR fences) or `\"zscore\"` ("},
+	"math.percentile":               {"math.percentile(arr: array, p: number) -> number", "`p` must be in `[0, 1]`; panics otherwise. Linear-interpolation percentile on sorted data."},
+	"math.permutation":              {"math.permutation(n, k: number) -> number", "`n! / (n-k)!`; returns `0` for invalid `k`/`n`."},
+	"math.polynomialFit":            {"math.polynomialFit(xArr, yArr, degree: number) -> map", "**Only degree 1 is real** (delegates to `regression`); degree `2`/`3` return a placeholder map with a literal `\"error\": \"Polynomial regression simplified implementation\"` and fake coefficients `[0.0, 1.0, 0.0]` — do not use for actual quadratic/cubic fitting. Degree `> 3` or insufficient points returns an error map with empty coefficients."},
+	"math.pow":                      {"math.pow(base: number, exp: number) -> number", "`math.Pow`."},
+	"math.predict":                  {"math.predict(regResult: map, xValue: number, [order: number]) -> number|nil", "Given a `regression`-style map, predicts `y` for `xValue`. `order > 1` uses a simplified (not true polynomial-fit) formula: `intercept + slope*x + slope*x^2 + ...`. Returns `nil` if `regResult` lacks `slope`/`intercept` keys."},
+	"math.quartile":                 {"math.quartile(arr: array, q: number) -> number", "Shortcut for `percentile(arr, q * 0.25)` — so `q` is expected to be `0..4` (quartile index), not a fraction."},
+	"math.radToDeg":                 {"math.radToDeg(n: number) -> number", "Angle unit conversion."},
+	"math.random":                   {"math.random() -> number", "`rand.Float64()` in `[0, 1)` using Go's **global, package-level `math/rand`** source."},
+	"math.randomInt":                {"math.randomInt(max: number) -> number", "`rand.Intn(max)`, i.e. `[0, max)`. Panics if `max <= 0`."},
+	"math.randomRange":              {"math.randomRange(min, max: number) -> number", "`min + rand.Float64()*(max-min)`."},
+	"math.randomSample":             {"math.randomSample(arr: array, count: number) -> array", "Shuffles a copy of `arr` and returns the first `count` elements (shuffled full array if `count >= len(arr)`). Panics if `count < 0`."},
+	"math.range":                    {"math.range(arr: array) -> number", "**`max(arr) - min(arr)`** — NOT the same as `std.range`/`collections.range`, which build `[start,end)` sequences. Same function name, unrelated behavior across modules."},
+	"math.regression":               {"math.regression(xArr, yArr: array) -> map", "Simple linear regression; returns `{slope, intercept, correlation, r_squared, error}`. `error` is a string message (and other fields zeroed) if inputs mismatch or `< 2` points, else `nil`."},
+	"math.remainder":                {"math.remainder(x: number, y: number) -> number", "`math.Remainder` (IEEE 754 remainder, rounds to nearest)."},
+	"math.rollingStatistics":        {"math.rollingStatistics(arr, windowSize: number, statistic: string) -> array", "`statistic` ∈ `\"mean\"`,`\"sum\"`,`\"min\"`,`\"max\"`,`\"std\"`,`\"var\"` (defaults to mean for unknown values, no panic)."},
+	"math.round":                    {"math.round(x: number) -> number", "Standard rounding modes."},
+	"math.roundTo":                  {"math.roundTo(x: number, decimals: number) -> number", "Rounds to `decimals` decimal places. Panics if `decimals < 0` or so large that `10^decimals` overflows to `Inf`."},
+	"math.seasonalDecompose":        {"math.seasonalDecompose(arr, period: number) -> map", "Naive trend/seasonal/residual decomposition via centered moving average. Returns an `error` string field (with empty arrays) if `period <= 0` or `n < 2*period`."},
+	"math.seed":                     {"math.seed(n: number) -> nil", "`rand.Seed(int64(n))` — reseeds the **global** `math/rand` source used by all `math.*` random functions."},
+	"math.shuffle":                  {"math.shuffle(arr: array) -> array", "Returns a **new** shuffled array (Fisher–Yates); does not mutate the input."},
+	"math.sign":                     {"math.sign(x: number) -> number", "Returns `1.0`, `-1.0`, or `0.0`."},
+	"math.sin":                      {"math.sin(x: number) -> number", "`math.Sin/Cos/Tan`."},
+	"math.sinh":                     {"math.sinh(x: number) -> number", "Hyperbolic functions."},
+	"math.sqrt":                     {"math.sqrt(x: number) -> number", "Panics via `MathError` if `x < 0` (`\"cannot calculate square root of negative number\"`)."},
+	"math.stdDev":                   {"math.stdDev(arr: array) -> number", "`sqrt(variance)`."},
+	"math.sum":                      {"math.sum(arr: array) -> number", "Sum via `toFloat` on each element."},
+	"math.tan":                      {"math.tan(x: number) -> number", "`math.Sin/Cos/Tan`."},
+	"math.tanh":                     {"math.tanh(x: number) -> number", "Hyperbolic functions."},
+	"math.transpose":                {"math.transpose(matrix) -> array of arrays", "`[]` for empty/malformed input."},
+	"math.trendAnalysis":            {"math.trendAnalysis(arr) -> map", "Runs a regression against the index sequence; returns `{direction: \"increasing\"/\"decreasing\"/\"stable\", slope, strength, correlation, r_squared}`."},
+	"math.trunc":                    {"math.trunc(x: number) -> number", "Standard rounding modes."},
+	"math.variance":                 {"math.variance(arr: array) -> number", "Population variance (divides by `n`, not `n-1`)."},
+	"math.zscore":                   {"math.zscore(arr: array) -> array", "Per-element z-scores; `0` for all elements if `stdDev == 0`."},
+	"native.callFunc":               {"native.callFunc(funcName: string, ...args) -> value", "Calls the Go function previously registered under `funcName` via `RegisterNativeFunc`. Converts `args` to `reflect.Value`s, doing automatic numeric widening/narrowing (R2Lang numbers are always `float64`; a Go `func(int, int) int` parameter gets the float64 converted to `int` automatically) but not numeric↔string conversion. `nil` args are converted to the target parameter's zero value when the type is known. Returns: `nil` if the Go function has no return values, the single value if it returns one, or an array if it returns multiple. Panics if `funcName` isn't registered, or on any argument-count/type mismatch reflect.Call would raise."},
+	"native.callMethod":             {"native.callMethod(obj: *GoObject, methodName: string, ...args) -> value", "Calls an exported method on the wrapped Go value, with the same argument conversion and multi-return-value handling as `callFunc`. Panics if the method doesn't exist."},
+	"native.getField":               {"native.getField(obj: *GoObject, fieldName: string) -> value", "Reads an exported field's current value. Panics if the field doesn't exist."},
+	"native.new":                    {"native.new(structName: string) -> *GoObject", "Instantiates the Go struct/value registered under `structName` via its registered constructor, wrapping the result in a `*GoObject`. Panics if `structName` isn't registered."},
+	"native.setField":               {"native.setField(obj: *GoObject, fieldName: string, value) -> nil", "Sets an **exported** field on the wrapped Go struct (auto-dereferences if the wrapped value is a pointer). Does numeric-to-numeric conversion when needed (e.g. float64 → int) but panics on any other type mismatch, unassignable/unexported field, non-existent field, or a `nil` value."},
+	"os.Command":                    {"os.Command(commandLine: string) -> Command", "Builds a `Command` object (not yet run). `commandLine` is split with `strings.Fields` (whitespace-only, no shell quoting/globbing) when `.run()` executes it."},
+	"os.absPath":                    {"os.absPath(path: string) -> string", "`filepath.Abs`."},
+	"os.chDir":                      {"os.chDir(path: string) -> nil", "`os.Chdir` — process-wide CWD change."},
+	"os.currentDir":                 {"os.currentDir() -> string", "`os.Getwd()`."},
+	"os.envList":                    {"os.envList() -> map<string,string>", "All environment variables as one map (`os.Environ()` split on first `=`)."},
+	"os.execCmd":                    {"os.execCmd(cmdString: string) -> string", "Runs `sh -c cmdString`, returns combined stdout+stderr as a string. **On failure it does not panic** — it returns a string of the form `\"Error:<err>\\nOutput:\\n<output>\"`, so callers must inspect the returned string rather than catch an exception."},
+	"os.execWithEnv":                {"os.execWithEnv(cmdString: string, envMap: map<string,string>) -> map", "Runs `sh -c cmdString` with `os.Environ()` plus `envMap` overlaid (non-string values in `envMap` are silently skipped). Returns the same `{success, output, error}` shape."},
+	"os.execWithTimeout":            {"os.execWithTimeout(cmdString: string, timeoutSeconds: number) -> map", "Runs `sh -c cmdString` in a goroutine; if it doesn't finish within `timeoutSeconds` the process is killed. Returns `{success: bool, output: string, error: string|nil}` — never panics for command failure/timeout (only if the policy blocks shell use)."},
+	"os.exit":                       {"os.exit(code?: number) -> never", "Calls `os.Exit(code)` immediately (default `0`) — terminates the whole process, no cleanup/deferred code runs."},
+	"os.getArch":                    {"os.getArch() -> string", "`runtime.GOARCH`."},
+	"os.getDiskUsage":               {"os.getDiskUsage(path: string) -> map", "POSIX `statfs` via `syscall.Statfs` (not available/likely to fail to compile semantics on Windows); returns `{total, free, available}` in bytes, or `{\"error\": ...}` on failure."},
+	"os.getEnv":                     {"os.getEnv(key: string) -> string | nil", "`nil` if the variable isn't set."},
+	"os.getHomeDir":                 {"os.getHomeDir() -> string", "`os.UserHomeDir()`; error string on failure."},
+	"os.getHostname":                {"os.getHostname() -> string", "Returns an error-message string (not panic) on failure."},
+	"os.getLoadAvg":                 {"os.getLoadAvg() -> map", "Linux/macOS only (shells out to `uptime` and parses \"load average:\"); returns `{\"error\": ...}` on other platforms or parse failure, `{1min, 5min, 15min}` on success."},
+	"os.getMemoryInfo":              {"os.getMemoryInfo() -> map", "**Linux only** (parses `/proc/meminfo`, converting kB values to bytes); returns `{\"error\": \"Memory info not implemented for darwin\"}` etc. on other platforms including macOS."},
+	"os.getNumCPU":                  {"os.getNumCPU() -> number", "`runtime.NumCPU()`."},
+	"os.getParentPid":               {"os.getParentPid() -> number", "Parent PID."},
+	"os.getPid":                     {"os.getPid() -> number", "Current process PID."},
+	"os.getPlatform":                {"os.getPlatform() -> string", "`runtime.GOOS` (e.g. `\"darwin\"`, `\"linux\"`, `\"windows\"`) — the reliable OS name."},
+	"os.getSystemTime":              {"os.getSystemTime() -> map", "`{unix, iso, local, utc, timezone}` snapshot of `time.Now()`."},
+	"os.getTempDir":                 {"os.getTempDir() -> string", "`os.TempDir()` (duplicate of `io.tempDir`)."},
+	"os.getUptime":                  {"os.getUptime() -> number | map", "**Linux only** (parses `/proc/uptime`, seconds as number); `{\"error\": ...}` elsewhere."},
+	"os.getUser":                    {"os.getUser() -> map | string", "`{username, name, uid, gid, homeDir}`; returns an **error string** instead of panicking if `user.Current()` fails."},
+	"os.killPid":                    {"os.killPid(pid: number) -> string", "Finds and kills an **arbitrary** OS process by PID (not limited to child processes); always returns a human-readable status string, never panics."},
+	"os.killProcess":                {"os.killProcess(proc: R2Process) -> nil | string", "Sends `Process.Kill()`. No-op (`nil`) if already killed; returns an error string (not panic) on failure."},
+	"os.listDir":                    {"os.listDir(path: string) -> array<string>", "Entry names via `Readdirnames(-1)` (duplicate of `io.listDir`, implemented independently)."},
+	"os.osName":                     {"os.osName() -> string", "Reads the `OS` environment variable; returns `\"unknown\"` if unset/empty. **Not** `runtime.GOOS** — on macOS/Linux this will almost always be `\"unknown\"` since `$OS` is a Windows-ism. Use `os.getPlatform()` for a reliable OS name."},
+	"os.runProcess":                 {"os.runProcess(cmdString: string) -> R2Process | string", "Starts `sh -c cmdString` in the background (non-blocking, `cmd.Start()`) and returns an opaque `R2Process` handle for use with `waitProcess`/`killProcess`. Returns an error string instead of the handle if `Start()` fails."},
+	"os.setEnv":                     {"os.setEnv(key: string, value: string) -> nil", "`os.Setenv`."},
+	"os.signalProcess":              {"os.signalProcess(pid: number, signal: \"KILL\"|\"TERM\"|\"INT\"|\"HUP\"|\"USR1\"|\"USR2\") -> string", "Sends the named POSIX signal; unsupported signal names or errors return a message string rather than panicking."},
+	"os.waitProcess":                {"os.waitProcess(proc: R2Process) -> \"success\" | string", "Blocks until the process launched by `runProcess` exits. Returns `\"success\"`, an `\"error:...\"` string on wait failure, or `\"error:The process was already kill()ed..\"` if `killProcess` was already called on it."},
+	"promise.all":                   {"promise.all(items: array) -> Promise", "Fulfills with the array of all values, in the order of `items`, or rejects with the first rejection. An empty array fulfills with `[]`."},
+	"promise.any":                   {"promise.any(items: array) -> Promise", "Fulfills with the first fulfilled value; rejects only when every item rejects, with a message listing all the reasons."},
+	"promise.delay":                 {"promise.delay(seconds: number, value?: any) -> Promise", "Fulfills with `value` (or `nil`) after `seconds`."},
+	"promise.isPromise":             {"promise.isPromise(value: any) -> bool", "Whether `value` is a promise."},
+	"promise.race":                  {"promise.race(items: array) -> Promise", "Settles like the first item that settles, fulfilled or rejected. An empty array never settles."},
+	"promise.reject":                {"promise.reject(reason: any) -> Promise", "Returns a promise rejected with `reason`."},
+	"promise.resolve":               {"promise.resolve(value?: any) -> Promise", "Returns `value` if it is already a promise, otherwise a promise fulfilled with it."},
+	"promise.timeout":               {"promise.timeout(p: Promise, seconds: number) -> Promise", "Settles like `p`, or rejects with `\"promise timed out after <d>\"` if `p` is still pending after `seconds`. `p` itself keeps running."},
+	"r2printer.clearScreen":         {"r2printer.clearScreen() -> nil", "Emits the ANSI clear-screen + home-cursor escape sequence (`\\033[H\\033[2J`); has no effect on terminals without ANSI support (e.g. raw redirection to a file)."},
+	"r2printer.debugInspect":        {"r2printer.debugInspect(value: any) -> nil", "Prints `\"[debugInspect] Value = %v (type=%T)\\n\"` — a raw Go `%v`/`%T` dump, so it can leak internal Go type names (e.g. `*r2core.UserFunction`) and, for function values, a raw pointer/address rather than `std.print`'s `\"<function>\"` placeholder."},
+	"r2printer.printAlign":          {"r2printer.printAlign(str: string, align: string, width: number) -> nil", "`align` ∈ `\"left\"`,`\"right\"`,`\"center\"` (case-insensitive); any other value panics. `width` grows to fit `str` if too small."},
+	"r2printer.printBox":            {"r2printer.printBox(text: string, width: number) -> nil", "Draws an ASCII box (`+---+` borders) with `text` centered inside; `width` auto-grows to fit `text` if too small."},
+	"r2printer.printColor":          {"r2printer.printColor(str: string, colorName: string) -> nil", "ANSI-colors `str`. `colorName` ∈ `\"red\"`,`\"green\"`,`\"yellow\"`,`\"blue\"`,`\"reset\"` (case-insensitive); any other value silently falls back to a plain reset code (no color, no error)."},
+	"r2printer.printError":          {"r2printer.printError(str: string) -> nil", "Prints `str` in red (`\\033[31m`)."},
+	"r2printer.printHeader":         {"r2printer.printHeader(str: string) -> nil", "Prints an `=`-underline/overline of `str`'s rune length, `str`, then the same line again (3 lines total)."},
+	"r2printer.printJSON":           {"r2printer.printJSON(obj: any) -> nil", "`json.MarshalIndent(obj, \"\", \"  \")` then prints. Panics `\"printJSON: error al formatear JSON\"` if the value can't be marshaled (e.g. contains a function or other non-JSON-serializable Go type)."},
+	"r2printer.printProgress":       {"r2printer.printProgress(label: string, totalSteps: number, stepDelayMsPerStep: number) -> nil", "Synchronously renders a `totalSteps+1`-frame `#`-bar progress animation, sleeping `stepDelayMs` between frames — this **blocks** for `totalSteps * stepDelayMs` milliseconds total."},
+	"r2printer.printRepeat":         {"r2printer.printRepeat(str: string, count: number) -> nil", "Prints `str` `count` times concatenated on one line, then a newline."},
+	"r2printer.printSeparator":      {"r2printer.printSeparator([width=40]: number) -> nil", "Prints a line of `-` characters. Negative `width` is clamped to `0` (prints an empty line), not a panic."},
+	"r2printer.printSuccess":        {"r2printer.printSuccess(str: string) -> nil", "Prints `str` in green (`\\033[32m`)."},
+	"r2printer.printTable":          {"r2printer.printTable(rows: array of arrays) -> nil", "Left-aligned, space-padded column printout. Panics if any row isn't itself an array. Column widths computed from the max rune-length per column."},
+	"r2printer.printTimestamp":      {"r2printer.printTimestamp() -> nil", "Prints `time.Now()` formatted as `time.RFC1123`."},
+	"r2printer.printWarning":        {"r2printer.printWarning(str: string) -> nil", "Prints `str` in yellow (`\\033[33m`)."},
+	"r2printer.printf":              {"r2printer.printf(format: string, ...args: any) -> nil", "Straight passthrough to `fmt.Printf(format, args...)` — Go format verbs (`%s`, `%v`, `%d`, ...), no output newline unless included in `format`."},
+	"r2printer.println":             {"r2printer.println(...args: any) -> nil", "Prints args space-separated + newline, via bare `fmt.Print(arg)` — **unlike `std.print`, does NOT special-case function values**, so printing a function here shows Go's default `%v` rendering (typically a raw memory address) instead of `\"<function>\"`."},
+	"r2printer.sprint":              {"r2printer.sprint(...args: any) -> string", "`fmt.Sprint(args...)` — returns a concatenated string (no automatic spacing between operands unless neither is a string, per Go's `fmt.Sprint` rules)."},
+	"r2printer.sprintf":             {"r2printer.sprintf(format: string, ...args: any) -> string", "`fmt.Sprintf` equivalent — returns instead of printing."},
+	"rand.randChoice":               {"rand.randChoice(arr: array) -> any", "Picks a random element. Panics if `arr` isn't an array or is empty."},
+	"rand.randFloat":                {"rand.randFloat() -> number", "`localRand.Float64()` in `[0, 1)`."},
+	"rand.randInit":                 {"rand.randInit([seed: number]) -> nil", "Re-creates `localRand` with a new source. No args: time-based seed. With a `seed`, deterministic sequence."},
+	"rand.randInt":                  {"rand.randInt(min: number, max: number) -> number", "Inclusive `[min, max]`. Panics if `max < min`, or if `min`/`max` fall outside `±1e15` (guard against `int` overflow in the internal `max-min+1` computation)."},
+	"rand.sample":                   {"rand.sample(arr: array, n: number) -> array", "Returns a new shuffled `n`-element sub-slice (does not mutate `arr`). Panics if `n < 0` or `n > len(arr)`."},
+	"rand.shuffle":                  {"rand.shuffle(arr: array) -> array", "Returns a new shuffled array (Fisher–Yates); does not mutate `arr`, matching `math.shuffle` and `collections`' functional-style helpers."},
+	"regex.escape":                  {"regex.escape(str: string) -> string", "Escapes all regex metacharacters in `str` so it can be used literally inside another pattern (`regexp.QuoteMeta`)."},
+	"regex.groups":                  {"regex.groups(pattern: string, str: string) -> array<string> or nil", "Returns the first match plus its capture groups as an array (`match[0]` is the whole match, `match[1..]` are groups), via `FindStringSubmatch`. Returns `nil` if there's no match at all."},
+	"regex.match":                   {"regex.match(pattern: string, str: string) -> string or nil", "Returns the first matched substring, or `nil` if no match (`FindStringIndex` under the hood)."},
+	"regex.matchAll":                {"regex.matchAll(pattern: string, str: string) -> array<string>", "Returns all non-overlapping matches as an array of strings (`FindAllString(str, -1)`). Empty array (not nil) if no matches — note: an empty Go slice becomes an empty `[]interface{}`, still an array."},
+	"regex.replace":                 {"regex.replace(pattern: string, str: string, replacement: string) -> string", "Replaces **only the first match** of `pattern` in `str` with `replacement`. `replacement` supports Go's `$1`, `$2`, `${name}` capture-group syntax. Returns `str` unchanged if there's no match."},
+	"regex.replaceAll":              {"regex.replaceAll(pattern: string, str: string, replacement: string) -> string", "Replaces every match (`regexp.ReplaceAllString`), same `$1`/`${name}` replacement syntax."},
+	"regex.split":                   {"regex.split(pattern: string, str: string) -> array<string>", "Splits `str` on every match of `pattern` (`regexp.Split(str, -1)`), returning all pieces."},
+	"regex.test":                    {"regex.test(pattern: string, str: string) -> bool", "`true` if `pattern` matches anywhere in `str` (`MatchString`). Panics on invalid pattern or wrong arg types."},
+	"request.delete":                {"request.delete(url: string, options?: map) -> Response", "Convenience DELETE."},
+	"request.get":                   {"request.get(url: string, options?: map) -> Response", "Convenience GET. Creates a fresh, temporary `Session` per call (30s timeout) but reuses one **global cookie jar** shared across all `request.*` global calls (not sessions)."},
+	"request.head":                  {"request.head(url: string, options?: map) -> Response", "Convenience HEAD."},
+	"request.options":               {"request.options(url: string, options?: map) -> Response", "Convenience OPTIONS."},
+	"request.patch":                 {"request.patch(url: string, options?: map) -> Response", "Convenience PATCH."},
+	"request.post":                  {"request.post(url: string, options?: map) -> Response", "Convenience POST."},
+	"request.put":                   {"request.put(url: string, options?: map) -> Response", "Convenience PUT."},
+	"request.session":               {"request.session() -> Session", "Returns a map-based `Session` object (own cookie jar, own `http.Client`) with methods below."},
+	"request.urldecode":             {"request.urldecode(str: string) -> string", "`url.QueryUnescape`. Panics on malformed percent-encoding."},
+	"request.urlencode":             {"request.urlencode(str: string) -> string", "`url.QueryEscape`."},
+	"soap.client":                   {"soap.client(wsdlURL: string, customHeaders?: map) -> map", "Fetches and parses a WSDL document (sends browser-like `User-Agent`/`Accept` headers to avoid being blocked), extracts the first `<service><port>` address as the service URL, and builds an operation table from `<portType>` + matching `<binding>` (for `SOAPAction`). Returns a **client object** (see below). Panics with a decorated error message (adds hints for connection reset / DNS failure / timeout) if the WSDL can't be fetched or parsed."},
+	"soap.envelope":                 {"soap.envelope(namespace: string, methodName: string, bodyContent: string) -> string", "Builds a raw SOAP 1.1 envelope string: `<soap:Envelope xmlns:tns=\"namespace\"><soap:Header/><soap:Body><tns:methodName>bodyContent</tns:methodName></soap:Body></soap:Envelope>`. `bodyContent` is inserted verbatim (**not escaped** by this function)."},
+	"soap.request":                  {"soap.request(url: string, soapAction: string, envelope: string) -> string", "Sends a raw HTTP POST with `Content-Type: text/xml; charset=utf-8` and `SOAPAction: \"<soapAction>\"` headers, 30s timeout. Returns the raw response body as a string. Treats non-200 responses as an error **unless** the body looks like a SOAP envelope (contains \"envelope\" and \"body\", case-insensitively) — SOAP faults are conventionally returned over HTTP 500. Panics on request failure."},
+	"std.bigint":                    {"std.bigint(v: number|string) -> bigint", "Converts to a BigInt, dropping any fraction. Strings may have any number of digits."},
+	"std.contains":                  {"std.contains(s: string, substr: string) -> bool", "Substring containment only — **string arguments required**; this is not the array-membership check (that's `collections.contains`)."},
+	"std.curry":                     {"std.curry(fn: function) -> function", "Implemented in `pkg/r2core` (`CurryFunction`); enables partial call chaining."},
+	"std.decimal":                   {"std.decimal(v: number|string, scale?: number) -> decimal", "Converts to a Decimal. A float converts to its shortest representation (`0.1` is `0.1d`). With `scale`, the result has exactly that many places, rounding half away from zero (`std.decimal(12.5, 2)` is `12.50`)."},
+	"std.deepCopy":                  {"std.deepCopy(v: any) -> any", "Recursively copies maps/slices/arrays of plain data. Detects and safely handles self-referential structures (e.g. `a[0] = a`) via a `seen` pointer-map instead of infinite-recursing into a Go stack overflow. Pointer-typed interpreter values (functions, dates, object instances) are returned **as-is**, not deep-copied — deep-copying is only meaningful for plain map/slice/array trees."},
+	"std.eval":                      {"std.eval(code: string) -> any", "Parses `code` with a fresh `r2core.NewParser` and evaluates the resulting program **against the environment captured when `RegisterStd` ran** (the interpreter's top-level/global environment) — not necessarily the caller's current local scope. See gotcha below."},
+	"std.float":                     {"std.float(v: number|string) -> number", "Converts any number (or numeric string) to a `float64`."},
+	"std.int":                       {"std.int(v: number|string) -> int", "Converts to an exact `int64`, dropping any fraction (`std.int(3.9)` is `3`). Panics if the value doesn't fit in 64 bits."},
+	"std.is":                        {"std.is(value: any, typeString: string) -> bool", "Type check against one of: `\"number\"`/`\"float\"`/`\"float64\"`, `\"int\"`/`\"int64\"`, `\"bigint\"`, `\"decimal\"`, `\"string\"`, `\"bool\"`/`\"boolean\"`, `\"array\"`, `\"map\"`/`\"object\"`, `\"function\"`, `\"nil\"`/`\"null\"`, `\"date\"`, `\"duration\"`. Unknown `typeString` returns `false` (no panic)."},
+	"std.join":                      {"std.join(arr: array, sep: string) -> string", "Converts each element via `fmt.Sprint` then `strings.Join`. Accepts both `[]interface{}` and `r2core.InterfaceSlice` (via `toGenericSlice`)."},
+	"std.keys":                      {"std.keys(m: map) -> array", "Returns the map's keys as an array (Go map iteration order — unspecified/random). Panics if arg isn't `map[string]interface{}`."},
+	"std.len":                       {"std.len(v: string|array|map) -> number", "Length of a string (bytes... actually Go `len()`, so byte count not rune count), `[]interface{}`, `r2core.InterfaceSlice`, or `map[string]interface{}`. Panics on any other type or 0 args."},
+	"std.now":                       {"std.now([format: string]) -> string", "No args: `time.Now().Format(\"2006-01-02 15:04:05\")`. One arg: custom Go time-layout string. Panics with >1 arg."},
+	"std.parseFloat":                {"std.parseFloat(s: string) -> number", "`strconv.ParseFloat(s, 64)`; panics on failure."},
+	"std.parseInt":                  {"std.parseInt(s: string) -> number", "`strconv.Atoi`; panics `\"parseInt: could not convert '<s>' to int\"` on failure. Only accepts base-10 integer strings (no leading `+`/decimal/exponent handling beyond what `Atoi` allows)."},
+	"std.partial":                   {"std.partial(fn: function, ...args) -> function", "Implemented in `pkg/r2core` (`PartialBuiltin`); binds leading arguments."},
+	"std.print":                     {"std.print(...args: any) -> nil", "Prints args space-separated + trailing newline (like `println`), via `fmt.Print`. Any Go `func`-kind value (a `BuiltinFunction`, `*UserFunction`, or a closure from member access) is rendered as the literal string `\"<function>\"` instead of a raw pointer, via `printSafeArg`."},
+	"std.range":                     {"std.range(start: number, end: number) -> array", "Builds `[start, end)` as an array of numbers, step 1. If `start >= end`, silently returns `[]` (no panic) — **contrast with `collections.range`, which panics if `start > end`.**"},
+	"std.replace":                   {"std.replace(s: string, old: string, new: string) -> string", "`strings.ReplaceAll` (replaces **all** occurrences, despite the JS-`String.replace`-like name)."},
+	"std.sleep":                     {"std.sleep(seconds: number) -> nil", "Blocks via `time.Sleep(seconds * time.Second)`. Panics if arg isn't a number."},
+	"std.split":                     {"std.split(s: string, sep: string) -> array", "`strings.Split`, wrapped as `[]interface{}` of strings."},
+	"std.toLowerCase":               {"std.toLowerCase(s: string) -> string", "`strings.ToLower`."},
+	"std.toString":                  {"std.toString(v: any) -> string", "`fmt.Sprint(v)`."},
+	"std.toUpperCase":               {"std.toUpperCase(s: string) -> string", "`strings.ToUpper`."},
+	"std.typeOf":                    {"std.typeOf(value: any) -> string", "Returns the Go type name via `fmt.Sprintf(\"%T\", value)` (e.g. `\"float64\"`, `\"int64\"`, `\"string\"`, `\"[]interface {}\"`, `\"map[string]interface {}\"`), except for `\"bigint\"`, `\"decimal\"` and `\"enum\"` (a member of an `enum` declaration). Returns `\"nil\"` if called with 0 args (not if `value` is R2Lang `nil` — that returns `\"<nil>\"`)."},
+	"string.capitalize":             {"string.capitalize(str: string) -> string", "Uppercases only the first rune, leaves the rest untouched (does **not** lowercase the remainder). `\"HELLO\".capitalize` stays `\"HELLO\"`."},
+	"string.contains":               {"string.contains(str: string, sub: string) -> bool", "`strings.Contains`."},
+	"string.endsWith":               {"string.endsWith(str: string, suffix: string) -> bool", "`strings.HasSuffix`."},
+	"string.indexOf":                {"string.indexOf(str: string, sub: string) -> number", "`strings.Index`; returns `-1` if not found (byte index, not rune index)."},
+	"string.isBlank":                {"string.isBlank(str: string) -> bool", "`strings.TrimSpace(s) == \"\"`."},
+	"string.join":                   {"string.join(arr: array, sep: string) -> string", "**Only accepts a literal `[]interface{}`** — unlike `std.join`, it does NOT go through `toGenericSlice`, so an `r2core.InterfaceSlice` value would fail the type assertion and panic `\"join: primer argumento array nativo, segundo un string\"`."},
+	"string.lastIndexOf":            {"string.lastIndexOf(str: string, sub: string) -> number", "`strings.LastIndex`; `-1` if not found."},
+	"string.lengthOfString":         {"string.lengthOfString(str: string) -> number", "Rune count via `utf8.RuneCountInString` — correct for multi-byte UTF-8, unlike `std.len`."},
+	"string.padEnd":                 {"string.padEnd(str: string, targetLength: number, padStr: string) -> string", "Same as `padStart` but pads on the right."},
+	"string.padStart":               {"string.padStart(str: string, targetLength: number, padStr: string) -> string", "Left-pads with `padStr` (repeated/truncated as needed) until rune length reaches `targetLength`. No-op if already long enough or `padStr == \"\"`."},
+	"string.repeat":                 {"string.repeat(str: string, count: number) -> string", "`strings.Repeat`; panics if `count < 0`."},
+	"string.replace":                {"string.replace(str: string, old: string, new: string) -> string", "`strings.ReplaceAll` — replaces all occurrences."},
+	"string.reverse":                {"string.reverse(str: string) -> string", "Rune-safe reversal (correct for multi-byte characters)."},
+	"string.split":                  {"string.split(str: string, sep: string) -> array", "`strings.Split` wrapped as `[]interface{}`."},
+	"string.startsWith":             {"string.startsWith(str: string, prefix: string) -> bool", "`strings.HasPrefix`."},
+	"string.substring":              {"string.substring(str: string, start: number, length: number) -> string", "Rune-based (not byte-based) slice `[start, start+length)`. Out-of-range `start`/negative `start`/`length` returns `\"\"` rather than panicking; `end` is clamped to the string's rune length if it overflows."},
+	"string.toLower":                {"string.toLower(str: string) -> string", "Same underlying function (`strings.ToLower`); alias as above."},
+	"string.toLowerCase":            {"string.toLowerCase(str: string) -> string", "Same underlying function (`strings.ToLower`); alias as above."},
+	"string.toUpper":                {"string.toUpper(str: string) -> string", "Same underlying function (`strings.ToUpper`); `toUpperCase` is a JS-naming alias."},
+	"string.toUpperCase":            {"string.toUpperCase(str: string) -> string", "Same underlying function (`strings.ToUpper`); `toUpperCase` is a JS-naming alias."},
+	"string.trim":                   {"string.trim(str: string) -> string", "`strings.TrimSpace`."},
+	"string.trimEnd":                {"string.trimEnd(str: string) -> string", "`strings.TrimRight(s, \" \\t\\n\\r\")`, same caveat."},
+	"string.trimStart":              {"string.trimStart(str: string) -> string", "`strings.TrimLeft(s, \" \\t\\n\\r\")` — a fixed cutset, not full Unicode whitespace (`strings.TrimSpace` is broader)."},
+	"sync.Mutex":                    {"sync.Mutex() -> MutexObject", "Creates a mutex object wrapping a `*sync.Mutex`, with `.lock()`, `.unlock()`, `.tryLock()` methods."},
+	"sync.Once":                     {"sync.Once() -> OnceObject", "Creates a wrapper around `*sync.Once`, with a `.do(fn)` method."},
+	"sync.Semaphore":                {"sync.Semaphore(permits: number) -> SemaphoreObject", "Creates a counting semaphore (buffered channel of size `permits`) with `.acquire()`, `.release()`, `.tryAcquire()` methods. Panics if not exactly 1 arg, arg not a number, or `permits < 1`."},
+	"sync.WaitGroup":                {"sync.WaitGroup() -> WaitGroupObject", "Creates a wait-group object wrapping a `*sync.WaitGroup`, with `.add()`, `.done()`, `.wait()` methods."},
+	"test.assertEq":                 {"test.assertEq(actual, expected, [msg]) -> nil", "Panics if `!reflect.DeepEqual(actual, expected)`. If `msg` is given, panics with `\"<msg> (actual=..., expected=...)\"`; otherwise `\"assertEq fallo: actual=..., expected=...\"`. Returns `nil` on success."},
+	"test.assertEqual":              {"test.assertEqual(a, b) -> nil", "Similar to `assertEq` but uses the module-local `equals(a, b)` helper (not `reflect.DeepEqual`) and takes exactly 2 args (no optional message). Panics with `\"Assertion Failed: <a> != <b>\"` on mismatch. Requires exactly 2 arguments (panics otherwise, unlike `assertEq`'s \"at least 2\")."},
+	"test.assertTrue":               {"test.assertTrue(cond, [msg]) -> nil", "Panics if `toBool(cond)` is false. Default message: `\"assertTrue fallo: la condición es falsa\"`."},
+	"test.printStep":                {"test.printStep(...args) -> nil", "Prints each argument space-separated followed by a newline (`fmt.Print(arg, \" \")` per arg + `fmt.Println()`) — a simple step/trace logger for use inside tests."},
+	"test.runAllTests":              {"test.runAllTests() -> nil", "Scans the current environment's variable store for any bound `*UserFunction` whose name starts with `\"test\"` (case-insensitive), calls each with no arguments, catching panics per-test via `recover()`. Prints a `[PASSED]`/`[FAILED]` report line per test (with elapsed ms and, on failure, the indented panic message) plus a final summary line (`\"Resumen: N PASSED, N FAILED, N TOTAL (...ms)\"`) to stdout. Prints `\"No se encontraron funciones test* en este script.\"` and returns if no such functions exist. Does not stop the whole run on a single test's panic — only that one test is marked FAILED."},
+	"unicode.ucharcode":             {"unicode.ucharcode(str: string) -> number", "Unicode code point of the **first** rune. Panics on empty string."},
+	"unicode.ucompare":              {"unicode.ucompare(str1: string, str2: string, [locale=\"en\"]) -> number", "Locale-aware collation comparison (`golang.org/x/text/collate`); returns negative/zero/positive."},
+	"unicode.ufromcode":             {"unicode.ufromcode(code: number) -> string", "Builds a 1-rune string from a code point. Panics if the rune is not a valid Unicode code point."},
+	"unicode.ugetCategory":          {"unicode.ugetCategory(str: string) -> string", "Returns the most specific (longest-name) matching Unicode general-category code for the first rune (e.g. `\"Nd\"` for a digit, `\"Lu\"` for uppercase letter), deterministically tie-broken alphabetically to avoid Go map-iteration nondeterminism. Returns `\"Unknown\"` if no category matches. Excludes the synthetic `\"LC\"` grouping."},
+	"unicode.uisDigit":              {"unicode.uisDigit(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisLetter":             {"unicode.uisLetter(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisLower":              {"unicode.uisLower(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisPunct":              {"unicode.uisPunct(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisSpace":              {"unicode.uisSpace(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisUpper":              {"unicode.uisUpper(str: string) -> bool", "Classify the **first rune only** of `str` (via `unicode.IsLetter` etc.); panics on empty string. Multi-character input is silently truncated to its first rune."},
+	"unicode.uisvalid":              {"unicode.uisvalid(str: string) -> bool", "`utf8.ValidString`."},
+	"unicode.ulen":                  {"unicode.ulen(str: string) -> number", "Rune count (`utf8.RuneCountInString`), unlike `std.len`'s byte count."},
+	"unicode.ulower":                {"unicode.ulower(str: string) -> string", "`strings.ToUpper`/`ToLower` (same as `string.toUpper`/`toLower`, just re-exposed here)."},
+	"unicode.unormalize":            {"unicode.unormalize(str: string, [form=\"NFC\"]) -> string", "`form` ∈ `\"NFC\"`, `\"NFD\"`, `\"NFKC\"`, `\"NFKD\"`; panics on any other value."},
+	"unicode.uregex":                {"unicode.uregex(pattern: string, text: string) -> array", "All non-overlapping matches (`regexp.FindAllString`, Go `regexp` — RE2 syntax, Unicode-aware by default). Panics on invalid pattern."},
+	"unicode.uregexMatch":           {"unicode.uregexMatch(pattern: string, text: string) -> bool", "Whether `pattern` matches anywhere in `text`."},
+	"unicode.uregexReplace":         {"unicode.uregexReplace(pattern: string, replacement: string, text: string) -> string", "Note **argument order**: `(pattern, replacement, text)` — replacement comes before the text, unlike `string.replace`'s `(str, old, new)` order."},
+	"unicode.ureverse":              {"unicode.ureverse(str: string) -> string", "Rune-safe reversal (same algorithm as `string.reverse`)."},
+	"unicode.usubstr":               {"unicode.usubstr(str: string, start: number, [length: number]) -> string", "Rune-index substring. `start < 0` is clamped to `0`; `start >= len(runes)` returns `\"\"`. Omitting `length` returns to the end of the string. `length` is clamped, never panics on out-of-range."},
+	"unicode.utitle":                {"unicode.utitle(str: string) -> string", "True title-case (first letter of each word capitalized, rest lowercased) via `golang.org/x/text/cases.Title`. **Not** the same as `strings.ToTitle`, which per-character uppercases (would turn `\"hello world\"` into `\"HELLO WORLD\"`)."},
+	"unicode.uupper":                {"unicode.uupper(str: string) -> string", "`strings.ToUpper`/`ToLower` (same as `string.toUpper`/`toLower`, just re-exposed here)."},
+	"uuid.isValid":                  {"uuid.isValid(str: string) -> bool", "Validates the *format* only (regex `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`) — does **not** check the version/variant nibbles, so it accepts any correctly-shaped hex-hyphen string, not just v4 UUIDs. Returns `false` (not a panic) if the argument isn't a string."},
+	"uuid.v4":                       {"uuid.v4() -> string", "Generates a random RFC 4122 version-4 UUID using `crypto/rand` (cryptographically secure, not `math/rand`), formatted as lowercase `8-4-4-4-12` hex groups. Panics if the system's secure RNG fails to read."},
+	"validate.isEmail":              {"validate.isEmail(s: string) -> bool", "Regex-based email syntax check (a fairly standard RFC-5322-ish pattern: local-part `[a-zA-Z0-9.!#$%&'*+/=?^_`{"},
+	"validate.isIP":                 {"validate.isIP(s: string) -> bool", "`net.ParseIP(s) != nil` — accepts both IPv4 and IPv6 literal forms."},
+	"validate.isURL":                {"validate.isURL(s: string) -> bool", "`net/url.ParseRequestURI(s)`, then requires both `Scheme` and `Host` to be non-empty. Malformed URLs, or bare paths (`Scheme`/`Host` missing), return `false` rather than panicking."},
+	"web.createApp":                 {"web.createApp() -> App", "Creates an **isolated** `WebApp` (own route table, static map, middleware list) and returns an object with `get/post/put/delete/static/listen/use` bound to it."},
+	"web.delete":                    {"web.delete(path: string, handler) -> nil", "Same, `DELETE`."},
+	"web.get":                       {"web.get(path: string, handler: function(ctx) -> any) -> nil", "Registers a GET route on the **global** default app (`globalApp`, created once at `RegisterWeb` time — analogous to `http.handler`'s global table but per-method maps instead of a flat slice)."},
+	"web.html":                      {"web.html(content: string) -> map", "Returns `{type: \"html\", content}`, a response descriptor recognized by the handler-result dispatcher."},
+	"web.json":                      {"web.json(data: any) -> map", "Returns `{type: \"json\", data}`, recognized by the dispatcher (`Content-Type: application/json`)."},
+	"web.listen":                    {"web.listen(port: string) -> never (blocks)", "Starts the global app's server (`net/http.Server`, `ReadHeaderTimeout: 15s`). Panics if `ListenAndServe` fails."},
+	"web.parseForm":                 {"web.parseForm(body: string) -> map<string,string>", "Parses a `application/x-www-form-urlencoded` body into a flat string map (`url.ParseQuery`, first value per key)."},
+	"web.parseJSON":                 {"web.parseJSON(body: string) -> any", "`json.Unmarshal` into a generic value; silently returns `nil` on parse failure (no panic here, unlike most other modules' JSON parsers)."},
+	"web.post":                      {"web.post(path: string, handler) -> nil", "Same, `POST`."},
+	"web.put":                       {"web.put(path: string, handler) -> nil", "Same, `PUT`."},
+	"web.redirect":                  {"web.redirect(url: string) -> map", "Returns `{type: \"redirect\", url}`, recognized by the dispatcher (302 Found)."},
+	"web.static":                    {"web.static(prefix: string, dir: string) -> nil", "Serves files under local directory `dir` at URL prefix `prefix` (via `http.StripPrefix` + `http.FileServer`) on the global app. Static prefixes are matched before the dynamic route dispatcher."},
+	"web.status":                    {"web.status(code: number) -> map", "Returns `{type: \"status\", code}` — recognized by the top-level response dispatcher only if it's the sole/entire returned value, and even then only sets the status with **no body** (unlike `ctx.status(code)`, see below, which is the intended way to set status + send a body)."},
+	"xml.addChild":                  {"xml.addChild(parent: map, child: map) -> map", "Appends `child` to `parent.children` (mutates and returns `parent`)."},
+	"xml.createNode":                {"xml.createNode(tagName: string, content?: string) -> map", "Builds a fresh empty node map `{name, content, attributes:{}, children:[]}`."},
+	"xml.findByPath":                {"xml.findByPath(root: map, path: string) -> map|nil", "Walks `path` (slash-separated tag names, e.g. `\"a/b/c\"`) descending through `children` by matching `name`; returns `nil` if any segment isn't found."},
+	"xml.fromJSON":                  {"xml.fromJSON(jsonObj: map) -> map", "Inverse of `toJSON`: converts the `_text`/`@attr`/child-tag convention back into a node map. Only the first top-level key of `jsonObj` that maps to an object is converted (a JSON-XML object should have exactly one root key)."},
+	"xml.getAttribute":              {"xml.getAttribute(node: map, attrName: string) -> any|nil", "Reads `node.attributes[attrName]`, or `nil` if absent/not present."},
+	"xml.getChildByName":            {"xml.getChildByName(node: map, tagName: string) -> map|nil", "Returns the first direct child whose `name` equals `tagName`, or `nil`."},
+	"xml.getChildren":               {"xml.getChildren(node: map) -> array", "Returns `node.children`, or `[]` if not set."},
+	"xml.getChildrenByName":         {"xml.getChildrenByName(node: map, tagName: string) -> array", "Returns all direct children whose `name` equals `tagName`."},
+	"xml.minify":                    {"xml.minify(xmlString: string) -> string", "Removes blank lines and leading/trailing whitespace per line, then joins with no separator. This is a naive line-based minifier, not a real XML canonicalizer."},
+	"xml.parse":                     {"xml.parse(xmlString: string) -> map", "Parses XML into a node map `{name, content, attributes, children}`. `xmlns`/`xmlns:*` attributes are stripped (not exposed as regular attributes). Panics on malformed XML or if the document has multiple root elements."},
+	"xml.pretty":                    {"xml.pretty(xmlString: string, indent?: string) -> string", "Naive character-scanning re-indenter (tracks `<`/`>`/`/` to guess nesting depth); default indent is two spaces. Not a full XML formatter — can mishandle unusual XML (comments, CDATA, attributes containing `<`/`>`)."},
+	"xml.removeChild":               {"xml.removeChild(parent: map, index: number) -> map", "Removes the child at `index` (no-op if out of range)."},
+	"xml.setAttribute":              {"xml.setAttribute(node: map, attrName: string, value: string) -> map", "Sets `node.attributes[attrName] = value` (mutates and returns the same map)."},
+	"xml.stringify":                 {"xml.stringify(node: map, pretty?: bool) -> string", "Serializes a node map back to an XML string, escaping text/attribute content. `pretty=true` adds newlines/indentation. Panics if the map isn't a valid node object (missing/non-string `name`) or contains a circular reference."},
+	"xml.toJSON":                    {"xml.toJSON(node: map) -> map", "Converts the node tree to a \"JSON-XML\" convention: `{ tagName: { \"_text\": ..., \"@attr\": ..., childTag: ... or [...] } }`. Repeated child tags become an array automatically."},
+	"xml.validate":                  {"xml.validate(xmlString: string) -> bool", "Returns `true` if the string tokenizes as well-formed XML (drains the decoder to EOF without error)."},
+	"xml.xpath":                     {"xml.xpath(root: map, xpath: string) -> array", "**Very limited** XPath subset: `\"//tagname\"` finds all descendants with that tag anywhere in the tree; `\"/a/b\"` (absolute path) delegates to the same logic as `findByPath`. Any other expression form returns an empty array."},
+}
@@ -0,0 +1,122 @@
+package r2lsp
+
+//go:generate go run gen_docs.go ../../docs/en/stdlib-reference.md builtin_docs.go
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+// builtinDoc is the documentation of a builtin from the stdlib reference.
+type builtinDoc struct {
+	signature   string
+	description string
+}
+
+// Tipos de miembro de un módulo o global
+const (
+	kindFunction = "function"
+	kindModule   = "module"
+	kindClass    = "class"
+	kindValue    = "value"
+)
+
+// catalog describes the globals registered by the standard libraries, taken
+// from their registration maps.
+type catalog struct {
+	globals map[string]string            // Nombre -> tipo
+	modules map[string]map[string]string // Módulo -> miembro -> tipo
+}
+
+var (
+	builtinsOnce sync.Once
+	builtins     *catalog
+)
+
+// builtinCatalog returns the catalog of every library of r2lang.LibraryNames.
+func builtinCatalog() *catalog {
+	builtinsOnce.Do(func() {
+		in, err := r2lang.NewInterpreter(r2lang.Config{Stdout: io.Discard, Stderr: io.Discard})
+		if err != nil {
+			panic(fmt.Sprintf("r2lsp: cannot register the libraries: %v", err))
+		}
+		builtins = newCatalog(in.Environment())
+	})
+	return builtins
+}
+
+// newCatalog describes the globals of env.
+func newCatalog(env *r2core.Environment) *catalog {
+	c := &catalog{globals: map[string]string{}, modules: map[string]map[string]string{}}
+	for name, value := range env.GetStore() {
+		switch name {
+		case "true", "false", "nil", "null":
+			continue
+		}
+		kind := valueKind(value)
+		c.globals[name] = kind
+		if module, ok := value.(map[string]interface{}); ok {
+			members := make(map[string]string, len(module))
+			for member, v := range module {
+				members[member] = valueKind(v)
+			}
+			c.modules[name] = members
+		}
+	}
+	// Las clases de Error y las colecciones no se guardan en el entorno
+	for _, name := range r2core.ErrorClassNames() {
+		c.globals[name] = kindClass
+	}
+	for _, name := range r2core.CollectionNames() {
+		c.globals[name] = kindClass
+	}
+	return c
+}
+
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case r2core.BuiltinFunction, *r2core.UserFunction:
+		return kindFunction
+	case map[string]interface{}:
+		return kindModule
+	}
+	return kindValue
+}
+
+// members returns the sorted member names of module.
+func (c *catalog) members(module string) []string {
+	names := make([]string, 0, len(c.modules[module]))
+	for name := range c.modules[module] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describe returns the hover text of the global name, or of module.member
+// when module is not empty.
+func (c *catalog) describe(module, name string) (string, bool) {
+	if module == "" {
+		kind, ok := c.globals[name]
+		if !ok {
+			return "", false
+		}
+		if kind == kindModule {
+			return fmt.Sprintf("```r2\nmodule %s\n```\nBuiltin module with %d members.", name, len(c.modules[name])), true
+		}
+		return fmt.Sprintf("```r2\n%s\n```\nBuiltin %s.", name, kind), true
+	}
+	kind, ok := c.modules[module][name]
+	if !ok {
+		return "", false
+	}
+	if doc, ok := builtinDocs[module+"."+name]; ok {
+		return fmt.Sprintf("```r2\n%s\n```\n%s", doc.signature, doc.description), true
+	}
+	return fmt.Sprintf("```r2\n%s.%s\n```\nBuiltin %s of module %s.", module, name, kind, module), true
+}
@@ -0,0 +1,777 @@
+package r2lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2mod"
+)
+
+// document is an analyzed R2 source file. The analysis works on the tokens
+// rather than the AST so that it keeps working while the file is being
+// edited and does not parse: a file that stops lexing is analyzed up to the
+// error.
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+	lines   []int // Offset del comienzo de cada línea
+	tokens  []r2core.Token
+	match   map[int]int // Índice de cada '(' o '{' -> índice de su cierre
+
+	symbols []*symbol // Declaraciones de primer nivel, en orden
+	decls   []*symbol // Todas las declaraciones, incluidas parámetros y locales
+	imports []*importDecl
+
+	project      *r2mod.Project // Proyecto de los imports de paquetes; nil fuera de uno
+	projectFound bool           // project ya se buscó
+
+	exports      map[string]bool // Nombres que ve quien importa el documento; nil si no se saben
+	exportsFound bool            // exports ya se calculó
+}
+
+// symbol is a declaration in a document.
+type symbol struct {
+	name     string
+	kind     int    // symbolFunction, symbolClass, ...
+	detail   string // La declaración tal como se muestra en hover
+	start    int    // Offsets del nombre
+	end      int
+	declFrom int // Offsets de la declaración completa
+	declTo   int
+	scopeEnd int // Último offset en el que el nombre es visible
+	parent   *symbol
+	children []*symbol
+}
+
+// importDecl is an import statement.
+type importDecl struct {
+	path      string // Tal como está escrito
+	resolved  string // Relativo al directorio del documento
+	alias     *symbol
+	names     map[*symbol]string // Nombres de un import selectivo -> nombre en el módulo
+	pathStart int                // Offsets del string con la ruta
+	pathEnd   int
+}
+
+func newDocument(uri, path, text string, version int) *document {
+	d := &document{uri: uri, path: path, text: text, version: version, match: map[int]int{}}
+	d.lines = append(d.lines, 0)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.tokens = tokenize(text)
+	d.matchDelimiters()
+	d.scan()
+	return d
+}
+
+// tokenize returns the tokens of text up to the first lexical error.
+func tokenize(text string) (tokens []r2core.Token) {
+	defer func() {
+		// El lexer entra en pánico ante un error: nos quedamos con lo leído
+		recover()
+	}()
+	lexer := r2core.NewLexer(text)
+	for {
+		tok := lexer.NextToken()
+		if tok.Type == r2core.TOKEN_EOF {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (d *document) matchDelimiters() {
+	var stack []int
+	for i, tok := range d.tokens {
+		if tok.Type != r2core.TOKEN_SYMBOL {
+			continue
+		}
+		switch tok.Value {
+		case "(", "{", "[":
+			stack = append(stack, i)
+		case ")", "}", "]":
+			if len(stack) > 0 {
+				d.match[stack[len(stack)-1]] = i
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// value returns the value of token i when it is a symbol or an identifier,
+// which is what the scanner compares against.
+func (d *document) value(i int) string {
+	if i < 0 || i >= len(d.tokens) {
+		return ""
+	}
+	tok := d.tokens[i]
+	if tok.Type == r2core.TOKEN_STRING || tok.Type == r2core.TOKEN_TEMPLATE_STRING {
+		return ""
+	}
+	return tok.Value
+}
+
+func (d *document) isIdent(i int) bool {
+	return i >= 0 && i < len(d.tokens) && d.tokens[i].Type == r2core.TOKEN_IDENT
+}
+
+// closing returns the end offset of the delimiter closing token i, or the end
+// of the text when it is not closed.
+func (d *document) closing(i int) int {
+	if j, ok := d.match[i]; ok {
+		return d.tokens[j].Pos
+	}
+	return len(d.text)
+}
+
+// scan collects the declarations and imports of the document.
+func (d *document) scan() {
+	var braces []int             // Índices de las '{' abiertas
+	classes := map[int]*symbol{} // '{' del cuerpo de una clase -> clase
+
+	scopeEnd := func() int {
+		if len(braces) == 0 {
+			return len(d.text)
+		}
+		return d.closing(braces[len(braces)-1])
+	}
+	enclosingClass := func() *symbol {
+		if len(braces) == 0 {
+			return nil
+		}
+		return classes[braces[len(braces)-1]]
+	}
+	var memberStart func(i int) bool
+	memberStart = func(i int) bool {
+		switch d.value(i - 1) {
+		case "{", "}", "\n", ";":
+			return true
+		case "static", "get", "set", "async", "*":
+			// Modifiers before the member: static let x, get total(), async *items()
+			return memberStart(i - 1)
+		}
+		return false
+	}
+
+	for i := 0; i < len(d.tokens); i++ {
+		tok := d.tokens[i]
+		v := d.value(i)
+
+		switch {
+		case tok.Type == r2core.TOKEN_SYMBOL && v == "{":
+			braces = append(braces, i)
+			continue
+		case tok.Type == r2core.TOKEN_SYMBOL && v == "}":
+			if len(braces) > 0 {
+				braces = braces[:len(braces)-1]
+			}
+			continue
+		case tok.Type == r2core.TOKEN_IMPORT:
+			d.scanImport(i)
+			continue
+		}
+		if tok.Type != r2core.TOKEN_IDENT {
+			continue
+		}
+
+		class := enclosingClass()
+		switch {
+		case class != nil && memberStart(i) && (v == "let" || v == "var") && d.isIdent(i+1):
+			field := d.declare(i+1, symbolField, class.name+"."+d.tokens[i+1].Value, tok.Start, d.lineEnd(i), class.declTo)
+			field.parent = class
+			class.children = append(class.children, field)
+
+		case class != nil && memberStart(i) && d.value(i+1) == "(":
+			d.scanFunction(i, i, i+1, class, scopeEnd())
+			i = d.skipTo(i + 1)
+
+		case (v == "func" || v == "function" || v == "method") && d.isIdent(i+1) && d.value(i+2) == "(":
+			d.scanFunction(i, i+1, i+2, class, scopeEnd())
+			i = d.skipTo(i + 2)
+
+		case (v == "func" || v == "function") && d.value(i+1) == "(":
+			d.scanFunction(i, -1, i+1, nil, scopeEnd())
+			i = d.skipTo(i + 1)
+
+		case (v == "class" || v == "obj") && d.isIdent(i+1):
+			d.scanClass(i, len(braces) == 0, classes)
+
+		case (v == "interface" || v == "trait") && d.isIdent(i+1) && d.value(d.next(i+2)) == "{":
+			d.scanClass(i, len(braces) == 0, classes)
+
+		case v == "enum" && d.isIdent(i+1) && d.value(d.next(i+2)) == "{":
+			d.scanEnum(i, len(braces) == 0, scopeEnd())
+
+		case (v == "let" || v == "var" || v == "const") && d.isIdent(i+1) && class == nil:
+			d.scanLet(i, len(braces) == 0, scopeEnd())
+
+		case v == "catch" && d.value(i+1) == "(" && d.isIdent(i+2):
+			// catch (e) o catch (e: Tipo | Otro)
+			if end := d.skipType(i + 3); d.value(end) == ")" {
+				if body := d.next(end + 1); d.value(body) == "{" {
+					detail := "catch (" + d.tokens[i+2].Value
+					for j := i + 3; j < end; j++ {
+						switch t := d.tokens[j].Value; t {
+						case ":":
+							detail += ": "
+						case "|":
+							detail += " | "
+						default:
+							detail += t
+						}
+					}
+					d.declare(i+2, symbolVariable, detail+")", tok.Start, d.tokens[end].Pos, d.closing(body))
+				}
+			}
+		}
+	}
+}
+
+// declare records the declaration named by token i and returns it.
+func (d *document) declare(i, kind int, detail string, from, to, scopeEnd int) *symbol {
+	tok := d.tokens[i]
+	sym := &symbol{
+		name:     tok.Value,
+		kind:     kind,
+		detail:   detail,
+		start:    tok.Start,
+		end:      tok.Pos,
+		declFrom: from,
+		declTo:   to,
+		scopeEnd: scopeEnd,
+	}
+	d.decls = append(d.decls, sym)
+	return sym
+}
+
+// scanFunction records the function whose keyword (or name, for methods
+// without one) is token kw, name token name (-1 when anonymous) and '('
+// token open. A method belongs to class; a function is visible until
+// scopeEnd, and its parameters in its body.
+func (d *document) scanFunction(kw, name, open int, class *symbol, scopeEnd int) {
+	params := d.params(open)
+	names := make([]string, len(params))
+	for j, p := range params {
+		names[j] = d.tokens[p].Value
+	}
+	closeParen, closed := d.match[open]
+	body := d.next(d.skipType(closeParen + 1))
+	if !closed || d.value(body) != "{" {
+		// A method of an interface may have no body: it is required
+		if closed && name >= 0 && class != nil && class.kind == symbolInterface {
+			signature := d.tokens[name].Value + "(" + strings.Join(names, ", ") + ")"
+			fn := d.declare(name, symbolMethod, class.name+"."+signature, d.tokens[kw].Start, d.tokens[closeParen].Pos, class.declTo)
+			fn.parent = class
+			class.children = append(class.children, fn)
+		}
+		return
+	}
+	bodyEnd := d.closing(body)
+
+	var fn *symbol
+	if name >= 0 {
+		signature := d.tokens[name].Value + "(" + strings.Join(names, ", ") + ")"
+		if class != nil {
+			fn = d.declare(name, symbolMethod, class.name+"."+signature, d.tokens[kw].Start, bodyEnd, class.declTo)
+			fn.parent = class
+			class.children = append(class.children, fn)
+		} else {
+			fn = d.declare(name, symbolFunction, "func "+signature, d.tokens[kw].Start, bodyEnd, scopeEnd)
+			if scopeEnd == len(d.text) {
+				d.symbols = append(d.symbols, fn)
+			}
+		}
+	}
+	owner := "function"
+	if fn != nil {
+		owner = fn.name
+	}
+	for _, p := range params {
+		d.declare(p, symbolVariable, "parameter "+d.tokens[p].Value+" of "+owner, d.tokens[p].Start, d.tokens[p].Pos, bodyEnd)
+	}
+}
+
+// params returns the indexes of the parameter names between the '(' at
+// open and its ')'.
+func (d *document) params(open int) []int {
+	closeParen, ok := d.match[open]
+	if !ok {
+		return nil
+	}
+	var params []int
+	depth := 0
+	for j := open + 1; j < closeParen; j++ {
+		switch d.value(j) {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if depth != 0 || !d.isIdent(j) {
+			continue
+		}
+		switch prev := d.value(j - 1); {
+		case j-1 == open, prev == ",", d.tokens[j-1].Type == r2core.TOKEN_ELLIPSIS:
+			params = append(params, j)
+		}
+	}
+	return params
+}
+
+// scanClass records the class, interface or trait declared at token kw. Its
+// body is registered in classes so the members are scanned as its children.
+func (d *document) scanClass(kw int, topLevel bool, classes map[int]*symbol) {
+	name := d.tokens[kw+1].Value
+	kind, detail := symbolClass, "class "+name
+	if v := d.value(kw); v == "interface" || v == "trait" {
+		kind, detail = symbolInterface, v+" "+name
+	}
+	j := kw + 2
+	if d.value(j) == "extends" && d.isIdent(j+1) {
+		detail += " extends " + d.tokens[j+1].Value
+		j += 2
+	}
+	if d.value(j) == "implements" {
+		var names []string
+		for d.isIdent(j + 1) {
+			names = append(names, d.tokens[j+1].Value)
+			j += 2
+			if d.value(j) != "," {
+				break
+			}
+		}
+		detail += " implements " + strings.Join(names, ", ")
+	}
+	body := d.next(j)
+	if d.value(body) != "{" {
+		return
+	}
+	class := d.declare(kw+1, kind, detail, d.tokens[kw].Start, d.closing(body), len(d.text))
+	classes[body] = class
+	if topLevel {
+		d.symbols = append(d.symbols, class)
+	}
+}
+
+// scanEnum records the enum declared at token kw. Its members are only its
+// children: they are not visible as bare names.
+func (d *document) scanEnum(kw int, topLevel bool, scopeEnd int) {
+	name := d.tokens[kw+1].Value
+	body := d.next(kw + 2)
+	enum := d.declare(kw+1, symbolEnum, "enum "+name, d.tokens[kw].Start, d.closing(body), scopeEnd)
+	if topLevel {
+		d.symbols = append(d.symbols, enum)
+	}
+	end, ok := d.match[body]
+	if !ok {
+		end = len(d.tokens)
+	}
+	depth := 0
+	for j := body + 1; j < end; j++ {
+		switch d.value(j) {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if depth != 0 || !d.isIdent(j) {
+			continue
+		}
+		if prev := d.value(j - 1); prev != "{" && prev != "," && prev != "\n" {
+			continue
+		}
+		tok := d.tokens[j]
+		enum.children = append(enum.children, &symbol{
+			name:     tok.Value,
+			kind:     symbolEnumMember,
+			detail:   name + "." + tok.Value,
+			start:    tok.Start,
+			end:      tok.Pos,
+			declFrom: tok.Start,
+			declTo:   d.lineEnd(j),
+			scopeEnd: scopeEnd,
+			parent:   enum,
+		})
+	}
+}
+
+// enum returns the enum called name visible at offset, or nil.
+func (d *document) enum(name string, offset int) *symbol {
+	if sym := d.resolve(name, offset); sym != nil && sym.kind == symbolEnum {
+		return sym
+	}
+	return nil
+}
+
+// scanLet records the names declared by the let, var or const at token kw:
+// the first one and every ", name" at the same nesting level before the end
+// of the statement.
+func (d *document) scanLet(kw int, topLevel bool, scopeEnd int) {
+	keyword := d.value(kw)
+	kind := symbolVariable
+	if keyword == "const" {
+		kind = symbolConstant
+	}
+	end := d.lineEnd(kw)
+	add := func(i int) {
+		sym := d.declare(i, kind, keyword+" "+d.tokens[i].Value, d.tokens[kw].Start, end, scopeEnd)
+		if topLevel {
+			d.symbols = append(d.symbols, sym)
+		}
+	}
+	add(kw + 1)
+	depth := 0
+	for j := kw + 2; j < len(d.tokens); j++ {
+		v := d.value(j)
+		if depth == 0 && (v == "\n" || v == ";") {
+			break
+		}
+		switch v {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+		if depth == 0 && v == "," && d.isIdent(j+1) {
+			add(j + 1)
+		}
+	}
+}
+
+// scanImport records `import "path" as alias` and
+// `import { name, other as alias } from "path"` at token i. Standard library
+// imports ("std:json") declare their names but are not files.
+func (d *document) scanImport(i int) {
+	var names []int // Índices de los nombres que declara el import
+	imported := map[int]string{}
+	p := i + 1
+	selective := d.value(p) == "{"
+	if selective {
+		close, ok := d.match[p]
+		if !ok {
+			return
+		}
+		for j := p + 1; j < close; j++ {
+			if !d.isIdent(j) {
+				continue
+			}
+			name := d.tokens[j].Value
+			if j+2 < close && d.tokens[j+1].Type == r2core.TOKEN_AS && d.isIdent(j+2) {
+				j += 2
+			}
+			names = append(names, j)
+			imported[j] = name
+		}
+		if d.value(close+1) != "from" {
+			return
+		}
+		p = close + 2
+	}
+	if p >= len(d.tokens) || d.tokens[p].Type != r2core.TOKEN_STRING {
+		return
+	}
+	pathTok := d.tokens[p]
+	if !selective && p+2 < len(d.tokens) && d.tokens[p+1].Type == r2core.TOKEN_AS && d.isIdent(p+2) {
+		names = append(names, p+2)
+	}
+	imp := &importDecl{path: pathTok.Value, pathStart: pathTok.Start, pathEnd: pathTok.Pos}
+	for _, j := range names {
+		if !selective {
+			imp.alias = d.declare(j, symbolModule, fmt.Sprintf("import %q as %s", imp.path, d.tokens[j].Value),
+				d.tokens[i].Start, d.tokens[j].Pos, len(d.text))
+		} else {
+			sym := d.declare(j, symbolVariable, fmt.Sprintf("import { %s } from %q", d.tokens[j].Value, imp.path),
+				d.tokens[i].Start, pathTok.Pos, len(d.text))
+			if imp.names == nil {
+				imp.names = map[*symbol]string{}
+			}
+			imp.names[sym] = imported[j]
+		}
+	}
+	if strings.HasPrefix(imp.path, "std:") {
+		return
+	}
+	imp.resolved = d.resolveImport(imp.path)
+	d.imports = append(d.imports, imp)
+}
+
+// resolveImport returns the file an import of path reads: a file of a
+// package of the project (see r2mod), or path relative to the document.
+func (d *document) resolveImport(path string) string {
+	if r2core.IsPackageSpecifier(path) {
+		if !d.projectFound {
+			d.project, _ = r2mod.FindProject(filepath.Dir(d.path))
+			d.projectFound = true
+		}
+		if d.project != nil {
+			// Un paquete sin bajar se señala en los diagnósticos de CheckSource
+			if file, ok, _ := d.project.Resolve(path); ok {
+				return filepath.Clean(file)
+			}
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(d.path), path)
+	}
+	return filepath.Clean(path)
+}
+
+// next returns the index of the first token from i that is not a newline.
+func (d *document) next(i int) int {
+	for i < len(d.tokens) && d.value(i) == "\n" {
+		i++
+	}
+	return i
+}
+
+// skipType returns the index of the token after the type annotation
+// (": number[] | nil") starting at token i, or i when there is none.
+func (d *document) skipType(i int) int {
+	if d.value(i) != ":" {
+		return i
+	}
+	for {
+		i++ // ':' o '|'
+		if !d.isIdent(i) && (i >= len(d.tokens) || d.tokens[i].Type != r2core.TOKEN_NIL) {
+			return i
+		}
+		i++
+		for d.value(i) == "[" && d.value(i+1) == "]" {
+			i += 2
+		}
+		if d.value(i) != "|" {
+			return i
+		}
+	}
+}
+
+// skipTo returns the index of the ')' matching the '(' at open, so that the
+// scanner continues after the parameter list.
+func (d *document) skipTo(open int) int {
+	if j, ok := d.match[open]; ok {
+		return j
+	}
+	return open
+}
+
+// lineEnd returns the offset of the end of the line of token i.
+func (d *document) lineEnd(i int) int {
+	start := d.tokens[i].Start
+	if end := strings.IndexByte(d.text[start:], '\n'); end >= 0 {
+		return start + end
+	}
+	return len(d.text)
+}
+
+// tokenAt returns the index of the identifier, or failing that the string,
+// under offset, or -1.
+func (d *document) tokenAt(offset int) int {
+	found := -1
+	for i, tok := range d.tokens {
+		if tok.Start > offset {
+			break
+		}
+		if offset > tok.Pos {
+			continue
+		}
+		switch tok.Type {
+		case r2core.TOKEN_IDENT:
+			return i
+		case r2core.TOKEN_STRING:
+			found = i
+		}
+	}
+	return found
+}
+
+// receiver returns the identifier before the '.' that precedes token i, as
+// in receiver.member, or "".
+func (d *document) receiver(i int) string {
+	if d.value(i-1) == "." && d.isIdent(i-2) {
+		return d.tokens[i-2].Value
+	}
+	return ""
+}
+
+// resolve returns the declaration of name visible at offset: the innermost
+// one declared before offset, or a top-level one declared after it.
+func (d *document) resolve(name string, offset int) *symbol {
+	var best *symbol
+	for _, sym := range d.decls {
+		if sym.name != name || sym.kind == symbolMethod || sym.kind == symbolField {
+			continue
+		}
+		if sym.declFrom <= offset && offset <= sym.scopeEnd && (best == nil || sym.declFrom > best.declFrom) {
+			best = sym
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return d.topSymbol(name)
+}
+
+// topSymbol returns the top-level declaration called name, or nil.
+func (d *document) topSymbol(name string) *symbol {
+	for _, sym := range d.symbols {
+		if sym.name == name {
+			return sym
+		}
+	}
+	return nil
+}
+
+// exported returns the top-level declaration called name if a module that
+// imports the document can see it: it is exported, or the document exports
+// nothing. When the exports cannot be known (the text does not parse) every
+// top-level declaration counts.
+func (d *document) exported(name string) *symbol {
+	if !d.exportsFound {
+		if prog, errs := r2core.ParseWithErrors(d.text, d.path); len(errs) == 0 {
+			if names, ok := r2core.ExportedNames(prog); ok {
+				d.exports = names
+			}
+		}
+		d.exportsFound = true
+	}
+	if d.exports != nil && !d.exports[name] {
+		return nil
+	}
+	return d.topSymbol(name)
+}
+
+// importOf returns the selective import that declares sym and the name sym
+// has in the imported module, or nil.
+func (d *document) importOf(sym *symbol) (*importDecl, string) {
+	for _, imp := range d.imports {
+		if name, ok := imp.names[sym]; ok {
+			return imp, name
+		}
+	}
+	return nil, ""
+}
+
+// importByAlias returns the import whose alias is name, or nil.
+func (d *document) importByAlias(name string) *importDecl {
+	for _, imp := range d.imports {
+		if imp.alias != nil && imp.alias.name == name {
+			return imp
+		}
+	}
+	return nil
+}
+
+// declared reports whether the document declares name anywhere, so that it
+// shadows a builtin of the same name.
+func (d *document) declared(name string) bool {
+	for _, sym := range d.decls {
+		if sym.name == name && sym.kind != symbolMethod && sym.kind != symbolField {
+			return true
+		}
+	}
+	return false
+}
+
+// classAt returns the class whose body contains offset, or nil.
+func (d *document) classAt(offset int) *symbol {
+	for _, sym := range d.decls {
+		if (sym.kind == symbolClass || sym.kind == symbolInterface) && sym.declFrom <= offset && offset <= sym.declTo {
+			return sym
+		}
+	}
+	return nil
+}
+
+// members returns the methods and fields named name of every class.
+func (d *document) members(name string) []*symbol {
+	var found []*symbol
+	for _, sym := range d.decls {
+		if (sym.kind == symbolMethod || sym.kind == symbolField) && sym.name == name {
+			found = append(found, sym)
+		}
+	}
+	return found
+}
+
+// offsetOf converts an LSP position (UTF-16 columns) to a byte offset.
+func (d *document) offsetOf(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[pos.Line]
+	for units := 0; units < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// positionOf converts a byte offset to an LSP position.
+func (d *document) positionOf(offset int) position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := 0
+	for line+1 < len(d.lines) && d.lines[line+1] <= offset {
+		line++
+	}
+	units := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		units += utf16.RuneLen(r)
+	}
+	return position{Line: line, Character: units}
+}
+
+func (d *document) rangeOf(start, end int) lspRange {
+	return lspRange{Start: d.positionOf(start), End: d.positionOf(end)}
+}
+
+// errorRange returns the range highlighted for an error reported at offset,
+// the end of the offending token: the word ending there, or the character at
+// offset when there is none. An error at a newline is shown at the end of
+// its line.
+func (d *document) errorRange(offset int) lspRange {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	if offset > 0 && d.text[offset-1] == '\n' {
+		offset--
+	}
+	start := offset
+	for start > 0 && !strings.ContainsRune(" \t\r\n", rune(d.text[start-1])) {
+		start--
+	}
+	end := offset
+	if start == end && end < len(d.text) && d.text[end] != '\n' {
+		end++
+	}
+	return d.rangeOf(start, end)
+}
+
+func lastRune(s string) (rune, int) {
+	return utf8.DecodeLastRuneInString(s)
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
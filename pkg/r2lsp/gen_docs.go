@@ -0,0 +1,105 @@
+//go:build ignore
+
+// gen_docs genera builtin_docs.go a partir de las tablas de
+// docs/en/stdlib-reference.md. Ejecutar con go generate ./pkg/r2lsp.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Primera columna de una fila: `mod.name`, opcionalmente seguida de alias
+// (`mod.a` / `b` / `mod.c`)
+var nameCell = regexp.MustCompile("^`([A-Za-z0-9_]+)\\.([A-Za-z0-9_]+)`((?:\\s*/\\s*`[A-Za-z0-9_.]+`)*)$")
+var aliasName = regexp.MustCompile("`(?:([A-Za-z0-9_]+)\\.)?([A-Za-z0-9_]+)`")
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("usage: go run gen_docs.go stdlib-reference.md output.go")
+	}
+	data, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	docs := map[string][2]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	for scanner.Scan() {
+		cells := splitRow(scanner.Text())
+		if len(cells) < 3 {
+			continue
+		}
+		m := nameCell.FindStringSubmatch(cells[0])
+		if m == nil {
+			continue
+		}
+		module := m[1]
+		names := []string{m[2]}
+		for _, alias := range aliasName.FindAllStringSubmatch(m[3], -1) {
+			if alias[1] == "" || alias[1] == module {
+				names = append(names, alias[2])
+			}
+		}
+		signature := strings.Trim(cells[1], "`")
+		for _, name := range names {
+			full := module + "." + name
+			sig := signature
+			if strings.HasPrefix(sig, "(") {
+				sig = full + sig
+			}
+			if _, ok := docs[full]; !ok {
+				docs[full] = [2]string{sig, cells[2]}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_docs.go from docs/en/stdlib-reference.md; DO NOT EDIT.\n\n")
+	buf.WriteString("package r2lsp\n\n")
+	buf.WriteString("// builtinDocs maps module.member to its signature and description.\n")
+	buf.WriteString("var builtinDocs = map[string]builtinDoc{\n")
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "\t%q: {%q, %q},\n", key, docs[key][0], docs[key][1])
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(os.Args[2], src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// splitRow devuelve las celdas de una fila de tabla markdown, respetando los
+// "\|" escapados.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "|") || !strings.HasSuffix(line, "|") {
+		return nil
+	}
+	line = strings.ReplaceAll(line[1:len(line)-1], `\|`, "\x00")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(cell, "\x00", "|"))
+	}
+	return cells
+}
@@ -0,0 +1,656 @@
+// Package r2lsp implements a Language Server Protocol server for R2Lang:
+// diagnostics, go-to-definition, hover, completion and document symbols.
+//
+// The r2 command runs it with "r2 lsp", speaking JSON-RPC on stdin and
+// stdout; editors with a generic LSP client only need that command line and
+// the .r2 extension. The hover and completion details of the builtins come
+// from docs/en/stdlib-reference.md (see builtin_docs.go, regenerated with
+// go generate), and the list of modules and members from the libraries'
+// registration maps.
+package r2lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arturoeanton/go-r2lang/pkg/r2core"
+	"github.com/arturoeanton/go-r2lang/pkg/r2lang"
+)
+
+// keywords are offered by completion outside of a member access.
+var keywords = []string{
+	"let", "var", "const", "func", "function", "class", "extends", "return",
+	"if", "else", "while", "for", "in", "break", "continue", "try", "catch",
+	"finally", "throw", "import", "as", "match", "case", "switch", "default",
+	"enum", "static", "instanceof", "interface", "trait", "implements", "export", "from", "true", "false", "nil", "this", "super", "dsl", "use", "async", "await",
+	"yield",
+}
+
+// Server is an LSP server speaking JSON-RPC over a pair of streams, usually
+// stdin and stdout. Documents are synchronized in full.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document // Documentos abiertos, por URI
+	builtins *catalog
+	shutdown bool
+}
+
+// NewServer returns a server that reads requests from in and writes
+// responses and notifications to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: map[string]*document{},
+	}
+}
+
+// Serve handles messages until the client sends exit or closes the input.
+// It returns nil after an orderly shutdown.
+func (s *Server) Serve() error {
+	s.builtins = builtinCatalog()
+	for {
+		msg, err := readMessage(s.in)
+		if err != nil {
+			var rerr *responseError
+			if errors.As(err, &rerr) {
+				s.reply(nil, nil, rerr)
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				if s.shutdown {
+					return nil
+				}
+				return errors.New("the client closed the connection without shutdown")
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return errors.New("exit before shutdown")
+		}
+		result, rerr := s.handle(msg)
+		if msg.ID != nil {
+			s.reply(msg.ID, result, rerr)
+		}
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	resp := &message{ID: id, Result: result, Error: rerr}
+	if rerr == nil && result == nil {
+		// Una respuesta sin error debe llevar result, aunque sea null
+		resp.Result = json.RawMessage("null")
+	}
+	writeMessage(s.out, resp)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	data, _ := json.Marshal(params)
+	writeMessage(s.out, &message{Method: method, Params: data})
+}
+
+// handle dispatches msg and returns the result of a request.
+func (s *Server) handle(msg *message) (interface{}, *responseError) {
+	if s.shutdown && msg.ID != nil {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+	decode := func(v interface{}) *responseError {
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			return &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // Full
+				"hoverProvider":          true,
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"."},
+				},
+			},
+			"serverInfo": map[string]string{"name": "r2lsp"},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "textDocument/didSave":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Text, params.TextDocument.Version)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			// Sincronización completa: el último cambio es el texto entero
+			s.open(params.TextDocument.URI, params.ContentChanges[n-1].Text, params.TextDocument.Version)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}})
+		return nil, nil
+
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+		offset := doc.offsetOf(params.Position)
+		switch msg.Method {
+		case "textDocument/hover":
+			if h := s.hover(doc, offset); h != nil {
+				return h, nil
+			}
+		case "textDocument/definition":
+			if loc := s.definition(doc, offset); loc != nil {
+				return loc, nil
+			}
+		default:
+			return s.completion(doc, offset), nil
+		}
+		return nil, nil
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return []documentSymbol{}, nil
+		}
+		return documentSymbols(doc, doc.symbols), nil
+	}
+
+	if msg.ID == nil {
+		// Las notificaciones desconocidas se ignoran
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+}
+
+// open analyzes the text of uri and publishes its diagnostics.
+func (s *Server) open(uri, text string, version int) {
+	doc := newDocument(uri, uriToPath(uri), text, version)
+	s.docs[uri] = doc
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+// load returns the document of the file at path: the open one if there is
+// one, otherwise the file read from disk.
+func (s *Server) load(path string) *document {
+	for _, doc := range s.docs {
+		if doc.path == path {
+			return doc
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return newDocument(pathToURI(path), path, string(data), 0)
+}
+
+// diagnostics returns the problems of doc: the parse errors and import
+// problems found by r2lang.CheckSource, plus member accesses on builtin
+// modules and imported modules that do not exist.
+func (s *Server) diagnostics(doc *document) []diagnostic {
+	diags := []diagnostic{}
+	result := r2lang.CheckSource(doc.path, doc.text)
+	self := filepath.Clean(doc.path)
+	imported := map[string]*importDecl{}
+	for _, imp := range doc.imports {
+		imported[imp.resolved] = imp
+	}
+	for _, perr := range result.Diagnostics {
+		if perr.Position == nil {
+			continue
+		}
+		file := filepath.Clean(perr.Position.Filename)
+		if file == self {
+			diags = append(diags, diagnostic{
+				Range:    doc.errorRange(perr.Position.Pos),
+				Severity: severityError,
+				Source:   "r2",
+				Message:  perr.Message,
+			})
+		} else if imp := imported[file]; imp != nil {
+			// Los errores de un módulo importado se señalan en su import
+			diags = append(diags, diagnostic{
+				Range:    doc.rangeOf(imp.pathStart, imp.pathEnd),
+				Severity: severityError,
+				Source:   "r2",
+				Message:  fmt.Sprintf("%s:%d:%d: %s", filepath.Base(file), perr.Position.Line, perr.Position.Col, perr.Message),
+			})
+		}
+	}
+
+	modules := map[string]*document{}
+	for i := range doc.tokens {
+		if !doc.isIdent(i) || doc.value(i-1) != "." || !doc.isIdent(i-2) || doc.value(i-3) == "." {
+			continue
+		}
+		receiver, member := doc.tokens[i-2].Value, doc.tokens[i].Value
+		if imp := doc.importByAlias(receiver); imp != nil {
+			mod, ok := modules[imp.resolved]
+			if !ok {
+				mod = s.load(imp.resolved)
+				modules[imp.resolved] = mod
+			}
+			if mod != nil && mod.exported(member) == nil {
+				message := fmt.Sprintf("%s has no top-level declaration %s", filepath.Base(imp.resolved), member)
+				if mod.topSymbol(member) != nil {
+					message = fmt.Sprintf("%s does not export %s", filepath.Base(imp.resolved), member)
+				}
+				diags = append(diags, diagnostic{
+					Range:    doc.rangeOf(doc.tokens[i].Start, doc.tokens[i].Pos),
+					Severity: severityWarning,
+					Source:   "r2",
+					Message:  message,
+				})
+			}
+			continue
+		}
+		members, isModule := s.builtins.modules[receiver]
+		if !isModule || doc.declared(receiver) {
+			continue
+		}
+		if _, ok := members[member]; !ok {
+			diags = append(diags, diagnostic{
+				Range:    doc.rangeOf(doc.tokens[i].Start, doc.tokens[i].Pos),
+				Severity: severityWarning,
+				Source:   "r2",
+				Message:  fmt.Sprintf("module %s has no member %s", receiver, member),
+			})
+		}
+	}
+	return append(diags, enumMatchWarnings(doc)...)
+}
+
+// enumMatchWarnings warns about match expressions whose cases name members
+// of an enum declared in doc, but not all of them, and have no catch-all
+// case: a missing member fails at run time with "No matching case".
+func enumMatchWarnings(doc *document) []diagnostic {
+	var diags []diagnostic
+	for i, tok := range doc.tokens {
+		if tok.Type != r2core.TOKEN_MATCH {
+			continue
+		}
+		open := i + 1
+		for open < len(doc.tokens) && doc.value(open) != "{" && doc.value(open) != "\n" {
+			open++
+		}
+		end, ok := doc.match[open]
+		if !ok {
+			continue
+		}
+		var enum *symbol
+		handled := map[string]bool{}
+		covered := false // Un caso que acepta cualquier valor u otro patrón
+		depth := 0
+		for j := open + 1; j < end && !covered; j++ {
+			switch doc.value(j) {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			}
+			if depth != 0 || doc.tokens[j].Type != r2core.TOKEN_CASE {
+				continue
+			}
+			if doc.isIdent(j+1) && doc.value(j+2) == "." && doc.isIdent(j+3) {
+				sym := doc.enum(doc.value(j+1), doc.tokens[j+1].Start)
+				if sym == nil || (enum != nil && sym != enum) {
+					covered = true
+					break
+				}
+				enum = sym
+				// Un caso con guard no cubre el miembro
+				if doc.value(j+4) == "=>" {
+					handled[doc.value(j+3)] = true
+				}
+				continue
+			}
+			covered = true
+		}
+		if covered || enum == nil {
+			continue
+		}
+		var missing []string
+		for _, member := range enum.children {
+			if !handled[member.name] {
+				missing = append(missing, member.name)
+			}
+		}
+		if len(missing) > 0 {
+			diags = append(diags, diagnostic{
+				Range:    doc.rangeOf(tok.Start, tok.Pos),
+				Severity: severityWarning,
+				Source:   "r2",
+				Message:  fmt.Sprintf("match on %s does not handle %s", enum.name, strings.Join(missing, ", ")),
+			})
+		}
+	}
+	return diags
+}
+
+// definition returns the location of the declaration under offset.
+func (s *Server) definition(doc *document, offset int) *location {
+	i := doc.tokenAt(offset)
+	if i < 0 {
+		return nil
+	}
+	for _, imp := range doc.imports {
+		if doc.tokens[i].Start == imp.pathStart {
+			if _, err := os.Stat(imp.resolved); err != nil {
+				return nil
+			}
+			return &location{URI: pathToURI(imp.resolved)}
+		}
+	}
+	if !doc.isIdent(i) {
+		return nil
+	}
+	sym, target := s.lookup(doc, i)
+	if sym == nil {
+		return nil
+	}
+	// Un nombre de un import selectivo se declara en el módulo importado
+	if imp, name := target.importOf(sym); imp != nil {
+		if mod := s.load(imp.resolved); mod != nil {
+			if decl := mod.exported(name); decl != nil {
+				sym, target = decl, mod
+			}
+		}
+	}
+	return &location{URI: target.uri, Range: target.rangeOf(sym.start, sym.end)}
+}
+
+// lookup returns the declaration of the identifier at token i and the
+// document that declares it.
+func (s *Server) lookup(doc *document, i int) (*symbol, *document) {
+	name := doc.tokens[i].Value
+	offset := doc.tokens[i].Start
+
+	if receiver := doc.receiver(i); receiver != "" {
+		if imp := doc.importByAlias(receiver); imp != nil {
+			if mod := s.load(imp.resolved); mod != nil {
+				if sym := mod.exported(name); sym != nil {
+					return sym, mod
+				}
+			}
+			return nil, nil
+		}
+		if receiver == "this" {
+			if class := doc.classAt(offset); class != nil {
+				for _, member := range class.children {
+					if member.name == name {
+						return member, doc
+					}
+				}
+			}
+		}
+		if members := doc.members(name); len(members) > 0 {
+			return members[0], doc
+		}
+		return nil, nil
+	}
+
+	if sym := doc.resolve(name, offset); sym != nil {
+		return sym, doc
+	}
+	return nil, nil
+}
+
+// hover returns the hover of the identifier under offset.
+func (s *Server) hover(doc *document, offset int) *hover {
+	i := doc.tokenAt(offset)
+	if i < 0 || !doc.isIdent(i) {
+		return nil
+	}
+	tok := doc.tokens[i]
+	rng := doc.rangeOf(tok.Start, tok.Pos)
+	markdown := func(text string) *hover {
+		return &hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: &rng}
+	}
+
+	if sym, target := s.lookup(doc, i); sym != nil {
+		text := "```r2\n" + sym.detail + "\n```"
+		if target != doc {
+			text += "\nDeclared in " + filepath.Base(target.path) + "."
+		}
+		if sym.kind == symbolModule {
+			for _, imp := range doc.imports {
+				if imp.alias == sym {
+					text += "\nModule " + imp.resolved + "."
+				}
+			}
+		}
+		return markdown(text)
+	}
+
+	receiver := doc.receiver(i)
+	if receiver != "" && doc.declared(receiver) {
+		return nil
+	}
+	if text, ok := s.builtins.describe(receiver, tok.Value); ok {
+		return markdown(text)
+	}
+	return nil
+}
+
+// completion returns the completion items at offset: the members of the
+// module, import or class before a '.', or the names in scope.
+func (s *Server) completion(doc *document, offset int) completionList {
+	items := []completionItem{}
+	receiver, dotted := memberContext(doc.text, offset)
+
+	if dotted {
+		seen := map[string]bool{}
+		addSymbol := func(sym *symbol) {
+			if !seen[sym.name] {
+				seen[sym.name] = true
+				items = append(items, completionItem{Label: sym.name, Kind: completionKind(sym.kind), Detail: sym.detail})
+			}
+		}
+		switch {
+		case receiver == "":
+		case doc.importByAlias(receiver) != nil:
+			if mod := s.load(doc.importByAlias(receiver).resolved); mod != nil {
+				for _, sym := range mod.symbols {
+					if mod.exported(sym.name) == sym {
+						addSymbol(sym)
+					}
+				}
+			}
+		case s.builtins.modules[receiver] != nil && !doc.declared(receiver):
+			for _, name := range s.builtins.members(receiver) {
+				item := completionItem{Label: name, Kind: builtinKind(s.builtins.modules[receiver][name])}
+				if bd, ok := builtinDocs[receiver+"."+name]; ok {
+					item.Detail = bd.signature
+					item.Documentation = &markupContent{Kind: "markdown", Value: bd.description}
+				}
+				items = append(items, item)
+			}
+		case doc.enum(receiver, offset) != nil:
+			enum := doc.enum(receiver, offset)
+			for _, member := range enum.children {
+				addSymbol(member)
+			}
+			for _, method := range []string{"values", "valueOf", "fromValue"} {
+				items = append(items, completionItem{Label: method, Kind: completionMethod, Detail: enum.name + "." + method + "()"})
+			}
+		case receiver == "this" && doc.classAt(offset) != nil:
+			for _, member := range doc.classAt(offset).children {
+				addSymbol(member)
+			}
+		default:
+			// Puede ser cualquier instancia: ofrecemos los miembros de todas las clases
+			for _, sym := range doc.decls {
+				if sym.kind == symbolMethod || sym.kind == symbolField {
+					addSymbol(sym)
+				}
+			}
+		}
+		return completionList{Items: items}
+	}
+
+	seen := map[string]bool{}
+	for _, sym := range doc.decls {
+		if sym.kind == symbolMethod || sym.kind == symbolField || seen[sym.name] {
+			continue
+		}
+		if sym.declFrom <= offset && offset <= sym.scopeEnd || doc.topSymbol(sym.name) == sym {
+			seen[sym.name] = true
+			items = append(items, completionItem{Label: sym.name, Kind: completionKind(sym.kind), Detail: sym.detail})
+		}
+	}
+	globals := make([]string, 0, len(s.builtins.globals))
+	for name := range s.builtins.globals {
+		globals = append(globals, name)
+	}
+	sort.Strings(globals)
+	for _, name := range globals {
+		if !seen[name] {
+			seen[name] = true
+			items = append(items, completionItem{Label: name, Kind: builtinKind(s.builtins.globals[name]), Detail: "builtin " + s.builtins.globals[name]})
+		}
+	}
+	for _, kw := range keywords {
+		if !seen[kw] {
+			items = append(items, completionItem{Label: kw, Kind: completionKeyword})
+		}
+	}
+	return completionList{Items: items}
+}
+
+// memberContext reports whether the identifier being typed at offset follows
+// a '.', and the identifier before the '.'.
+func memberContext(text string, offset int) (receiver string, dotted bool) {
+	start := identStart(text, offset)
+	if start == 0 || text[start-1] != '.' {
+		return "", false
+	}
+	end := start - 1
+	return text[identStart(text, end):end], true
+}
+
+// identStart returns the offset where the identifier ending at offset begins.
+func identStart(text string, offset int) int {
+	for offset > 0 {
+		r, size := lastRune(text[:offset])
+		if !isIdentRune(r) {
+			break
+		}
+		offset -= size
+	}
+	return offset
+}
+
+func completionKind(kind int) int {
+	switch kind {
+	case symbolFunction:
+		return completionFunction
+	case symbolMethod:
+		return completionMethod
+	case symbolClass:
+		return completionClass
+	case symbolField:
+		return completionField
+	case symbolModule:
+		return completionModule
+	case symbolConstant:
+		return completionConstant
+	case symbolInterface:
+		return completionInterface
+	case symbolEnum:
+		return completionEnum
+	case symbolEnumMember:
+		return completionEnumMember
+	}
+	return completionVariable
+}
+
+func builtinKind(kind string) int {
+	switch kind {
+	case kindFunction:
+		return completionFunction
+	case kindModule:
+		return completionModule
+	case kindClass:
+		return completionClass
+	}
+	return completionConstant
+}
+
+// documentSymbols converts symbols to the LSP outline.
+func documentSymbols(doc *document, symbols []*symbol) []documentSymbol {
+	out := make([]documentSymbol, 0, len(symbols))
+	for _, sym := range symbols {
+		out = append(out, documentSymbol{
+			Name:           sym.name,
+			Detail:         sym.detail,
+			Kind:           sym.kind,
+			Range:          doc.rangeOf(sym.declFrom, sym.declTo),
+			SelectionRange: doc.rangeOf(sym.start, sym.end),
+			Children:       documentSymbols(doc, sym.children),
+		})
+	}
+	return out
+}
+
+// uriToPath converts a file:// URI to a path. Other URIs are returned as is.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
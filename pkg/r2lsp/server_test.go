@@ -0,0 +1,557 @@
+package r2lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const utilSource = `func twice(x) {
+    return x * 2
+}
+
+class Counter {
+    let count = 0
+    inc() {
+        this.count = this.count + 1
+    }
+}
+`
+
+const mainSource = `import "util.r2" as util
+let total = util.twice(21)
+func show(value) {
+    std.print(value)
+    io.nope()
+    util.missing()
+}
+show(total)
+let broken = (
+`
+
+// session runs the server over the requests and returns the messages it
+// wrote, responses keyed by id and notifications in order.
+type session struct {
+	responses     map[int]*message
+	notifications []*message
+}
+
+func runSession(t *testing.T, requests ...*message) *session {
+	t.Helper()
+	var in bytes.Buffer
+	for _, req := range requests {
+		if err := writeMessage(&in, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMessage(&in, request(0, "shutdown", nil))
+	writeMessage(&in, request(-1, "exit", nil))
+
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Serve(); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	s := &session{responses: map[int]*message{}}
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		if msg.ID == nil {
+			s.notifications = append(s.notifications, msg)
+			continue
+		}
+		var id int
+		json.Unmarshal(*msg.ID, &id)
+		s.responses[id] = msg
+	}
+	return s
+}
+
+// request builds a request with id, or a notification when id is -1.
+func request(id int, method string, params interface{}) *message {
+	msg := &message{Method: method}
+	if params != nil {
+		msg.Params, _ = json.Marshal(params)
+	}
+	if id >= 0 {
+		raw := json.RawMessage(strings.TrimSpace(string(mustJSON(id))))
+		msg.ID = &raw
+	}
+	return msg
+}
+
+func mustJSON(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// result decodes the result of response id into v.
+func (s *session) result(t *testing.T, id int, v interface{}) {
+	t.Helper()
+	resp := s.responses[id]
+	if resp == nil {
+		t.Fatalf("no response for request %d", id)
+	}
+	if resp.Error != nil {
+		t.Fatalf("request %d failed: %s", id, resp.Error.Message)
+	}
+	if err := json.Unmarshal(mustJSON(resp.Result), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// at returns the position of the n-th byte of the first occurrence of
+// substr in text.
+func at(text, substr string, n int) position {
+	offset := strings.Index(text, substr) + n
+	line := strings.Count(text[:offset], "\n")
+	return position{Line: line, Character: offset - strings.LastIndex(text[:offset], "\n") - 1}
+}
+
+func openMain(t *testing.T) (dir, uri string, open *message) {
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "util.r2"), []byte(utilSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri = pathToURI(filepath.Join(dir, "main.r2"))
+	open = request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: mainSource}})
+	return dir, uri, open
+}
+
+func positionParams(uri string, pos position) textDocumentPositionParams {
+	return textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: uri}, Position: pos}
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	_, uri, open := openMain(t)
+	s := runSession(t, request(1, "initialize", map[string]interface{}{}), open)
+
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	s.result(t, 1, &init)
+	if init.Capabilities["hoverProvider"] != true || init.Capabilities["definitionProvider"] != true {
+		t.Errorf("unexpected capabilities: %v", init.Capabilities)
+	}
+
+	if len(s.notifications) != 1 || s.notifications[0].Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected one publishDiagnostics, got %d notifications", len(s.notifications))
+	}
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	if params.URI != uri {
+		t.Errorf("expected diagnostics for %s, got %s", uri, params.URI)
+	}
+	var messages []string
+	for _, d := range params.Diagnostics {
+		messages = append(messages, d.Message)
+	}
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{"module io has no member nope", "util.r2 has no top-level declaration missing"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected a diagnostic %q, got:\n%s", want, joined)
+		}
+	}
+	var parseErrors int
+	for _, d := range params.Diagnostics {
+		if d.Severity == severityError {
+			parseErrors++
+			if d.Range.Start.Line != 8 {
+				t.Errorf("expected the parse error on line 8, got %+v", d.Range)
+			}
+		}
+	}
+	if parseErrors != 1 {
+		t.Errorf("expected one parse error, got %d:\n%s", parseErrors, joined)
+	}
+}
+
+func TestServer_Definition(t *testing.T) {
+	dir, uri, open := openMain(t)
+	s := runSession(t, open,
+		request(1, "textDocument/definition", positionParams(uri, at(mainSource, "twice(21)", 2))),
+		request(2, "textDocument/definition", positionParams(uri, at(mainSource, "print(value)", 7))),
+		request(3, "textDocument/definition", positionParams(uri, at(mainSource, "show(total)", 1))),
+		request(4, "textDocument/definition", positionParams(uri, at(mainSource, "\"util.r2\"", 3))),
+		request(5, "textDocument/definition", positionParams(uri, at(mainSource, "std.print", 5))),
+	)
+
+	var loc location
+	s.result(t, 1, &loc)
+	if loc.URI != pathToURI(filepath.Join(dir, "util.r2")) || loc.Range.Start != (position{Line: 0, Character: 5}) {
+		t.Errorf("expected twice in util.r2, got %+v", loc)
+	}
+	s.result(t, 2, &loc)
+	if loc.URI != uri || loc.Range.Start != at(mainSource, "value)", 0) {
+		t.Errorf("expected the parameter value, got %+v", loc)
+	}
+	s.result(t, 3, &loc)
+	if loc.Range.Start != at(mainSource, "show(value)", 0) {
+		t.Errorf("expected the function show, got %+v", loc)
+	}
+	s.result(t, 4, &loc)
+	if loc.URI != pathToURI(filepath.Join(dir, "util.r2")) {
+		t.Errorf("expected the imported file, got %+v", loc)
+	}
+	if string(mustJSON(s.responses[5].Result)) != "null" {
+		t.Errorf("expected no definition for a builtin, got %s", mustJSON(s.responses[5].Result))
+	}
+}
+
+func TestServer_HoverAndCompletion(t *testing.T) {
+	_, uri, open := openMain(t)
+	s := runSession(t, open,
+		request(1, "textDocument/hover", positionParams(uri, at(mainSource, "print(value)", 1))),
+		request(2, "textDocument/hover", positionParams(uri, at(mainSource, "twice(21)", 1))),
+		request(3, "textDocument/completion", positionParams(uri, at(mainSource, "nope", 0))),
+		request(4, "textDocument/completion", positionParams(uri, at(mainSource, "missing", 0))),
+		request(5, "textDocument/completion", positionParams(uri, at(mainSource, "show(total)", 0))),
+	)
+
+	var h hover
+	s.result(t, 1, &h)
+	if !strings.Contains(h.Contents.Value, "std.print(") {
+		t.Errorf("expected the signature of std.print, got %q", h.Contents.Value)
+	}
+	s.result(t, 2, &h)
+	if !strings.Contains(h.Contents.Value, "func twice(x)") || !strings.Contains(h.Contents.Value, "util.r2") {
+		t.Errorf("expected the imported declaration, got %q", h.Contents.Value)
+	}
+
+	labels := func(id int) map[string]completionItem {
+		var list completionList
+		s.result(t, id, &list)
+		items := map[string]completionItem{}
+		for _, item := range list.Items {
+			items[item.Label] = item
+		}
+		return items
+	}
+	io := labels(3)
+	if item, ok := io["readFile"]; !ok || !strings.HasPrefix(item.Detail, "io.readFile(") {
+		t.Errorf("expected io.readFile with its signature, got %+v", item)
+	}
+	if _, ok := io["print"]; ok {
+		t.Error("expected only the members of io")
+	}
+	util := labels(4)
+	if _, ok := util["twice"]; !ok || len(util) != 2 {
+		t.Errorf("expected the top-level declarations of util.r2, got %v", util)
+	}
+	global := labels(5)
+	for _, name := range []string{"show", "total", "util", "std", "while"} {
+		if _, ok := global[name]; !ok {
+			t.Errorf("expected %s in the completion", name)
+		}
+	}
+	if _, ok := global["value"]; ok {
+		t.Error("expected the parameter value to be out of scope")
+	}
+}
+
+func TestServer_DocumentSymbols(t *testing.T) {
+	dir := t.TempDir()
+	uri := pathToURI(filepath.Join(dir, "util.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: utilSource}}),
+		request(1, "textDocument/documentSymbol", documentSymbolParams{TextDocument: textDocumentIdentifier{URI: uri}}),
+		request(2, "textDocument/unknownMethod", map[string]interface{}{}),
+	)
+
+	var symbols []documentSymbol
+	s.result(t, 1, &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "twice" || symbols[0].Kind != symbolFunction ||
+		symbols[1].Name != "Counter" || symbols[1].Kind != symbolClass {
+		t.Fatalf("expected twice and Counter, got %+v", symbols)
+	}
+	children := symbols[1].Children
+	if len(children) != 2 || children[0].Name != "count" || children[1].Name != "inc" || children[1].Kind != symbolMethod {
+		t.Errorf("expected the members of Counter, got %+v", children)
+	}
+	if symbols[1].Range.End.Line != 9 {
+		t.Errorf("expected the class to end on line 9, got %+v", symbols[1].Range)
+	}
+
+	if resp := s.responses[2]; resp == nil || resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+		t.Errorf("expected MethodNotFound for an unknown request, got %+v", resp)
+	}
+}
+
+func TestServer_Enums(t *testing.T) {
+	const source = `enum Status {
+    Active,
+    Suspended = "S"
+    Closed
+}
+let a = match s {
+    case Status.Active => 1
+    case Status.Suspended if ok => 2
+}
+let b = match s {
+    case Status.Active => 1
+    case _ => 2
+}
+Status.
+`
+	uri := pathToURI(filepath.Join(t.TempDir(), "enums.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/documentSymbol", documentSymbolParams{TextDocument: textDocumentIdentifier{URI: uri}}),
+		request(2, "textDocument/completion", positionParams(uri, at(source, "Status.\n", 7))),
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	var warnings []diagnostic
+	for _, d := range params.Diagnostics {
+		if d.Severity == severityWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Message != "match on Status does not handle Suspended, Closed" || warnings[0].Range.Start.Line != 5 {
+		t.Errorf("expected one exhaustiveness warning on the first match, got %+v", warnings)
+	}
+
+	var symbols []documentSymbol
+	s.result(t, 1, &symbols)
+	if len(symbols) < 1 || symbols[0].Name != "Status" || symbols[0].Kind != symbolEnum || len(symbols[0].Children) != 3 {
+		t.Fatalf("expected the enum Status with its members, got %+v", symbols)
+	}
+
+	var list completionList
+	s.result(t, 2, &list)
+	labels := map[string]int{}
+	for _, item := range list.Items {
+		labels[item.Label] = item.Kind
+	}
+	if labels["Closed"] != completionEnumMember || labels["values"] != completionMethod {
+		t.Errorf("expected the members and methods of Status, got %v", labels)
+	}
+}
+
+func TestServer_ClassMemberModifiers(t *testing.T) {
+	const source = `class Temp {
+    static let unit = "C"
+    let #celsius = 0
+    get celsius() { return this.#celsius }
+    set celsius(v) { this.#celsius = v }
+    static async load() {}
+}
+`
+	uri := pathToURI(filepath.Join(t.TempDir(), "temp.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/documentSymbol", documentSymbolParams{TextDocument: textDocumentIdentifier{URI: uri}}),
+	)
+
+	var symbols []documentSymbol
+	s.result(t, 1, &symbols)
+	if len(symbols) != 1 || symbols[0].Name != "Temp" {
+		t.Fatalf("expected the class Temp, got %+v", symbols)
+	}
+	var names []string
+	for _, c := range symbols[0].Children {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, " "); got != "unit #celsius celsius celsius load" {
+		t.Errorf("expected the fields, accessors and static method of Temp, got %s", got)
+	}
+}
+
+func TestServer_Interfaces(t *testing.T) {
+	const source = `trait Plugin {
+    name()
+    describe() { return this.name() }
+}
+class Echo implements Plugin, Closeable {
+    name() { return "echo" }
+}
+`
+	uri := pathToURI(filepath.Join(t.TempDir(), "plugin.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/documentSymbol", documentSymbolParams{TextDocument: textDocumentIdentifier{URI: uri}}),
+	)
+
+	var symbols []documentSymbol
+	s.result(t, 1, &symbols)
+	if len(symbols) != 2 || symbols[0].Kind != symbolInterface || symbols[0].Detail != "trait Plugin" ||
+		symbols[1].Detail != "class Echo implements Plugin, Closeable" {
+		t.Fatalf("expected the trait Plugin and the class Echo, got %+v", symbols)
+	}
+	if children := symbols[0].Children; len(children) != 2 || children[0].Name != "name" || children[1].Name != "describe" {
+		t.Errorf("expected the required and default methods of Plugin, got %+v", children)
+	}
+}
+
+func TestServer_SelectiveImports(t *testing.T) {
+	const source = `import { twice as double } from "util.r2"
+import "std:json" as j
+std.print(double(2), j.stringify(1))
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "util.r2"), []byte(utilSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri := pathToURI(filepath.Join(dir, "main.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/hover", positionParams(uri, at(source, "double(2)", 1))),
+		request(2, "textDocument/definition", positionParams(uri, at(source, "j.stringify", 0))),
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", params.Diagnostics)
+	}
+	var h hover
+	s.result(t, 1, &h)
+	if !strings.Contains(h.Contents.Value, `import { double } from "util.r2"`) {
+		t.Errorf("expected the selective import, got %q", h.Contents.Value)
+	}
+	var loc location
+	s.result(t, 2, &loc)
+	if loc.URI != uri || loc.Range.Start != at(source, "j\n", 0) {
+		t.Errorf("expected the alias of the std import, got %+v", loc)
+	}
+}
+
+func TestServer_ModuleExports(t *testing.T) {
+	const util = `export func twice(x) { return helper(x) }
+func helper(x) { return x * 2 }
+`
+	const source = `import { twice } from "util.r2"
+import "util.r2" as u
+std.print(twice(1), u.twice(2), u.helper(3))
+u.
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "util.r2"), []byte(util), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri := pathToURI(filepath.Join(dir, "main.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/completion", positionParams(uri, at(source, "u.\n", 2))),
+		request(2, "textDocument/definition", positionParams(uri, at(source, "twice(1)", 0))),
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	// The first one is the syntax error of the unfinished "u."
+	if len(params.Diagnostics) != 2 || params.Diagnostics[1].Message != "util.r2 does not export helper" {
+		t.Errorf("expected a warning for u.helper, got %+v", params.Diagnostics)
+	}
+	var list completionList
+	s.result(t, 1, &list)
+	if len(list.Items) != 1 || list.Items[0].Label != "twice" {
+		t.Errorf("expected only the exported twice, got %+v", list.Items)
+	}
+	var loc location
+	s.result(t, 2, &loc)
+	if loc.URI != pathToURI(filepath.Join(dir, "util.r2")) || loc.Range.Start != (position{Line: 0, Character: 12}) {
+		t.Errorf("expected twice in util.r2, got %+v", loc)
+	}
+}
+
+func TestServer_PackageImports(t *testing.T) {
+	const source = `import "colors" as c
+c.red("x")
+`
+	dir := t.TempDir()
+	files := map[string]string{
+		"r2.mod":                     "require colors 1.0.0\n",
+		"r2_modules/colors/index.r2": "func red(s) { return s }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := pathToURI(filepath.Join(dir, "main.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/definition", positionParams(uri, at(source, "colors", 1))),
+		request(2, "textDocument/definition", positionParams(uri, at(source, "red", 1))),
+	)
+
+	var loc location
+	s.result(t, 1, &loc)
+	if loc.URI != pathToURI(filepath.Join(dir, "r2_modules", "colors", "index.r2")) {
+		t.Errorf("expected the main file of the package, got %+v", loc)
+	}
+	s.result(t, 2, &loc)
+	if loc.URI != pathToURI(filepath.Join(dir, "r2_modules", "colors", "index.r2")) || loc.Range.Start != (position{Line: 0, Character: 5}) {
+		t.Errorf("expected red in the package, got %+v", loc)
+	}
+}
+
+func TestServer_TypedFunctions(t *testing.T) {
+	const source = `func area(w: number, h?: number[] | nil): number {
+    return w * h
+}
+std.print(area(1))
+`
+	uri := pathToURI(filepath.Join(t.TempDir(), "main.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/hover", positionParams(uri, at(source, "area(1)", 1))),
+		request(2, "textDocument/hover", positionParams(uri, at(source, "h\n", 0))),
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", params.Diagnostics)
+	}
+	var h hover
+	s.result(t, 1, &h)
+	if !strings.Contains(h.Contents.Value, "func area(w, h)") {
+		t.Errorf("expected the annotated function, got %q", h.Contents.Value)
+	}
+	s.result(t, 2, &h)
+	if !strings.Contains(h.Contents.Value, "parameter h of area") {
+		t.Errorf("expected the parameter, got %q", h.Contents.Value)
+	}
+}
+
+func TestServer_TypedCatch(t *testing.T) {
+	const source = `try {
+    throw TypeError("bad")
+} catch (e: TypeError | ArgumentError) {
+    std.print(e.message)
+}
+`
+	uri := pathToURI(filepath.Join(t.TempDir(), "main.r2"))
+	s := runSession(t,
+		request(-1, "textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: source}}),
+		request(1, "textDocument/hover", positionParams(uri, at(source, "e.message", 0))),
+		request(2, "textDocument/hover", positionParams(uri, at(source, "TypeError(", 0))),
+	)
+
+	var params publishDiagnosticsParams
+	json.Unmarshal(s.notifications[0].Params, &params)
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", params.Diagnostics)
+	}
+	var h hover
+	s.result(t, 1, &h)
+	if !strings.Contains(h.Contents.Value, "catch (e: TypeError | ArgumentError)") {
+		t.Errorf("expected the catch variable, got %q", h.Contents.Value)
+	}
+	s.result(t, 2, &h)
+	if !strings.Contains(h.Contents.Value, "Builtin class") {
+		t.Errorf("expected the builtin error class, got %q", h.Contents.Value)
+	}
+}
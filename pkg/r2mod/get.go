@@ -0,0 +1,451 @@
+package r2mod
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RegistryEnv names the environment variable with the registry directory
+// used when the manifest does not declare one.
+const RegistryEnv = "R2_REGISTRY"
+
+// ParseSpec parses a package given to "r2 get": a git URL, a directory or the
+// name of a registry package, optionally followed by @version. The name of
+// the package is the last element of the URL or directory, without ".git".
+func ParseSpec(spec string) (Require, error) {
+	source, version := spec, ""
+	if i := strings.LastIndex(spec, "@"); i > 0 && i > strings.LastIndexAny(spec, "/:") {
+		source, version = spec[:i], spec[i+1:]
+	}
+	if strings.HasPrefix(source, "-") || strings.HasPrefix(version, "-") {
+		return Require{}, fmt.Errorf("a source or version cannot start with \"-\": %s", spec)
+	}
+	req := Require{Name: source, Version: version}
+	switch {
+	case isGitURL(source):
+		// https://host/acme/strutil.git, git@host:acme/strutil, ../strutil/.git
+		name := strings.TrimSuffix(strings.TrimSuffix(source, "/"), ".git")
+		name = strings.TrimSuffix(name, "/")
+		req.Name, req.Source = name[strings.LastIndexAny(name, "/:")+1:], source
+	case isDirPath(source):
+		if version != "" {
+			return Require{}, fmt.Errorf("a directory package has no version: %s", spec)
+		}
+		req.Name, req.Source = filepath.Base(filepath.Clean(source)), source
+	}
+	if !validName(req.Name) {
+		return Require{}, fmt.Errorf("cannot name a package after %q", spec)
+	}
+	return req, nil
+}
+
+// Get adds the packages in specs to the manifest, then fetches every package
+// the project needs, with their own requirements, into r2_modules and
+// writes r2.mod and r2.lock. Packages already fetched at their locked
+// version are kept; a fetched package whose checksum does not match r2.lock
+// is an error. Progress is written to out.
+func (p *Project) Get(specs []string, out io.Writer) error {
+	for _, spec := range specs {
+		req, err := ParseSpec(spec)
+		if err != nil {
+			return err
+		}
+		if req.Kind() == "dir" {
+			if req.Source, err = p.relative(req.Source); err != nil {
+				return err
+			}
+		}
+		if req.Kind() == "registry" && req.Version == "" {
+			if req.Version, err = p.latest(req.Name); err != nil {
+				return err
+			}
+		}
+		p.Manifest.SetRequire(req)
+	}
+
+	// Los requires del proyecto primero: ganan a los de sus paquetes
+	queue := append([]Require{}, p.Manifest.Requires...)
+	requiredBy := map[string]string{}
+	for _, req := range queue {
+		requiredBy[req.Name] = ManifestFile
+	}
+	installed := map[string]Require{}
+	lock := &Lock{Entries: map[string]LockEntry{}}
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+		if prev, done := installed[req.Name]; done {
+			if prev != req && requiredBy[req.Name] != ManifestFile {
+				return fmt.Errorf("conflicting requirements for %s: %s and %s", req.Name, prev, req)
+			}
+			continue
+		}
+		entry, err := p.install(req, out)
+		if err != nil {
+			return err
+		}
+		installed[req.Name] = req
+		lock.Entries[req.Name] = entry
+
+		deps, err := ReadManifest(filepath.Join(p.PackageDir(req.Name), ManifestFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if deps != nil {
+			base, err := p.sourceDir(req)
+			if err != nil {
+				return err
+			}
+			for _, dep := range deps.Requires {
+				if dep, err = p.rebase(dep, base); err != nil {
+					return err
+				}
+				if _, known := requiredBy[dep.Name]; !known {
+					requiredBy[dep.Name] = req.Name
+				}
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	// Los paquetes que ya no se usan se borran
+	for name := range p.Lock.Entries {
+		if _, used := lock.Entries[name]; !used {
+			if err := os.RemoveAll(p.PackageDir(name)); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "removed %s\n", name)
+		}
+	}
+	p.Lock = lock
+	p.mu.Lock()
+	p.verified = map[string]error{}
+	p.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(p.Dir, ManifestFile), p.Manifest.Format(), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(p.Dir, LockFile), lock.Format(), 0644)
+}
+
+// install fetches req into r2_modules unless it is already there at the
+// version of r2.lock, and returns its lock entry.
+func (p *Project) install(req Require, out io.Writer) (LockEntry, error) {
+	entry := LockEntry{Name: req.Name, Source: req.Source, Version: req.Version}
+	switch req.Kind() {
+	case "registry":
+		entry.Source = registrySource
+	case "dir":
+		entry.Version = "-"
+	}
+
+	// Un paquete git se vuelve a bajar en el commit del lock mientras
+	// r2.mod pida la misma ref
+	locked, isLocked := p.Lock.Entries[req.Name]
+	commit := ""
+	if isLocked && locked.Source == entry.Source {
+		switch req.Kind() {
+		case "git":
+			ref, lockedCommit, _ := strings.Cut(locked.Version, "@")
+			isLocked = ref == gitRef(req)
+			if isLocked {
+				commit = lockedCommit
+			}
+		case "registry":
+			isLocked = locked.Version == entry.Version
+		case "dir":
+			// Un directorio local se copia siempre: su contenido es el que vale
+			isLocked = false
+		}
+	} else {
+		isLocked = false
+	}
+
+	dir := p.PackageDir(req.Name)
+	if isLocked {
+		if sum, err := HashDir(dir); err == nil && sum == locked.Sum {
+			return locked, nil
+		}
+	}
+
+	version, err := p.fetch(req, commit, dir)
+	if err != nil {
+		return LockEntry{}, fmt.Errorf("fetching %s: %w", req.Name, err)
+	}
+	if req.Kind() == "git" {
+		entry.Version = gitRef(req) + "@" + version
+	}
+	if entry.Sum, err = HashDir(dir); err != nil {
+		return LockEntry{}, err
+	}
+	if isLocked && entry.Sum != locked.Sum {
+		os.RemoveAll(dir)
+		return LockEntry{}, fmt.Errorf("checksum mismatch for package %s: r2.lock has %s, fetched %s", req.Name, locked.Sum, entry.Sum)
+	}
+	fmt.Fprintf(out, "fetched %s\n", strings.Join(strings.Fields(req.Name+" "+req.Source+" "+strings.TrimSuffix(entry.Version, "-")), " "))
+	return entry, nil
+}
+
+// gitRef returns the ref a git require asks for, HEAD by default.
+func gitRef(req Require) string {
+	if req.Version == "" {
+		return "HEAD"
+	}
+	return req.Version
+}
+
+// fetch replaces dir with the files of req and returns the commit fetched
+// for a git package. commit, when set, is the commit to check out instead of
+// the ref of req.
+func (p *Project) fetch(req Require, commit, dir string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	version := ""
+	switch req.Kind() {
+	case "git":
+		source := req.Source
+		if isLocalGit(source) {
+			// Un repositorio local: relativo al proyecto, como los directorios
+			source = p.abs(source)
+		}
+		target := commit
+		if target == "" {
+			target = req.Version
+		}
+		if version, err = gitFetch(source, target, tmp); err != nil {
+			return "", err
+		}
+	case "registry":
+		registry, err := p.registry()
+		if err != nil {
+			return "", err
+		}
+		src := filepath.Join(registry, req.Name, req.Version)
+		if _, err := os.Stat(src); err != nil {
+			return "", fmt.Errorf("version %s not found in the registry %s", req.Version, registry)
+		}
+		if err := copyDir(src, tmp); err != nil {
+			return "", err
+		}
+	case "dir":
+		if err := copyDir(p.abs(req.Source), tmp); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	return version, os.Rename(tmp, dir)
+}
+
+// gitFetch clones the repository source into dir, checks out target when it
+// is set, and returns the commit checked out. The .git directory is not kept.
+// Neither source nor target may start with "-", so git never reads them as
+// options.
+func gitFetch(source, target, dir string) (string, error) {
+	for _, arg := range []string{source, target} {
+		if strings.HasPrefix(arg, "-") {
+			return "", fmt.Errorf("invalid git source or version %q", arg)
+		}
+	}
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+	if _, err := git("clone", "--quiet", "--", source, dir); err != nil {
+		return "", err
+	}
+	if target != "" {
+		if _, err := git("-C", dir, "-c", "advice.detachedHead=false", "checkout", "--quiet", target, "--"); err != nil {
+			return "", err
+		}
+	}
+	head, err := git("-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return head, os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+// copyDir copies the files under src into dst, except version control and
+// fetched packages.
+func copyDir(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+	return filepath.WalkDir(src, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir() && (d.Name() == ".git" || d.Name() == ModulesDir):
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case !d.Type().IsRegular():
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+// registry returns the registry directory: the one of the manifest, relative
+// to the project, or $R2_REGISTRY.
+func (p *Project) registry() (string, error) {
+	if p.Manifest.Registry != "" {
+		return p.abs(p.Manifest.Registry), nil
+	}
+	if dir := os.Getenv(RegistryEnv); dir != "" {
+		return dir, nil
+	}
+	return "", fmt.Errorf("no registry configured: add \"registry <dir>\" to %s or set %s", ManifestFile, RegistryEnv)
+}
+
+// latest returns the highest version of the package name in the registry.
+func (p *Project) latest(name string) (string, error) {
+	registry, err := p.registry()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(filepath.Join(registry, name))
+	if err != nil {
+		return "", fmt.Errorf("package %s not found in the registry %s", name, registry)
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("package %s has no versions in the registry %s", name, registry)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+	return versions[len(versions)-1], nil
+}
+
+// compareVersions compares two versions like 1.10.0 and v1.9.2 element by
+// element, numerically when both elements are numbers.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// sourceDir returns the directory the relative sources in the r2.mod of the
+// package req are written against: the directory or local repository it
+// comes from, or its version in the registry. It is "" for a remote git
+// package.
+func (p *Project) sourceDir(req Require) (string, error) {
+	switch req.Kind() {
+	case "registry":
+		registry, err := p.registry()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(registry, req.Name, req.Version), nil
+	case "git":
+		if isLocalGit(req.Source) {
+			dir := p.abs(req.Source)
+			if filepath.Base(dir) == ".git" {
+				// ../repos/strutil/.git: the working tree
+				dir = filepath.Dir(dir)
+			}
+			return dir, nil
+		}
+		return "", nil
+	}
+	return p.abs(req.Source), nil
+}
+
+// rebase rewrites the relative source of dep, a requirement of a package
+// whose sources are relative to base, as relative to the project.
+func (p *Project) rebase(dep Require, base string) (Require, error) {
+	local := dep.Kind() == "dir" || (dep.Kind() == "git" && isLocalGit(dep.Source))
+	if !local || base == "" || filepath.IsAbs(filepath.FromSlash(dep.Source)) {
+		return dep, nil
+	}
+	source, err := p.relative(filepath.Join(base, filepath.FromSlash(dep.Source)))
+	if err != nil {
+		return Require{}, err
+	}
+	dep.Source = source
+	return dep, nil
+}
+
+// isLocalGit reports whether the git source is a repository on disk.
+func isLocalGit(source string) bool {
+	return !strings.Contains(source, "://") && !strings.HasPrefix(source, "git@")
+}
+
+// abs returns source, a path relative to the project, as an absolute path.
+func (p *Project) abs(source string) string {
+	if filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(p.Dir, filepath.FromSlash(source))
+}
+
+// relative returns the directory source, relative to the current directory,
+// as written in the manifest: relative to the project.
+func (p *Project) relative(source string) (string, error) {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(p.Dir, abs)
+	if err != nil {
+		return abs, nil
+	}
+	rel = path.Clean(filepath.ToSlash(rel))
+	if rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
@@ -0,0 +1,227 @@
+package r2mod
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// gitRepo creates a git repository in dir with files committed, and returns
+// a function that commits more files.
+func gitRepo(t *testing.T, dir string, files map[string]string) func(files map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=r2", "-c", "user.email=r2@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(files map[string]string) {
+		writeTree(t, dir, files)
+		git("add", ".")
+		git("commit", "--quiet", "-m", "update")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "--quiet")
+	commit(files)
+	return commit
+}
+
+func newTestProject(t *testing.T, root, manifest string) *Project {
+	t.Helper()
+	writeTree(t, root, map[string]string{"app/r2.mod": manifest})
+	p, err := LoadProject(filepath.Join(root, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestGet_RegistryAndDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"registry/colors/0.2.0/index.r2":  `export let version = "0.2.0"`,
+		"registry/colors/0.10.0/index.r2": `export let version = "0.10.0"`,
+		"shared/r2.mod":                   "main lib.r2\nrequire colors 0.2.0\n",
+		"shared/lib.r2":                   `import "colors" as c`,
+		"shared/r2_modules/junk/index.r2": "",
+	})
+	p := newTestProject(t, root, "module app\nregistry ../registry\nrequire shared ../shared\n")
+
+	if err := p.Get([]string{"colors"}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if req := p.Manifest.Require("colors"); req == nil || req.Version != "0.10.0" {
+		t.Errorf("expected the latest version of colors to be required, got %+v", req)
+	}
+	if got := readFile(t, filepath.Join(p.Dir, ManifestFile)); !strings.Contains(got, "require colors 0.10.0\n") {
+		t.Errorf("expected r2.mod to be written, got:\n%s", got)
+	}
+	// El proyecto pide colors 0.10.0 y gana al 0.2.0 de shared
+	if got := readFile(t, filepath.Join(p.PackageDir("colors"), "index.r2")); !strings.Contains(got, "0.10.0") {
+		t.Errorf("expected colors 0.10.0 to be fetched, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(p.PackageDir("shared"), ModulesDir)); !os.IsNotExist(err) {
+		t.Error("expected the packages of a directory not to be copied")
+	}
+
+	path, ok, err := p.Resolve("shared")
+	if err != nil || !ok || path != filepath.Join(p.PackageDir("shared"), "lib.r2") {
+		t.Errorf("expected the main file of shared, got %q %v %v", path, ok, err)
+	}
+	path, ok, err = p.Resolve("colors/index.r2")
+	if err != nil || !ok || path != filepath.Join(p.PackageDir("colors"), "index.r2") {
+		t.Errorf("expected a file of colors, got %q %v %v", path, ok, err)
+	}
+	if _, ok, _ := p.Resolve("util.r2"); ok {
+		t.Error("expected a name that is not a package not to resolve")
+	}
+
+	lock, err := ReadLock(filepath.Join(p.Dir, LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := lock.Entries["colors"]; e.Source != "registry" || e.Version != "0.10.0" || !strings.HasPrefix(e.Sum, "sha256:") {
+		t.Errorf("unexpected lock entry %+v", e)
+	}
+	if e := lock.Entries["shared"]; e.Source != "../shared" || e.Version != "-" {
+		t.Errorf("unexpected lock entry %+v", e)
+	}
+
+	// Sin shared, colors sigue y shared se borra
+	p.Manifest.Requires = p.Manifest.Requires[1:]
+	if err := p.Get(nil, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p.PackageDir("shared")); !os.IsNotExist(err) {
+		t.Error("expected shared to be removed")
+	}
+}
+
+func TestGet_GitFollowsTheLock(t *testing.T) {
+	root := t.TempDir()
+	commit := gitRepo(t, filepath.Join(root, "strutil"), map[string]string{"index.r2": "let v = 1"})
+	p := newTestProject(t, root, "module app\n")
+
+	if err := p.Get([]string{"../strutil/.git"}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	locked := p.Lock.Entries["strutil"]
+	if !strings.HasPrefix(locked.Version, "HEAD@") {
+		t.Fatalf("expected the commit of HEAD to be locked, got %+v", locked)
+	}
+
+	// Con el paquete borrado, r2 get vuelve a bajar el commit del lock
+	commit(map[string]string{"index.r2": "let v = 2"})
+	if err := os.RemoveAll(filepath.Join(p.Dir, ModulesDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Get(nil, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(p.PackageDir("strutil"), "index.r2")); got != "let v = 1" {
+		t.Errorf("expected the locked commit, got %q", got)
+	}
+	if p.Lock.Entries["strutil"] != locked {
+		t.Errorf("expected the lock to be kept, got %+v", p.Lock.Entries["strutil"])
+	}
+
+	// Un archivo modificado falla al importarlo
+	writeTree(t, p.PackageDir("strutil"), map[string]string{"index.r2": "let v = 3"})
+	fresh, err := LoadProject(p.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fresh.Resolve("strutil"); err == nil || !strings.Contains(err.Error(), "checksum mismatch for package strutil") {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+
+	// Y un lock que no coincide con lo que se baja es un error de r2 get
+	p.Lock.Entries["strutil"] = LockEntry{Name: "strutil", Source: locked.Source, Version: locked.Version, Sum: "sha256:0"}
+	if err := p.Get(nil, io.Discard); err == nil || !strings.Contains(err.Error(), "checksum mismatch for package strutil") {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+}
+
+// Las fuentes relativas del r2.mod de un paquete son relativas a ese
+// paquete, no al proyecto.
+func TestGet_RelativeSourcesOfPackages(t *testing.T) {
+	root := t.TempDir()
+	gitRepo(t, filepath.Join(root, "repos", "c"), map[string]string{
+		"r2.mod":   "require d ../../libs/d\n",
+		"index.r2": "",
+	})
+	writeTree(t, root, map[string]string{
+		"libs/a/r2.mod":   "require b ../b\nrequire c ../../repos/c/.git\n",
+		"libs/a/index.r2": "",
+		"libs/b/index.r2": `export let name = "b"`,
+		"libs/d/index.r2": `export let name = "d"`,
+	})
+	p := newTestProject(t, root, "require a ../libs/a\n")
+	if err := p.Get(nil, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b", "d"} {
+		if got := readFile(t, filepath.Join(p.PackageDir(name), "index.r2")); !strings.Contains(got, `"`+name+`"`) {
+			t.Errorf("expected %s to be fetched, got %q", name, got)
+		}
+	}
+	want := map[string]string{"b": "../libs/b", "c": "../repos/c/.git", "d": "../libs/d"}
+	for name, source := range want {
+		if e := p.Lock.Entries[name]; e.Source != source {
+			t.Errorf("expected %s to be locked with the source %s, got %+v", name, source, e)
+		}
+	}
+}
+
+func TestGet_ConflictingRequirements(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"registry/colors/1.0.0/index.r2": "",
+		"registry/colors/2.0.0/index.r2": "",
+		"a/r2.mod":                       "require colors 1.0.0\n",
+		"b/r2.mod":                       "require colors 2.0.0\n",
+	})
+	p := newTestProject(t, root, "registry ../registry\nrequire a ../a\nrequire b ../b\n")
+	if err := p.Get(nil, io.Discard); err == nil || !strings.Contains(err.Error(), "conflicting requirements for colors") {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
+
+func TestResolve_NotInstalled(t *testing.T) {
+	p := newTestProject(t, t.TempDir(), "require colors 1.0.0\n")
+	if _, ok, err := p.Resolve("colors"); !ok || err == nil || !strings.Contains(err.Error(), "package colors is not installed: run r2 get") {
+		t.Errorf("expected an error for a package that was not fetched, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package r2mod
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Lock is a parsed r2.lock. Each line pins a fetched package:
+//
+//	strutil https://github.com/acme/strutil.git 3f2a9c... sha256:9b1e...
+//	colors registry 0.3.0 sha256:41d0...
+//	shared ../shared - sha256:77ac...
+//
+// The version of a git package is the commit that was fetched. The sum is
+// the checksum of the package's files (see HashDir).
+type Lock struct {
+	Entries map[string]LockEntry
+}
+
+// LockEntry is a line of r2.lock.
+type LockEntry struct {
+	Name    string
+	Source  string // As in the manifest; "registry" for registry packages
+	Version string // Commit, registry version, or "-" for directories
+	Sum     string
+}
+
+// registrySource is the source of registry packages in r2.lock.
+const registrySource = "registry"
+
+// ReadLock reads the lockfile at path. A missing file is an empty lock.
+func ReadLock(path string) (*Lock, error) {
+	lock := &Lock{Entries: map[string]LockEntry{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%s:%d: expected <name> <source> <version> <sum>", path, line)
+		}
+		lock.Entries[fields[0]] = LockEntry{Name: fields[0], Source: fields[1], Version: fields[2], Sum: fields[3]}
+	}
+	return lock, scanner.Err()
+}
+
+// Format returns the lockfile, sorted by package name.
+func (l *Lock) Format() []byte {
+	names := make([]string, 0, len(l.Entries))
+	for name := range l.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by r2 get. Do not edit.\n")
+	for _, name := range names {
+		e := l.Entries[name]
+		fmt.Fprintf(&buf, "%s %s %s %s\n", e.Name, e.Source, e.Version, e.Sum)
+	}
+	return buf.Bytes()
+}
+
+// HashDir returns the checksum of the files under dir: the SHA-256 of the
+// sorted list of their slash-separated paths and the SHA-256 of each one.
+// The .git directory is ignored.
+func HashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	sum := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(sum, "%x  %s\n", h.Sum(nil), file)
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
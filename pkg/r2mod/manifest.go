@@ -0,0 +1,237 @@
+// Package r2mod manages R2 packages: the r2.mod manifest of a project, the
+// r2.lock lockfile, fetching packages into r2_modules with "r2 get" and
+// resolving the imports that name a package (import "strutil").
+package r2mod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// ManifestFile is the manifest of a project or package.
+	ManifestFile = "r2.mod"
+	// LockFile pins the version and checksum of every fetched package.
+	LockFile = "r2.lock"
+	// ModulesDir is the directory, next to the manifest, the packages are
+	// fetched into.
+	ModulesDir = "r2_modules"
+	// DefaultMain is the file imported by the bare name of a package whose
+	// manifest does not say otherwise.
+	DefaultMain = "index.r2"
+)
+
+// Manifest is a parsed r2.mod:
+//
+//	module myapp
+//	main index.r2
+//	registry ../registry
+//	require strutil https://github.com/acme/strutil.git v1.2.0
+//	require shared ../shared
+//	require colors 0.3.0
+//
+// A require names a package and where it comes from: a git repository at an
+// optional tag, branch or commit, a local directory, or a version of the
+// package in the registry, a local directory with one subdirectory per
+// package and version (registry/colors/0.3.0).
+type Manifest struct {
+	Module   string
+	Main     string // Entry file of the package; empty means DefaultMain
+	Registry string // Registry directory, relative to the manifest
+	Requires []Require
+}
+
+// Require is a dependency declared in a manifest.
+type Require struct {
+	Name    string
+	Source  string // Git URL or directory; empty for a registry package
+	Version string // Git ref or registry version; may be empty for git
+}
+
+// String returns the require as written in a manifest, without the keyword.
+func (r Require) String() string {
+	return strings.Join(strings.Fields(r.Name+" "+r.Source+" "+r.Version), " ")
+}
+
+// Kind returns "git", "dir" or "registry".
+func (r Require) Kind() string {
+	switch {
+	case r.Source == "":
+		return "registry"
+	case isGitURL(r.Source):
+		return "git"
+	}
+	return "dir"
+}
+
+// isGitURL reports whether source is a git repository URL rather than a
+// local directory.
+func isGitURL(source string) bool {
+	return strings.Contains(source, "://") || strings.HasPrefix(source, "git@") || strings.HasSuffix(source, ".git")
+}
+
+// isDirPath reports whether source is written as a directory path.
+func isDirPath(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") ||
+		strings.HasPrefix(source, "/") || source == "." || source == ".."
+}
+
+// ReadManifest reads and parses the manifest at path.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(path, data)
+}
+
+// ParseManifest parses the manifest in data. filename is only used in error
+// messages.
+func ParseManifest(filename string, data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", filename, line, fmt.Sprintf(format, args...))
+		}
+		switch fields[0] {
+		case "module", "main", "registry":
+			if len(fields) != 2 {
+				return nil, fail("%s takes exactly one argument", fields[0])
+			}
+			switch fields[0] {
+			case "module":
+				m.Module = fields[1]
+			case "main":
+				m.Main = fields[1]
+			case "registry":
+				m.Registry = fields[1]
+			}
+		case "require":
+			req, err := parseRequire(fields[1:])
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			if seen[req.Name] {
+				return nil, fail("%s is required twice", req.Name)
+			}
+			seen[req.Name] = true
+			m.Requires = append(m.Requires, req)
+		default:
+			return nil, fail("unknown directive %q", fields[0])
+		}
+	}
+	return m, scanner.Err()
+}
+
+// stripComment removes a trailing // comment from line. The // of a URL does
+// not start a comment.
+func stripComment(line string) string {
+	for i := strings.Index(line, "//"); i >= 0; {
+		if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+			return line[:i]
+		}
+		next := strings.Index(line[i+2:], "//")
+		if next < 0 {
+			break
+		}
+		i += 2 + next
+	}
+	return line
+}
+
+func parseRequire(fields []string) (Require, error) {
+	if len(fields) < 2 || len(fields) > 3 {
+		return Require{}, fmt.Errorf("usage: require <name> <source> [version] or require <name> <version>")
+	}
+	req := Require{Name: fields[0]}
+	if !validName(req.Name) {
+		return Require{}, fmt.Errorf("invalid package name %q", req.Name)
+	}
+	for _, field := range fields[1:] {
+		// git would read them as options
+		if strings.HasPrefix(field, "-") {
+			return Require{}, fmt.Errorf("a source or version cannot start with \"-\": %q", field)
+		}
+	}
+	if isGitURL(fields[1]) || isDirPath(fields[1]) {
+		req.Source = fields[1]
+		if len(fields) == 3 {
+			req.Version = fields[2]
+		}
+		if req.Kind() == "dir" && req.Version != "" {
+			return Require{}, fmt.Errorf("a directory package has no version: %s", req)
+		}
+		return req, nil
+	}
+	if len(fields) == 3 {
+		return Require{}, fmt.Errorf("%q is neither a git URL nor a directory", fields[1])
+	}
+	req.Version = fields[1]
+	return req, nil
+}
+
+// validName reports whether name can be the first element of an import.
+func validName(name string) bool {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\ :@`) {
+		return false
+	}
+	return !strings.HasPrefix(name, ".")
+}
+
+// Require returns the require of the package name, or nil.
+func (m *Manifest) Require(name string) *Require {
+	for i := range m.Requires {
+		if m.Requires[i].Name == name {
+			return &m.Requires[i]
+		}
+	}
+	return nil
+}
+
+// SetRequire adds req, or replaces the require with the same name.
+func (m *Manifest) SetRequire(req Require) {
+	if existing := m.Require(req.Name); existing != nil {
+		*existing = req
+		return
+	}
+	m.Requires = append(m.Requires, req)
+}
+
+// MainFile returns the entry file of the package, relative to its directory.
+func (m *Manifest) MainFile() string {
+	if m.Main == "" {
+		return DefaultMain
+	}
+	return path.Clean(m.Main)
+}
+
+// Format returns the manifest in its canonical form.
+func (m *Manifest) Format() []byte {
+	var buf bytes.Buffer
+	if m.Module != "" {
+		fmt.Fprintf(&buf, "module %s\n", m.Module)
+	}
+	if m.Main != "" {
+		fmt.Fprintf(&buf, "main %s\n", m.Main)
+	}
+	if m.Registry != "" {
+		fmt.Fprintf(&buf, "registry %s\n", m.Registry)
+	}
+	if len(m.Requires) > 0 && buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	for _, req := range m.Requires {
+		fmt.Fprintf(&buf, "require %s\n", req)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,111 @@
+package r2mod
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	src := `// Manifest of the app
+module myapp
+main app.r2
+registry ../registry
+
+require strutil https://github.com/acme/strutil.git v1.2.0 // pinned
+require shared ../shared
+require colors 0.3.0
+require head git@github.com:acme/head.git
+`
+	m, err := ParseManifest("r2.mod", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Module != "myapp" || m.MainFile() != "app.r2" || m.Registry != "../registry" {
+		t.Errorf("unexpected header %+v", m)
+	}
+	want := []Require{
+		{Name: "strutil", Source: "https://github.com/acme/strutil.git", Version: "v1.2.0"},
+		{Name: "shared", Source: "../shared"},
+		{Name: "colors", Version: "0.3.0"},
+		{Name: "head", Source: "git@github.com:acme/head.git"},
+	}
+	if len(m.Requires) != len(want) {
+		t.Fatalf("expected %d requires, got %+v", len(want), m.Requires)
+	}
+	for i, req := range want {
+		if m.Requires[i] != req {
+			t.Errorf("require %d: expected %+v, got %+v", i, req, m.Requires[i])
+		}
+	}
+	kinds := []string{"git", "dir", "registry", "git"}
+	for i, kind := range kinds {
+		if got := m.Requires[i].Kind(); got != kind {
+			t.Errorf("require %d: expected kind %s, got %s", i, kind, got)
+		}
+	}
+
+	again, err := ParseManifest("r2.mod", m.Format())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.Format()) != string(m.Format()) {
+		t.Errorf("Format does not round-trip:\n%s", m.Format())
+	}
+}
+
+func TestParseManifest_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"module", "r2.mod:1: module takes exactly one argument"},
+		{"require a 1.0\nrequire a 2.0", "r2.mod:2: a is required twice"},
+		{"require ../x ../x", `invalid package name "../x"`},
+		{"require x ../x v1", "a directory package has no version"},
+		{"require x y z", `"y" is neither a git URL nor a directory`},
+		{"replace x y", `unknown directive "replace"`},
+		{"require evil --upload-pack=touch;x://y", `cannot start with "-"`},
+		{"require x https://host/x.git --output=y", `cannot start with "-"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseManifest("r2.mod", []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected an error containing %q, got %v", tt.src, tt.want, err)
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Require
+	}{
+		{"https://github.com/acme/strutil.git@v1.2.0", Require{Name: "strutil", Source: "https://github.com/acme/strutil.git", Version: "v1.2.0"}},
+		{"git@github.com:acme/strutil.git", Require{Name: "strutil", Source: "git@github.com:acme/strutil.git"}},
+		{"../repos/strutil/.git@main", Require{Name: "strutil", Source: "../repos/strutil/.git", Version: "main"}},
+		{"./libs/shared", Require{Name: "shared", Source: "./libs/shared"}},
+		{"colors@0.3.0", Require{Name: "colors", Version: "0.3.0"}},
+		{"colors", Require{Name: "colors"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpec(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v (%v)", tt.spec, tt.want, got, err)
+		}
+	}
+	if _, err := ParseSpec("./libs/shared@1.0"); err == nil {
+		t.Error("expected an error for a versioned directory")
+	}
+	if _, err := ParseSpec("https://host/x.git@--upload-pack=y"); err == nil {
+		t.Error("expected an error for a version that git would read as an option")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"0.1.0", "0.2.0", "0.10.0", "v1.0.0", "1.0.1", "1.0.1.1"}
+	for i := 1; i < len(ordered); i++ {
+		if compareVersions(ordered[i-1], ordered[i]) >= 0 || compareVersions(ordered[i], ordered[i-1]) <= 0 {
+			t.Errorf("expected %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package r2mod
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Project is a directory with an r2.mod, and the packages fetched for it.
+type Project struct {
+	Dir      string
+	Manifest *Manifest
+	Lock     *Lock
+
+	mu       sync.Mutex
+	verified map[string]error // Result of checking each package against the lock
+}
+
+// LoadProject reads the manifest and the lockfile of the project in dir.
+func LoadProject(dir string) (*Project, error) {
+	manifest, err := ReadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	lock, err := ReadLock(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, err
+	}
+	return &Project{Dir: dir, Manifest: manifest, Lock: lock, verified: map[string]error{}}, nil
+}
+
+// FindProject loads the project of dir: the nearest directory, dir or one of
+// its parents, with an r2.mod. It returns nil and no error when there is
+// none.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+			return LoadProject(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// NewProject returns a project in dir with an empty manifest named after the
+// directory. Nothing is written until Get.
+func NewProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Project{
+		Dir:      dir,
+		Manifest: &Manifest{Module: filepath.Base(dir)},
+		Lock:     &Lock{Entries: map[string]LockEntry{}},
+		verified: map[string]error{},
+	}, nil
+}
+
+// PackageDir returns the directory the package name is fetched into.
+func (p *Project) PackageDir(name string) string {
+	return filepath.Join(p.Dir, ModulesDir, name)
+}
+
+// Resolve returns the file imported by spec when its first element names a
+// package of the project: "strutil" is the package's main file and
+// "strutil/text.r2" a file inside it. ok is false for any other spec. The
+// package must have been fetched and match the checksum in r2.lock; if not,
+// the error says so and path is where the file would be.
+//
+// Resolve implements r2core.ModuleResolver.
+func (p *Project) Resolve(spec string) (path string, ok bool, err error) {
+	name, rest, _ := strings.Cut(spec, "/")
+	if _, locked := p.Lock.Entries[name]; !locked && p.Manifest.Require(name) == nil {
+		return "", false, nil
+	}
+	dir := p.PackageDir(name)
+	if rest == "" {
+		rest = packageMain(dir)
+	}
+	return filepath.Join(dir, filepath.FromSlash(rest)), true, p.verify(name)
+}
+
+// verify checks, once per package, that it was fetched and that its files
+// match r2.lock.
+func (p *Project) verify(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err, done := p.verified[name]; done {
+		return err
+	}
+	err := p.check(name)
+	p.verified[name] = err
+	return err
+}
+
+func (p *Project) check(name string) error {
+	entry, locked := p.Lock.Entries[name]
+	dir := p.PackageDir(name)
+	if _, err := os.Stat(dir); !locked || err != nil {
+		return fmt.Errorf("package %s is not installed: run r2 get", name)
+	}
+	sum, err := HashDir(dir)
+	if err != nil {
+		return err
+	}
+	if sum != entry.Sum {
+		return fmt.Errorf("checksum mismatch for package %s: r2.lock has %s but %s has %s; run r2 get",
+			name, entry.Sum, filepath.Join(ModulesDir, name), sum)
+	}
+	return nil
+}
+
+// packageMain returns the main file of the package in dir, as declared by
+// its own manifest.
+func packageMain(dir string) string {
+	manifest, err := ReadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return DefaultMain
+	}
+	return manifest.MainFile()
+}
@@ -53,6 +53,7 @@ func newR2Environment(ctx *r2BridgeContext) *r2core.Environment {
 	r2libs.RegisterHack(env)
 	r2libs.RegisterEncoding(env)
 	r2libs.RegisterConcurrency(env)
+	r2libs.RegisterPromise(env)
 	r2libs.RegisterSync(env)
 	r2libs.RegisterCollections(env)
 	r2libs.RegisterValidate(env)
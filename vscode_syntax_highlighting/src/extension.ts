@@ -50,6 +50,14 @@ export function activate(context: vscode.ExtensionContext) {
     // Register CodeLens provider for test execution
     const codeLensProvider = vscode.languages.registerCodeLensProvider('r2lang', new R2LangTestCodeLensProvider());
 
+    // Run the debug adapter of the R2Lang executable ("r2 debug -dap")
+    const debugAdapterFactory = vscode.debug.registerDebugAdapterDescriptorFactory('r2', {
+        createDebugAdapterDescriptor() {
+            const executablePath = vscode.workspace.getConfiguration('r2lang').get<string>('executablePath', 'r2lang');
+            return new vscode.DebugAdapterExecutable(executablePath, ['debug', '-dap']);
+        }
+    });
+
     // Add to context subscriptions
     context.subscriptions.push(
         runFileCommand,
@@ -62,7 +70,8 @@ export function activate(context: vscode.ExtensionContext) {
         runInReplCommand,
         documentFormattingProvider,
         hoverProvider,
-        codeLensProvider
+        codeLensProvider,
+        debugAdapterFactory
     );
 
     // Show welcome message on first use